@@ -0,0 +1,177 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// Sr2PrivateTagId is the tag in IFD0 of a Sony ARW that has the offset of
+	// the SR2Private IFD. It has the same ID as the DNGPrivateData tag.
+	Sr2PrivateTagId = 0xc634
+)
+
+var (
+	// ErrNotArw means that the data is not a TIFF made by a Sony camera.
+	ErrNotArw = errors.New("not an arw file")
+)
+
+var (
+	// sonySr2PrivateIfdTag is the root of the tags of the SR2Private IFD.
+	sonySr2PrivateIfdTag = exifcommon.NewIfdTag(nil, Sr2PrivateTagId, "SR2Private")
+
+	// SonySr2PrivateIfdIdentity is the identity of the SR2Private IFD of an
+	// ARW. Its tags are only known to the index that the IFD is parsed with.
+	SonySr2PrivateIfdIdentity = exifcommon.NewIfdIdentity(sonySr2PrivateIfdTag, exifcommon.IfdIdentityPart{Name: "SR2Private", Index: 0})
+)
+
+var (
+	// sonySr2PrivateTags are the SR2Private tags that we recognize. The
+	// SR2SubIFD that the first three describe is encrypted with the key and
+	// isn't parsed.
+	sonySr2PrivateTags = []struct {
+		id       uint16
+		name     string
+		typeName exifcommon.TagTypePrimitive
+	}{
+		{0x7200, "SR2SubIFDOffset", exifcommon.TypeLong},
+		{0x7201, "SR2SubIFDLength", exifcommon.TypeLong},
+		{0x7221, "SR2SubIFDKey", exifcommon.TypeLong},
+		{0x7240, "IDC_IFD", exifcommon.TypeLong},
+		{0x7241, "IDC2_IFD", exifcommon.TypeLong},
+		{0x7250, "MRWInfo", exifcommon.TypeUndefined},
+	}
+
+	sonySr2PrivateTagIndex     *TagIndex
+	sonySr2PrivateTagIndexOnce sync.Once
+)
+
+// getSonySr2PrivateTagIndex returns the tag index for SR2Private IFDs.
+func getSonySr2PrivateTagIndex() *TagIndex {
+	sonySr2PrivateTagIndexOnce.Do(func() {
+		ti := NewTagIndex()
+
+		ifdPath := SonySr2PrivateIfdIdentity.UnindexedString()
+		for _, st := range sonySr2PrivateTags {
+			it := &IndexedTag{
+				Id:             st.id,
+				Name:           st.name,
+				IfdPath:        ifdPath,
+				SupportedTypes: []exifcommon.TagTypePrimitive{st.typeName},
+				Group:          TagGroupCamera,
+			}
+
+			err := ti.Add(it)
+			log.PanicIf(err)
+		}
+
+		sonySr2PrivateTagIndex = ti
+	})
+
+	return sonySr2PrivateTagIndex
+}
+
+// ArwFile is a parsed Sony ARW raw file. An ARW is a TIFF whose IFD0 has the
+// EXIF and GPS IFDs as children, a SubIFDs tag that lists the IFD of the raw
+// data, and an SR2Private tag that points to an IFD of Sony's own tags.
+type ArwFile struct {
+	*TiffRawFile
+}
+
+// NewArwFile parses the IFDs of the given ARW file, including the sub-IFDs of
+// IFD0. `ErrNotArw` is returned if the data isn't a TIFF or IFD0 doesn't have
+// a Sony make.
+func NewArwFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (af *ArwFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	trf, err := NewTiffRawFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, ErrNotArw
+		}
+
+		log.Panic(err)
+	}
+
+	isSony, err := trf.hasMakePrefix("SONY")
+	log.PanicIf(err)
+
+	if isSony == false {
+		return nil, ErrNotArw
+	}
+
+	af = &ArwFile{
+		TiffRawFile: trf,
+	}
+
+	return af, nil
+}
+
+// Sr2PrivateIfd parses the IFD that the SR2Private tag of IFD0 points to. Its
+// offsets are relative to the start of the file, like the rest of the TIFF.
+// `found` is false if there's no SR2Private tag or it isn't a LONG (in which
+// case it's DNG private-data).
+func (af *ArwFile) Sr2PrivateIfd() (index IfdIndex, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := af.index.RootIfd.FindTagWithId(Sr2PrivateTagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return index, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	ite := results[0]
+	if ite.TagType() != exifcommon.TypeLong || ite.UnitCount() != 1 {
+		return index, false, nil
+	}
+
+	value, err := ite.Value()
+	log.PanicIf(err)
+
+	offset := value.([]uint32)[0]
+
+	// The file is the scope of the IFD, so at least its tag-count has to be in
+	// it.
+
+	size, err := exifcommon.CheckedIntToUint32(len(af.data))
+	log.PanicIf(err)
+
+	scope := NewOffsetScope("Arw", af.index.RootIfd.ByteOrder(), size)
+
+	_, err = scope.Resolve(offset, 2)
+	log.PanicIf(err)
+
+	im := exifcommon.NewIfdMapping()
+
+	err = im.Add([]uint16{}, sonySr2PrivateIfdTag.TagId(), sonySr2PrivateIfdTag.Name())
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeekerWithBytes(af.data)
+	ie := NewIfdEnumerate(im, getSonySr2PrivateTagIndex(), ebs, scope.ByteOrder())
+
+	index, err = ie.collect(SonySr2PrivateIfdIdentity, offset)
+	log.PanicIf(err)
+
+	return index, true, nil
+}
+
+// String returns a descriptive string.
+func (af *ArwFile) String() string {
+	return fmt.Sprintf("ArwFile<SIZE=(%d) IFDS=(%d) SUB-IFDS=(%d)>", len(af.data), len(af.index.Ifds), len(af.subIfds))
+}
@@ -0,0 +1,194 @@
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// buildTestSr2PrivateIfd returns an SR2Private IFD with the location and key
+// of the (encrypted) SR2SubIFD.
+func buildTestSr2PrivateIfd(byteOrder binary.ByteOrder) []byte {
+	ifd := make([]byte, 2+3*12+4)
+	byteOrder.PutUint16(ifd[0:2], 3)
+
+	values := []struct {
+		tagId uint16
+		value uint32
+	}{
+		{0x7200, 0x1000},
+		{0x7201, 0x200},
+		{0x7221, 0x12345678},
+	}
+
+	for i, v := range values {
+		raw := ifd[2+i*12:]
+
+		byteOrder.PutUint16(raw[0:2], v.tagId)
+		byteOrder.PutUint16(raw[2:4], uint16(exifcommon.TypeLong))
+		byteOrder.PutUint32(raw[4:8], 1)
+		byteOrder.PutUint32(raw[8:12], v.value)
+	}
+
+	return ifd
+}
+
+// buildTestArw returns a minimal ARW with the raw data in a sub-IFD and an
+// SR2Private IFD.
+func buildTestArw() []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	byteOrder := binary.LittleEndian
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib.AddStandardWithName("Make", "SONY")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("SonyRawFileType", []uint16{0})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("SubIFDs", []uint32{0})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("DNGPrivateData", []uint32{0})
+	log.PanicIf(err)
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, byteOrder)
+
+	err = exifIb.AddStandardWithName("ISOSpeedRatings", []uint16{100})
+	log.PanicIf(err)
+
+	err = ib.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	data, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	// Put the IFDs at the end and then point the tags at them. Both values are
+	// inline, so they're in the raw value-offset.
+
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	rawOffset := uint32(len(data))
+	data = append(data, buildTestNefSubIfd(byteOrder, 0, 6000)...)
+
+	sr2PrivateOffset := uint32(len(data))
+	data = append(data, buildTestSr2PrivateIfd(byteOrder)...)
+
+	_, index, err := Collect(im, ti, data)
+	log.PanicIf(err)
+
+	for tagId, offset := range map[uint16]uint32{SubIfdsTagId: rawOffset, Sr2PrivateTagId: sr2PrivateOffset} {
+		results, err := index.RootIfd.FindTagWithId(tagId)
+		log.PanicIf(err)
+
+		// The value is the last four bytes of the entry.
+		entryOffset := index.RootIfd.Offset() + 2 + uint32(results[0].tagIndex)*12
+		byteOrder.PutUint32(data[entryOffset+8:], offset)
+	}
+
+	return data
+}
+
+func TestNewArwFile(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	af, err := NewArwFile(im, ti, buildTestArw())
+	log.PanicIf(err)
+
+	if len(af.SubIfds()) != 1 {
+		t.Fatalf("Sub-IFD count not correct: (%d)", len(af.SubIfds()))
+	}
+
+	rawIfd, err := af.RawIfd()
+	log.PanicIf(err)
+
+	if rawIfd != af.SubIfds()[0] {
+		t.Fatalf("Raw IFD not correct: [%v]", rawIfd)
+	}
+
+	results, err := af.Index().RootIfd.FindTagWithName("SonyRawFileType")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint16)[0] != 0 {
+		t.Fatalf("Raw file-type not correct: %v", value)
+	}
+
+	exifIfd, found := af.ExifIfd()
+	if found != true {
+		t.Fatalf("EXIF IFD not found.")
+	}
+
+	_, err = exifIfd.FindTagWithName("ISOSpeedRatings")
+	log.PanicIf(err)
+}
+
+func TestArwFile_Sr2PrivateIfd(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	af, err := NewArwFile(im, ti, buildTestArw())
+	log.PanicIf(err)
+
+	index, found, err := af.Sr2PrivateIfd()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("SR2Private IFD not found.")
+	}
+
+	ifd := index.RootIfd
+
+	if ifd.IfdIdentity().Equals(SonySr2PrivateIfdIdentity) != true {
+		t.Fatalf("SR2Private identity not correct: [%s]", ifd.IfdIdentity())
+	} else if len(ifd.Entries()) != 3 {
+		t.Fatalf("SR2Private tag count not correct: (%d)", len(ifd.Entries()))
+	}
+
+	results, err := ifd.FindTagWithName("SR2SubIFDKey")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint32)[0] != 0x12345678 {
+		t.Fatalf("SR2SubIFD key not correct: %v", value)
+	}
+}
+
+func TestNewArwFile_NotArw(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, err = NewArwFile(im, ti, buildTestNef())
+	if err != ErrNotArw {
+		t.Fatalf("Expected not-ARW error: [%v]", err)
+	}
+
+	_, err = NewArwFile(im, ti, []byte("not a tiff"))
+	if err != ErrNotArw {
+		t.Fatalf("Expected not-ARW error for non-TIFF: [%v]", err)
+	}
+}
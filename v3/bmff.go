@@ -0,0 +1,603 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrBmffFormat means that the ISO BMFF (HEIF, AVIF) structure could not
+	// be parsed.
+	ErrBmffFormat = errors.New("bmff format error")
+)
+
+const (
+	// bmffMaxFtypSize is the most that we'll look at to identify a file by
+	// its 'ftyp' box, which is normally a few dozen bytes.
+	bmffMaxFtypSize = 256
+)
+
+var (
+	bmffExifItemType = []byte("Exif")
+
+	// bmffHeifBrands are the 'ftyp' brands of HEIF images (including HEIC and
+	// AVIF), as opposed to other BMFF files like MP4 videos.
+	bmffHeifBrands = []string{
+		"mif1", "msf1", "heic", "heix", "heim", "heis", "hevc", "hevx", "avif", "avis",
+	}
+)
+
+// IsHeif returns true if the data starts with an 'ftyp' box whose major or
+// compatible brands identify a HEIF (HEIC, AVIF) image. The whole 'ftyp' box
+// must be in the data.
+func IsHeif(data []byte) bool {
+	if len(data) < 16 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+
+	size := binary.BigEndian.Uint32(data[:4])
+	if size < 16 || size > bmffMaxFtypSize || int(size) > len(data) {
+		return false
+	}
+
+	// The major brand, the minor version, and the compatible brands.
+	for position := uint32(8); position+4 <= size; position += 4 {
+		if position == 12 {
+			continue
+		}
+
+		brand := string(data[position : position+4])
+
+		for _, heifBrand := range bmffHeifBrands {
+			if brand == heifBrand {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isHeif returns true if the reader is positioned at the 'ftyp' box of a HEIF
+// image. Nothing is consumed.
+func isHeif(br *bufio.Reader) bool {
+	header, err := br.Peek(8)
+	if err != nil {
+		return false
+	}
+
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 16 || size > bmffMaxFtypSize {
+		return false
+	}
+
+	ftyp, err := br.Peek(int(size))
+	if err != nil {
+		return false
+	}
+
+	return IsHeif(ftyp)
+}
+
+// BmffExifOffsetVariant describes how the TIFF-header offset at the start of
+// a BMFF 'Exif' item was written.
+type BmffExifOffsetVariant int
+
+const (
+	// BmffExifOffsetStandard means that the offset counts the bytes between
+	// the end of the offset field and the TIFF header, per ISO/IEC 23008-12.
+	BmffExifOffsetStandard BmffExifOffsetVariant = iota
+
+	// BmffExifOffsetFromItemStart means that the offset was counted from the
+	// start of the item, so it includes the offset field itself.
+	BmffExifOffsetFromItemStart
+
+	// BmffExifOffsetOmitsPreamble means that an "Exif\0\0" preamble precedes
+	// the TIFF header but was not counted in the offset.
+	BmffExifOffsetOmitsPreamble
+
+	// BmffExifOffsetMissing means that there is no offset field and the item
+	// starts with the TIFF header (or its "Exif\0\0" preamble).
+	BmffExifOffsetMissing
+)
+
+// String returns a descriptive string.
+func (variant BmffExifOffsetVariant) String() string {
+	switch variant {
+	case BmffExifOffsetStandard:
+		return "standard"
+	case BmffExifOffsetFromItemStart:
+		return "from-item-start"
+	case BmffExifOffsetOmitsPreamble:
+		return "omits-preamble"
+	case BmffExifOffsetMissing:
+		return "missing"
+	}
+
+	return fmt.Sprintf("BmffExifOffsetVariant<%d>", int(variant))
+}
+
+// BmffExifItem is the content of a BMFF 'Exif' item, split into the EXIF and
+// the framing around it so that the item can be written back the same way.
+type BmffExifItem struct {
+	// Variant is how the offset was written.
+	Variant BmffExifOffsetVariant
+
+	// Offset is the value of the offset field as stored. It is zero if the
+	// variant is `BmffExifOffsetMissing`.
+	Offset uint32
+
+	// Prefix is the data between the offset field (or the start of the item)
+	// and the TIFF header, usually empty or an "Exif\0\0" preamble.
+	Prefix []byte
+
+	// RawExif is the EXIF data, starting at the TIFF header.
+	RawExif []byte
+}
+
+// String returns a descriptive string.
+func (bei BmffExifItem) String() string {
+	return fmt.Sprintf("BmffExifItem<VARIANT=[%s] OFFSET=(%d) PREFIX-SIZE=(%d) EXIF-SIZE=(%d)>", bei.Variant, bei.Offset, len(bei.Prefix), len(bei.RawExif))
+}
+
+// Encode returns a new item with the given EXIF data in place of the current
+// one, framed exactly like the original (including a wrong offset, if that
+// is how it was found).
+func (bei BmffExifItem) Encode(rawExif []byte) []byte {
+	item := make([]byte, 0, 4+len(bei.Prefix)+len(rawExif))
+
+	if bei.Variant != BmffExifOffsetMissing {
+		item = append(item, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(item, bei.Offset)
+	}
+
+	item = append(item, bei.Prefix...)
+	item = append(item, rawExif...)
+
+	return item
+}
+
+// ParseBmffExifItem splits the content of a BMFF 'Exif' item into the EXIF
+// data and its framing. The standard layout is tried first and then the
+// mistakes that writers are known to make with the offset. `ErrNoExif` is
+// returned if no layout yields a TIFF header.
+func ParseBmffExifItem(item []byte) (bei BmffExifItem, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	isTiffAt := func(position int) bool {
+		if position < 0 || position+ExifSignatureLength > len(item) {
+			return false
+		}
+
+		_, err := ParseExifHeader(item[position:])
+		return err == nil
+	}
+
+	found := func(variant BmffExifOffsetVariant, offset uint32, prefixStart, position int) BmffExifItem {
+		bei := BmffExifItem{
+			Variant: variant,
+			Offset:  offset,
+			Prefix:  item[prefixStart:position],
+			RawExif: item[position:],
+		}
+
+		exifLogger.Debugf(nil, "Found BMFF EXIF: %s", bei)
+
+		return bei
+	}
+
+	if len(item) >= 4 {
+		offset := binary.BigEndian.Uint32(item[:4])
+
+		// Guard against overflowing the position on 32-bit platforms.
+		if uint64(offset) <= uint64(len(item)) {
+			position := 4 + int(offset)
+
+			if isTiffAt(position) == true {
+				return found(BmffExifOffsetStandard, offset, 4, position), nil
+			}
+
+			if offset >= 4 && isTiffAt(int(offset)) == true {
+				return found(BmffExifOffsetFromItemStart, offset, 4, int(offset)), nil
+			}
+
+			if bytes.HasPrefix(item[position:], jpegExifPreamble) == true && isTiffAt(position+len(jpegExifPreamble)) == true {
+				return found(BmffExifOffsetOmitsPreamble, offset, 4, position+len(jpegExifPreamble)), nil
+			}
+		}
+	}
+
+	if isTiffAt(0) == true {
+		return found(BmffExifOffsetMissing, 0, 0, 0), nil
+	}
+
+	if bytes.HasPrefix(item, jpegExifPreamble) == true && isTiffAt(len(jpegExifPreamble)) == true {
+		return found(BmffExifOffsetMissing, 0, 0, len(jpegExifPreamble)), nil
+	}
+
+	return bei, ErrNoExif
+}
+
+// ExtractExifFromBmff finds the 'Exif' item of an ISO BMFF file (HEIF/HEIC,
+// AVIF) and parses it with `ParseBmffExifItem()`. Only the primary 'meta'
+// box at the top level is read. `ErrNoExif` is returned if there is no
+// 'Exif' item.
+func ExtractExifFromBmff(data []byte) (bei BmffExifItem, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bei, _, err = extractExifFromBmff(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return bei, err
+		}
+
+		log.Panic(err)
+	}
+
+	return bei, nil
+}
+
+// extractExifFromBmff returns the 'Exif' item and the offset of its EXIF data
+// from the start of the file. The offset is (-1) if the item is not stored in
+// one piece in the file.
+func extractExifFromBmff(data []byte) (bei BmffExifItem, offset int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	meta, found := findBmffBox(data, "meta")
+	if found == false {
+		return bei, 0, ErrNoExif
+	}
+
+	// 'meta' is a full box.
+	if len(meta) < 4 {
+		log.Panic(ErrBmffFormat)
+	}
+
+	meta = meta[4:]
+
+	iinf, found := findBmffBox(meta, "iinf")
+	if found == false {
+		return bei, 0, ErrNoExif
+	}
+
+	itemId, found, err := findBmffExifItemId(iinf)
+	log.PanicIf(err)
+
+	if found == false {
+		return bei, 0, ErrNoExif
+	}
+
+	iloc, found := findBmffBox(meta, "iloc")
+	if found == false {
+		log.Panic(ErrBmffFormat)
+	}
+
+	idat, _ := findBmffBox(meta, "idat")
+
+	item, itemOffset, err := readBmffItem(data, iloc, idat, itemId)
+	log.PanicIf(err)
+
+	bei, err = ParseBmffExifItem(item)
+	if err != nil {
+		if err == ErrNoExif {
+			return bei, 0, err
+		}
+
+		log.Panic(err)
+	}
+
+	offset = -1
+	if itemOffset >= 0 {
+		offset = itemOffset + len(item) - len(bei.RawExif)
+	}
+
+	return bei, offset, nil
+}
+
+// CollectFromBmff finds the 'Exif' item of an ISO BMFF file (HEIF/HEIC,
+// AVIF) with `ExtractExifFromBmff()` and parses its IFDs, as `Collect()`
+// does for raw EXIF data. `ErrNoExif` is returned if there is no 'Exif'
+// item.
+func CollectFromBmff(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (eh ExifHeader, index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bei, err := ExtractExifFromBmff(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return eh, index, err
+		}
+
+		log.Panic(err)
+	}
+
+	eh, index, err = Collect(ifdMapping, tagIndex, bei.RawExif)
+	log.PanicIf(err)
+
+	return eh, index, nil
+}
+
+// findBmffBox returns the payload of the first box of the given type in a
+// sequence of boxes.
+func findBmffBox(data []byte, boxType string) (payload []byte, found bool) {
+	for len(data) >= 8 {
+		var currentType string
+		currentType, payload, data = nextBmffBox(data)
+
+		if currentType == boxType {
+			return payload, true
+		}
+	}
+
+	return nil, false
+}
+
+// nextBmffBox splits the first box from a sequence of boxes.
+func nextBmffBox(data []byte) (boxType string, payload, rest []byte) {
+	if len(data) < 8 {
+		log.Panic(ErrBmffFormat)
+	}
+
+	size := uint64(binary.BigEndian.Uint32(data[:4]))
+	headerSize := uint64(8)
+
+	if size == 1 {
+		if len(data) < 16 {
+			log.Panic(ErrBmffFormat)
+		}
+
+		size = binary.BigEndian.Uint64(data[8:16])
+		headerSize = 16
+	} else if size == 0 {
+		size = uint64(len(data))
+	}
+
+	if size < headerSize || size > uint64(len(data)) {
+		log.Panic(ErrBmffFormat)
+	}
+
+	return string(data[4:8]), data[headerSize:size], data[size:]
+}
+
+// findBmffExifItemId returns the ID of the first item of type 'Exif' in the
+// payload of an 'iinf' box.
+func findBmffExifItemId(iinf []byte) (itemId uint32, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	br := newBmffReader(iinf)
+
+	version := br.uint8()
+	br.skip(3)
+
+	if version == 0 {
+		br.uint16()
+	} else {
+		br.uint32()
+	}
+
+	entries := br.remaining()
+	for len(entries) >= 8 {
+		var boxType string
+		var infe []byte
+
+		boxType, infe, entries = nextBmffBox(entries)
+		if boxType != "infe" {
+			continue
+		}
+
+		ir := newBmffReader(infe)
+
+		infeVersion := ir.uint8()
+		ir.skip(3)
+
+		// Versions before 2 have no item-type.
+		if infeVersion < 2 {
+			continue
+		}
+
+		var id uint32
+		if infeVersion == 2 {
+			id = uint32(ir.uint16())
+		} else {
+			id = ir.uint32()
+		}
+
+		// Protection index.
+		ir.uint16()
+
+		if bytes.Equal(ir.bytes(4), bmffExifItemType) == true {
+			return id, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// readBmffItem assembles the extents of an item from its 'iloc' entry.
+// Extents may be stored in the file or in the 'idat' box. The offset of the
+// item in the file is returned if it is a single extent stored in the file,
+// or (-1) otherwise.
+func readBmffItem(data, iloc, idat []byte, itemId uint32) (item []byte, offset int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	br := newBmffReader(iloc)
+
+	version := br.uint8()
+	br.skip(3)
+
+	if version > 2 {
+		log.Panicf("iloc version not supported: (%d)", version)
+	}
+
+	sizes := br.uint16()
+	offsetSize := int(sizes >> 12)
+	lengthSize := int(sizes >> 8 & 0xf)
+	baseOffsetSize := int(sizes >> 4 & 0xf)
+
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(sizes & 0xf)
+	}
+
+	var itemCount uint32
+	if version < 2 {
+		itemCount = uint32(br.uint16())
+	} else {
+		itemCount = br.uint32()
+	}
+
+	for i := uint32(0); i < itemCount; i++ {
+		var id uint32
+		if version < 2 {
+			id = uint32(br.uint16())
+		} else {
+			id = br.uint32()
+		}
+
+		constructionMethod := 0
+		if version == 1 || version == 2 {
+			constructionMethod = int(br.uint16() & 0xf)
+		}
+
+		// Data-reference index.
+		br.uint16()
+
+		baseOffset := br.sized(baseOffsetSize)
+		extentCount := int(br.uint16())
+
+		if id != itemId {
+			br.skip(extentCount * (indexSize + offsetSize + lengthSize))
+			continue
+		}
+
+		var source []byte
+		switch constructionMethod {
+		case 0:
+			source = data
+		case 1:
+			source = idat
+		default:
+			log.Panicf("iloc construction-method not supported: (%d)", constructionMethod)
+		}
+
+		offset = -1
+
+		item = make([]byte, 0)
+		for j := 0; j < extentCount; j++ {
+			br.sized(indexSize)
+
+			extentOffset := baseOffset + br.sized(offsetSize)
+			extentLength := br.sized(lengthSize)
+
+			// A zero length means the rest of the source.
+			if extentLength == 0 && extentOffset <= uint64(len(source)) {
+				extentLength = uint64(len(source)) - extentOffset
+			}
+
+			if extentOffset > uint64(len(source)) || extentLength > uint64(len(source))-extentOffset {
+				log.Panic(ErrBmffFormat)
+			}
+
+			item = append(item, source[extentOffset:extentOffset+extentLength]...)
+
+			if constructionMethod == 0 && extentCount == 1 {
+				offset = int(extentOffset)
+			}
+		}
+
+		return item, offset, nil
+	}
+
+	log.Panic(ErrBmffFormat)
+	return nil, 0, nil
+}
+
+// bmffReader reads big-endian fields from a box payload and panics with
+// `ErrBmffFormat` on truncation.
+type bmffReader struct {
+	data     []byte
+	position int
+}
+
+func newBmffReader(data []byte) *bmffReader {
+	return &bmffReader{
+		data: data,
+	}
+}
+
+func (br *bmffReader) bytes(n int) []byte {
+	if n < 0 || br.position+n > len(br.data) {
+		log.Panic(ErrBmffFormat)
+	}
+
+	raw := br.data[br.position : br.position+n]
+	br.position += n
+
+	return raw
+}
+
+func (br *bmffReader) skip(n int) {
+	br.bytes(n)
+}
+
+func (br *bmffReader) remaining() []byte {
+	return br.data[br.position:]
+}
+
+func (br *bmffReader) uint8() uint8 {
+	return br.bytes(1)[0]
+}
+
+func (br *bmffReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(br.bytes(2))
+}
+
+func (br *bmffReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(br.bytes(4))
+}
+
+// sized reads an unsigned field of 0, 4, or 8 bytes, as used by 'iloc'.
+func (br *bmffReader) sized(size int) uint64 {
+	switch size {
+	case 0:
+		return 0
+	case 4:
+		return uint64(br.uint32())
+	case 8:
+		return binary.BigEndian.Uint64(br.bytes(8))
+	}
+
+	log.Panicf("iloc field size not supported: (%d)", size)
+	return 0
+}
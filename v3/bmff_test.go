@@ -0,0 +1,185 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func buildTestBmffBox(boxType string, payload []byte) []byte {
+	box := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(box[:4], uint32(8+len(payload)))
+	copy(box[4:8], boxType)
+
+	return append(box, payload...)
+}
+
+// buildTestBmff returns a minimal HEIF file with an 'Exif' item (ID 2) that
+// is stored either in 'mdat' or, if `inIdat` is true, in 'idat'.
+func buildTestBmff(item []byte, inIdat bool) []byte {
+	infe := func(id uint16, itemType string) []byte {
+		payload := []byte{2, 0, 0, 0}
+		payload = append(payload, byte(id>>8), byte(id))
+		payload = append(payload, 0, 0)
+		payload = append(payload, itemType...)
+		payload = append(payload, 0)
+
+		return buildTestBmffBox("infe", payload)
+	}
+
+	iinfPayload := []byte{0, 0, 0, 0, 0, 2}
+	iinfPayload = append(iinfPayload, infe(1, "hvc1")...)
+	iinfPayload = append(iinfPayload, infe(2, "Exif")...)
+
+	constructionMethod := byte(0)
+	if inIdat == true {
+		constructionMethod = 1
+	}
+
+	// Version 1, offset-size 4, length-size 4, two items with one extent each.
+	// The offset of the EXIF extent is patched once the layout is known.
+	ilocPayload := []byte{1, 0, 0, 0, 0x44, 0x00, 0, 2}
+	ilocPayload = append(ilocPayload, 0, 1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	ilocPayload = append(ilocPayload, 0, 2, 0, constructionMethod, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(ilocPayload[len(ilocPayload)-4:], uint32(len(item)))
+
+	metaPayload := []byte{0, 0, 0, 0}
+	metaPayload = append(metaPayload, buildTestBmffBox("hdlr", make([]byte, 25))...)
+	metaPayload = append(metaPayload, buildTestBmffBox("iinf", iinfPayload)...)
+
+	ilocAt := len(metaPayload) + 8 + 8
+	metaPayload = append(metaPayload, buildTestBmffBox("iloc", ilocPayload)...)
+
+	if inIdat == true {
+		metaPayload = append(metaPayload, buildTestBmffBox("idat", item)...)
+	}
+
+	data := buildTestBmffBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+	ilocAt += len(data)
+	data = append(data, buildTestBmffBox("meta", metaPayload)...)
+
+	if inIdat == false {
+		binary.BigEndian.PutUint32(data[ilocAt+len(ilocPayload)-8:], uint32(len(data)+8))
+		data = append(data, buildTestBmffBox("mdat", item)...)
+	}
+
+	return data
+}
+
+func TestParseBmffExifItem(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	cases := []struct {
+		item    []byte
+		variant BmffExifOffsetVariant
+		prefix  []byte
+	}{
+		{append([]byte{0, 0, 0, 0}, exifData...), BmffExifOffsetStandard, []byte{}},
+		{append([]byte("\x00\x00\x00\x06Exif\x00\x00"), exifData...), BmffExifOffsetStandard, jpegExifPreamble},
+		{append([]byte("\x00\x00\x00\x0aExif\x00\x00"), exifData...), BmffExifOffsetFromItemStart, jpegExifPreamble},
+		{append([]byte("\x00\x00\x00\x00Exif\x00\x00"), exifData...), BmffExifOffsetOmitsPreamble, jpegExifPreamble},
+		{exifData, BmffExifOffsetMissing, []byte{}},
+		{append([]byte("Exif\x00\x00"), exifData...), BmffExifOffsetMissing, jpegExifPreamble},
+	}
+
+	for i, c := range cases {
+		bei, err := ParseBmffExifItem(c.item)
+		log.PanicIf(err)
+
+		if bei.Variant != c.variant {
+			t.Fatalf("Case (%d) variant not correct: [%s]", i, bei.Variant)
+		} else if bytes.Equal(bei.Prefix, c.prefix) != true {
+			t.Fatalf("Case (%d) prefix not correct: %v", i, bei.Prefix)
+		} else if bytes.Equal(bei.RawExif, exifData) != true {
+			t.Fatalf("Case (%d) EXIF not correct.", i)
+		} else if bytes.Equal(bei.Encode(exifData), c.item) != true {
+			t.Fatalf("Case (%d) not reproduced.", i)
+		}
+	}
+
+	_, err := ParseBmffExifItem([]byte{0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8})
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+}
+
+func TestExtractExifFromBmff(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+	item := append([]byte("\x00\x00\x00\x06Exif\x00\x00"), exifData...)
+
+	for _, inIdat := range []bool{false, true} {
+		bei, err := ExtractExifFromBmff(buildTestBmff(item, inIdat))
+		log.PanicIf(err)
+
+		if bei.Variant != BmffExifOffsetStandard {
+			t.Fatalf("Variant not correct (idat=%v): [%s]", inIdat, bei.Variant)
+		} else if bytes.Equal(bei.RawExif, exifData) != true {
+			t.Fatalf("EXIF not correct (idat=%v).", inIdat)
+		}
+	}
+}
+
+func TestExtractExifFromBmff_NoExif(t *testing.T) {
+	data := buildTestBmffBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+
+	_, err := ExtractExifFromBmff(data)
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+}
+
+func TestIsHeif(t *testing.T) {
+	if IsHeif(buildTestBmff(getExifSimpleTestIbBytes(), false)) != true {
+		t.Fatalf("HEIC not detected.")
+	}
+
+	mp4 := buildTestBmffBox("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	if IsHeif(mp4) != false {
+		t.Fatalf("MP4 detected as HEIF.")
+	}
+}
+
+func TestSearchAndExtractExif_Bmff(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+	item := append([]byte("\x00\x00\x00\x06Exif\x00\x00"), exifData...)
+
+	for _, inIdat := range []bool{false, true} {
+		data := buildTestBmff(item, inIdat)
+
+		rawExif, discarded, err := searchAndExtractExifWithReaderWithDiscarded(bytes.NewReader(data))
+		log.PanicIf(err)
+
+		if bytes.Equal(rawExif[:len(exifData)], exifData) != true {
+			t.Fatalf("EXIF not correct (idat=%v).", inIdat)
+		} else if bytes.Equal(data[discarded:discarded+len(exifData)], exifData) != true {
+			t.Fatalf("Offset not correct (idat=%v): (%d)", inIdat, discarded)
+		}
+	}
+}
+
+func TestCollectFromBmff(t *testing.T) {
+	item := append([]byte("\x00\x00\x00\x06Exif\x00\x00"), getExifSimpleTestIbBytes()...)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := CollectFromBmff(im, ti, buildTestBmff(item, false))
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(0x000b)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "asciivalue" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
@@ -0,0 +1,108 @@
+package exif
+
+import (
+	"sync"
+)
+
+const (
+	// bufferPoolChunkSize is the size of the blocks that the small, raw reads
+	// of a scan are carved from.
+	bufferPoolChunkSize = 4096
+)
+
+// BufferPool recycles the entry slices and raw-value buffers that are
+// allocated while scanning so that scanning many images doesn't produce as
+// much garbage. Set it on `ScanOptions` to use it with `Visit()` or
+// `IfdEnumerate.Scan()`. One pool can, and should, be shared by any number of
+// concurrent scans.
+//
+// The tag entries passed to the visitor refer to pooled memory. They, and any
+// byte slices read from them, must not be used after the scan returns.
+// `Visit()` also reads the EXIF data in place instead of copying it, so it
+// must not be modified while scanning.
+type BufferPool struct {
+	entries sync.Pool
+	chunks  sync.Pool
+}
+
+// NewBufferPool returns a new BufferPool.
+func NewBufferPool() *BufferPool {
+	bp := new(BufferPool)
+
+	bp.entries.New = func() interface{} {
+		entries := make([]*IfdTagEntry, 0)
+		return &entries
+	}
+
+	bp.chunks.New = func() interface{} {
+		chunk := make([]byte, bufferPoolChunkSize)
+		return &chunk
+	}
+
+	return bp
+}
+
+// getEntries returns an empty entry slice.
+func (bp *BufferPool) getEntries() *[]*IfdTagEntry {
+	return bp.entries.Get().(*[]*IfdTagEntry)
+}
+
+// putEntries clears the slice and returns it to the pool.
+func (bp *BufferPool) putEntries(entries *[]*IfdTagEntry) {
+	for i := range *entries {
+		(*entries)[i] = nil
+	}
+
+	*entries = (*entries)[:0]
+
+	bp.entries.Put(entries)
+}
+
+// newArena returns an arena that allocates from this pool.
+func (bp *BufferPool) newArena() *bufferArena {
+	return &bufferArena{
+		pool: bp,
+	}
+}
+
+// bufferArena hands out small buffers carved from pooled chunks. Nothing is
+// freed individually; every chunk is returned to the pool by `release()`.
+type bufferArena struct {
+	pool    *BufferPool
+	chunks  []*[]byte
+	current []byte
+}
+
+// get returns a zeroed buffer of the given size.
+func (ba *bufferArena) get(size int) []byte {
+	if size > bufferPoolChunkSize {
+		return make([]byte, size)
+	}
+
+	if len(ba.current) < size {
+		chunk := ba.pool.chunks.Get().(*[]byte)
+		ba.chunks = append(ba.chunks, chunk)
+
+		ba.current = *chunk
+	}
+
+	buffer := ba.current[:size:size]
+	ba.current = ba.current[size:]
+
+	for i := range buffer {
+		buffer[i] = 0
+	}
+
+	return buffer
+}
+
+// release returns all chunks to the pool. No buffer from this arena may be
+// used afterward.
+func (ba *bufferArena) release() {
+	for _, chunk := range ba.chunks {
+		ba.pool.chunks.Put(chunk)
+	}
+
+	ba.chunks = nil
+	ba.current = nil
+}
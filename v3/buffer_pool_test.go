@@ -0,0 +1,129 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// visitTestImage scans the test image and returns a description of every tag
+// visited.
+func visitTestImage(rawExif []byte, so *ScanOptions) []string {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	visited := make([]string, 0)
+
+	visitor := func(ite *IfdTagEntry) error {
+		valueString, err := ite.FormatFirst()
+		if err != nil {
+			valueString = err.Error()
+		}
+
+		visited = append(visited, fmt.Sprintf("%s 0x%04x %s", ite.IfdPath(), ite.TagId(), valueString))
+		return nil
+	}
+
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, rawExif, visitor, so)
+	log.PanicIf(err)
+
+	return visited
+}
+
+func TestBufferPool_Scan(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	expected := visitTestImage(rawExif, nil)
+
+	so := &ScanOptions{
+		BufferPool: NewBufferPool(),
+	}
+
+	// Scan more than once so that recycled buffers are used.
+
+	for i := 0; i < 3; i++ {
+		actual := visitTestImage(rawExif, so)
+
+		if reflect.DeepEqual(actual, expected) == false {
+			t.Fatalf("Scan (%d) with pool not correct: (%d) != (%d)", i, len(actual), len(expected))
+		}
+	}
+}
+
+func TestBufferArena_Get(t *testing.T) {
+	ba := NewBufferPool().newArena()
+
+	first := ba.get(4)
+	second := ba.get(4)
+
+	first[0] = 0xff
+
+	if len(first) != 4 || cap(first) != 4 {
+		t.Fatalf("Buffer size not correct: (%d) (%d)", len(first), cap(first))
+	} else if second[0] != 0 {
+		t.Fatalf("Buffers overlap.")
+	}
+
+	large := ba.get(bufferPoolChunkSize + 1)
+	if len(large) != bufferPoolChunkSize+1 {
+		t.Fatalf("Large buffer size not correct: (%d)", len(large))
+	}
+
+	for i := 0; i < bufferPoolChunkSize; i++ {
+		ba.get(1)
+	}
+
+	if len(ba.chunks) != 2 {
+		t.Fatalf("Expected two chunks: (%d)", len(ba.chunks))
+	}
+
+	ba.release()
+
+	if ba.chunks != nil {
+		t.Fatalf("Chunks not released.")
+	}
+}
+
+func benchmarkVisit(b *testing.B, so *ScanOptions) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	visitor := func(ite *IfdTagEntry) error {
+		return nil
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _, err := Visit(exifcommon.IfdStandardIfdIdentity, im, ti, rawExif, visitor, so)
+		log.PanicIf(err)
+	}
+}
+
+func BenchmarkVisit(b *testing.B) {
+	benchmarkVisit(b, nil)
+}
+
+func BenchmarkVisit_BufferPool(b *testing.B) {
+	so := &ScanOptions{
+		BufferPool: NewBufferPool(),
+	}
+
+	benchmarkVisit(b, so)
+}
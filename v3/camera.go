@@ -0,0 +1,272 @@
+package exif
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// CameraIdentity is a normalized camera make, model, and lens.
+type CameraIdentity struct {
+	// Make is the canonical manufacturer name (e.g. "Nikon" for "NIKON
+	// CORPORATION").
+	Make string
+
+	// Model is the model with the manufacturer prefix and any redundant
+	// whitespace removed (e.g. "EOS 5D Mark III" for "Canon EOS 5D Mark III").
+	Model string
+
+	// LensModel is the normalized lens model. It is empty if not known.
+	LensModel string
+}
+
+// MakeId returns a lowercase identifier for the make suitable for use as a
+// key (e.g. "nikon").
+func (ci CameraIdentity) MakeId() string {
+	return slugify(ci.Make)
+}
+
+// ModelId returns a lowercase identifier for the make and model suitable for
+// use as a key (e.g. "canon/eos-5d-mark-iii").
+func (ci CameraIdentity) ModelId() string {
+	return ci.MakeId() + "/" + slugify(ci.Model)
+}
+
+// LensId returns a lowercase identifier for the lens suitable for use as a
+// key, or an empty string if there is no lens model.
+func (ci CameraIdentity) LensId() string {
+	if ci.LensModel == "" {
+		return ""
+	}
+
+	return slugify(ci.LensModel)
+}
+
+// String returns a descriptive string.
+func (ci CameraIdentity) String() string {
+	return fmt.Sprintf("CameraIdentity<MAKE=[%s] MODEL=[%s] LENS=[%s]>", ci.Make, ci.Model, ci.LensModel)
+}
+
+// CameraNormalizer maps the raw Make, Model, and LensModel strings, as
+// written by the camera, to a normalized identity.
+type CameraNormalizer interface {
+	// NormalizeCamera returns the normalized identity. `found` is false if
+	// the make was not recognized, in which case `ci` is still populated
+	// with a best-effort cleanup of the raw values.
+	NormalizeCamera(rawMake, rawModel, rawLensModel string) (ci CameraIdentity, found bool)
+}
+
+// TableCameraNormalizer is a `CameraNormalizer` backed by lookup tables. The
+// tables may be extended by the caller before the normalizer is used.
+type TableCameraNormalizer struct {
+	// Makes maps the lowercased raw make to the canonical make.
+	Makes map[string]string
+
+	// ModelPrefixes are lowercased prefixes that are stripped from the model,
+	// keyed by canonical make. Manufacturers frequently repeat their own name
+	// at the front of the model.
+	ModelPrefixes map[string][]string
+
+	// Lenses maps the lowercased and whitespace-collapsed raw lens model to
+	// the canonical lens model.
+	Lenses map[string]string
+}
+
+// NewTableCameraNormalizer returns a normalizer populated with the built-in
+// tables.
+func NewTableCameraNormalizer() *TableCameraNormalizer {
+	tcn := &TableCameraNormalizer{
+		Makes:         make(map[string]string),
+		ModelPrefixes: make(map[string][]string),
+		Lenses:        make(map[string]string),
+	}
+
+	for rawMake, canonicalMake := range builtinCameraMakes {
+		tcn.Makes[rawMake] = canonicalMake
+	}
+
+	for canonicalMake, prefixes := range builtinCameraModelPrefixes {
+		tcn.ModelPrefixes[canonicalMake] = append([]string(nil), prefixes...)
+	}
+
+	for rawLensModel, canonicalLensModel := range builtinLensModels {
+		tcn.Lenses[rawLensModel] = canonicalLensModel
+	}
+
+	return tcn
+}
+
+// NormalizeCamera implements `CameraNormalizer`.
+func (tcn *TableCameraNormalizer) NormalizeCamera(rawMake, rawModel, rawLensModel string) (ci CameraIdentity, found bool) {
+	cleanMake := collapseWhitespace(rawMake)
+	canonicalMake, found := tcn.Makes[strings.ToLower(cleanMake)]
+	if found == false {
+		canonicalMake = cleanMake
+	}
+
+	model := collapseWhitespace(rawModel)
+	lowerModel := strings.ToLower(model)
+
+	prefixes := []string{strings.ToLower(canonicalMake) + " ", strings.ToLower(cleanMake) + " "}
+	prefixes = append(prefixes, tcn.ModelPrefixes[canonicalMake]...)
+
+	for _, prefix := range prefixes {
+		if prefix != " " && strings.HasPrefix(lowerModel, prefix) == true && len(model) > len(prefix) {
+			model = strings.TrimSpace(model[len(prefix):])
+			break
+		}
+	}
+
+	lensModel := collapseWhitespace(rawLensModel)
+	if canonicalLensModel, found := tcn.Lenses[strings.ToLower(lensModel)]; found == true {
+		lensModel = canonicalLensModel
+	}
+
+	ci = CameraIdentity{
+		Make:      canonicalMake,
+		Model:     model,
+		LensModel: lensModel,
+	}
+
+	return ci, found
+}
+
+var (
+	cameraNormalizer      CameraNormalizer = NewTableCameraNormalizer()
+	cameraNormalizerMutex sync.RWMutex
+)
+
+// SetCameraNormalizer replaces the normalizer used by `NormalizeCamera()` and
+// `IfdIndex.CameraIdentity()`. Catalog applications can install one backed by
+// a fuller database. Passing nil restores the built-in one.
+func SetCameraNormalizer(cn CameraNormalizer) {
+	if cn == nil {
+		cn = NewTableCameraNormalizer()
+	}
+
+	cameraNormalizerMutex.Lock()
+	defer cameraNormalizerMutex.Unlock()
+
+	cameraNormalizer = cn
+}
+
+// NormalizeCamera normalizes the given raw values with the current
+// normalizer.
+func NormalizeCamera(rawMake, rawModel, rawLensModel string) (ci CameraIdentity, found bool) {
+	cameraNormalizerMutex.RLock()
+	cn := cameraNormalizer
+	cameraNormalizerMutex.RUnlock()
+
+	return cn.NormalizeCamera(rawMake, rawModel, rawLensModel)
+}
+
+// CameraIdentity reads the Make, Model, and LensModel tags and normalizes
+// them with the current normalizer. `ErrTagNotFound` is returned if neither
+// Make nor Model are present.
+func (index IfdIndex) CameraIdentity() (ci CameraIdentity, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := index.GetTags("Make", "Model", "LensModel")
+	log.PanicIf(err)
+
+	if results["Make"].Found() == false && results["Model"].Found() == false {
+		log.Panic(ErrTagNotFound)
+	}
+
+	asString := func(tv TypedValue) string {
+		return tv.Value.Ascii()
+	}
+
+	ci, found = NormalizeCamera(
+		asString(results["Make"]),
+		asString(results["Model"]),
+		asString(results["LensModel"]))
+
+	return ci, found, nil
+}
+
+// collapseWhitespace trims the string, drops trailing NULs, and reduces runs
+// of whitespace to a single space.
+func collapseWhitespace(s string) string {
+	s = strings.TrimRight(s, "\x00")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// slugify lowercases the string and replaces every run of characters other
+// than letters and digits with a single dash.
+func slugify(s string) string {
+	b := strings.Builder{}
+	pendingDash := false
+
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) == true || unicode.IsDigit(r) == true {
+			if pendingDash == true && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+
+			b.WriteRune(r)
+			pendingDash = false
+		} else {
+			pendingDash = true
+		}
+	}
+
+	return b.String()
+}
+
+var (
+	builtinCameraMakes = map[string]string{
+		"apple":                       "Apple",
+		"canon":                       "Canon",
+		"dji":                         "DJI",
+		"eastman kodak company":       "Kodak",
+		"fujifilm":                    "Fujifilm",
+		"fujifilm corporation":        "Fujifilm",
+		"google":                      "Google",
+		"hasselblad":                  "Hasselblad",
+		"huawei":                      "Huawei",
+		"kodak":                       "Kodak",
+		"leica":                       "Leica",
+		"leica camera ag":             "Leica",
+		"minolta co., ltd.":           "Minolta",
+		"konica minolta":              "Konica Minolta",
+		"konica minolta camera, inc.": "Konica Minolta",
+		"nikon":                       "Nikon",
+		"nikon corporation":           "Nikon",
+		"olympus":                     "Olympus",
+		"olympus corporation":         "Olympus",
+		"olympus imaging corp.":       "Olympus",
+		"om digital solutions":        "OM Digital Solutions",
+		"panasonic":                   "Panasonic",
+		"pentax":                      "Pentax",
+		"pentax corporation":          "Pentax",
+		"ricoh":                       "Ricoh",
+		"ricoh imaging company, ltd.": "Ricoh",
+		"samsung":                     "Samsung",
+		"samsung techwin":             "Samsung",
+		"sigma":                       "Sigma",
+		"sony":                        "Sony",
+	}
+
+	builtinCameraModelPrefixes = map[string][]string{
+		"Kodak":   {"kodak "},
+		"Olympus": {"olympus "},
+		"Pentax":  {"pentax "},
+		"Ricoh":   {"ricoh ", "pentax "},
+	}
+
+	builtinLensModels = map[string]string{
+		"ef24-105mm f/4l is usm":      "Canon EF 24-105mm f/4L IS USM",
+		"ef24-70mm f/2.8l ii usm":     "Canon EF 24-70mm f/2.8L II USM",
+		"ef70-200mm f/2.8l is ii usm": "Canon EF 70-200mm f/2.8L IS II USM",
+		"ef50mm f/1.8 stm":            "Canon EF 50mm f/1.8 STM",
+		"ef100mm f/2.8l macro is usm": "Canon EF 100mm f/2.8L Macro IS USM",
+	}
+)
@@ -0,0 +1,94 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestTableCameraNormalizer_NormalizeCamera(t *testing.T) {
+	tcn := NewTableCameraNormalizer()
+
+	ci, found := tcn.NormalizeCamera("NIKON CORPORATION", "NIKON  D850\x00", "")
+	if found != true {
+		t.Fatalf("Make should have been recognized.")
+	} else if ci.Make != "Nikon" {
+		t.Fatalf("Make not correct: [%s]", ci.Make)
+	} else if ci.Model != "D850" {
+		t.Fatalf("Model not correct: [%s]", ci.Model)
+	} else if ci.ModelId() != "nikon/d850" {
+		t.Fatalf("Model ID not correct: [%s]", ci.ModelId())
+	} else if ci.LensId() != "" {
+		t.Fatalf("Lens ID should be empty: [%s]", ci.LensId())
+	}
+
+	ci, found = tcn.NormalizeCamera("Canon", "Canon EOS 5D Mark III", "EF24-105mm f/4L IS USM")
+	if found != true {
+		t.Fatalf("Make should have been recognized.")
+	} else if ci.Model != "EOS 5D Mark III" {
+		t.Fatalf("Model not correct: [%s]", ci.Model)
+	} else if ci.LensModel != "Canon EF 24-105mm f/4L IS USM" {
+		t.Fatalf("Lens not correct: [%s]", ci.LensModel)
+	} else if ci.LensId() != "canon-ef-24-105mm-f-4l-is-usm" {
+		t.Fatalf("Lens ID not correct: [%s]", ci.LensId())
+	}
+
+	ci, found = tcn.NormalizeCamera(" Acme Optical ", "Acme Optical X1", "")
+	if found != false {
+		t.Fatalf("Make should not have been recognized.")
+	} else if ci.Make != "Acme Optical" {
+		t.Fatalf("Unknown make not cleaned: [%s]", ci.Make)
+	} else if ci.Model != "X1" {
+		t.Fatalf("Unknown model prefix not stripped: [%s]", ci.Model)
+	}
+}
+
+func TestTableCameraNormalizer_NormalizeCamera_Extended(t *testing.T) {
+	tcn := NewTableCameraNormalizer()
+	tcn.Makes["acme optical"] = "Acme"
+
+	ci, found := tcn.NormalizeCamera("ACME OPTICAL", "X1", "")
+	if found != true {
+		t.Fatalf("Added make should have been recognized.")
+	} else if ci.Make != "Acme" {
+		t.Fatalf("Make not correct: [%s]", ci.Make)
+	}
+
+	// The built-in table must not have been modified.
+	_, found = NewTableCameraNormalizer().NormalizeCamera("ACME OPTICAL", "X1", "")
+	if found != false {
+		t.Fatalf("Built-in table was modified.")
+	}
+}
+
+type testCameraNormalizer struct{}
+
+func (testCameraNormalizer) NormalizeCamera(rawMake, rawModel, rawLensModel string) (ci CameraIdentity, found bool) {
+	return CameraIdentity{Make: "Test", Model: rawModel}, true
+}
+
+func TestIfdIndex_CameraIdentity(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	ci, found, err := index.CameraIdentity()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Make should have been recognized.")
+	} else if ci.ModelId() != "canon/eos-5d-mark-iii" {
+		t.Fatalf("Model ID not correct: [%s]", ci.ModelId())
+	}
+
+	SetCameraNormalizer(testCameraNormalizer{})
+	defer SetCameraNormalizer(nil)
+
+	ci, _, err = index.CameraIdentity()
+	log.PanicIf(err)
+
+	if ci.Make != "Test" {
+		t.Fatalf("Custom normalizer not used: [%s]", ci.Make)
+	}
+}
@@ -0,0 +1,502 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// CanonMakerNoteParserName is the name of the maker-note parser for Canon.
+	CanonMakerNoteParserName = "Canon"
+)
+
+var (
+	// ErrNotCanonMakerNote means that a maker-note wasn't parsed by the Canon
+	// parser.
+	ErrNotCanonMakerNote = errors.New("not a canon maker-note")
+)
+
+var (
+	// canonMakerNoteIfdTag is the root of the tags of Canon maker-notes.
+	canonMakerNoteIfdTag = exifcommon.NewIfdTag(nil, MakerNoteTagId, "MakerNoteCanon")
+
+	// CanonMakerNoteIfdIdentity is the identity of the IFD of a Canon
+	// maker-note. Its tags are only known to the index that the maker-note is
+	// parsed with.
+	CanonMakerNoteIfdIdentity = exifcommon.NewIfdIdentity(canonMakerNoteIfdTag, exifcommon.IfdIdentityPart{Name: "MakerNoteCanon", Index: 0})
+)
+
+var (
+	// canonMakerNoteTags are the Canon maker-note tags that we recognize.
+	// Others are skipped when parsing.
+	canonMakerNoteTags = []struct {
+		id       uint16
+		name     string
+		typeName exifcommon.TagTypePrimitive
+	}{
+		{0x0001, "CanonCameraSettings", exifcommon.TypeShort},
+		{0x0002, "CanonFocalLength", exifcommon.TypeShort},
+		{0x0004, "CanonShotInfo", exifcommon.TypeShort},
+		{0x0006, "CanonImageType", exifcommon.TypeAscii},
+		{0x0007, "CanonFirmwareVersion", exifcommon.TypeAscii},
+		{0x0008, "FileNumber", exifcommon.TypeLong},
+		{0x0009, "OwnerName", exifcommon.TypeAscii},
+		{0x000c, "SerialNumber", exifcommon.TypeLong},
+		{0x0010, "CanonModelID", exifcommon.TypeLong},
+		{0x0012, "CanonAFInfo", exifcommon.TypeShort},
+		{0x0026, "CanonAFInfo2", exifcommon.TypeShort},
+		{0x0028, "ImageUniqueID", exifcommon.TypeByte},
+		{0x0095, "LensModel", exifcommon.TypeAscii},
+		{0x0096, "InternalSerialNumber", exifcommon.TypeAscii},
+		{0x00b4, "ColorSpace", exifcommon.TypeShort},
+	}
+
+	canonMakerNoteTagIndex     *TagIndex
+	canonMakerNoteTagIndexOnce sync.Once
+)
+
+var (
+	// canonCameraSettingsFields are the fields of the CanonCameraSettings
+	// array that we recognize, by position. The first element is the size of
+	// the array in bytes.
+	canonCameraSettingsFields = map[int]string{
+		1:  "MacroMode",
+		2:  "SelfTimer",
+		3:  "Quality",
+		4:  "CanonFlashMode",
+		5:  "ContinuousDrive",
+		7:  "FocusMode",
+		9:  "RecordMode",
+		10: "CanonImageSize",
+		11: "EasyMode",
+		12: "DigitalZoom",
+		13: "Contrast",
+		14: "Saturation",
+		15: "Sharpness",
+		16: "CameraISO",
+		17: "MeteringMode",
+		18: "FocusRange",
+		19: "AFPoint",
+		20: "CanonExposureMode",
+		22: "LensType",
+		23: "MaxFocalLength",
+		24: "MinFocalLength",
+		25: "FocalUnits",
+		26: "MaxAperture",
+		27: "MinAperture",
+		28: "FlashActivity",
+		29: "FlashBits",
+		32: "FocusContinuous",
+		33: "AESetting",
+		34: "ImageStabilization",
+		35: "DisplayAperture",
+		36: "ZoomSourceWidth",
+		37: "ZoomTargetWidth",
+		39: "SpotMeteringMode",
+		40: "PhotoEffect",
+		41: "ManualFlashOutput",
+		42: "ColorTone",
+		46: "SRAWQuality",
+	}
+
+	// canonShotInfoFields are the fields of the CanonShotInfo array that we
+	// recognize, by position. The first element is the size of the array in
+	// bytes.
+	canonShotInfoFields = map[int]string{
+		1:  "AutoISO",
+		2:  "BaseISO",
+		3:  "MeasuredEV",
+		4:  "TargetAperture",
+		5:  "TargetExposureTime",
+		6:  "ExposureCompensation",
+		7:  "WhiteBalance",
+		8:  "SlowShutter",
+		9:  "SequenceNumber",
+		10: "OpticalZoomCode",
+		12: "CameraTemperature",
+		13: "FlashGuideNumber",
+		14: "AFPointsInFocus",
+		15: "FlashExposureComp",
+		16: "AutoExposureBracketing",
+		17: "AEBBracketValue",
+		18: "ControlMode",
+		19: "FocusDistanceUpper",
+		20: "FocusDistanceLower",
+		21: "FNumber",
+		22: "ExposureTime",
+		23: "MeasuredEV2",
+		24: "BulbDuration",
+		26: "CameraType",
+		27: "AutoRotate",
+		28: "NDFilter",
+		29: "SelfTimer2",
+		33: "FlashOutput",
+	}
+
+	// CanonLensTypes names the lenses by the LensType of the camera settings.
+	// It's only consulted if the maker-note doesn't have a LensModel (which
+	// every EOS since around 2008 writes), and more can be added. Several
+	// IDs are shared by third-party lenses, so only unambiguous ones are
+	// listed.
+	CanonLensTypes = map[uint16]string{
+		1:    "Canon EF 50mm f/1.8",
+		2:    "Canon EF 28mm f/2.8",
+		3:    "Canon EF 135mm f/2.8 Soft",
+		237:  "Canon EF 24-105mm f/4L IS USM",
+		254:  "Canon EF 100mm f/2.8L Macro IS USM",
+		4154: "Canon EF-S 24mm f/2.8 STM",
+		4156: "Canon EF 50mm f/1.8 STM",
+	}
+)
+
+// getCanonMakerNoteTagIndex returns the tag index for Canon maker-notes.
+func getCanonMakerNoteTagIndex() *TagIndex {
+	canonMakerNoteTagIndexOnce.Do(func() {
+		ti := NewTagIndex()
+
+		ifdPath := CanonMakerNoteIfdIdentity.UnindexedString()
+		for _, cmt := range canonMakerNoteTags {
+			it := &IndexedTag{
+				Id:             cmt.id,
+				Name:           cmt.name,
+				IfdPath:        ifdPath,
+				SupportedTypes: []exifcommon.TagTypePrimitive{cmt.typeName},
+				Group:          TagGroupCamera,
+			}
+
+			err := ti.Add(it)
+			log.PanicIf(err)
+		}
+
+		canonMakerNoteTagIndex = ti
+	})
+
+	return canonMakerNoteTagIndex
+}
+
+// canonMakerNoteParser parses Canon maker-notes, which are bare IFDs whose
+// offsets are relative to the EXIF.
+type canonMakerNoteParser struct{}
+
+// Name returns the name of the parser.
+func (canonMakerNoteParser) Name() string {
+	return CanonMakerNoteParserName
+}
+
+// Accepts returns true for maker-notes without a preamble from a Canon.
+func (canonMakerNoteParser) Accepts(mnc MakerNoteContext) bool {
+	if strings.HasPrefix(strings.ToUpper(mnc.Make), "CANON") == false {
+		return false
+	}
+
+	_, found := DetectMakerNoteHeader(mnc.Data)
+	return found == false
+}
+
+// Parse parses the maker-note.
+func (canonMakerNoteParser) Parse(mnc MakerNoteContext) (index IfdIndex, err error) {
+	return mnc.ParseIfd(CanonMakerNoteIfdIdentity, getCanonMakerNoteTagIndex())
+}
+
+func init() {
+	RegisterMakerNoteParser(canonMakerNoteParser{})
+}
+
+// CanonMakerNote decodes the values of a parsed Canon maker-note, including
+// those packed into arrays.
+type CanonMakerNote struct {
+	ifd *Ifd
+}
+
+// NewCanonMakerNote returns the decoder for the given maker-note.
+// `ErrNotCanonMakerNote` is returned if it wasn't parsed by the Canon parser.
+func NewCanonMakerNote(mn MakerNote) (cmn *CanonMakerNote, err error) {
+	if mn.Parser != CanonMakerNoteParserName || mn.Index.RootIfd == nil {
+		return nil, ErrNotCanonMakerNote
+	}
+
+	cmn = &CanonMakerNote{
+		ifd: mn.Index.RootIfd,
+	}
+
+	return cmn, nil
+}
+
+// Ifd returns the IFD of the maker-note.
+func (cmn *CanonMakerNote) Ifd() *Ifd {
+	return cmn.ifd
+}
+
+// shorts returns the value of the given SHORT tag.
+func (cmn *CanonMakerNote) shorts(tagName string) (values []uint16, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := cmn.ifd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	return value.([]uint16), true, nil
+}
+
+// decodeArray returns the named fields of the given array tag. Canon stores
+// these as SHORTs but they're signed.
+func (cmn *CanonMakerNote) decodeArray(tagName string, fields map[int]string) (values map[string]int16, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	raw, found, err := cmn.shorts(tagName)
+	log.PanicIf(err)
+
+	if found == false {
+		return nil, false, nil
+	}
+
+	values = make(map[string]int16)
+	for i, name := range fields {
+		if i < len(raw) {
+			values[name] = int16(raw[i])
+		}
+	}
+
+	return values, true, nil
+}
+
+// CameraSettings returns the fields of the CanonCameraSettings array (e.g.
+// "FocusMode" or "LensType"), as Canon stores them. Fields that the model
+// doesn't write are absent.
+func (cmn *CanonMakerNote) CameraSettings() (values map[string]int16, found bool, err error) {
+	return cmn.decodeArray("CanonCameraSettings", canonCameraSettingsFields)
+}
+
+// ShotInfo returns the fields of the CanonShotInfo array (e.g. "BaseISO" or
+// "SequenceNumber"), as Canon stores them. Fields that the model doesn't
+// write are absent.
+func (cmn *CanonMakerNote) ShotInfo() (values map[string]int16, found bool, err error) {
+	return cmn.decodeArray("CanonShotInfo", canonShotInfoFields)
+}
+
+// LensName returns the LensModel of the maker-note or, for older models that
+// don't write one, the name of the LensType of the camera settings (see
+// `CanonLensTypes`). `found` is false if neither is available.
+func (cmn *CanonMakerNote) LensName() (name string, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := cmn.ifd.FindTagWithName("LensModel")
+	if err == nil {
+		value, err := results[0].Value()
+		log.PanicIf(err)
+
+		if name := strings.TrimSpace(value.(string)); name != "" {
+			return name, true, nil
+		}
+	} else if log.Is(err, ErrTagNotFound) == false {
+		log.Panic(err)
+	}
+
+	settings, found, err := cmn.CameraSettings()
+	log.PanicIf(err)
+
+	if found == false {
+		return "", false, nil
+	}
+
+	lensType, found := settings["LensType"]
+	if found == false {
+		return "", false, nil
+	}
+
+	name, found = CanonLensTypes[uint16(lensType)]
+	return name, found, nil
+}
+
+// SerialNumber returns the serial number of the body, zero-padded to ten
+// digits as Canon prints it.
+func (cmn *CanonMakerNote) SerialNumber() (serialNumber string, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	values, found, err := readTiffUint32s(cmn.ifd, 0x000c)
+	log.PanicIf(err)
+
+	if found == false || len(values) == 0 {
+		return "", false, nil
+	}
+
+	return fmt.Sprintf("%010d", values[0]), true, nil
+}
+
+// CanonAfPoint is one of the AF points of a Canon.
+type CanonAfPoint struct {
+	// X and Y are the position of the center of the point relative to the
+	// center of the AF image (Y is up).
+	X, Y int16
+
+	// Width and Height are the size of the point.
+	Width, Height uint16
+
+	// InFocus is true if the point was in focus.
+	InFocus bool
+
+	// Selected is true if the point was selected. This is only known from
+	// CanonAFInfo2 and only for some models.
+	Selected bool
+}
+
+// CanonAfInfo describes the AF points of a Canon.
+type CanonAfInfo struct {
+	// AreaMode is the AF area mode. This is only known from CanonAFInfo2.
+	AreaMode uint16
+
+	// ValidPoints is the number of points that the camera was using.
+	ValidPoints uint16
+
+	// ImageWidth and ImageHeight are the size of the image that the point
+	// positions are relative to.
+	ImageWidth, ImageHeight uint16
+
+	// Points are the AF points.
+	Points []CanonAfPoint
+}
+
+// InFocus returns the positions of the points that were in focus.
+func (cai CanonAfInfo) InFocus() []int {
+	positions := make([]int, 0)
+	for i, point := range cai.Points {
+		if point.InFocus == true {
+			positions = append(positions, i)
+		}
+	}
+
+	return positions
+}
+
+// AfInfo returns the AF points from CanonAFInfo2 or, for older models, from
+// CanonAFInfo.
+func (cmn *CanonMakerNote) AfInfo() (cai CanonAfInfo, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	raw, found, err := cmn.shorts("CanonAFInfo2")
+	log.PanicIf(err)
+
+	if found == true {
+		if len(raw) < 8 {
+			log.Panicf("canon af-info2 too short: (%d)", len(raw))
+		}
+
+		cai.AreaMode = raw[1]
+		cai.ValidPoints = raw[3]
+		cai.ImageWidth = raw[6]
+		cai.ImageHeight = raw[7]
+
+		err := decodeCanonAfPoints(&cai, int(raw[2]), raw[8:], true)
+		log.PanicIf(err)
+
+		return cai, true, nil
+	}
+
+	raw, found, err = cmn.shorts("CanonAFInfo")
+	log.PanicIf(err)
+
+	if found == false {
+		return cai, false, nil
+	}
+
+	if len(raw) < 8 {
+		log.Panicf("canon af-info too short: (%d)", len(raw))
+	}
+
+	cai.ValidPoints = raw[1]
+	cai.ImageWidth = raw[4]
+	cai.ImageHeight = raw[5]
+
+	// The older format has one size for every point.
+
+	pointCount := int(raw[0])
+	sizes := make([]uint16, 0, pointCount*2)
+	for i := 0; i < pointCount; i++ {
+		sizes = append(sizes, raw[6])
+	}
+
+	for i := 0; i < pointCount; i++ {
+		sizes = append(sizes, raw[7])
+	}
+
+	err = decodeCanonAfPoints(&cai, pointCount, append(sizes, raw[8:]...), false)
+	log.PanicIf(err)
+
+	return cai, true, nil
+}
+
+// decodeCanonAfPoints decodes the per-point part of the AF info: the widths,
+// heights, X positions, and Y positions, followed by the in-focus bits and,
+// optionally, the selected bits (sixteen points per SHORT).
+func decodeCanonAfPoints(cai *CanonAfInfo, pointCount int, raw []uint16, hasSelected bool) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bitsLength := (pointCount + 15) / 16
+	if len(raw) < pointCount*4+bitsLength {
+		log.Panicf("canon af-info too short for (%d) points: (%d)", pointCount, len(raw))
+	}
+
+	inFocus := raw[pointCount*4 : pointCount*4+bitsLength]
+
+	var selected []uint16
+	if hasSelected == true && len(raw) >= pointCount*4+bitsLength*2 {
+		selected = raw[pointCount*4+bitsLength : pointCount*4+bitsLength*2]
+	}
+
+	cai.Points = make([]CanonAfPoint, pointCount)
+	for i := range cai.Points {
+		point := CanonAfPoint{
+			Width:   raw[i],
+			Height:  raw[pointCount+i],
+			X:       int16(raw[pointCount*2+i]),
+			Y:       int16(raw[pointCount*3+i]),
+			InFocus: inFocus[i/16]&(1<<uint(i%16)) != 0,
+		}
+
+		if selected != nil {
+			point.Selected = selected[i/16]&(1<<uint(i%16)) != 0
+		}
+
+		cai.Points[i] = point
+	}
+
+	return nil
+}
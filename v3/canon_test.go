@@ -0,0 +1,252 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+type testCanonMakerNoteEntry struct {
+	tagId    uint16
+	tagType  exifcommon.TagTypePrimitive
+	count    uint32
+	rawValue []byte
+}
+
+// buildTestCanonMakerNote returns a bare-IFD maker-note whose values are at
+// offsets relative to the EXIF, as if the maker-note is at `base`.
+func buildTestCanonMakerNote(byteOrder binary.ByteOrder, base uint32, entries []testCanonMakerNoteEntry) []byte {
+	ifdSize := 2 + len(entries)*12 + 4
+	data := make([]byte, ifdSize)
+
+	byteOrder.PutUint16(data[0:2], uint16(len(entries)))
+
+	for i, entry := range entries {
+		offset := 2 + i*12
+
+		byteOrder.PutUint16(data[offset:offset+2], entry.tagId)
+		byteOrder.PutUint16(data[offset+2:offset+4], uint16(entry.tagType))
+		byteOrder.PutUint32(data[offset+4:offset+8], entry.count)
+
+		if len(entry.rawValue) <= 4 {
+			copy(data[offset+8:offset+12], entry.rawValue)
+			continue
+		}
+
+		byteOrder.PutUint32(data[offset+8:offset+12], base+uint32(len(data)))
+		data = append(data, entry.rawValue...)
+	}
+
+	return data
+}
+
+func testCanonShorts(byteOrder binary.ByteOrder, values ...uint16) []byte {
+	data := make([]byte, len(values)*2)
+	for i, value := range values {
+		byteOrder.PutUint16(data[i*2:], value)
+	}
+
+	return data
+}
+
+// buildTestCanonExif returns EXIF from a Canon with a maker-note with the
+// given entries.
+func buildTestCanonExif(entries []testCanonMakerNoteEntry) []byte {
+	byteOrder := binary.LittleEndian
+
+	// Find where the maker-note lands before we can write its offsets.
+
+	placeholder := buildTestCanonMakerNote(byteOrder, 0, entries)
+	index := collectTestMakerNoteExif(buildTestMakerNoteExif(byteOrder, "Canon", placeholder))
+
+	mnc, found, err := NewMakerNoteContext(index)
+	log.PanicIf(err)
+
+	if found != true {
+		log.Panicf("maker-note placeholder not found")
+	}
+
+	makerNoteData := buildTestCanonMakerNote(byteOrder, mnc.Offset, entries)
+	return buildTestMakerNoteExif(byteOrder, "Canon", makerNoteData)
+}
+
+func getTestCanonMakerNote(entries []testCanonMakerNoteEntry) *CanonMakerNote {
+	mn, found, err := ParseMakerNote(collectTestMakerNoteExif(buildTestCanonExif(entries)))
+	log.PanicIf(err)
+
+	if found != true {
+		log.Panicf("maker-note not found")
+	}
+
+	cmn, err := NewCanonMakerNote(mn)
+	log.PanicIf(err)
+
+	return cmn
+}
+
+func TestCanonMakerNote(t *testing.T) {
+	byteOrder := binary.LittleEndian
+
+	cameraSettings := make([]uint16, 25)
+	cameraSettings[0] = 50
+	cameraSettings[7] = 3
+	cameraSettings[22] = 237
+	cameraSettings[23] = 105
+	cameraSettings[24] = 24
+
+	shotInfo := make([]uint16, 10)
+	shotInfo[0] = 20
+	shotInfo[2] = 160
+	shotInfo[6] = 0xfff0
+	shotInfo[9] = 2
+
+	// Three AF points, the second in focus and the third selected.
+
+	afInfo2 := []uint16{
+		22, 2, 3, 3, 5472, 3648, 5472, 3648,
+		100, 100, 100,
+		120, 120, 120,
+		0xfe0c, 0, 500,
+		0, 0, 0xff38,
+		0x0002,
+		0x0004,
+	}
+
+	serialNumber := make([]byte, 4)
+	byteOrder.PutUint32(serialNumber, 12345)
+
+	entries := []testCanonMakerNoteEntry{
+		{0x0001, exifcommon.TypeShort, uint32(len(cameraSettings)), testCanonShorts(byteOrder, cameraSettings...)},
+		{0x0004, exifcommon.TypeShort, uint32(len(shotInfo)), testCanonShorts(byteOrder, shotInfo...)},
+		{0x000c, exifcommon.TypeLong, 1, serialNumber},
+		{0x0026, exifcommon.TypeShort, uint32(len(afInfo2)), testCanonShorts(byteOrder, afInfo2...)},
+		{0x0095, exifcommon.TypeAscii, 23, []byte("EF24-105mm f/4L IS USM\000")},
+	}
+
+	cmn := getTestCanonMakerNote(entries)
+
+	settings, found, err := cmn.CameraSettings()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Camera settings not found.")
+	} else if settings["FocusMode"] != 3 || settings["LensType"] != 237 || settings["MinFocalLength"] != 24 {
+		t.Fatalf("Camera settings not correct: %v", settings)
+	} else if _, found := settings["FocalUnits"]; found != false {
+		t.Fatalf("Field beyond the array should be absent.")
+	}
+
+	info, found, err := cmn.ShotInfo()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Shot info not found.")
+	} else if info["BaseISO"] != 160 || info["ExposureCompensation"] != -16 || info["SequenceNumber"] != 2 {
+		t.Fatalf("Shot info not correct: %v", info)
+	}
+
+	lensName, found, err := cmn.LensName()
+	log.PanicIf(err)
+
+	if found != true || lensName != "EF24-105mm f/4L IS USM" {
+		t.Fatalf("Lens name not correct: [%s]", lensName)
+	}
+
+	serial, found, err := cmn.SerialNumber()
+	log.PanicIf(err)
+
+	if found != true || serial != "0000012345" {
+		t.Fatalf("Serial number not correct: [%s]", serial)
+	}
+
+	cai, found, err := cmn.AfInfo()
+	log.PanicIf(err)
+
+	expectedPoints := []CanonAfPoint{
+		{X: -500, Y: 0, Width: 100, Height: 120},
+		{X: 0, Y: 0, Width: 100, Height: 120, InFocus: true},
+		{X: 500, Y: -200, Width: 100, Height: 120, Selected: true},
+	}
+
+	if found != true {
+		t.Fatalf("AF info not found.")
+	} else if cai.AreaMode != 2 || cai.ValidPoints != 3 || cai.ImageWidth != 5472 {
+		t.Fatalf("AF info not correct: %v", cai)
+	} else if reflect.DeepEqual(cai.Points, expectedPoints) != true {
+		t.Fatalf("AF points not correct: %v", cai.Points)
+	} else if reflect.DeepEqual(cai.InFocus(), []int{1}) != true {
+		t.Fatalf("AF points in focus not correct: %v", cai.InFocus())
+	}
+}
+
+func TestCanonMakerNote_LensName_LensType(t *testing.T) {
+	byteOrder := binary.LittleEndian
+
+	cameraSettings := make([]uint16, 23)
+	cameraSettings[22] = 4156
+
+	entries := []testCanonMakerNoteEntry{
+		{0x0001, exifcommon.TypeShort, uint32(len(cameraSettings)), testCanonShorts(byteOrder, cameraSettings...)},
+	}
+
+	cmn := getTestCanonMakerNote(entries)
+
+	lensName, found, err := cmn.LensName()
+	log.PanicIf(err)
+
+	if found != true || lensName != "Canon EF 50mm f/1.8 STM" {
+		t.Fatalf("Lens name not correct: [%s]", lensName)
+	}
+
+	_, found, err = cmn.AfInfo()
+	log.PanicIf(err)
+
+	if found != false {
+		t.Fatalf("Expected no AF info.")
+	}
+}
+
+func TestNewCanonMakerNote_NotCanon(t *testing.T) {
+	mn, _, err := ParseMakerNote(collectTestMakerNoteExif(buildTestNef()))
+	log.PanicIf(err)
+
+	_, err = NewCanonMakerNote(mn)
+	if err != ErrNotCanonMakerNote {
+		t.Fatalf("Expected not-canon error: [%v]", err)
+	}
+}
+
+func TestParseMakerNote_Canon(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, NewTagIndex(), getTestExifData())
+	log.PanicIf(err)
+
+	mn, found, err := ParseMakerNote(index)
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Maker-note not found.")
+	} else if mn.Parser != CanonMakerNoteParserName {
+		t.Fatalf("Parser not correct: [%s]", mn.Parser)
+	}
+
+	cmn, err := NewCanonMakerNote(mn)
+	log.PanicIf(err)
+
+	_, found, err = cmn.CameraSettings()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Camera settings not found.")
+	}
+}
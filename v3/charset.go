@@ -0,0 +1,167 @@
+package exif
+
+import (
+	"unicode/utf8"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+var (
+	// DefaultCharsetCandidates are the legacy charsets that are tried, in
+	// order of preference, when the text of an ASCII tag is neither ASCII nor
+	// UTF-8. Many cameras write the local encoding into tags like Artist and
+	// Copyright.
+	DefaultCharsetCandidates = []string{exifundefined.CharsetShiftJis, exifundefined.CharsetGbk}
+)
+
+// charsetScore returns how typical the text is for the charset, as the
+// fraction of its non-ASCII characters that are in the commonly-used part of
+// the charset, or -1 if the text isn't valid in it (or we don't know the
+// charset). This only looks at the byte structure, which is enough to tell
+// the two apart most of the time: GB2312 text tends to be half-width katakana
+// in Shift-JIS, and Shift-JIS kanji and kana tend to fall outside of GB2312 in
+// GBK.
+func charsetScore(name string, raw []byte) float64 {
+	characters := 0
+	score := 0.0
+
+	for i := 0; i < len(raw); i++ {
+		b := raw[i]
+		if b < 0x80 {
+			continue
+		}
+
+		characters++
+
+		switch name {
+		case exifundefined.CharsetShiftJis:
+			if b >= 0xa1 && b <= 0xdf {
+				// Half-width katakana.
+				continue
+			} else if (b >= 0x81 && b <= 0x9f) || (b >= 0xe0 && b <= 0xef) {
+				if i+1 >= len(raw) {
+					return -1
+				}
+
+				trail := raw[i+1]
+				if trail < 0x40 || trail > 0xfc || trail == 0x7f {
+					return -1
+				}
+
+				score++
+				i++
+			} else {
+				return -1
+			}
+		case exifundefined.CharsetGbk:
+			if b == 0x80 || b == 0xff || i+1 >= len(raw) {
+				return -1
+			}
+
+			trail := raw[i+1]
+			if trail < 0x40 || trail == 0x7f || trail == 0xff {
+				return -1
+			}
+
+			if b >= 0xa1 && b <= 0xf7 && trail >= 0xa1 {
+				score++
+			} else {
+				score += 0.5
+			}
+
+			i++
+		default:
+			return -1
+		}
+	}
+
+	if characters == 0 {
+		return 1
+	}
+
+	return score / float64(characters)
+}
+
+// SniffCharset returns the name of the charset that the raw text is most
+// likely in: `exifundefined.CharsetAscii` or `exifundefined.CharsetUtf8` if
+// it's valid as either, otherwise the candidate that fits it best (with ties
+// going to the earlier candidate), or an empty string if it fits none of
+// them. If `candidates` is nil, `DefaultCharsetCandidates` are used. This is a
+// best-effort guess.
+func SniffCharset(raw []byte, candidates []string) string {
+	isAscii := true
+	for _, b := range raw {
+		if b >= 0x80 {
+			isAscii = false
+			break
+		}
+	}
+
+	if isAscii == true {
+		return exifundefined.CharsetAscii
+	} else if utf8.Valid(raw) == true {
+		return exifundefined.CharsetUtf8
+	}
+
+	if candidates == nil {
+		candidates = DefaultCharsetCandidates
+	}
+
+	best := ""
+	bestScore := -1.0
+
+	for _, name := range candidates {
+		score := charsetScore(name, raw)
+		if score > bestScore {
+			best = name
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// DecodedText returns the value of an ASCII tag converted to UTF-8 from the
+// charset that it appears to be in (see `SniffCharset()`), along with the
+// name of that charset. The conversion is done by the converter registered
+// for the charset (see `exifundefined.RegisterCharsetConverter()`; building
+// with the "exif_xtext" tag registers them for Shift_JIS and GBK). If there's
+// no converter, or the text isn't valid in any of the charsets, the value is
+// returned as-is with an empty name. `Value()` is not affected and still
+// returns the original bytes, which is what should be written back.
+// `ErrWrongType` is returned for other types.
+func (ite *IfdTagEntry) DecodedText(candidates []string) (text string, charset string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ite.TagType() != exifcommon.TypeAscii {
+		return "", "", exifcommon.ErrWrongType
+	}
+
+	value, err := ite.Value()
+	log.PanicIf(err)
+
+	raw := value.(string)
+
+	charset = SniffCharset([]byte(raw), candidates)
+	if charset == "" {
+		return raw, "", nil
+	}
+
+	text, err = exifundefined.DecodeCharset(charset, []byte(raw))
+	if err != nil {
+		if log.Is(err, exifundefined.ErrCharsetNotSupported) == true || log.Is(err, exifundefined.ErrCharsetNotDecodable) == true {
+			return raw, "", nil
+		}
+
+		log.Panic(err)
+	}
+
+	return text, charset, nil
+}
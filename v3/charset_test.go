@@ -0,0 +1,89 @@
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+func TestSniffCharset(t *testing.T) {
+	cases := []struct {
+		raw      string
+		expected string
+	}{
+		{"Jane Doe", exifundefined.CharsetAscii},
+		{"Zoë", exifundefined.CharsetUtf8},
+		{"\x8e\x52\x93\x63\x91\xbe\x98\x59", exifundefined.CharsetShiftJis},
+		{"\x82\xe2\x82\xdc\x82\xbe", exifundefined.CharsetShiftJis},
+		{"\xd5\xc5\xc8\xfd", exifundefined.CharsetGbk},
+		{"\xc0\xee\xcb\xc4", exifundefined.CharsetGbk},
+		{"(C) \xb0\xe6\xc8\xa8\xcb\xf9\xd3\xd0", exifundefined.CharsetGbk},
+	}
+
+	for _, c := range cases {
+		charset := SniffCharset([]byte(c.raw), nil)
+		if charset != c.expected {
+			t.Fatalf("Charset for [%x] not correct: [%s] != [%s]", c.raw, charset, c.expected)
+		}
+	}
+
+	if charset := SniffCharset([]byte{0x80, 0xff}, nil); charset != "" {
+		t.Fatalf("Expected unknown charset: [%s]", charset)
+	}
+
+	// Candidates that aren't given aren't chosen.
+	if charset := SniffCharset([]byte("\xd5\xc5\xc8\xfd"), []string{exifundefined.CharsetShiftJis}); charset != "" {
+		t.Fatalf("Expected unknown charset: [%s]", charset)
+	}
+}
+
+// getCharsetTestExif returns EXIF with the given Artist.
+func getCharsetTestExif(artist string) []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Artist", artist)
+	log.PanicIf(err)
+
+	exifData, err := NewIfdByteEncoder().EncodeToExif(ib)
+	log.PanicIf(err)
+
+	return exifData
+}
+
+func TestIfdTagEntry_DecodedText_NoConverter(t *testing.T) {
+	raw := "\x8e\x52\x93\x63\x91\xbe\x98\x59"
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, NewTagIndex(), getCharsetTestExif(raw))
+	log.PanicIf(err)
+
+	ite := index.RootIfd.Entries()[0]
+
+	// Sniff with a charset that nothing converts from.
+
+	text, charset, err := ite.DecodedText([]string{"TEST"})
+	log.PanicIf(err)
+
+	if text != raw || charset != "" {
+		t.Fatalf("Text should be returned as-is: [%x] [%s]", text, charset)
+	}
+
+	_, index, err = Collect(im, NewTagIndex(), getCharsetTestExif("Zoë"))
+	log.PanicIf(err)
+
+	text, charset, err = index.RootIfd.Entries()[0].DecodedText(nil)
+	log.PanicIf(err)
+
+	if text != "Zoë" || charset != exifundefined.CharsetUtf8 {
+		t.Fatalf("UTF-8 text not correct: [%s] [%s]", text, charset)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build exif_xtext
+// +build exif_xtext
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+func TestIfdTagEntry_DecodedText(t *testing.T) {
+	cases := []struct {
+		raw      string
+		expected string
+		text     string
+	}{
+		{"\x8e\x52\x93\x63\x91\xbe\x98\x59", exifundefined.CharsetShiftJis, "山田太郎"},
+		{"\x82\xe2\x82\xdc\x82\xbe", exifundefined.CharsetShiftJis, "やまだ"},
+		{"\xd5\xc5\xc8\xfd", exifundefined.CharsetGbk, "张三"},
+		{"(C) \xb0\xe6\xc8\xa8\xcb\xf9\xd3\xd0", exifundefined.CharsetGbk, "(C) 版权所有"},
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	for _, c := range cases {
+		_, index, err := Collect(im, NewTagIndex(), getCharsetTestExif(c.raw))
+		log.PanicIf(err)
+
+		ite := index.RootIfd.Entries()[0]
+
+		text, charset, err := ite.DecodedText(nil)
+		log.PanicIf(err)
+
+		if text != c.text || charset != c.expected {
+			t.Fatalf("Decoded text not correct: [%s] [%s]", text, charset)
+		}
+
+		// The original bytes are what are still read and copied.
+
+		value, err := ite.Value()
+		log.PanicIf(err)
+
+		if value.(string) != c.raw {
+			t.Fatalf("Value not correct: [%x]", value)
+		}
+	}
+}
+
+func TestGetFlatExifData_Charsets(t *testing.T) {
+	raw := "\x8e\x52\x93\x63\x91\xbe\x98\x59"
+	exifData := getCharsetTestExif(raw)
+
+	// The flat tags only convert when asked to.
+
+	so := &ScanOptions{
+		Charsets: DefaultCharsetCandidates,
+	}
+
+	exifTags, _, err := GetFlatExifData(exifData, so)
+	log.PanicIf(err)
+
+	et := exifTags[0]
+	if et.Formatted != "山田太郎" || et.Value.(string) != "山田太郎" || et.Charset != exifundefined.CharsetShiftJis {
+		t.Fatalf("Flat tag not converted: %s", et)
+	} else if bytes.Equal(et.ValueBytes, []byte(raw+"\x00")) != true {
+		t.Fatalf("Original bytes not kept: %x", et.ValueBytes)
+	}
+
+	exifTags, _, err = GetFlatExifData(exifData, nil)
+	log.PanicIf(err)
+
+	if exifTags[0].Charset != "" || exifTags[0].Value.(string) != raw {
+		t.Fatalf("Flat tag should not be converted: %s", exifTags[0])
+	}
+}
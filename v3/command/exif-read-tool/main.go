@@ -4,6 +4,9 @@
 //
 //   exif-read-tool -filepath <file-path>
 //
+// Pass --summarize to show large binary values (e.g. maker-notes) as their
+// size, hash, and first few bytes rather than in full.
+//
 // Example Output:
 //
 //   IFD=[IfdIdentity<PARENT-NAME=[] NAME=[IFD]>] ID=(0x010f) NAME=[Make] COUNT=(6) TYPE=[ASCII] VALUE=[Canon]
@@ -57,6 +60,8 @@ type parameters struct {
 	DoNotPrintTags          bool   `short:"n" long:"no-tags" description:"Do not actually print tags. Good for auditing the logs or merely checking the EXIF structure for errors."`
 	SkipBlocks              int    `short:"s" long:"skip" description:"Skip this many EXIF blocks before returning"`
 	DoUniversalTagSearch    bool   `short:"u" long:"universal-tags" description:"If tags not found in known mapped IFDs, fallback to trying all IFDs."`
+	SummarizeValues         bool   `short:"S" long:"summarize" description:"Summarize large binary values instead of printing them in full"`
+	MaxValueLength          int    `short:"m" long:"max-value-length" description:"With --summarize, summarize binary values with more bytes than this and truncate other values that are longer (default 256)"`
 }
 
 var (
@@ -108,7 +113,16 @@ func main() {
 
 	// Run the parse.
 
-	entries, _, err := exif.GetFlatExifDataUniversalSearch(rawExif, nil, arguments.DoUniversalTagSearch)
+	var so *exif.ScanOptions
+	if arguments.SummarizeValues == true {
+		so = &exif.ScanOptions{
+			Dump: &exif.DumpOptions{
+				MaxLength: arguments.MaxValueLength,
+			},
+		}
+	}
+
+	entries, _, err := exif.GetFlatExifDataUniversalSearch(rawExif, so, arguments.DoUniversalTagSearch)
 	if err != nil {
 		if arguments.SkipBlocks > 0 {
 			mainLogger.Warningf(nil, "Encountered an error. This might be related to the request to skip EXIF blocks.")
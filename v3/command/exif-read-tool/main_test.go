@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package main
 
 import (
@@ -165,6 +168,55 @@ func TestMainJson(t *testing.T) {
 	}
 }
 
+func TestMainJson_Summarized(t *testing.T) {
+	appFilepath := getAppFilepath()
+	testImageFilepath := getTestImageFilepath()
+
+	cmd := exec.Command(
+		"go", "run", appFilepath,
+		"--filepath", testImageFilepath,
+		"--json",
+		"--summarize")
+
+	b := new(bytes.Buffer)
+	cmd.Stdout = b
+	cmd.Stderr = b
+
+	err := cmd.Run()
+	actualRaw := b.Bytes()
+
+	if err != nil {
+		fmt.Printf(string(actualRaw))
+		log.Panic(err)
+	}
+
+	actual := make([]map[string]interface{}, 0)
+
+	err = json.Unmarshal(actualRaw, &actual)
+	log.PanicIf(err)
+
+	found := false
+	for _, tagInfo := range actual {
+		if tagInfo["name"] != "MakerNote" {
+			continue
+		}
+
+		found = true
+
+		if tagInfo["is_summarized"] != true {
+			t.Fatalf("Maker-note not summarized: %v", tagInfo)
+		} else if tagInfo["value_bytes"] != nil {
+			t.Fatalf("Summarized maker-note should not have value-bytes.")
+		} else if tagInfo["formatted"] != "BINARY<SIZE=(8152) SHA256=[a8aca7906260d21b95726b4ae5eccd92eeaaf0143b4b189a38e9868cce6eae1c] HEAD=[28 00 01 00 03 00 31 00 00 00 74 05 00 00 02 00]>" {
+			t.Fatalf("Summary not correct: [%v]", tagInfo["formatted"])
+		}
+	}
+
+	if found == false {
+		t.Fatalf("Maker-note not found.")
+	}
+}
+
 func getAppFilepath() string {
 	moduleRootPath := exifcommon.GetModuleRootPath()
 	appFilepath := path.Join(moduleRootPath, "command", "exif-read-tool", "main.go")
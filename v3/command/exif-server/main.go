@@ -0,0 +1,132 @@
+// This tool serves EXIF extraction and rewriting over HTTP. It is an example
+// of using the library in a long-running service: request bodies are bounded,
+// each request is given a deadline, and edits are applied with the builder
+// and encoder rather than by patching bytes.
+//
+// Example command-line:
+//
+//	exif-server --listen :8080
+//
+// Endpoints:
+//
+//	POST /extract
+//	  Multipart form with the image in the "file" field. Responds with the
+//	  tags as JSON (the same model as `exif-read-tool --json`).
+//
+//	POST /rewrite
+//	  Multipart form with the image in the "file" field and an edit manifest
+//	  in the "manifest" field. Responds with the modified file. JPEGs are
+//	  returned as JPEGs. Raw EXIF/TIFF blobs are returned as EXIF blobs.
+//	  If the "dry_run" field is "true", nothing is rewritten and the response
+//	  is a JSON report of the new EXIF size, whether the rest of the file
+//	  would have to move, and which segment and IFD offsets would change
+//	  (JPEGs only).
+//
+// Example manifest:
+//
+//	{
+//	    "set": [
+//	        { "ifd_path": "IFD", "tag_name": "Artist", "values": ["Jane Doe"] },
+//	        { "ifd_path": "IFD", "tag_name": "XResolution", "values": ["300/1"] }
+//	    ],
+//	    "delete": [
+//	        { "ifd_path": "IFD/GPSInfo", "tag_name": "GPSLatitude" }
+//	    ]
+//	}
+//
+// The manifest may also have an "exiftool" field with the output of
+// `exiftool -j` (or `-struct`) for one file, which is imported before the
+// deletes and sets. Fields that aren't EXIF tags are ignored.
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/jessevdk/go-flags"
+)
+
+var (
+	mainLogger = log.NewLogger("main.main")
+)
+
+type parameters struct {
+	ListenAddress    string        `short:"l" long:"listen" default:":8080" description:"Address to listen on"`
+	MaxUploadBytes   int64         `short:"m" long:"max-upload-bytes" default:"33554432" description:"Largest request body that will be accepted"`
+	RequestTimeout   time.Duration `short:"t" long:"request-timeout" default:"30s" description:"Deadline for processing one request"`
+	MaxManifestEdits int           `long:"max-manifest-edits" default:"256" description:"Largest number of edits accepted in one manifest"`
+	IsVerbose        bool          `short:"v" long:"verbose" description:"Print logging"`
+}
+
+var (
+	arguments = new(parameters)
+)
+
+func main() {
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			os.Exit(-2)
+		}
+	}()
+
+	_, err := flags.Parse(arguments)
+	if err != nil {
+		os.Exit(-1)
+	}
+
+	if arguments.IsVerbose == true {
+		cla := log.NewConsoleLogAdapter()
+		log.AddAdapter("console", cla)
+
+		scp := log.NewStaticConfigurationProvider()
+		scp.SetLevelName(log.LevelNameDebug)
+
+		log.LoadConfiguration(scp)
+	}
+
+	es := &exifServer{
+		maxUploadBytes:   arguments.MaxUploadBytes,
+		requestTimeout:   arguments.RequestTimeout,
+		maxManifestEdits: arguments.MaxManifestEdits,
+	}
+
+	server := &http.Server{
+		Addr:              arguments.ListenAddress,
+		Handler:           es.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       arguments.RequestTimeout,
+		WriteTimeout:      arguments.RequestTimeout + 10*time.Second,
+		MaxHeaderBytes:    1 << 16,
+	}
+
+	// Stop accepting requests on interrupt and let in-flight ones finish.
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt)
+
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), arguments.RequestTimeout)
+		defer cancel()
+
+		err := server.Shutdown(ctx)
+		if err != nil {
+			mainLogger.Errorf(nil, err, "Could not shut down cleanly.")
+		}
+	}()
+
+	mainLogger.Infof(nil, "Listening on [%s].", arguments.ListenAddress)
+
+	err = server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Panic(err)
+	}
+}
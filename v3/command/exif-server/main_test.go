@@ -0,0 +1,358 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package main
+
+import (
+	"bytes"
+	"path"
+	"testing"
+	"time"
+
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3"
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getTestImageData() []byte {
+	testImageFilepath := path.Join(exifcommon.GetTestAssetsPath(), "NDM_8901.jpg")
+
+	data, err := ioutil.ReadFile(testImageFilepath)
+	log.PanicIf(err)
+
+	return data
+}
+
+func newTestServer() *httptest.Server {
+	es := &exifServer{
+		maxUploadBytes:   32 << 20,
+		requestTimeout:   30 * time.Second,
+		maxManifestEdits: 10,
+	}
+
+	return httptest.NewServer(es.Handler())
+}
+
+func postMultipart(url string, data []byte, manifest string) (response *http.Response, body []byte) {
+	fields := make(map[string]string)
+	if manifest != "" {
+		fields["manifest"] = manifest
+	}
+
+	return postMultipartWithFields(url, data, fields)
+}
+
+func postMultipartWithFields(url string, data []byte, fields map[string]string) (response *http.Response, body []byte) {
+	b := new(bytes.Buffer)
+	mw := multipart.NewWriter(b)
+
+	fw, err := mw.CreateFormFile("file", "image.jpg")
+	log.PanicIf(err)
+
+	_, err = fw.Write(data)
+	log.PanicIf(err)
+
+	for name, value := range fields {
+		err := mw.WriteField(name, value)
+		log.PanicIf(err)
+	}
+
+	err = mw.Close()
+	log.PanicIf(err)
+
+	response, err = http.Post(url, mw.FormDataContentType(), b)
+	log.PanicIf(err)
+
+	defer response.Body.Close()
+
+	body, err = ioutil.ReadAll(response.Body)
+	log.PanicIf(err)
+
+	return response, body
+}
+
+func findTag(entries []exif.ExifTag, ifdPath, tagName string) (et exif.ExifTag, found bool) {
+	for _, et := range entries {
+		if et.IfdPath == ifdPath && et.TagName == tagName {
+			return et, true
+		}
+	}
+
+	return et, false
+}
+
+func TestExtract(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	response, body := postMultipart(server.URL+"/extract", getTestImageData(), "")
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Status not correct: (%d) %s", response.StatusCode, body)
+	}
+
+	entries := make([]exif.ExifTag, 0)
+
+	err := json.Unmarshal(body, &entries)
+	log.PanicIf(err)
+
+	et, found := findTag(entries, "IFD", "Model")
+	if found != true {
+		t.Fatalf("Model not found.")
+	} else if et.Formatted != "Canon EOS 5D Mark III" {
+		t.Fatalf("Model not correct: [%s]", et.Formatted)
+	}
+}
+
+func TestExtract_NoExif(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	response, _ := postMultipart(server.URL+"/extract", []byte("not an image"), "")
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Status not correct: (%d)", response.StatusCode)
+	}
+}
+
+func TestExtract_TooLarge(t *testing.T) {
+	es := &exifServer{
+		maxUploadBytes:   1024,
+		requestTimeout:   30 * time.Second,
+		maxManifestEdits: 10,
+	}
+
+	server := httptest.NewServer(es.Handler())
+	defer server.Close()
+
+	response, _ := postMultipart(server.URL+"/extract", getTestImageData(), "")
+	if response.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Status not correct: (%d)", response.StatusCode)
+	}
+}
+
+func TestExtract_MethodNotAllowed(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/extract")
+	log.PanicIf(err)
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Status not correct: (%d)", response.StatusCode)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	manifest := `{
+		"set": [
+			{ "ifd_path": "IFD", "tag_name": "Artist", "values": ["Jane Doe"] },
+			{ "ifd_path": "IFD", "tag_name": "XResolution", "values": ["300/1"] },
+			{ "ifd_path": "IFD/GPSInfo", "tag_name": "GPSAltitudeRef", "values": ["0"] }
+		],
+		"delete": [
+			{ "ifd_path": "IFD", "tag_name": "DateTime" },
+			{ "ifd_path": "IFD/GPSInfo", "tag_name": "GPSLatitude" }
+		]
+	}`
+
+	original := getTestImageData()
+
+	response, body := postMultipart(server.URL+"/rewrite", original, manifest)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Status not correct: (%d) %s", response.StatusCode, body)
+	} else if response.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("Content-type not correct: [%s]", response.Header.Get("Content-Type"))
+	} else if bytes.HasSuffix(body, original[len(original)-1024:]) != true {
+		t.Fatalf("Image data was not preserved.")
+	}
+
+	rawExif, err := exif.ExtractExifFromJpegSegments(bytes.NewReader(body))
+	log.PanicIf(err)
+
+	entries, _, err := exif.GetFlatExifData(rawExif, nil)
+	log.PanicIf(err)
+
+	if et, found := findTag(entries, "IFD", "Artist"); found != true || et.Formatted != "Jane Doe" {
+		t.Fatalf("Artist not set: [%s]", et.Formatted)
+	} else if et, found := findTag(entries, "IFD", "XResolution"); found != true || et.Formatted != "[300/1]" {
+		t.Fatalf("XResolution not set: [%s]", et.Formatted)
+	} else if _, found := findTag(entries, "IFD/GPSInfo", "GPSAltitudeRef"); found != true {
+		t.Fatalf("GPS IFD not created.")
+	} else if _, found := findTag(entries, "IFD", "DateTime"); found != false {
+		t.Fatalf("DateTime not deleted.")
+	} else if _, found := findTag(entries, "IFD", "Model"); found != true {
+		t.Fatalf("Untouched tag was lost.")
+	}
+}
+
+func TestRewrite_Exiftool(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	manifest := `{
+		"exiftool": [{
+			"SourceFile": "other.jpg",
+			"Artist": "Jane Doe",
+			"GPSPosition": "54 deg 59' 22.80\" N, 1 deg 54' 55.20\" W"
+		}]
+	}`
+
+	response, body := postMultipart(server.URL+"/rewrite", getTestImageData(), manifest)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Status not correct: (%d) %s", response.StatusCode, body)
+	}
+
+	rawExif, err := exif.ExtractExifFromJpegSegments(bytes.NewReader(body))
+	log.PanicIf(err)
+
+	entries, _, err := exif.GetFlatExifData(rawExif, nil)
+	log.PanicIf(err)
+
+	if et, found := findTag(entries, "IFD", "Artist"); found != true || et.Formatted != "Jane Doe" {
+		t.Fatalf("Artist not set: [%s]", et.Formatted)
+	} else if et, found := findTag(entries, "IFD/GPSInfo", "GPSLongitudeRef"); found != true || et.Formatted != "W" {
+		t.Fatalf("GPSLongitudeRef not set: [%s]", et.Formatted)
+	}
+}
+
+func TestRewrite_DryRun(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	fields := map[string]string{
+		"manifest": `{ "set": [{ "ifd_path": "IFD", "tag_name": "Artist", "values": ["Jane Doe"] }] }`,
+		"dry_run":  "true",
+	}
+
+	original := getTestImageData()
+
+	response, body := postMultipartWithFields(server.URL+"/rewrite", original, fields)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Status not correct: (%d) %s", response.StatusCode, body)
+	} else if response.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Content-type not correct: [%s]", response.Header.Get("Content-Type"))
+	}
+
+	plan := new(exif.RewritePlan)
+
+	err := json.Unmarshal(body, plan)
+	log.PanicIf(err)
+
+	if plan.OldFileSize != len(original) || plan.NewExifSize == 0 {
+		t.Fatalf("Plan not correct: %s", plan)
+	} else if plan.NeedsRelocation != true || len(plan.ShiftedSegments) == 0 {
+		t.Fatalf("Expected the image data to move: %s", plan)
+	}
+}
+
+func TestRewrite_BadManifest(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	manifests := []string{
+		`{ "sett": [] }`,
+		`{ "set": [{ "ifd_path": "IFD", "tag_name": "NotATag", "values": ["1"] }] }`,
+		`{ "set": [{ "ifd_path": "IFD", "tag_name": "XResolution", "values": ["300"] }] }`,
+		`{ "set": [{ "ifd_path": "IFD/Nowhere", "tag_name": "Artist", "values": ["x"] }] }`,
+	}
+
+	for _, manifest := range manifests {
+		response, body := postMultipart(server.URL+"/rewrite", getTestImageData(), manifest)
+		if response.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Status not correct for manifest [%s]: (%d) %s", manifest, response.StatusCode, body)
+		}
+	}
+}
+
+func TestRewrite_MatchesDryRun(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	app1 := func(payload string) []byte {
+		segment := []byte{0xff, 0xe1, 0, 0}
+		binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+
+		return append(segment, payload...)
+	}
+
+	// Put an Extended XMP packet and a second EXIF segment after the real
+	// EXIF. Only the first EXIF is replaced, and the Extended XMP has to stay
+	// intact since it's only found by its identifier and GUID.
+
+	original := getTestImageData()
+
+	_, segments, err := exif.ExtractExifAndSegmentsFromJpeg(original)
+	log.PanicIf(err)
+
+	var exifEnd int
+	for _, js := range segments {
+		if js.Identifier == "Exif" {
+			exifEnd = js.Offset + js.Length
+			break
+		}
+	}
+
+	guid := "0123456789ABCDEF0123456789ABCDEF"
+
+	jpeg := append([]byte{}, original[:exifEnd]...)
+	jpeg = append(jpeg, app1(exif.JpegXmpIdentifier+"\x00<x:xmpmeta/>")...)
+	jpeg = append(jpeg, app1(exif.JpegExtendedXmpIdentifier+"\x00"+guid+"\x00\x00\x00\x04\x00\x00\x00\x00ab")...)
+	jpeg = append(jpeg, app1("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08")...)
+	jpeg = append(jpeg, app1(exif.JpegExtendedXmpIdentifier+"\x00"+guid+"\x00\x00\x00\x04\x00\x00\x00\x02cd")...)
+	jpeg = append(jpeg, original[exifEnd:]...)
+
+	manifest := `{ "set": [{ "ifd_path": "IFD", "tag_name": "Artist", "values": ["Jane Doe"] }] }`
+
+	fields := map[string]string{
+		"manifest": manifest,
+		"dry_run":  "true",
+	}
+
+	response, body := postMultipartWithFields(server.URL+"/rewrite", jpeg, fields)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Dry-run status not correct: (%d) %s", response.StatusCode, body)
+	}
+
+	plan := new(exif.RewritePlan)
+
+	err = json.Unmarshal(body, plan)
+	log.PanicIf(err)
+
+	response, output := postMultipart(server.URL+"/rewrite", jpeg, manifest)
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Status not correct: (%d) %s", response.StatusCode, output)
+	} else if len(output) != plan.NewFileSize {
+		t.Fatalf("Output size does not match the plan: (%d) != (%d)", len(output), plan.NewFileSize)
+	}
+
+	_, after, err := exif.ExtractExifAndSegmentsFromJpeg(output)
+	log.PanicIf(err)
+
+	exifCount := 0
+	var jex *exif.JpegExtendedXmp
+	for _, js := range after {
+		if js.Identifier == "Exif" {
+			exifCount++
+		} else if js.ExtendedXmp != nil {
+			jex = js.ExtendedXmp
+		}
+	}
+
+	if exifCount != 2 {
+		t.Fatalf("Second EXIF segment not kept: (%d)", exifCount)
+	} else if jex == nil || jex.Complete != true || string(jex.Payload) != "abcd" {
+		t.Fatalf("Extended XMP not intact: %v", jex)
+	}
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3"
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// multipartMemoryBytes is how much of a multipart form is buffered in
+	// memory before it is spooled to temporary files.
+	multipartMemoryBytes = 8 << 20
+)
+
+// clientError is an error caused by the request rather than by us.
+type clientError struct {
+	status  int
+	message string
+}
+
+func (ce clientError) Error() string {
+	return ce.message
+}
+
+func newClientError(status int, format string, args ...interface{}) error {
+	return clientError{
+		status:  status,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// manifestTag identifies a tag in an edit manifest. `Values` is only used
+// when setting. Each value is converted according to the tag's type (e.g.
+// "72/1" for a RATIONAL). ASCII tags take a single value.
+type manifestTag struct {
+	IfdPath string   `json:"ifd_path"`
+	TagName string   `json:"tag_name"`
+	Values  []string `json:"values"`
+}
+
+// editManifest describes the changes that `/rewrite` makes. `Exiftool` is
+// the JSON that ExifTool wrote for another file (see
+// `exif.ImportExiftoolJson()`); it is imported first. Deletes are applied
+// before sets.
+type editManifest struct {
+	Exiftool json.RawMessage `json:"exiftool"`
+	Set      []manifestTag   `json:"set"`
+	Delete   []manifestTag   `json:"delete"`
+}
+
+type exifServer struct {
+	maxUploadBytes   int64
+	requestTimeout   time.Duration
+	maxManifestEdits int
+}
+
+// Handler returns the handler that serves all endpoints.
+func (es *exifServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/extract", es.handleExtract)
+	mux.HandleFunc("/rewrite", es.handleRewrite)
+
+	return mux
+}
+
+func (es *exifServer) handleExtract(w http.ResponseWriter, r *http.Request) {
+	data, _, err := es.readUpload(w, r, false)
+	if err != nil {
+		es.writeError(w, err)
+		return
+	}
+
+	output, err := es.runWithDeadline(r.Context(), func() (output []byte, err error) {
+		rawExif, err := exif.SearchAndExtractExif(data)
+		if err == exif.ErrNoExif {
+			return nil, newClientError(http.StatusUnprocessableEntity, "no EXIF data")
+		} else if err != nil {
+			return nil, newClientError(http.StatusUnprocessableEntity, "could not find EXIF data: %s", err.Error())
+		}
+
+		entries, _, err := exif.GetFlatExifData(rawExif, nil)
+		if err != nil {
+			return nil, newClientError(http.StatusUnprocessableEntity, "could not parse EXIF data: %s", err.Error())
+		}
+
+		return json.Marshal(entries)
+	})
+
+	if err != nil {
+		es.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(output)
+}
+
+func (es *exifServer) handleRewrite(w http.ResponseWriter, r *http.Request) {
+	data, manifestRaw, err := es.readUpload(w, r, true)
+	if err != nil {
+		es.writeError(w, err)
+		return
+	}
+
+	manifest, err := es.parseManifest(manifestRaw)
+	if err != nil {
+		es.writeError(w, err)
+		return
+	}
+
+	isJpeg := bytes.HasPrefix(data, []byte{0xff, 0xd8})
+	isDryRun := r.FormValue("dry_run") == "true"
+
+	if isDryRun == true && isJpeg == false {
+		es.writeError(w, newClientError(http.StatusBadRequest, "dry runs are only supported for JPEG images"))
+		return
+	}
+
+	output, err := es.runWithDeadline(r.Context(), func() (output []byte, err error) {
+		var rawExif []byte
+		if isJpeg == true {
+			rawExif, err = exif.ExtractExifFromJpegSegments(bytes.NewReader(data))
+			if err == exif.ErrNoExif {
+				rawExif = nil
+			} else if err != nil {
+				return nil, newClientError(http.StatusUnprocessableEntity, "could not read JPEG: %s", err.Error())
+			}
+		} else if _, err := exif.ParseExifHeader(data); err == nil {
+			rawExif = data
+		} else {
+			return nil, newClientError(http.StatusUnsupportedMediaType, "only JPEG images and raw EXIF data can be rewritten")
+		}
+
+		newExif, err := applyManifest(rawExif, manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		if isJpeg == false {
+			return newExif, nil
+		}
+
+		if isDryRun == true {
+			plan, err := exif.PlanJpegExifRewrite(data, newExif)
+			if err != nil {
+				return nil, newClientError(http.StatusUnprocessableEntity, "could not plan rewrite: %s", err.Error())
+			}
+
+			return json.Marshal(plan)
+		}
+
+		output, err = exif.SetJpegExif(data, newExif)
+		if err != nil {
+			if err == exif.ErrJpegExifTooLarge {
+				return nil, newClientError(http.StatusUnprocessableEntity, "EXIF is too large for a JPEG segment: (%d) bytes", len(newExif))
+			}
+
+			return nil, newClientError(http.StatusUnprocessableEntity, "could not rewrite JPEG: %s", err.Error())
+		}
+
+		return output, nil
+	})
+
+	if err != nil {
+		es.writeError(w, err)
+		return
+	}
+
+	if isDryRun == true {
+		w.Header().Set("Content-Type", "application/json")
+	} else if isJpeg == true {
+		w.Header().Set("Content-Type", "image/jpeg")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+
+	w.Write(output)
+}
+
+// countingReader counts the bytes that are read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	cr.n += int64(n)
+
+	return n, err
+}
+
+// readUpload reads the "file" field and, if requested, the "manifest" field
+// from a multipart request whose total size is bounded.
+func (es *exifServer) readUpload(w http.ResponseWriter, r *http.Request, withManifest bool) (data []byte, manifestRaw []byte, err error) {
+	if r.Method != http.MethodPost {
+		return nil, nil, newClientError(http.StatusMethodNotAllowed, "only POST is supported")
+	}
+
+	// Allow one byte more than the limit so that we can tell a request that
+	// exceeds it from one that's exactly at it.
+	cr := &countingReader{
+		r: io.LimitReader(r.Body, es.maxUploadBytes+1),
+	}
+
+	r.Body = ioutil.NopCloser(cr)
+
+	err = r.ParseMultipartForm(multipartMemoryBytes)
+	if cr.n > es.maxUploadBytes {
+		return nil, nil, newClientError(http.StatusRequestEntityTooLarge, "request is larger than (%d) bytes", es.maxUploadBytes)
+	} else if err != nil {
+		return nil, nil, newClientError(http.StatusBadRequest, "could not parse form: %s", err.Error())
+	}
+
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, nil, newClientError(http.StatusBadRequest, "the \"file\" field is required")
+	}
+
+	defer f.Close()
+
+	data, err = ioutil.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if withManifest == true {
+		manifestPhrase := r.FormValue("manifest")
+		if manifestPhrase == "" {
+			return nil, nil, newClientError(http.StatusBadRequest, "the \"manifest\" field is required")
+		}
+
+		manifestRaw = []byte(manifestPhrase)
+	}
+
+	return data, manifestRaw, nil
+}
+
+func (es *exifServer) parseManifest(manifestRaw []byte) (manifest editManifest, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(manifestRaw))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&manifest)
+	if err != nil {
+		return manifest, newClientError(http.StatusBadRequest, "manifest not valid: %s", err.Error())
+	}
+
+	if len(manifest.Set)+len(manifest.Delete) > es.maxManifestEdits {
+		return manifest, newClientError(http.StatusBadRequest, "manifest has more than (%d) edits", es.maxManifestEdits)
+	}
+
+	return manifest, nil
+}
+
+// runWithDeadline runs the work in the background and gives up on it if the
+// request deadline passes or the client goes away. The library calls aren't
+// cancellable, so the work finishes on its own but its result is discarded.
+func (es *exifServer) runWithDeadline(ctx context.Context, fn func() (output []byte, err error)) (output []byte, err error) {
+	ctx, cancel := context.WithTimeout(ctx, es.requestTimeout)
+	defer cancel()
+
+	type outcome struct {
+		output []byte
+		err    error
+	}
+
+	done := make(chan outcome, 1)
+
+	go func() {
+		defer func() {
+			if state := recover(); state != nil {
+				done <- outcome{err: fmt.Errorf("processing failed: %v", state)}
+			}
+		}()
+
+		output, err := fn()
+		done <- outcome{output: output, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.output, o.err
+	case <-ctx.Done():
+		return nil, newClientError(http.StatusServiceUnavailable, "request took too long: %s", ctx.Err().Error())
+	}
+}
+
+func (es *exifServer) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "internal error"
+
+	if ce, ok := err.(clientError); ok == true {
+		status = ce.status
+		message = ce.message
+	} else {
+		mainLogger.Errorf(nil, err, "Request failed.")
+	}
+
+	response := map[string]string{
+		"error": message,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// applyManifest applies the edits to the given EXIF data, or to an empty tree
+// if `rawExif` is nil, and returns the newly-encoded EXIF.
+func applyManifest(rawExif []byte, manifest editManifest) (newExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := exif.NewTagIndex()
+
+	var rootIb *exif.IfdBuilder
+	existing := make(map[string]bool)
+
+	if rawExif != nil {
+		_, index, err := exif.Collect(im, ti, rawExif)
+		if err != nil {
+			return nil, newClientError(http.StatusUnprocessableEntity, "could not parse EXIF data: %s", err.Error())
+		}
+
+		for fqIfdPath := range index.Lookup {
+			existing[fqIfdPath] = true
+		}
+
+		var report *exif.CopyReport
+
+		rootIb, report, err = exif.NewIfdBuilderFromExistingChainWithReport(index.RootIfd)
+		log.PanicIf(err)
+
+		for _, st := range report.Skipped {
+			mainLogger.Warningf(nil, "Tag [%s] in IFD [%s] could not be read and was not copied: %v", st.TagName, st.FqIfdPath, st.Reason)
+		}
+	} else {
+		rootIb = exif.NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.EncodeDefaultByteOrder)
+	}
+
+	if len(manifest.Exiftool) > 0 {
+		skipped, err := exif.ImportExiftoolJson(rootIb, manifest.Exiftool)
+		if err != nil {
+			return nil, newClientError(http.StatusBadRequest, "exiftool json not valid: %s", err.Error())
+		}
+
+		for _, esf := range skipped {
+			mainLogger.Debugf(nil, "ExifTool field [%s] not imported: %s", esf.Key, esf.Reason)
+		}
+	}
+
+	for _, mt := range manifest.Delete {
+		// Deleting from an IFD that isn't there is a no-op. Don't create it.
+		if existing[mt.IfdPath] == false {
+			continue
+		}
+
+		ib, err := exif.GetOrCreateIbFromRootIb(rootIb, mt.IfdPath)
+		log.PanicIf(err)
+
+		it, err := ti.GetWithName(ib.IfdIdentity(), mt.TagName)
+		if err != nil {
+			return nil, newClientError(http.StatusBadRequest, "tag [%s] is not valid in IFD [%s]", mt.TagName, mt.IfdPath)
+		}
+
+		_, err = ib.DeleteAll(it.Id)
+		log.PanicIf(err)
+	}
+
+	for _, mt := range manifest.Set {
+		ii, err := exifcommon.NewIfdIdentityFromString(im, mt.IfdPath)
+		if err != nil {
+			return nil, newClientError(http.StatusBadRequest, "IFD [%s] is not valid", mt.IfdPath)
+		}
+
+		it, err := ti.GetWithName(ii, mt.TagName)
+		if err != nil {
+			return nil, newClientError(http.StatusBadRequest, "tag [%s] is not valid in IFD [%s]", mt.TagName, mt.IfdPath)
+		}
+
+		value, err := manifestValue(it.SupportedTypes[0], mt.Values)
+		if err != nil {
+			return nil, newClientError(http.StatusBadRequest, "value for tag [%s] is not valid: %s", mt.TagName, err.Error())
+		}
+
+		err = rootIb.SetStandardWithNameInIfd(mt.IfdPath, mt.TagName, value)
+		log.PanicIf(err)
+	}
+
+	ibe := exif.NewIfdByteEncoder()
+
+	newExif, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	return newExif, nil
+}
+
+// manifestValue converts the manifest strings to a value of the given type.
+func manifestValue(tagType exifcommon.TagTypePrimitive, values []string) (value interface{}, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(values) == 0 {
+		log.Panicf("no values given")
+	}
+
+	if tagType == exifcommon.TypeAscii || tagType == exifcommon.TypeAsciiNoNul {
+		if len(values) != 1 {
+			log.Panicf("ASCII tags take exactly one value")
+		}
+
+		return values[0], nil
+	} else if tagType == exifcommon.TypeUndefined {
+		log.Panicf("undefined-type tags can not be set from a manifest")
+	}
+
+	converted := make([]interface{}, len(values))
+	for i, valueString := range values {
+		if tagType == exifcommon.TypeRational || tagType == exifcommon.TypeSignedRational {
+			if strings.Count(valueString, "/") != 1 {
+				log.Panicf("rational value must be of the form N/D: [%s]", valueString)
+			}
+		}
+
+		converted[i], err = exifcommon.TranslateStringToType(tagType, valueString)
+		log.PanicIf(err)
+	}
+
+	switch tagType {
+	case exifcommon.TypeByte:
+		typed := make([]byte, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(byte)
+		}
+
+		return typed, nil
+	case exifcommon.TypeShort:
+		typed := make([]uint16, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(uint16)
+		}
+
+		return typed, nil
+	case exifcommon.TypeLong:
+		typed := make([]uint32, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(uint32)
+		}
+
+		return typed, nil
+	case exifcommon.TypeRational:
+		typed := make([]exifcommon.Rational, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(exifcommon.Rational)
+		}
+
+		return typed, nil
+	case exifcommon.TypeSignedLong:
+		typed := make([]int32, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(int32)
+		}
+
+		return typed, nil
+	case exifcommon.TypeSignedRational:
+		typed := make([]exifcommon.SignedRational, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(exifcommon.SignedRational)
+		}
+
+		return typed, nil
+	case exifcommon.TypeFloat:
+		typed := make([]float32, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(float32)
+		}
+
+		return typed, nil
+	case exifcommon.TypeDouble:
+		typed := make([]float64, len(converted))
+		for i, v := range converted {
+			typed[i] = v.(float64)
+		}
+
+		return typed, nil
+	}
+
+	log.Panicf("type not supported: [%s]", tagType)
+	return nil, nil
+}
@@ -0,0 +1,344 @@
+// This tool runs checks over collections of images.
+//
+// The "validate" command parses and lints the EXIF of every file under the
+// given paths (directories are walked) and prints a report of the errors and
+// warnings found in each. It exits with (1) if anything was found at or above
+// the --fail-on severity, so it can be used to check image assets in CI.
+//
+// Example command-line:
+//
+//   exif-tool validate --format csv --fail-on warning --conformance exif assets/
+//
+// Files that can't be parsed are reported with the "parse-error" code and an
+// error severity. Files without EXIF are reported as such but are not
+// findings.
+//
+// Example Output (JSON):
+//
+//   [
+//     {
+//       "filepath": "assets/NDM_8901.jpg",
+//       "has_exif": true,
+//       "errors": 0,
+//       "warnings": 1,
+//       "findings": [
+//         {
+//           "severity": "warning",
+//           "code": "enum-value-out-of-range",
+//           ...
+//         }
+//       ]
+//     }
+//   ]
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/jessevdk/go-flags"
+
+	"github.com/dsoprea/go-exif/v3"
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// parseErrorCode is the code that files that can't be parsed are
+	// reported with.
+	parseErrorCode = "parse-error"
+)
+
+var (
+	severities = map[string]exif.LintSeverity{
+		"info":    exif.LintSeverityInfo,
+		"warning": exif.LintSeverityWarning,
+		"error":   exif.LintSeverityError,
+	}
+
+	conformanceLevels = map[string]exif.ConformanceLevel{
+		"none":    exif.ConformanceNone,
+		"exif":    exif.ConformanceExif,
+		"tiff-ep": exif.ConformanceTiffEp,
+		"dng":     exif.ConformanceDng,
+	}
+)
+
+// Finding is a JSON model for one problem in a file.
+type Finding struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	IfdPath  string `json:"ifd_path,omitempty"`
+	TagName  string `json:"tag_name,omitempty"`
+	Message  string `json:"message"`
+}
+
+// FileReport is a JSON model for the findings in one file.
+type FileReport struct {
+	Filepath string    `json:"filepath"`
+	HasExif  bool      `json:"has_exif"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Findings []Finding `json:"findings"`
+
+	// worst is the most serious severity found, or -1.
+	worst exif.LintSeverity
+}
+
+// String returns a descriptive string.
+func (fr FileReport) String() string {
+	return fmt.Sprintf("FileReport<FILEPATH=[%s] HAS-EXIF=[%v] ERRORS=(%d) WARNINGS=(%d)>", fr.Filepath, fr.HasExif, fr.Errors, fr.Warnings)
+}
+
+type validateParameters struct {
+	Format      string `long:"format" choice:"json" choice:"csv" default:"json" description:"Format of the report"`
+	FailOn      string `long:"fail-on" choice:"info" choice:"warning" choice:"error" choice:"never" default:"error" description:"Exit with (1) if anything this severe or worse is found"`
+	Conformance string `long:"conformance" choice:"none" choice:"exif" choice:"tiff-ep" choice:"dng" default:"none" description:"Specification to check conformance with"`
+	IsVerbose   bool   `short:"v" long:"verbose" description:"Print logging"`
+
+	Positional struct {
+		Paths []string `positional-arg-name:"path" required:"1" description:"Files and directories to validate"`
+	} `positional-args:"yes"`
+}
+
+type parameters struct {
+	Validate validateParameters `command:"validate" description:"Parse and lint the EXIF of every file and report the problems found"`
+}
+
+var (
+	arguments = new(parameters)
+)
+
+func main() {
+	defer func() {
+		if errRaw := recover(); errRaw != nil {
+			err := errRaw.(error)
+			log.PrintError(err)
+
+			os.Exit(-2)
+		}
+	}()
+
+	p := flags.NewParser(arguments, flags.Default)
+
+	_, err := p.Parse()
+	if err != nil {
+		os.Exit(-1)
+	}
+
+	switch p.Active.Name {
+	case "validate":
+		isFailed, err := handleValidate(&arguments.Validate)
+		log.PanicIf(err)
+
+		if isFailed == true {
+			os.Exit(1)
+		}
+	}
+}
+
+func handleValidate(vp *validateParameters) (isFailed bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if vp.IsVerbose == true {
+		cla := log.NewConsoleLogAdapter()
+		log.AddAdapter("console", cla)
+
+		scp := log.NewStaticConfigurationProvider()
+		scp.SetLevelName(log.LevelNameDebug)
+
+		log.LoadConfiguration(scp)
+	}
+
+	filepaths, err := collectFilepaths(vp.Positional.Paths)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := exif.NewTagIndex()
+
+	lo := &exif.LintOptions{
+		Conformance: conformanceLevels[vp.Conformance],
+	}
+
+	reports := make([]*FileReport, len(filepaths))
+	for i, filepath := range filepaths {
+		reports[i] = validateFile(im, ti, lo, filepath)
+
+		if vp.FailOn != "never" && reports[i].worst >= severities[vp.FailOn] {
+			isFailed = true
+		}
+	}
+
+	if vp.Format == "csv" {
+		err = writeCsvReport(reports)
+		log.PanicIf(err)
+	} else {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+
+		err := e.Encode(reports)
+		log.PanicIf(err)
+	}
+
+	return isFailed, nil
+}
+
+// collectFilepaths returns the regular files at or under the given paths, in
+// order.
+func collectFilepaths(paths []string) (filepaths []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	filepaths = make([]string, 0)
+
+	for _, rootPath := range paths {
+		err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.Mode().IsRegular() == true {
+				filepaths = append(filepaths, path)
+			}
+
+			return nil
+		})
+
+		log.PanicIf(err)
+	}
+
+	sort.Strings(filepaths)
+
+	return filepaths, nil
+}
+
+// validateFile parses and lints one file. Problems with the file are
+// reported rather than returned.
+func validateFile(im *exifcommon.IfdMapping, ti *exif.TagIndex, lo *exif.LintOptions, filepath string) (fr *FileReport) {
+	fr = &FileReport{
+		Filepath: filepath,
+		Findings: make([]Finding, 0),
+		worst:    -1,
+	}
+
+	findings, err := lintFile(im, ti, lo, filepath)
+	if err != nil {
+		if err == exif.ErrNoExif {
+			return fr
+		}
+
+		fr.HasExif = true
+		fr.add(exif.LintSeverityError, Finding{
+			Code:    parseErrorCode,
+			Message: err.Error(),
+		})
+
+		return fr
+	}
+
+	fr.HasExif = true
+
+	for _, lf := range findings {
+		fr.add(lf.Severity, Finding{
+			Code:    string(lf.Code),
+			IfdPath: lf.IfdPath,
+			TagName: lf.TagName,
+			Message: lf.Message,
+		})
+	}
+
+	return fr
+}
+
+// lintFile returns the findings for one file. `exif.ErrNoExif` is returned if
+// it has no EXIF.
+func lintFile(im *exifcommon.IfdMapping, ti *exif.TagIndex, lo *exif.LintOptions, filepath string) (findings []exif.LintFinding, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	rawExif, err := exif.SearchAndExtractExif(data)
+	if err != nil {
+		if err == exif.ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	_, index, err := exif.Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	fileLo := *lo
+	if len(data) >= 2 && data[0] == 0xff && data[1] == exif.JpegMarkerSoi {
+		fileLo.ImageData = data
+	}
+
+	findings, err = exif.Lint(index.RootIfd, &fileLo)
+	log.PanicIf(err)
+
+	return findings, nil
+}
+
+func (fr *FileReport) add(severity exif.LintSeverity, finding Finding) {
+	finding.Severity = severity.String()
+	fr.Findings = append(fr.Findings, finding)
+
+	if severity == exif.LintSeverityError {
+		fr.Errors++
+	} else if severity == exif.LintSeverityWarning {
+		fr.Warnings++
+	}
+
+	if severity > fr.worst {
+		fr.worst = severity
+	}
+}
+
+// writeCsvReport prints one row per finding.
+func writeCsvReport(reports []*FileReport) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	w := csv.NewWriter(os.Stdout)
+
+	err = w.Write([]string{"filepath", "severity", "code", "ifd_path", "tag_name", "message"})
+	log.PanicIf(err)
+
+	for _, fr := range reports {
+		for _, finding := range fr.Findings {
+			err := w.Write([]string{fr.Filepath, finding.Severity, finding.Code, finding.IfdPath, finding.TagName, finding.Message})
+			log.PanicIf(err)
+		}
+	}
+
+	w.Flush()
+
+	err = w.Error()
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,132 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestValidate_Json(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "exif-tool")
+	log.PanicIf(err)
+
+	defer os.RemoveAll(tempPath)
+
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "image.jpg"), data, 0644)
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(tempPath, "notes.txt"), []byte("not an image"), 0644)
+	log.PanicIf(err)
+
+	// Truncated right after the TIFF header.
+	offset := bytes.Index(data, []byte("II*\x00"))
+	err = ioutil.WriteFile(path.Join(tempPath, "truncated.jpg"), data[:offset+12], 0644)
+	log.PanicIf(err)
+
+	cmd := exec.Command(
+		"go", "run", getAppFilepath(),
+		"validate", "--fail-on", "never", tempPath)
+
+	b := new(bytes.Buffer)
+	cmd.Stdout = b
+
+	err = cmd.Run()
+	if err != nil {
+		fmt.Printf(b.String())
+		log.Panic(err)
+	}
+
+	reports := make([]FileReport, 0)
+
+	err = json.Unmarshal(b.Bytes(), &reports)
+	log.PanicIf(err)
+
+	if len(reports) != 3 {
+		t.Fatalf("Report count not correct: (%d)", len(reports))
+	}
+
+	if reports[0].Filepath != path.Join(tempPath, "image.jpg") || reports[0].HasExif != true || reports[0].Errors != 0 {
+		t.Fatalf("Image report not correct: %s", reports[0])
+	} else if reports[1].HasExif != false || len(reports[1].Findings) != 0 {
+		t.Fatalf("Non-image report not correct: %s", reports[1])
+	} else if reports[2].Errors != 1 || reports[2].Findings[0].Code != parseErrorCode {
+		t.Fatalf("Truncated-image report not correct: %s", reports[2])
+	}
+}
+
+func TestValidate_Csv_FailOn(t *testing.T) {
+	gpsImageFilepath := path.Join(exifcommon.GetTestAssetsPath(), "gps.jpg")
+
+	cmd := exec.Command(
+		"go", "run", getAppFilepath(),
+		"validate", "--format", "csv", "--conformance", "exif", gpsImageFilepath)
+
+	b := new(bytes.Buffer)
+	cmd.Stdout = b
+
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok == false {
+		t.Fatalf("Expected failing exit-code: %v", err)
+	}
+
+	records, err := csv.NewReader(b).ReadAll()
+	log.PanicIf(err)
+
+	if strings.Join(records[0], ",") != "filepath,severity,code,ifd_path,tag_name,message" {
+		t.Fatalf("Header not correct: %v", records[0])
+	}
+
+	foundError := false
+	for _, record := range records[1:] {
+		if record[0] != gpsImageFilepath {
+			t.Fatalf("Filepath not correct: %v", record)
+		} else if record[1] == "error" {
+			foundError = true
+		}
+	}
+
+	if foundError != true {
+		t.Fatalf("No errors reported: %v", records)
+	}
+
+	// The same findings don't fail a less strict threshold.
+
+	cmd = exec.Command(
+		"go", "run", getAppFilepath(),
+		"validate", "--conformance", "none", "--fail-on", "error", gpsImageFilepath)
+
+	err = cmd.Run()
+	log.PanicIf(err)
+}
+
+func getAppFilepath() string {
+	moduleRootPath := exifcommon.GetModuleRootPath()
+	appFilepath := path.Join(moduleRootPath, "command", "exif-tool", "main.go")
+
+	return appFilepath
+}
+
+func getTestImageFilepath() string {
+	assetsPath := exifcommon.GetTestAssetsPath()
+	testImageFilepath := path.Join(assetsPath, "NDM_8901.jpg")
+
+	return testImageFilepath
+}
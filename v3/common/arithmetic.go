@@ -0,0 +1,59 @@
+package exifcommon
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrArithmeticOverflow means that an offset or size calculation did not
+	// fit in the type that it's stored as. With offsets and counts read from
+	// the data, this indicates corrupt or malicious data.
+	ErrArithmeticOverflow = errors.New("arithmetic overflow")
+)
+
+// CheckedAddUint32 returns the sum of the given values or
+// `ErrArithmeticOverflow` if it does not fit in a uint32.
+func CheckedAddUint32(values ...uint32) (sum uint32, err error) {
+	total := uint64(0)
+	for _, value := range values {
+		total += uint64(value)
+
+		if total > math.MaxUint32 {
+			return 0, ErrArithmeticOverflow
+		}
+	}
+
+	return uint32(total), nil
+}
+
+// CheckedMulUint32 returns the product of the given values or
+// `ErrArithmeticOverflow` if it does not fit in a uint32.
+func CheckedMulUint32(a, b uint32) (product uint32, err error) {
+	total := uint64(a) * uint64(b)
+	if total > math.MaxUint32 {
+		return 0, ErrArithmeticOverflow
+	}
+
+	return uint32(total), nil
+}
+
+// CheckedIntToUint32 converts an int (e.g. a length) to a uint32 or returns
+// `ErrArithmeticOverflow` if it is negative or too large.
+func CheckedIntToUint32(value int) (converted uint32, err error) {
+	if value < 0 || uint64(value) > math.MaxUint32 {
+		return 0, ErrArithmeticOverflow
+	}
+
+	return uint32(value), nil
+}
+
+// CheckedIntToUint16 converts an int (e.g. a count) to a uint16 or returns
+// `ErrArithmeticOverflow` if it is negative or too large.
+func CheckedIntToUint16(value int) (converted uint16, err error) {
+	if value < 0 || value > math.MaxUint16 {
+		return 0, ErrArithmeticOverflow
+	}
+
+	return uint16(value), nil
+}
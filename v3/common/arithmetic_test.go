@@ -0,0 +1,79 @@
+package exifcommon
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestCheckedAddUint32(t *testing.T) {
+	sum, err := CheckedAddUint32(1, 2, 3)
+	log.PanicIf(err)
+
+	if sum != 6 {
+		t.Fatalf("Sum not correct: (%d)", sum)
+	}
+
+	sum, err = CheckedAddUint32(math.MaxUint32-1, 1)
+	log.PanicIf(err)
+
+	if sum != math.MaxUint32 {
+		t.Fatalf("Sum at limit not correct: (%d)", sum)
+	}
+
+	_, err = CheckedAddUint32(math.MaxUint32, 1)
+	if err != ErrArithmeticOverflow {
+		t.Fatalf("Expected overflow: %v", err)
+	}
+
+	sum, err = CheckedAddUint32()
+	log.PanicIf(err)
+
+	if sum != 0 {
+		t.Fatalf("Empty sum not zero: (%d)", sum)
+	}
+}
+
+func TestCheckedMulUint32(t *testing.T) {
+	product, err := CheckedMulUint32(4, 0x3fffffff)
+	log.PanicIf(err)
+
+	if product != 0xfffffffc {
+		t.Fatalf("Product not correct: (%d)", product)
+	}
+
+	// This would wrap around to (4) without checking.
+	_, err = CheckedMulUint32(4, 0x40000001)
+	if err != ErrArithmeticOverflow {
+		t.Fatalf("Expected overflow: %v", err)
+	}
+}
+
+func TestCheckedIntToUint32(t *testing.T) {
+	value, err := CheckedIntToUint32(100)
+	log.PanicIf(err)
+
+	if value != 100 {
+		t.Fatalf("Value not correct: (%d)", value)
+	}
+
+	_, err = CheckedIntToUint32(-1)
+	if err != ErrArithmeticOverflow {
+		t.Fatalf("Expected overflow for negative: %v", err)
+	}
+}
+
+func TestCheckedIntToUint16(t *testing.T) {
+	value, err := CheckedIntToUint16(math.MaxUint16)
+	log.PanicIf(err)
+
+	if value != math.MaxUint16 {
+		t.Fatalf("Value not correct: (%d)", value)
+	}
+
+	_, err = CheckedIntToUint16(math.MaxUint16 + 1)
+	if err != ErrArithmeticOverflow {
+		t.Fatalf("Expected overflow: %v", err)
+	}
+}
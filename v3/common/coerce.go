@@ -0,0 +1,418 @@
+package exifcommon
+
+import (
+	"errors"
+	"math"
+	"reflect"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// DefaultCoercionMaxDenominator is the largest denominator that floats
+	// are approximated with when they are stored as RATIONAL or SRATIONAL.
+	DefaultCoercionMaxDenominator = 1000000
+)
+
+var (
+	// ErrNotCoercible means that a value can not be stored as the given type
+	// at all, either because the conversion isn't supported or because the
+	// value is out of range for the type.
+	ErrNotCoercible = errors.New("value can not be converted to type")
+
+	// ErrLossyCoercion means that a value can only be stored as the given
+	// type by changing it, and lossy conversions were not allowed.
+	ErrLossyCoercion = errors.New("value can not be converted to type without loss")
+)
+
+// CoercionOptions controls `CoerceValue()`.
+type CoercionOptions struct {
+	// ForbidLossy causes `ErrLossyCoercion` to be returned rather than the
+	// value being rounded or approximated.
+	ForbidLossy bool
+
+	// MaxDenominator is the largest denominator that floats are approximated
+	// with. If zero, `DefaultCoercionMaxDenominator` is used.
+	MaxDenominator uint32
+}
+
+// coercionNumber is one value being coerced. `float` is always set, though it
+// may be inexact for integers and rationals.
+type coercionNumber struct {
+	isInteger bool
+	integer   int64
+
+	isRational  bool
+	numerator   int64
+	denominator int64
+
+	float float64
+}
+
+// CoerceValue converts the value to the Go type that the given tag type is
+// encoded from ([]uint8, []uint16, []uint32, []int32, []float32, []float64,
+// []Rational, or []SignedRational). The value may be a single value or a
+// slice of any Go integer or float type, `Rational`, or `SignedRational`.
+// ASCII and UNDEFINED values are returned unchanged. The conversions are:
+//
+//	from \ to          BYTE/SHORT/LONG/SLONG  FLOAT/DOUBLE   RATIONAL/SRATIONAL
+//	integers           exact if in range      exact, or      exact (n/1) if in
+//	                                          lossy if too   range
+//	                                          many digits
+//	floats             rounded (lossy) if     exact, or      approximated with a
+//	                   not integral           lossy if       bounded denominator
+//	                                          narrowed       (lossy if inexact)
+//	Rational/          exact if the division  lossy unless   exact if in range
+//	SignedRational     is exact, otherwise    exactly
+//	                   rounded (lossy)        representable
+//
+// Values that are out of range for the type (e.g. negative values for an
+// unsigned type, 70000 for SHORT), NaN, infinities, and unsupported Go types
+// return `ErrNotCoercible`, and are never wrapped or clamped. Lossy
+// conversions return `ErrLossyCoercion` if `ForbidLossy` is set.
+func CoerceValue(value interface{}, tagType TagTypePrimitive, options CoercionOptions) (coerced interface{}, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if tagType == TypeAscii || tagType == TypeAsciiNoNul || tagType == TypeUndefined {
+		return value, nil
+	}
+
+	numbers, err := coercionNumbers(value)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDenominator := uint64(options.MaxDenominator)
+	if maxDenominator == 0 {
+		maxDenominator = DefaultCoercionMaxDenominator
+	}
+
+	isLossy := false
+
+	switch tagType {
+	case TypeByte:
+		converted := make([]uint8, len(numbers))
+		for i, cn := range numbers {
+			integer, lossy, err := cn.toInteger(0, math.MaxUint8)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = uint8(integer)
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	case TypeShort:
+		converted := make([]uint16, len(numbers))
+		for i, cn := range numbers {
+			integer, lossy, err := cn.toInteger(0, math.MaxUint16)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = uint16(integer)
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	case TypeLong:
+		converted := make([]uint32, len(numbers))
+		for i, cn := range numbers {
+			integer, lossy, err := cn.toInteger(0, math.MaxUint32)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = uint32(integer)
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	case TypeSignedLong:
+		converted := make([]int32, len(numbers))
+		for i, cn := range numbers {
+			integer, lossy, err := cn.toInteger(math.MinInt32, math.MaxInt32)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = int32(integer)
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	case TypeFloat:
+		converted := make([]float32, len(numbers))
+		for i, cn := range numbers {
+			if math.IsNaN(cn.float) == true || math.Abs(cn.float) > math.MaxFloat32 {
+				return nil, ErrNotCoercible
+			}
+
+			converted[i] = float32(cn.float)
+			isLossy = isLossy || cn.isInexact(float64(converted[i]))
+		}
+
+		coerced = converted
+	case TypeDouble:
+		converted := make([]float64, len(numbers))
+		for i, cn := range numbers {
+			if math.IsNaN(cn.float) == true || math.IsInf(cn.float, 0) == true {
+				return nil, ErrNotCoercible
+			}
+
+			converted[i] = cn.float
+			isLossy = isLossy || cn.isInexact(converted[i])
+		}
+
+		coerced = converted
+	case TypeRational:
+		converted := make([]Rational, len(numbers))
+		for i, cn := range numbers {
+			numerator, denominator, lossy, err := cn.toRational(0, math.MaxUint32, maxDenominator)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = Rational{Numerator: uint32(numerator), Denominator: uint32(denominator)}
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	case TypeSignedRational:
+		converted := make([]SignedRational, len(numbers))
+		for i, cn := range numbers {
+			numerator, denominator, lossy, err := cn.toRational(math.MinInt32, math.MaxInt32, maxDenominator)
+			if err != nil {
+				return nil, err
+			}
+
+			converted[i] = SignedRational{Numerator: int32(numerator), Denominator: int32(denominator)}
+			isLossy = isLossy || lossy
+		}
+
+		coerced = converted
+	default:
+		return nil, ErrNotCoercible
+	}
+
+	if isLossy == true && options.ForbidLossy == true {
+		return nil, ErrLossyCoercion
+	}
+
+	return coerced, nil
+}
+
+// coercionNumbers returns the individual values of a single value or a slice.
+func coercionNumbers(value interface{}) (numbers []coercionNumber, err error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		cn, err := newCoercionNumber(rv)
+		if err != nil {
+			return nil, err
+		}
+
+		return []coercionNumber{cn}, nil
+	}
+
+	numbers = make([]coercionNumber, rv.Len())
+	for i := range numbers {
+		numbers[i], err = newCoercionNumber(rv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return numbers, nil
+}
+
+func newCoercionNumber(rv reflect.Value) (cn coercionNumber, err error) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		cn.isInteger = true
+		cn.integer = rv.Int()
+		cn.float = float64(cn.integer)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return cn, ErrNotCoercible
+		}
+
+		cn.isInteger = true
+		cn.integer = int64(rv.Uint())
+		cn.float = float64(cn.integer)
+	case reflect.Float32, reflect.Float64:
+		cn.float = rv.Float()
+	case reflect.Struct:
+		switch t := rv.Interface().(type) {
+		case Rational:
+			cn.numerator = int64(t.Numerator)
+			cn.denominator = int64(t.Denominator)
+		case SignedRational:
+			cn.numerator = int64(t.Numerator)
+			cn.denominator = int64(t.Denominator)
+		default:
+			return cn, ErrNotCoercible
+		}
+
+		cn.isRational = true
+
+		if cn.denominator < 0 {
+			cn.numerator = -cn.numerator
+			cn.denominator = -cn.denominator
+		}
+
+		if cn.denominator == 0 {
+			cn.float = math.NaN()
+		} else {
+			cn.float = float64(cn.numerator) / float64(cn.denominator)
+		}
+	default:
+		return cn, ErrNotCoercible
+	}
+
+	return cn, nil
+}
+
+// isInexact returns true if the given float doesn't represent the number
+// exactly.
+func (cn coercionNumber) isInexact(converted float64) bool {
+	if cn.isInteger == true {
+		return converted != float64(cn.integer) || int64(converted) != cn.integer
+	} else if cn.isRational == true {
+		return converted*float64(cn.denominator) != float64(cn.numerator)
+	}
+
+	return converted != cn.float
+}
+
+// toInteger returns the number as an integer in the given range.
+func (cn coercionNumber) toInteger(min, max int64) (integer int64, lossy bool, err error) {
+	if cn.isInteger == true {
+		integer = cn.integer
+	} else if cn.isRational == true && cn.denominator != 0 && cn.numerator%cn.denominator == 0 {
+		integer = cn.numerator / cn.denominator
+	} else {
+		if math.IsNaN(cn.float) == true || math.IsInf(cn.float, 0) == true {
+			return 0, false, ErrNotCoercible
+		}
+
+		rounded := math.Round(cn.float)
+		if rounded < float64(min) || rounded > float64(max) {
+			return 0, false, ErrNotCoercible
+		}
+
+		return int64(rounded), true, nil
+	}
+
+	if integer < min || integer > max {
+		return 0, false, ErrNotCoercible
+	}
+
+	return integer, false, nil
+}
+
+// toRational returns the number as a fraction whose parts are in the given
+// range. Floats are approximated.
+func (cn coercionNumber) toRational(min, max int64, maxDenominator uint64) (numerator, denominator int64, lossy bool, err error) {
+	if cn.isInteger == true {
+		if cn.integer < min || cn.integer > max {
+			return 0, 0, false, ErrNotCoercible
+		}
+
+		return cn.integer, 1, false, nil
+	} else if cn.isRational == true {
+		if cn.numerator < min || cn.numerator > max || cn.denominator > max {
+			return 0, 0, false, ErrNotCoercible
+		}
+
+		return cn.numerator, cn.denominator, false, nil
+	}
+
+	if math.IsNaN(cn.float) == true || math.IsInf(cn.float, 0) == true {
+		return 0, 0, false, ErrNotCoercible
+	}
+
+	magnitude := math.Abs(cn.float)
+
+	limit := max
+	if cn.float < 0 {
+		limit = -min
+	}
+
+	if (cn.float < 0 && min == 0) || magnitude > float64(limit) {
+		return 0, 0, false, ErrNotCoercible
+	}
+
+	if maxDenominator > uint64(max) {
+		maxDenominator = uint64(max)
+	}
+
+	n, d := approximateRational(magnitude, uint64(limit), maxDenominator)
+
+	numerator = int64(n)
+	denominator = int64(d)
+
+	if cn.float < 0 {
+		numerator = -numerator
+	}
+
+	lossy = float64(numerator)/float64(denominator) != cn.float
+
+	return numerator, denominator, lossy, nil
+}
+
+// approximateRational returns the fraction closest to the given non-negative
+// value whose parts don't exceed the given limits, using the convergents and
+// semiconvergents of its continued fraction.
+func approximateRational(value float64, maxNumerator, maxDenominator uint64) (numerator, denominator uint64) {
+	h0, h1 := uint64(0), uint64(1)
+	k0, k1 := uint64(1), uint64(0)
+
+	x := value
+	for i := 0; i < 64; i++ {
+		a := math.Floor(x)
+
+		hNext := a*float64(h1) + float64(h0)
+		kNext := a*float64(k1) + float64(k0)
+
+		if hNext > float64(maxNumerator) || kNext > float64(maxDenominator) {
+			// The next convergent doesn't fit. The best that does is either
+			// the last convergent or the largest semiconvergent between them.
+			t := a
+			if k1 > 0 {
+				t = math.Min(t, math.Floor(float64(maxDenominator-k0)/float64(k1)))
+			}
+
+			if h1 > 0 {
+				t = math.Min(t, math.Floor(float64(maxNumerator-h0)/float64(h1)))
+			}
+
+			if t >= 1 {
+				hs := uint64(t)*h1 + h0
+				ks := uint64(t)*k1 + k0
+
+				if math.Abs(value-float64(hs)/float64(ks)) < math.Abs(value-float64(h1)/float64(k1)) {
+					return hs, ks
+				}
+			}
+
+			break
+		}
+
+		h0, h1 = h1, uint64(hNext)
+		k0, k1 = k1, uint64(kNext)
+
+		fraction := x - a
+		if fraction == 0 || float64(h1)/float64(k1) == value {
+			break
+		}
+
+		x = 1 / fraction
+	}
+
+	return h1, k1
+}
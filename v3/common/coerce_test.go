@@ -0,0 +1,107 @@
+package exifcommon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestCoerceValue(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		tagType  TagTypePrimitive
+		expected interface{}
+	}{
+		{5, TypeShort, []uint16{5}},
+		{[]int{1, 2}, TypeLong, []uint32{1, 2}},
+		{[]uint16{1, 2}, TypeLong, []uint32{1, 2}},
+		{uint32(70000), TypeLong, []uint32{70000}},
+		{-5, TypeSignedLong, []int32{-5}},
+		{2.0, TypeByte, []uint8{2}},
+		{2.6, TypeShort, []uint16{3}},
+		{Rational{Numerator: 10, Denominator: 2}, TypeShort, []uint16{5}},
+		{3, TypeRational, []Rational{{Numerator: 3, Denominator: 1}}},
+		{0.5, TypeRational, []Rational{{Numerator: 1, Denominator: 2}}},
+		{-0.25, TypeSignedRational, []SignedRational{{Numerator: -1, Denominator: 4}}},
+		{SignedRational{Numerator: 1, Denominator: 3}, TypeRational, []Rational{{Numerator: 1, Denominator: 3}}},
+		{3.14159265358979, TypeRational, []Rational{{Numerator: 3126535, Denominator: 995207}}},
+		{1, TypeDouble, []float64{1}},
+		{1.5, TypeFloat, []float32{1.5}},
+		{Rational{Numerator: 1, Denominator: 4}, TypeDouble, []float64{0.25}},
+		{"text", TypeAscii, "text"},
+		{[]byte{1, 2}, TypeUndefined, []byte{1, 2}},
+	}
+
+	for i, c := range cases {
+		coerced, err := CoerceValue(c.value, c.tagType, CoercionOptions{})
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(coerced, c.expected) != true {
+			t.Fatalf("Case (%d) not correct: %v (%T) != %v (%T)", i, coerced, coerced, c.expected, c.expected)
+		}
+	}
+}
+
+func TestCoerceValue_MaxDenominator(t *testing.T) {
+	coerced, err := CoerceValue(3.14159265358979, TypeRational, CoercionOptions{MaxDenominator: 100})
+	log.PanicIf(err)
+
+	expected := []Rational{{Numerator: 311, Denominator: 99}}
+	if reflect.DeepEqual(coerced, expected) != true {
+		t.Fatalf("Approximation not correct: %v", coerced)
+	}
+}
+
+func TestCoerceValue_NotCoercible(t *testing.T) {
+	cases := []struct {
+		value   interface{}
+		tagType TagTypePrimitive
+	}{
+		{70000, TypeShort},
+		{-1, TypeLong},
+		{256.0, TypeByte},
+		{-0.5, TypeRational},
+		{"text", TypeShort},
+		{[]interface{}{1}, TypeShort},
+		{Rational{Numerator: 1 << 31, Denominator: 1}, TypeSignedRational},
+	}
+
+	for i, c := range cases {
+		_, err := CoerceValue(c.value, c.tagType, CoercionOptions{})
+		if err != ErrNotCoercible {
+			t.Fatalf("Case (%d) expected not-coercible error: %v", i, err)
+		}
+	}
+}
+
+func TestCoerceValue_ForbidLossy(t *testing.T) {
+	options := CoercionOptions{
+		ForbidLossy: true,
+	}
+
+	lossy := []struct {
+		value   interface{}
+		tagType TagTypePrimitive
+	}{
+		{2.5, TypeShort},
+		{0.1, TypeFloat},
+		{3.14159265358979, TypeRational},
+		{Rational{Numerator: 1, Denominator: 3}, TypeLong},
+		{int64(1<<53 + 1), TypeDouble},
+	}
+
+	for i, c := range lossy {
+		_, err := CoerceValue(c.value, c.tagType, options)
+		if err != ErrLossyCoercion {
+			t.Fatalf("Case (%d) expected lossy error: %v", i, err)
+		}
+	}
+
+	coerced, err := CoerceValue(0.375, TypeRational, options)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(coerced, []Rational{{Numerator: 3, Denominator: 8}}) != true {
+		t.Fatalf("Exact value not correct: %v", coerced)
+	}
+}
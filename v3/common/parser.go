@@ -60,7 +60,12 @@ func (p *Parser) ParseAscii(data []byte, unitCount uint32) (value string, err er
 		log.Panic(ErrNotEnoughData)
 	}
 
-	if len(data) == 0 || data[count-1] != 0 {
+	// Some writers emit empty strings without even a NUL.
+	if count == 0 {
+		return "", nil
+	}
+
+	if data[count-1] != 0 {
 		s := string(data[:count])
 		parserLogger.Warningf(nil, "ASCII not terminated with NUL as expected: [%v]", s)
 
@@ -84,6 +89,40 @@ func (p *Parser) ParseAscii(data []byte, unitCount uint32) (value string, err er
 	return string(data[:count-1]), nil
 }
 
+// ParseAsciiList returns the NUL-terminated strings of an ASCII value. Some
+// tags legally hold more than one string, one after the other. The NUL at the
+// end of the last string may be missing. A value that holds a single string
+// returns a list of one.
+func (p *Parser) ParseAsciiList(data []byte, unitCount uint32) (value []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	count := int(unitCount)
+
+	if len(data) < (TypeAscii.Size() * count) {
+		log.Panic(ErrNotEnoughData)
+	}
+
+	value = make([]string, 0)
+
+	remaining := data[:count]
+	for len(remaining) > 0 {
+		i := bytes.IndexByte(remaining, 0)
+		if i == -1 {
+			value = append(value, string(remaining))
+			break
+		}
+
+		value = append(value, string(remaining[:i]))
+		remaining = remaining[i+1:]
+	}
+
+	return value, nil
+}
+
 // ParseAsciiNoNul returns a string without any consideration for a trailing NUL
 // character.
 func (p *Parser) ParseAsciiNoNul(data []byte, unitCount uint32) (value string, err error) {
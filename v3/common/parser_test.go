@@ -72,6 +72,40 @@ func TestParser_ParseAscii(t *testing.T) {
 	}
 }
 
+func TestParser_ParseAscii__ZeroLength(t *testing.T) {
+	p := new(Parser)
+
+	value, err := p.ParseAscii([]byte{}, 0)
+	log.PanicIf(err)
+
+	if value != "" {
+		t.Fatalf("Zero-length value not empty: [%s]", value)
+	}
+}
+
+func TestParser_ParseAsciiList(t *testing.T) {
+	p := new(Parser)
+
+	cases := []struct {
+		encoded  string
+		expected []string
+	}{
+		{"abc\x00", []string{"abc"}},
+		{"abc\x00de\x00", []string{"abc", "de"}},
+		{"abc\x00\x00de", []string{"abc", "", "de"}},
+		{"", []string{}},
+	}
+
+	for _, c := range cases {
+		value, err := p.ParseAsciiList([]byte(c.encoded), uint32(len(c.encoded)))
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, c.expected) != true {
+			t.Fatalf("Value not correct for [%q]: %q", c.encoded, value)
+		}
+	}
+}
+
 func TestParser_ParseAsciiNoNul(t *testing.T) {
 	p := new(Parser)
 
@@ -1,10 +1,12 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exifcommon
 
 import (
 	"os"
 	"path"
 
-	"encoding/binary"
 	"io/ioutil"
 
 	"github.com/dsoprea/go-logging"
@@ -14,12 +16,6 @@ var (
 	moduleRootPath = ""
 
 	testExifData []byte = nil
-
-	// EncodeDefaultByteOrder is the default byte-order for encoding operations.
-	EncodeDefaultByteOrder = binary.BigEndian
-
-	// Default byte order for tests.
-	TestDefaultByteOrder = binary.BigEndian
 )
 
 func GetModuleRootPath() string {
@@ -0,0 +1,13 @@
+package exifcommon
+
+import (
+	"encoding/binary"
+)
+
+// These don't read files, so they are available with the "exif_nofile" build
+// tag, too.
+
+var (
+	// Default byte order for tests.
+	TestDefaultByteOrder = binary.BigEndian
+)
@@ -8,11 +8,16 @@ import (
 	"strings"
 	"time"
 
+	"encoding/binary"
+
 	"github.com/dsoprea/go-logging"
 )
 
 var (
 	timeType = reflect.TypeOf(time.Time{})
+
+	// EncodeDefaultByteOrder is the default byte-order for encoding operations.
+	EncodeDefaultByteOrder = binary.BigEndian
 )
 
 // DumpBytes prints a list of hex-encoded bytes.
@@ -17,6 +17,10 @@ var (
 	// ErrNotFarValue indicates that an offset-based lookup was attempted for a
 	// non-offset-based (embedded) value.
 	ErrNotFarValue = errors.New("not a far value")
+
+	// ErrNoSourceData indicates that a value can't be returned without copying
+	// because the data that it was read from is not held in memory.
+	ErrNoSourceData = errors.New("source data not available")
 )
 
 // ValueContext embeds all of the parameters required to find and extract the
@@ -36,6 +40,10 @@ type ValueContext struct {
 
 	ifdPath string
 	tagId   uint16
+
+	// data is the block that `rs` reads from, if it is held in memory (see
+	// `SetSourceData()`).
+	data []byte
 }
 
 // TODO(dustin): We can update newValueContext() to derive `valueOffset` itself (from `rawValueOffset`).
@@ -65,6 +73,13 @@ func (vc *ValueContext) SetUndefinedValueType(tagType TagTypePrimitive) {
 	vc.undefinedValueTagType = tagType
 }
 
+// SetSourceData sets the block of data that the value offsets are relative to,
+// for `ValueBytesNoCopy()`. It must have the same content as
+// `AddressableData()`.
+func (vc *ValueContext) SetSourceData(data []byte) {
+	vc.data = data
+}
+
 // UnitCount returns the embedded unit-count.
 func (vc *ValueContext) UnitCount() uint32 {
 	return vc.unitCount
@@ -111,7 +126,7 @@ func (vc *ValueContext) TagId() uint16 {
 func (vc *ValueContext) isEmbedded() bool {
 	tagType := vc.effectiveValueType()
 
-	return (tagType.Size() * int(vc.unitCount)) <= 4
+	return uint64(tagType.Size())*uint64(vc.unitCount) <= 4
 }
 
 // SizeInBytes returns the number of bytes that this value requires. The
@@ -151,15 +166,40 @@ func (vc *ValueContext) readRawEncoded() (rawBytes []byte, err error) {
 
 	unitSizeRaw := uint32(tagType.Size())
 
+	// The unit-count comes straight from the data. Don't let a huge count
+	// wrap around to a small size.
+	byteLength, err := CheckedMulUint32(unitSizeRaw, vc.unitCount)
+	log.PanicIf(err)
+
 	if vc.isEmbedded() == true {
-		byteLength := unitSizeRaw * vc.unitCount
-		return vc.rawValueOffset[:byteLength], nil
+		rawBytes = make([]byte, byteLength)
+		copy(rawBytes, vc.rawValueOffset)
+
+		return rawBytes, nil
+	}
+
+	end, err := CheckedAddUint32(vc.valueOffset, byteLength)
+	log.PanicIf(err)
+
+	// Make sure that the data is actually there before allocating for it. A
+	// corrupted count would otherwise have us allocate gigabytes.
+
+	var available int64
+	if vc.data != nil {
+		available = int64(len(vc.data))
+	} else {
+		available, err = vc.rs.Seek(0, io.SeekEnd)
+		log.PanicIf(err)
+	}
+
+	if int64(end) > available {
+		log.Panicf("value extends past the end of the data: (%d) > (%d)", end, available)
 	}
 
 	_, err = vc.rs.Seek(int64(vc.valueOffset), io.SeekStart)
 	log.PanicIf(err)
 
-	rawBytes = make([]byte, vc.unitCount*unitSizeRaw)
+	rawBytes = make([]byte, byteLength)
 
 	_, err = io.ReadFull(vc.rs, rawBytes)
 	log.PanicIf(err)
@@ -178,6 +218,7 @@ func (vc *ValueContext) GetFarOffset() (offset uint32, err error) {
 }
 
 // ReadRawEncoded returns the encoded bytes for the value that we represent.
+// These are always a copy, which the caller owns and may modify.
 func (vc *ValueContext) ReadRawEncoded() (rawBytes []byte, err error) {
 
 	// TODO(dustin): Remove this method and rename readRawEncoded in its place.
@@ -185,6 +226,47 @@ func (vc *ValueContext) ReadRawEncoded() (rawBytes []byte, err error) {
 	return vc.readRawEncoded()
 }
 
+// ValueBytesNoCopy returns the encoded bytes for the value without copying
+// them. For performance-critical, read-only access only:
+//
+//   - The bytes are shared with the data that was parsed (or, for embedded
+//     values, with the entry) and must NOT be modified. Doing so changes what
+//     every other reader of that data sees.
+//   - They are only valid for as long as that data is, which matters if it
+//     came from a reused buffer (see `ScanOptions.BufferPool`).
+//
+// The capacity of the slice is limited to the value, so appending to it
+// always copies. Use `ReadRawEncoded()` for a copy that the caller owns.
+// `ErrNoSourceData` is returned if the data was read from a stream rather than
+// from memory.
+func (vc *ValueContext) ValueBytesNoCopy() (rawBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	tagType := vc.effectiveValueType()
+
+	byteLength, err := CheckedMulUint32(uint32(tagType.Size()), vc.unitCount)
+	log.PanicIf(err)
+
+	if vc.isEmbedded() == true {
+		return vc.rawValueOffset[:byteLength:byteLength], nil
+	} else if vc.data == nil {
+		return nil, ErrNoSourceData
+	}
+
+	end, err := CheckedAddUint32(vc.valueOffset, byteLength)
+	log.PanicIf(err)
+
+	if uint64(end) > uint64(len(vc.data)) {
+		log.Panicf("value extends past the end of the data: (%d) > (%d)", end, len(vc.data))
+	}
+
+	return vc.data[vc.valueOffset:end:end], nil
+}
+
 // Format returns a string representation for the value.
 //
 // Where the type is not ASCII, `justFirst` indicates whether to just stringify
@@ -263,6 +345,24 @@ func (vc *ValueContext) ReadAscii() (value string, err error) {
 	return value, nil
 }
 
+// ReadAsciiList parses the encoded NUL-terminated ASCII strings from the
+// value-context. See `Parser.ParseAsciiList()`.
+func (vc *ValueContext) ReadAsciiList() (value []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawValue, err := vc.readRawEncoded()
+	log.PanicIf(err)
+
+	value, err = parser.ParseAsciiList(rawValue, vc.unitCount)
+	log.PanicIf(err)
+
+	return value, nil
+}
+
 // ReadAsciiNoNul parses the non-NUL-terminated encoded ASCII string from the
 // value-context.
 func (vc *ValueContext) ReadAsciiNoNul() (value string, err error) {
@@ -392,6 +392,122 @@ func TestValueContext_readRawEncoded__IsRelative(t *testing.T) {
 	}
 }
 
+func TestValueContext_ReadRawEncoded__IsCopy(t *testing.T) {
+	rawValueOffset := []byte{1, 2, 3, 4}
+
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		4,
+		0,
+		rawValueOffset,
+		rifs.NewSeekableBufferWithBytes([]byte{}),
+		TypeByte,
+		TestDefaultByteOrder)
+
+	recovered, err := vc.ReadRawEncoded()
+	log.PanicIf(err)
+
+	recovered[0] = 99
+
+	if bytes.Equal(rawValueOffset, []byte{1, 2, 3, 4}) != true {
+		t.Fatalf("Modifying the copy modified the entry: %v", rawValueOffset)
+	}
+}
+
+func TestValueContext_ValueBytesNoCopy__IsRelative(t *testing.T) {
+	addressableData := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		5,
+		4,
+		[]byte{0, 0, 0, 4},
+		rifs.NewSeekableBufferWithBytes(addressableData),
+		TypeByte,
+		TestDefaultByteOrder)
+
+	_, err := vc.ValueBytesNoCopy()
+	if err != ErrNoSourceData {
+		t.Fatalf("Expected no-source-data error: %v", err)
+	}
+
+	vc.SetSourceData(addressableData)
+
+	recovered, err := vc.ValueBytesNoCopy()
+	log.PanicIf(err)
+
+	if bytes.Equal(recovered, []byte{5, 6, 7, 8, 9}) != true {
+		t.Fatalf("Value not correct: %v", recovered)
+	} else if &recovered[0] != &addressableData[4] {
+		t.Fatalf("Value was copied.")
+	}
+
+	// Appending must not overwrite the bytes that follow the value.
+
+	_ = append(recovered, 0)
+
+	if addressableData[9] != 10 {
+		t.Fatalf("Appending to the value modified the source data: %v", addressableData)
+	}
+
+	// Nor does reading a copy share anything with it.
+
+	copied, err := vc.ReadRawEncoded()
+	log.PanicIf(err)
+
+	copied[0] = 99
+
+	if recovered[0] != 5 || addressableData[4] != 5 {
+		t.Fatalf("Modifying the copy modified the source data: %v", addressableData)
+	}
+}
+
+func TestValueContext_ValueBytesNoCopy__IsEmbedded(t *testing.T) {
+	rawValueOffset := []byte{1, 2, 3, 4}
+
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		2,
+		0,
+		rawValueOffset,
+		rifs.NewSeekableBufferWithBytes([]byte{}),
+		TypeByte,
+		TestDefaultByteOrder)
+
+	recovered, err := vc.ValueBytesNoCopy()
+	log.PanicIf(err)
+
+	if bytes.Equal(recovered, []byte{1, 2}) != true {
+		t.Fatalf("Value not correct: %v", recovered)
+	} else if cap(recovered) != 2 {
+		t.Fatalf("Capacity not limited to the value: (%d)", cap(recovered))
+	}
+}
+
+func TestValueContext_ValueBytesNoCopy__PastEnd(t *testing.T) {
+	addressableData := []byte{1, 2, 3, 4, 5, 6}
+
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		5,
+		4,
+		[]byte{0, 0, 0, 4},
+		rifs.NewSeekableBufferWithBytes(addressableData),
+		TypeByte,
+		TestDefaultByteOrder)
+
+	vc.SetSourceData(addressableData)
+
+	_, err := vc.ValueBytesNoCopy()
+	if err == nil {
+		t.Fatalf("Expected error for value past the end of the data.")
+	}
+}
+
 func TestValueContext_Format__Byte(t *testing.T) {
 	unitCount := uint32(8)
 
@@ -1306,3 +1422,74 @@ func TestValueContext_Values__SignedRational(t *testing.T) {
 		t.Fatalf("Values not correct (signed rationals): %v", value)
 	}
 }
+
+func TestValueContext_ReadRawEncoded__UnitCountOverflow(t *testing.T) {
+	rawValueOffset := []byte{0, 0, 0, 0}
+	sb := rifs.NewSeekableBufferWithBytes(make([]byte, 100))
+
+	// (4 * 0x40000001) wraps to (4) in 32 bits.
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		0x40000001,
+		0,
+		rawValueOffset,
+		sb,
+		TypeLong,
+		TestDefaultByteOrder)
+
+	_, err := vc.ReadRawEncoded()
+	if log.Is(err, ErrArithmeticOverflow) != true {
+		t.Fatalf("Expected overflow: %v", err)
+	}
+}
+
+func TestValueContext_ReadRawEncoded__OffsetOverflow(t *testing.T) {
+	rawValueOffset := []byte{0xff, 0xff, 0xff, 0xfe}
+	sb := rifs.NewSeekableBufferWithBytes(make([]byte, 100))
+
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		2,
+		0xfffffffe,
+		rawValueOffset,
+		sb,
+		TypeLong,
+		TestDefaultByteOrder)
+
+	_, err := vc.ReadRawEncoded()
+	if log.Is(err, ErrArithmeticOverflow) != true {
+		t.Fatalf("Expected overflow: %v", err)
+	}
+}
+
+func TestValueContext_ReadRawEncoded__PastEnd(t *testing.T) {
+	rawValueOffset := []byte{0, 0, 0, 8}
+	data := make([]byte, 100)
+	sb := rifs.NewSeekableBufferWithBytes(data)
+
+	// A corrupted count that asks for about 3GB. This has to fail before
+	// allocating anything.
+	vc := NewValueContext(
+		"aa/bb",
+		0x1234,
+		788529153,
+		8,
+		rawValueOffset,
+		sb,
+		TypeLong,
+		TestDefaultByteOrder)
+
+	_, err := vc.ReadRawEncoded()
+	if err == nil {
+		t.Fatalf("Expected error for value past the end of the data.")
+	}
+
+	vc.SetSourceData(data)
+
+	_, err = vc.ReadRawEncoded()
+	if err == nil {
+		t.Fatalf("Expected error for value past the end of the source data.")
+	}
+}
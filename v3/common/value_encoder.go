@@ -55,6 +55,22 @@ func (ve *ValueEncoder) encodeAscii(value string) (ed EncodedData, err error) {
 	return ed, nil
 }
 
+// encodeAsciiList encodes several strings into one ASCII value, each
+// terminated with a NUL.
+func (ve *ValueEncoder) encodeAsciiList(value []string) (ed EncodedData, err error) {
+	ed.Type = TypeAscii
+
+	ed.Encoded = make([]byte, 0)
+	for _, s := range value {
+		ed.Encoded = append(ed.Encoded, s...)
+		ed.Encoded = append(ed.Encoded, 0)
+	}
+
+	ed.UnitCount = uint32(len(ed.Encoded))
+
+	return ed, nil
+}
+
 // encodeAsciiNoNul returns a string encoded as a byte-string without a trailing
 // NUL byte.
 //
@@ -221,7 +237,8 @@ func (ve *ValueEncoder) encodeSignedRationals(value []SignedRational) (ed Encode
 
 // Encode returns bytes for the given value, infering type from the actual
 // value. This does not support `TypeAsciiNoNull` (all strings are encoded as
-// `TypeAscii`).
+// `TypeAscii`). A `[]string` is encoded as one ASCII value with several
+// NUL-terminated strings.
 func (ve *ValueEncoder) Encode(value interface{}) (ed EncodedData, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -236,6 +253,9 @@ func (ve *ValueEncoder) Encode(value interface{}) (ed EncodedData, err error) {
 	case string:
 		ed, err = ve.encodeAscii(t)
 		log.PanicIf(err)
+	case []string:
+		ed, err = ve.encodeAsciiList(t)
+		log.PanicIf(err)
 	case []uint16:
 		ed, err = ve.encodeShorts(t)
 		log.PanicIf(err)
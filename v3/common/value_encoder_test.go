@@ -72,6 +72,32 @@ func TestValueEncoder_encodeAscii__Cycle(t *testing.T) {
 	}
 }
 
+func TestValueEncoder_Encode__AsciiList(t *testing.T) {
+	ve := NewValueEncoder(TestDefaultByteOrder)
+
+	original := []string{"abc", "", "de"}
+
+	ed, err := ve.Encode(original)
+	log.PanicIf(err)
+
+	if ed.Type != TypeAscii {
+		t.Fatalf("Type not correct: [%s]", ed.Type)
+	} else if bytes.Equal(ed.Encoded, []byte("abc\x00\x00de\x00")) != true {
+		t.Fatalf("Encoding not correct: %q", ed.Encoded)
+	} else if ed.UnitCount != 8 {
+		t.Fatalf("Unit-count not correct: (%d)", ed.UnitCount)
+	}
+
+	p := new(Parser)
+
+	recovered, err := p.ParseAsciiList(ed.Encoded, ed.UnitCount)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recovered, original) != true {
+		t.Fatalf("Value not recovered: %q", recovered)
+	}
+}
+
 func TestValueEncoder_encodeAsciiNoNul__Cycle(t *testing.T) {
 	byteOrder := TestDefaultByteOrder
 	ve := NewValueEncoder(byteOrder)
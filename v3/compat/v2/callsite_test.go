@@ -0,0 +1,104 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/compat/v2"
+	"github.com/dsoprea/go-exif/v3/compat/v2/common"
+
+	exifcommonv3 "github.com/dsoprea/go-exif/v3/common"
+)
+
+// TestV2CallSites compiles code written against the v2 API, as taken from
+// the v2 tests, with only the import paths changed.
+func TestV2CallSites(t *testing.T) {
+	defer func() {
+		if state := recover(); state != nil {
+			err := log.Wrap(state.(error))
+			log.PrintError(err)
+
+			t.Fatalf("Test failure.")
+		}
+	}()
+
+	ti := exif.NewTagIndex()
+
+	testImageFilepath := path.Join(exifcommonv3.GetTestAssetsPath(), "NDM_8901.jpg")
+
+	f, err := os.Open(testImageFilepath)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	log.PanicIf(err)
+
+	rawExif, err := exif.SearchAndExtractExif(data)
+	log.PanicIf(err)
+
+	im := exif.NewIfdMappingWithStandard()
+
+	tags := make([]string, 0)
+
+	visitor := func(fqIfdPath string, ifdIndex int, ite *exif.IfdTagEntry) (err error) {
+		defer func() {
+			if state := recover(); state != nil {
+				err = log.Wrap(state.(error))
+				log.Panic(err)
+			}
+		}()
+
+		tagId := ite.TagId()
+		ii := ite.IfdIdentity()
+
+		it, err := ti.Get(ii, tagId)
+		if err != nil {
+			if log.Is(err, exif.ErrTagNotFound) {
+				return nil
+			}
+
+			log.Panic(err)
+		}
+
+		description := fmt.Sprintf("IFD-PATH=[%s] ID=(0x%04x) NAME=[%s]", fqIfdPath, tagId, it.Name)
+		tags = append(tags, description)
+
+		return nil
+	}
+
+	eh, furthestOffset, err := exif.Visit(exifcommon.IfdStandardIfdIdentity, im, ti, rawExif, visitor)
+	log.PanicIf(err)
+
+	if furthestOffset == 0 {
+		t.Fatalf("Furthest-offset is not valid: (%d)", furthestOffset)
+	} else if tags[0] != "IFD-PATH=[IFD] ID=(0x010f) NAME=[Make]" {
+		t.Fatalf("First tag not correct: [%s]", tags[0])
+	}
+
+	ie := exif.NewIfdEnumerate(im, ti, rawExif, eh.ByteOrder)
+
+	var med *exif.MiscellaneousExifData
+	med, err = ie.Scan(exifcommon.IfdPathStandard, eh.FirstIfdOffset, visitor)
+	log.PanicIf(err)
+
+	if len(med.UnknownTags()) != 0 {
+		t.Fatalf("Unknown tags not expected: %v", med.UnknownTags())
+	}
+
+	mi, err := im.GetWithPath(exifcommon.IfdPathStandardGps.UnindexedString())
+	log.PanicIf(err)
+
+	if mi.TagId != exifcommon.IfdGpsInfoStandardIfdIdentity.TagId() {
+		t.Fatalf("GPS tag-ID not correct: (0x%04x)", mi.TagId)
+	}
+}
@@ -0,0 +1,112 @@
+// Package exifcommon provides the parts of the dsoprea/go-exif v2 "common"
+// package API whose signatures changed in v3, implemented on top of v3.
+// Projects that imported "github.com/dsoprea/go-exif/v2/common" can import
+// this package instead without changing their call sites. Everything whose
+// signature didn't change is aliased to the v3 definition so values can be
+// passed freely between the two.
+package exifcommon
+
+import (
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/v2/filesystem"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// IfdStandardIfdIdentity is the v3 identity of IFD0.
+	IfdStandardIfdIdentity = exifcommon.IfdStandardIfdIdentity
+
+	// IfdExifStandardIfdIdentity is the v3 identity of IFD0/Exif0.
+	IfdExifStandardIfdIdentity = exifcommon.IfdExifStandardIfdIdentity
+
+	// IfdExifIopStandardIfdIdentity is the v3 identity of IFD0/Exif0/Iop0.
+	IfdExifIopStandardIfdIdentity = exifcommon.IfdExifIopStandardIfdIdentity
+
+	// IfdGpsInfoStandardIfdIdentity is the v3 identity of IFD0/GPSInfo0.
+	IfdGpsInfoStandardIfdIdentity = exifcommon.IfdGpsInfoStandardIfdIdentity
+
+	// Ifd1StandardIfdIdentity is the v3 identity of IFD1.
+	Ifd1StandardIfdIdentity = exifcommon.Ifd1StandardIfdIdentity
+)
+
+var (
+	// These are identities rather than paths, as in v2.
+
+	IfdPathStandard        = IfdStandardIfdIdentity
+	IfdPathStandardExif    = IfdExifStandardIfdIdentity
+	IfdPathStandardExifIop = IfdExifIopStandardIfdIdentity
+	IfdPathStandardGps     = IfdGpsInfoStandardIfdIdentity
+)
+
+type (
+	// IfdMapping is an alias of the v3 type.
+	IfdMapping = exifcommon.IfdMapping
+
+	// IfdIdentity is an alias of the v3 type.
+	IfdIdentity = exifcommon.IfdIdentity
+
+	// ValueContext is an alias of the v3 type.
+	ValueContext = exifcommon.ValueContext
+
+	// TagTypePrimitive is an alias of the v3 type.
+	TagTypePrimitive = exifcommon.TagTypePrimitive
+
+	// Rational is an alias of the v3 type.
+	Rational = exifcommon.Rational
+
+	// SignedRational is an alias of the v3 type.
+	SignedRational = exifcommon.SignedRational
+)
+
+// Tag types, as defined by v3.
+const (
+	TypeByte           = exifcommon.TypeByte
+	TypeAscii          = exifcommon.TypeAscii
+	TypeShort          = exifcommon.TypeShort
+	TypeLong           = exifcommon.TypeLong
+	TypeRational       = exifcommon.TypeRational
+	TypeUndefined      = exifcommon.TypeUndefined
+	TypeSignedLong     = exifcommon.TypeSignedLong
+	TypeSignedRational = exifcommon.TypeSignedRational
+	TypeAsciiNoNul     = exifcommon.TypeAsciiNoNul
+)
+
+var (
+	// EncodeDefaultByteOrder is the byte-order used when encoding by default.
+	EncodeDefaultByteOrder = exifcommon.EncodeDefaultByteOrder
+
+	// NewIfdMapping is the v3 function, which is unchanged.
+	NewIfdMapping = exifcommon.NewIfdMapping
+
+	// LoadStandardIfds is the v3 function, which is unchanged.
+	LoadStandardIfds = exifcommon.LoadStandardIfds
+)
+
+// NewIfdMappingWithStandard returns a mapping populated with the standard
+// IFDs. Unlike v3, it panics rather than returning an error, as in v2.
+func NewIfdMappingWithStandard() (ifdMapping *IfdMapping) {
+	ifdMapping, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	return ifdMapping
+}
+
+// NewValueContext returns a value-context for a tag whose out-of-line value
+// is found in `addressableData`, as in v2. In v3 the data is read from a
+// ReadSeeker instead.
+func NewValueContext(ifdPath string, tagId uint16, unitCount, valueOffset uint32, rawValueOffset, addressableData []byte, tagType TagTypePrimitive, byteOrder binary.ByteOrder) *ValueContext {
+	sb := rifs.NewSeekableBufferWithBytes(addressableData)
+
+	return exifcommon.NewValueContext(
+		ifdPath,
+		tagId,
+		unitCount,
+		valueOffset,
+		rawValueOffset,
+		sb,
+		tagType,
+		byteOrder)
+}
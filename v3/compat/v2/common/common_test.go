@@ -0,0 +1,44 @@
+package exifcommon
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestNewIfdMappingWithStandard(t *testing.T) {
+	im := NewIfdMappingWithStandard()
+
+	mi, err := im.GetWithPath(IfdPathStandardGps.UnindexedString())
+	log.PanicIf(err)
+
+	if mi.TagId != IfdPathStandardGps.TagId() {
+		t.Fatalf("GPS tag-ID not correct: (0x%04x)", mi.TagId)
+	}
+}
+
+func TestNewValueContext(t *testing.T) {
+	addressableData := []byte{
+		0, 0, 0, 0,
+		'a', 'b', 'c', 'd', 'e', 'f', 0,
+	}
+
+	vc := NewValueContext(
+		IfdPathStandard.UnindexedString(),
+		0x010f,
+		7,
+		4,
+		[]byte{0, 0, 0, 4},
+		addressableData,
+		TypeAscii,
+		binary.BigEndian)
+
+	value, err := vc.ReadAscii()
+	log.PanicIf(err)
+
+	if value != "abcdef" {
+		t.Fatalf("Value not correct: [%s]", value)
+	}
+}
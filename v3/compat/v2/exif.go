@@ -0,0 +1,204 @@
+// Package exif provides the parts of the dsoprea/go-exif v2 root-package API
+// whose signatures changed in v3, implemented on top of v3. Projects that
+// imported "github.com/dsoprea/go-exif/v2" can import this package instead
+// without changing their call sites. Everything whose signature didn't change
+// is aliased to the v3 definition so values can be passed freely between the
+// two.
+package exif
+
+import (
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3"
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+type (
+	// ExifHeader is an alias of the v3 type.
+	ExifHeader = exif.ExifHeader
+
+	// ExifTag is an alias of the v3 type.
+	ExifTag = exif.ExifTag
+
+	// Ifd is an alias of the v3 type.
+	Ifd = exif.Ifd
+
+	// IfdIndex is an alias of the v3 type.
+	IfdIndex = exif.IfdIndex
+
+	// IfdTagEntry is an alias of the v3 type.
+	IfdTagEntry = exif.IfdTagEntry
+
+	// TagIndex is an alias of the v3 type.
+	TagIndex = exif.TagIndex
+
+	// IndexedTag is an alias of the v3 type.
+	IndexedTag = exif.IndexedTag
+
+	// IfdBuilder is an alias of the v3 type.
+	IfdBuilder = exif.IfdBuilder
+
+	// BuilderTag is an alias of the v3 type.
+	BuilderTag = exif.BuilderTag
+
+	// IfdByteEncoder is an alias of the v3 type.
+	IfdByteEncoder = exif.IfdByteEncoder
+
+	// GpsInfo is an alias of the v3 type.
+	GpsInfo = exif.GpsInfo
+
+	// GpsDegrees is an alias of the v3 type.
+	GpsDegrees = exif.GpsDegrees
+
+	// MiscellaneousExifData is an alias of the v3 type.
+	MiscellaneousExifData = exif.MiscellaneousExifData
+)
+
+var (
+	// ErrNoExif is the v3 error.
+	ErrNoExif = exif.ErrNoExif
+
+	// ErrTagNotFound is the v3 error.
+	ErrTagNotFound = exif.ErrTagNotFound
+
+	// ErrTagNotKnown is the v3 error.
+	ErrTagNotKnown = exif.ErrTagNotKnown
+
+	// SearchAndExtractExif is the v3 function, which is unchanged.
+	SearchAndExtractExif = exif.SearchAndExtractExif
+
+	// SearchAndExtractExifWithReader is the v3 function, which is unchanged.
+	SearchAndExtractExifWithReader = exif.SearchAndExtractExifWithReader
+
+	// ParseExifHeader is the v3 function, which is unchanged.
+	ParseExifHeader = exif.ParseExifHeader
+
+	// BuildExifHeader is the v3 function, which is unchanged.
+	BuildExifHeader = exif.BuildExifHeader
+
+	// Collect is the v3 function, which is unchanged.
+	Collect = exif.Collect
+
+	// NewTagIndex is the v3 function, which is unchanged.
+	NewTagIndex = exif.NewTagIndex
+
+	// NewIfdBuilder is the v3 function, which is unchanged.
+	NewIfdBuilder = exif.NewIfdBuilder
+
+	// NewIfdByteEncoder is the v3 function, which is unchanged.
+	NewIfdByteEncoder = exif.NewIfdByteEncoder
+)
+
+// TagVisitorFn is called for each tag by `Visit()` and `IfdEnumerate.Scan()`
+// with the fully-qualified path and index of the IFD that the tag was found
+// in, as in v2. In v3 these are available from the tag itself.
+type TagVisitorFn func(fqIfdPath string, ifdIndex int, ite *IfdTagEntry) (err error)
+
+// adapt returns a v3 visitor that calls the v2 visitor.
+func (visitor TagVisitorFn) adapt() exif.TagVisitorFn {
+	if visitor == nil {
+		return nil
+	}
+
+	return func(ite *IfdTagEntry) (err error) {
+		ii := ite.IfdIdentity()
+		return visitor(ii.String(), ii.Index(), ite)
+	}
+}
+
+// IfdEnumerate wraps the v3 enumerator with the v2 `Scan()` signature. All
+// other methods are inherited.
+type IfdEnumerate struct {
+	*exif.IfdEnumerate
+}
+
+// NewIfdEnumerate returns an enumerator over the given EXIF data, as in v2.
+// In v3 the data is read from an `ExifBlobSeeker` instead.
+func NewIfdEnumerate(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, exifData []byte, byteOrder binary.ByteOrder) *IfdEnumerate {
+	ebs := exif.NewExifReadSeekerWithBytes(exifData)
+
+	return &IfdEnumerate{
+		IfdEnumerate: exif.NewIfdEnumerate(ifdMapping, tagIndex, ebs, byteOrder),
+	}
+}
+
+// Scan enumerates the IFD chain starting at the given offset and calls the
+// visitor for every tag.
+func (ie *IfdEnumerate) Scan(iiRoot *exifcommon.IfdIdentity, ifdOffset uint32, visitor TagVisitorFn) (med *MiscellaneousExifData, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	med, err = ie.IfdEnumerate.Scan(iiRoot, ifdOffset, visitor.adapt(), nil)
+	log.PanicIf(err)
+
+	return med, nil
+}
+
+// Visit recursively invokes a callback for every tag, as in v2. v3 also takes
+// scan options.
+func Visit(rootIfdIdentity *exifcommon.IfdIdentity, ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, exifData []byte, visitor TagVisitorFn) (eh ExifHeader, furthestOffset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	eh, furthestOffset, err = exif.Visit(rootIfdIdentity, ifdMapping, tagIndex, exifData, visitor.adapt(), nil)
+	log.PanicIf(err)
+
+	return eh, furthestOffset, nil
+}
+
+// NewIfdMappingWithStandard returns a mapping populated with the standard
+// IFDs. It panics on failure, as in v2.
+func NewIfdMappingWithStandard() (ifdMapping *exifcommon.IfdMapping) {
+	ifdMapping, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	return ifdMapping
+}
+
+// LoadStandardIfds loads the standard IFDs into the mapping.
+func LoadStandardIfds(im *exifcommon.IfdMapping) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = exifcommon.LoadStandardIfds(im)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// NewIfdBuilderFromExistingChain creates a chain of IBs from an existing IFD
+// chain, as in v2. It panics on failure. v3 deprecates its own version of this
+// in favor of `NewIfdBuilderFromExistingChainWithReport()`, so this one is
+// built on that.
+func NewIfdBuilderFromExistingChain(rootIfd *Ifd) (firstIb *IfdBuilder) {
+	firstIb, _, err := exif.NewIfdBuilderFromExistingChainWithReport(rootIfd)
+	log.PanicIf(err)
+
+	return firstIb
+}
+
+// GetFlatExifData returns a simple, flat representation of all tags, as in
+// v2.
+func GetFlatExifData(exifData []byte) (exifTags []ExifTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	exifTags, _, err = exif.GetFlatExifData(exifData, nil)
+	log.PanicIf(err)
+
+	return exifTags, nil
+}
@@ -0,0 +1,95 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"path"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	exifcommonv2 "github.com/dsoprea/go-exif/v3/compat/v2/common"
+)
+
+func getTestExif() []byte {
+	testImageFilepath := path.Join(exifcommon.GetTestAssetsPath(), "NDM_8901.jpg")
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	return rawExif
+}
+
+func TestVisit(t *testing.T) {
+	rawExif := getTestExif()
+
+	im := exifcommonv2.NewIfdMappingWithStandard()
+	ti := NewTagIndex()
+
+	counts := make(map[string]int)
+	visitor := func(fqIfdPath string, ifdIndex int, ite *IfdTagEntry) (err error) {
+		if fqIfdPath != ite.IfdIdentity().String() {
+			t.Fatalf("IFD path not correct: [%s] != [%s]", fqIfdPath, ite.IfdIdentity().String())
+		}
+
+		counts[fqIfdPath]++
+		return nil
+	}
+
+	eh, furthestOffset, err := Visit(exifcommonv2.IfdStandardIfdIdentity, im, ti, rawExif, visitor)
+	log.PanicIf(err)
+
+	if eh.FirstIfdOffset != 8 {
+		t.Fatalf("First IFD offset not correct: (%d)", eh.FirstIfdOffset)
+	} else if furthestOffset == 0 {
+		t.Fatalf("Furthest offset not set.")
+	} else if counts["IFD"] == 0 || counts["IFD/Exif"] == 0 || counts["IFD1"] == 0 {
+		t.Fatalf("Not all IFDs were visited: %v", counts)
+	}
+}
+
+func TestIfdEnumerate_Scan(t *testing.T) {
+	rawExif := getTestExif()
+
+	eh, err := ParseExifHeader(rawExif)
+	log.PanicIf(err)
+
+	im := exifcommonv2.NewIfdMappingWithStandard()
+	ti := NewTagIndex()
+
+	ie := NewIfdEnumerate(im, ti, rawExif, eh.ByteOrder)
+
+	count := 0
+	med, err := ie.Scan(exifcommonv2.IfdPathStandard, eh.FirstIfdOffset, func(fqIfdPath string, ifdIndex int, ite *IfdTagEntry) (err error) {
+		count++
+		return nil
+	})
+
+	log.PanicIf(err)
+
+	if count == 0 {
+		t.Fatalf("No tags were visited.")
+	} else if med == nil {
+		t.Fatalf("No miscellaneous data was returned.")
+	}
+
+	index, err := ie.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	if index.RootIfd == nil {
+		t.Fatalf("Inherited Collect() did not work.")
+	}
+}
+
+func TestGetFlatExifData(t *testing.T) {
+	rawExif := getTestExif()
+
+	exifTags, err := GetFlatExifData(rawExif)
+	log.PanicIf(err)
+
+	if len(exifTags) == 0 {
+		t.Fatalf("No tags returned.")
+	}
+}
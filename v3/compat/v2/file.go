@@ -0,0 +1,13 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"github.com/dsoprea/go-exif/v3"
+)
+
+var (
+	// SearchFileAndExtractExif is the v3 function, which is unchanged.
+	SearchFileAndExtractExif = exif.SearchFileAndExtractExif
+)
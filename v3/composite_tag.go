@@ -0,0 +1,293 @@
+package exif
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// CompositeTagProviderName is the name of `CompositeTagProvider`. It is
+	// recorded as the `Provider` of every composite tag.
+	CompositeTagProviderName = "composite"
+
+	// CompositeIfdPath is the pseudo IFD-path that composite tags are
+	// associated with. It doesn't exist in the data; it marks the tags as
+	// synthetic and allows them to be qualified in `GetTags()` (e.g.
+	// "Composite/Megapixels").
+	CompositeIfdPath = "Composite"
+)
+
+// CompositeTagProvider computes read-only tags from combinations of stored
+// tags, in the manner of ExifTool's "Composite" group:
+//
+//	Megapixels        float64  image width times height, in millions
+//	ScaleFactor35efl  float64  35mm-equivalent focal length over focal length
+//	LightValue        float64  exposure value normalized to ISO 100
+//	GPSPosition       string   signed decimal latitude and longitude
+//	ShutterSpeed      float64  exposure time in seconds
+//
+// A tag is only produced if all of its inputs are present. This provider is
+// not registered by default:
+//
+//	exif.RegisterComputedTagProvider(exif.CompositeTagProvider{})
+type CompositeTagProvider struct{}
+
+// Name implements `ComputedTagProvider`.
+func (CompositeTagProvider) Name() string {
+	return CompositeTagProviderName
+}
+
+// Compute implements `ComputedTagProvider`.
+func (ctp CompositeTagProvider) Compute(index IfdIndex) (tags []ComputedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rootIfd := index.Lookup[exifcommon.IfdStandardIfdIdentity.String()]
+	exifIfd := index.Lookup[exifcommon.IfdExifStandardIfdIdentity.String()]
+	gpsIfd := index.Lookup[exifcommon.IfdGpsInfoStandardIfdIdentity.String()]
+
+	tags = make([]ComputedTag, 0)
+
+	// Megapixels
+
+	width, hasWidth, err := compositeNumber(exifIfd, "PixelXDimension")
+	log.PanicIf(err)
+
+	height, hasHeight, err := compositeNumber(exifIfd, "PixelYDimension")
+	log.PanicIf(err)
+
+	if hasWidth == false || hasHeight == false {
+		width, hasWidth, err = compositeNumber(rootIfd, "ImageWidth")
+		log.PanicIf(err)
+
+		height, hasHeight, err = compositeNumber(rootIfd, "ImageLength")
+		log.PanicIf(err)
+	}
+
+	if hasWidth == true && hasHeight == true {
+		megapixels := width * height / 1000000
+
+		tags = append(tags, ComputedTag{
+			IfdPath:   CompositeIfdPath,
+			Name:      "Megapixels",
+			Value:     megapixels,
+			Formatted: fmt.Sprintf("%.1f", megapixels),
+		})
+	}
+
+	// ScaleFactor35efl
+
+	focalLength, hasFocalLength, err := compositeNumber(exifIfd, "FocalLength")
+	log.PanicIf(err)
+
+	focalLength35, hasFocalLength35, err := compositeNumber(exifIfd, "FocalLengthIn35mmFilm")
+	log.PanicIf(err)
+
+	if hasFocalLength == true && hasFocalLength35 == true && focalLength > 0 && focalLength35 > 0 {
+		scaleFactor := focalLength35 / focalLength
+
+		tags = append(tags, ComputedTag{
+			IfdPath:   CompositeIfdPath,
+			Name:      "ScaleFactor35efl",
+			Value:     scaleFactor,
+			Formatted: fmt.Sprintf("%.1f", scaleFactor),
+		})
+	}
+
+	// ShutterSpeed
+
+	exposureTime, hasExposureTime, err := compositeNumber(exifIfd, "ExposureTime")
+	log.PanicIf(err)
+
+	if hasExposureTime == false {
+		// The APEX value is -log2(seconds).
+
+		shutterSpeedValue, hasShutterSpeedValue, err := compositeNumber(exifIfd, "ShutterSpeedValue")
+		log.PanicIf(err)
+
+		if hasShutterSpeedValue == true {
+			exposureTime = math.Pow(2, -shutterSpeedValue)
+			hasExposureTime = true
+		}
+	}
+
+	if hasExposureTime == true && exposureTime > 0 {
+		tags = append(tags, ComputedTag{
+			IfdPath:   CompositeIfdPath,
+			Name:      "ShutterSpeed",
+			Value:     exposureTime,
+			Formatted: formatExposureTime(exposureTime),
+		})
+	}
+
+	// LightValue
+
+	fNumber, hasFNumber, err := compositeNumber(exifIfd, "FNumber")
+	log.PanicIf(err)
+
+	iso, hasIso, err := compositeNumber(exifIfd, "ISOSpeedRatings")
+	log.PanicIf(err)
+
+	if hasFNumber == true && hasExposureTime == true && hasIso == true && fNumber > 0 && exposureTime > 0 && iso > 0 {
+		lightValue := 2*math.Log2(fNumber) - math.Log2(exposureTime) - math.Log2(iso/100)
+
+		tags = append(tags, ComputedTag{
+			IfdPath:   CompositeIfdPath,
+			Name:      "LightValue",
+			Value:     lightValue,
+			Formatted: fmt.Sprintf("%.1f", lightValue),
+		})
+	}
+
+	// GPSPosition
+
+	latitude, hasLatitude, err := compositeGpsDegrees(gpsIfd, "GPSLatitude", "GPSLatitudeRef", 'N')
+	log.PanicIf(err)
+
+	longitude, hasLongitude, err := compositeGpsDegrees(gpsIfd, "GPSLongitude", "GPSLongitudeRef", 'E')
+	log.PanicIf(err)
+
+	if hasLatitude == true && hasLongitude == true {
+		tags = append(tags, ComputedTag{
+			IfdPath:   CompositeIfdPath,
+			Name:      "GPSPosition",
+			Value:     fmt.Sprintf("%.6f, %.6f", latitude.Decimal(), longitude.Decimal()),
+			Formatted: fmt.Sprintf("%s, %s", formatGpsDegrees(latitude), formatGpsDegrees(longitude)),
+		})
+	}
+
+	return tags, nil
+}
+
+// compositeNumber returns the first value of the given numeric tag as a
+// float. `found` is false if the IFD or tag is missing or if the value can't
+// be represented (e.g. a zero denominator).
+func compositeNumber(ifd *Ifd, tagName string) (value float64, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ifd == nil {
+		return 0, false, nil
+	}
+
+	results, err := ifd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true || log.Is(err, ErrTagNotKnown) == true {
+			return 0, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	raw, err := results[0].Value()
+	log.PanicIf(err)
+
+	switch t := raw.(type) {
+	case []uint16:
+		if len(t) > 0 {
+			return float64(t[0]), true, nil
+		}
+	case []uint32:
+		if len(t) > 0 {
+			return float64(t[0]), true, nil
+		}
+	case []exifcommon.Rational:
+		if len(t) > 0 && t[0].Denominator != 0 {
+			return float64(t[0].Numerator) / float64(t[0].Denominator), true, nil
+		}
+	case []exifcommon.SignedRational:
+		if len(t) > 0 && t[0].Denominator != 0 {
+			return float64(t[0].Numerator) / float64(t[0].Denominator), true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// compositeGpsDegrees returns the coordinate in the given tags. A missing
+// reference is taken to be `defaultRef`, which is how readers generally
+// treat it.
+func compositeGpsDegrees(gpsIfd *Ifd, tagName, refTagName string, defaultRef byte) (gd GpsDegrees, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if gpsIfd == nil {
+		return gd, false, nil
+	}
+
+	results, err := gpsIfd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return gd, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	raw, err := results[0].Value()
+	log.PanicIf(err)
+
+	rawCoordinate, ok := raw.([]exifcommon.Rational)
+	if ok == false || len(rawCoordinate) != 3 {
+		return gd, false, nil
+	}
+
+	for _, r := range rawCoordinate {
+		if r.Denominator == 0 {
+			return gd, false, nil
+		}
+	}
+
+	ref := string([]byte{defaultRef})
+
+	results, err = gpsIfd.FindTagWithName(refTagName)
+	if err == nil {
+		refRaw, err := results[0].Value()
+		log.PanicIf(err)
+
+		if refPhrase, ok := refRaw.(string); ok == true && refPhrase != "" {
+			ref = refPhrase
+		}
+	} else if log.Is(err, ErrTagNotFound) == false {
+		log.Panic(err)
+	}
+
+	gd, err = NewGpsDegreesFromRationals(ref, rawCoordinate)
+	log.PanicIf(err)
+
+	return gd, true, nil
+}
+
+// formatGpsDegrees formats a coordinate like `41 deg 24' 12.20" N`.
+func formatGpsDegrees(gd GpsDegrees) string {
+	decimal := math.Abs(gd.Decimal())
+
+	degrees := math.Floor(decimal)
+	minutes := math.Floor((decimal - degrees) * 60)
+	seconds := (decimal - degrees - minutes/60) * 3600
+
+	return fmt.Sprintf("%d deg %d' %.2f\" %s", int(degrees), int(minutes), seconds, string([]byte{gd.Orientation}))
+}
+
+// formatExposureTime formats short exposures as a fraction of a second (e.g.
+// "1/250") and longer ones as seconds.
+func formatExposureTime(seconds float64) string {
+	if seconds < 0.25001 {
+		return fmt.Sprintf("1/%d", int(math.Round(1/seconds)))
+	}
+
+	return fmt.Sprintf("%g", math.Round(seconds*10)/10)
+}
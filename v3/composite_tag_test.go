@@ -0,0 +1,122 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestCompositeTagProvider_Compute(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	tags, err := CompositeTagProvider{}.Compute(index)
+	log.PanicIf(err)
+
+	formatted := make(map[string]string)
+	for _, ct := range tags {
+		if ct.IfdPath != CompositeIfdPath {
+			t.Fatalf("IFD-path not correct: [%s]", ct.IfdPath)
+		}
+
+		formatted[ct.Name] = ct.FormattedValue()
+	}
+
+	expected := map[string]string{
+		"Megapixels":   "9.8",
+		"ShutterSpeed": "1/640",
+		"LightValue":   "9.3",
+	}
+
+	if len(formatted) != len(expected) {
+		t.Fatalf("Composite tags not correct: %v", formatted)
+	}
+
+	for name, value := range expected {
+		if formatted[name] != value {
+			t.Fatalf("Composite tag [%s] not correct: [%s] != [%s]", name, formatted[name], value)
+		}
+	}
+}
+
+func TestCompositeTagProvider_Compute_GpsAndFocalLength(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.SetExifStandardWithName("FocalLength", []exifcommon.Rational{{Numerator: 50, Denominator: 1}})
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("FocalLengthIn35mmFilm", []uint16{75})
+	log.PanicIf(err)
+
+	gpsIfdPath := exifcommon.IfdGpsInfoStandardIfdIdentity.String()
+
+	err = rootIb.SetStandardWithNameInIfd(gpsIfdPath, "GPSLatitudeRef", "N")
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(gpsIfdPath, "GPSLatitude", []exifcommon.Rational{{Numerator: 41, Denominator: 1}, {Numerator: 24, Denominator: 1}, {Numerator: 1220, Denominator: 100}})
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(gpsIfdPath, "GPSLongitudeRef", "W")
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(gpsIfdPath, "GPSLongitude", []exifcommon.Rational{{Numerator: 2, Denominator: 1}, {Numerator: 10, Denominator: 1}, {Numerator: 2650, Denominator: 100}})
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	RegisterComputedTagProvider(CompositeTagProvider{})
+	defer UnregisterComputedTagProvider(CompositeTagProviderName)
+
+	results, err := index.GetTags("Composite/ScaleFactor35efl", "Composite/GPSPosition", "Composite/Megapixels")
+	log.PanicIf(err)
+
+	tv := results["Composite/ScaleFactor35efl"]
+	if tv.Computed == nil || tv.Computed.Provider != CompositeTagProviderName {
+		t.Fatalf("ScaleFactor35efl not resolved as a composite tag: %v", tv)
+	} else if math.Abs(tv.Value.Doubles()[0]-1.5) > 1e-9 {
+		t.Fatalf("ScaleFactor35efl not correct: [%v]", tv.Value)
+	}
+
+	tv = results["Composite/GPSPosition"]
+	if tv.Value.Ascii() != "41.403389, -2.174028" {
+		t.Fatalf("GPSPosition not correct: [%v]", tv.Value)
+	} else if tv.Computed.FormattedValue() != `41 deg 24' 12.20" N, 2 deg 10' 26.50" W` {
+		t.Fatalf("GPSPosition formatting not correct: [%s]", tv.Computed.FormattedValue())
+	}
+
+	if results["Composite/Megapixels"].Found() != false {
+		t.Fatalf("Megapixels should not be present without dimensions.")
+	}
+}
+
+func TestFormatExposureTime(t *testing.T) {
+	cases := map[float64]string{
+		1.0 / 250: "1/250",
+		0.25:      "1/4",
+		0.5:       "0.5",
+		2:         "2",
+		1.33:      "1.3",
+	}
+
+	for seconds, expected := range cases {
+		if actual := formatExposureTime(seconds); actual != expected {
+			t.Fatalf("Formatting of (%f) not correct: [%s] != [%s]", seconds, actual, expected)
+		}
+	}
+}
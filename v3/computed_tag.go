@@ -0,0 +1,179 @@
+package exif
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// ComputedTag is a synthetic tag whose value is not stored in any IFD but is
+// derived from data that is, typically from a parsed maker-note (e.g. a
+// lens name looked up from a Canon LensType ID or a Nikon shutter-count).
+type ComputedTag struct {
+	// IfdPath is the fully-qualified path of the IFD that the tag is
+	// associated with. It is used to qualify the name in `GetTags()`.
+	IfdPath string
+
+	// Name is the name of the tag. It should not collide with the name of a
+	// standard tag in the same IFD.
+	Name string
+
+	// Value is the computed value. It must be something that `NewTagValue()`
+	// accepts in order to be returned by `GetTags()`, which otherwise reports
+	// `ErrTagTypeNotInferable` for it.
+	Value interface{}
+
+	// Formatted is the value formatted for display. If empty, `Value` is
+	// formatted with "%v".
+	Formatted string
+
+	// Provider is the name of the provider that produced the tag. It is set
+	// automatically.
+	Provider string
+}
+
+// FormattedValue returns the value formatted for display.
+func (ct ComputedTag) FormattedValue() string {
+	if ct.Formatted != "" {
+		return ct.Formatted
+	}
+
+	return fmt.Sprintf("%v", ct.Value)
+}
+
+// String returns a descriptive string.
+func (ct ComputedTag) String() string {
+	return fmt.Sprintf("ComputedTag<PROVIDER=[%s] IFD-PATH=[%s] NAME=[%s] VALUE=[%s]>", ct.Provider, ct.IfdPath, ct.Name, ct.FormattedValue())
+}
+
+// ComputedTagProvider derives computed tags from a collected IFD tree.
+// Providers are given the whole index because the inputs are usually spread
+// across IFDs (e.g. the Make in IFD0 decides how to read the maker-note in
+// the Exif IFD).
+type ComputedTagProvider interface {
+	// Name returns a unique name for the provider.
+	Name() string
+
+	// Compute returns zero or more tags. A provider that doesn't apply to the
+	// given data (e.g. a different manufacturer) should return nothing rather
+	// than an error.
+	Compute(index IfdIndex) (tags []ComputedTag, err error)
+}
+
+// ComputedTagVisitorFn is called for each computed tag by
+// `IfdIndex.VisitComputedTags()`.
+type ComputedTagVisitorFn func(ct ComputedTag) (err error)
+
+var (
+	computedTagProviders      = make([]ComputedTagProvider, 0)
+	computedTagProvidersMutex sync.RWMutex
+)
+
+// RegisterComputedTagProvider adds a provider to those consulted for
+// computed tags. Providers are consulted in the order that they were
+// registered. It is a programming error to register two providers with the
+// same name.
+func RegisterComputedTagProvider(ctp ComputedTagProvider) {
+	computedTagProvidersMutex.Lock()
+	defer computedTagProvidersMutex.Unlock()
+
+	name := ctp.Name()
+	for _, existing := range computedTagProviders {
+		if existing.Name() == name {
+			log.Panicf("computed-tag provider already registered: [%s]", name)
+		}
+	}
+
+	computedTagProviders = append(computedTagProviders, ctp)
+}
+
+// UnregisterComputedTagProvider removes the provider with the given name.
+// Returns false if it was not registered.
+func UnregisterComputedTagProvider(name string) bool {
+	computedTagProvidersMutex.Lock()
+	defer computedTagProvidersMutex.Unlock()
+
+	for i, existing := range computedTagProviders {
+		if existing.Name() == name {
+			computedTagProviders = append(computedTagProviders[:i], computedTagProviders[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ComputedTags runs every registered provider against the index and returns
+// all of the tags that they produce, in provider order.
+func (index IfdIndex) ComputedTags() (tags []ComputedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	computedTagProvidersMutex.RLock()
+	providers := make([]ComputedTagProvider, len(computedTagProviders))
+	copy(providers, computedTagProviders)
+	computedTagProvidersMutex.RUnlock()
+
+	tags = make([]ComputedTag, 0)
+	for _, ctp := range providers {
+		produced, err := ctp.Compute(index)
+		log.PanicIf(err)
+
+		name := ctp.Name()
+		for _, ct := range produced {
+			ct.Provider = name
+			tags = append(tags, ct)
+		}
+	}
+
+	return tags, nil
+}
+
+// VisitComputedTags calls the visitor for every computed tag.
+func (index IfdIndex) VisitComputedTags(visitor ComputedTagVisitorFn) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	tags, err := index.ComputedTags()
+	log.PanicIf(err)
+
+	for _, ct := range tags {
+		err := visitor(ct)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// FindComputedTagWithName returns the computed tags with the given name.
+// `ErrTagNotFound` is returned if there are none.
+func (index IfdIndex) FindComputedTagWithName(name string) (results []ComputedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	tags, err := index.ComputedTags()
+	log.PanicIf(err)
+
+	results = make([]ComputedTag, 0)
+	for _, ct := range tags {
+		if ct.Name == name {
+			results = append(results, ct)
+		}
+	}
+
+	if len(results) == 0 {
+		log.Panic(ErrTagNotFound)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,178 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+type testModelComputedTagProvider struct{}
+
+func (testModelComputedTagProvider) Name() string {
+	return "test-model"
+}
+
+func (testModelComputedTagProvider) Compute(index IfdIndex) (tags []ComputedTag, err error) {
+	results, err := index.RootIfd.FindTagWithName("Model")
+	if err == ErrTagNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	value, err := results[0].Value()
+	if err != nil {
+		return nil, err
+	}
+
+	model := value.(string)
+
+	tags = []ComputedTag{
+		{
+			IfdPath:   "IFD",
+			Name:      "ModelUpper",
+			Value:     strings.ToUpper(model),
+			Formatted: "[" + strings.ToUpper(model) + "]",
+		},
+		{
+			IfdPath: "IFD/Exif",
+			Name:    "ModelLength",
+			Value:   uint32(len(model)),
+		},
+	}
+
+	return tags, nil
+}
+
+func getComputedTagTestIndex() IfdIndex {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	return index
+}
+
+func TestIfdIndex_ComputedTags(t *testing.T) {
+	RegisterComputedTagProvider(testModelComputedTagProvider{})
+	defer UnregisterComputedTagProvider("test-model")
+
+	index := getComputedTagTestIndex()
+
+	tags, err := index.ComputedTags()
+	log.PanicIf(err)
+
+	if len(tags) != 2 {
+		t.Fatalf("Computed-tag count not correct: (%d)", len(tags))
+	}
+
+	ct := tags[0]
+	if ct.Provider != "test-model" {
+		t.Fatalf("Provider not correct: [%s]", ct.Provider)
+	} else if ct.Value.(string) != "CANON EOS 5D MARK III" {
+		t.Fatalf("Value not correct: [%v]", ct.Value)
+	} else if ct.FormattedValue() != "[CANON EOS 5D MARK III]" {
+		t.Fatalf("Formatted value not correct: [%s]", ct.FormattedValue())
+	}
+
+	if tags[1].FormattedValue() != "21" {
+		t.Fatalf("Default formatting not correct: [%s]", tags[1].FormattedValue())
+	}
+}
+
+func TestIfdIndex_ComputedTags_NoProviders(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	tags, err := index.ComputedTags()
+	log.PanicIf(err)
+
+	if len(tags) != 0 {
+		t.Fatalf("Expected no computed tags: (%d)", len(tags))
+	}
+
+	_, err = index.FindComputedTagWithName("ModelUpper")
+	if log.Is(err, ErrTagNotFound) == false {
+		t.Fatalf("Expected not-found error: [%v]", err)
+	}
+}
+
+func TestIfdIndex_VisitComputedTags(t *testing.T) {
+	RegisterComputedTagProvider(testModelComputedTagProvider{})
+	defer UnregisterComputedTagProvider("test-model")
+
+	index := getComputedTagTestIndex()
+
+	names := make([]string, 0)
+	err := index.VisitComputedTags(func(ct ComputedTag) (err error) {
+		names = append(names, ct.Name)
+		return nil
+	})
+
+	log.PanicIf(err)
+
+	if strings.Join(names, ",") != "ModelUpper,ModelLength" {
+		t.Fatalf("Visited tags not correct: %v", names)
+	}
+}
+
+func TestIfdIndex_GetTags_Computed(t *testing.T) {
+	RegisterComputedTagProvider(testModelComputedTagProvider{})
+	defer UnregisterComputedTagProvider("test-model")
+
+	index := getComputedTagTestIndex()
+
+	results, err := index.GetTags("Model", "ModelUpper", "IFD/Exif/ModelLength", "IFD/ModelLength")
+	log.PanicIf(err)
+
+	if results["Model"].Ite == nil {
+		t.Fatalf("Stored tag should still be resolved directly.")
+	}
+
+	tv := results["ModelUpper"]
+	if tv.Found() != true {
+		t.Fatalf("ModelUpper not found.")
+	} else if tv.Computed == nil || tv.Computed.Name != "ModelUpper" {
+		t.Fatalf("ModelUpper not resolved as a computed tag.")
+	} else if tv.Value.Ascii() != "CANON EOS 5D MARK III" {
+		t.Fatalf("ModelUpper value not correct: [%v]", tv.Value)
+	}
+
+	tv = results["IFD/Exif/ModelLength"]
+	if tv.Err != nil {
+		log.Panic(tv.Err)
+	} else if tv.Value.Kind() != exifcommon.TypeLong || tv.Value.Longs()[0] != 21 {
+		t.Fatalf("ModelLength value not correct: [%v]", tv.Value)
+	}
+
+	tv = results["IFD/ModelLength"]
+	if tv.Found() != false {
+		t.Fatalf("ModelLength should not have been found in IFD0.")
+	}
+}
+
+func TestRegisterComputedTagProvider_Duplicate(t *testing.T) {
+	RegisterComputedTagProvider(testModelComputedTagProvider{})
+	defer UnregisterComputedTagProvider("test-model")
+
+	defer func() {
+		if state := recover(); state == nil {
+			t.Fatalf("Expected panic for duplicate registration.")
+		}
+	}()
+
+	RegisterComputedTagProvider(testModelComputedTagProvider{})
+}
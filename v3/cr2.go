@@ -0,0 +1,251 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// Cr2HeaderSize is the size of the CR2 header: the TIFF header followed
+	// by the CR2 signature, version, and the offset of the raw IFD.
+	Cr2HeaderSize = 16
+)
+
+var (
+	// cr2Signature follows the TIFF header of a CR2 file.
+	cr2Signature = []byte("CR")
+)
+
+var (
+	// ErrNotCr2 means that the data does not start with a CR2 header.
+	ErrNotCr2 = errors.New("not a cr2 file")
+)
+
+// Cr2Header is the header of a Canon CR2 raw file. It extends the TIFF header
+// with a version and the offset of the IFD that describes the raw data.
+type Cr2Header struct {
+	// ExifHeader is the TIFF header that the file starts with.
+	ExifHeader
+
+	// MajorVersion and MinorVersion are the version of the CR2 format
+	// (normally 2.0).
+	MajorVersion uint8
+	MinorVersion uint8
+
+	// RawIfdOffset is the offset of the IFD of the raw data, which is also the
+	// last IFD in the root chain.
+	RawIfdOffset uint32
+}
+
+// String returns a descriptive string.
+func (ch Cr2Header) String() string {
+	return fmt.Sprintf("Cr2Header<BYTE-ORDER=[%v] FIRST-IFD-OFFSET=(0x%02x) VERSION=(%d.%d) RAW-IFD-OFFSET=(0x%02x)>", ch.ByteOrder, ch.FirstIfdOffset, ch.MajorVersion, ch.MinorVersion, ch.RawIfdOffset)
+}
+
+// IsCr2 returns true if the data starts with a CR2 header.
+func IsCr2(data []byte) bool {
+	_, err := ParseCr2Header(data)
+	return err == nil
+}
+
+// ParseCr2Header parses the header at the start of a CR2 file. `ErrNotCr2` is
+// returned if there isn't one.
+func ParseCr2Header(data []byte) (ch Cr2Header, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(data) < Cr2HeaderSize || bytes.Equal(data[8:10], cr2Signature) == false {
+		return ch, ErrNotCr2
+	}
+
+	eh, err := ParseExifHeader(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return ch, ErrNotCr2
+		}
+
+		log.Panic(err)
+	}
+
+	ch = Cr2Header{
+		ExifHeader:   eh,
+		MajorVersion: data[10],
+		MinorVersion: data[11],
+		RawIfdOffset: eh.ByteOrder.Uint32(data[12:16]),
+	}
+
+	return ch, nil
+}
+
+// Cr2Preview is one of the JPEG previews embedded in a CR2 file.
+type Cr2Preview struct {
+	// Ifd is the IFD that describes the preview.
+	Ifd *Ifd
+
+	// Data is the JPEG image.
+	Data []byte
+}
+
+// String returns a descriptive string.
+func (cp Cr2Preview) String() string {
+	return fmt.Sprintf("Cr2Preview<IFD=[%s] SIZE=(%d)>", cp.Ifd.IfdIdentity(), len(cp.Data))
+}
+
+// Cr2File is a parsed Canon CR2 raw file. A CR2 is a TIFF whose root chain
+// normally has four IFDs: IFD0 describes a full-size JPEG preview and has the
+// EXIF and GPS IFDs as children, IFD1 has a small JPEG thumbnail, IFD2 has an
+// uncompressed RGB preview, and IFD3 (the one in the header) describes the
+// raw data.
+type Cr2File struct {
+	*TiffRawFile
+
+	header Cr2Header
+}
+
+// NewCr2File parses the IFDs of the given CR2 file.
+func NewCr2File(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (cf *Cr2File, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	header, err := ParseCr2Header(data)
+	if err != nil {
+		if err == ErrNotCr2 {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	trf, err := NewTiffRawFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, ErrNotCr2
+		}
+
+		log.Panic(err)
+	}
+
+	cf = &Cr2File{
+		TiffRawFile: trf,
+		header:      header,
+	}
+
+	return cf, nil
+}
+
+// Header returns the CR2 header.
+func (cf *Cr2File) Header() Cr2Header {
+	return cf.header
+}
+
+// RawIfd returns the IFD of the raw data, as given by the header, or nil if it
+// isn't in the root chain.
+func (cf *Cr2File) RawIfd() (ifd *Ifd, err error) {
+	for ifd := cf.index.RootIfd; ifd != nil; ifd = ifd.NextIfd() {
+		if ifd.Offset() == cf.header.RawIfdOffset {
+			return ifd, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Previews returns the JPEG previews, in the order of the root chain. These
+// are the images of the IFDs other than the raw one that are stored as JPEG
+// data, either in strips or as a JPEG "interchange format" (the thumbnail).
+// The data refers to the file unless it had to be joined from several strips.
+func (cf *Cr2File) Previews() (previews []Cr2Preview, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	previews = make([]Cr2Preview, 0)
+
+	for ifd := cf.index.RootIfd; ifd != nil; ifd = ifd.NextIfd() {
+		if ifd.Offset() == cf.header.RawIfdOffset {
+			continue
+		}
+
+		data, err := cf.readImage(ifd)
+		log.PanicIf(err)
+
+		if bytes.HasPrefix(data, []byte{0xff, JpegMarkerSoi}) == false {
+			continue
+		}
+
+		cp := Cr2Preview{
+			Ifd:  ifd,
+			Data: data,
+		}
+
+		previews = append(previews, cp)
+	}
+
+	return previews, nil
+}
+
+// readImage returns the image data of the given IFD, or nil if it has none.
+// Strips are joined if there is more than one.
+func (cf *Cr2File) readImage(ifd *Ifd) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// The enumerator turns the value of the JPEG offset into the JPEG itself,
+	// so the offset is taken from the entry.
+	if results, err := ifd.FindTagWithId(ThumbnailOffsetTagId); err == nil {
+		offset := results[0].getValueOffset()
+
+		sizes, found, err := readTiffUint32s(ifd, ThumbnailSizeTagId)
+		log.PanicIf(err)
+
+		if found == false || len(sizes) != 1 {
+			log.Panicf("cr2 ifd [%s] has a jpeg offset but no single jpeg size", ifd)
+		}
+
+		end := uint64(offset) + uint64(sizes[0])
+		if end > uint64(len(cf.data)) {
+			log.Panicf("cr2 ifd [%s] jpeg runs past the end of the file: (%d) > (%d)", ifd, end, len(cf.data))
+		}
+
+		return cf.data[offset:end], nil
+	} else if log.Is(err, ErrTagNotFound) == false {
+		log.Panic(err)
+	}
+
+	tid, err := readTiffImageData(ifd, cf.data)
+	log.PanicIf(err)
+
+	if tid == nil {
+		return nil, nil
+	} else if len(tid.chunks) == 1 {
+		return tid.chunks[0], nil
+	}
+
+	data = make([]byte, 0)
+	for _, chunk := range tid.chunks {
+		data = append(data, chunk...)
+	}
+
+	return data, nil
+}
+
+// String returns a descriptive string.
+func (cf *Cr2File) String() string {
+	return fmt.Sprintf("Cr2File<SIZE=(%d) IFDS=(%d) VERSION=(%d.%d)>", len(cf.data), len(cf.index.Ifds), cf.header.MajorVersion, cf.header.MinorVersion)
+}
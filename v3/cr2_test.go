@@ -0,0 +1,208 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// buildTestCr2 returns a minimal CR2 with the usual four IFDs: a JPEG preview
+// in strips (with EXIF and GPS children), a JPEG thumbnail, an RGB preview,
+// and the raw data, which is lossless JPEG and so also starts with an SOI.
+func buildTestCr2() (data, preview, thumbnail []byte) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	byteOrder := binary.LittleEndian
+
+	preview = append(buildTestJpeg(nil), 0x11, 0x22, 0x33)
+	thumbnail = append(buildTestJpeg(nil), 0x44)
+	rgb := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
+	raw := []byte{0xff, 0xd8, 0xff, 0xc3, 0x00, 0x00}
+
+	ib0 := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib0.AddStandardWithName("Make", "Canon")
+	log.PanicIf(err)
+
+	err = ib0.AddStandardWithName("Model", "Canon EOS 5D Mark III")
+	log.PanicIf(err)
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, byteOrder)
+
+	err = exifIb.AddStandardWithName("ExposureTime", []exifcommon.Rational{{Numerator: 1, Denominator: 200}})
+	log.PanicIf(err)
+
+	err = ib0.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	gpsIb := NewIfdBuilder(im, ti, exifcommon.IfdGpsInfoStandardIfdIdentity, byteOrder)
+
+	err = gpsIb.AddStandardWithName("GPSVersionID", []uint8{2, 2, 0, 0})
+	log.PanicIf(err)
+
+	err = ib0.AddChildIb(gpsIb)
+	log.PanicIf(err)
+
+	ib1 := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib1.SetThumbnail(thumbnail)
+	log.PanicIf(err)
+
+	ib2 := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+	ib3 := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib0.SetNextIb(ib1)
+	log.PanicIf(err)
+
+	err = ib1.SetNextIb(ib2)
+	log.PanicIf(err)
+
+	err = ib2.SetNextIb(ib3)
+	log.PanicIf(err)
+
+	// Encode once to find out where the strips go, and then with their real
+	// offsets. The offset of the raw IFD is set in the header afterwards.
+
+	ibs := []*IfdBuilder{ib0, ib2, ib3}
+	tids := []*tiffImageData{
+		{offsetsTagId: ThumbnailStripOffsetsTagId, byteCountsTagId: ThumbnailStripByteCountsTagId, chunks: [][]byte{preview}},
+		{offsetsTagId: ThumbnailStripOffsetsTagId, byteCountsTagId: ThumbnailStripByteCountsTagId, chunks: [][]byte{rgb}},
+		{offsetsTagId: ThumbnailStripOffsetsTagId, byteCountsTagId: ThumbnailStripByteCountsTagId, chunks: [][]byte{raw}},
+	}
+
+	for i, tid := range tids {
+		err := tid.setTags(ibs[i], []uint32{0})
+		log.PanicIf(err)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	ibe.SetHeaderLayout(HeaderLayout{
+		ByteOrder:      byteOrder,
+		FirstIfdOffset: Cr2HeaderSize,
+		PreIfdGap:      []byte{'C', 'R', 2, 0, 0, 0, 0, 0},
+	})
+
+	data, err = ibe.EncodeToExif(ib0)
+	log.PanicIf(err)
+
+	position := uint32(len(data))
+	for i, tid := range tids {
+		err := tid.setTags(ibs[i], []uint32{position})
+		log.PanicIf(err)
+
+		position += uint32(len(tid.chunks[0]))
+	}
+
+	data, err = ibe.EncodeToExif(ib0)
+	log.PanicIf(err)
+
+	for _, tid := range tids {
+		data = append(data, tid.chunks[0]...)
+	}
+
+	_, index, err := Collect(im, ti, data)
+	log.PanicIf(err)
+
+	rawIfd := index.RootIfd.NextIfd().NextIfd().NextIfd()
+	byteOrder.PutUint32(data[12:16], rawIfd.Offset())
+
+	return data, preview, thumbnail
+}
+
+func TestParseCr2Header(t *testing.T) {
+	data, _, _ := buildTestCr2()
+
+	ch, err := ParseCr2Header(data)
+	log.PanicIf(err)
+
+	if ch.ByteOrder != binary.LittleEndian {
+		t.Fatalf("Byte-order not correct: [%v]", ch.ByteOrder)
+	} else if ch.FirstIfdOffset != Cr2HeaderSize {
+		t.Fatalf("First IFD offset not correct: (%d)", ch.FirstIfdOffset)
+	} else if ch.MajorVersion != 2 || ch.MinorVersion != 0 {
+		t.Fatalf("Version not correct: (%d.%d)", ch.MajorVersion, ch.MinorVersion)
+	} else if ch.RawIfdOffset == 0 {
+		t.Fatalf("Raw IFD offset not set.")
+	}
+
+	if IsCr2(getTestTiffData()) != false {
+		t.Fatalf("Plain TIFF detected as CR2.")
+	}
+}
+
+func TestNewCr2File(t *testing.T) {
+	data, preview, thumbnail := buildTestCr2()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	cf, err := NewCr2File(im, ti, data)
+	log.PanicIf(err)
+
+	ifds := cf.Ifds()
+	if len(ifds) != 4 {
+		t.Fatalf("IFD count not correct: (%d)", len(ifds))
+	}
+
+	rawIfd, err := cf.RawIfd()
+	log.PanicIf(err)
+
+	if rawIfd != ifds[3] {
+		t.Fatalf("Raw IFD not correct: [%v]", rawIfd)
+	}
+
+	exifIfd, found := cf.ExifIfd()
+	if found != true {
+		t.Fatalf("EXIF IFD not found.")
+	}
+
+	_, err = exifIfd.FindTagWithName("ExposureTime")
+	log.PanicIf(err)
+
+	gpsIfd, found := cf.GpsIfd()
+	if found != true {
+		t.Fatalf("GPS IFD not found.")
+	}
+
+	_, err = gpsIfd.FindTagWithName("GPSVersionID")
+	log.PanicIf(err)
+
+	// The RGB preview isn't a JPEG and the raw data is skipped.
+
+	previews, err := cf.Previews()
+	log.PanicIf(err)
+
+	if len(previews) != 2 {
+		t.Fatalf("Preview count not correct: (%d)", len(previews))
+	} else if previews[0].Ifd != ifds[0] || bytes.Equal(previews[0].Data, preview) != true {
+		t.Fatalf("First preview not correct: %s", previews[0])
+	} else if previews[1].Ifd != ifds[1] || bytes.Equal(previews[1].Data, thumbnail) != true {
+		t.Fatalf("Second preview not correct: %s", previews[1])
+	}
+}
+
+func TestNewCr2File_NotCr2(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, err = NewCr2File(im, ti, getTestTiffData())
+	if err != ErrNotCr2 {
+		t.Fatalf("Expected not-CR2 error: [%v]", err)
+	}
+}
@@ -16,6 +16,10 @@ type ExifBlobSeeker interface {
 // EXIF data).
 type ExifReadSeeker struct {
 	rs io.ReadSeeker
+
+	// data is what `rs` reads from, if it is held in memory. Values can be
+	// returned as slices of it (see `IfdTagEntry.ValueBytesNoCopy()`).
+	data []byte
 }
 
 func NewExifReadSeeker(rs io.ReadSeeker) *ExifReadSeeker {
@@ -27,6 +31,7 @@ func NewExifReadSeeker(rs io.ReadSeeker) *ExifReadSeeker {
 func NewExifReadSeekerWithBytes(exifData []byte) *ExifReadSeeker {
 	sb := rifs.NewSeekableBufferWithBytes(exifData)
 	edbs := NewExifReadSeeker(sb)
+	edbs.data = sb.Bytes()
 
 	return edbs
 }
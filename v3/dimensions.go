@@ -0,0 +1,159 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// DimensionCheck compares the pixel dimensions declared by the EXIF with the
+// actual dimensions of the image. Editors often resize an image without
+// updating the EXIF.
+type DimensionCheck struct {
+	// ExifWidth and ExifHeight are the PixelXDimension and PixelYDimension
+	// tags of the Exif IFD.
+	ExifWidth  uint32
+	ExifHeight uint32
+
+	// HasExif indicates that the EXIF declares both dimensions.
+	HasExif bool
+
+	// FrameWidth and FrameHeight are the actual dimensions of the image.
+	FrameWidth  uint32
+	FrameHeight uint32
+}
+
+// String returns a descriptive string.
+func (dc DimensionCheck) String() string {
+	return fmt.Sprintf("DimensionCheck<EXIF=(%dx%d) HAS-EXIF=[%v] FRAME=(%dx%d)>", dc.ExifWidth, dc.ExifHeight, dc.HasExif, dc.FrameWidth, dc.FrameHeight)
+}
+
+// Matches returns true if the EXIF dimensions are the actual ones or if the
+// EXIF doesn't declare any.
+func (dc DimensionCheck) Matches() bool {
+	if dc.HasExif == false {
+		return true
+	}
+
+	return dc.ExifWidth == dc.FrameWidth && dc.ExifHeight == dc.FrameHeight
+}
+
+// Apply sets the actual dimensions in the EXIF being rebuilt if they don't
+// match. This must be called on the root IB. Returns true if the IB was
+// changed.
+func (dc DimensionCheck) Apply(rootIb *IfdBuilder) (changed bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if dc.Matches() == true {
+		return false, nil
+	}
+
+	err = rootIb.SetPixelDimensions(dc.FrameWidth, dc.FrameHeight)
+	log.PanicIf(err)
+
+	return true, nil
+}
+
+// CheckDimensions compares the EXIF dimensions with the given actual ones.
+func (index IfdIndex) CheckDimensions(frameWidth, frameHeight uint32) (dc DimensionCheck, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	dc = DimensionCheck{
+		FrameWidth:  frameWidth,
+		FrameHeight: frameHeight,
+	}
+
+	exifIfd, err := FindIfdFromRootIfd(index.RootIfd, exifcommon.IfdExifStandardIfdIdentity.UnindexedString())
+	if err != nil {
+		if log.Is(err, ErrIfdNotFound) == true {
+			return dc, nil
+		}
+
+		log.Panic(err)
+	}
+
+	width, widthFound, err := recordedPixelDimension(exifIfd, "PixelXDimension")
+	log.PanicIf(err)
+
+	height, heightFound, err := recordedPixelDimension(exifIfd, "PixelYDimension")
+	log.PanicIf(err)
+
+	if widthFound == true && heightFound == true {
+		dc.ExifWidth = width
+		dc.ExifHeight = height
+		dc.HasExif = true
+	}
+
+	return dc, nil
+}
+
+// CheckJpegDimensions compares the EXIF dimensions of a JPEG with the
+// dimensions in its frame header (see `JpegDimensions()`). A JPEG without
+// EXIF always matches.
+func CheckJpegDimensions(data []byte) (dc DimensionCheck, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	width, height, err := JpegDimensions(bytes.NewReader(data))
+	log.PanicIf(err)
+
+	rawExif, _, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != nil {
+		if err == ErrNoExif {
+			dc = DimensionCheck{
+				FrameWidth:  width,
+				FrameHeight: height,
+			}
+
+			return dc, nil
+		}
+
+		log.Panic(err)
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	dc, err = index.CheckDimensions(width, height)
+	log.PanicIf(err)
+
+	return dc, nil
+}
+
+// SetPixelDimensions sets the PixelXDimension and PixelYDimension tags in
+// the Exif IFD, creating the IFD if necessary. This must be called on the
+// root IB.
+func (ib *IfdBuilder) SetPixelDimensions(width, height uint32) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ib.SetExifStandardWithName("PixelXDimension", []uint32{width})
+	log.PanicIf(err)
+
+	err = ib.SetExifStandardWithName("PixelYDimension", []uint32{height})
+	log.PanicIf(err)
+
+	return nil
+}
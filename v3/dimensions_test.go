@@ -0,0 +1,70 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestCheckJpegDimensions(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	dc, err := CheckJpegDimensions(data)
+	log.PanicIf(err)
+
+	if dc.HasExif != true || dc.FrameWidth != 3840 || dc.FrameHeight != 2560 {
+		t.Fatalf("Dimensions not correct: %s", dc)
+	} else if dc.Matches() != true {
+		t.Fatalf("Dimensions should match: %s", dc)
+	}
+}
+
+func TestDimensionCheck_Apply(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	dc, err := index.CheckDimensions(1920, 1280)
+	log.PanicIf(err)
+
+	if dc.Matches() != false {
+		t.Fatalf("Dimensions should not match: %s", dc)
+	} else if dc.ExifWidth != 3840 || dc.ExifHeight != 2560 {
+		t.Fatalf("EXIF dimensions not correct: %s", dc)
+	}
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	changed, err := dc.Apply(rootIb)
+	log.PanicIf(err)
+
+	if changed != true {
+		t.Fatalf("IB should have been changed.")
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, updatedIndex, err := Collect(index.RootIfd.ifdMapping, index.RootIfd.tagIndex, exifData)
+	log.PanicIf(err)
+
+	dc, err = updatedIndex.CheckDimensions(1920, 1280)
+	log.PanicIf(err)
+
+	if dc.Matches() != true {
+		t.Fatalf("Dimensions should match after applying: %s", dc)
+	}
+
+	changed, err = dc.Apply(rootIb)
+	log.PanicIf(err)
+
+	if changed != false {
+		t.Fatalf("IB should not have been changed again.")
+	}
+}
@@ -0,0 +1,86 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// DngVersionTagId is the tag in IFD0 that identifies a DNG.
+	DngVersionTagId = 0xc612
+)
+
+var (
+	// ErrNotDng means that the data is not a TIFF with a DNGVersion tag.
+	ErrNotDng = errors.New("not a dng file")
+)
+
+// DngFile is a parsed Adobe DNG file. A DNG is a TIFF whose IFD0 has a
+// DNGVersion tag, the EXIF and GPS IFDs as children, and (usually) a SubIFDs
+// tag that lists the IFDs of the raw data and of any previews. The DNG tags
+// are in the standard index under IFD0, and the sub-IFDs are parsed with the
+// same identity, so their tags (BlackLevel, ActiveArea, OpcodeList1, etc.)
+// have names.
+type DngFile struct {
+	*TiffRawFile
+
+	version []byte
+}
+
+// NewDngFile parses the IFDs of the given DNG file, including the sub-IFDs of
+// IFD0. `ErrNotDng` is returned if the data isn't a TIFF or IFD0 doesn't have
+// a DNGVersion tag.
+func NewDngFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (df *DngFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	trf, err := NewTiffRawFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, ErrNotDng
+		}
+
+		log.Panic(err)
+	}
+
+	results, err := trf.index.RootIfd.FindTagWithId(DngVersionTagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil, ErrNotDng
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	version, ok := value.([]byte)
+	if ok == false || len(version) != 4 {
+		return nil, ErrNotDng
+	}
+
+	df = &DngFile{
+		TiffRawFile: trf,
+		version:     version,
+	}
+
+	return df, nil
+}
+
+// Version returns the DNG version (e.g. "1.4.0.0").
+func (df *DngFile) Version() string {
+	return fmt.Sprintf("%d.%d.%d.%d", df.version[0], df.version[1], df.version[2], df.version[3])
+}
+
+// String returns a descriptive string.
+func (df *DngFile) String() string {
+	return fmt.Sprintf("DngFile<VERSION=[%s] SIZE=(%d) IFDS=(%d) SUB-IFDS=(%d)>", df.Version(), len(df.data), len(df.index.Ifds), len(df.subIfds))
+}
@@ -0,0 +1,172 @@
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// buildTestDng returns a minimal DNG with some color tags in IFD0 and the raw
+// data in a sub-IFD.
+func buildTestDng() []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	byteOrder := binary.BigEndian
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib.AddStandardWithName("NewSubfileType", []uint32{1})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("DNGVersion", []uint8{1, 6, 0, 0})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("UniqueCameraModel", "Test Camera")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("ProfileName", "Standard")
+	log.PanicIf(err)
+
+	asShotNeutral := []exifcommon.Rational{
+		{Numerator: 1, Denominator: 2},
+		{Numerator: 1, Denominator: 1},
+		{Numerator: 2, Denominator: 3},
+	}
+
+	err = ib.AddStandardWithName("AsShotNeutral", asShotNeutral)
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("ProfileToneCurve", []float32{0, 0, 1, 1})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("SubIFDs", []uint32{0})
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	data, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	rawOffset := uint32(len(data))
+	data = append(data, buildTestNefSubIfd(byteOrder, 0, 4000)...)
+
+	_, index, err := Collect(im, ti, data)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(SubIfdsTagId)
+	log.PanicIf(err)
+
+	entryOffset := index.RootIfd.Offset() + 2 + uint32(results[0].tagIndex)*12
+	byteOrder.PutUint32(data[entryOffset+8:], rawOffset)
+
+	return data
+}
+
+func TestNewDngFile(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	df, err := NewDngFile(im, ti, buildTestDng())
+	log.PanicIf(err)
+
+	if df.Version() != "1.6.0.0" {
+		t.Fatalf("Version not correct: [%s]", df.Version())
+	}
+
+	rawIfd, err := df.RawIfd()
+	log.PanicIf(err)
+
+	if len(df.SubIfds()) != 1 || rawIfd != df.SubIfds()[0] {
+		t.Fatalf("Raw IFD not correct: [%v]", rawIfd)
+	}
+
+	results, err := rawIfd.FindTagWithName("ImageWidth")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint32)[0] != 4000 {
+		t.Fatalf("Raw width not correct: %v", value)
+	}
+}
+
+func TestDngFile_Tags(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	df, err := NewDngFile(im, ti, buildTestDng())
+	log.PanicIf(err)
+
+	ifd := df.Index().RootIfd
+
+	results, err := ifd.FindTagWithName("AsShotNeutral")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if neutral := value.([]exifcommon.Rational); len(neutral) != 3 || neutral[2].Denominator != 3 {
+		t.Fatalf("AsShotNeutral not correct: %v", value)
+	}
+
+	results, err = ifd.FindTagWithName("ProfileName")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if value != "Standard" {
+		t.Fatalf("ProfileName not correct: [%v]", value)
+	}
+
+	results, err = ifd.FindTagWithName("ProfileToneCurve")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if curve := value.([]float32); len(curve) != 4 || curve[3] != 1 {
+		t.Fatalf("ProfileToneCurve not correct: %v", value)
+	}
+}
+
+func TestNewDngFile_NotDng(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, err = NewDngFile(im, ti, buildTestNef())
+	if err != ErrNotDng {
+		t.Fatalf("Expected not-DNG error: [%v]", err)
+	}
+}
+
+func TestIndexedTag_GetEncodingType_Rational(t *testing.T) {
+	ti := NewTagIndex()
+
+	it, err := ti.GetWithName(exifcommon.IfdStandardIfdIdentity, "BlackLevel")
+	log.PanicIf(err)
+
+	if tagType := it.GetEncodingType([]exifcommon.Rational{{Numerator: 1, Denominator: 2}}); tagType != exifcommon.TypeRational {
+		t.Fatalf("Rational type not correct: [%s]", tagType)
+	} else if tagType := it.GetEncodingType([]uint16{64}); tagType != exifcommon.TypeLong {
+		t.Fatalf("Integer type not correct: [%s]", tagType)
+	}
+}
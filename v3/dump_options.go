@@ -0,0 +1,128 @@
+package exif
+
+import (
+	"fmt"
+
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// DefaultDumpMaxLength is the longest that a value is shown in full when
+	// `DumpOptions.MaxLength` is not set.
+	DefaultDumpMaxLength = 256
+
+	// DefaultDumpHeadLength is how many leading bytes a summary shows when
+	// `DumpOptions.HeadLength` is not set.
+	DefaultDumpHeadLength = 16
+)
+
+// DumpOptions controls how values are displayed in dumps. The zero value
+// summarizes large binary values with the defaults.
+type DumpOptions struct {
+	// MaxLength is the longest that a value is shown in full. Binary (BYTE
+	// and UNDEFINED) values with more bytes than this are summarized and
+	// the formatted phrases of other values are truncated. Zero means
+	// `DefaultDumpMaxLength`.
+	MaxLength int
+
+	// MaxLengthByTagId overrides `MaxLength` for particular tags.
+	MaxLengthByTagId map[uint16]int
+
+	// HeadLength is how many leading bytes a summary shows. Zero means
+	// `DefaultDumpHeadLength`.
+	HeadLength int
+
+	// Full shows every value in full, regardless of the other options.
+	Full bool
+}
+
+// maxLength returns the longest that the given tag's value is shown in full.
+func (do *DumpOptions) maxLength(tagId uint16) int {
+	if maxLength, found := do.MaxLengthByTagId[tagId]; found == true {
+		return maxLength
+	}
+
+	if do.MaxLength > 0 {
+		return do.MaxLength
+	}
+
+	return DefaultDumpMaxLength
+}
+
+// headLength returns how many leading bytes a summary shows.
+func (do *DumpOptions) headLength() int {
+	if do != nil && do.HeadLength > 0 {
+		return do.HeadLength
+	}
+
+	return DefaultDumpHeadLength
+}
+
+// isBinaryType returns true if values of the type are opaque bytes.
+func isBinaryType(tagType exifcommon.TagTypePrimitive) bool {
+	return tagType == exifcommon.TypeByte || tagType == exifcommon.TypeUndefined
+}
+
+// ShouldSummarize returns true if the given raw value of the given tag is
+// summarized rather than shown in full.
+func (do *DumpOptions) ShouldSummarize(tagId uint16, tagType exifcommon.TagTypePrimitive, rawBytes []byte) bool {
+	if do == nil || do.Full == true || isBinaryType(tagType) == false {
+		return false
+	}
+
+	return len(rawBytes) > do.maxLength(tagId)
+}
+
+// Summarize returns a summary of the raw value: its size, its SHA-256, and
+// its first few bytes.
+func (do *DumpOptions) Summarize(rawBytes []byte) string {
+	head := rawBytes
+	if headLength := do.headLength(); len(head) > headLength {
+		head = head[:headLength]
+	}
+
+	digest := sha256.Sum256(rawBytes)
+
+	return fmt.Sprintf("BINARY<SIZE=(%d) SHA256=[%x] HEAD=[% x]>", len(rawBytes), digest, head)
+}
+
+// truncate caps the length of a formatted phrase of the given tag, unless
+// full output was requested.
+func (do *DumpOptions) truncate(tagId uint16, phrase string) string {
+	if do == nil || do.Full == true {
+		return phrase
+	}
+
+	return truncateDebugPhrase(phrase, do.maxLength(tagId))
+}
+
+// FormatBytes returns the phrase that a dump shows for the given raw value.
+// `isSummarized` is true if it is a summary (see `Summarize()`) rather than
+// the formatted value. A nil receiver shows everything in full.
+func (do *DumpOptions) FormatBytes(tagId uint16, tagType exifcommon.TagTypePrimitive, rawBytes []byte, byteOrder binary.ByteOrder) (phrase string, isSummarized bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if do.ShouldSummarize(tagId, tagType, rawBytes) == true {
+		return do.Summarize(rawBytes), true, nil
+	}
+
+	// There's nothing more to say about UNDEFINED bytes without knowing the
+	// tag, so show them as bytes.
+	if tagType == exifcommon.TypeUndefined {
+		tagType = exifcommon.TypeByte
+	}
+
+	phrase, err = exifcommon.FormatFromBytes(rawBytes, tagType, false, byteOrder)
+	log.PanicIf(err)
+
+	return do.truncate(tagId, phrase), false, nil
+}
@@ -0,0 +1,179 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestDumpOptions_FormatBytes(t *testing.T) {
+	rawBytes := make([]byte, 300)
+	for i := range rawBytes {
+		rawBytes[i] = byte(i)
+	}
+
+	do := &DumpOptions{
+		HeadLength: 4,
+	}
+
+	phrase, isSummarized, err := do.FormatBytes(0x1234, exifcommon.TypeUndefined, rawBytes, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != true {
+		t.Fatalf("Expected value to be summarized.")
+	} else if strings.HasPrefix(phrase, "BINARY<SIZE=(300) SHA256=[") != true {
+		t.Fatalf("Summary not correct: [%s]", phrase)
+	} else if strings.HasSuffix(phrase, "HEAD=[00 01 02 03]>") != true {
+		t.Fatalf("Summary head not correct: [%s]", phrase)
+	}
+
+	// Small values are shown as they are.
+
+	phrase, isSummarized, err = do.FormatBytes(0x1234, exifcommon.TypeByte, rawBytes[:3], exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != false {
+		t.Fatalf("Small value should not be summarized.")
+	} else if phrase != "00 01 02" {
+		t.Fatalf("Small value not correct: [%s]", phrase)
+	}
+}
+
+func TestDumpOptions_FormatBytes_PerTag(t *testing.T) {
+	rawBytes := make([]byte, 32)
+
+	do := &DumpOptions{
+		MaxLengthByTagId: map[uint16]int{
+			0x1234: 16,
+		},
+	}
+
+	_, isSummarized, err := do.FormatBytes(0x1234, exifcommon.TypeUndefined, rawBytes, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != true {
+		t.Fatalf("Expected value to be summarized with the per-tag length.")
+	}
+
+	_, isSummarized, err = do.FormatBytes(0x5678, exifcommon.TypeUndefined, rawBytes, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != false {
+		t.Fatalf("Expected other tags to use the default length.")
+	}
+}
+
+func TestDumpOptions_FormatBytes_Full(t *testing.T) {
+	rawBytes := make([]byte, 1000)
+
+	do := &DumpOptions{
+		MaxLength: 10,
+		Full:      true,
+	}
+
+	phrase, isSummarized, err := do.FormatBytes(0x1234, exifcommon.TypeByte, rawBytes, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != false {
+		t.Fatalf("Full output should never be summarized.")
+	} else if len(phrase) != 2999 {
+		t.Fatalf("Full output not correct: (%d)", len(phrase))
+	}
+}
+
+func TestDumpOptions_FormatBytes_TruncatesOtherTypes(t *testing.T) {
+	do := &DumpOptions{
+		MaxLength: 5,
+	}
+
+	rawBytes := []byte("a long description\000")
+
+	phrase, isSummarized, err := do.FormatBytes(0x010e, exifcommon.TypeAscii, rawBytes, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if isSummarized != false {
+		t.Fatalf("Only binary values are summarized.")
+	} else if phrase != "a lon...(13 more)" {
+		t.Fatalf("Truncated phrase not correct: [%s]", phrase)
+	}
+}
+
+func TestGetFlatExifData_Dump(t *testing.T) {
+	testExifData := getTestExifData()
+
+	so := &ScanOptions{
+		Dump: &DumpOptions{},
+	}
+
+	exifTags, _, err := GetFlatExifData(testExifData, so)
+	log.PanicIf(err)
+
+	for _, et := range exifTags {
+		if et.TagName == "MakerNote" {
+			if et.IsSummarized != true {
+				t.Fatalf("Maker-note not summarized.")
+			} else if et.ValueBytes != nil {
+				t.Fatalf("Summarized tag should not have value bytes.")
+			} else if strings.HasPrefix(et.Formatted, "BINARY<SIZE=(8152) ") != true {
+				t.Fatalf("Summary not correct: [%s]", et.Formatted)
+			}
+		} else if et.TagName == "Model" && et.IsSummarized != false {
+			t.Fatalf("Model should not be summarized.")
+		}
+	}
+
+	// Without options, everything is in full.
+
+	exifTags, _, err = GetFlatExifData(testExifData, nil)
+	log.PanicIf(err)
+
+	for _, et := range exifTags {
+		if et.IsSummarized == true {
+			t.Fatalf("Tag should not be summarized: %s", et)
+		}
+	}
+}
+
+func TestIfdBuilder_DumpToStringsWithOptions(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Model", "some model")
+	log.PanicIf(err)
+
+	err = ib.AddCustom(0xc001, exifcommon.TypeUndefined, make([]byte, 100))
+	log.PanicIf(err)
+
+	do := DumpOptions{
+		MaxLength:  50,
+		HeadLength: 2,
+	}
+
+	lines := ib.DumpToStringsWithOptions(do)
+
+	if len(lines) != 3 {
+		t.Fatalf("Line count not correct: (%d)\n%s", len(lines), strings.Join(lines, "\n"))
+	} else if strings.HasSuffix(lines[1], "TAG=[0x0110] VALUE=[some model]>") != true {
+		t.Fatalf("Model line not correct: [%s]", lines[1])
+	} else if strings.Contains(lines[2], "VALUE=[BINARY<SIZE=(100) ") != true || strings.HasSuffix(lines[2], "HEAD=[00 00]>]>") != true {
+		t.Fatalf("Binary line not correct: [%s]", lines[2])
+	}
+
+	// The plain dump doesn't show values.
+
+	lines = ib.DumpToStrings()
+
+	if strings.Contains(lines[1], "VALUE=") == true {
+		t.Fatalf("Plain dump should not show values: [%s]", lines[1])
+	}
+}
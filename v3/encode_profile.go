@@ -0,0 +1,92 @@
+package exif
+
+import (
+	"fmt"
+)
+
+// EncodeProfile is a set of encoder options chosen for a purpose.
+type EncodeProfile int
+
+const (
+	// EncodeProfileDefault is the configuration of a new encoder.
+	EncodeProfileDefault EncodeProfile = iota
+
+	// EncodeProfileCompatible avoids the constructs that common consumers
+	// (Windows Explorer, macOS Finder, Lightroom) are known to mishandle even
+	// though the specification allows or merely recommends against them:
+	//
+	// - Tags are written in ascending order of tag-ID, as the specification
+	//   requires. Consumers that binary-search the tables miss tags otherwise.
+	// - Values and IFDs start on word (even) boundaries.
+	// - Empty ASCII values are written as a single NUL rather than with a
+	//   unit-count of zero.
+	// - Child IFDs that would be empty are left out along with the tags that
+	//   point to them.
+	EncodeProfileCompatible
+)
+
+// String returns a descriptive string.
+func (ep EncodeProfile) String() string {
+	switch ep {
+	case EncodeProfileDefault:
+		return "default"
+	case EncodeProfileCompatible:
+		return "compatible"
+	}
+
+	return fmt.Sprintf("EncodeProfile<%d>", int(ep))
+}
+
+// ApplyEncodeProfile sets the options that make up the profile. Options that
+// the profile doesn't concern are not changed, and options may still be
+// changed individually afterward.
+func (ibe *IfdByteEncoder) ApplyEncodeProfile(profile EncodeProfile) {
+	switch profile {
+	case EncodeProfileDefault:
+		ibe.SetSortTags(false)
+		ibe.SetWordAlignment(false)
+		ibe.SetEmptyAsciiPolicy(EmptyAsciiEmitNul)
+		ibe.SetEmptyChildIfdPolicy(EmptyChildIfdEmit)
+	case EncodeProfileCompatible:
+		ibe.SetSortTags(true)
+		ibe.SetWordAlignment(true)
+		ibe.SetEmptyAsciiPolicy(EmptyAsciiEmitNul)
+		ibe.SetEmptyChildIfdPolicy(EmptyChildIfdPrune)
+	}
+}
+
+// SetSortTags determines whether the tags of each IFD are written in
+// ascending order of tag-ID rather than in the order of the IB. Tags with the
+// same ID keep their order.
+func (ibe *IfdByteEncoder) SetSortTags(flag bool) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.sortTags = flag
+}
+
+// SortTags returns whether the tags are sorted when written.
+func (ibe *IfdByteEncoder) SortTags() bool {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.sortTags
+}
+
+// SetWordAlignment determines whether values that don't fit in their tag
+// entries, and the IFDs, are written at even offsets, as TIFF recommends. A
+// padding byte is inserted where necessary.
+func (ibe *IfdByteEncoder) SetWordAlignment(flag bool) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.wordAlign = flag
+}
+
+// WordAlignment returns whether values and IFDs are word-aligned.
+func (ibe *IfdByteEncoder) WordAlignment() bool {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.wordAlign
+}
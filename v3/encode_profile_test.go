@@ -0,0 +1,124 @@
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getEncodeProfileTestIndex(profile EncodeProfile) IfdIndex {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	// Out of order, odd-sized, and empty values.
+
+	err = rootIb.AddStandardWithName("Artist", "")
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("ImageDescription", "some texts")
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("Make", "Maker")
+	log.PanicIf(err)
+
+	exifIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/Exif")
+	log.PanicIf(err)
+
+	err = exifIb.AddStandardWithName("LensModel", "some lens")
+	log.PanicIf(err)
+
+	_, err = GetOrCreateIbFromRootIb(rootIb, "IFD/Exif/Iop")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetEmptyAsciiPolicy(EmptyAsciiEmitZeroLength)
+	ibe.ApplyEncodeProfile(profile)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index
+}
+
+func TestEncodeProfile_String(t *testing.T) {
+	if EncodeProfileCompatible.String() != "compatible" {
+		t.Fatalf("String not correct: [%s]", EncodeProfileCompatible)
+	} else if EncodeProfile(99).String() != "EncodeProfile<99>" {
+		t.Fatalf("String of unknown profile not correct: [%s]", EncodeProfile(99))
+	}
+}
+
+func TestIfdByteEncoder_ApplyEncodeProfile__Compatible(t *testing.T) {
+	index := getEncodeProfileTestIndex(EncodeProfileCompatible)
+
+	if _, found := index.Lookup["IFD/Exif/Iop"]; found == true {
+		t.Fatalf("Empty child IFD not pruned.")
+	}
+
+	for _, ifd := range index.Ifds {
+		if ifd.Offset()%2 != 0 {
+			t.Fatalf("IFD not aligned: %s", ifd)
+		}
+
+		entries := ifd.Entries()
+		for i, ite := range entries {
+			if i > 0 && ite.TagId() <= entries[i-1].TagId() {
+				t.Fatalf("Tags not in order: %v", entries)
+			}
+
+			if ite.TagType() == exifcommon.TypeAscii && ite.UnitCount() == 0 {
+				t.Fatalf("Empty ASCII value not given a NUL: %s", ite)
+			}
+
+			size := uint32(ite.TagType().Size()) * ite.UnitCount()
+			if size > 4 && ite.getValueOffset()%2 != 0 {
+				t.Fatalf("Value not aligned: %s", ite)
+			}
+		}
+	}
+
+	value, err := index.RootIfd.Entries()[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "some texts" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
+func TestIfdByteEncoder_ApplyEncodeProfile__Default(t *testing.T) {
+	index := getEncodeProfileTestIndex(EncodeProfileDefault)
+
+	if _, found := index.Lookup["IFD/Exif/Iop"]; found == false {
+		t.Fatalf("Empty child IFD not kept.")
+	}
+
+	entries := index.RootIfd.Entries()
+	if entries[0].TagName() != "Artist" {
+		t.Fatalf("Tags not in original order: %v", entries)
+	}
+
+	// The profile names the ASCII policy, so it's reset.
+	if entries[0].UnitCount() != 1 {
+		t.Fatalf("Empty ASCII value not given a NUL: %s", entries[0])
+	}
+
+	misaligned := false
+	for _, ite := range entries {
+		size := uint32(ite.TagType().Size()) * ite.UnitCount()
+		if size > 4 && ite.getValueOffset()%2 != 0 {
+			misaligned = true
+		}
+	}
+
+	if misaligned == false {
+		t.Fatalf("Expected a misaligned value without the profile.")
+	}
+}
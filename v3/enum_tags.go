@@ -0,0 +1,150 @@
+package exif
+
+import (
+	"errors"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrEnumValueOutOfRange means that an enumerated tag has a value that the
+	// specification does not define. This is only reported in strict mode.
+	ErrEnumValueOutOfRange = errors.New("enumerated tag value out of range")
+)
+
+// enumeratedTagValues are the values that the specification defines for the
+// tags that are enumerations, by IFD-path and tag-name. Writers do produce
+// other values (e.g. Orientation of zero or -1), which are preserved unless
+// strictness is enabled.
+var enumeratedTagValues = map[string]map[string][]int64{
+	exifcommon.IfdStandardIfdIdentity.UnindexedString(): {
+		"Orientation":      {1, 2, 3, 4, 5, 6, 7, 8},
+		"ResolutionUnit":   {1, 2, 3},
+		"YCbCrPositioning": {1, 2},
+	},
+	exifcommon.IfdExifStandardIfdIdentity.UnindexedString(): {
+		"ExposureProgram":          {0, 1, 2, 3, 4, 5, 6, 7, 8},
+		"MeteringMode":             {0, 1, 2, 3, 4, 5, 6, 255},
+		"ColorSpace":               {1, 0xffff},
+		"SensingMethod":            {1, 2, 3, 4, 5, 7, 8},
+		"CustomRendered":           {0, 1},
+		"ExposureMode":             {0, 1, 2},
+		"WhiteBalance":             {0, 1},
+		"SceneCaptureType":         {0, 1, 2, 3},
+		"GainControl":              {0, 1, 2, 3, 4},
+		"Contrast":                 {0, 1, 2},
+		"Saturation":               {0, 1, 2},
+		"Sharpness":                {0, 1, 2},
+		"SubjectDistanceRange":     {0, 1, 2, 3},
+		"FocalPlaneResolutionUnit": {1, 2, 3},
+	},
+	exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString(): {
+		"GPSAltitudeRef":  {0, 1},
+		"GPSDifferential": {0, 1},
+	},
+}
+
+// isEnumeratedTag returns true if the tag has a defined set of values.
+func isEnumeratedTag(ifdPath, tagName string) bool {
+	_, found := enumeratedTagValues[ifdPath][tagName]
+	return found
+}
+
+// isEnumValueDefined returns true if the value is one that the specification
+// defines for the tag. Tags that are not enumerations always return true.
+func isEnumValueDefined(ifdPath, tagName string, value int64) bool {
+	values, found := enumeratedTagValues[ifdPath][tagName]
+	if found == false {
+		return true
+	}
+
+	for _, definedValue := range values {
+		if definedValue == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enumIntegerValues returns the values of an integer-typed tag value. `ok` is
+// false for other types.
+func enumIntegerValues(value interface{}) (values []int64, ok bool) {
+	switch t := value.(type) {
+	case []uint8:
+		values = make([]int64, len(t))
+		for i, v := range t {
+			values[i] = int64(v)
+		}
+	case []uint16:
+		values = make([]int64, len(t))
+		for i, v := range t {
+			values[i] = int64(v)
+		}
+	case []uint32:
+		values = make([]int64, len(t))
+		for i, v := range t {
+			values[i] = int64(v)
+		}
+	case []int32:
+		values = make([]int64, len(t))
+		for i, v := range t {
+			values[i] = int64(v)
+		}
+	default:
+		return nil, false
+	}
+
+	return values, true
+}
+
+// isEnumCompatibleType returns true for the integer types that enumerated
+// values are preserved in, regardless of which one the specification calls
+// for.
+func isEnumCompatibleType(tagType exifcommon.TagTypePrimitive) bool {
+	return tagType == exifcommon.TypeByte || tagType == exifcommon.TypeShort || tagType == exifcommon.TypeLong || tagType == exifcommon.TypeSignedLong
+}
+
+// checkEnumeratedTag returns `ErrEnumValueOutOfRange` if the tag is an
+// enumeration and the encoded value has a value that is not defined for it.
+func checkEnumeratedTag(ib *IfdBuilder, bt *BuilderTag, valueBytes []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if isEnumCompatibleType(bt.typeId) == false {
+		return nil
+	}
+
+	it, err := ib.tagIndex.Get(ib.IfdIdentity(), bt.tagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	ifdPath := ib.IfdIdentity().UnindexedString()
+
+	if isEnumeratedTag(ifdPath, it.Name) == false {
+		return nil
+	}
+
+	value, err := decodeBuilderTagValueBytes(bt.typeId, valueBytes, ib.byteOrder)
+	log.PanicIf(err)
+
+	values, _ := enumIntegerValues(value)
+
+	for _, v := range values {
+		if isEnumValueDefined(ifdPath, it.Name, v) == false {
+			return ErrEnumValueOutOfRange
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getNegativeOrientationExif returns EXIF with an Orientation of -1 stored as
+// a SLONG, as some writers do.
+func getNegativeOrientationExif() (im *exifcommon.IfdMapping, ti *TagIndex, exifData []byte) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti = NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Make", "some camera")
+	log.PanicIf(err)
+
+	valueBytes, err := exifcommon.NewValueEncoder(exifcommon.TestDefaultByteOrder).Encode([]int32{-1})
+	log.PanicIf(err)
+
+	bt := NewBuilderTag(
+		exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		0x0112,
+		exifcommon.TypeSignedLong,
+		NewIfdBuilderTagValueFromBytes(valueBytes.Encoded),
+		exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.Add(bt)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	return im, ti, exifData
+}
+
+func TestEnumeratedTag_Preserved(t *testing.T) {
+	im, ti, exifData := getNegativeOrientationExif()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	// Rebuild.
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Orientation")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []int32{-1}) != true {
+		t.Fatalf("Orientation not preserved: %v", value)
+	}
+
+	findings, err := Lint(index.RootIfd, nil)
+	log.PanicIf(err)
+
+	if len(findings) != 1 {
+		t.Fatalf("Expected one finding: %v", findings)
+	} else if findings[0].Code != LintEnumValueOutOfRange || findings[0].TagName != "Orientation" || findings[0].IsFixable() != false {
+		t.Fatalf("Finding not correct: %s", findings[0])
+	}
+}
+
+func TestIfdEnumerate_SetStrictEnums(t *testing.T) {
+	im, ti, exifData := getNegativeOrientationExif()
+
+	eh, err := ParseExifHeader(exifData)
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeekerWithBytes(exifData)
+	ie := NewIfdEnumerate(im, ti, ebs, eh.ByteOrder)
+
+	ie.SetStrictEnums(true)
+
+	index, err := ie.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.FindTagWithName("Orientation")
+	if log.Is(err, ErrTagNotFound) != true {
+		t.Fatalf("Expected Orientation to be skipped: %v", err)
+	}
+}
+
+func TestIfdByteEncoder_SetStrictEnums(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Orientation", []uint16{9})
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	_, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	ibe.SetStrictEnums(true)
+
+	_, err = ibe.EncodeToExif(rootIb)
+	if log.Is(err, ErrEnumValueOutOfRange) != true {
+		t.Fatalf("Expected ErrEnumValueOutOfRange: %v", err)
+	}
+
+	err = rootIb.SetStandardWithName("Orientation", []uint16{6})
+	log.PanicIf(err)
+
+	_, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+}
+
+func TestIsEnumValueDefined(t *testing.T) {
+	if isEnumValueDefined("IFD", "Orientation", 8) != true {
+		t.Fatalf("Expected defined value.")
+	} else if isEnumValueDefined("IFD", "Orientation", 0) != false {
+		t.Fatalf("Expected undefined value.")
+	} else if isEnumValueDefined("IFD", "Make", 0) != true {
+		t.Fatalf("Non-enumerated tags should always be valid.")
+	}
+}
@@ -11,4 +11,8 @@ var (
 	// ErrTagNotKnown indicates that the tag is not registered with us as a
 	// known tag.
 	ErrTagNotKnown = errors.New("tag is not known")
+
+	// ErrTagNameAmbiguous indicates that a tag-name is registered in more than
+	// one IFD.
+	ErrTagNameAmbiguous = errors.New("tag name is ambiguous")
 )
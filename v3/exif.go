@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 
 	"encoding/binary"
 	"io/ioutil"
@@ -134,11 +133,104 @@ func searchAndExtractExifWithReaderWithDiscarded(r io.Reader) (rawExif []byte, d
 
 	br := bufio.NewReader(r)
 
+	// Photoshop documents store the EXIF as an image resource, so it can be
+	// located precisely rather than searched for.
+	if signature, err := br.Peek(len(psdSignature)); err == nil && IsPsd(signature) == true {
+		rawExif, discarded, err = extractExifFromPsd(br)
+		if err != nil {
+			if err == ErrNoExif {
+				return nil, 0, err
+			}
+
+			log.Panic(err)
+		}
+
+		return rawExif, discarded, nil
+	}
+
+	// JPEG 2000 files store it in a box.
+	if signature, err := br.Peek(len(jp2Signature)); err == nil && IsJp2(signature) == true {
+		rawExif, discarded, err = extractExifFromJp2(br)
+		if err != nil {
+			if err == ErrNoExif {
+				return nil, 0, err
+			}
+
+			log.Panic(err)
+		}
+
+		return rawExif, discarded, nil
+	}
+
+	// WebP files store it in a chunk, usually after the image data.
+	if signature, err := br.Peek(webpHeaderSize); err == nil && IsWebp(signature) == true {
+		rawExif, discarded, err = extractExifFromWebp(br)
+		if err != nil {
+			if err == ErrNoExif {
+				return nil, 0, err
+			}
+
+			log.Panic(err)
+		}
+
+		return rawExif, discarded, nil
+	}
+
+	// HEIF files store it in an item, which the 'meta' box locates. The item
+	// is usually in the 'mdat' box at the end, so the whole file is read.
+	if isHeif(br) == true {
+		data, err := ioutil.ReadAll(br)
+		log.PanicIf(err)
+
+		bei, offset, err := extractExifFromBmff(data)
+		if err != nil {
+			if err == ErrNoExif {
+				return nil, 0, err
+			}
+
+			log.Panic(err)
+		}
+
+		if offset >= 0 {
+			return bei.RawExif, offset, nil
+		}
+
+		// The item isn't stored in one piece in the file, so it doesn't have
+		// an offset. Find it by searching, instead.
+		br = bufio.NewReader(bytes.NewReader(data))
+	}
+
+	discarded, err = searchExifHeader(br)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, 0, err
+		}
+
+		log.Panic(err)
+	}
+
+	exifLogger.Debugf(nil, "Found EXIF blob (%d) bytes from initial position.", discarded)
+
+	rawExif, err = ioutil.ReadAll(br)
+	log.PanicIf(err)
+
+	return rawExif, discarded, nil
+}
+
+// searchExifHeader advances the reader to the beginning of the EXIF header
+// and returns the number of bytes that were skipped.
+func searchExifHeader(br *bufio.Reader) (discarded int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
 	for {
 		window, err := br.Peek(ExifSignatureLength)
 		if err != nil {
 			if err == io.EOF {
-				return nil, 0, ErrNoExif
+				return 0, ErrNoExif
 			}
 
 			log.Panic(err)
@@ -164,12 +256,7 @@ func searchAndExtractExifWithReaderWithDiscarded(r io.Reader) (rawExif []byte, d
 		break
 	}
 
-	exifLogger.Debugf(nil, "Found EXIF blob (%d) bytes from initial position.", discarded)
-
-	rawExif, err = ioutil.ReadAll(br)
-	log.PanicIf(err)
-
-	return rawExif, discarded, nil
+	return discarded, nil
 }
 
 // RELEASE(dustin): We should replace the implementation of SearchAndExtractExifWithReader with searchAndExtractExifWithReaderWithDiscarded and drop the latter.
@@ -196,29 +283,6 @@ func SearchAndExtractExifWithReader(r io.Reader) (rawExif []byte, err error) {
 	return rawExif, nil
 }
 
-// SearchFileAndExtractExif returns a slice from the beginning of the EXIF data
-// to the end of the file (it's not practical to try and calculate where the
-// data actually ends).
-func SearchFileAndExtractExif(filepath string) (rawExif []byte, err error) {
-	defer func() {
-		if state := recover(); state != nil {
-			err = log.Wrap(state.(error))
-		}
-	}()
-
-	// Open the file.
-
-	f, err := os.Open(filepath)
-	log.PanicIf(err)
-
-	defer f.Close()
-
-	rawExif, err = SearchAndExtractExifWithReader(f)
-	log.PanicIf(err)
-
-	return rawExif, nil
-}
-
 type ExifHeader struct {
 	ByteOrder      binary.ByteOrder
 	FirstIfdOffset uint32
@@ -249,19 +313,76 @@ func ParseExifHeader(data []byte) (eh ExifHeader, err error) {
 		return eh, ErrNoExif
 	}
 
-	if bytes.Equal(data[:4], ExifBigEndianSignature[:]) == true {
-		exifLogger.Debugf(nil, "Byte-order is big-endian.")
-		eh.ByteOrder = binary.BigEndian
-	} else if bytes.Equal(data[:4], ExifLittleEndianSignature[:]) == true {
-		eh.ByteOrder = binary.LittleEndian
-		exifLogger.Debugf(nil, "Byte-order is little-endian.")
-	} else {
+	// Only the standard TIFF header may begin an EXIF block. See
+	// `ParseTiffHeader()` for the other variants.
+	th, err := ParseTiffHeader(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return eh, err
+		}
+
+		log.Panic(err)
+	} else if th.IsStandard() == false {
 		return eh, ErrNoExif
 	}
 
-	eh.FirstIfdOffset = eh.ByteOrder.Uint32(data[4:8])
+	exifLogger.Debugf(nil, "Byte-order is [%v].", th.ByteOrder)
+
+	return th.ExifHeader, nil
+}
+
+// ExifHeaderLocation describes where an EXIF block starts in a byte slice.
+type ExifHeaderLocation struct {
+	ExifHeader
+
+	// Offset is the position of the TIFF header in the data.
+	Offset int
 
-	return eh, nil
+	// HasPreamble indicates that the TIFF header is preceded by the
+	// "Exif\0\0" preamble used by the JPEG APP1 segment.
+	HasPreamble bool
+
+	// Padding is the number of bytes between the preamble and the TIFF
+	// header (see `SplitJpegExifPayload()`).
+	Padding int
+}
+
+// String returns a descriptive string.
+func (ehl ExifHeaderLocation) String() string {
+	return fmt.Sprintf("ExifHeaderLocation<BYTE-ORDER=[%v] FIRST-IFD-OFFSET=(0x%02x) OFFSET=(%d) HAS-PREAMBLE=[%v] PADDING=(%d)>", ehl.ByteOrder, ehl.FirstIfdOffset, ehl.Offset, ehl.HasPreamble, ehl.Padding)
+}
+
+// ParseHeader cheaply checks whether the data starts with EXIF: a standard
+// TIFF header, optionally preceded by the "Exif\0\0" preamble and any padding
+// after it. Nothing past the header is read, so this is suitable for
+// pre-filtering data before it's parsed. `ErrNoExif` is returned if there is
+// no header.
+func ParseHeader(data []byte) (ehl ExifHeaderLocation, err error) {
+	if bytes.HasPrefix(data, jpegExifPreamble) == true {
+		padding, _, err := SplitJpegExifPayload(data)
+		if err != nil {
+			return ExifHeaderLocation{}, ErrNoExif
+		}
+
+		ehl.Offset = len(jpegExifPreamble) + len(padding)
+		ehl.HasPreamble = true
+		ehl.Padding = len(padding)
+	}
+
+	eh, err := ParseExifHeader(data[ehl.Offset:])
+	if err != nil {
+		return ExifHeaderLocation{}, err
+	}
+
+	ehl.ExifHeader = eh
+
+	return ehl, nil
+}
+
+// IsExif returns true if the data starts with EXIF. See `ParseHeader()`.
+func IsExif(data []byte) bool {
+	_, err := ParseHeader(data)
+	return err == nil
 }
 
 // Visit recursively invokes a callback for every tag.
@@ -275,7 +396,16 @@ func Visit(rootIfdIdentity *exifcommon.IfdIdentity, ifdMapping *exifcommon.IfdMa
 	eh, err = ParseExifHeader(exifData)
 	log.PanicIf(err)
 
-	ebs := NewExifReadSeekerWithBytes(exifData)
+	var ebs *ExifReadSeeker
+	if so != nil && so.BufferPool != nil {
+		// Read the data in place rather than copying it. It must not be
+		// modified during the scan.
+		ebs = NewExifReadSeeker(bytes.NewReader(exifData))
+		ebs.data = exifData
+	} else {
+		ebs = NewExifReadSeekerWithBytes(exifData)
+	}
+
 	ie := NewIfdEnumerate(ifdMapping, tagIndex, ebs, eh.ByteOrder)
 
 	_, err = ie.Scan(rootIfdIdentity, eh.FirstIfdOffset, visitor, so)
@@ -0,0 +1,429 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrTagValueNotConvertible means that a value could not be returned as
+	// the requested type.
+	ErrTagValueNotConvertible = errors.New("tag value not convertible")
+)
+
+var (
+	// exifBlobSearchIfds are the IFDs that tags given without an IFD-path are
+	// looked for in, in order, when they are not already present.
+	exifBlobSearchIfds = []*exifcommon.IfdIdentity{
+		exifcommon.IfdStandardIfdIdentity,
+		exifcommon.IfdExifStandardIfdIdentity,
+		exifcommon.IfdGpsInfoStandardIfdIdentity,
+		exifcommon.IfdExifIopStandardIfdIdentity,
+	}
+)
+
+// ExifBlob is raw EXIF data together with its parsed IFDs. It is the simplest
+// way to read and change a handful of tags:
+//
+//	eb, err := exif.NewExifBlobFromFile("photo.jpg")
+//	model, err := eb.GetString("Model")
+//	exposureTime, err := eb.GetRational("ExposureTime")
+//
+//	updated, err := eb.Rebuild(exif.NewExifEdits().Set("Artist", "someone").Delete("Software"))
+//
+// Tags are named either by their name alone (e.g. "Make"), in which case the
+// first matching tag in any IFD is used, or qualified with the IFD-path (e.g.
+// "IFD/Exif/DateTimeOriginal" or "IFD1/Compression").
+type ExifBlob struct {
+	data  []byte
+	index IfdIndex
+
+	ifdMapping *exifcommon.IfdMapping
+	tagIndex   *TagIndex
+}
+
+// NewExifBlob parses the given EXIF data.
+func NewExifBlob(exifData []byte) (eb *ExifBlob, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	eb = &ExifBlob{
+		data:       exifData,
+		index:      index,
+		ifdMapping: im,
+		tagIndex:   ti,
+	}
+
+	return eb, nil
+}
+
+// Bytes returns the raw EXIF data.
+func (eb *ExifBlob) Bytes() []byte {
+	return eb.data
+}
+
+// Index returns the parsed IFDs.
+func (eb *ExifBlob) Index() IfdIndex {
+	return eb.index
+}
+
+// RootIfd returns the first IFD.
+func (eb *ExifBlob) RootIfd() *Ifd {
+	return eb.index.RootIfd
+}
+
+// splitTagPath separates the IFD-path from the tag-name. The IFD-path is empty
+// if the name is not qualified.
+func splitTagPath(tagPath string) (fqIfdPath, tagName string) {
+	i := strings.LastIndex(tagPath, "/")
+	if i == -1 {
+		return "", tagPath
+	}
+
+	return tagPath[:i], tagPath[i+1:]
+}
+
+// Tag returns the entry for the given tag. `ErrTagNotFound` is returned if it
+// is not present.
+func (eb *ExifBlob) Tag(tagPath string) (ite *IfdTagEntry, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fqIfdPath, tagName := splitTagPath(tagPath)
+
+	for _, ifd := range eb.index.Ifds {
+		if fqIfdPath != "" && ifd.ifdIdentity.String() != fqIfdPath {
+			continue
+		}
+
+		results, err := ifd.FindTagWithName(tagName)
+		if err == nil {
+			return results[0], nil
+		} else if log.Is(err, ErrTagNotFound) == false && log.Is(err, ErrTagNotKnown) == false {
+			log.Panic(err)
+		}
+	}
+
+	return nil, ErrTagNotFound
+}
+
+// Value returns the decoded value of the given tag.
+func (eb *ExifBlob) Value(tagPath string) (value interface{}, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	ite, err := eb.Tag(tagPath)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	value, err = ite.Value()
+	log.PanicIf(err)
+
+	return value, nil
+}
+
+// GetString returns the value of the given tag as a string. ASCII values are
+// returned as-is and anything else as it is formatted for display.
+func (eb *ExifBlob) GetString(tagPath string) (value string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	ite, err := eb.Tag(tagPath)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return "", err
+		}
+
+		log.Panic(err)
+	}
+
+	if ite.TagType() == exifcommon.TypeAscii || ite.TagType() == exifcommon.TypeAsciiNoNul {
+		raw, err := ite.Value()
+		log.PanicIf(err)
+
+		return raw.(string), nil
+	}
+
+	value, err = ite.Format()
+	log.PanicIf(err)
+
+	return value, nil
+}
+
+// GetInt returns the first value of the given integer tag.
+// `ErrTagValueNotConvertible` is returned for other types.
+func (eb *ExifBlob) GetInt(tagPath string) (value int64, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	raw, err := eb.Value(tagPath)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return 0, err
+		}
+
+		log.Panic(err)
+	}
+
+	switch t := raw.(type) {
+	case []uint8:
+		if len(t) > 0 {
+			return int64(t[0]), nil
+		}
+	case []uint16:
+		if len(t) > 0 {
+			return int64(t[0]), nil
+		}
+	case []uint32:
+		if len(t) > 0 {
+			return int64(t[0]), nil
+		}
+	case []int32:
+		if len(t) > 0 {
+			return int64(t[0]), nil
+		}
+	}
+
+	return 0, ErrTagValueNotConvertible
+}
+
+// GetRational returns the first value of the given RATIONAL tag.
+// `ErrTagValueNotConvertible` is returned for other types.
+func (eb *ExifBlob) GetRational(tagPath string) (value exifcommon.Rational, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	raw, err := eb.Value(tagPath)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return value, err
+		}
+
+		log.Panic(err)
+	}
+
+	if t, ok := raw.([]exifcommon.Rational); ok == true && len(t) > 0 {
+		return t[0], nil
+	}
+
+	return value, ErrTagValueNotConvertible
+}
+
+// Thumbnail returns the thumbnail data. `ErrNoThumbnail` is returned if there
+// is none.
+func (eb *ExifBlob) Thumbnail() (data []byte, err error) {
+	ifd1 := eb.index.RootIfd.NextIfd()
+	if ifd1 == nil {
+		return nil, ErrNoThumbnail
+	}
+
+	return ifd1.Thumbnail()
+}
+
+// Gps returns the GPS information. `ErrNoGpsTags` is returned if there is
+// none.
+func (eb *ExifBlob) Gps() (gi *GpsInfo, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	gpsIfd, found := eb.index.Lookup[exifcommon.IfdGpsInfoStandardIfdIdentity.String()]
+	if found == false {
+		return nil, ErrNoGpsTags
+	}
+
+	gi, err = gpsIfd.GpsInfo()
+	if err != nil {
+		if log.Is(err, ErrNoGpsTags) == true {
+			return nil, ErrNoGpsTags
+		}
+
+		log.Panic(err)
+	}
+
+	return gi, nil
+}
+
+// exifEdit is one change to make in `ExifBlob.Rebuild()`.
+type exifEdit struct {
+	tagPath  string
+	value    interface{}
+	isDelete bool
+}
+
+// ExifEdits collects the changes to make in `ExifBlob.Rebuild()`. They are
+// applied in the order given.
+type ExifEdits struct {
+	edits []exifEdit
+}
+
+// NewExifEdits returns an empty set of edits.
+func NewExifEdits() *ExifEdits {
+	return &ExifEdits{
+		edits: make([]exifEdit, 0),
+	}
+}
+
+// Set sets the given tag to the given native value (e.g. a string or a
+// `[]uint16`), adding it if necessary. A new tag given without an IFD-path is
+// added to the first standard IFD that it belongs to.
+func (ee *ExifEdits) Set(tagPath string, value interface{}) *ExifEdits {
+	ee.edits = append(ee.edits, exifEdit{
+		tagPath: tagPath,
+		value:   value,
+	})
+
+	return ee
+}
+
+// Delete removes the given tag. It is not an error if the tag is not present.
+func (ee *ExifEdits) Delete(tagPath string) *ExifEdits {
+	ee.edits = append(ee.edits, exifEdit{
+		tagPath:  tagPath,
+		isDelete: true,
+	})
+
+	return ee
+}
+
+// resolveIfdPath returns the fully-qualified IFD-path that the given tag
+// should be changed in.
+func (eb *ExifBlob) resolveIfdPath(tagPath string) (fqIfdPath, tagName string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fqIfdPath, tagName = splitTagPath(tagPath)
+	if fqIfdPath != "" {
+		return fqIfdPath, tagName, nil
+	}
+
+	ite, err := eb.Tag(tagName)
+	if err == nil {
+		return ite.IfdPath(), tagName, nil
+	} else if err != ErrTagNotFound {
+		log.Panic(err)
+	}
+
+	for _, ii := range exifBlobSearchIfds {
+		_, err := eb.tagIndex.GetWithName(ii, tagName)
+		if err == nil {
+			return ii.String(), tagName, nil
+		} else if log.Is(err, ErrTagNotFound) == false {
+			log.Panic(err)
+		}
+	}
+
+	return "", "", ErrTagNotFound
+}
+
+// Rebuild applies the edits and returns the re-encoded EXIF. This blob is not
+// changed.
+func (eb *ExifBlob) Rebuild(edits *ExifEdits) (updated *ExifBlob, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rootIb, report, err := NewIfdBuilderFromExistingChainWithReport(eb.index.RootIfd)
+	log.PanicIf(err)
+
+	report.logSkipped()
+
+	for _, edit := range edits.edits {
+		fqIfdPath, tagName, err := eb.resolveIfdPath(edit.tagPath)
+		if err != nil {
+			if err == ErrTagNotFound {
+				if edit.isDelete == true {
+					continue
+				}
+
+				log.Panicf("tag not known: [%s]", edit.tagPath)
+			}
+
+			log.Panic(err)
+		}
+
+		if edit.isDelete == false {
+			err := rootIb.SetStandardWithNameInIfd(fqIfdPath, tagName, edit.value)
+			log.PanicIf(err)
+
+			continue
+		}
+
+		if _, found := eb.index.Lookup[fqIfdPath]; found == false {
+			continue
+		}
+
+		ib, err := GetOrCreateIbFromRootIb(rootIb, fqIfdPath)
+		log.PanicIf(err)
+
+		bt, err := ib.FindTagWithName(tagName)
+		if err != nil {
+			if log.Is(err, ErrTagEntryNotFound) == true || log.Is(err, ErrTagNotFound) == true {
+				continue
+			}
+
+			log.Panic(err)
+		}
+
+		_, err = ib.DeleteAll(bt.tagId)
+		log.PanicIf(err)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	updated, err = NewExifBlob(exifData)
+	log.PanicIf(err)
+
+	return updated, nil
+}
+
+// String returns a descriptive string.
+func (eb *ExifBlob) String() string {
+	return fmt.Sprintf("ExifBlob<SIZE=(%d) IFDS=(%d)>", len(eb.data), len(eb.index.Ifds))
+}
@@ -0,0 +1,156 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestExifBlob_Get(t *testing.T) {
+	eb, err := NewExifBlobFromFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	model, err := eb.GetString("Model")
+	log.PanicIf(err)
+
+	if model != "Canon EOS 5D Mark III" {
+		t.Fatalf("Model not correct: [%s]", model)
+	}
+
+	dateTimeOriginal, err := eb.GetString("IFD/Exif/DateTimeOriginal")
+	log.PanicIf(err)
+
+	if dateTimeOriginal != "2017:12:02 08:18:50" {
+		t.Fatalf("DateTimeOriginal not correct: [%s]", dateTimeOriginal)
+	}
+
+	iso, err := eb.GetInt("ISOSpeedRatings")
+	log.PanicIf(err)
+
+	if iso != 1600 {
+		t.Fatalf("ISOSpeedRatings not correct: (%d)", iso)
+	}
+
+	exposureTime, err := eb.GetRational("ExposureTime")
+	log.PanicIf(err)
+
+	if exposureTime.Numerator != 1 || exposureTime.Denominator != 640 {
+		t.Fatalf("ExposureTime not correct: %v", exposureTime)
+	}
+
+	compression, err := eb.GetInt("IFD1/Compression")
+	log.PanicIf(err)
+
+	if compression != 6 {
+		t.Fatalf("Compression not correct: (%d)", compression)
+	}
+
+	_, err = eb.GetInt("Model")
+	if err != ErrTagValueNotConvertible {
+		t.Fatalf("Expected ErrTagValueNotConvertible: %v", err)
+	}
+
+	_, err = eb.GetString("GPSLatitude")
+	if err != ErrTagNotFound {
+		t.Fatalf("Expected ErrTagNotFound: %v", err)
+	}
+}
+
+func TestExifBlob_Thumbnail(t *testing.T) {
+	eb, err := NewExifBlobFromFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	thumbnailData, err := eb.Thumbnail()
+	log.PanicIf(err)
+
+	expected, err := ioutil.ReadFile(getTestImageFilepath() + ".thumbnail")
+	log.PanicIf(err)
+
+	if bytes.Equal(thumbnailData, expected) != true {
+		t.Fatalf("Thumbnail not correct.")
+	}
+}
+
+func TestExifBlob_Gps(t *testing.T) {
+	eb, err := NewExifBlobFromFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	_, err = eb.Gps()
+	if err != ErrNoGpsTags {
+		t.Fatalf("Expected ErrNoGpsTags: %v", err)
+	}
+
+	eb, err = NewExifBlobFromFile(path.Join(exifcommon.GetTestAssetsPath(), "gps.jpg"))
+	log.PanicIf(err)
+
+	gi, err := eb.Gps()
+	log.PanicIf(err)
+
+	if gi.Latitude.Orientation != 'N' {
+		t.Fatalf("GPS not correct: %s", gi)
+	}
+}
+
+func TestExifBlob_Rebuild(t *testing.T) {
+	eb, err := NewExifBlobFromFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	edits := NewExifEdits().
+		Set("Model", "some model").
+		Set("ImageUniqueID", "abc123").
+		Set("GPSLatitudeRef", "S").
+		Delete("Software").
+		Delete("ImageDescription")
+
+	updated, err := eb.Rebuild(edits)
+	log.PanicIf(err)
+
+	model, err := updated.GetString("Model")
+	log.PanicIf(err)
+
+	if model != "some model" {
+		t.Fatalf("Model not updated: [%s]", model)
+	}
+
+	ite, err := updated.Tag("ImageUniqueID")
+	log.PanicIf(err)
+
+	if ite.IfdPath() != "IFD/Exif" {
+		t.Fatalf("ImageUniqueID in wrong IFD: [%s]", ite.IfdPath())
+	}
+
+	ite, err = updated.Tag("GPSLatitudeRef")
+	log.PanicIf(err)
+
+	if ite.IfdPath() != "IFD/GPSInfo" {
+		t.Fatalf("GPSLatitudeRef in wrong IFD: [%s]", ite.IfdPath())
+	}
+
+	_, err = updated.Tag("Software")
+	if err != ErrTagNotFound {
+		t.Fatalf("Software not deleted: %v", err)
+	}
+
+	// The original is unchanged.
+
+	model, err = eb.GetString("Model")
+	log.PanicIf(err)
+
+	if model != "Canon EOS 5D Mark III" {
+		t.Fatalf("Original changed: [%s]", model)
+	}
+
+	_, err = eb.Rebuild(NewExifEdits().Set("NotATag", "x"))
+	if err == nil {
+		t.Fatalf("Expected error for unknown tag.")
+	}
+}
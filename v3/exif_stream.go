@@ -0,0 +1,221 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// jpegMaxSegmentPayloadSize is the most data that a JPEG segment can
+	// carry after its length.
+	jpegMaxSegmentPayloadSize = 0xffff - 2
+)
+
+// ExifStreamTransformer copies JPEG streams while passing the tags of their
+// EXIF through a `TagTransformFn`, for services that sanitize images on the
+// way through. The stream is processed in one pass: segments other than EXIF
+// are written as they are read, and the image data is copied through, so
+// memory is bounded by the size of a segment (64K) no matter how large the
+// image is. Other containers (TIFF, HEIF, PNG) record offsets across the
+// whole file and can't be rewritten this way (see `TiffRewriter` for TIFFs).
+type ExifStreamTransformer struct {
+	ifdMapping *exifcommon.IfdMapping
+	tagIndex   *TagIndex
+	transform  TagTransformFn
+	ibe        *IfdByteEncoder
+
+	preservePadding bool
+}
+
+// NewExifStreamTransformer returns a transformer that applies `transform` to
+// every tag. A nil transform re-encodes the EXIF unchanged, which still drops
+// anything that isn't reachable from the IFDs.
+func NewExifStreamTransformer(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, transform TagTransformFn) *ExifStreamTransformer {
+	return &ExifStreamTransformer{
+		ifdMapping: ifdMapping,
+		tagIndex:   tagIndex,
+		transform:  transform,
+		ibe:        NewIfdByteEncoder(),
+	}
+}
+
+// SetEncoder sets the encoder that the transformed EXIF is written with, so
+// that its options (e.g. `ApplyEncodeProfile()`) can be chosen.
+func (est *ExifStreamTransformer) SetEncoder(ibe *IfdByteEncoder) {
+	est.ibe = ibe
+}
+
+// SetPreservePadding determines whether any padding between the "Exif\0\0"
+// preamble and the TIFF header (see `SplitJpegExifPayload()`) is written back.
+// By default, it's dropped and the segment is written the standard way.
+func (est *ExifStreamTransformer) SetPreservePadding(flag bool) {
+	est.preservePadding = flag
+}
+
+// String returns a descriptive string.
+func (est *ExifStreamTransformer) String() string {
+	return fmt.Sprintf("ExifStreamTransformer<TRANSFORM=[%v]>", est.transform != nil)
+}
+
+// TransformJpeg reads a JPEG from `r` and writes it to `w` with the tags of
+// every EXIF segment passed through the transform. The other segments, the
+// image data, and anything after it are written unchanged. The tags that
+// could not be read are not written, and are returned in the report. Since
+// the output is written as the input is read, `w` will have partial output
+// if an error is returned; callers that must not forward a partially
+// sanitized image should write to a buffer or a temporary file. Corrupt EXIF
+// is an error rather than being passed through.
+func (est *ExifStreamTransformer) TransformJpeg(r io.Reader, w io.Writer) (report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	br := bufio.NewReader(r)
+
+	soi := make([]byte, 2)
+
+	_, err = io.ReadFull(br, soi)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrNotJpeg
+	}
+
+	log.PanicIf(err)
+
+	if soi[0] != 0xff || soi[1] != JpegMarkerSoi {
+		return nil, ErrNotJpeg
+	}
+
+	_, err = w.Write(soi)
+	log.PanicIf(err)
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	for {
+		marker, err := readJpegStreamMarker(br)
+		log.PanicIf(err)
+
+		if jpegMarkerHasLength(marker) == false {
+			_, err := w.Write([]byte{0xff, marker})
+			log.PanicIf(err)
+
+			if marker == JpegMarkerEoi {
+				break
+			}
+
+			continue
+		}
+
+		header := make([]byte, 4)
+		header[0] = 0xff
+		header[1] = marker
+
+		_, err = io.ReadFull(br, header[2:])
+		log.PanicIf(err)
+
+		length := int(binary.BigEndian.Uint16(header[2:]))
+		if length < 2 {
+			log.Panicf("jpeg segment length not valid: (%d)", length)
+		}
+
+		payload := make([]byte, length-2)
+
+		_, err = io.ReadFull(br, payload)
+		log.PanicIf(err)
+
+		if marker == JpegMarkerApp1 && bytes.HasPrefix(payload, jpegExifPreamble) == true {
+			padding, rawExif, err := SplitJpegExifPayload(payload)
+			log.PanicIf(err)
+
+			rawExif, err = est.transformExif(rawExif, report)
+			log.PanicIf(err)
+
+			payload = append([]byte{}, jpegExifPreamble...)
+			if est.preservePadding == true {
+				payload = append(payload, padding...)
+			}
+
+			payload = append(payload, rawExif...)
+			if len(payload) > jpegMaxSegmentPayloadSize {
+				log.Panicf("transformed exif is too large for a jpeg segment: (%d)", len(payload))
+			}
+
+			binary.BigEndian.PutUint16(header[2:], uint16(len(payload)+2))
+		}
+
+		_, err = w.Write(header)
+		log.PanicIf(err)
+
+		_, err = w.Write(payload)
+		log.PanicIf(err)
+
+		// Nothing that we rewrite can follow the image data, so the rest is
+		// copied through without being parsed.
+		if marker == JpegMarkerSos {
+			break
+		}
+	}
+
+	_, err = io.Copy(w, br)
+	log.PanicIf(err)
+
+	return report, nil
+}
+
+// transformExif passes the tags of the given EXIF through the transform and
+// returns the re-encoded EXIF.
+func (est *ExifStreamTransformer) transformExif(rawExif []byte, report *CopyReport) (transformedExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	_, index, err := Collect(est.ifdMapping, est.tagIndex, rawExif)
+	log.PanicIf(err)
+
+	rootIb, err := newIfdBuilderFromExistingChain(index.RootIfd, report, est.transform, false)
+	log.PanicIf(err)
+
+	transformedExif, err = est.ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	return transformedExif, nil
+}
+
+// readJpegStreamMarker reads the next marker, skipping any fill bytes, and
+// returns its second byte.
+func readJpegStreamMarker(br *bufio.Reader) (marker byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	b, err := br.ReadByte()
+	log.PanicIf(err)
+
+	if b != 0xff {
+		log.Panicf("jpeg marker not found: (0x%02x)", b)
+	}
+
+	for {
+		b, err = br.ReadByte()
+		log.PanicIf(err)
+
+		if b != 0xff {
+			return b, nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"testing/iotest"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestExifStreamTransformer_TransformJpeg(t *testing.T) {
+	original, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	transform := func(ite *IfdTagEntry, value interface{}) (newValue interface{}, keep bool) {
+		if ite.TagName() == "Model" {
+			return nil, false
+		} else if ite.TagName() == "Make" {
+			return "Sanitized", true
+		}
+
+		return value, true
+	}
+
+	est := NewExifStreamTransformer(im, ti, transform)
+
+	b := new(bytes.Buffer)
+
+	// Read a byte at a time to make sure that nothing depends on the size of
+	// the reads.
+	_, err = est.TransformJpeg(iotest.OneByteReader(bytes.NewReader(original)), b)
+	log.PanicIf(err)
+
+	rawExif, segments, err := ExtractExifAndSegmentsFromJpeg(b.Bytes())
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.FindTagWithName("Model")
+	if log.Is(err, ErrTagNotFound) == false {
+		t.Fatalf("Model not removed: %v", err)
+	}
+
+	results, err := index.RootIfd.FindTagWithName("Make")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "Sanitized" {
+		t.Fatalf("Make not transformed: [%v]", value)
+	}
+
+	// Everything but the EXIF should be the same.
+
+	_, originalSegments, err := ExtractExifAndSegmentsFromJpeg(original)
+	log.PanicIf(err)
+
+	if len(segments) != len(originalSegments) {
+		t.Fatalf("Segment count not correct: (%d) != (%d)", len(segments), len(originalSegments))
+	}
+
+	for i, js := range segments {
+		ojs := originalSegments[i]
+
+		if js.Identifier == "Exif" {
+			continue
+		}
+
+		if bytes.Equal(b.Bytes()[js.Offset:js.Offset+js.Length], original[ojs.Offset:ojs.Offset+ojs.Length]) != true {
+			t.Fatalf("Segment (%d) not copied: %s", i, js)
+		}
+	}
+
+	last := originalSegments[len(originalSegments)-1]
+	trailing := original[last.Offset+last.Length:]
+
+	if bytes.HasSuffix(b.Bytes(), trailing) != true {
+		t.Fatalf("Trailing data not copied.")
+	}
+}
+
+func TestExifStreamTransformer_TransformJpeg__NotJpeg(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	est := NewExifStreamTransformer(im, NewTagIndex(), nil)
+
+	b := new(bytes.Buffer)
+
+	_, err = est.TransformJpeg(bytes.NewReader(getExifSimpleTestIbBytes()), b)
+	if err != ErrNotJpeg {
+		t.Fatalf("Expected not-JPEG error: %v", err)
+	} else if b.Len() != 0 {
+		t.Fatalf("Expected no output.")
+	}
+}
+
+func TestExifStreamTransformer_TransformJpeg__Padding(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	exifData := getExifSimpleTestIbBytes()
+	padding := []byte{0, 0, 0, 0}
+
+	original := buildTestJpeg(append(append([]byte{}, padding...), exifData...))
+
+	for _, preservePadding := range []bool{false, true} {
+		est := NewExifStreamTransformer(im, NewTagIndex(), nil)
+		est.SetPreservePadding(preservePadding)
+
+		b := new(bytes.Buffer)
+
+		_, err := est.TransformJpeg(bytes.NewReader(original), b)
+		log.PanicIf(err)
+
+		_, segments, err := ExtractExifAndSegmentsFromJpeg(b.Bytes())
+		log.PanicIf(err)
+
+		actualPadding, rawExif, err := SplitJpegExifPayload(segments[2].Payload)
+		log.PanicIf(err)
+
+		if bytes.Equal(rawExif, exifData) != true {
+			t.Fatalf("EXIF not correct (preserve=%v).", preservePadding)
+		}
+
+		if preservePadding == true {
+			if bytes.Equal(b.Bytes(), original) != true {
+				t.Fatalf("Image not reproduced exactly.")
+			}
+		} else if len(actualPadding) != 0 {
+			t.Fatalf("Padding not dropped: %v", actualPadding)
+		}
+	}
+}
@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
@@ -395,6 +398,50 @@ func TestParseExifHeader(t *testing.T) {
 	}
 }
 
+func TestParseHeader(t *testing.T) {
+	testExifData := getTestExifData()
+
+	ehl, err := ParseHeader(testExifData)
+	log.PanicIf(err)
+
+	if ehl.ByteOrder != binary.LittleEndian || ehl.FirstIfdOffset != 0x8 || ehl.Offset != 0 || ehl.HasPreamble != false {
+		t.Fatalf("Header not correct: %s", ehl)
+	}
+
+	ehl, err = ParseHeader(append([]byte("Exif\x00\x00"), testExifData[:8]...))
+	log.PanicIf(err)
+
+	if ehl.Offset != 6 || ehl.HasPreamble != true || ehl.ByteOrder != binary.LittleEndian {
+		t.Fatalf("Header with preamble not correct: %s", ehl)
+	}
+
+	ehl, err = ParseHeader(append([]byte("Exif\x00\x00\x00\x00"), testExifData[:8]...))
+	log.PanicIf(err)
+
+	if ehl.Offset != 8 || ehl.Padding != 2 || ehl.HasPreamble != true || ehl.ByteOrder != binary.LittleEndian {
+		t.Fatalf("Header with padding not correct: %s", ehl)
+	}
+
+	invalid := [][]byte{
+		nil,
+		[]byte("Exif\x00\x00"),
+		[]byte("MM\x00\x2a\x00\x00"),
+		[]byte("MM\x00\x2b\x00\x00\x00\x08"),
+		[]byte("MM\x00\x2a\x00\x00\x00\x04"),
+		[]byte("\xff\xd8\xff\xe1Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08"),
+	}
+
+	for i, data := range invalid {
+		if IsExif(data) != false {
+			t.Fatalf("Data (%d) should not be EXIF.", i)
+		}
+	}
+
+	if IsExif([]byte("MM\x00\x2a\x00\x00\x00\x08")) != true {
+		t.Fatalf("Bare header should be EXIF.")
+	}
+}
+
 func TestExif_BuildAndParseExifHeader(t *testing.T) {
 	headerBytes, err := BuildExifHeader(exifcommon.TestDefaultByteOrder, 0x11223344)
 	log.PanicIf(err)
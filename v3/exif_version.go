@@ -0,0 +1,305 @@
+package exif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+const (
+	// ExifVersionClaimed targets whatever version the ExifVersion tag of the
+	// IB being encoded claims. See `IfdByteEncoder.SetTargetExifVersion()`.
+	ExifVersionClaimed = "claimed"
+
+	// exifVersionTagId is the tag-ID of ExifVersion in the Exif IFD.
+	exifVersionTagId = 0x9000
+
+	// exifIfdTagId is the tag-ID of the pointer to the Exif IFD.
+	exifIfdTagId = 0x8769
+)
+
+// exifTagVersions are the versions of the specification (as written to
+// ExifVersion) that introduced the tags that did not exist in 2.1, by IFD
+// path and tag-ID.
+var exifTagVersions = map[string]map[uint16]string{
+	"IFD/Exif": {
+		// 2.2
+
+		0x9214: "0220", // SubjectArea
+		0xa401: "0220", // CustomRendered
+		0xa402: "0220", // ExposureMode
+		0xa403: "0220", // WhiteBalance
+		0xa404: "0220", // DigitalZoomRatio
+		0xa405: "0220", // FocalLengthIn35mmFilm
+		0xa406: "0220", // SceneCaptureType
+		0xa407: "0220", // GainControl
+		0xa408: "0220", // Contrast
+		0xa409: "0220", // Saturation
+		0xa40a: "0220", // Sharpness
+		0xa40b: "0220", // DeviceSettingDescription
+		0xa40c: "0220", // SubjectDistanceRange
+		0xa420: "0220", // ImageUniqueID
+
+		// 2.21
+
+		0xa500: "0221", // Gamma
+
+		// 2.3
+
+		0x8830: "0230", // SensitivityType
+		0x8831: "0230", // StandardOutputSensitivity
+		0x8832: "0230", // RecommendedExposureIndex
+		0x8833: "0230", // ISOSpeed
+		0x8834: "0230", // ISOSpeedLatitudeyyy
+		0x8835: "0230", // ISOSpeedLatitudezzz
+		0xa430: "0230", // CameraOwnerName
+		0xa431: "0230", // BodySerialNumber
+		0xa432: "0230", // LensSpecification
+		0xa433: "0230", // LensMake
+		0xa434: "0230", // LensModel
+		0xa435: "0230", // LensSerialNumber
+
+		// 2.31
+
+		0x9010: "0231", // OffsetTime
+		0x9011: "0231", // OffsetTimeOriginal
+		0x9012: "0231", // OffsetTimeDigitized
+		0x9400: "0231", // Temperature
+		0x9401: "0231", // Humidity
+		0x9402: "0231", // Pressure
+		0x9403: "0231", // WaterDepth
+		0x9404: "0231", // Acceleration
+		0x9405: "0231", // CameraElevationAngle
+
+		// 2.32
+
+		0xa460: "0232", // CompositeImage
+		0xa461: "0232", // SourceImageNumberOfCompositeImage
+		0xa462: "0232", // SourceExposureTimesOfCompositeImage
+	},
+	"IFD/GPSInfo": {
+		// 2.2
+
+		0x001b: "0220", // GPSProcessingMethod
+		0x001c: "0220", // GPSAreaInformation
+		0x001d: "0220", // GPSDateStamp
+		0x001e: "0220", // GPSDifferential
+
+		// 2.3
+
+		0x001f: "0230", // GPSHPositioningError
+	},
+}
+
+// TagExifVersion returns the version of the specification, as written to
+// ExifVersion (e.g. "0230"), that introduced the given tag. `found` is false
+// if the tag has been around since 2.1 or is not an EXIF tag.
+func TagExifVersion(ifdPath string, tagId uint16) (version string, found bool) {
+	version, found = exifTagVersions[ifdPath][tagId]
+	return version, found
+}
+
+// IsTagInExifVersion returns true if the given tag exists in the given
+// version of the specification.
+func IsTagInExifVersion(ifdPath string, tagId uint16, version string) bool {
+	introducedIn, found := TagExifVersion(ifdPath, tagId)
+	if found == false {
+		return true
+	}
+
+	// Versions are four digits, so they sort as strings.
+	return introducedIn <= version
+}
+
+// ExifVersionPolicy determines what happens to tags that are newer than the
+// version being targeted.
+type ExifVersionPolicy int
+
+const (
+	// ExifVersionWarn writes newer tags but logs and reports them.
+	ExifVersionWarn ExifVersionPolicy = iota
+
+	// ExifVersionDrop leaves newer tags out and reports them.
+	ExifVersionDrop
+)
+
+// String returns a descriptive string.
+func (evp ExifVersionPolicy) String() string {
+	switch evp {
+	case ExifVersionWarn:
+		return "Warn"
+	case ExifVersionDrop:
+		return "Drop"
+	}
+
+	return fmt.Sprintf("ExifVersionPolicy(%d)", int(evp))
+}
+
+// ExifVersionConflict is a tag that is newer than the targeted version.
+type ExifVersionConflict struct {
+	// FqIfdPath is the fully-qualified path of the IFD that the tag is in.
+	FqIfdPath string
+
+	// TagId is the ID of the tag.
+	TagId uint16
+
+	// TagName is the name of the tag, or empty if not known.
+	TagName string
+
+	// IntroducedIn is the version that introduced the tag.
+	IntroducedIn string
+
+	// Dropped is true if the tag was left out.
+	Dropped bool
+}
+
+// String returns a descriptive string.
+func (evc ExifVersionConflict) String() string {
+	return fmt.Sprintf("ExifVersionConflict<FQ-IFD-PATH=[%s] TAG-ID=(0x%04x) TAG-NAME=[%s] INTRODUCED-IN=[%s] DROPPED=[%v]>", evc.FqIfdPath, evc.TagId, evc.TagName, evc.IntroducedIn, evc.Dropped)
+}
+
+// SetTargetExifVersion makes the encoder check every tag against the given
+// version of the specification, as written to ExifVersion (e.g. "0220"), so
+// that a file that claims an older version doesn't carry tags that were
+// introduced later and confuse strict validators. Pass `ExifVersionClaimed`
+// to target the version in the IB's own ExifVersion tag (nothing is checked
+// if there isn't one), or an empty string to check nothing (the default).
+// Conflicts are handled according to the policy and are available from
+// `ExifVersionConflicts()` after the encode.
+func (ibe *IfdByteEncoder) SetTargetExifVersion(version string, policy ExifVersionPolicy) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.targetExifVersion = version
+	ibe.exifVersionPolicy = policy
+}
+
+// ExifVersionConflicts returns the tags that were newer than the targeted
+// version during the last encode, ordered by IFD and tag-ID.
+func (ibe *IfdByteEncoder) ExifVersionConflicts() []ExifVersionConflict {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	conflicts := make([]ExifVersionConflict, 0, len(ibe.exifVersionConflicts))
+	for _, evc := range ibe.exifVersionConflicts {
+		conflicts = append(conflicts, evc)
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].FqIfdPath != conflicts[j].FqIfdPath {
+			return conflicts[i].FqIfdPath < conflicts[j].FqIfdPath
+		}
+
+		return conflicts[i].TagId < conflicts[j].TagId
+	})
+
+	return conflicts
+}
+
+// claimedExifVersion returns the value of the ExifVersion tag in the Exif
+// IFD under the given root IB, or an empty string if there isn't one.
+func claimedExifVersion(rootIb *IfdBuilder) (version string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bt, err := rootIb.FindTag(exifIfdTagId)
+	if err != nil {
+		if log.Is(err, ErrTagEntryNotFound) == true {
+			return "", nil
+		}
+
+		log.Panic(err)
+	}
+
+	if bt.value.IsIb() == false {
+		return "", nil
+	}
+
+	bt, err = bt.value.Ib().FindTag(exifVersionTagId)
+	if err != nil {
+		if log.Is(err, ErrTagEntryNotFound) == true {
+			return "", nil
+		}
+
+		log.Panic(err)
+	}
+
+	if bt.value.IsValue() == true {
+		if ev, ok := bt.value.Value().(exifundefined.Tag9000ExifVersion); ok == true {
+			return ev.ExifVersion, nil
+		}
+	}
+
+	valueBytes, err := bt.EncodedBytes(rootIb.byteOrder)
+	log.PanicIf(err)
+
+	return strings.TrimRight(string(valueBytes), "\000"), nil
+}
+
+// resolveTargetExifVersion replaces `ExifVersionClaimed` with the version
+// that the given root IB claims. It must only be called on a session.
+func (ibe *IfdByteEncoder) resolveTargetExifVersion(rootIb *IfdBuilder) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ibe.targetExifVersion != ExifVersionClaimed {
+		return nil
+	}
+
+	ibe.targetExifVersion, err = claimedExifVersion(rootIb)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// isTooNewForTarget returns true if the tag was introduced after the targeted
+// version, recording the conflict the first time that it is seen.
+func (ibe *IfdByteEncoder) isTooNewForTarget(ib *IfdBuilder, bt *BuilderTag) bool {
+	if ibe.targetExifVersion == "" {
+		return false
+	}
+
+	ifdPath := ib.IfdIdentity().UnindexedString()
+
+	if IsTagInExifVersion(ifdPath, bt.tagId, ibe.targetExifVersion) == true {
+		return false
+	}
+
+	key := ValueOffsetKey{
+		FqIfdPath: ib.IfdIdentity().NewSibling(ibe.chainIndices[ib]).String(),
+		TagId:     bt.tagId,
+	}
+
+	if _, found := ibe.exifVersionConflicts[key]; found == false {
+		introducedIn, _ := TagExifVersion(ifdPath, bt.tagId)
+
+		tagName := ""
+		if it, err := ib.tagIndex.Get(ib.IfdIdentity(), bt.tagId); err == nil {
+			tagName = it.Name
+		}
+
+		evc := ExifVersionConflict{
+			FqIfdPath:    key.FqIfdPath,
+			TagId:        bt.tagId,
+			TagName:      tagName,
+			IntroducedIn: introducedIn,
+			Dropped:      ibe.exifVersionPolicy == ExifVersionDrop,
+		}
+
+		ibe.exifVersionConflicts[key] = evc
+
+		ifdBuilderLogger.Warningf(nil, "Tag (0x%04x) [%s] in IFD [%s] was introduced in EXIF [%s] but [%s] is targeted (%s).", bt.tagId, tagName, key.FqIfdPath, introducedIn, ibe.targetExifVersion, ibe.exifVersionPolicy)
+	}
+
+	return true
+}
@@ -0,0 +1,183 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+func getExifVersionTestIb() *IfdBuilder {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	exifIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/Exif")
+	log.PanicIf(err)
+
+	ev := exifundefined.Tag9000ExifVersion{
+		ExifVersion: "0220",
+	}
+
+	err = exifIb.AddStandardWithName("ExifVersion", ev)
+	log.PanicIf(err)
+
+	err = exifIb.AddStandardWithName("ExposureMode", []uint16{1})
+	log.PanicIf(err)
+
+	err = exifIb.AddStandardWithName("LensModel", "Some Lens")
+	log.PanicIf(err)
+
+	err = exifIb.AddStandardWithName("BodySerialNumber", "12345")
+	log.PanicIf(err)
+
+	return rootIb
+}
+
+func getExifVersionTestTagNames(exifData []byte) []string {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	exifIfd := index.Lookup["IFD/Exif"]
+
+	names := make([]string, len(exifIfd.Entries()))
+	for i, ite := range exifIfd.Entries() {
+		names[i] = ite.TagName()
+	}
+
+	return names
+}
+
+func TestIsTagInExifVersion(t *testing.T) {
+	if IsTagInExifVersion("IFD/Exif", 0xa434, "0230") != true {
+		t.Fatalf("LensModel should be in 2.3.")
+	} else if IsTagInExifVersion("IFD/Exif", 0xa434, "0221") != false {
+		t.Fatalf("LensModel should not be in 2.21.")
+	} else if IsTagInExifVersion("IFD/Exif", 0x829a, "0210") != true {
+		t.Fatalf("ExposureTime should be in 2.1.")
+	} else if IsTagInExifVersion("IFD/GPSInfo", 0x001f, "0220") != false {
+		t.Fatalf("GPSHPositioningError should not be in 2.2.")
+	}
+}
+
+func TestIfdByteEncoder_SetTargetExifVersion__Warn(t *testing.T) {
+	rootIb := getExifVersionTestIb()
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetTargetExifVersion("0220", ExifVersionWarn)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	expectedConflicts := []ExifVersionConflict{
+		{FqIfdPath: "IFD/Exif", TagId: 0xa431, TagName: "BodySerialNumber", IntroducedIn: "0230"},
+		{FqIfdPath: "IFD/Exif", TagId: 0xa434, TagName: "LensModel", IntroducedIn: "0230"},
+	}
+
+	conflicts := ibe.ExifVersionConflicts()
+	if reflect.DeepEqual(conflicts, expectedConflicts) != true {
+		t.Fatalf("Conflicts not correct: %v", conflicts)
+	}
+
+	names := getExifVersionTestTagNames(exifData)
+
+	expectedNames := []string{
+		"ExifVersion",
+		"ExposureMode",
+		"LensModel",
+		"BodySerialNumber",
+	}
+
+	if reflect.DeepEqual(names, expectedNames) != true {
+		t.Fatalf("Tags not correct: %v", names)
+	}
+}
+
+func TestIfdByteEncoder_SetTargetExifVersion__Drop(t *testing.T) {
+	rootIb := getExifVersionTestIb()
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetTargetExifVersion("0220", ExifVersionDrop)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	conflicts := ibe.ExifVersionConflicts()
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected two conflicts: %v", conflicts)
+	} else if conflicts[0].Dropped != true || conflicts[1].Dropped != true {
+		t.Fatalf("Conflicts should be marked as dropped: %v", conflicts)
+	}
+
+	names := getExifVersionTestTagNames(exifData)
+
+	expectedNames := []string{
+		"ExifVersion",
+		"ExposureMode",
+	}
+
+	if reflect.DeepEqual(names, expectedNames) != true {
+		t.Fatalf("Tags not correct: %v", names)
+	}
+
+	// The builder itself is untouched.
+
+	exifIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/Exif")
+	log.PanicIf(err)
+
+	_, err = exifIb.FindTagWithName("LensModel")
+	log.PanicIf(err)
+}
+
+func TestIfdByteEncoder_SetTargetExifVersion__Claimed(t *testing.T) {
+	rootIb := getExifVersionTestIb()
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetTargetExifVersion(ExifVersionClaimed, ExifVersionDrop)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	names := getExifVersionTestTagNames(exifData)
+
+	expectedNames := []string{
+		"ExifVersion",
+		"ExposureMode",
+	}
+
+	if reflect.DeepEqual(names, expectedNames) != true {
+		t.Fatalf("Tags not correct: %v", names)
+	}
+}
+
+func TestIfdByteEncoder_SetTargetExifVersion__Disabled(t *testing.T) {
+	rootIb := getExifVersionTestIb()
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	if len(ibe.ExifVersionConflicts()) != 0 {
+		t.Fatalf("Expected no conflicts: %v", ibe.ExifVersionConflicts())
+	}
+
+	names := getExifVersionTestTagNames(exifData)
+	if len(names) != 4 {
+		t.Fatalf("Expected all tags: %v", names)
+	}
+}
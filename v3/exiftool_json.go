@@ -0,0 +1,857 @@
+package exif
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"encoding/json"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// exiftoolTagNames are the ExifTool names that differ from ours.
+	exiftoolTagNames = map[string]string{
+		"ModifyDate":              "DateTime",
+		"CreateDate":              "DateTimeDigitized",
+		"ISO":                     "ISOSpeedRatings",
+		"ExifImageWidth":          "PixelXDimension",
+		"ExifImageHeight":         "PixelYDimension",
+		"ImageHeight":             "ImageLength",
+		"ExposureCompensation":    "ExposureBiasValue",
+		"FocalLengthIn35mmFormat": "FocalLengthIn35mmFilm",
+		"SerialNumber":            "BodySerialNumber",
+		"OwnerName":               "CameraOwnerName",
+		"InteropIndex":            "InteroperabilityIndex",
+	}
+
+	// exiftoolGroupIfdPaths are the ExifTool family-1 groups that correspond
+	// to one of our IFDs.
+	exiftoolGroupIfdPaths = map[string]string{
+		"IFD0":       exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		"ExifIFD":    exifcommon.IfdExifStandardIfdIdentity.UnindexedString(),
+		"GPS":        exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString(),
+		"InteropIFD": exifcommon.IfdExifIopStandardIfdIdentity.UnindexedString(),
+	}
+
+	// exiftoolNameGroups are the groups whose tags are placed by name alone:
+	// no group, the family-0 EXIF group, and the composite tags (of which
+	// only the GPS ones will be found).
+	exiftoolNameGroups = map[string]bool{
+		"":          true,
+		"EXIF":      true,
+		"Composite": true,
+	}
+
+	// exiftoolIfdPathPreference decides where a name that is registered in
+	// more than one IFD goes.
+	exiftoolIfdPathPreference = []string{
+		exifcommon.IfdExifStandardIfdIdentity.UnindexedString(),
+		exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString(),
+		exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		exifcommon.IfdExifIopStandardIfdIdentity.UnindexedString(),
+	}
+
+	// exiftoolApexTagNames are the APEX tags, which ExifTool converts to an
+	// exposure time (the shutter speed) or an f-number (the apertures).
+	exiftoolApexTagNames = map[string]bool{
+		"ShutterSpeedValue": true,
+		"ApertureValue":     true,
+		"MaxApertureValue":  true,
+	}
+
+	// exiftoolGpsValues are the ExifTool descriptions of the codes of the
+	// GPS ASCII tags, in lowercase.
+	exiftoolGpsValues = map[string]string{
+		"north":                     "N",
+		"south":                     "S",
+		"east":                      "E",
+		"west":                      "W",
+		"km/h":                      "K",
+		"mph":                       "M",
+		"knots":                     "N",
+		"kilometers":                "K",
+		"miles":                     "M",
+		"nautical miles":            "N",
+		"true north":                "T",
+		"magnetic north":            "M",
+		"measurement active":        "A",
+		"measurement void":          "V",
+		"2-dimensional measurement": "2",
+		"3-dimensional measurement": "3",
+	}
+
+	// exiftoolCoordinateRegex matches a coordinate in ExifTool's notation
+	// (e.g. `54 deg 59' 22.80" N`) or in decimal degrees, with an optional
+	// hemisphere. The sign is removed before matching.
+	exiftoolCoordinateRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:\s*deg(?:\s*(\d+(?:\.\d+)?)')?(?:\s*(\d+(?:\.\d+)?)")?)?\s*([NSEWnsew])?$`)
+)
+
+// ExiftoolSkippedField is a field of ExifTool JSON that wasn't imported.
+type ExiftoolSkippedField struct {
+	// Key is the key of the field. Keys of nested objects are joined to their
+	// parent's key with a colon, like ExifTool's own group prefixes.
+	Key string
+
+	// Reason describes why it was skipped.
+	Reason string
+}
+
+// String returns a descriptive string.
+func (esf ExiftoolSkippedField) String() string {
+	return fmt.Sprintf("ExiftoolSkippedField<KEY=[%s] REASON=[%s]>", esf.Key, esf.Reason)
+}
+
+// ImportExiftoolJson sets the tags described by the JSON that ExifTool writes
+// with `-j` (optionally with `-n`, `-G`, `-g`, or `-struct`) on the IB chain.
+// The JSON is either one object or a list with exactly one. ExifTool's names
+// are translated to ours, group prefixes and nested groups are used to pick
+// the IFD, and the converted values that ExifTool prints are translated back:
+// coordinates such as `54 deg 59' 22.80" N` (which also set the reference),
+// altitudes such as "12 m Below Sea Level", the GPS position, descriptions of
+// the GPS reference codes, units, exposure times and f-numbers of the APEX
+// tags, and "1/200"-style fractions. Fields are applied in the order of their
+// keys, so a reference (e.g. GPSLatitudeRef) wins over the hemisphere of its
+// coordinate. Fields that aren't EXIF tags or whose values can't be
+// translated (e.g. enumerations that were printed as descriptions rather than
+// with `-n`) are returned rather than failing the import.
+func ImportExiftoolJson(rootIb *IfdBuilder, data []byte) (skipped []ExiftoolSkippedField, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var decoded interface{}
+
+	err = json.Unmarshal(data, &decoded)
+	log.PanicIf(err)
+
+	if list, ok := decoded.([]interface{}); ok == true {
+		if len(list) != 1 {
+			log.Panicf("exiftool json must describe exactly one file: (%d)", len(list))
+		}
+
+		decoded = list[0]
+	}
+
+	object, ok := decoded.(map[string]interface{})
+	if ok == false {
+		log.Panicf("exiftool json must be an object")
+	}
+
+	ei := &exiftoolImporter{
+		rootIb:  rootIb,
+		skipped: make([]ExiftoolSkippedField, 0),
+	}
+
+	err = ei.importObject("", "", object)
+	log.PanicIf(err)
+
+	return ei.skipped, nil
+}
+
+// exiftoolImporter applies ExifTool fields to an IB chain.
+type exiftoolImporter struct {
+	rootIb  *IfdBuilder
+	skipped []ExiftoolSkippedField
+}
+
+func (ei *exiftoolImporter) skip(key string, format string, args ...interface{}) {
+	esf := ExiftoolSkippedField{
+		Key:    key,
+		Reason: fmt.Sprintf(format, args...),
+	}
+
+	ei.skipped = append(ei.skipped, esf)
+}
+
+// importObject imports the fields of the object in the order of their keys.
+// Nested objects are groups (`-g`) or structures (`-struct`).
+func (ei *exiftoolImporter) importObject(keyPrefix, group string, object map[string]interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fullKey := key
+		if keyPrefix != "" {
+			fullKey = keyPrefix + ":" + key
+		}
+
+		// Family-0 and family-1 prefixes may both be present (e.g.
+		// "EXIF:GPS:GPSLatitude"). The innermost one is the most specific.
+		fieldGroup := group
+		name := key
+
+		if i := strings.LastIndex(key, ":"); i >= 0 {
+			name = key[i+1:]
+
+			parts := strings.Split(key[:i], ":")
+			fieldGroup = parts[len(parts)-1]
+		}
+
+		if nested, ok := object[key].(map[string]interface{}); ok == true {
+			err := ei.importObject(fullKey, name, nested)
+			log.PanicIf(err)
+
+			continue
+		}
+
+		err := ei.importField(fullKey, fieldGroup, name, object[key])
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// importField imports one field, or records why it can't be.
+func (ei *exiftoolImporter) importField(key, group, name string, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if alias, found := exiftoolTagNames[name]; found == true {
+		name = alias
+	}
+
+	ifdPath, found := exiftoolGroupIfdPaths[group]
+	if found == false && exiftoolNameGroups[group] == false {
+		ei.skip(key, "group [%s] is not an exif group", group)
+		return nil
+	}
+
+	if name == "GPSPosition" {
+		err := ei.importGpsPosition(key, value)
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	ti := ei.rootIb.tagIndex
+
+	var it *IndexedTag
+	if found == true {
+		ii, err := exifcommon.NewIfdIdentityFromString(ei.rootIb.ifdMapping, ifdPath)
+		log.PanicIf(err)
+
+		it, err = ti.GetWithName(ii, name)
+		if err != nil {
+			if log.Is(err, ErrTagNotFound) == true {
+				ei.skip(key, "tag [%s] is not known in ifd [%s]", name, ifdPath)
+				return nil
+			}
+
+			log.Panic(err)
+		}
+	} else {
+		locations, err := ti.Lookup(name)
+		if err != nil {
+			if log.Is(err, ErrTagNotFound) == true {
+				ei.skip(key, "tag [%s] is not known", name)
+				return nil
+			}
+
+			log.Panic(err)
+		}
+
+		it = exiftoolPreferredLocation(locations).Tag
+		ifdPath = it.IfdPath
+	}
+
+	ib, err := GetOrCreateIbFromRootIb(ei.rootIb, ifdPath)
+	log.PanicIf(err)
+
+	if ifdPath == exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString() {
+		handled, err := ei.importGpsField(key, ib, it, value)
+		log.PanicIf(err)
+
+		if handled == true {
+			return nil
+		}
+	}
+
+	if exiftoolApexTagNames[it.Name] == true {
+		value, err = exiftoolApexValue(it.Name, value)
+		if err != nil {
+			ei.skip(key, "%s", err.Error())
+			return nil
+		}
+	}
+
+	converted, err := exiftoolTagValue(it.SupportedTypes[0], value)
+	if err != nil {
+		ei.skip(key, "%s", err.Error())
+		return nil
+	}
+
+	err = ib.SetStandard(it.Id, converted)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// importGpsField handles the GPS tags whose ExifTool values aren't just a
+// formatting of the tag value. `handled` is false for any others.
+func (ei *exiftoolImporter) importGpsField(key string, ib *IfdBuilder, it *IndexedTag, value interface{}) (handled bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for i, tagIds := range gpsCoordinateTagIds {
+		if it.Id != tagIds[0] {
+			continue
+		}
+
+		err := ei.setGpsCoordinate(key, ib, tagIds, i%2 == 0, value)
+		log.PanicIf(err)
+
+		return true, nil
+	}
+
+	switch it.Id {
+	case TagAltitudeId:
+		meters, err := exiftoolGpsAltitude(value)
+		if err != nil {
+			ei.skip(key, "%s", err.Error())
+			return true, nil
+		}
+
+		err = ib.SetGpsAltitude(meters)
+		log.PanicIf(err)
+
+		return true, nil
+	case TagAltitudeRefId:
+		// Numeric references are handled like any other BYTE.
+		phrase := strings.ToLower(fmt.Sprintf("%v", value))
+
+		var ref byte
+		if strings.Contains(phrase, "below") == true || strings.Contains(phrase, "negative") == true {
+			ref = GpsAltitudeRefBelowSeaLevel
+		} else if strings.Contains(phrase, "above") == true || strings.Contains(phrase, "positive") == true {
+			ref = GpsAltitudeRefAboveSeaLevel
+		} else {
+			return false, nil
+		}
+
+		err := ib.SetStandard(it.Id, []byte{ref})
+		log.PanicIf(err)
+
+		return true, nil
+	case TagTimestampId:
+		timestamp, err := exiftoolGpsTimestamp(value)
+		if err != nil {
+			ei.skip(key, "%s", err.Error())
+			return true, nil
+		}
+
+		err = ib.SetStandard(it.Id, timestamp)
+		log.PanicIf(err)
+
+		return true, nil
+	}
+
+	if phrase, ok := value.(string); ok == true && it.SupportedTypes[0] == exifcommon.TypeAscii {
+		if code, found := exiftoolGpsValues[strings.ToLower(phrase)]; found == true {
+			err := ib.SetStandard(it.Id, code)
+			log.PanicIf(err)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// importGpsPosition imports the composite position, which is the latitude
+// and the longitude separated by a comma (or a space, with `-n`).
+func (ei *exiftoolImporter) importGpsPosition(key string, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	phrase, ok := value.(string)
+	if ok == false {
+		ei.skip(key, "position is not a string")
+		return nil
+	}
+
+	parts := strings.Split(phrase, ",")
+	if len(parts) != 2 {
+		parts = strings.Fields(phrase)
+	}
+
+	if len(parts) != 2 {
+		ei.skip(key, "position does not have two coordinates: [%s]", phrase)
+		return nil
+	}
+
+	ib, err := GetOrCreateIbFromRootIb(ei.rootIb, exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString())
+	log.PanicIf(err)
+
+	for i, part := range parts {
+		err := ei.setGpsCoordinate(key, ib, gpsCoordinateTagIds[i], i == 0, strings.TrimSpace(part))
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// setGpsCoordinate sets the coordinate and, if the value has a hemisphere or
+// a sign, its reference.
+func (ei *exiftoolImporter) setGpsCoordinate(key string, ib *IfdBuilder, tagIds [2]uint16, isLatitude bool, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	decimal, hemisphere, err := exiftoolGpsCoordinate(value, isLatitude)
+	if err != nil {
+		ei.skip(key, "%s", err.Error())
+		return nil
+	}
+
+	err = ib.SetStandard(tagIds[0], gpsDecimalToRationals(decimal))
+	log.PanicIf(err)
+
+	if hemisphere != "" {
+		err = ib.SetStandard(tagIds[1], hemisphere)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// exiftoolPreferredLocation returns the location that a name registered in
+// several IFDs is imported to.
+func exiftoolPreferredLocation(locations []TagLocation) TagLocation {
+	for _, ifdPath := range exiftoolIfdPathPreference {
+		for _, tl := range locations {
+			if tl.IfdPath == ifdPath {
+				return tl
+			}
+		}
+	}
+
+	return locations[0]
+}
+
+// exiftoolGpsCoordinate parses a coordinate. The hemisphere is taken from the
+// letter, if there is one, or else the sign of a decimal value. It is empty
+// if the value is an unsigned number in ExifTool's notation without a letter.
+func exiftoolGpsCoordinate(value interface{}, isLatitude bool) (decimal float64, hemisphere string, err error) {
+	positive, negative := "E", "W"
+	if isLatitude == true {
+		positive, negative = "N", "S"
+	}
+
+	if number, ok := value.(float64); ok == true {
+		if number < 0 {
+			return -number, negative, nil
+		}
+
+		return number, positive, nil
+	}
+
+	phrase, ok := value.(string)
+	if ok == false {
+		return 0, "", fmt.Errorf("coordinate is not a number or a string: [%v]", value)
+	}
+
+	phrase = strings.TrimSpace(phrase)
+
+	isNegative := strings.HasPrefix(phrase, "-")
+	phrase = strings.TrimLeft(phrase, "+-")
+
+	matches := exiftoolCoordinateRegex.FindStringSubmatch(phrase)
+	if matches == nil {
+		return 0, "", fmt.Errorf("coordinate not valid: [%s]", phrase)
+	}
+
+	for i, part := range matches[1:4] {
+		if part == "" {
+			continue
+		}
+
+		component, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, "", err
+		}
+
+		decimal += component / math.Pow(60, float64(i))
+	}
+
+	if letter := strings.ToUpper(matches[4]); letter != "" {
+		if letter != positive && letter != negative {
+			return 0, "", fmt.Errorf("coordinate hemisphere not valid: [%s]", letter)
+		}
+
+		hemisphere = letter
+	} else if isNegative == true {
+		hemisphere = negative
+	} else if strings.Contains(phrase, "deg") == false {
+		hemisphere = positive
+	}
+
+	if isNegative == true && hemisphere != negative {
+		return 0, "", fmt.Errorf("coordinate has a sign and a hemisphere: [%s]", phrase)
+	}
+
+	return decimal, hemisphere, nil
+}
+
+// exiftoolGpsAltitude parses an altitude such as "12.5 m Below Sea Level" or
+// a number of meters.
+func exiftoolGpsAltitude(value interface{}) (meters float64, err error) {
+	if number, ok := value.(float64); ok == true {
+		return number, nil
+	}
+
+	phrase, ok := value.(string)
+	if ok == false {
+		return 0, fmt.Errorf("altitude is not a number or a string: [%v]", value)
+	}
+
+	fields := strings.Fields(phrase)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("altitude is empty")
+	}
+
+	meters, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("altitude not valid: [%s]", phrase)
+	}
+
+	if strings.Contains(strings.ToLower(phrase), "below") == true {
+		meters = -meters
+	}
+
+	return meters, nil
+}
+
+// exiftoolGpsTimestamp parses a time of day such as "12:34:56.78".
+func exiftoolGpsTimestamp(value interface{}) (timestamp []exifcommon.Rational, err error) {
+	phrase, ok := value.(string)
+	if ok == false {
+		return nil, fmt.Errorf("timestamp is not a string: [%v]", value)
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimSpace(phrase), "Z"), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("timestamp not valid: [%s]", phrase)
+	}
+
+	timestamp = make([]exifcommon.Rational, 3)
+	for i, part := range parts {
+		component, err := strconv.ParseFloat(part, 64)
+		if err != nil || component < 0 {
+			return nil, fmt.Errorf("timestamp not valid: [%s]", phrase)
+		}
+
+		if i < 2 {
+			timestamp[i] = exifcommon.Rational{Numerator: uint32(component), Denominator: 1}
+		} else {
+			timestamp[i] = exifcommon.Rational{Numerator: uint32(math.Round(component * 100)), Denominator: 100}
+		}
+	}
+
+	return timestamp, nil
+}
+
+// exiftoolApexValue converts the exposure time or f-number that ExifTool
+// reports for an APEX tag back to APEX units.
+func exiftoolApexValue(tagName string, value interface{}) (apex float64, err error) {
+	tokens, err := exiftoolTokens(value)
+	if err != nil {
+		return 0, err
+	} else if len(tokens) != 1 {
+		return 0, fmt.Errorf("apex value must be a single number: [%v]", value)
+	}
+
+	number, err := exiftoolFloat(strings.TrimPrefix(tokens[0], "f/"))
+	if err != nil {
+		return 0, err
+	} else if number <= 0 {
+		return 0, fmt.Errorf("apex value must be positive: [%v]", value)
+	}
+
+	if tagName == "ShutterSpeedValue" {
+		return -math.Log2(number), nil
+	}
+
+	return 2 * math.Log2(number), nil
+}
+
+// exiftoolTokens splits a value into its numbers, dropping any trailing
+// units (e.g. "mm").
+func exiftoolTokens(value interface{}) (tokens []string, err error) {
+	switch t := value.(type) {
+	case float64:
+		return []string{strconv.FormatFloat(t, 'f', -1, 64)}, nil
+	case string:
+		tokens = strings.FieldsFunc(t, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+
+		for len(tokens) > 0 && strings.IndexAny(tokens[len(tokens)-1], "0123456789") == -1 {
+			tokens = tokens[:len(tokens)-1]
+		}
+
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("value is not numeric: [%s]", t)
+		}
+
+		return tokens, nil
+	case []interface{}:
+		tokens = make([]string, 0, len(t))
+		for _, element := range t {
+			elementTokens, err := exiftoolTokens(element)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, elementTokens...)
+		}
+
+		return tokens, nil
+	}
+
+	return nil, fmt.Errorf("value type not supported: [%v]", value)
+}
+
+// exiftoolFloat parses a decimal or a fraction.
+func exiftoolFloat(token string) (number float64, err error) {
+	if parts := strings.SplitN(token, "/", 2); len(parts) == 2 {
+		numerator, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("fraction not valid: [%s]", token)
+		}
+
+		denominator, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || denominator == 0 {
+			return 0, fmt.Errorf("fraction not valid: [%s]", token)
+		}
+
+		return numerator / denominator, nil
+	}
+
+	number, err = strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, fmt.Errorf("number not valid: [%s]", token)
+	}
+
+	return number, nil
+}
+
+// exiftoolFraction returns the token as a fraction. Fractions are kept as
+// they are. Decimals that are the reciprocal of an integer (exposure times)
+// become 1/N and others are approximated in ten-thousandths.
+func exiftoolFraction(token string) (numerator, denominator int64, err error) {
+	if parts := strings.SplitN(token, "/", 2); len(parts) == 2 {
+		numerator, err = strconv.ParseInt(strings.TrimPrefix(parts[0], "+"), 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("fraction not valid: [%s]", token)
+		}
+
+		denominator, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || denominator <= 0 {
+			return 0, 0, fmt.Errorf("fraction not valid: [%s]", token)
+		}
+
+		return numerator, denominator, nil
+	}
+
+	number, err := exiftoolFloat(token)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if number == math.Trunc(number) {
+		return int64(number), 1, nil
+	}
+
+	sign := int64(1)
+	if number < 0 {
+		sign = -1
+	}
+
+	if reciprocal := 1 / math.Abs(number); math.Abs(number) < 1 && math.Abs(reciprocal-math.Round(reciprocal)) < 1e-9*reciprocal {
+		return sign, int64(math.Round(reciprocal)), nil
+	}
+
+	numerator = int64(math.Round(number * 10000))
+	denominator = 10000
+
+	a, b := numerator*sign, denominator
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return numerator / a, denominator / a, nil
+}
+
+// exiftoolInteger parses an integer within the given range. Decimals are
+// accepted if they're whole.
+func exiftoolInteger(token string, minimum, maximum float64) (number int64, err error) {
+	value, err := exiftoolFloat(token)
+	if err != nil {
+		return 0, err
+	} else if value != math.Trunc(value) || value < minimum || value > maximum {
+		return 0, fmt.Errorf("integer not valid: [%s]", token)
+	}
+
+	return int64(value), nil
+}
+
+// exiftoolTagValue converts an ExifTool value to a value of the given type.
+func exiftoolTagValue(tagType exifcommon.TagTypePrimitive, value interface{}) (converted interface{}, err error) {
+	if tagType == exifcommon.TypeAscii || tagType == exifcommon.TypeAsciiNoNul {
+		switch t := value.(type) {
+		case string:
+			return t, nil
+		case float64:
+			return strconv.FormatFloat(t, 'f', -1, 64), nil
+		}
+
+		return nil, fmt.Errorf("value is not a string: [%v]", value)
+	} else if tagType == exifcommon.TypeUndefined {
+		return nil, fmt.Errorf("undefined-type tags are not imported")
+	}
+
+	tokens, err := exiftoolTokens(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Version numbers (e.g. GPSVersionID) are printed with dots.
+	if tagType == exifcommon.TypeByte && len(tokens) == 1 && strings.Count(tokens[0], ".") > 1 {
+		tokens = strings.Split(tokens[0], ".")
+	}
+
+	switch tagType {
+	case exifcommon.TypeByte:
+		typed := make([]byte, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolInteger(token, 0, math.MaxUint8)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = byte(n)
+		}
+
+		return typed, nil
+	case exifcommon.TypeShort:
+		typed := make([]uint16, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolInteger(token, 0, math.MaxUint16)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = uint16(n)
+		}
+
+		return typed, nil
+	case exifcommon.TypeLong:
+		typed := make([]uint32, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolInteger(token, 0, math.MaxUint32)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = uint32(n)
+		}
+
+		return typed, nil
+	case exifcommon.TypeSignedLong:
+		typed := make([]int32, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolInteger(token, math.MinInt32, math.MaxInt32)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = int32(n)
+		}
+
+		return typed, nil
+	case exifcommon.TypeRational:
+		typed := make([]exifcommon.Rational, len(tokens))
+		for i, token := range tokens {
+			numerator, denominator, err := exiftoolFraction(token)
+			if err != nil {
+				return nil, err
+			} else if numerator < 0 || numerator > math.MaxUint32 || denominator > math.MaxUint32 {
+				return nil, fmt.Errorf("rational not valid: [%s]", token)
+			}
+
+			typed[i] = exifcommon.Rational{Numerator: uint32(numerator), Denominator: uint32(denominator)}
+		}
+
+		return typed, nil
+	case exifcommon.TypeSignedRational:
+		typed := make([]exifcommon.SignedRational, len(tokens))
+		for i, token := range tokens {
+			numerator, denominator, err := exiftoolFraction(token)
+			if err != nil {
+				return nil, err
+			} else if numerator < math.MinInt32 || numerator > math.MaxInt32 || denominator > math.MaxInt32 {
+				return nil, fmt.Errorf("signed rational not valid: [%s]", token)
+			}
+
+			typed[i] = exifcommon.SignedRational{Numerator: int32(numerator), Denominator: int32(denominator)}
+		}
+
+		return typed, nil
+	case exifcommon.TypeFloat:
+		typed := make([]float32, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolFloat(token)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = float32(n)
+		}
+
+		return typed, nil
+	case exifcommon.TypeDouble:
+		typed := make([]float64, len(tokens))
+		for i, token := range tokens {
+			n, err := exiftoolFloat(token)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[i] = n
+		}
+
+		return typed, nil
+	}
+
+	return nil, fmt.Errorf("type not supported: [%s]", tagType)
+}
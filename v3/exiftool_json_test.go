@@ -0,0 +1,205 @@
+package exif
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func importTestExiftoolJson(data string) (index IfdIndex, skipped []ExiftoolSkippedField) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.EncodeDefaultByteOrder)
+
+	skipped, err = ImportExiftoolJson(rootIb, []byte(data))
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index, skipped
+}
+
+func getTestTagValue(index IfdIndex, ifdPath, tagName string) interface{} {
+	ifd, err := FindIfdFromRootIfd(index.RootIfd, ifdPath)
+	log.PanicIf(err)
+
+	results, err := ifd.FindTagWithName(tagName)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	return value
+}
+
+func TestImportExiftoolJson_Printed(t *testing.T) {
+	data := `[{
+		"SourceFile": "image.jpg",
+		"EXIF:Make": "Canon",
+		"EXIF:ModifyDate": "2020:01:02 03:04:05",
+		"EXIF:ExposureTime": "1/200",
+		"EXIF:FNumber": 5.6,
+		"EXIF:FocalLength": "50.0 mm",
+		"EXIF:ISO": 400,
+		"EXIF:ShutterSpeedValue": "1/256",
+		"EXIF:Orientation": "Horizontal (normal)",
+		"EXIF:GPSVersionID": "2.3.0.0",
+		"EXIF:GPSLatitude": "54 deg 59' 22.80\"",
+		"EXIF:GPSLatitudeRef": "North",
+		"EXIF:GPSAltitude": "12.5 m Below Sea Level",
+		"EXIF:GPSTimeStamp": "12:34:56.78",
+		"Composite:GPSLongitude": "1 deg 54' 55.20\" W",
+		"XMP:Rating": 5
+	}]`
+
+	index, skipped := importTestExiftoolJson(data)
+
+	if value := getTestTagValue(index, "IFD", "Make"); value != "Canon" {
+		t.Fatalf("Make not correct: [%v]", value)
+	} else if value := getTestTagValue(index, "IFD", "DateTime"); value != "2020:01:02 03:04:05" {
+		t.Fatalf("DateTime not correct: [%v]", value)
+	}
+
+	rationals := map[string]exifcommon.Rational{
+		"ExposureTime": {Numerator: 1, Denominator: 200},
+		"FNumber":      {Numerator: 28, Denominator: 5},
+		"FocalLength":  {Numerator: 50, Denominator: 1},
+	}
+
+	for tagName, expected := range rationals {
+		value := getTestTagValue(index, "IFD/Exif", tagName).([]exifcommon.Rational)
+		if value[0] != expected {
+			t.Fatalf("%s not correct: %v", tagName, value)
+		}
+	}
+
+	if value := getTestTagValue(index, "IFD/Exif", "ISOSpeedRatings").([]uint16); value[0] != 400 {
+		t.Fatalf("ISO not correct: %v", value)
+	}
+
+	shutterSpeed := getTestTagValue(index, "IFD/Exif", "ShutterSpeedValue").([]exifcommon.SignedRational)[0]
+	if float64(shutterSpeed.Numerator)/float64(shutterSpeed.Denominator) != 8 {
+		t.Fatalf("Shutter-speed (APEX) not correct: %v", shutterSpeed)
+	}
+
+	gpsIfd, err := FindIfdFromRootIfd(index.RootIfd, "IFD/GPSInfo")
+	log.PanicIf(err)
+
+	gi, err := gpsIfd.GpsInfo()
+	log.PanicIf(err)
+
+	if math.Abs(gi.Latitude.Decimal()-54.9896667) > 1e-6 {
+		t.Fatalf("Latitude not correct: %s", gi.Latitude)
+	} else if math.Abs(gi.Longitude.Decimal()-(-1.9153333)) > 1e-6 {
+		t.Fatalf("Longitude not correct: %s", gi.Longitude)
+	} else if gi.Altitude != -12 {
+		t.Fatalf("Altitude not correct: (%d)", gi.Altitude)
+	}
+
+	if value := getTestTagValue(index, "IFD/GPSInfo", "GPSVersionID"); reflect.DeepEqual(value, []byte{2, 3, 0, 0}) != true {
+		t.Fatalf("GPSVersionID not correct: %v", value)
+	}
+
+	timestamp := getTestTagValue(index, "IFD/GPSInfo", "GPSTimeStamp").([]exifcommon.Rational)
+	if timestamp[0].Numerator != 12 || timestamp[1].Numerator != 34 || timestamp[2].Numerator != 5678 || timestamp[2].Denominator != 100 {
+		t.Fatalf("GPSTimeStamp not correct: %v", timestamp)
+	}
+
+	skippedKeys := make([]string, len(skipped))
+	for i, esf := range skipped {
+		skippedKeys[i] = esf.Key
+	}
+
+	expected := []string{"EXIF:Orientation", "SourceFile", "XMP:Rating"}
+	if reflect.DeepEqual(skippedKeys, expected) != true {
+		t.Fatalf("Skipped fields not correct: %v", skipped)
+	}
+}
+
+func TestImportExiftoolJson_NumericGrouped(t *testing.T) {
+	data := `{
+		"IFD0": {"Orientation": 6},
+		"GPS": {
+			"GPSLatitude": 54.989667,
+			"GPSLatitudeRef": "S",
+			"GPSAltitude": 100,
+			"GPSAltitudeRef": 1,
+			"GPSSpeedRef": "km/h"
+		}
+	}`
+
+	index, skipped := importTestExiftoolJson(data)
+
+	if len(skipped) != 0 {
+		t.Fatalf("Fields skipped: %v", skipped)
+	}
+
+	if value := getTestTagValue(index, "IFD", "Orientation").([]uint16); value[0] != 6 {
+		t.Fatalf("Orientation not correct: %v", value)
+	} else if value := getTestTagValue(index, "IFD/GPSInfo", "GPSLatitudeRef"); value != "S" {
+		t.Fatalf("Latitude reference not correct: [%v]", value)
+	} else if value := getTestTagValue(index, "IFD/GPSInfo", "GPSAltitudeRef"); reflect.DeepEqual(value, []byte{1}) != true {
+		t.Fatalf("Altitude reference not correct: %v", value)
+	} else if value := getTestTagValue(index, "IFD/GPSInfo", "GPSSpeedRef"); value != "K" {
+		t.Fatalf("Speed reference not correct: [%v]", value)
+	}
+}
+
+func TestImportExiftoolJson_GpsPosition(t *testing.T) {
+	index, _ := importTestExiftoolJson(`{"GPSPosition": "54 deg 59' 22.80\" S, 1 deg 54' 55.20\" E"}`)
+
+	gpsIfd, err := FindIfdFromRootIfd(index.RootIfd, "IFD/GPSInfo")
+	log.PanicIf(err)
+
+	gi, err := gpsIfd.GpsInfo()
+	log.PanicIf(err)
+
+	if math.Abs(gi.Latitude.Decimal()-(-54.9896667)) > 1e-6 {
+		t.Fatalf("Latitude not correct: %s", gi.Latitude)
+	} else if math.Abs(gi.Longitude.Decimal()-1.9153333) > 1e-6 {
+		t.Fatalf("Longitude not correct: %s", gi.Longitude)
+	}
+}
+
+func TestExiftoolGpsCoordinate(t *testing.T) {
+	cases := []struct {
+		value      interface{}
+		isLatitude bool
+		decimal    float64
+		hemisphere string
+	}{
+		{`54 deg 59' 22.80" N`, true, 54.989667, "N"},
+		{`54 deg 59' 22.80"`, true, 54.989667, ""},
+		{`1 deg 54.92' W`, false, 1.915333, "W"},
+		{"-1.915333", false, 1.915333, "W"},
+		{-54.5, true, 54.5, "S"},
+		{54.5, true, 54.5, "N"},
+	}
+
+	for _, c := range cases {
+		decimal, hemisphere, err := exiftoolGpsCoordinate(c.value, c.isLatitude)
+		log.PanicIf(err)
+
+		if math.Abs(decimal-c.decimal) > 1e-6 || hemisphere != c.hemisphere {
+			t.Fatalf("Coordinate [%v] not correct: (%f) [%s]", c.value, decimal, hemisphere)
+		}
+	}
+
+	_, _, err := exiftoolGpsCoordinate(`54 deg 59' 22.80" E`, true)
+	if err == nil {
+		t.Fatalf("Expected error for longitude hemisphere on latitude.")
+	}
+}
@@ -0,0 +1,234 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+// This file has the helpers that read from the filesystem. Building with the
+// "exif_nofile" tag leaves them out for platforms that don't have one (e.g.
+// GOOS=js in a browser), where EXIF is parsed from buffers instead.
+
+package exif
+
+import (
+	"os"
+
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// SearchFileAndExtractExif returns a slice from the beginning of the EXIF data
+// to the end of the file (it's not practical to try and calculate where the
+// data actually ends).
+func SearchFileAndExtractExif(filepath string) (rawExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// Open the file.
+
+	f, err := os.Open(filepath)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	rawExif, err = SearchAndExtractExifWithReader(f)
+	log.PanicIf(err)
+
+	return rawExif, nil
+}
+
+// ProbeExifFile is `ProbeExif()` for the given file.
+func ProbeExifFile(filepath string) (ep ExifProbe, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	f, err := os.Open(filepath)
+	log.PanicIf(err)
+
+	defer f.Close()
+
+	ep, err = ProbeExif(f)
+	log.PanicIf(err)
+
+	return ep, nil
+}
+
+// NewExifBlobFromFile finds and parses the EXIF data in the given file.
+func NewExifBlobFromFile(filepath string) (eb *ExifBlob, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, err := SearchFileAndExtractExif(filepath)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	eb, err = NewExifBlob(rawExif)
+	log.PanicIf(err)
+
+	return eb, nil
+}
+
+// NewCr2FileFromFile reads and parses the given CR2 file.
+func NewCr2FileFromFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, filepath string) (cf *Cr2File, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	cf, err = NewCr2File(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNotCr2 {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return cf, nil
+}
+
+// NewNefFileFromFile reads and parses the given NEF file.
+func NewNefFileFromFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, filepath string) (nf *NefFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	nf, err = NewNefFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNotNef {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return nf, nil
+}
+
+// NewArwFileFromFile reads and parses the given ARW file.
+func NewArwFileFromFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, filepath string) (af *ArwFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	af, err = NewArwFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNotArw {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return af, nil
+}
+
+// NewDngFileFromFile reads and parses the given DNG file.
+func NewDngFileFromFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, filepath string) (df *DngFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	df, err = NewDngFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNotDng {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return df, nil
+}
+
+// SetJpegFileExif replaces (or inserts) the EXIF of the given JPEG file with
+// the chain of the given root IB. See `SetJpegExif()`. The new content is
+// written to a temporary file alongside the original and then renamed over it,
+// so the original is left as it was if anything fails.
+func SetJpegFileExif(jpegFilepath string, rootIb *IfdBuilder) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fi, err := os.Stat(jpegFilepath)
+	log.PanicIf(err)
+
+	data, err := ioutil.ReadFile(jpegFilepath)
+	log.PanicIf(err)
+
+	updated, err := SetJpegExifFromBuilder(data, rootIb)
+	if err != nil {
+		if err == ErrNotJpeg || err == ErrJpegExifTooLarge {
+			return err
+		}
+
+		log.Panic(err)
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(jpegFilepath), ".exif-")
+	log.PanicIf(err)
+
+	tempFilepath := f.Name()
+	renamed := false
+
+	defer func() {
+		if renamed == false {
+			os.Remove(tempFilepath)
+		}
+	}()
+
+	_, err = f.Write(updated)
+	if err != nil {
+		f.Close()
+		log.Panic(err)
+	}
+
+	err = f.Close()
+	log.PanicIf(err)
+
+	err = os.Chmod(tempFilepath, fi.Mode())
+	log.PanicIf(err)
+
+	err = os.Rename(tempFilepath, jpegFilepath)
+	log.PanicIf(err)
+
+	renamed = true
+
+	return nil
+}
@@ -0,0 +1,323 @@
+package exif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// WriterGuess is one candidate for the software that wrote the EXIF.
+type WriterGuess struct {
+	// Name is the name of the software or, for camera firmware, the camera.
+	Name string
+
+	// Confidence is between zero and one. It is only meaningful relative to
+	// the other guesses.
+	Confidence float64
+
+	// Reasons are the observations that support the guess.
+	Reasons []string
+}
+
+// String returns a descriptive string.
+func (wg WriterGuess) String() string {
+	return fmt.Sprintf("WriterGuess<NAME=[%s] CONFIDENCE=(%.2f)>", wg.Name, wg.Confidence)
+}
+
+// WriterFingerprint is the set of traits that tend to differ between the
+// programs that write EXIF, together with the guesses drawn from them. These
+// are heuristics: any writer can imitate any other, and editors frequently
+// preserve most of what the camera wrote.
+type WriterFingerprint struct {
+	// ByteOrder is the byte-order of the EXIF data.
+	ByteOrder binary.ByteOrder
+
+	// UnsortedIfds are the IFDs whose tags are not stored in ascending order
+	// of tag-ID, as the specification requires.
+	UnsortedIfds []string
+
+	// HasPadding is true if any IFD has the padding tag that Windows reserves
+	// for later edits.
+	HasPadding bool
+
+	// UnalignedValueCount is the number of values stored outside of their
+	// tags at odd offsets. The specification requires word alignment, which
+	// camera firmware observes and some editors don't.
+	UnalignedValueCount int
+
+	// HasMakerNote is true if there is a maker-note.
+	HasMakerNote bool
+
+	// MakerNoteFormat is the name of the maker-note header, if it has a
+	// recognized one (see `MakerNoteHeaders`).
+	MakerNoteFormat string
+
+	// Software, ProcessingSoftware, Make, and Model are the values of the
+	// tags of the same names, or empty if not present.
+	Software           string
+	ProcessingSoftware string
+	Make               string
+	Model              string
+
+	// Guesses are ordered by descending confidence. It is empty if nothing
+	// could be inferred.
+	Guesses []WriterGuess
+}
+
+// IsSorted returns true if the tags of every IFD are in ascending order.
+func (wf WriterFingerprint) IsSorted() bool {
+	return len(wf.UnsortedIfds) == 0
+}
+
+// Best returns the most likely writer. `found` is false if there are no
+// guesses.
+func (wf WriterFingerprint) Best() (wg WriterGuess, found bool) {
+	if len(wf.Guesses) == 0 {
+		return wg, false
+	}
+
+	return wf.Guesses[0], true
+}
+
+// String returns a descriptive string.
+func (wf WriterFingerprint) String() string {
+	best := "(none)"
+	if wg, found := wf.Best(); found == true {
+		best = wg.Name
+	}
+
+	return fmt.Sprintf("WriterFingerprint<BYTE-ORDER=[%v] SORTED=[%v] PADDING=[%v] UNALIGNED=(%d) MAKER-NOTE=[%v] SOFTWARE=[%s] BEST=[%s]>", wf.ByteOrder, wf.IsSorted(), wf.HasPadding, wf.UnalignedValueCount, wf.HasMakerNote, wf.Software, best)
+}
+
+// knownWriterSoftware maps lowercased substrings of the Software and
+// ProcessingSoftware values to the software that they identify. They are
+// tried in order, so more specific substrings come first.
+var knownWriterSoftware = []struct {
+	substring string
+	name      string
+}{
+	{"lightroom", "Adobe Lightroom"},
+	{"camera raw", "Adobe Camera Raw"},
+	{"photoshop", "Adobe Photoshop"},
+	{"gimp", "GIMP"},
+	{"picasa", "Picasa"},
+	{"windows photo", "Microsoft Windows Photos"},
+	{"microsoft windows", "Microsoft Windows"},
+	{"darktable", "darktable"},
+	{"rawtherapee", "RawTherapee"},
+	{"capture one", "Capture One"},
+	{"digital photo professional", "Canon Digital Photo Professional"},
+	{"nx studio", "Nikon NX Studio"},
+	{"affinity photo", "Affinity Photo"},
+	{"exiftool", "ExifTool"},
+	{"imagemagick", "ImageMagick"},
+	{"go-exif", "go-exif"},
+}
+
+// identifyWriterSoftware returns the known software that the value names.
+func identifyWriterSoftware(value string) (name string, found bool) {
+	lowered := strings.ToLower(value)
+
+	for _, kws := range knownWriterSoftware {
+		if strings.Contains(lowered, kws.substring) == true {
+			return kws.name, true
+		}
+	}
+
+	return "", false
+}
+
+// WriterFingerprint inspects the traits of the EXIF that reveal what wrote it
+// and guesses at the software or camera firmware responsible.
+func (index IfdIndex) WriterFingerprint() (wf WriterFingerprint, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	wf.ByteOrder = index.RootIfd.ByteOrder()
+	wf.UnsortedIfds = make([]string, 0)
+
+	for _, ifd := range index.Ifds {
+		var previousTagId uint16
+		isSorted := true
+
+		for i, ite := range ifd.Entries() {
+			if i > 0 && ite.TagId() < previousTagId {
+				isSorted = false
+			}
+
+			previousTagId = ite.TagId()
+
+			if ite.TagId() == PaddingTagId {
+				wf.HasPadding = true
+			}
+
+			// The units of UNDEFINED values are bytes.
+			unitSize := uint32(1)
+			if ite.TagType() != exifcommon.TypeUndefined {
+				unitSize = uint32(ite.TagType().Size())
+			}
+
+			if ite.ChildIfdPath() == "" && unitSize*ite.UnitCount() > 4 && ite.getValueOffset()%2 != 0 {
+				wf.UnalignedValueCount++
+			}
+		}
+
+		if isSorted == false {
+			wf.UnsortedIfds = append(wf.UnsortedIfds, ifd.ifdIdentity.String())
+		}
+	}
+
+	if exifIfd, found := index.Lookup[exifcommon.IfdExifStandardIfdIdentity.String()]; found == true {
+		results, err := exifIfd.FindTagWithId(MakerNoteTagId)
+		if err == nil {
+			wf.HasMakerNote = true
+
+			rawBytes, err := results[0].GetRawBytes()
+			if err == nil {
+				if mnh, found := DetectMakerNoteHeader(rawBytes); found == true {
+					wf.MakerNoteFormat = mnh.Name
+				}
+			}
+		} else if log.Is(err, ErrTagNotFound) == false {
+			log.Panic(err)
+		}
+	}
+
+	results, err := index.GetTags("Software", "ProcessingSoftware", "Make", "Model")
+	log.PanicIf(err)
+
+	asString := func(tv TypedValue) string {
+		return collapseWhitespace(tv.Value.Ascii())
+	}
+
+	wf.Software = asString(results["Software"])
+	wf.ProcessingSoftware = asString(results["ProcessingSoftware"])
+	wf.Make = asString(results["Make"])
+	wf.Model = asString(results["Model"])
+
+	wf.Guesses = guessWriters(wf)
+
+	return wf, nil
+}
+
+// guessWriters draws the guesses from the observed traits.
+func guessWriters(wf WriterFingerprint) []WriterGuess {
+	guesses := make(map[string]*WriterGuess)
+
+	add := func(name string, confidence float64, reason string) {
+		wg, found := guesses[name]
+		if found == false {
+			wg = &WriterGuess{
+				Name:    name,
+				Reasons: make([]string, 0),
+			}
+
+			guesses[name] = wg
+		}
+
+		if confidence > wg.Confidence {
+			wg.Confidence = confidence
+		}
+
+		wg.Reasons = append(wg.Reasons, reason)
+	}
+
+	// Whatever last processed the image is more telling than what it was
+	// originally saved by.
+	isEditor := false
+
+	if wf.ProcessingSoftware != "" {
+		reason := fmt.Sprintf("ProcessingSoftware is [%s]", wf.ProcessingSoftware)
+		if name, found := identifyWriterSoftware(wf.ProcessingSoftware); found == true {
+			add(name, 0.9, reason)
+		} else {
+			add(wf.ProcessingSoftware, 0.7, reason)
+		}
+
+		isEditor = true
+	}
+
+	if wf.Software != "" {
+		reason := fmt.Sprintf("Software is [%s]", wf.Software)
+		if name, found := identifyWriterSoftware(wf.Software); found == true {
+			add(name, 0.8, reason)
+			isEditor = true
+		} else if wf.Make == "" {
+			add(wf.Software, 0.6, reason)
+			isEditor = true
+		}
+	}
+
+	if wf.HasPadding == true {
+		add("Microsoft Windows", 0.5, "has the padding tag that Windows reserves for later edits")
+	}
+
+	if wf.Make != "" || wf.Model != "" {
+		ci, _ := NormalizeCamera(wf.Make, wf.Model, "")
+		name := strings.TrimSpace(ci.Make + " " + ci.Model)
+
+		confidence := 0.5
+		reasons := []string{fmt.Sprintf("Make is [%s] and Model is [%s]", wf.Make, wf.Model)}
+
+		if wf.HasMakerNote == true {
+			confidence += 0.2
+			reasons = append(reasons, "has a maker-note")
+		}
+
+		if wf.IsSorted() == true {
+			reasons = append(reasons, "tags are in ascending order")
+		} else {
+			confidence -= 0.2
+			reasons = append(reasons, fmt.Sprintf("tags are out of order in %v", wf.UnsortedIfds))
+		}
+
+		if wf.UnalignedValueCount > 0 {
+			confidence -= 0.1
+			reasons = append(reasons, fmt.Sprintf("(%d) values are not word-aligned", wf.UnalignedValueCount))
+		}
+
+		// Firmware usually records its version as the Software, so an
+		// unrecognized value supports the camera.
+		if wf.Software != "" && isEditor == false {
+			confidence += 0.1
+			reasons = append(reasons, fmt.Sprintf("Software [%s] looks like a firmware version", wf.Software))
+		}
+
+		if isEditor == true || wf.HasPadding == true {
+			confidence -= 0.3
+			reasons = append(reasons, "was edited afterward")
+		}
+
+		if confidence < 0.1 {
+			confidence = 0.1
+		}
+
+		for _, reason := range reasons {
+			add(name+" firmware", confidence, reason)
+		}
+	}
+
+	ordered := make([]WriterGuess, 0, len(guesses))
+	for _, wg := range guesses {
+		ordered = append(ordered, *wg)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Confidence != ordered[j].Confidence {
+			return ordered[i].Confidence > ordered[j].Confidence
+		}
+
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	return ordered
+}
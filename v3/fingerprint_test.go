@@ -0,0 +1,137 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"path"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestIfdIndex_WriterFingerprint_Camera(t *testing.T) {
+	rawExif := getTestExifData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	wf, err := index.WriterFingerprint()
+	log.PanicIf(err)
+
+	if wf.ByteOrder != binary.LittleEndian {
+		t.Fatalf("Byte-order not correct: [%v]", wf.ByteOrder)
+	} else if wf.IsSorted() != true {
+		t.Fatalf("Tags not reported as sorted: %v", wf.UnsortedIfds)
+	} else if wf.HasMakerNote != true {
+		t.Fatalf("Maker-note not found.")
+	} else if wf.HasPadding != false {
+		t.Fatalf("Padding not expected.")
+	} else if wf.Make != "Canon" || wf.Model != "Canon EOS 5D Mark III" {
+		t.Fatalf("Make or model not correct: [%s] [%s]", wf.Make, wf.Model)
+	}
+
+	wg, found := wf.Best()
+	if found != true {
+		t.Fatalf("No guesses.")
+	} else if wg.Name != "Canon EOS 5D Mark III firmware" {
+		t.Fatalf("Best guess not correct: %v", wg)
+	} else if wg.Confidence < 0.5 {
+		t.Fatalf("Confidence too low: %v", wg)
+	}
+}
+
+func TestIfdIndex_WriterFingerprint_Editor(t *testing.T) {
+	filepath := path.Join(exifcommon.GetTestAssetsPath(), "gps.jpg")
+
+	rawExif, err := SearchFileAndExtractExif(filepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	wf, err := index.WriterFingerprint()
+	log.PanicIf(err)
+
+	if wf.Software != "GIMP 2.8.20" {
+		t.Fatalf("Software not correct: [%s]", wf.Software)
+	}
+
+	wg, _ := wf.Best()
+	if wg.Name != "GIMP" {
+		t.Fatalf("Best guess not correct: %v", wf.Guesses)
+	}
+
+	// The camera that originally took it is still a candidate, but a weaker
+	// one.
+	if len(wf.Guesses) != 2 {
+		t.Fatalf("Expected two guesses: %v", wf.Guesses)
+	} else if wf.Guesses[1].Confidence >= wg.Confidence {
+		t.Fatalf("Camera guess not ranked lower: %v", wf.Guesses)
+	}
+}
+
+func TestIfdIndex_WriterFingerprint_ProcessingSoftwareAndPadding(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("ProcessingSoftware", "Adobe Photoshop Lightroom Classic 12.0")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Make", "NIKON CORPORATION")
+	log.PanicIf(err)
+
+	err = ib.SetPadding(100)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	wf, err := index.WriterFingerprint()
+	log.PanicIf(err)
+
+	if wf.HasPadding != true {
+		t.Fatalf("Padding not found.")
+	} else if wf.HasMakerNote != false {
+		t.Fatalf("Maker-note not expected.")
+	}
+
+	names := make([]string, len(wf.Guesses))
+	for i, wg := range wf.Guesses {
+		names[i] = wg.Name
+	}
+
+	if len(names) != 3 || names[0] != "Adobe Lightroom" || names[1] != "Microsoft Windows" || names[2] != "Nikon firmware" {
+		t.Fatalf("Guesses not correct: %v", names)
+	}
+}
+
+func Test_guessWriters_Nothing(t *testing.T) {
+	guesses := guessWriters(WriterFingerprint{})
+	if len(guesses) != 0 {
+		t.Fatalf("Expected no guesses: %v", guesses)
+	}
+}
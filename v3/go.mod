@@ -14,5 +14,6 @@ require (
 	github.com/jessevdk/go-flags v1.5.0
 	golang.org/x/net v0.0.0-20221002022538-bcab6841153b // indirect
 	golang.org/x/sys v0.0.0-20220928140112-f11e5e49a4ec // indirect
+	golang.org/x/text v0.3.7
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -91,8 +91,14 @@ func (d GpsDegrees) Raw() []exifcommon.Rational {
 // GpsInfo encapsulates all of the geographic information in one place.
 type GpsInfo struct {
 	Latitude, Longitude GpsDegrees
-	Altitude            int
-	Timestamp           time.Time
+
+	// Altitude is the altitude in whole meters, negative below sea-level.
+	Altitude int
+
+	// AltitudeMeters is the altitude in meters, negative below sea-level.
+	AltitudeMeters float64
+
+	Timestamp time.Time
 }
 
 // String returns a descriptive string.
@@ -0,0 +1,160 @@
+package exif
+
+import (
+	"errors"
+	"math"
+	"reflect"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// GpsAltitudeRefAboveSeaLevel is the GPSAltitudeRef value for altitudes
+	// at or above sea-level.
+	GpsAltitudeRefAboveSeaLevel = 0
+
+	// GpsAltitudeRefBelowSeaLevel is the GPSAltitudeRef value for altitudes
+	// below sea-level.
+	GpsAltitudeRefBelowSeaLevel = 1
+
+	// gpsAltitudeDenominator is the denominator that altitudes are written
+	// with (centimeters).
+	gpsAltitudeDenominator = 100
+)
+
+var (
+	// ErrGpsAltitudeNotValid means that the GPSAltitude value can't be read
+	// as an altitude (e.g. it has a zero denominator).
+	ErrGpsAltitudeNotValid = errors.New("GPS altitude not valid")
+)
+
+// GpsAltitudeMeters normalizes a GPSAltitude value and its optional
+// GPSAltitudeRef value (nil if absent) into signed meters. The standard
+// stores the magnitude as an unsigned RATIONAL and the sign in the reference,
+// but some phones store a negative SRATIONAL (or a negative number in a
+// RATIONAL) instead, sometimes along with a reference of (1) and sometimes
+// not. A negative value is always taken at its word; otherwise a reference of
+// (1) makes it negative. `ErrGpsAltitudeNotValid` is returned if the value is
+// empty, has a zero denominator, or isn't a rational.
+func GpsAltitudeMeters(altitudeValue interface{}, refValue interface{}) (meters float64, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	switch v := altitudeValue.(type) {
+	case []exifcommon.Rational:
+		if len(v) == 0 || v[0].Denominator == 0 {
+			return 0, ErrGpsAltitudeNotValid
+		}
+
+		// Writers that meant an SRATIONAL leave the sign bit set.
+		numerator := float64(v[0].Numerator)
+		if v[0].Numerator >= 0x80000000 {
+			numerator = float64(int32(v[0].Numerator))
+		}
+
+		meters = numerator / float64(v[0].Denominator)
+	case []exifcommon.SignedRational:
+		if len(v) == 0 || v[0].Denominator == 0 {
+			return 0, ErrGpsAltitudeNotValid
+		}
+
+		meters = float64(v[0].Numerator) / float64(v[0].Denominator)
+	default:
+		exifLogger.Warningf(nil, "GPS altitude type not supported: [%v]", reflect.TypeOf(altitudeValue))
+		return 0, ErrGpsAltitudeNotValid
+	}
+
+	if meters < 0 {
+		return meters, nil
+	}
+
+	if refBytes, ok := refValue.([]byte); ok == true && len(refBytes) > 0 && refBytes[0] == GpsAltitudeRefBelowSeaLevel {
+		meters = -meters
+	}
+
+	return meters, nil
+}
+
+// gpsAltitudeMeters reads the altitude in signed meters from the GPS
+// IFD. `found` is false if there is no GPSAltitude tag or it isn't valid.
+func (ifd *Ifd) gpsAltitudeMeters() (meters float64, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	altitudeTags, found := ifd.entriesByTagId[TagAltitudeId]
+	if found == false {
+		return 0, false, nil
+	}
+
+	altitudeValue, err := altitudeTags[0].Value()
+	log.PanicIf(err)
+
+	var refValue interface{}
+	if refTags, found := ifd.entriesByTagId[TagAltitudeRefId]; found == true {
+		refValue, err = refTags[0].Value()
+		log.PanicIf(err)
+	}
+
+	meters, err = GpsAltitudeMeters(altitudeValue, refValue)
+	if err != nil {
+		if err == ErrGpsAltitudeNotValid {
+			ifdEnumerateLogger.Warningf(nil, "GPS altitude not valid: %v", altitudeValue)
+			return 0, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	return meters, true, nil
+}
+
+// SetGpsAltitude writes the given altitude, in meters (negative below
+// sea-level), to this IB, which must be the GPS IFD. It is always written
+// the way the standard specifies: the magnitude as an unsigned RATIONAL (in
+// centimeters) and the sign in GPSAltitudeRef, regardless of how the
+// altitude was stored before.
+func (ib *IfdBuilder) SetGpsAltitude(meters float64) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.IfdIdentity().Equals(exifcommon.IfdGpsInfoStandardIfdIdentity) == false {
+		log.Panicf("GPS altitude can only be set on GPS IFD: [%s]", ib.IfdIdentity().UnindexedString())
+	}
+
+	if math.IsNaN(meters) == true || math.IsInf(meters, 0) == true {
+		log.Panicf("GPS altitude not valid: (%f)", meters)
+	}
+
+	ref := byte(GpsAltitudeRefAboveSeaLevel)
+	if meters < 0 {
+		ref = GpsAltitudeRefBelowSeaLevel
+	}
+
+	centimeters := math.Round(math.Abs(meters) * gpsAltitudeDenominator)
+	if centimeters > math.MaxUint32 {
+		log.Panicf("GPS altitude too large: (%f)", meters)
+	}
+
+	altitude := []exifcommon.Rational{
+		{Numerator: uint32(centimeters), Denominator: gpsAltitudeDenominator},
+	}
+
+	err = ib.SetStandard(TagAltitudeRefId, []byte{ref})
+	log.PanicIf(err)
+
+	err = ib.SetStandard(TagAltitudeId, altitude)
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,187 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"math"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestGpsAltitudeMeters(t *testing.T) {
+	above := []byte{GpsAltitudeRefAboveSeaLevel}
+	below := []byte{GpsAltitudeRefBelowSeaLevel}
+
+	cases := []struct {
+		altitude interface{}
+		ref      interface{}
+		expected float64
+	}{
+		// Standard.
+		{[]exifcommon.Rational{{Numerator: 1234, Denominator: 10}}, above, 123.4},
+		{[]exifcommon.Rational{{Numerator: 1234, Denominator: 10}}, below, -123.4},
+		{[]exifcommon.Rational{{Numerator: 50, Denominator: 1}}, nil, 50},
+
+		// Negative SRATIONAL, with and without a matching reference.
+		{[]exifcommon.SignedRational{{Numerator: -25, Denominator: 1}}, nil, -25},
+		{[]exifcommon.SignedRational{{Numerator: -25, Denominator: 1}}, above, -25},
+		{[]exifcommon.SignedRational{{Numerator: -25, Denominator: 1}}, below, -25},
+		{[]exifcommon.SignedRational{{Numerator: 25, Denominator: 1}}, below, -25},
+
+		// A negative number written into a RATIONAL.
+		{[]exifcommon.Rational{{Numerator: uint32(0xffffffe7), Denominator: 1}}, below, -25},
+	}
+
+	for i, c := range cases {
+		meters, err := GpsAltitudeMeters(c.altitude, c.ref)
+		log.PanicIf(err)
+
+		if math.Abs(meters-c.expected) > 1e-9 {
+			t.Fatalf("Case (%d) not correct: (%f) != (%f)", i, meters, c.expected)
+		}
+	}
+}
+
+func TestGpsAltitudeMeters__Invalid(t *testing.T) {
+	_, err := GpsAltitudeMeters([]exifcommon.Rational{{Numerator: 1, Denominator: 0}}, nil)
+	if err != ErrGpsAltitudeNotValid {
+		t.Fatalf("Expected failure for zero denominator: %v", err)
+	}
+
+	_, err = GpsAltitudeMeters("100", nil)
+	if err != ErrGpsAltitudeNotValid {
+		t.Fatalf("Expected failure for unsupported type: %v", err)
+	}
+}
+
+func getGpsAltitudeTestIbs() (rootIb, gpsIb *IfdBuilder) {
+	filepath := path.Join(exifcommon.GetTestAssetsPath(), "gps.jpg")
+
+	rawExif, err := SearchFileAndExtractExif(filepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb = NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	gpsIb, err = GetOrCreateIbFromRootIb(rootIb, "IFD/GPSInfo")
+	log.PanicIf(err)
+
+	return rootIb, gpsIb
+}
+
+func getGpsAltitudeTestGpsInfo(rootIb *IfdBuilder) *GpsInfo {
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	gpsIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdGpsInfoStandardIfdIdentity)
+	log.PanicIf(err)
+
+	gi, err := gpsIfd.GpsInfo()
+	log.PanicIf(err)
+
+	return gi
+}
+
+func TestIfdBuilder_SetGpsAltitude(t *testing.T) {
+	rootIb, gpsIb := getGpsAltitudeTestIbs()
+
+	err := gpsIb.SetGpsAltitude(-12.345)
+	log.PanicIf(err)
+
+	bt, err := gpsIb.FindTag(TagAltitudeRefId)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(bt.value.Bytes(), []byte{GpsAltitudeRefBelowSeaLevel}) != true {
+		t.Fatalf("Altitude reference not correct: %v", bt.value.Bytes())
+	}
+
+	bt, err = gpsIb.FindTag(TagAltitudeId)
+	log.PanicIf(err)
+
+	if bt.typeId != exifcommon.TypeRational {
+		t.Fatalf("Altitude not written as an unsigned rational: [%s]", bt.typeId)
+	}
+
+	gi := getGpsAltitudeTestGpsInfo(rootIb)
+
+	if math.Abs(gi.AltitudeMeters-(-12.35)) > 1e-9 {
+		t.Fatalf("Altitude not correct: (%f)", gi.AltitudeMeters)
+	} else if gi.Altitude != -12 {
+		t.Fatalf("Whole altitude not correct: (%d)", gi.Altitude)
+	}
+}
+
+func TestIfd_GpsInfo__SignedRationalAltitude(t *testing.T) {
+	rootIb, gpsIb := getGpsAltitudeTestIbs()
+
+	// Write it the way that some phones do: a negative SRATIONAL with the
+	// reference also indicating below sea-level.
+
+	altitude := []exifcommon.SignedRational{
+		{Numerator: -75, Denominator: 2},
+	}
+
+	bt, err := NewCustomBuilderTag("IFD/GPSInfo", TagAltitudeId, exifcommon.TypeSignedRational, altitude, gpsIb.byteOrder)
+	log.PanicIf(err)
+
+	err = gpsIb.Set(bt)
+	log.PanicIf(err)
+
+	err = gpsIb.SetStandard(TagAltitudeRefId, []byte{GpsAltitudeRefBelowSeaLevel})
+	log.PanicIf(err)
+
+	gi := getGpsAltitudeTestGpsInfo(rootIb)
+
+	if gi.AltitudeMeters != -37.5 {
+		t.Fatalf("Altitude not correct: (%f)", gi.AltitudeMeters)
+	}
+}
+
+func TestIfd_GpsInfo__ZeroDenominatorAltitude(t *testing.T) {
+	rootIb, gpsIb := getGpsAltitudeTestIbs()
+
+	altitude := []exifcommon.Rational{
+		{Numerator: 100, Denominator: 0},
+	}
+
+	err := gpsIb.SetStandard(TagAltitudeId, altitude)
+	log.PanicIf(err)
+
+	gi := getGpsAltitudeTestGpsInfo(rootIb)
+
+	if gi.AltitudeMeters != 0 {
+		t.Fatalf("Altitude not correct: (%f)", gi.AltitudeMeters)
+	}
+}
+
+func TestIfdBuilder_SetGpsAltitude__NotGpsIfd(t *testing.T) {
+	rootIb, _ := getGpsAltitudeTestIbs()
+
+	err := rootIb.SetGpsAltitude(100)
+	if err == nil {
+		t.Fatalf("Expected failure on non-GPS IFD.")
+	}
+}
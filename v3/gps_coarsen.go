@@ -0,0 +1,176 @@
+package exif
+
+import (
+	"math"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// gpsMetersPerDegreeLatitude is the approximate length of one degree of
+	// latitude (and of longitude at the equator).
+	gpsMetersPerDegreeLatitude = 111320.0
+
+	// gpsMinimumLongitudeScale keeps the longitude step finite near the
+	// poles.
+	gpsMinimumLongitudeScale = 0.01
+)
+
+var (
+	// gpsPrecisionDetailTagIds are the tags that describe how good the fix
+	// was rather than where it was: GPSSatellites, GPSMeasureMode, GPSDOP,
+	// GPSDifferential, and GPSHPositioningError. They are removed when the
+	// location is coarsened.
+	gpsPrecisionDetailTagIds = []uint16{0x0008, 0x000a, 0x000b, 0x001e, 0x001f}
+)
+
+// gpsCoordinateTagIds are the coordinates that are coarsened, paired with
+// their references. The first pair is the latitude.
+var gpsCoordinateTagIds = [][2]uint16{
+	{TagLatitudeId, TagLatitudeRefId},
+	{TagLongitudeId, TagLongitudeRefId},
+
+	// GPSDestLatitude and GPSDestLongitude.
+	{0x0014, 0x0013},
+	{0x0016, 0x0015},
+}
+
+// CoarsenGpsDecimal truncates the decimal degrees toward zero to a multiple
+// of `step` degrees.
+func CoarsenGpsDecimal(decimal float64, step float64) float64 {
+	return math.Trunc(decimal/step) * step
+}
+
+// gpsDecimalToRationals returns the degrees, minutes, and seconds of the
+// magnitude of the decimal degrees. Seconds are kept to hundredths.
+func gpsDecimalToRationals(decimal float64) []exifcommon.Rational {
+	decimal = math.Abs(decimal)
+
+	degrees := uint32(decimal)
+
+	minutesRaw := (decimal - float64(degrees)) * 60.0
+	minutes := uint32(minutesRaw)
+
+	hundredthsOfSeconds := uint32(math.Round((minutesRaw - float64(minutes)) * 60.0 * 100.0))
+
+	if hundredthsOfSeconds >= 6000 {
+		hundredthsOfSeconds -= 6000
+		minutes++
+	}
+
+	if minutes >= 60 {
+		minutes -= 60
+		degrees++
+	}
+
+	return []exifcommon.Rational{
+		{Numerator: degrees, Denominator: 1},
+		{Numerator: minutes, Denominator: 1},
+		{Numerator: hundredthsOfSeconds, Denominator: 100},
+	}
+}
+
+// gpsCoordinate reads a coordinate and its reference from the IB. `found` is
+// false if either is missing.
+func (ib *IfdBuilder) gpsCoordinate(coordinateTagId, refTagId uint16) (gd GpsDegrees, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	values := make([]interface{}, 2)
+
+	for i, tagId := range []uint16{coordinateTagId, refTagId} {
+		bt, err := ib.FindTag(tagId)
+		if err != nil {
+			if log.Is(err, ErrTagEntryNotFound) == true {
+				return gd, false, nil
+			}
+
+			log.Panic(err)
+		}
+
+		if bt.value.IsValue() == true {
+			values[i] = bt.value.Value()
+			continue
+		}
+
+		values[i], err = decodeBuilderTagValueBytes(bt.typeId, bt.value.Bytes(), ib.byteOrder)
+		log.PanicIf(err)
+	}
+
+	rawCoordinate, ok := values[0].([]exifcommon.Rational)
+	if ok == false {
+		log.Panicf("GPS coordinate (0x%04x) is not a rational: [%v]", coordinateTagId, values[0])
+	}
+
+	refValue, ok := values[1].(string)
+	if ok == false || refValue == "" {
+		log.Panicf("GPS coordinate reference (0x%04x) is not valid: [%v]", refTagId, values[1])
+	}
+
+	gd, err = NewGpsDegreesFromRationals(refValue, rawCoordinate)
+	log.PanicIf(err)
+
+	return gd, true, nil
+}
+
+// CoarsenGps reduces the precision of the location in this IB, which must be
+// the GPS IFD, to about `precisionMeters` (e.g. 1000 for roughly a
+// kilometer). The latitude and longitude (and the destination, if present)
+// are truncated toward zero, which keeps them in the same hemisphere, and the
+// details of the fix (satellites, DOP, etc..) are removed. This is a middle
+// ground between keeping the location and stripping it. Everything else, such
+// as the altitude and the timestamp, is left alone.
+func (ib *IfdBuilder) CoarsenGps(precisionMeters float64) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.IfdIdentity().Equals(exifcommon.IfdGpsInfoStandardIfdIdentity) == false {
+		log.Panicf("GPS can only be coarsened on GPS IFD: [%s]", ib.IfdIdentity().UnindexedString())
+	}
+
+	if precisionMeters <= 0 {
+		log.Panicf("precision must be positive: (%f)", precisionMeters)
+	}
+
+	latitudeStep := precisionMeters / gpsMetersPerDegreeLatitude
+
+	for i, tagIds := range gpsCoordinateTagIds {
+		gd, found, err := ib.gpsCoordinate(tagIds[0], tagIds[1])
+		log.PanicIf(err)
+
+		if found == false {
+			continue
+		}
+
+		// Latitudes are at even indices. A degree of longitude shrinks toward
+		// the poles, so the step grows to cover the same distance.
+		step := latitudeStep
+		if i%2 == 1 {
+			latitude, found, err := ib.gpsCoordinate(gpsCoordinateTagIds[i-1][0], gpsCoordinateTagIds[i-1][1])
+			log.PanicIf(err)
+
+			if found == true {
+				scale := math.Abs(math.Cos(latitude.Decimal() * math.Pi / 180.0))
+				step = latitudeStep / math.Max(scale, gpsMinimumLongitudeScale)
+			}
+		}
+
+		coarsened := CoarsenGpsDecimal(gd.Decimal(), step)
+
+		err = ib.SetStandard(tagIds[0], gpsDecimalToRationals(coarsened))
+		log.PanicIf(err)
+	}
+
+	_, err = ib.DeleteMany(gpsPrecisionDetailTagIds...)
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,144 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"math"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestCoarsenGpsDecimal(t *testing.T) {
+	if coarsened := CoarsenGpsDecimal(26.5866, 0.01); math.Abs(coarsened-26.58) > 1e-9 {
+		t.Fatalf("Positive value not coarsened correctly: (%f)", coarsened)
+	}
+
+	if coarsened := CoarsenGpsDecimal(-80.0536, 0.01); math.Abs(coarsened+80.05) > 1e-9 {
+		t.Fatalf("Negative value not coarsened toward zero: (%f)", coarsened)
+	}
+}
+
+func TestGpsDecimalToRationals(t *testing.T) {
+	rationals := gpsDecimalToRationals(-26.5)
+
+	expected := []exifcommon.Rational{
+		{Numerator: 26, Denominator: 1},
+		{Numerator: 30, Denominator: 1},
+		{Numerator: 0, Denominator: 100},
+	}
+
+	if reflect.DeepEqual(rationals, expected) != true {
+		t.Fatalf("Rationals not correct: %v", rationals)
+	}
+
+	// Seconds that round up carry into the minutes and degrees.
+
+	rationals = gpsDecimalToRationals(10.9999999)
+
+	expected = []exifcommon.Rational{
+		{Numerator: 11, Denominator: 1},
+		{Numerator: 0, Denominator: 1},
+		{Numerator: 0, Denominator: 100},
+	}
+
+	if reflect.DeepEqual(rationals, expected) != true {
+		t.Fatalf("Carried rationals not correct: %v", rationals)
+	}
+}
+
+func TestIfdBuilder_CoarsenGps(t *testing.T) {
+	filepath := path.Join(exifcommon.GetTestAssetsPath(), "gps.jpg")
+
+	rawExif, err := SearchFileAndExtractExif(filepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	originalGpsIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdGpsInfoStandardIfdIdentity)
+	log.PanicIf(err)
+
+	originalGi, err := originalGpsIfd.GpsInfo()
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	gpsIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/GPSInfo")
+	log.PanicIf(err)
+
+	err = gpsIb.SetStandard(0x000b, []exifcommon.Rational{{Numerator: 12, Denominator: 10}})
+	log.PanicIf(err)
+
+	err = gpsIb.CoarsenGps(1000)
+	log.PanicIf(err)
+
+	if _, err := gpsIb.FindTag(0x000b); log.Is(err, ErrTagEntryNotFound) != true {
+		t.Fatalf("DOP should have been removed: %v", err)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	updatedExif, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, updatedExif)
+	log.PanicIf(err)
+
+	gpsIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdGpsInfoStandardIfdIdentity)
+	log.PanicIf(err)
+
+	gi, err := gpsIfd.GpsInfo()
+	log.PanicIf(err)
+
+	latitudeStep := 1000 / gpsMetersPerDegreeLatitude
+	longitudeStep := latitudeStep / math.Cos(gi.Latitude.Decimal()*math.Pi/180.0)
+
+	originalLatitude := originalGi.Latitude.Decimal()
+	latitude := gi.Latitude.Decimal()
+
+	if latitude == originalLatitude {
+		t.Fatalf("Latitude was not coarsened: (%f)", latitude)
+	} else if math.Abs(latitude) > math.Abs(originalLatitude) || math.Abs(originalLatitude-latitude) >= latitudeStep {
+		t.Fatalf("Latitude not coarsened correctly: (%f) -> (%f)", originalLatitude, latitude)
+	}
+
+	originalLongitude := originalGi.Longitude.Decimal()
+	longitude := gi.Longitude.Decimal()
+
+	if longitude >= 0 {
+		t.Fatalf("Longitude should still be west: (%f)", longitude)
+	} else if longitude == originalLongitude {
+		t.Fatalf("Longitude was not coarsened: (%f)", longitude)
+	} else if math.Abs(longitude) > math.Abs(originalLongitude) || math.Abs(originalLongitude-longitude) >= longitudeStep {
+		t.Fatalf("Longitude not coarsened correctly: (%f) -> (%f)", originalLongitude, longitude)
+	}
+
+	if gi.Timestamp != originalGi.Timestamp {
+		t.Fatalf("Timestamp should not have changed: [%s] != [%s]", gi.Timestamp, originalGi.Timestamp)
+	}
+}
+
+func TestIfdBuilder_CoarsenGps_NotGpsIfd(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.CoarsenGps(1000)
+	if err == nil {
+		t.Fatalf("Expected error for a non-GPS IFD.")
+	}
+}
@@ -0,0 +1,137 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// maxPreIfdGapSize is the largest gap before IFD0 that we'll keep. A
+	// TIFF can put IFD0 after its image data, and that's not worth holding in
+	// memory just to reproduce it.
+	maxPreIfdGapSize = 64 * 1024
+)
+
+// HeaderLayout describes the TIFF header that an EXIF block was parsed from.
+// Most blocks put the first IFD immediately after the eight-byte header, but
+// some writers leave a gap (sometimes holding data of their own) before it.
+type HeaderLayout struct {
+	// ByteOrder is the byte-order declared by the header.
+	ByteOrder binary.ByteOrder
+
+	// FirstIfdOffset is the offset of IFD0.
+	FirstIfdOffset uint32
+
+	// PreIfdGap holds the bytes between the end of the header and IFD0. It is
+	// empty if IFD0 immediately follows the header, if the IFDs aren't the
+	// standard ones (e.g. a maker-note), or if the gap is too large to keep.
+	PreIfdGap []byte
+}
+
+// String returns a descriptive string.
+func (hl HeaderLayout) String() string {
+	return fmt.Sprintf("HeaderLayout<BYTE-ORDER=[%v] FIRST-IFD-OFFSET=(0x%02x) PRE-IFD-GAP=(%d)>", hl.ByteOrder, hl.FirstIfdOffset, len(hl.PreIfdGap))
+}
+
+// IsDefault returns true if the header is the one that we write by default.
+func (hl HeaderLayout) IsDefault() bool {
+	return hl.FirstIfdOffset == ExifDefaultFirstIfdOffset
+}
+
+// readHeaderLayout reads the header and, if `captureGap` is true and it is
+// not too large, the gap before the first IFD.
+func readHeaderLayout(ebs ExifBlobSeeker, byteOrder binary.ByteOrder, firstIfdOffset uint32, captureGap bool) (hl HeaderLayout, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	hl = HeaderLayout{
+		ByteOrder:      byteOrder,
+		FirstIfdOffset: firstIfdOffset,
+	}
+
+	if captureGap == false || firstIfdOffset <= ExifDefaultFirstIfdOffset {
+		return hl, nil
+	} else if firstIfdOffset-ExifDefaultFirstIfdOffset > maxPreIfdGapSize {
+		return hl, nil
+	}
+
+	rs, err := ebs.GetReadSeeker(int64(ExifDefaultFirstIfdOffset))
+	log.PanicIf(err)
+
+	hl.PreIfdGap = make([]byte, firstIfdOffset-ExifDefaultFirstIfdOffset)
+
+	_, err = io.ReadFull(rs, hl.PreIfdGap)
+	log.PanicIf(err)
+
+	return hl, nil
+}
+
+// SetHeaderLayout makes the encoder reproduce the given header (normally the
+// `Header` of the `IfdIndex` that the IB was built from) rather than putting
+// the first IFD immediately after the header. This keeps unusual but valid
+// layouts byte-stable across a rebuild. If the gap is shorter than the
+// first-IFD offset implies, the rest is zero-filled. The byte-order of the
+// IB being encoded has to match.
+func (ibe *IfdByteEncoder) SetHeaderLayout(hl HeaderLayout) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.headerLayout = &hl
+}
+
+// firstIfdOffset returns the offset that the first IFD will be written at.
+func (ibe *IfdByteEncoder) firstIfdOffset() uint32 {
+	if ibe.headerLayout == nil || ibe.headerLayout.FirstIfdOffset < ExifDefaultFirstIfdOffset {
+		return ExifDefaultFirstIfdOffset
+	}
+
+	return ibe.headerLayout.FirstIfdOffset
+}
+
+// buildHeader returns the header and gap that precede the first IFD.
+func (ibe *IfdByteEncoder) buildHeader(ib *IfdBuilder) (headerBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	hl := ibe.headerLayout
+	if hl != nil && hl.ByteOrder != nil && hl.ByteOrder != ib.byteOrder {
+		log.Panicf("byte-order of header layout does not match IB: [%v] != [%v]", hl.ByteOrder, ib.byteOrder)
+	}
+
+	firstIfdOffset := ibe.firstIfdOffset()
+
+	headerBytes, err = BuildExifHeader(ib.byteOrder, firstIfdOffset)
+	log.PanicIf(err)
+
+	if firstIfdOffset == ExifDefaultFirstIfdOffset {
+		return headerBytes, nil
+	}
+
+	b := bytes.NewBuffer(headerBytes)
+
+	gapSize := int(firstIfdOffset - ExifDefaultFirstIfdOffset)
+	gap := hl.PreIfdGap
+
+	if len(gap) > gapSize {
+		gap = gap[:gapSize]
+	}
+
+	_, err = b.Write(gap)
+	log.PanicIf(err)
+
+	_, err = b.Write(make([]byte, gapSize-len(gap)))
+	log.PanicIf(err)
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,187 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getHeaderLayoutTestExifData(gap []byte) []byte {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	ib := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+
+	firstIfdOffset := ExifDefaultFirstIfdOffset + uint32(len(gap))
+
+	payload, err := ibe.encodeAndAttachIfd(ib, firstIfdOffset)
+	log.PanicIf(err)
+
+	headerBytes, err := BuildExifHeader(exifcommon.TestDefaultByteOrder, firstIfdOffset)
+	log.PanicIf(err)
+
+	shiftedExifData := append(headerBytes, gap...)
+	shiftedExifData = append(shiftedExifData, payload...)
+
+	return shiftedExifData
+}
+
+func TestIfdIndex_Header__Default(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	hl := index.Header
+
+	if hl.ByteOrder != exifcommon.TestDefaultByteOrder {
+		t.Fatalf("Byte-order not correct: [%v]", hl.ByteOrder)
+	} else if hl.FirstIfdOffset != ExifDefaultFirstIfdOffset {
+		t.Fatalf("First-IFD offset not correct: (%d)", hl.FirstIfdOffset)
+	} else if len(hl.PreIfdGap) != 0 {
+		t.Fatalf("Expected no gap: %v", hl.PreIfdGap)
+	} else if hl.IsDefault() != true {
+		t.Fatalf("Expected default header.")
+	}
+}
+
+func TestIfdIndex_Header__Gap(t *testing.T) {
+	gap := []byte("GAPDATA!")
+	exifData := getHeaderLayoutTestExifData(gap)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	hl := index.Header
+
+	if hl.FirstIfdOffset != ExifDefaultFirstIfdOffset+uint32(len(gap)) {
+		t.Fatalf("First-IFD offset not correct: (%d)", hl.FirstIfdOffset)
+	} else if bytes.Equal(hl.PreIfdGap, gap) != true {
+		t.Fatalf("Gap not correct: %v", hl.PreIfdGap)
+	} else if hl.IsDefault() != false {
+		t.Fatalf("Expected non-default header.")
+	}
+}
+
+func TestIfdIndex_Header__LargeGap(t *testing.T) {
+	gap := make([]byte, maxPreIfdGapSize+1)
+	exifData := getHeaderLayoutTestExifData(gap)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	hl := index.Header
+
+	if hl.FirstIfdOffset != ExifDefaultFirstIfdOffset+uint32(len(gap)) {
+		t.Fatalf("First-IFD offset not correct: (%d)", hl.FirstIfdOffset)
+	} else if hl.PreIfdGap != nil {
+		t.Fatalf("Expected a gap this large to not be kept: (%d)", len(hl.PreIfdGap))
+	}
+}
+
+func TestIfdByteEncoder_SetHeaderLayout(t *testing.T) {
+	gap := []byte("GAPDATA!")
+	exifData := getHeaderLayoutTestExifData(gap)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	ib := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetHeaderLayout(index.Header)
+
+	rebuiltExifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	if bytes.Equal(rebuiltExifData, exifData) != true {
+		t.Fatalf("Rebuilt EXIF not byte-identical:\nACTUAL: %x\nEXPECTED: %x", rebuiltExifData, exifData)
+	}
+
+	validateExifSimpleTestIbAtOffset(rebuiltExifData, index.Header.FirstIfdOffset, t)
+
+	// The plan accounts for the gap, too.
+
+	el, err := ibe.Plan(ib)
+	log.PanicIf(err)
+
+	if el.Size != uint32(len(exifData)) {
+		t.Fatalf("Planned size not correct: (%d) != (%d)", el.Size, len(exifData))
+	}
+}
+
+func TestIfdByteEncoder_SetHeaderLayout__ZeroFill(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	hl := HeaderLayout{
+		ByteOrder:      exifcommon.TestDefaultByteOrder,
+		FirstIfdOffset: ExifDefaultFirstIfdOffset + 4,
+	}
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetHeaderLayout(hl)
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	if bytes.Equal(exifData[ExifDefaultFirstIfdOffset:ExifDefaultFirstIfdOffset+4], []byte{0, 0, 0, 0}) != true {
+		t.Fatalf("Gap not zero-filled: %v", exifData[:ExifDefaultFirstIfdOffset+4])
+	}
+
+	validateExifSimpleTestIbAtOffset(exifData, hl.FirstIfdOffset, t)
+}
+
+func TestIfdByteEncoder_SetHeaderLayout__ByteOrderMismatch(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	hl := HeaderLayout{
+		ByteOrder:      binary.LittleEndian,
+		FirstIfdOffset: ExifDefaultFirstIfdOffset,
+	}
+
+	if ib.byteOrder == binary.LittleEndian {
+		hl.ByteOrder = binary.BigEndian
+	}
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetHeaderLayout(hl)
+
+	_, err := ibe.EncodeToExif(ib)
+	if err == nil {
+		t.Fatalf("Expected failure for mismatched byte-order.")
+	}
+}
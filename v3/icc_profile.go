@@ -0,0 +1,147 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/v2/filesystem"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+var (
+	// ErrNoIccProfile means that the IFD does not have an embedded ICC
+	// profile.
+	ErrNoIccProfile = errors.New("no ICC profile")
+)
+
+// ValueReader returns a reader over the raw, encoded bytes of the value and
+// their count. Unlike `GetRawBytes()`, nothing is read up front, so this is
+// suited to large values such as ICC profiles. The reader shares the
+// underlying resource with the rest of the IFD and must not be used
+// concurrently with it.
+func (ite *IfdTagEntry) ValueReader() (r io.Reader, size uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// The units of UNDEFINED values are bytes.
+	unitSize := uint32(1)
+	if ite.tagType != exifcommon.TypeUndefined {
+		unitSize = uint32(ite.tagType.Size())
+	}
+
+	size, err = exifcommon.CheckedMulUint32(unitSize, ite.unitCount)
+	log.PanicIf(err)
+
+	if size <= 4 {
+		return bytes.NewReader(ite.rawValueOffset[:size]), size, nil
+	}
+
+	_, err = exifcommon.CheckedAddUint32(ite.valueOffset, size)
+	log.PanicIf(err)
+
+	ra := rifs.NewReadSeekerToReaderAt(ite.rs)
+	r = io.NewSectionReader(ra, int64(ite.valueOffset), int64(size))
+
+	return r, size, nil
+}
+
+// IccProfileReader returns a reader over the ICC profile embedded in the IFD
+// and its size. `ErrNoIccProfile` is returned if there isn't one.
+func (ifd *Ifd) IccProfileReader() (r io.Reader, size uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := ifd.FindTagWithId(InterColorProfileTagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil, 0, ErrNoIccProfile
+		}
+
+		log.Panic(err)
+	}
+
+	r, size, err = results[0].ValueReader()
+	log.PanicIf(err)
+
+	return r, size, nil
+}
+
+// IccProfile returns the ICC profile embedded in the IFD. `ErrNoIccProfile` is
+// returned if there isn't one.
+func (ifd *Ifd) IccProfile() (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	r, size, err := ifd.IccProfileReader()
+	if err != nil {
+		if err == ErrNoIccProfile {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	data = make([]byte, size)
+
+	_, err = io.ReadFull(r, data)
+	log.PanicIf(err)
+
+	return data, nil
+}
+
+// SetIccProfile adds or replaces the embedded ICC profile. The profile must
+// have a valid header. Profiles can run to several megabytes; install a
+// callback with `IfdByteEncoder.SetProgressFn()` to follow the encoding.
+func (ib *IfdBuilder) SetIccProfile(data []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	value := exifundefined.Tag8773InterColorProfile{
+		Data: data,
+	}
+
+	if value.IsValid() == false {
+		log.Panicf("ICC profile is not valid: %s", value)
+	}
+
+	err = ib.SetStandard(InterColorProfileTagId, value)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// SetIccProfileFromReader adds or replaces the embedded ICC profile with the
+// one read from the given reader. See `SetIccProfile()`.
+func (ib *IfdBuilder) SetIccProfileFromReader(r io.Reader) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := ioutil.ReadAll(r)
+	log.PanicIf(err)
+
+	err = ib.SetIccProfile(data)
+	log.PanicIf(err)
+
+	return nil
+}
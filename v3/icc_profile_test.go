@@ -0,0 +1,141 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getTestIccProfile(size int) []byte {
+	data := make([]byte, size)
+
+	for i := 128; i < size; i++ {
+		data[i] = byte(i % 251)
+	}
+
+	data[0] = byte(size >> 24)
+	data[1] = byte(size >> 16)
+	data[2] = byte(size >> 8)
+	data[3] = byte(size)
+	data[8] = 4
+
+	copy(data[12:], "mntr")
+	copy(data[16:], "RGB ")
+	copy(data[36:], "acsp")
+
+	return data
+}
+
+func testIccProfileRoundTrip(t *testing.T, profile []byte) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.SetIccProfileFromReader(bytes.NewReader(profile))
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	recovered, err := index.RootIfd.IccProfile()
+	log.PanicIf(err)
+
+	if bytes.Equal(recovered, profile) != true {
+		t.Fatalf("Profile not read back correctly: (%d) != (%d)", len(recovered), len(profile))
+	}
+
+	r, size, err := index.RootIfd.IccProfileReader()
+	log.PanicIf(err)
+
+	if size != uint32(len(profile)) {
+		t.Fatalf("Size not correct: (%d) != (%d)", size, len(profile))
+	}
+
+	streamed, err := ioutil.ReadAll(r)
+	log.PanicIf(err)
+
+	if bytes.Equal(streamed, profile) != true {
+		t.Fatalf("Profile not streamed correctly: (%d) != (%d)", len(streamed), len(profile))
+	}
+}
+
+func TestIccProfile_RoundTrip(t *testing.T) {
+	testIccProfileRoundTrip(t, getTestIccProfile(560))
+}
+
+func TestIccProfile_RoundTrip_Large(t *testing.T) {
+	testIccProfileRoundTrip(t, getTestIccProfile(3*1024*1024))
+}
+
+func TestIfd_IccProfile_Missing(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.IccProfile()
+	if err != ErrNoIccProfile {
+		t.Fatalf("Expected ErrNoIccProfile: %v", err)
+	}
+}
+
+func TestIfdBuilder_SetIccProfile_Invalid(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.SetIccProfile([]byte("not a profile"))
+	if err == nil {
+		t.Fatalf("Expected error for an invalid profile.")
+	}
+}
+
+func TestIfdTagEntry_ValueReader_Embedded(t *testing.T) {
+	ite := newIfdTagEntry(
+		exifcommon.IfdStandardIfdIdentity,
+		0x1234,
+		0,
+		exifcommon.TypeShort,
+		2,
+		0,
+		[]byte{0x00, 0x01, 0x00, 0x02},
+		nil,
+		exifcommon.TestDefaultByteOrder)
+
+	r, size, err := ite.ValueReader()
+	log.PanicIf(err)
+
+	data, err := ioutil.ReadAll(r)
+	log.PanicIf(err)
+
+	if size != 4 {
+		t.Fatalf("Size not correct: (%d)", size)
+	} else if bytes.Equal(data, []byte{0x00, 0x01, 0x00, 0x02}) != true {
+		t.Fatalf("Embedded value not correct: %v", data)
+	}
+}
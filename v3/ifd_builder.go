@@ -6,8 +6,10 @@ package exif
 // (*IfdBuilder).SetThumbnail() method instead.
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"encoding/binary"
@@ -30,6 +32,17 @@ var (
 type IfdBuilderTagValue struct {
 	valueBytes []byte
 	ib         *IfdBuilder
+
+	// value is a native value whose encoding is deferred until the IB is
+	// encoded, at which point the byte-order of the IB is used.
+	value interface{}
+
+	// isOriginal indicates that the value is the `originalSize` bytes at
+	// `originalOffset` in the EXIF that the IB was copied from, which are only
+	// read when the IB is encoded (see `OriginalDataProvider`).
+	isOriginal     bool
+	originalOffset uint32
+	originalSize   uint32
 }
 
 func (ibtv IfdBuilderTagValue) String() string {
@@ -44,6 +57,10 @@ func (ibtv IfdBuilderTagValue) String() string {
 		return fmt.Sprintf("IfdBuilderTagValue<BYTES=%v LEN=(%d)>", valuePhrase, len(ibtv.valueBytes))
 	} else if ibtv.IsIb() == true {
 		return fmt.Sprintf("IfdBuilderTagValue<IB=%s>", ibtv.ib)
+	} else if ibtv.IsValue() == true {
+		return fmt.Sprintf("IfdBuilderTagValue<VALUE=[%v]>", ibtv.value)
+	} else if ibtv.IsOriginal() == true {
+		return fmt.Sprintf("IfdBuilderTagValue<ORIGINAL-OFFSET=(0x%08x) LEN=(%d)>", ibtv.originalOffset, ibtv.originalSize)
 	} else {
 		log.Panicf("IBTV state undefined")
 		return ""
@@ -62,6 +79,42 @@ func NewIfdBuilderTagValueFromIfdBuilder(ib *IfdBuilder) *IfdBuilderTagValue {
 	}
 }
 
+// NewIfdBuilderTagValueFromValue returns a tag-value for a native value (e.g.
+// `[]uint16`, `string`, `[]exifcommon.Rational`, or an
+// `exifundefined.EncodeableValue`). It is not encoded until the IB is
+// encoded, so it does not depend on a byte-order until then.
+func NewIfdBuilderTagValueFromValue(value interface{}) *IfdBuilderTagValue {
+	return &IfdBuilderTagValue{
+		value: value,
+	}
+}
+
+// NewIfdBuilderTagValueFromOriginal returns a tag-value for the `size` bytes
+// at `offset` in the EXIF that the IB is copied from. They are read from the
+// `OriginalDataProvider` of the encoder when the IB is encoded.
+func NewIfdBuilderTagValueFromOriginal(offset uint32, size uint32) *IfdBuilderTagValue {
+	return &IfdBuilderTagValue{
+		isOriginal:     true,
+		originalOffset: offset,
+		originalSize:   size,
+	}
+}
+
+// IsOriginal returns true if the value refers to the original EXIF rather
+// than being held.
+func (ibtv IfdBuilderTagValue) IsOriginal() bool {
+	return ibtv.isOriginal
+}
+
+// Original returns the offset and the size of the value in the original EXIF.
+func (ibtv IfdBuilderTagValue) Original() (offset uint32, size uint32) {
+	if ibtv.IsOriginal() == false {
+		log.Panicf("this tag is not a reference to original data")
+	}
+
+	return ibtv.originalOffset, ibtv.originalSize
+}
+
 // IsBytes returns true if the bytes are populated. This is always the case
 // when we're loaded from a tag in an existing IFD.
 func (ibtv IfdBuilderTagValue) IsBytes() bool {
@@ -82,6 +135,21 @@ func (ibtv IfdBuilderTagValue) IsIb() bool {
 	return ibtv.ib != nil
 }
 
+// IsValue returns true if this is a native value whose encoding has been
+// deferred.
+func (ibtv IfdBuilderTagValue) IsValue() bool {
+	return ibtv.value != nil
+}
+
+// Value returns the native value.
+func (ibtv IfdBuilderTagValue) Value() interface{} {
+	if ibtv.IsValue() == false {
+		log.Panicf("this tag is not a native value")
+	}
+
+	return ibtv.value
+}
+
 func (ibtv IfdBuilderTagValue) Ib() *IfdBuilder {
 	if ibtv.IsIb() == false {
 		log.Panicf("this tag is not an IFD-builder value")
@@ -107,6 +175,13 @@ type BuilderTag struct {
 	// byteOrder is the byte order. It's chiefly/originally here to support
 	// printing the value.
 	byteOrder binary.ByteOrder
+
+	// sourceOffset is where the value was in the EXIF that the tag was copied
+	// from, if `hasSourceOffset` is true. It's only kept for the maker-note,
+	// whose IFD offsets may be relative to that EXIF (see
+	// `relocateMakerNote()`).
+	sourceOffset    uint32
+	hasSourceOffset bool
 }
 
 func NewBuilderTag(ifdPath string, tagId uint16, typeId exifcommon.TagTypePrimitive, value *IfdBuilderTagValue, byteOrder binary.ByteOrder) *BuilderTag {
@@ -132,6 +207,76 @@ func (bt *BuilderTag) Value() (value *IfdBuilderTagValue) {
 	return bt.value
 }
 
+// Clone returns a copy of the tag that shares nothing with it but the child
+// IB, if the tag points to one. A native value (see
+// `NewIfdBuilderTagValueFromValue()`) is shared rather than deep-copied.
+func (bt *BuilderTag) Clone() *BuilderTag {
+	cloned := *bt
+
+	if bt.value != nil {
+		value := *bt.value
+
+		if value.valueBytes != nil {
+			value.valueBytes = make([]byte, len(bt.value.valueBytes))
+			copy(value.valueBytes, bt.value.valueBytes)
+		}
+
+		cloned.value = &value
+	}
+
+	return &cloned
+}
+
+// EncodedBytes returns the encoded value. Native values are encoded with the
+// given byte-order. Values that are already encoded are returned as-is.
+// `ErrUnresolvedOriginalValue` is returned for values that refer to the
+// original EXIF, which only the encoder can read.
+func (bt *BuilderTag) EncodedBytes(byteOrder binary.ByteOrder) (valueBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if bt.value.IsBytes() == true {
+		return bt.value.Bytes(), nil
+	} else if bt.value.IsOriginal() == true {
+		return nil, ErrUnresolvedOriginalValue
+	} else if bt.value.IsValue() == false {
+		log.Panicf("tag does not have a byte-slice or native value: %s", bt)
+	}
+
+	valueBytes, err = encodeBuilderTagValue(bt.typeId, bt.value.Value(), byteOrder)
+	log.PanicIf(err)
+
+	return valueBytes, nil
+}
+
+// encodeBuilderTagValue encodes a native value.
+func encodeBuilderTagValue(typeId exifcommon.TagTypePrimitive, value interface{}, byteOrder binary.ByteOrder) (valueBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if typeId == exifcommon.TypeUndefined {
+		encodeable := value.(exifundefined.EncodeableValue)
+
+		valueBytes, _, err = exifundefined.Encode(encodeable, byteOrder)
+		log.PanicIf(err)
+
+		return valueBytes, nil
+	}
+
+	ve := exifcommon.NewValueEncoder(byteOrder)
+
+	ed, err := ve.Encode(value)
+	log.PanicIf(err)
+
+	return ed.Encoded, nil
+}
+
 func (bt *BuilderTag) String() string {
 	var valueString string
 
@@ -140,6 +285,8 @@ func (bt *BuilderTag) String() string {
 
 		valueString, err = exifcommon.FormatFromBytes(bt.value.Bytes(), bt.typeId, false, bt.byteOrder)
 		log.PanicIf(err)
+	} else if bt.value.IsValue() == true {
+		valueString = fmt.Sprintf("%v", bt.value.Value())
 	} else {
 		valueString = fmt.Sprintf("%v", bt.value)
 	}
@@ -156,35 +303,40 @@ func (bt *BuilderTag) SetValue(byteOrder binary.ByteOrder, value interface{}) (e
 
 	// TODO(dustin): !! Add test.
 
-	var ed exifcommon.EncodedData
-	if bt.typeId == exifcommon.TypeUndefined {
-		encodeable := value.(exifundefined.EncodeableValue)
-
-		encoded, unitCount, err := exifundefined.Encode(encodeable, byteOrder)
-		log.PanicIf(err)
-
-		ed = exifcommon.EncodedData{
-			Type:      exifcommon.TypeUndefined,
-			Encoded:   encoded,
-			UnitCount: unitCount,
-		}
-	} else {
-		ve := exifcommon.NewValueEncoder(byteOrder)
-
-		var err error
-
-		ed, err = ve.Encode(value)
-		log.PanicIf(err)
-	}
+	encoded, err := encodeBuilderTagValue(bt.typeId, value, byteOrder)
+	log.PanicIf(err)
 
-	bt.value = NewIfdBuilderTagValueFromBytes(ed.Encoded)
+	bt.value = NewIfdBuilderTagValueFromBytes(encoded)
 
 	return nil
 }
 
 // NewStandardBuilderTag constructs a `BuilderTag` instance. The type is looked
-// up. `ii` is the type of IFD that owns this tag.
+// up. `ii` is the type of IFD that owns this tag. Numeric values are converted
+// to the type as described for `exifcommon.CoerceValue()`, allowing lossy
+// conversions; this panics if the value can't be converted at all.
+//
+// Deprecated: Use `NewStandardBuilderTagWithOptions()`, which returns an error
+// rather than panicking. This stays until the next major version (v4).
 func NewStandardBuilderTag(ifdPath string, it *IndexedTag, byteOrder binary.ByteOrder, value interface{}) *BuilderTag {
+	bt, err := NewStandardBuilderTagWithOptions(ifdPath, it, byteOrder, value, exifcommon.CoercionOptions{})
+	log.PanicIf(err)
+
+	return bt
+}
+
+// NewStandardBuilderTagWithOptions constructs a `BuilderTag` instance like
+// `NewStandardBuilderTag()`, with control over how the value is converted. An
+// error is returned if the value can't be converted.
+func NewStandardBuilderTagWithOptions(ifdPath string, it *IndexedTag, byteOrder binary.ByteOrder, value interface{}, options exifcommon.CoercionOptions) (bt *BuilderTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	value = unwrapTagValue(value)
+
 	// If there is more than one supported type, we'll go with the larger to
 	// encode with. It'll use the same amount of fixed-space, and we'll
 	// eliminate unnecessary overflows/issues.
@@ -199,6 +351,16 @@ func NewStandardBuilderTag(ifdPath string, it *IndexedTag, byteOrder binary.Byte
 		rawBytes, _, err = exifundefined.Encode(encodeable, byteOrder)
 		log.PanicIf(err)
 	} else {
+		if exifcommon.IsTime(value) == false {
+			coerced, err := exifcommon.CoerceValue(value, tagType, options)
+			if err != nil {
+				ifdBuilderLogger.Warningf(nil, "Value of type [%T] for tag [%s] can not be stored as [%s]: %v", value, it.Name, tagType, err)
+				log.Panic(err)
+			}
+
+			value = coerced
+		}
+
 		ve := exifcommon.NewValueEncoder(byteOrder)
 
 		ed, err := ve.Encode(value)
@@ -209,12 +371,60 @@ func NewStandardBuilderTag(ifdPath string, it *IndexedTag, byteOrder binary.Byte
 
 	tagValue := NewIfdBuilderTagValueFromBytes(rawBytes)
 
-	return NewBuilderTag(
+	bt = NewBuilderTag(
 		ifdPath,
 		it.Id,
 		tagType,
 		tagValue,
 		byteOrder)
+
+	return bt, nil
+}
+
+// decodeBuilderTagValueBytes is the inverse of `encodeBuilderTagValue()` for
+// non-UNDEFINED types.
+func decodeBuilderTagValueBytes(typeId exifcommon.TagTypePrimitive, valueBytes []byte, byteOrder binary.ByteOrder) (value interface{}, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	unitCount := uint32(len(valueBytes) / typeId.Size())
+
+	rawValueOffset := make([]byte, 4)
+	copy(rawValueOffset, valueBytes)
+
+	vc := exifcommon.NewValueContext(
+		"",
+		0,
+		unitCount,
+		0,
+		rawValueOffset,
+		bytes.NewReader(valueBytes),
+		typeId,
+		byteOrder)
+
+	value, err = vc.Values()
+	log.PanicIf(err)
+
+	return value, nil
+}
+
+// NewNativeStandardBuilderTag constructs a `BuilderTag` like
+// `NewStandardBuilderTag` but keeps the native value rather than encoding it.
+// It is encoded with the byte-order of the IB when the IB is encoded.
+func NewNativeStandardBuilderTag(ifdPath string, it *IndexedTag, value interface{}) *BuilderTag {
+	value = unwrapTagValue(value)
+
+	tagType := it.GetEncodingType(value)
+
+	return NewBuilderTag(
+		ifdPath,
+		it.Id,
+		tagType,
+		NewIfdBuilderTagValueFromValue(value),
+		nil)
 }
 
 type IfdBuilder struct {
@@ -238,16 +448,32 @@ type IfdBuilder struct {
 	// data. Otherwise, it's nil.
 	thumbnailData []byte
 
+	// thumbnailFormat is how `thumbnailData` will be stored.
+	thumbnailFormat ThumbnailFormat
+
 	ifdMapping *exifcommon.IfdMapping
 	tagIndex   *TagIndex
+
+	// mutationObserver, if not nil, is notified of every change to `tags`.
+	mutationObserver MutationObserver
+
+	// positionsByTagId indexes the positions in `tags` of each tag-ID, in
+	// ascending order. It is kept in sync by the same methods that change
+	// `tags`.
+	positionsByTagId map[uint16][]int
+
+	// forbidLossyCoercion causes the standard setters to fail rather than
+	// round or approximate values (see `SetForbidLossyCoercion()`).
+	forbidLossyCoercion bool
 }
 
 func NewIfdBuilder(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ii *exifcommon.IfdIdentity, byteOrder binary.ByteOrder) (ib *IfdBuilder) {
 	ib = &IfdBuilder{
 		ifdIdentity: ii,
 
-		byteOrder: byteOrder,
-		tags:      make([]*BuilderTag, 0),
+		byteOrder:        byteOrder,
+		tags:             make([]*BuilderTag, 0),
+		positionsByTagId: make(map[uint16][]int),
 
 		ifdMapping: ifdMapping,
 		tagIndex:   tagIndex,
@@ -263,17 +489,44 @@ func NewIfdBuilderWithExistingIfd(ifd *Ifd) (ib *IfdBuilder) {
 		ifdIdentity: ifd.IfdIdentity(),
 
 		byteOrder:      ifd.ByteOrder(),
-		existingOffset: ifd.Offset(),
-		ifdMapping:     ifd.ifdMapping,
-		tagIndex:       ifd.tagIndex,
+		existingOffset:   ifd.Offset(),
+		ifdMapping:       ifd.ifdMapping,
+		tagIndex:         ifd.tagIndex,
+		positionsByTagId: make(map[uint16][]int),
 	}
 
 	return ib
 }
 
 // NewIfdBuilderFromExistingChain creates a chain of IB instances from an
-// IFD chain generated from real data.
+// IFD chain generated from real data. Tags whose values can't be read are
+// skipped with a warning; use `NewIfdBuilderFromExistingChainWithReport()` to
+// find out which.
+//
+// Deprecated: Use `NewIfdBuilderFromExistingChainWithReport()`, which returns
+// an error rather than panicking. This stays until the next major version
+// (v4).
 func NewIfdBuilderFromExistingChain(rootIfd *Ifd) (firstIb *IfdBuilder) {
+	firstIb, report, err := NewIfdBuilderFromExistingChainWithReport(rootIfd)
+	log.PanicIf(err)
+
+	report.logSkipped()
+
+	return firstIb
+}
+
+// newIfdBuilderFromExistingChain creates the chain of IBs and records any
+// skipped tags, from this chain and its children, to `report`. If `transform`
+// is not nil, it is applied to every tag that is copied. If `deferOriginals` is
+// true, values that aren't stored in their entries are referred to rather than
+// read (see `NewIfdBuilderFromExistingChainWithOriginals()`).
+func newIfdBuilderFromExistingChain(rootIfd *Ifd, report *CopyReport, transform TagTransformFn, deferOriginals bool) (firstIb *IfdBuilder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
 	var lastIb *IfdBuilder
 	i := 0
 	for thisExistingIfd := rootIfd; thisExistingIfd != nil; thisExistingIfd = thisExistingIfd.nextIfd {
@@ -289,14 +542,14 @@ func NewIfdBuilderFromExistingChain(rootIfd *Ifd) (firstIb *IfdBuilder) {
 			lastIb.SetNextIb(newIb)
 		}
 
-		err := newIb.AddTagsFromExisting(thisExistingIfd, nil, nil)
+		err := newIb.addTagsFromExisting(thisExistingIfd, nil, nil, report, transform, deferOriginals)
 		log.PanicIf(err)
 
 		lastIb = newIb
 		i++
 	}
 
-	return firstIb
+	return firstIb, nil
 }
 
 func (ib *IfdBuilder) IfdIdentity() *exifcommon.IfdIdentity {
@@ -307,6 +560,55 @@ func (ib *IfdBuilder) NextIb() (nextIb *IfdBuilder, err error) {
 	return ib.nextIb, nil
 }
 
+// ByteOrder returns the byte-order that the IB will be encoded with.
+func (ib *IfdBuilder) ByteOrder() binary.ByteOrder {
+	return ib.byteOrder
+}
+
+// SetByteOrder re-targets the IB, its children, and the IBs chained after it
+// to a different byte-order. Already-encoded multibyte values are decoded
+// with the old byte-order and kept as native values so that they are encoded
+// with the new one. UNDEFINED-type values are opaque and left unchanged.
+func (ib *IfdBuilder) SetByteOrder(byteOrder binary.ByteOrder) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for thisIb := ib; thisIb != nil; thisIb = thisIb.nextIb {
+		for _, bt := range thisIb.tags {
+			if bt.value.IsIb() == true {
+				err := bt.value.Ib().SetByteOrder(byteOrder)
+				log.PanicIf(err)
+
+				continue
+			}
+
+			bt.byteOrder = byteOrder
+
+			if bt.value.IsOriginal() == true && bt.typeId != exifcommon.TypeUndefined && bt.typeId.Size() > 1 {
+				log.Panicf("tag (0x%04x) refers to original data and can't be converted to another byte-order", bt.tagId)
+			} else if bt.value.IsBytes() == false {
+				continue
+			} else if bt.typeId == exifcommon.TypeUndefined || bt.typeId.Size() == 1 {
+				continue
+			} else if _, found := tagsWithoutAlignment[bt.tagId]; found == true {
+				continue
+			}
+
+			value, err := decodeBuilderTagValueBytes(bt.typeId, bt.value.Bytes(), thisIb.byteOrder)
+			log.PanicIf(err)
+
+			bt.value = NewIfdBuilderTagValueFromValue(value)
+		}
+
+		thisIb.byteOrder = byteOrder
+	}
+
+	return nil
+}
+
 func (ib *IfdBuilder) ChildWithTagId(childIfdTagId uint16) (childIb *IfdBuilder, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -381,6 +683,8 @@ func getOrCreateIbFromRootIbInner(rootIb *IfdBuilder, parentIb *IfdBuilder, curr
 
 			iiSibling := thisIb.IfdIdentity().NewSibling(i + 1)
 			thisIb.nextIb = NewIfdBuilder(thisIb.ifdMapping, thisIb.tagIndex, iiSibling, thisIb.byteOrder)
+			thisIb.nextIb.mutationObserver = thisIb.mutationObserver
+			thisIb.nextIb.forbidLossyCoercion = thisIb.forbidLossyCoercion
 		}
 
 		thisIb = thisIb.nextIb
@@ -429,6 +733,9 @@ func getOrCreateIbFromRootIbInner(rootIb *IfdBuilder, parentIb *IfdBuilder, curr
 				iiChild,
 				thisIb.byteOrder)
 
+		foundChild.mutationObserver = thisIb.mutationObserver
+		foundChild.forbidLossyCoercion = thisIb.forbidLossyCoercion
+
 		err = thisIb.AddChildIb(foundChild)
 		log.PanicIf(err)
 	}
@@ -470,7 +777,24 @@ func (ib *IfdBuilder) String() string {
 	return fmt.Sprintf("IfdBuilder<PATH=[%s] TAG-ID=(0x%04x) COUNT=(%d) OFF=(0x%04x) NEXT-IFD-PATH=[%s]>", ib.IfdIdentity().UnindexedString(), ib.IfdIdentity().TagId(), len(ib.tags), ib.existingOffset, nextIfdPhrase)
 }
 
+// Tags returns copies of the tags of this IB, in order. Changing the list or
+// the tags in it (e.g. with `SetValue()`) does not affect the IB; use the IB's
+// own methods for that. Child IBs are not copied, so a tag that points to a
+// child IFD still refers to the live child IB.
 func (ib *IfdBuilder) Tags() (tags []*BuilderTag) {
+	tags = make([]*BuilderTag, len(ib.tags))
+	for i, bt := range ib.tags {
+		tags[i] = bt.Clone()
+	}
+
+	return tags
+}
+
+// TagsUnsafe returns the IB's own list of tags without copying it. This is for
+// callers that only read the tags and can't afford the copy. The list must not
+// be changed, and it's only valid until the next change to the IB, after
+// which positions may have shifted.
+func (ib *IfdBuilder) TagsUnsafe() (tags []*BuilderTag) {
 	return ib.tags
 }
 
@@ -502,7 +826,18 @@ func (ib *IfdBuilder) SetThumbnail(data []byte) (err error) {
 		log.Panic("thumbnail is empty")
 	}
 
+	if ib.thumbnailData != nil && ib.thumbnailFormat == ThumbnailFormatUncompressed {
+		// Drop the strip that the previous thumbnail was stored in.
+
+		_, err = ib.DeleteAll(ThumbnailStripOffsetsTagId)
+		log.PanicIf(err)
+
+		_, err = ib.DeleteAll(ThumbnailStripByteCountsTagId)
+		log.PanicIf(err)
+	}
+
 	ib.thumbnailData = data
+	ib.thumbnailFormat = ThumbnailFormatJpeg
 
 	ibtvfb := NewIfdBuilderTagValueFromBytes(ib.thumbnailData)
 	offsetBt :=
@@ -519,7 +854,8 @@ func (ib *IfdBuilder) SetThumbnail(data []byte) (err error) {
 	thumbnailSizeIt, err := ib.tagIndex.Get(ib.IfdIdentity(), ThumbnailSizeTagId)
 	log.PanicIf(err)
 
-	sizeBt := NewStandardBuilderTag(ib.IfdIdentity().UnindexedString(), thumbnailSizeIt, ib.byteOrder, []uint32{uint32(len(ib.thumbnailData))})
+	sizeBt, err := NewStandardBuilderTagWithOptions(ib.IfdIdentity().UnindexedString(), thumbnailSizeIt, ib.byteOrder, []uint32{uint32(len(ib.thumbnailData))}, exifcommon.CoercionOptions{})
+	log.PanicIf(err)
 
 	err = ib.Set(sizeBt)
 	log.PanicIf(err)
@@ -531,6 +867,54 @@ func (ib *IfdBuilder) Thumbnail() []byte {
 	return ib.thumbnailData
 }
 
+// SetPadding sets the padding tag (0xEA1C) to reserve the given number of
+// bytes, as Windows does. This is only valid in IFD0 and the Exif IFD.
+// Windows consumes the reserve as it grows other values and will add its
+// own padding to files that don't have any, so writing one keeps the layout
+// of Windows-edited files stable. Padding that was read from a file is
+// preserved by `NewIfdBuilderFromExistingChainWithReport()`; use
+// `DeleteAll()` with `PaddingTagId` to drop it.
+func (ib *IfdBuilder) SetPadding(size uint32) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if size == 0 {
+		log.Panicf("padding size must be non-zero")
+	}
+
+	err = ib.SetStandard(PaddingTagId, exifundefined.TagEA1CPadding{Size: size})
+	log.PanicIf(err)
+
+	return nil
+}
+
+// Padding returns the number of bytes reserved by the padding tag.
+// `ErrTagEntryNotFound` is returned if there is no padding tag.
+func (ib *IfdBuilder) Padding() (size uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bt, err := ib.FindTag(PaddingTagId)
+	if err != nil {
+		if log.Is(err, ErrTagEntryNotFound) == true {
+			return 0, err
+		}
+
+		log.Panic(err)
+	}
+
+	valueBytes, err := bt.EncodedBytes(ib.byteOrder)
+	log.PanicIf(err)
+
+	return uint32(len(valueBytes)), nil
+}
+
 func (ib *IfdBuilder) printTagTree(levels int) {
 	indent := strings.Repeat(" ", levels*2)
 
@@ -647,7 +1031,7 @@ func (ib *IfdBuilder) PrintIfdTree() {
 	ib.printIfdTree(0)
 }
 
-func (ib *IfdBuilder) dumpToStrings(thisIb *IfdBuilder, prefix string, tagId uint16, lines []string) (linesOutput []string) {
+func (ib *IfdBuilder) dumpToStrings(thisIb *IfdBuilder, prefix string, tagId uint16, lines []string, do *DumpOptions) (linesOutput []string) {
 	if lines == nil {
 		linesOutput = make([]string, 0)
 	} else {
@@ -667,7 +1051,12 @@ func (ib *IfdBuilder) dumpToStrings(thisIb *IfdBuilder, prefix string, tagId uin
 				childIfdName = childIb.IfdIdentity().UnindexedString()
 			}
 
-			line := fmt.Sprintf("TAG<PARENTS=[%s] FQ-IFD-PATH=[%s] IFD-TAG-ID=(0x%04x) CHILD-IFD=[%s] TAG-INDEX=(%d) TAG=[0x%04x]>", prefix, thisIb.IfdIdentity().String(), thisIb.IfdIdentity().TagId(), childIfdName, i, tag.tagId)
+			valuePhrase := ""
+			if do != nil && childIb == nil {
+				valuePhrase = fmt.Sprintf(" VALUE=[%s]", thisIb.dumpValuePhrase(tag, do))
+			}
+
+			line := fmt.Sprintf("TAG<PARENTS=[%s] FQ-IFD-PATH=[%s] IFD-TAG-ID=(0x%04x) CHILD-IFD=[%s] TAG-INDEX=(%d) TAG=[0x%04x]%s>", prefix, thisIb.IfdIdentity().String(), thisIb.IfdIdentity().TagId(), childIfdName, i, tag.tagId, valuePhrase)
 			linesOutput = append(linesOutput, line)
 
 			if childIb == nil {
@@ -681,7 +1070,7 @@ func (ib *IfdBuilder) dumpToStrings(thisIb *IfdBuilder, prefix string, tagId uin
 				childPrefix = fmt.Sprintf("%s->%s", prefix, thisIb.IfdIdentity().UnindexedString())
 			}
 
-			linesOutput = thisIb.dumpToStrings(childIb, childPrefix, tag.tagId, linesOutput)
+			linesOutput = thisIb.dumpToStrings(childIb, childPrefix, tag.tagId, linesOutput, do)
 		}
 
 		siblingIfdIndex++
@@ -690,8 +1079,30 @@ func (ib *IfdBuilder) dumpToStrings(thisIb *IfdBuilder, prefix string, tagId uin
 	return linesOutput
 }
 
+// dumpValuePhrase returns how the value of the tag is shown in a dump. This
+// never fails; any problem encoding the value is described in its place.
+func (ib *IfdBuilder) dumpValuePhrase(bt *BuilderTag, do *DumpOptions) string {
+	valueBytes, err := bt.EncodedBytes(ib.byteOrder)
+	if err != nil {
+		return fmt.Sprintf("!ERROR<%s>", err.Error())
+	}
+
+	phrase, _, err := do.FormatBytes(bt.tagId, bt.typeId, valueBytes, ib.byteOrder)
+	if err != nil {
+		return fmt.Sprintf("!ERROR<%s>", err.Error())
+	}
+
+	return phrase
+}
+
 func (ib *IfdBuilder) DumpToStrings() (lines []string) {
-	return ib.dumpToStrings(ib, "", 0, lines)
+	return ib.dumpToStrings(ib, "", 0, lines, nil)
+}
+
+// DumpToStringsWithOptions is like `DumpToStrings()` but also shows the value
+// of every tag that isn't a child IFD, displayed according to the options.
+func (ib *IfdBuilder) DumpToStringsWithOptions(do DumpOptions) (lines []string) {
+	return ib.dumpToStrings(ib, "", 0, lines, &do)
 }
 
 func (ib *IfdBuilder) SetNextIb(nextIb *IfdBuilder) (err error) {
@@ -706,6 +1117,9 @@ func (ib *IfdBuilder) SetNextIb(nextIb *IfdBuilder) (err error) {
 	return nil
 }
 
+// DeleteN removes the first `n` occurrences of the given tag.
+// `ErrTagEntryNotFound` is returned if there were fewer, in which case the
+// ones that were found have still been removed.
 func (ib *IfdBuilder) DeleteN(tagId uint16, n int) (err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -717,21 +1131,15 @@ func (ib *IfdBuilder) DeleteN(tagId uint16, n int) (err error) {
 		log.Panicf("N must be at least 1: (%d)", n)
 	}
 
-	for n > 0 {
-		j := -1
-		for i, bt := range ib.tags {
-			if bt.tagId == tagId {
-				j = i
-				break
-			}
-		}
+	matches := func(bt *BuilderTag) bool {
+		return bt.tagId == tagId
+	}
 
-		if j == -1 {
-			log.Panic(ErrTagEntryNotFound)
-		}
+	deleted, err := ib.deleteMatching(matches, n)
+	log.PanicIf(err)
 
-		ib.tags = append(ib.tags[:j], ib.tags[j+1:]...)
-		n--
+	if deleted < n {
+		log.Panic(ErrTagEntryNotFound)
 	}
 
 	return nil
@@ -750,6 +1158,8 @@ func (ib *IfdBuilder) DeleteFirst(tagId uint16) (err error) {
 	return nil
 }
 
+// DeleteAll removes every occurrence of the given tag and returns how many
+// there were.
 func (ib *IfdBuilder) DeleteAll(tagId uint16) (n int, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -757,17 +1167,46 @@ func (ib *IfdBuilder) DeleteAll(tagId uint16) (n int, err error) {
 		}
 	}()
 
-	for {
-		err = ib.DeleteN(tagId, 1)
-		if log.Is(err, ErrTagEntryNotFound) == true {
-			break
-		} else if err != nil {
-			log.Panic(err)
+	n, err = ib.DeleteMany(tagId)
+	log.PanicIf(err)
+
+	return n, nil
+}
+
+// DeleteMany removes every occurrence of any of the given tags in one pass
+// and returns how many were removed. This is linear in the number of tags
+// regardless of how many are removed, so prefer it to repeated calls to
+// `DeleteAll()` when clearing many tags from a large IFD.
+func (ib *IfdBuilder) DeleteMany(tagIds ...uint16) (n int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var matches func(bt *BuilderTag) bool
+
+	if len(tagIds) == 1 {
+		tagId := tagIds[0]
+
+		matches = func(bt *BuilderTag) bool {
+			return bt.tagId == tagId
+		}
+	} else {
+		wanted := make(map[uint16]struct{}, len(tagIds))
+		for _, tagId := range tagIds {
+			wanted[tagId] = struct{}{}
 		}
 
-		n++
+		matches = func(bt *BuilderTag) bool {
+			_, found := wanted[bt.tagId]
+			return found
+		}
 	}
 
+	n, err = ib.deleteMatching(matches, 0)
+	log.PanicIf(err)
+
 	return n, nil
 }
 
@@ -784,7 +1223,8 @@ func (ib *IfdBuilder) ReplaceAt(position int, bt *BuilderTag) (err error) {
 		log.Panicf("replacement position does not exist")
 	}
 
-	ib.tags[position] = bt
+	err = ib.replaceAt(position, bt)
+	log.PanicIf(err)
 
 	return nil
 }
@@ -799,7 +1239,8 @@ func (ib *IfdBuilder) Replace(tagId uint16, bt *BuilderTag) (err error) {
 	position, err := ib.Find(tagId)
 	log.PanicIf(err)
 
-	ib.tags[position] = bt
+	err = ib.replaceAt(position, bt)
+	log.PanicIf(err)
 
 	return nil
 }
@@ -814,7 +1255,8 @@ func (ib *IfdBuilder) Set(bt *BuilderTag) (err error) {
 
 	position, err := ib.Find(bt.tagId)
 	if err == nil {
-		ib.tags[position] = bt
+		err = ib.replaceAt(position, bt)
+		log.PanicIf(err)
 	} else if log.Is(err, ErrTagEntryNotFound) == true {
 		err = ib.add(bt)
 		log.PanicIf(err)
@@ -825,27 +1267,66 @@ func (ib *IfdBuilder) Set(bt *BuilderTag) (err error) {
 	return nil
 }
 
+// FindN returns the positions of the first `maxFound` occurrences of the given
+// tag, or all of them if `maxFound` is zero. This is a lookup rather than a
+// scan, so it stays cheap for large IFDs.
 func (ib *IfdBuilder) FindN(tagId uint16, maxFound int) (found []int, err error) {
-	defer func() {
-		if state := recover(); state != nil {
-			err = log.Wrap(state.(error))
-		}
-	}()
-
-	found = make([]int, 0)
+	positions := ib.positionsByTagId[tagId]
 
-	for i, bt := range ib.tags {
-		if bt.tagId == tagId {
-			found = append(found, i)
-			if maxFound == 0 || len(found) >= maxFound {
-				break
-			}
-		}
+	if maxFound > 0 && len(positions) > maxFound {
+		positions = positions[:maxFound]
 	}
 
+	found = make([]int, len(positions))
+	copy(found, positions)
+
 	return found, nil
 }
 
+// indexTag records that the tag at the given position has the given tag-ID.
+func (ib *IfdBuilder) indexTag(tagId uint16, position int) {
+	if ib.positionsByTagId == nil {
+		ib.positionsByTagId = make(map[uint16][]int)
+	}
+
+	positions := ib.positionsByTagId[tagId]
+
+	i := sort.SearchInts(positions, position)
+
+	positions = append(positions, 0)
+	copy(positions[i+1:], positions[i:])
+	positions[i] = position
+
+	ib.positionsByTagId[tagId] = positions
+}
+
+// unindexTag forgets that the tag at the given position has the given tag-ID.
+func (ib *IfdBuilder) unindexTag(tagId uint16, position int) {
+	positions := ib.positionsByTagId[tagId]
+
+	i := sort.SearchInts(positions, position)
+	if i >= len(positions) || positions[i] != position {
+		return
+	}
+
+	positions = append(positions[:i], positions[i+1:]...)
+
+	if len(positions) == 0 {
+		delete(ib.positionsByTagId, tagId)
+	} else {
+		ib.positionsByTagId[tagId] = positions
+	}
+}
+
+// reindexTags rebuilds the tag-ID index after the positions have shifted.
+func (ib *IfdBuilder) reindexTags() {
+	ib.positionsByTagId = make(map[uint16][]int)
+
+	for i, bt := range ib.tags {
+		ib.positionsByTagId[bt.tagId] = append(ib.positionsByTagId[bt.tagId], i)
+	}
+}
+
 func (ib *IfdBuilder) Find(tagId uint16) (position int, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -919,7 +1400,9 @@ func (ib *IfdBuilder) add(bt *BuilderTag) (err error) {
 		log.Panicf("BuilderTag value is not set: %s", bt)
 	}
 
-	ib.tags = append(ib.tags, bt)
+	err = ib.appendTag(bt)
+	log.PanicIf(err)
+
 	return nil
 }
 
@@ -964,7 +1447,9 @@ func (ib *IfdBuilder) AddChildIb(childIb *IfdBuilder) (err error) {
 	}
 
 	bt := ib.NewBuilderTagFromBuilder(childIb)
-	ib.tags = append(ib.tags, bt)
+
+	err = ib.appendTag(bt)
+	log.PanicIf(err)
 
 	return nil
 }
@@ -989,7 +1474,8 @@ func (ib *IfdBuilder) NewBuilderTagFromBuilder(childIb *IfdBuilder) (bt *Builder
 
 // AddTagsFromExisting does a verbatim copy of the entries in `ifd` to this
 // builder. It excludes child IFDs. These must be added explicitly via
-// `AddChildIb()`.
+// `AddChildIb()`. Tags whose values can't be read are skipped with a warning;
+// use `AddTagsFromExistingWithReport()` to find out which.
 func (ib *IfdBuilder) AddTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excludeTagIds []uint16) (err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -997,12 +1483,46 @@ func (ib *IfdBuilder) AddTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excl
 		}
 	}()
 
-	thumbnailData, err := ifd.Thumbnail()
+	report, err := ib.AddTagsFromExistingWithReport(ifd, includeTagIds, excludeTagIds)
+	log.PanicIf(err)
+
+	report.logSkipped()
+
+	return nil
+}
+
+// addTagsFromExisting does the copy for `AddTagsFromExisting()` and records
+// any skipped tags to `report`. If `transform` is not nil, it is applied to
+// every tag that is copied. If `deferOriginals` is true, values that aren't
+// stored in their entries are referred to rather than read.
+func (ib *IfdBuilder) addTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excludeTagIds []uint16, report *CopyReport, transform TagTransformFn, deferOriginals bool) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	thumbnailFormat, err := ifd.ThumbnailFormat()
+	if err != nil && log.Is(err, ErrNoThumbnail) == false {
+		log.Panic(err)
+	}
+
+	hasUncompressedThumbnail := err == nil && thumbnailFormat == ThumbnailFormatUncompressed
+
+	var thumbnailData []byte
 	if err == nil {
+		thumbnailData, err = ifd.Thumbnail()
+		if err != nil && log.Is(err, ErrNoThumbnail) == false {
+			log.Panic(err)
+		}
+
+		// The strips of an uncompressed thumbnail might not be readable.
+		hasUncompressedThumbnail = hasUncompressedThumbnail && err == nil
+	}
+
+	if thumbnailData != nil && thumbnailFormat == ThumbnailFormatJpeg {
 		err = ib.SetThumbnail(thumbnailData)
 		log.PanicIf(err)
-	} else if log.Is(err, ErrNoThumbnail) == false {
-		log.Panic(err)
 	}
 
 	for i, ite := range ifd.Entries() {
@@ -1011,6 +1531,11 @@ func (ib *IfdBuilder) AddTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excl
 			continue
 		}
 
+		if hasUncompressedThumbnail == true && (ite.TagId() == ThumbnailStripOffsetsTagId || ite.TagId() == ThumbnailStripByteCountsTagId) {
+			// As above. The strip will be re-added as a single strip.
+			continue
+		}
+
 		if excludeTagIds != nil && len(excludeTagIds) > 0 {
 			found := false
 			for _, excludedTagId := range excludeTagIds {
@@ -1071,15 +1596,49 @@ func (ib *IfdBuilder) AddTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excl
 				log.Panicf("could not find child IFD for child ITE: IFD-PATH=[%s] TAG-ID=(0x%04x) CURRENT-TAG-POSITION=(%d) CHILDREN=%v", ite.IfdPath(), ite.TagId(), i, childTagIds)
 			}
 
-			childIb := NewIfdBuilderFromExistingChain(childIfd)
+			childIb, err := newIfdBuilderFromExistingChain(childIfd, report, transform, deferOriginals)
+			log.PanicIf(err)
+
 			bt = ib.NewBuilderTagFromBuilder(childIb)
 		} else {
 			// Non-IFD tag.
 
-			rawBytes, err := ite.GetRawBytes()
-			log.PanicIf(err)
+			var value *IfdBuilderTagValue
+
+			// UNDEFINED values are read so that the ones that we can't parse
+			// are skipped the same way either way, unless they're opaque
+			// (e.g. the maker-note or the ICC profile) and so can't fail.
+			isDeferred := false
+			if deferOriginals == true {
+				isDeferrable := ite.TagType() != exifcommon.TypeUndefined || exifundefined.IsOpaque(ifd.ifdIdentity.UnindexedString(), ite.TagId())
+
+				// The units of UNDEFINED values are bytes.
+				size := ite.UnitCount()
+				if ite.TagType() != exifcommon.TypeUndefined {
+					size *= uint32(ite.TagType().Size())
+				}
+
+				if isDeferrable == true && size > 4 {
+					value = NewIfdBuilderTagValueFromOriginal(ite.getValueOffset(), size)
+					isDeferred = true
+				}
+			}
 
-			value := NewIfdBuilderTagValueFromBytes(rawBytes)
+			if isDeferred == false {
+				rawBytes, err := ite.GetRawBytes()
+				if err != nil {
+					if err == exifundefined.ErrUnparseableValue {
+						err := report.addSkipped(ite, err)
+						log.PanicIf(err)
+
+						continue
+					}
+
+					log.Panic(err)
+				}
+
+				value = NewIfdBuilderTagValueFromBytes(rawBytes)
+			}
 
 			bt = NewBuilderTag(
 				ifd.ifdIdentity.UnindexedString(),
@@ -1087,12 +1646,33 @@ func (ib *IfdBuilder) AddTagsFromExisting(ifd *Ifd, includeTagIds []uint16, excl
 				ite.TagType(),
 				value,
 				ib.byteOrder)
+
+			if ite.TagId() == MakerNoteTagId && ifd.ifdIdentity.UnindexedString() == exifcommon.IfdExifStandardIfdIdentity.UnindexedString() {
+				bt.sourceOffset = ite.getValueOffset()
+				bt.hasSourceOffset = true
+			}
+
+			if transform != nil {
+				var keep bool
+
+				bt, keep, err = ib.transformExistingTag(ite, bt, transform)
+				log.PanicIf(err)
+
+				if keep == false {
+					continue
+				}
+			}
 		}
 
 		err := ib.add(bt)
 		log.PanicIf(err)
 	}
 
+	if hasUncompressedThumbnail == true {
+		err = ib.setThumbnailStrip(thumbnailData)
+		log.PanicIf(err)
+	}
+
 	return nil
 }
 
@@ -1108,7 +1688,8 @@ func (ib *IfdBuilder) AddStandard(tagId uint16, value interface{}) (err error) {
 	it, err := ib.tagIndex.Get(ib.IfdIdentity(), tagId)
 	log.PanicIf(err)
 
-	bt := NewStandardBuilderTag(ib.IfdIdentity().UnindexedString(), it, ib.byteOrder, value)
+	bt, err := ib.newStandardBuilderTag(it, value)
+	log.PanicIf(err)
 
 	err = ib.add(bt)
 	log.PanicIf(err)
@@ -1116,6 +1697,34 @@ func (ib *IfdBuilder) AddStandard(tagId uint16, value interface{}) (err error) {
 	return nil
 }
 
+// SetForbidLossyCoercion determines whether the standard setters (e.g.
+// `SetStandardWithName()`) fail with `exifcommon.ErrLossyCoercion` rather than
+// rounding or approximating values that don't exactly fit the type of the
+// tag (see `exifcommon.CoerceValue()`). IBs that are created from this one by
+// `GetOrCreateIbFromRootIb()` inherit it.
+func (ib *IfdBuilder) SetForbidLossyCoercion(flag bool) {
+	ib.forbidLossyCoercion = flag
+}
+
+// newStandardBuilderTag returns a tag for this IB with the value converted
+// according to its options.
+func (ib *IfdBuilder) newStandardBuilderTag(it *IndexedTag, value interface{}) (bt *BuilderTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	options := exifcommon.CoercionOptions{
+		ForbidLossy: ib.forbidLossyCoercion,
+	}
+
+	bt, err = NewStandardBuilderTagWithOptions(ib.IfdIdentity().UnindexedString(), it, ib.byteOrder, value, options)
+	log.PanicIf(err)
+
+	return bt, nil
+}
+
 // AddStandardWithName quickly and easily composes and adds the tag using the
 // information already known about a tag (using the name). Only works with
 // standard tags.
@@ -1129,7 +1738,8 @@ func (ib *IfdBuilder) AddStandardWithName(tagName string, value interface{}) (er
 	it, err := ib.tagIndex.GetWithName(ib.IfdIdentity(), tagName)
 	log.PanicIf(err)
 
-	bt := NewStandardBuilderTag(ib.IfdIdentity().UnindexedString(), it, ib.byteOrder, value)
+	bt, err := ib.newStandardBuilderTag(it, value)
+	log.PanicIf(err)
 
 	err = ib.add(bt)
 	log.PanicIf(err)
@@ -1151,7 +1761,8 @@ func (ib *IfdBuilder) SetStandard(tagId uint16, value interface{}) (err error) {
 	it, err := ib.tagIndex.Get(ib.IfdIdentity(), tagId)
 	log.PanicIf(err)
 
-	bt := NewStandardBuilderTag(ib.IfdIdentity().UnindexedString(), it, ib.byteOrder, value)
+	bt, err := ib.newStandardBuilderTag(it, value)
+	log.PanicIf(err)
 
 	i, err := ib.Find(tagId)
 	if err != nil {
@@ -1159,9 +1770,11 @@ func (ib *IfdBuilder) SetStandard(tagId uint16, value interface{}) (err error) {
 			log.Panic(err)
 		}
 
-		ib.tags = append(ib.tags, bt)
+		err = ib.appendTag(bt)
+		log.PanicIf(err)
 	} else {
-		ib.tags[i] = bt
+		err = ib.replaceAt(i, bt)
+		log.PanicIf(err)
 	}
 
 	return nil
@@ -1182,7 +1795,8 @@ func (ib *IfdBuilder) SetStandardWithName(tagName string, value interface{}) (er
 	it, err := ib.tagIndex.GetWithName(ib.IfdIdentity(), tagName)
 	log.PanicIf(err)
 
-	bt := NewStandardBuilderTag(ib.IfdIdentity().UnindexedString(), it, ib.byteOrder, value)
+	bt, err := ib.newStandardBuilderTag(it, value)
+	log.PanicIf(err)
 
 	i, err := ib.Find(bt.tagId)
 	if err != nil {
@@ -1190,10 +1804,85 @@ func (ib *IfdBuilder) SetStandardWithName(tagName string, value interface{}) (er
 			log.Panic(err)
 		}
 
-		ib.tags = append(ib.tags, bt)
+		err = ib.appendTag(bt)
+		log.PanicIf(err)
 	} else {
-		ib.tags[i] = bt
+		err = ib.replaceAt(i, bt)
+		log.PanicIf(err)
 	}
 
 	return nil
 }
+
+// SetStandardWithNameInIfd sets the tag in the IFD with the given fully-
+// qualified IFD-path, where this IB is the root IB. If the IFD does not exist
+// yet, it is created along with the tag that points to it (e.g. the Exif IFD
+// is commonly missing from scanned TIFFs).
+func (ib *IfdBuilder) SetStandardWithNameInIfd(fqIfdPath string, tagName string, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	childIb, err := GetOrCreateIbFromRootIb(ib, fqIfdPath)
+	log.PanicIf(err)
+
+	err = childIb.SetStandardWithName(tagName, value)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// ExifIb returns the IB for the Exif IFD, creating it (and the tag in this IB
+// that points to it) if it does not exist. This must be called on the root IB.
+func (ib *IfdBuilder) ExifIb() (exifIb *IfdBuilder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	exifIb, err = GetOrCreateIbFromRootIb(ib, exifcommon.IfdExifStandardIfdIdentity.String())
+	log.PanicIf(err)
+
+	return exifIb, nil
+}
+
+// SetExifStandardWithName sets a tag in the Exif IFD, creating the IFD if
+// necessary. This must be called on the root IB.
+func (ib *IfdBuilder) SetExifStandardWithName(tagName string, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ib.SetStandardWithNameInIfd(exifcommon.IfdExifStandardIfdIdentity.String(), tagName, value)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// BuildExif flattens the whole chain (child IFDs, siblings, and the next-IFD
+// pointers) into a complete EXIF block, header included. This must be called
+// on the root IB. It is a shortcut for `NewIfdByteEncoder().EncodeToExif()`;
+// use an `IfdByteEncoder` directly if you need any of the encoder options.
+func (ib *IfdBuilder) BuildExif() (exifData []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.IfdIdentity().UnindexedString() != exifcommon.IfdStandardIfdIdentity.UnindexedString() {
+		log.Panicf("EXIF can only be built from a root IB: [%s]", ib.IfdIdentity().UnindexedString())
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err = ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	return exifData, nil
+}
@@ -0,0 +1,278 @@
+package exif
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// SkippedTag is a tag that could not be copied from an existing IFD.
+type SkippedTag struct {
+	// FqIfdPath is the fully-qualified path of the IFD the tag is in.
+	FqIfdPath string
+
+	// IfdPath is the path of the IFD the tag is in, without indices.
+	IfdPath string
+
+	// TagId is the ID of the tag.
+	TagId uint16
+
+	// TagName is the name of the tag, or empty if it is not known.
+	TagName string
+
+	// TagType is the type of the tag.
+	TagType exifcommon.TagTypePrimitive
+
+	// UnitCount is the number of units in the value.
+	UnitCount uint32
+
+	// Reason is why the tag was skipped:
+	// `exifcommon.ErrUnhandledUndefinedTypedTag` if there is no codec for an
+	// UNDEFINED value or `exifundefined.ErrUnparseableValue` if the codec
+	// could not decode it.
+	Reason error
+
+	// RawBytes is the value as it was stored, so that it can still be passed
+	// through (see `NewBuilderTag()`).
+	RawBytes []byte
+}
+
+// String returns a descriptive string.
+func (st SkippedTag) String() string {
+	return fmt.Sprintf("SkippedTag<FQ-IFD-PATH=[%s] TAG-ID=(0x%04x) TAG-NAME=[%s] TAG-TYPE=[%s] UNIT-COUNT=(%d) REASON=[%v]>", st.FqIfdPath, st.TagId, st.TagName, st.TagType, st.UnitCount, st.Reason)
+}
+
+// NewBuilderTag returns a tag that writes the raw value back out unchanged.
+// Add it to the IB for `IfdPath` to pass the tag through rather than lose it.
+func (st SkippedTag) NewBuilderTag(byteOrder binary.ByteOrder) *BuilderTag {
+	return NewBuilderTag(
+		st.IfdPath,
+		st.TagId,
+		st.TagType,
+		NewIfdBuilderTagValueFromBytes(st.RawBytes),
+		byteOrder)
+}
+
+// CopyReport describes what was not copied from an existing IFD.
+type CopyReport struct {
+	// Skipped are the tags that were not copied, in the order that they
+	// were encountered.
+	Skipped []SkippedTag
+}
+
+// HasSkipped returns true if any tags were skipped.
+func (cr *CopyReport) HasSkipped() bool {
+	return len(cr.Skipped) > 0
+}
+
+// String returns a descriptive string.
+func (cr *CopyReport) String() string {
+	return fmt.Sprintf("CopyReport<SKIPPED=(%d)>", len(cr.Skipped))
+}
+
+// addSkipped records that the given tag was skipped for the given reason.
+func (cr *CopyReport) addSkipped(ite *IfdTagEntry, reason error) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ite.isUnhandledUnknown == true {
+		reason = exifcommon.ErrUnhandledUndefinedTypedTag
+	}
+
+	r, size, err := ite.ValueReader()
+	log.PanicIf(err)
+
+	rawBytes := make([]byte, size)
+
+	_, err = io.ReadFull(r, rawBytes)
+	log.PanicIf(err)
+
+	st := SkippedTag{
+		FqIfdPath: ite.ifdIdentity.String(),
+		IfdPath:   ite.ifdIdentity.UnindexedString(),
+		TagId:     ite.TagId(),
+		TagName:   ite.TagName(),
+		TagType:   ite.TagType(),
+		UnitCount: ite.UnitCount(),
+		Reason:    reason,
+		RawBytes:  rawBytes,
+	}
+
+	cr.Skipped = append(cr.Skipped, st)
+
+	return nil
+}
+
+// logSkipped logs a warning for every skipped tag.
+func (cr *CopyReport) logSkipped() {
+	for _, st := range cr.Skipped {
+		ifdBuilderLogger.Warningf(nil, "Tag (0x%04x) [%s] in IFD [%s] could not be read and was not copied: %v", st.TagId, st.TagName, st.FqIfdPath, st.Reason)
+	}
+}
+
+// AddTagsFromExistingWithReport is like `AddTagsFromExisting()` but, rather
+// than logging them, returns the tags that could not be copied so that the
+// caller can decide whether to abort, pass them through raw (see
+// `SkippedTag.NewBuilderTag()`), or accept the loss.
+func (ib *IfdBuilder) AddTagsFromExistingWithReport(ifd *Ifd, includeTagIds []uint16, excludeTagIds []uint16) (report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	err = ib.addTagsFromExisting(ifd, includeTagIds, excludeTagIds, report, nil, false)
+	log.PanicIf(err)
+
+	return report, nil
+}
+
+// NewIfdBuilderFromExistingChainWithReport is like
+// `NewIfdBuilderFromExistingChain()` but also returns the tags, from the
+// whole tree, that could not be copied. See
+// `AddTagsFromExistingWithReport()`.
+func NewIfdBuilderFromExistingChainWithReport(rootIfd *Ifd) (firstIb *IfdBuilder, report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	firstIb, err = newIfdBuilderFromExistingChain(rootIfd, report, nil, false)
+	log.PanicIf(err)
+
+	return firstIb, report, nil
+}
+
+// TagTransformFn is called with every tag that is copied from an existing IFD
+// and its decoded value. It returns the value to write instead, which may be
+// the same value, and whether to keep the tag at all. A new value must be of
+// the tag's type, or convertible to it as described for
+// `NewCustomBuilderTag()`; UNDEFINED values must be encodeable by
+// `exifundefined`. Tags that represent child IFDs are not passed, though the
+// tags in those IFDs are.
+type TagTransformFn func(ite *IfdTagEntry, value interface{}) (newValue interface{}, keep bool)
+
+// transformExistingTag applies the transform to a tag being copied. `bt` is
+// the verbatim copy, which is returned as-is if the value isn't changed.
+func (ib *IfdBuilder) transformExistingTag(ite *IfdTagEntry, bt *BuilderTag, transform TagTransformFn) (transformedBt *BuilderTag, keep bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	value, err := ite.Value()
+	log.PanicIf(err)
+
+	newValue, keep := transform(ite, value)
+	if keep == false {
+		return nil, false, nil
+	}
+
+	// Leave the value byte-for-byte alone if it wasn't changed.
+	if reflect.DeepEqual(newValue, value) == true {
+		return bt, true, nil
+	}
+
+	if ite.TagType() == exifcommon.TypeUndefined {
+		valueBytes, err := encodeBuilderTagValue(exifcommon.TypeUndefined, newValue, ib.byteOrder)
+		log.PanicIf(err)
+
+		transformedBt = NewBuilderTag(
+			bt.ifdPath,
+			bt.tagId,
+			bt.typeId,
+			NewIfdBuilderTagValueFromBytes(valueBytes),
+			ib.byteOrder)
+
+		return transformedBt, true, nil
+	}
+
+	transformedBt, err = NewCustomBuilderTag(bt.ifdPath, bt.tagId, bt.typeId, newValue, ib.byteOrder)
+	log.PanicIf(err)
+
+	return transformedBt, true, nil
+}
+
+// AddTagsFromExistingWithTransform is like `AddTagsFromExistingWithReport()`
+// but passes every tag through `transform` so that values can be rewritten,
+// or tags dropped, in flight.
+func (ib *IfdBuilder) AddTagsFromExistingWithTransform(ifd *Ifd, includeTagIds []uint16, excludeTagIds []uint16, transform TagTransformFn) (report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	err = ib.addTagsFromExisting(ifd, includeTagIds, excludeTagIds, report, transform, false)
+	log.PanicIf(err)
+
+	return report, nil
+}
+
+// NewIfdBuilderFromExistingChainWithTransform is like
+// `NewIfdBuilderFromExistingChainWithReport()` but passes every tag in the
+// tree through `transform`. See `AddTagsFromExistingWithTransform()`.
+func NewIfdBuilderFromExistingChainWithTransform(rootIfd *Ifd, transform TagTransformFn) (firstIb *IfdBuilder, report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	firstIb, err = newIfdBuilderFromExistingChain(rootIfd, report, transform, false)
+	log.PanicIf(err)
+
+	return firstIb, report, nil
+}
+
+// NewIfdBuilderFromExistingChainWithOriginals is like
+// `NewIfdBuilderFromExistingChainWithReport()` but values that aren't stored
+// in their entries (anything over four bytes, other than UNDEFINED values that
+// have to be decoded) are not read. Rather, they refer to where they are in the original EXIF,
+// and are read from the `OriginalDataProvider` of the encoder (see
+// `SetOriginalDataProvider()`) as they are written. This allows the EXIF of a
+// large file to be rewritten without keeping all of it in memory. Those
+// values can't be inspected or converted to another byte-order before then.
+func NewIfdBuilderFromExistingChainWithOriginals(rootIfd *Ifd) (firstIb *IfdBuilder, report *CopyReport, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	report = &CopyReport{
+		Skipped: make([]SkippedTag, 0),
+	}
+
+	firstIb, err = newIfdBuilderFromExistingChain(rootIfd, report, nil, true)
+	log.PanicIf(err)
+
+	return firstIb, report, nil
+}
@@ -0,0 +1,247 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getTestExifWithUnparseableTag returns EXIF with an indexed UNDEFINED tag
+// that there is no codec for, along with the index that knows it.
+func getTestExifWithUnparseableTag() (exifData []byte, im *exifcommon.IfdMapping, ti *TagIndex) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti = NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Model", "some model")
+	log.PanicIf(err)
+
+	err = ib.AddCustom(0xc010, exifcommon.TypeUndefined, []byte("opaque proprietary data"))
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err = ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	it := &IndexedTag{
+		Id:             0xc010,
+		Name:           "Proprietary",
+		IfdPath:        exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		SupportedTypes: []exifcommon.TagTypePrimitive{exifcommon.TypeUndefined},
+	}
+
+	err = ti.Add(it)
+	log.PanicIf(err)
+
+	return exifData, im, ti
+}
+
+func TestIfdBuilder_AddTagsFromExistingWithReport(t *testing.T) {
+	exifData, im, ti := getTestExifWithUnparseableTag()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	report, err := ib.AddTagsFromExistingWithReport(index.RootIfd, nil, nil)
+	log.PanicIf(err)
+
+	if report.HasSkipped() != true || len(report.Skipped) != 1 {
+		t.Fatalf("Expected exactly one skipped tag: %v", report.Skipped)
+	}
+
+	st := report.Skipped[0]
+
+	if st.TagId != 0xc010 {
+		t.Fatalf("Skipped tag-ID not correct: (0x%04x)", st.TagId)
+	} else if st.TagName != "Proprietary" {
+		t.Fatalf("Skipped tag-name not correct: [%s]", st.TagName)
+	} else if st.FqIfdPath != "IFD" {
+		t.Fatalf("Skipped IFD not correct: [%s]", st.FqIfdPath)
+	} else if st.Reason != exifcommon.ErrUnhandledUndefinedTypedTag {
+		t.Fatalf("Reason not correct: [%v]", st.Reason)
+	} else if string(st.RawBytes) != "opaque proprietary data" {
+		t.Fatalf("Raw bytes not correct: [%s]", string(st.RawBytes))
+	}
+
+	if _, err := ib.FindTag(0xc010); err == nil {
+		t.Fatalf("Skipped tag should not have been copied.")
+	}
+
+	if _, err := ib.FindTagWithName("Model"); err != nil {
+		t.Fatalf("Other tags should have been copied: %v", err)
+	}
+}
+
+func TestIfdBuilder_AddTagsFromExisting_SkipsUnparseable(t *testing.T) {
+	exifData, im, ti := getTestExifWithUnparseableTag()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddTagsFromExisting(index.RootIfd, nil, nil)
+	log.PanicIf(err)
+
+	if len(ib.Tags()) != 1 {
+		t.Fatalf("Expected only the readable tag to be copied: (%d)", len(ib.Tags()))
+	}
+}
+
+func TestNewIfdBuilderFromExistingChainWithReport_PassThrough(t *testing.T) {
+	exifData, im, ti := getTestExifWithUnparseableTag()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	rootIb, report, err := NewIfdBuilderFromExistingChainWithReport(index.RootIfd)
+	log.PanicIf(err)
+
+	if len(report.Skipped) != 1 {
+		t.Fatalf("Expected exactly one skipped tag: %v", report.Skipped)
+	}
+
+	// Pass the skipped tag through as-is.
+
+	for _, st := range report.Skipped {
+		err := rootIb.Add(st.NewBuilderTag(rootIb.ByteOrder()))
+		log.PanicIf(err)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	updatedExif, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, updatedExif)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(0xc010)
+	log.PanicIf(err)
+
+	r, _, err := results[0].ValueReader()
+	log.PanicIf(err)
+
+	rawBytes, err := ioutil.ReadAll(r)
+	log.PanicIf(err)
+
+	if bytes.Equal(rawBytes, []byte("opaque proprietary data")) != true {
+		t.Fatalf("Passed-through value not correct: [%s]", string(rawBytes))
+	}
+}
+
+func TestNewIfdBuilderFromExistingChainWithTransform(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	transform := func(ite *IfdTagEntry, value interface{}) (newValue interface{}, keep bool) {
+		switch ite.TagName() {
+		case "Model":
+			return "Normalized Model", true
+		case "DateTimeOriginal":
+			return nil, false
+		}
+
+		return value, true
+	}
+
+	rootIb, report, err := NewIfdBuilderFromExistingChainWithTransform(index.RootIfd, transform)
+	log.PanicIf(err)
+
+	if report.HasSkipped() != false {
+		t.Fatalf("No tags should have been skipped: %v", report.Skipped)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	updatedExif, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, updatedIndex, err := Collect(im, ti, updatedExif)
+	log.PanicIf(err)
+
+	results, err := updatedIndex.RootIfd.FindTagWithName("Model")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "Normalized Model" {
+		t.Fatalf("Model not rewritten: [%v]", value)
+	}
+
+	exifIfd := updatedIndex.Lookup["IFD/Exif"]
+
+	_, err = exifIfd.FindTagWithName("DateTimeOriginal")
+	if log.Is(err, ErrTagNotFound) != true {
+		t.Fatalf("DateTimeOriginal should have been dropped from the child IFD: %v", err)
+	}
+
+	// Untouched values are copied verbatim.
+
+	originalResults, err := index.Lookup["IFD/Exif"].FindTagWithId(MakerNoteTagId)
+	log.PanicIf(err)
+
+	originalBytes, err := originalResults[0].GetRawBytes()
+	log.PanicIf(err)
+
+	updatedResults, err := exifIfd.FindTagWithId(MakerNoteTagId)
+	log.PanicIf(err)
+
+	updatedBytes, err := updatedResults[0].GetRawBytes()
+	log.PanicIf(err)
+
+	if bytes.Equal(originalBytes, updatedBytes) != true {
+		t.Fatalf("Maker-note not copied verbatim.")
+	}
+}
+
+func TestIfdBuilder_AddTagsFromExistingWithTransform_WrongType(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	transform := func(ite *IfdTagEntry, value interface{}) (newValue interface{}, keep bool) {
+		if ite.TagName() == "Orientation" {
+			return "not a short", true
+		}
+
+		return value, true
+	}
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	_, err = ib.AddTagsFromExistingWithTransform(index.RootIfd, nil, nil, transform)
+	if log.Is(err, ErrTagTypeNotInferable) != true {
+		t.Fatalf("Expected ErrTagTypeNotInferable for a value of the wrong type: %v", err)
+	}
+}
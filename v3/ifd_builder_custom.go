@@ -0,0 +1,190 @@
+package exif
+
+import (
+	"errors"
+	"reflect"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrTagTypeNotInferable means that the type of a tag could not be
+	// determined from its value, or that the value can not be stored as the
+	// type that was given.
+	ErrTagTypeNotInferable = errors.New("tag type not inferable from value")
+)
+
+// normalizeCustomValue turns single values into the one-element slices that
+// the value encoder takes (and unwraps a `TagValue`).
+func normalizeCustomValue(value interface{}) interface{} {
+	value = unwrapTagValue(value)
+
+	switch t := value.(type) {
+	case uint8:
+		return []uint8{t}
+	case uint16:
+		return []uint16{t}
+	case uint32:
+		return []uint32{t}
+	case int32:
+		return []int32{t}
+	case float32:
+		return []float32{t}
+	case float64:
+		return []float64{t}
+	case exifcommon.Rational:
+		return []exifcommon.Rational{t}
+	case exifcommon.SignedRational:
+		return []exifcommon.SignedRational{t}
+	}
+
+	return value
+}
+
+// InferTagType returns the type that the given value is written as when the
+// tag is not in the index: strings are ASCII, `[]byte` is BYTE, `uint16` is
+// SHORT, `uint32` is LONG, `int32` is SLONG, `float32` is FLOAT, `float64` is
+// DOUBLE, `Rational` is RATIONAL, and `SignedRational` is SRATIONAL. Both
+// single values and slices are accepted. `ErrTagTypeNotInferable` is returned
+// for anything else.
+func InferTagType(value interface{}) (tagType exifcommon.TagTypePrimitive, err error) {
+	switch normalizeCustomValue(value).(type) {
+	case string:
+		return exifcommon.TypeAscii, nil
+	case []uint8:
+		return exifcommon.TypeByte, nil
+	case []uint16:
+		return exifcommon.TypeShort, nil
+	case []uint32:
+		return exifcommon.TypeLong, nil
+	case []int32:
+		return exifcommon.TypeSignedLong, nil
+	case []float32:
+		return exifcommon.TypeFloat, nil
+	case []float64:
+		return exifcommon.TypeDouble, nil
+	case []exifcommon.Rational:
+		return exifcommon.TypeRational, nil
+	case []exifcommon.SignedRational:
+		return exifcommon.TypeSignedRational, nil
+	}
+
+	return 0, ErrTagTypeNotInferable
+}
+
+// NewCustomBuilderTag returns a tag that doesn't need to be in the index, such
+// as a proprietary one. If `tagType` is zero, it is inferred from the value
+// (see `InferTagType()`). Otherwise, the value is stored as that type, which
+// must either be the inferred type or one that the value converts to without
+// loss: UNDEFINED from bytes or from a string (which is then written without
+// a NUL), or LONG from shorts. `ErrTagTypeNotInferable` is returned if that's
+// not possible.
+func NewCustomBuilderTag(ifdPath string, tagId uint16, tagType exifcommon.TagTypePrimitive, value interface{}, byteOrder binary.ByteOrder) (bt *BuilderTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	value = normalizeCustomValue(value)
+
+	inferredType, err := InferTagType(value)
+	if err != nil {
+		if err == ErrTagTypeNotInferable {
+			ifdBuilderLogger.Warningf(nil, "Type of tag (0x%04x) can not be inferred from value of type [%v].", tagId, reflect.TypeOf(value))
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	if tagType == 0 {
+		tagType = inferredType
+	}
+
+	if tagType != inferredType {
+		switch {
+		case tagType == exifcommon.TypeUndefined && inferredType == exifcommon.TypeByte:
+		case tagType == exifcommon.TypeUndefined && inferredType == exifcommon.TypeAscii:
+			value = []byte(value.(string))
+		case tagType == exifcommon.TypeLong && inferredType == exifcommon.TypeShort:
+			shorts := value.([]uint16)
+
+			longs := make([]uint32, len(shorts))
+			for i, v := range shorts {
+				longs[i] = uint32(v)
+			}
+
+			value = longs
+		default:
+			ifdBuilderLogger.Warningf(nil, "Value of tag (0x%04x) is [%s] and can not be stored as [%s].", tagId, inferredType, tagType)
+			return nil, ErrTagTypeNotInferable
+		}
+	}
+
+	ve := exifcommon.NewValueEncoder(byteOrder)
+
+	ed, err := ve.Encode(value)
+	log.PanicIf(err)
+
+	bt = NewBuilderTag(
+		ifdPath,
+		tagId,
+		tagType,
+		NewIfdBuilderTagValueFromBytes(ed.Encoded),
+		byteOrder)
+
+	return bt, nil
+}
+
+// AddCustom adds a tag that doesn't need to be in the index. See
+// `NewCustomBuilderTag()` for how `tagType` is used.
+func (ib *IfdBuilder) AddCustom(tagId uint16, tagType exifcommon.TagTypePrimitive, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bt, err := NewCustomBuilderTag(ib.IfdIdentity().UnindexedString(), tagId, tagType, value, ib.byteOrder)
+	if err != nil {
+		if err == ErrTagTypeNotInferable {
+			return err
+		}
+
+		log.Panic(err)
+	}
+
+	err = ib.add(bt)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// SetCustom adds or replaces a tag that doesn't need to be in the index. See
+// `NewCustomBuilderTag()` for how `tagType` is used.
+func (ib *IfdBuilder) SetCustom(tagId uint16, tagType exifcommon.TagTypePrimitive, value interface{}) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bt, err := NewCustomBuilderTag(ib.IfdIdentity().UnindexedString(), tagId, tagType, value, ib.byteOrder)
+	if err != nil {
+		if err == ErrTagTypeNotInferable {
+			return err
+		}
+
+		log.Panic(err)
+	}
+
+	err = ib.Set(bt)
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestInferTagType(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected exifcommon.TagTypePrimitive
+	}{
+		{"some string", exifcommon.TypeAscii},
+		{[]byte{1, 2}, exifcommon.TypeByte},
+		{uint8(1), exifcommon.TypeByte},
+		{uint16(1), exifcommon.TypeShort},
+		{[]uint16{1, 2}, exifcommon.TypeShort},
+		{uint32(1), exifcommon.TypeLong},
+		{int32(-1), exifcommon.TypeSignedLong},
+		{float32(1.5), exifcommon.TypeFloat},
+		{[]float64{1.5}, exifcommon.TypeDouble},
+		{exifcommon.Rational{Numerator: 1, Denominator: 2}, exifcommon.TypeRational},
+		{[]exifcommon.SignedRational{{Numerator: -1, Denominator: 2}}, exifcommon.TypeSignedRational},
+	}
+
+	for _, c := range cases {
+		tagType, err := InferTagType(c.value)
+		log.PanicIf(err)
+
+		if tagType != c.expected {
+			t.Fatalf("Type for %v not correct: [%s] != [%s]", c.value, tagType, c.expected)
+		}
+	}
+
+	_, err := InferTagType(map[string]int{})
+	if err != ErrTagTypeNotInferable {
+		t.Fatalf("Expected ErrTagTypeNotInferable: %v", err)
+	}
+
+	_, err = InferTagType(int(1))
+	if err != ErrTagTypeNotInferable {
+		t.Fatalf("Expected ErrTagTypeNotInferable for a platform-sized integer: %v", err)
+	}
+}
+
+func TestIfdBuilder_AddCustom(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddCustom(0xc001, 0, uint16(5))
+	log.PanicIf(err)
+
+	err = ib.AddCustom(0xc002, 0, "proprietary")
+	log.PanicIf(err)
+
+	err = ib.AddCustom(0xc003, exifcommon.TypeLong, []uint16{1, 2})
+	log.PanicIf(err)
+
+	err = ib.AddCustom(0xc004, exifcommon.TypeUndefined, "raw")
+	log.PanicIf(err)
+
+	err = ib.SetCustom(0xc001, 0, uint16(6))
+	log.PanicIf(err)
+
+	bt, err := ib.FindTag(0xc004)
+	log.PanicIf(err)
+
+	if bt.typeId != exifcommon.TypeUndefined {
+		t.Fatalf("Overridden type not correct: [%s]", bt.typeId)
+	} else if string(bt.value.Bytes()) != "raw" {
+		t.Fatalf("Undefined value not correct: %v", bt.value.Bytes())
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	// Teach the index about the tags so that the parser reads them back.
+
+	ifdPath := exifcommon.IfdStandardIfdIdentity.UnindexedString()
+
+	customTags := []*IndexedTag{
+		{Id: 0xc001, Name: "Custom1", IfdPath: ifdPath, SupportedTypes: []exifcommon.TagTypePrimitive{exifcommon.TypeShort}},
+		{Id: 0xc002, Name: "Custom2", IfdPath: ifdPath, SupportedTypes: []exifcommon.TagTypePrimitive{exifcommon.TypeAscii}},
+		{Id: 0xc003, Name: "Custom3", IfdPath: ifdPath, SupportedTypes: []exifcommon.TagTypePrimitive{exifcommon.TypeLong}},
+	}
+
+	for _, it := range customTags {
+		err := ti.Add(it)
+		log.PanicIf(err)
+	}
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	expected := map[uint16]interface{}{
+		0xc001: []uint16{6},
+		0xc002: "proprietary",
+		0xc003: []uint32{1, 2},
+	}
+
+	for tagId, expectedValue := range expected {
+		results, err := index.RootIfd.FindTagWithId(tagId)
+		log.PanicIf(err)
+
+		if len(results) != 1 {
+			t.Fatalf("Tag (0x%04x) not found exactly once: (%d)", tagId, len(results))
+		}
+
+		value, err := results[0].Value()
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, expectedValue) != true {
+			t.Fatalf("Value of tag (0x%04x) not correct: %v != %v", tagId, value, expectedValue)
+		}
+	}
+}
+
+func TestIfdBuilder_AddCustom_NotInferable(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddCustom(0xc001, 0, struct{}{})
+	if err != ErrTagTypeNotInferable {
+		t.Fatalf("Expected ErrTagTypeNotInferable for an unsupported value: %v", err)
+	}
+
+	err = ib.AddCustom(0xc001, exifcommon.TypeRational, "not a rational")
+	if err != ErrTagTypeNotInferable {
+		t.Fatalf("Expected ErrTagTypeNotInferable for an incompatible type: %v", err)
+	}
+
+	if len(ib.Tags()) != 0 {
+		t.Fatalf("No tags should have been added.")
+	}
+}
@@ -0,0 +1,412 @@
+package exif
+
+import (
+	"fmt"
+	"math"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// BuilderDraftVersion is the version of the draft schema that we write.
+	BuilderDraftVersion = uint32(1)
+)
+
+// BuilderDraft is a serializable copy of an IB chain, with its children and
+// every edit that has been made to it so far, so that an editor can save its
+// work and resume it later (even in another process). The serialized form is
+// the protobuf message described by ifd_builder_draft.proto. Mutation
+// observers are not saved.
+type BuilderDraft struct {
+	Version      uint32
+	LittleEndian bool
+
+	// Source describes where the IBs came from (e.g. a file-path or the
+	// `ContentId()` of a snapshot of the original). It is not interpreted.
+	Source string
+
+	// Ibs are the IBs in depth-first order. The root IB is first.
+	Ibs []DraftIb
+}
+
+// DraftIb is one IB in a draft.
+type DraftIb struct {
+	FqIfdPath string
+
+	// ExistingOffset is the offset that the IFD was read from, if it was read
+	// from existing data.
+	ExistingOffset uint32
+
+	// NextId is one more than the position of the next IB in the chain in
+	// `BuilderDraft.Ibs`, or zero if this is the last one.
+	NextId uint32
+
+	Tags []DraftTag
+
+	Thumbnail       []byte
+	ThumbnailFormat ThumbnailFormat
+}
+
+// DraftTag is one tag in a draft.
+type DraftTag struct {
+	TagId   uint16
+	TagType exifcommon.TagTypePrimitive
+
+	// Value is the encoded value, in the draft's byte-order. It is empty for
+	// child IFDs.
+	Value []byte
+
+	// ChildId is one more than the position of the child IB in
+	// `BuilderDraft.Ibs`, or zero if this is not a child IFD.
+	ChildId uint32
+
+	// Native is true if the value was set as a native value whose encoding
+	// was deferred. It is restored as one, except for UNDEFINED values.
+	Native bool
+
+	// SourceOffset is where the value was in the EXIF that the tag was copied
+	// from, or zero if that isn't kept. Only the maker-note has one, so that
+	// it can be relocated when it's written somewhere else.
+	SourceOffset uint32
+}
+
+// NewBuilderDraft captures the given IB chain, including its children.
+func NewBuilderDraft(rootIb *IfdBuilder, source string) (draft *BuilderDraft, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// Assign the positions first so that tags can refer to IBs that come
+	// after them.
+
+	ibs := make([]*IfdBuilder, 0)
+	positions := make(map[*IfdBuilder]uint32)
+
+	var visit func(ib *IfdBuilder)
+	visit = func(ib *IfdBuilder) {
+		for ; ib != nil; ib = ib.nextIb {
+			if _, found := positions[ib]; found == true {
+				log.Panicf("IB [%s] appears more than once", ib.IfdIdentity())
+			}
+
+			positions[ib] = uint32(len(ibs))
+			ibs = append(ibs, ib)
+
+			for _, bt := range ib.tags {
+				if bt.value.IsIb() == true {
+					visit(bt.value.Ib())
+				}
+			}
+		}
+	}
+
+	visit(rootIb)
+
+	draft = &BuilderDraft{
+		Version:      BuilderDraftVersion,
+		LittleEndian: rootIb.byteOrder == binary.LittleEndian,
+		Source:       source,
+		Ibs:          make([]DraftIb, len(ibs)),
+	}
+
+	for i, ib := range ibs {
+		if ib.byteOrder != rootIb.byteOrder {
+			log.Panicf("IB [%s] has a different byte-order than the root IB", ib.IfdIdentity())
+		}
+
+		di := DraftIb{
+			FqIfdPath:       ib.IfdIdentity().String(),
+			ExistingOffset:  ib.existingOffset,
+			Tags:            make([]DraftTag, len(ib.tags)),
+			Thumbnail:       ib.thumbnailData,
+			ThumbnailFormat: ib.thumbnailFormat,
+		}
+
+		if ib.nextIb != nil {
+			di.NextId = positions[ib.nextIb] + 1
+		}
+
+		for j, bt := range ib.tags {
+			dt := DraftTag{
+				TagId:   bt.tagId,
+				TagType: bt.typeId,
+			}
+
+			if bt.value.IsIb() == true {
+				dt.ChildId = positions[bt.value.Ib()] + 1
+			} else if bt.value.IsValue() == true {
+				dt.Value, err = encodeBuilderTagValue(bt.typeId, bt.value.Value(), ib.byteOrder)
+				log.PanicIf(err)
+
+				dt.Native = true
+			} else if bt.value.IsOriginal() == true {
+				log.Panic(ErrUnresolvedOriginalValue)
+			} else {
+				dt.Value = bt.value.Bytes()
+			}
+
+			if bt.hasSourceOffset == true {
+				dt.SourceOffset = bt.sourceOffset
+			}
+
+			di.Tags[j] = dt
+		}
+
+		draft.Ibs[i] = di
+	}
+
+	return draft, nil
+}
+
+// ByteOrder returns the byte-order of the IBs in the draft.
+func (draft *BuilderDraft) ByteOrder() binary.ByteOrder {
+	if draft.LittleEndian == true {
+		return binary.LittleEndian
+	}
+
+	return binary.BigEndian
+}
+
+// String returns a descriptive string.
+func (draft *BuilderDraft) String() string {
+	return fmt.Sprintf("BuilderDraft<VERSION=(%d) BYTE-ORDER=[%v] SOURCE=[%s] IBS=(%d)>", draft.Version, draft.ByteOrder(), draft.Source, len(draft.Ibs))
+}
+
+// Marshal encodes the draft. The encoding is deterministic.
+func (draft *BuilderDraft) Marshal() (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	pw := new(protoWriter)
+	pw.uint32Field(1, draft.Version)
+	pw.boolField(2, draft.LittleEndian)
+	pw.stringField(3, draft.Source)
+
+	for _, di := range draft.Ibs {
+		ibPw := new(protoWriter)
+		ibPw.stringField(1, di.FqIfdPath)
+		ibPw.uint32Field(2, di.ExistingOffset)
+		ibPw.uint32Field(3, di.NextId)
+
+		for _, dt := range di.Tags {
+			tagPw := new(protoWriter)
+			tagPw.uint32Field(1, uint32(dt.TagId))
+			tagPw.uint32Field(2, uint32(dt.TagType))
+			tagPw.bytesField(3, dt.Value)
+			tagPw.uint32Field(4, dt.ChildId)
+			tagPw.boolField(5, dt.Native)
+			tagPw.uint32Field(6, dt.SourceOffset)
+
+			ibPw.messageField(4, tagPw.b)
+		}
+
+		ibPw.bytesField(5, di.Thumbnail)
+		ibPw.uint32Field(6, uint32(di.ThumbnailFormat))
+
+		pw.messageField(4, ibPw.b)
+	}
+
+	return pw.b, nil
+}
+
+// UnmarshalBuilderDraft decodes a draft produced by `BuilderDraft.Marshal()`.
+// Unknown fields are ignored. Malformed data fails with `ErrSnapshotFormat`.
+func UnmarshalBuilderDraft(data []byte) (draft *BuilderDraft, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	draft = new(BuilderDraft)
+
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireVarint:
+			draft.Version = pr.uint32()
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			draft.LittleEndian = pr.varint() != 0
+		case fieldNumber == 3 && wireType == protoWireBytes:
+			draft.Source = string(pr.bytes())
+		case fieldNumber == 4 && wireType == protoWireBytes:
+			di := unmarshalDraftIb(pr.bytes())
+			draft.Ibs = append(draft.Ibs, di)
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	if draft.Version > BuilderDraftVersion {
+		log.Panicf("draft version not supported: (%d)", draft.Version)
+	}
+
+	return draft, nil
+}
+
+func unmarshalDraftIb(data []byte) (di DraftIb) {
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireBytes:
+			di.FqIfdPath = string(pr.bytes())
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			di.ExistingOffset = pr.uint32()
+		case fieldNumber == 3 && wireType == protoWireVarint:
+			di.NextId = pr.uint32()
+		case fieldNumber == 4 && wireType == protoWireBytes:
+			dt := unmarshalDraftTag(pr.bytes())
+			di.Tags = append(di.Tags, dt)
+		case fieldNumber == 5 && wireType == protoWireBytes:
+			di.Thumbnail = pr.bytes()
+		case fieldNumber == 6 && wireType == protoWireVarint:
+			di.ThumbnailFormat = ThumbnailFormat(pr.uint32())
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	return di
+}
+
+func unmarshalDraftTag(data []byte) (dt DraftTag) {
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireVarint:
+			tagId := pr.uint32()
+			if tagId > math.MaxUint16 {
+				log.Panic(ErrSnapshotFormat)
+			}
+
+			dt.TagId = uint16(tagId)
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			tagType := pr.uint32()
+			if tagType > math.MaxUint16 {
+				log.Panic(ErrSnapshotFormat)
+			}
+
+			dt.TagType = exifcommon.TagTypePrimitive(tagType)
+		case fieldNumber == 3 && wireType == protoWireBytes:
+			dt.Value = pr.bytes()
+		case fieldNumber == 4 && wireType == protoWireVarint:
+			dt.ChildId = pr.uint32()
+		case fieldNumber == 5 && wireType == protoWireVarint:
+			dt.Native = pr.varint() != 0
+		case fieldNumber == 6 && wireType == protoWireVarint:
+			dt.SourceOffset = pr.uint32()
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	return dt
+}
+
+// Builder restores the IB chain that the draft was captured from and returns
+// its root.
+func (draft *BuilderDraft) Builder(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex) (rootIb *IfdBuilder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(draft.Ibs) == 0 {
+		log.Panicf("draft is empty")
+	}
+
+	byteOrder := draft.ByteOrder()
+
+	ibs := make([]*IfdBuilder, len(draft.Ibs))
+	for i, di := range draft.Ibs {
+		ii, err := exifcommon.NewIfdIdentityFromString(ifdMapping, di.FqIfdPath)
+		log.PanicIf(err)
+
+		ib := NewIfdBuilder(ifdMapping, tagIndex, ii, byteOrder)
+		ib.existingOffset = di.ExistingOffset
+		ib.thumbnailData = di.Thumbnail
+		ib.thumbnailFormat = di.ThumbnailFormat
+
+		ibs[i] = ib
+	}
+
+	// IBs are only ever referred to by ones that precede them, exactly once,
+	// which also rules out cycles.
+
+	referenced := make([]bool, len(ibs))
+
+	resolve := func(i int, id uint32) *IfdBuilder {
+		if id == 0 {
+			return nil
+		} else if int(id) > len(ibs) || int(id)-1 <= i || referenced[id-1] == true {
+			log.Panic(ErrSnapshotFormat)
+		}
+
+		referenced[id-1] = true
+
+		return ibs[id-1]
+	}
+
+	for i, di := range draft.Ibs {
+		ib := ibs[i]
+		ifdPath := ib.IfdIdentity().UnindexedString()
+
+		for _, dt := range di.Tags {
+			var bt *BuilderTag
+
+			if dt.ChildId != 0 {
+				childIb := resolve(i, dt.ChildId)
+				bt = NewChildIfdBuilderTag(ifdPath, dt.TagId, NewIfdBuilderTagValueFromIfdBuilder(childIb))
+			} else {
+				if dt.TagType.IsValid() == false {
+					log.Panicf("draft tag (0x%04x) in IB [%s] has invalid type (%d)", dt.TagId, di.FqIfdPath, dt.TagType)
+				}
+
+				// Empty values aren't written, so they come back as nil.
+				valueBytes := dt.Value
+				if valueBytes == nil {
+					valueBytes = []byte{}
+				}
+
+				value := NewIfdBuilderTagValueFromBytes(valueBytes)
+
+				if dt.Native == true && dt.TagType != exifcommon.TypeUndefined {
+					nativeValue, err := decodeBuilderTagValueBytes(dt.TagType, dt.Value, byteOrder)
+					log.PanicIf(err)
+
+					value = NewIfdBuilderTagValueFromValue(nativeValue)
+				}
+
+				bt = NewBuilderTag(ifdPath, dt.TagId, dt.TagType, value, byteOrder)
+
+				if dt.SourceOffset != 0 {
+					bt.sourceOffset = dt.SourceOffset
+					bt.hasSourceOffset = true
+				}
+			}
+
+			err := ib.appendTag(bt)
+			log.PanicIf(err)
+		}
+
+		ib.nextIb = resolve(i, di.NextId)
+	}
+
+	return ibs[0], nil
+}
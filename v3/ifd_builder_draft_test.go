@@ -0,0 +1,163 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getBuilderDraftTestIb() *IfdBuilder {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	// Some edits.
+
+	err = rootIb.SetStandardWithName("Artist", "Someone Else")
+	log.PanicIf(err)
+
+	_, err = rootIb.DeleteAll(0x0131)
+	log.PanicIf(err)
+
+	it, err := ti.GetWithName(exifcommon.IfdStandardIfdIdentity, "XResolution")
+	log.PanicIf(err)
+
+	xResolution := []exifcommon.Rational{{Numerator: 300, Denominator: 1}}
+
+	bt := NewNativeStandardBuilderTag(exifcommon.IfdStandardIfdIdentity.UnindexedString(), it, xResolution)
+
+	err = rootIb.Set(bt)
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("ISOSpeedRatings", []uint16{3200})
+	log.PanicIf(err)
+
+	return rootIb
+}
+
+func TestBuilderDraft_RoundTrip(t *testing.T) {
+	rootIb := getBuilderDraftTestIb()
+
+	draft, err := NewBuilderDraft(rootIb, "NDM_8901.jpg")
+	log.PanicIf(err)
+
+	data, err := draft.Marshal()
+	log.PanicIf(err)
+
+	recovered, err := UnmarshalBuilderDraft(data)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recovered, draft) != true {
+		t.Fatalf("Unmarshaled draft not equal.")
+	} else if recovered.Source != "NDM_8901.jpg" {
+		t.Fatalf("Source not correct: [%s]", recovered.Source)
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	restoredIb, err := recovered.Builder(im, ti)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(restoredIb.DumpToStrings(), rootIb.DumpToStrings()) != true {
+		t.Fatalf("Restored structure not correct.")
+	} else if restoredIb.existingOffset != rootIb.existingOffset {
+		t.Fatalf("Existing offset not restored: (%d)", restoredIb.existingOffset)
+	}
+
+	// The native value is still native.
+
+	bt, err := restoredIb.FindTagWithName("XResolution")
+	log.PanicIf(err)
+
+	if bt.Value().IsValue() != true {
+		t.Fatalf("Native value not restored as a native value.")
+	}
+
+	// Both encode identically.
+
+	ibe := NewIfdByteEncoder()
+
+	expectedExif, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	actualExif, err := ibe.EncodeToExif(restoredIb)
+	log.PanicIf(err)
+
+	if bytes.Equal(actualExif, expectedExif) != true {
+		t.Fatalf("Restored IB does not encode identically.")
+	}
+
+	_, index, err := Collect(im, ti, actualExif)
+	log.PanicIf(err)
+
+	results, err := index.GetTags("Artist", "ISOSpeedRatings", "Software")
+	log.PanicIf(err)
+
+	if results["Artist"].Value.Ascii() != "Someone Else" {
+		t.Fatalf("Artist not correct: [%v]", results["Artist"].Value)
+	} else if reflect.DeepEqual(results["ISOSpeedRatings"].Value.Shorts(), []uint16{3200}) != true {
+		t.Fatalf("ISOSpeedRatings not correct: [%v]", results["ISOSpeedRatings"].Value)
+	} else if results["Software"].Err != ErrTagNotFound {
+		t.Fatalf("Deleted tag was restored: %s", results["Software"])
+	}
+}
+
+func TestBuilderDraft_Builder__BadReference(t *testing.T) {
+	draft := &BuilderDraft{
+		Version: BuilderDraftVersion,
+		Ibs: []DraftIb{
+			{
+				FqIfdPath: "IFD",
+				Tags: []DraftTag{
+					{TagId: 0x8769, TagType: exifcommon.TypeLong, ChildId: 1},
+				},
+			},
+		},
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, err = draft.Builder(im, ti)
+	if err == nil {
+		t.Fatalf("Expected failure for self-reference.")
+	} else if log.Is(err, ErrSnapshotFormat) != true {
+		t.Fatalf("Error not correct: %v", err)
+	}
+}
+
+func TestUnmarshalBuilderDraft_Truncated(t *testing.T) {
+	rootIb := getBuilderDraftTestIb()
+
+	draft, err := NewBuilderDraft(rootIb, "")
+	log.PanicIf(err)
+
+	data, err := draft.Marshal()
+	log.PanicIf(err)
+
+	_, err = UnmarshalBuilderDraft(data[:len(data)-3])
+	if err == nil {
+		t.Fatalf("Expected failure for truncated data.")
+	}
+}
@@ -3,7 +3,9 @@ package exif
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"encoding/binary"
 
@@ -90,26 +92,81 @@ func (bw ByteWriter) WriteFourBytes(value []byte) (err error) {
 // keeping track of where the offsets start, the data that has been added, and
 // bumping the offset *when* the data is added.
 type ifdDataAllocator struct {
-	offset uint32
-	b      bytes.Buffer
+	startOffset uint32
+	offset      uint32
+	b           bytes.Buffer
 }
 
 func newIfdDataAllocator(ifdDataAddressableOffset uint32) *ifdDataAllocator {
 	return &ifdDataAllocator{
-		offset: ifdDataAddressableOffset,
+		startOffset: ifdDataAddressableOffset,
+		offset:      ifdDataAddressableOffset,
 	}
 }
 
 func (ida *ifdDataAllocator) Allocate(value []byte) (offset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	len_, err := exifcommon.CheckedIntToUint32(len(value))
+	log.PanicIf(err)
+
+	nextOffset, err := exifcommon.CheckedAddUint32(ida.offset, len_)
+	log.PanicIf(err)
+
 	_, err = ida.b.Write(value)
 	log.PanicIf(err)
 
 	offset = ida.offset
-	ida.offset += uint32(len(value))
+	ida.offset = nextOffset
 
 	return offset, nil
 }
 
+// Align pads the data so that the next allocation starts on a word (even)
+// boundary.
+func (ida *ifdDataAllocator) Align() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ida.offset%2 != 0 {
+		_, err := ida.Allocate([]byte{0})
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// Reserve allocates space without writing anything to it. This is only useful
+// when sizing things up, since the data will not agree with the offsets
+// afterward.
+func (ida *ifdDataAllocator) Reserve(size uint32) (offset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	nextOffset, err := exifcommon.CheckedAddUint32(ida.offset, size)
+	log.PanicIf(err)
+
+	offset = ida.offset
+	ida.offset = nextOffset
+
+	return offset, nil
+}
+
+// Size returns the amount of data allocated, including anything reserved.
+func (ida *ifdDataAllocator) Size() uint32 {
+	return ida.offset - ida.startOffset
+}
+
 func (ida *ifdDataAllocator) NextOffset() uint32 {
 	return ida.offset
 }
@@ -121,19 +178,327 @@ func (ida *ifdDataAllocator) Bytes() []byte {
 // IfdByteEncoder converts an IB to raw bytes (for writing) while also figuring
 // out all of the allocations and indirection that is required for extended
 // data.
+//
+// An encoder may be shared by many goroutines. Each encode works on private
+// state, and the configuration and the results of the last encode are guarded.
+// Since "last" is ambiguous when encodes overlap, goroutines that need the
+// value offsets or journal of their own encode should use their own encoder.
+// The IBs being encoded must not be modified during the encode.
 type IfdByteEncoder struct {
-	// journal holds a list of actions taken while encoding.
+	// mutex guards the configuration and the results of the last encode.
+	mutex sync.RWMutex
+
+	// journal holds a list of actions taken during the last encode.
 	journal [][3]string
+
+	emptyAsciiPolicy    EmptyAsciiPolicy
+	emptyChildIfdPolicy EmptyChildIfdPolicy
+	verifyOutput        bool
+
+	ifdPadding      uint32
+	trailingPadding uint32
+
+	// valueOffsets records where each allocated value was written during the
+	// last encode.
+	valueOffsets map[ValueOffsetKey]uint32
+
+	// chainIndices records the position of each IB within its chain during
+	// the last encode. IBs do not necessarily have indexed identities.
+	chainIndices map[*IfdBuilder]int
+
+	progressFn EncodeProgressFn
+
+	strictEnums bool
+
+	// valueSizes records the size of each allocated value during the last
+	// encode, alongside `valueOffsets`.
+	valueSizes map[ValueOffsetKey]uint32
+
+	// ifdLayouts records where each IFD was written during the last encode.
+	ifdLayouts []IfdLayout
+
+	// pins are the values to write at fixed offsets after everything else
+	// (see `EncodeLayout.PinValue()`). They are only set for an `Emit()`.
+	pins map[ValueOffsetKey]uint32
+
+	// pinnedValues collects the values that were left out of their IFDs'
+	// data areas because they are pinned.
+	pinnedValues []pinnedValue
+
+	targetExifVersion string
+	exifVersionPolicy ExifVersionPolicy
+
+	// exifVersionConflicts records the tags that were newer than the
+	// targeted version during the last encode.
+	exifVersionConflicts map[ValueOffsetKey]ExifVersionConflict
+
+	// headerLayout is the header to reproduce, if any (see
+	// `SetHeaderLayout()`).
+	headerLayout *HeaderLayout
+
+	sortTags  bool
+	wordAlign bool
+
+	// relocateMakerNotes determines whether maker-notes that share the
+	// offset-space of the EXIF are relocated when they move (see
+	// `SetRelocateMakerNotes()`).
+	relocateMakerNotes bool
+
+	// originalDataProvider supplies the values that refer to the original
+	// EXIF (see `SetOriginalDataProvider()`).
+	originalDataProvider OriginalDataProvider
 }
 
 func NewIfdByteEncoder() (ibe *IfdByteEncoder) {
 	return &IfdByteEncoder{
-		journal: make([][3]string, 0),
+		journal:      make([][3]string, 0),
+		valueOffsets: make(map[ValueOffsetKey]uint32),
+		chainIndices: make(map[*IfdBuilder]int),
+		valueSizes:   make(map[ValueOffsetKey]uint32),
+		ifdLayouts:   make([]IfdLayout, 0),
+
+		exifVersionConflicts: make(map[ValueOffsetKey]ExifVersionConflict),
+	}
+}
+
+// SetIfdPadding reserves the given number of zero bytes at the end of the
+// data area of every IFD. Later edits that grow values slightly can then be
+// made in place without relocating everything that follows.
+func (ibe *IfdByteEncoder) SetIfdPadding(size uint32) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.ifdPadding = size
+}
+
+// IfdPadding returns the number of bytes reserved after each IFD's data.
+func (ibe *IfdByteEncoder) IfdPadding() uint32 {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.ifdPadding
+}
+
+// SetTrailingPadding reserves the given number of zero bytes at the end of
+// the encoded data, after all IFDs. This is room for additional tags to be
+// allocated without rewriting the whole block.
+func (ibe *IfdByteEncoder) SetTrailingPadding(size uint32) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.trailingPadding = size
+}
+
+// TrailingPadding returns the number of bytes reserved after all IFDs.
+func (ibe *IfdByteEncoder) TrailingPadding() uint32 {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.trailingPadding
+}
+
+// EncodeProgressChunkSize is the size of the values that progress is reported
+// for and the number of bytes written between reports.
+const EncodeProgressChunkSize = 64 * 1024
+
+// EncodeProgress describes how much of a large value has been written.
+type EncodeProgress struct {
+	// FqIfdPath is the fully-qualified path of the IFD that the tag is in.
+	FqIfdPath string
+
+	// TagId is the ID of the tag whose value is being written.
+	TagId uint16
+
+	// Written is the number of bytes of the value written so far.
+	Written uint32
+
+	// Total is the size of the value.
+	Total uint32
+}
+
+// EncodeProgressFn is called as large values are written. Returning an error
+// aborts the encode.
+type EncodeProgressFn func(ep EncodeProgress) (err error)
+
+// SetProgressFn installs a callback that is called every
+// `EncodeProgressChunkSize` bytes while writing values larger than that (e.g.
+// TIFF strips or big maker-notes). The callback is invoked on the encoding
+// goroutine. Pass nil to remove it.
+func (ibe *IfdByteEncoder) SetProgressFn(fn EncodeProgressFn) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.progressFn = fn
+}
+
+// allocateValue allocates the value of the given tag in the IFD's data area,
+// reporting progress for large values.
+func (ibe *IfdByteEncoder) allocateValue(ib *IfdBuilder, bt *BuilderTag, ida *ifdDataAllocator, valueBytes []byte, isFinalPass bool) (offset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ibe.wordAlign == true {
+		err := ida.Align()
+		log.PanicIf(err)
 	}
+
+	if ibe.progressFn == nil || isFinalPass == false || len(valueBytes) <= EncodeProgressChunkSize {
+		offset, err = ida.Allocate(valueBytes)
+		log.PanicIf(err)
+
+		return offset, nil
+	}
+
+	total, err := exifcommon.CheckedIntToUint32(len(valueBytes))
+	log.PanicIf(err)
+
+	ep := EncodeProgress{
+		FqIfdPath: ib.IfdIdentity().NewSibling(ibe.chainIndices[ib]).String(),
+		TagId:     bt.tagId,
+		Total:     total,
+	}
+
+	offset = ida.NextOffset()
+
+	for len(valueBytes) > 0 {
+		chunk := valueBytes
+		if len(chunk) > EncodeProgressChunkSize {
+			chunk = chunk[:EncodeProgressChunkSize]
+		}
+
+		_, err := ida.Allocate(chunk)
+		log.PanicIf(err)
+
+		valueBytes = valueBytes[len(chunk):]
+		ep.Written += uint32(len(chunk))
+
+		err = ibe.progressFn(ep)
+		log.PanicIf(err)
+	}
+
+	return offset, nil
+}
+
+// SetStrictEnums determines whether enumerated tags (e.g. Orientation) with
+// values that the specification does not define fail the encode with
+// `ErrEnumValueOutOfRange`. By default, they are written as-is.
+func (ibe *IfdByteEncoder) SetStrictEnums(flag bool) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.strictEnums = flag
+}
+
+// SetRelocateMakerNotes determines whether a maker-note that was copied from
+// existing EXIF and whose IFD has offsets relative to the TIFF header of that
+// EXIF (as Canon's do) has those offsets adjusted when it's written at a
+// different offset. Maker-notes with their own offset-space (e.g. Nikon's,
+// which embed a TIFF header) never need it. Otherwise, the maker-note is
+// written verbatim (which is the default) and its values can no longer be
+// found in the new EXIF.
+func (ibe *IfdByteEncoder) SetRelocateMakerNotes(flag bool) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.relocateMakerNotes = flag
+}
+
+// ValueOffsetKey identifies a tag in the value-offset map.
+type ValueOffsetKey struct {
+	FqIfdPath string
+	TagId     uint16
+}
+
+// String returns a descriptive string.
+func (vok ValueOffsetKey) String() string {
+	return fmt.Sprintf("ValueOffsetKey<FQ-IFD-PATH=[%s] TAG-ID=(0x%04x)>", vok.FqIfdPath, vok.TagId)
+}
+
+// ValueOffsets returns the offsets, relative to the start of the TIFF header,
+// that the values of the tags were written at during the last encode. Only
+// values that were too large to be embedded in their tag entries are present.
+// This allows containers that store their own references to tag data (e.g.
+// thumbnails) to update them. If an IFD has more than one tag with the same
+// ID, the last one wins. The map is a copy.
+func (ibe *IfdByteEncoder) ValueOffsets() map[ValueOffsetKey]uint32 {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	valueOffsets := make(map[ValueOffsetKey]uint32, len(ibe.valueOffsets))
+	for key, offset := range ibe.valueOffsets {
+		valueOffsets[key] = offset
+	}
+
+	return valueOffsets
+}
+
+// ValueOffset returns the offset that the given tag's value was written at
+// during the last encode. `ErrTagNotFound` is returned if the tag was not
+// written or its value was embedded.
+func (ibe *IfdByteEncoder) ValueOffset(fqIfdPath string, tagId uint16) (offset uint32, err error) {
+	key := ValueOffsetKey{
+		FqIfdPath: fqIfdPath,
+		TagId:     tagId,
+	}
+
+	ibe.mutex.RLock()
+	offset, found := ibe.valueOffsets[key]
+	ibe.mutex.RUnlock()
+
+	if found == false {
+		return 0, ErrTagNotFound
+	}
+
+	return offset, nil
+}
+
+// SetEmptyAsciiPolicy determines how ASCII tags with empty values are written.
+// The default is `EmptyAsciiEmitNul`.
+func (ibe *IfdByteEncoder) SetEmptyAsciiPolicy(policy EmptyAsciiPolicy) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.emptyAsciiPolicy = policy
+}
+
+// EmptyAsciiPolicy returns the policy for writing empty ASCII values.
+func (ibe *IfdByteEncoder) EmptyAsciiPolicy() EmptyAsciiPolicy {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.emptyAsciiPolicy
 }
 
+// SetEmptyChildIfdPolicy determines how child IFDs that have no tags to write
+// (possibly because they were all skipped) are written. The default is
+// `EmptyChildIfdEmit`.
+func (ibe *IfdByteEncoder) SetEmptyChildIfdPolicy(policy EmptyChildIfdPolicy) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.emptyChildIfdPolicy = policy
+}
+
+// EmptyChildIfdPolicy returns the policy for writing empty child IFDs.
+func (ibe *IfdByteEncoder) EmptyChildIfdPolicy() EmptyChildIfdPolicy {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.emptyChildIfdPolicy
+}
+
+// Journal returns the steps taken during the last encode.
 func (ibe *IfdByteEncoder) Journal() [][3]string {
-	return ibe.journal
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	journal := make([][3]string, len(ibe.journal))
+	copy(journal, ibe.journal)
+
+	return journal
 }
 
 func (ibe *IfdByteEncoder) TableSize(entryCount int) uint32 {
@@ -152,10 +517,12 @@ func (ibe *IfdByteEncoder) pushToJournal(where, direction, format string, args .
 }
 
 // PrintJournal prints a hierarchical representation of the steps taken during
-// encoding.
+// the last encode.
 func (ibe *IfdByteEncoder) PrintJournal() {
+	journal := ibe.Journal()
+
 	maxWhereLength := 0
-	for _, event := range ibe.journal {
+	for _, event := range journal {
 		where := event[1]
 
 		len_ := len(where)
@@ -165,7 +532,7 @@ func (ibe *IfdByteEncoder) PrintJournal() {
 	}
 
 	level := 0
-	for i, event := range ibe.journal {
+	for i, event := range journal {
 		direction := event[0]
 		where := event[1]
 		message := event[2]
@@ -219,7 +586,9 @@ func (ibe *IfdByteEncoder) encodeTagToBytes(ib *IfdBuilder, bt *BuilderTag, bw *
 
 	// Write unit-count.
 
-	if bt.value.IsBytes() == true {
+	isFinalPass := nextIfdOffsetToWrite > 0
+
+	if bt.value.IsBytes() == true || bt.value.IsValue() == true || bt.value.IsOriginal() == true {
 		effectiveType := bt.typeId
 		if bt.typeId == exifcommon.TypeUndefined {
 			effectiveType = exifcommon.TypeByte
@@ -230,12 +599,40 @@ func (ibe *IfdByteEncoder) encodeTagToBytes(ib *IfdBuilder, bt *BuilderTag, bw *
 
 		typeSize := uint32(effectiveType.Size())
 
-		valueBytes := bt.value.Bytes()
+		// Native values are encoded here, with the byte-order of the IB.
+		// Values that refer to the original data are only read when they're
+		// about to be written. Before then, only their size is needed.
+		isSizeOnly := bt.value.IsOriginal() == true && isFinalPass == false
+
+		var valueBytes []byte
+		var len_ int
+
+		if isSizeOnly == true {
+			_, size := bt.value.Original()
+			len_ = int(size)
+		} else {
+			valueBytes, err = ibe.encodedTagBytes(ib, bt)
+			log.PanicIf(err)
+
+			if ibe.strictEnums == true {
+				err := checkEnumeratedTag(ib, bt, valueBytes)
+				log.PanicIf(err)
+			}
+
+			if ibe.emptyAsciiPolicy == EmptyAsciiEmitZeroLength && isEmptyAsciiTag(bt) == true {
+				valueBytes = nil
+			}
 
-		len_ := len(valueBytes)
+			len_ = len(valueBytes)
+		}
 		unitCount := uint32(len_) / typeSize
 
-		if _, found := tagsWithoutAlignment[bt.tagId]; found == false {
+		// An uncompressed thumbnail is written as a single strip, so there
+		// is exactly one offset regardless of the size of the data.
+		isThumbnailStripBlob := ib.isThumbnailStripBlob(bt)
+		if isThumbnailStripBlob == true {
+			unitCount = 1
+		} else if _, found := tagsWithoutAlignment[bt.tagId]; found == false {
 			remainder := uint32(len_) % typeSize
 
 			if remainder > 0 {
@@ -246,11 +643,62 @@ func (ibe *IfdByteEncoder) encodeTagToBytes(ib *IfdBuilder, bt *BuilderTag, bw *
 		err = bw.WriteUint32(unitCount)
 		log.PanicIf(err)
 
-		// Write four-byte value/offset.
+		// Write four-byte value/offset. Any value of up to four bytes is
+		// stored in the entry, left-justified, regardless of how many units
+		// it has (e.g. two SHORTs).
 
-		if len_ > 4 {
-			offset, err := ida.Allocate(valueBytes)
-			log.PanicIf(err)
+		if len_ > 4 || isThumbnailStripBlob == true {
+			key := ValueOffsetKey{
+				FqIfdPath: ib.IfdIdentity().NewSibling(ibe.chainIndices[ib]).String(),
+				TagId:     bt.tagId,
+			}
+
+			// Pinned values are written after everything else, so they take
+			// no space in the data area in either pass.
+			offset, isPinned := ibe.pins[key]
+			if isPinned == true {
+				if isFinalPass == true {
+					if ibe.relocateMakerNotes == true && bt.hasSourceOffset == true {
+						valueBytes, err = relocateMakerNote(valueBytes, ib.byteOrder, bt.sourceOffset, offset)
+						log.PanicIf(err)
+					}
+
+					ibe.pinnedValues = append(ibe.pinnedValues, pinnedValue{
+						key:    key,
+						offset: offset,
+						data:   valueBytes,
+					})
+				}
+			} else {
+				if isFinalPass == true && ibe.relocateMakerNotes == true && bt.hasSourceOffset == true {
+					if ibe.wordAlign == true {
+						err := ida.Align()
+						log.PanicIf(err)
+					}
+
+					valueBytes, err = relocateMakerNote(valueBytes, ib.byteOrder, bt.sourceOffset, ida.NextOffset())
+					log.PanicIf(err)
+				}
+
+				if isSizeOnly == true {
+					if ibe.wordAlign == true {
+						err := ida.Align()
+						log.PanicIf(err)
+					}
+
+					offset, err = ida.Reserve(uint32(len_))
+					log.PanicIf(err)
+				} else {
+					offset, err = ibe.allocateValue(ib, bt, ida, valueBytes, isFinalPass)
+					log.PanicIf(err)
+				}
+			}
+
+			// Only record the final pass (the first pass only sizes things).
+			if isFinalPass == true {
+				ibe.valueOffsets[key] = offset
+				ibe.valueSizes[key] = uint32(len_)
+			}
 
 			err = bw.WriteUint32(offset)
 			log.PanicIf(err)
@@ -300,6 +748,102 @@ func (ibe *IfdByteEncoder) encodeTagToBytes(ib *IfdBuilder, bt *BuilderTag, bw *
 	return childIfdBlock, nil
 }
 
+// EmptyAsciiPolicy determines how ASCII tags whose values are empty are
+// written. Some writers emit these with a unit-count of zero (not even a NUL),
+// which we read as empty strings.
+type EmptyAsciiPolicy int
+
+const (
+	// EmptyAsciiEmitNul writes empty strings as a single NUL (a unit-count of
+	// one). This is what the specification prescribes.
+	EmptyAsciiEmitNul EmptyAsciiPolicy = iota
+
+	// EmptyAsciiEmitZeroLength writes empty strings with a unit-count of zero.
+	EmptyAsciiEmitZeroLength
+
+	// EmptyAsciiSkip omits tags with empty strings entirely.
+	EmptyAsciiSkip
+)
+
+// isEmptyAsciiTag returns true if the tag is an ASCII tag whose value is
+// either zero-length or just a NUL.
+func isEmptyAsciiTag(bt *BuilderTag) bool {
+	if bt.typeId != exifcommon.TypeAscii {
+		return false
+	} else if bt.value.IsValue() == true {
+		s, ok := bt.value.Value().(string)
+		return ok == true && s == ""
+	} else if bt.value.IsBytes() == false {
+		return false
+	}
+
+	valueBytes := bt.value.Bytes()
+
+	return len(valueBytes) == 0 || (len(valueBytes) == 1 && valueBytes[0] == 0)
+}
+
+// EmptyChildIfdPolicy determines how child IFDs that have no tags to write
+// are handled.
+type EmptyChildIfdPolicy int
+
+const (
+	// EmptyChildIfdEmit writes the child IFD with no entries. This is valid
+	// but useless.
+	EmptyChildIfdEmit EmptyChildIfdPolicy = iota
+
+	// EmptyChildIfdPrune omits the tag that points to the child IFD (and so
+	// the child IFD itself). A parent that only had empty child IFDs is then
+	// empty, too, and is pruned in turn.
+	EmptyChildIfdPrune
+)
+
+// isEmptyChildIfdTag returns true if the tag points to a child IB that will
+// have no tags written.
+func (ibe *IfdByteEncoder) isEmptyChildIfdTag(bt *BuilderTag) bool {
+	if bt.value.IsIb() == false {
+		return false
+	}
+
+	childIb := bt.value.Ib()
+
+	return childIb.nextIb == nil && len(ibe.tagsToEncode(childIb)) == 0
+}
+
+// tagsToEncode returns the tags of the IB that will actually be written.
+func (ibe *IfdByteEncoder) tagsToEncode(ib *IfdBuilder) []*BuilderTag {
+	if ibe.emptyAsciiPolicy != EmptyAsciiSkip && ibe.targetExifVersion == "" && ibe.emptyChildIfdPolicy != EmptyChildIfdPrune && ibe.sortTags == false {
+		return ib.tags
+	}
+
+	tags := make([]*BuilderTag, 0, len(ib.tags))
+	for _, bt := range ib.tags {
+		if ibe.emptyAsciiPolicy == EmptyAsciiSkip && isEmptyAsciiTag(bt) == true {
+			ibe.pushToJournal("tagsToEncode", "-", "Skipping empty ASCII tag (0x%04x).", bt.tagId)
+			continue
+		}
+
+		if ibe.isTooNewForTarget(ib, bt) == true && ibe.exifVersionPolicy == ExifVersionDrop {
+			ibe.pushToJournal("tagsToEncode", "-", "Skipping tag (0x%04x) that is newer than EXIF [%s].", bt.tagId, ibe.targetExifVersion)
+			continue
+		}
+
+		if ibe.emptyChildIfdPolicy == EmptyChildIfdPrune && ibe.isEmptyChildIfdTag(bt) == true {
+			ibe.pushToJournal("tagsToEncode", "-", "Pruning empty child IFD [%s].", bt.value.Ib().IfdIdentity().UnindexedString())
+			continue
+		}
+
+		tags = append(tags, bt)
+	}
+
+	if ibe.sortTags == true {
+		sort.SliceStable(tags, func(i, j int) bool {
+			return tags[i].tagId < tags[j].tagId
+		})
+	}
+
+	return tags
+}
+
 // encodeIfdToBytes encodes the given IB to a byte-slice. We are given the
 // offset at which this IFD will be written. This method is used called both to
 // pre-determine how big the table is going to be (so that we can calculate the
@@ -318,13 +862,18 @@ func (ibe *IfdByteEncoder) encodeIfdToBytes(ib *IfdBuilder, ifdAddressableOffset
 
 	ibe.pushToJournal("encodeIfdToBytes", ">", "%s", ib)
 
-	tableSize = ibe.TableSize(len(ib.tags))
+	tags := ibe.tagsToEncode(ib)
+
+	tagCount, err := exifcommon.CheckedIntToUint16(len(tags))
+	log.PanicIf(err)
+
+	tableSize = ibe.TableSize(len(tags))
 
 	b := new(bytes.Buffer)
 	bw := NewByteWriter(b, ib.byteOrder)
 
 	// Write tag count.
-	err = bw.WriteUint16(uint16(len(ib.tags)))
+	err = bw.WriteUint16(tagCount)
 	log.PanicIf(err)
 
 	ida := newIfdDataAllocator(ifdAddressableOffset)
@@ -335,7 +884,7 @@ func (ibe *IfdByteEncoder) encodeIfdToBytes(ib *IfdBuilder, ifdAddressableOffset
 	// to in the follow-up data-block as required. Any "unknown"-byte tags that
 	// we can't parse will not be present here (using AddTagsFromExisting(), at
 	// least).
-	for _, bt := range ib.tags {
+	for _, bt := range tags {
 		childIfdBlock, err := ibe.encodeTagToBytes(ib, bt, bw, ida, nextIfdOffsetToWrite)
 		log.PanicIf(err)
 
@@ -346,13 +895,30 @@ func (ibe *IfdByteEncoder) encodeIfdToBytes(ib *IfdBuilder, ifdAddressableOffset
 				log.Panicf("no IFD offset provided for child-IFDs; no new child-IFDs permitted")
 			}
 
-			nextIfdOffsetToWrite += uint32(len(childIfdBlock))
+			childIfdBlockSize, err := exifcommon.CheckedIntToUint32(len(childIfdBlock))
+			log.PanicIf(err)
+
+			nextIfdOffsetToWrite, err = exifcommon.CheckedAddUint32(nextIfdOffsetToWrite, childIfdBlockSize)
+			log.PanicIf(err)
+
 			childIfdBlocks = append(childIfdBlocks, childIfdBlock)
 		}
 	}
 
+	if ibe.ifdPadding > 0 {
+		_, err := ida.Allocate(make([]byte, ibe.ifdPadding))
+		log.PanicIf(err)
+	}
+
+	// The tables are always an even size, so this keeps the following IFDs
+	// aligned, too.
+	if ibe.wordAlign == true {
+		err := ida.Align()
+		log.PanicIf(err)
+	}
+
 	dataBytes := ida.Bytes()
-	dataSize = uint32(len(dataBytes))
+	dataSize = ida.Size()
 
 	childIfdSizes = make([]uint32, len(childIfdBlocks))
 	childIfdsTotalSize := uint32(0)
@@ -423,6 +989,7 @@ func (ibe *IfdByteEncoder) encodeAndAttachIfd(ib *IfdBuilder, ifdAddressableOffs
 	i := 0
 
 	for thisIb := ib; thisIb != nil; thisIb = thisIb.nextIb {
+		ibe.chainIndices[thisIb] = i
 
 		// Do a dry-run in order to pre-determine its size requirement.
 
@@ -435,8 +1002,11 @@ func (ibe *IfdByteEncoder) encodeAndAttachIfd(ib *IfdBuilder, ifdAddressableOffs
 
 		ibe.pushToJournal("encodeAndAttachIfd", "<", "Finished calculating size: (%d) [%s]", i, thisIb.IfdIdentity().UnindexedString())
 
-		ifdAddressableOffset += tableSize
-		nextIfdOffsetToWrite := ifdAddressableOffset + allocatedDataSize
+		ifdAddressableOffset, err = exifcommon.CheckedAddUint32(ifdAddressableOffset, tableSize)
+		log.PanicIf(err)
+
+		nextIfdOffsetToWrite, err := exifcommon.CheckedAddUint32(ifdAddressableOffset, allocatedDataSize)
+		log.PanicIf(err)
 
 		ibe.pushToJournal("encodeAndAttachIfd", ">", "Next IFD will be written at offset (0x%08x)", nextIfdOffsetToWrite)
 
@@ -460,10 +1030,15 @@ func (ibe *IfdByteEncoder) encodeAndAttachIfd(ib *IfdBuilder, ifdAddressableOffs
 
 		ibe.pushToJournal("encodeAndAttachIfd", "<", "Encoding done: (%d) [%s]", i, thisIb.IfdIdentity().UnindexedString())
 
-		totalChildIfdSize := uint32(0)
-		for _, childIfdSize := range childIfdSizes {
-			totalChildIfdSize += childIfdSize
-		}
+		ibe.ifdLayouts = append(ibe.ifdLayouts, IfdLayout{
+			FqIfdPath: thisIb.IfdIdentity().NewSibling(i).String(),
+			Offset:    ifdAddressableOffset - tableSize,
+			TableSize: tableSize,
+			DataSize:  allocatedDataSize,
+		})
+
+		totalChildIfdSize, err := exifcommon.CheckedAddUint32(childIfdSizes...)
+		log.PanicIf(err)
 
 		if len(tableAndAllocated) != int(tableSize+allocatedDataSize+totalChildIfdSize) {
 			log.Panicf("IFD table and data is not a consistent size: (%d) != (%d)", len(tableAndAllocated), tableSize+allocatedDataSize+totalChildIfdSize)
@@ -476,7 +1051,8 @@ func (ibe *IfdByteEncoder) encodeAndAttachIfd(ib *IfdBuilder, ifdAddressableOffs
 
 		// Advance past what we've allocated, thus far.
 
-		ifdAddressableOffset += allocatedDataSize + totalChildIfdSize
+		ifdAddressableOffset, err = exifcommon.CheckedAddUint32(ifdAddressableOffset, allocatedDataSize, totalChildIfdSize)
+		log.PanicIf(err)
 
 		ibe.pushToJournal("encodeAndAttachIfd", "<", "Finishing encoding process: (%d) [%s] [FINAL:] NEXT-IFD-OFFSET-TO-WRITE=(0x%08x)", i, ib.IfdIdentity().UnindexedString(), nextIfdOffsetToWrite)
 
@@ -488,8 +1064,82 @@ func (ibe *IfdByteEncoder) encodeAndAttachIfd(ib *IfdBuilder, ifdAddressableOffs
 	return b.Bytes(), nil
 }
 
+// newSession returns an encoder with this encoder's configuration and empty
+// per-encode state. Each encode runs in its own session so that a shared
+// encoder has nothing mutable in common between encodes.
+func (ibe *IfdByteEncoder) newSession() *IfdByteEncoder {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return &IfdByteEncoder{
+		journal:          make([][3]string, 0),
+		emptyAsciiPolicy: ibe.emptyAsciiPolicy,
+		verifyOutput:     ibe.verifyOutput,
+		ifdPadding:       ibe.ifdPadding,
+		trailingPadding:  ibe.trailingPadding,
+		valueOffsets:     make(map[ValueOffsetKey]uint32),
+		chainIndices:     make(map[*IfdBuilder]int),
+		progressFn:       ibe.progressFn,
+		strictEnums:      ibe.strictEnums,
+		valueSizes:       make(map[ValueOffsetKey]uint32),
+		ifdLayouts:       make([]IfdLayout, 0),
+
+		targetExifVersion:    ibe.targetExifVersion,
+		exifVersionPolicy:    ibe.exifVersionPolicy,
+		exifVersionConflicts: make(map[ValueOffsetKey]ExifVersionConflict),
+
+		headerLayout: ibe.headerLayout,
+
+		emptyChildIfdPolicy: ibe.emptyChildIfdPolicy,
+		sortTags:            ibe.sortTags,
+		wordAlign:           ibe.wordAlign,
+		relocateMakerNotes:  ibe.relocateMakerNotes,
+
+		originalDataProvider: ibe.originalDataProvider,
+	}
+}
+
+// storeSessionResults makes the results of a finished session available as
+// those of the last encode.
+func (ibe *IfdByteEncoder) storeSessionResults(session *IfdByteEncoder) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.journal = session.journal
+	ibe.valueOffsets = session.valueOffsets
+	ibe.exifVersionConflicts = session.exifVersionConflicts
+}
+
+// encodeToExifPayload does the work of `EncodeToExifPayload()` within a
+// session.
+func (ibe *IfdByteEncoder) encodeToExifPayload(ib *IfdBuilder) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ibe.resolveTargetExifVersion(ib)
+	log.PanicIf(err)
+
+	data, err = ibe.encodeAndAttachIfd(ib, ibe.firstIfdOffset())
+	log.PanicIf(err)
+
+	if ibe.trailingPadding > 0 {
+		data = append(data, make([]byte, ibe.trailingPadding)...)
+	}
+
+	if len(ibe.pinnedValues) > 0 {
+		data, err = ibe.appendPinnedValues(data)
+		log.PanicIf(err)
+	}
+
+	return data, nil
+}
+
 // EncodeToExifPayload is the base encoding step that transcribes the entire IB
-// structure to its on-disk layout.
+// structure to its on-disk layout. The offsets in the payload assume that it
+// will follow the header (and any gap set with `SetHeaderLayout()`).
 func (ibe *IfdByteEncoder) EncodeToExifPayload(ib *IfdBuilder) (data []byte, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -497,29 +1147,32 @@ func (ibe *IfdByteEncoder) EncodeToExifPayload(ib *IfdBuilder) (data []byte, err
 		}
 	}()
 
-	data, err = ibe.encodeAndAttachIfd(ib, ExifDefaultFirstIfdOffset)
+	session := ibe.newSession()
+
+	data, err = session.encodeToExifPayload(ib)
 	log.PanicIf(err)
 
+	ibe.storeSessionResults(session)
+
 	return data, nil
 }
 
-// EncodeToExif calls EncodeToExifPayload and then packages the result into a
-// complete EXIF block.
-func (ibe *IfdByteEncoder) EncodeToExif(ib *IfdBuilder) (data []byte, err error) {
+// encodeToExif does the work of `EncodeToExif()` within a session.
+func (ibe *IfdByteEncoder) encodeToExif(ib *IfdBuilder) (data []byte, err error) {
 	defer func() {
 		if state := recover(); state != nil {
 			err = log.Wrap(state.(error))
 		}
 	}()
 
-	encodedIfds, err := ibe.EncodeToExifPayload(ib)
+	encodedIfds, err := ibe.encodeToExifPayload(ib)
 	log.PanicIf(err)
 
 	// Wrap the IFD in a formal EXIF block.
 
 	b := new(bytes.Buffer)
 
-	headerBytes, err := BuildExifHeader(ib.byteOrder, ExifDefaultFirstIfdOffset)
+	headerBytes, err := ibe.buildHeader(ib)
 	log.PanicIf(err)
 
 	_, err = b.Write(headerBytes)
@@ -528,5 +1181,31 @@ func (ibe *IfdByteEncoder) EncodeToExif(ib *IfdBuilder) (data []byte, err error)
 	_, err = b.Write(encodedIfds)
 	log.PanicIf(err)
 
-	return b.Bytes(), nil
+	data = b.Bytes()
+
+	if ibe.verifyOutput == true {
+		err := ibe.verifyExif(ib, data)
+		log.PanicIf(err)
+	}
+
+	return data, nil
+}
+
+// EncodeToExif calls EncodeToExifPayload and then packages the result into a
+// complete EXIF block.
+func (ibe *IfdByteEncoder) EncodeToExif(ib *IfdBuilder) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	session := ibe.newSession()
+
+	data, err = session.encodeToExif(ib)
+	log.PanicIf(err)
+
+	ibe.storeSessionResults(session)
+
+	return data, nil
 }
@@ -1,11 +1,18 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
+	"encoding/binary"
+
 	"github.com/dsoprea/go-logging"
 
 	"github.com/dsoprea/go-exif/v3/common"
@@ -169,6 +176,27 @@ func Test_IfdDataAllocator_Allocate_InitialOffset2(t *testing.T) {
 	}
 }
 
+func Test_IfdDataAllocator_Reserve(t *testing.T) {
+	addressableOffset := uint32(10)
+	ida := newIfdDataAllocator(addressableOffset)
+
+	_, err := ida.Allocate([]byte{0x1, 0x2})
+	log.PanicIf(err)
+
+	offset, err := ida.Reserve(1000)
+	log.PanicIf(err)
+
+	if offset != addressableOffset+2 {
+		t.Fatalf("Reserved offset not correct: (%d)", offset)
+	} else if ida.NextOffset() != addressableOffset+1002 {
+		t.Fatalf("Position counter not advanced properly: (%d)", ida.NextOffset())
+	} else if ida.Size() != 1002 {
+		t.Fatalf("Size not correct: (%d)", ida.Size())
+	} else if bytes.Equal(ida.Bytes(), []byte{0x1, 0x2}) != true {
+		t.Fatalf("Reserved space should not have been written: (%d)", len(ida.Bytes()))
+	}
+}
+
 func Test_IfdByteEncoder__Arithmetic(t *testing.T) {
 	ibe := NewIfdByteEncoder()
 
@@ -876,3 +904,528 @@ func ExampleIfdByteEncoder_EncodeToExif() {
 	// 4: IfdTagEntry<TAG-IFD-PATH=[IFD] TAG-ID=(0x013e) TAG-TYPE=[RATIONAL] UNIT-COUNT=(1)> [[{286335522 858997828}]]
 	// 5: IfdTagEntry<TAG-IFD-PATH=[IFD] TAG-ID=(0x9201) TAG-TYPE=[SRATIONAL] UNIT-COUNT=(1)> [[{286335522 858997828}]]
 }
+
+func getEmptyAsciiTestExifData(policy EmptyAsciiPolicy) []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Artist", "")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Copyright", "someone")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetEmptyAsciiPolicy(policy)
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	return exifData
+}
+
+func TestIfdByteEncoder_SetEmptyAsciiPolicy__EmitNul(t *testing.T) {
+	exifData := getEmptyAsciiTestExifData(EmptyAsciiEmitNul)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Artist")
+	log.PanicIf(err)
+
+	if results[0].UnitCount() != 1 {
+		t.Fatalf("Unit-count not correct: (%d)", results[0].UnitCount())
+	}
+}
+
+func TestIfdByteEncoder_SetEmptyAsciiPolicy__EmitZeroLength(t *testing.T) {
+	exifData := getEmptyAsciiTestExifData(EmptyAsciiEmitZeroLength)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Artist")
+	log.PanicIf(err)
+
+	ite := results[0]
+
+	if ite.UnitCount() != 0 {
+		t.Fatalf("Unit-count not correct: (%d)", ite.UnitCount())
+	}
+
+	value, err := ite.Value()
+	log.PanicIf(err)
+
+	if value.(string) != "" {
+		t.Fatalf("Zero-length value not read as an empty string: [%v]", value)
+	}
+
+	// Now, read it again while skipping them.
+
+	eh, err := ParseExifHeader(exifData)
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeekerWithBytes(exifData)
+	ie := NewIfdEnumerate(im, ti, ebs, eh.ByteOrder)
+	ie.SetSkipZeroLengthAscii(true)
+
+	index, err = ie.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.FindTagWithName("Artist")
+	if log.Is(err, ErrTagNotFound) != true {
+		t.Fatalf("Expected zero-length tag to be skipped: %v", err)
+	}
+
+	_, err = index.RootIfd.FindTagWithName("Copyright")
+	log.PanicIf(err)
+}
+
+func TestIfdByteEncoder_SetEmptyAsciiPolicy__Skip(t *testing.T) {
+	exifData := getEmptyAsciiTestExifData(EmptyAsciiSkip)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	if len(index.RootIfd.Entries()) != 1 {
+		t.Fatalf("Empty tag not skipped: (%d)", len(index.RootIfd.Entries()))
+	}
+
+	_, err = index.RootIfd.FindTagWithName("Copyright")
+	log.PanicIf(err)
+}
+
+func getEmptyChildIfdTestIndex(policy EmptyChildIfdPolicy) IfdIndex {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Artist", "someone")
+	log.PanicIf(err)
+
+	// The Exif IFD only has an empty Iop IFD and the GPS IFD only has an
+	// empty string, which is skipped.
+
+	_, err = GetOrCreateIbFromRootIb(rootIb, "IFD/Exif/Iop")
+	log.PanicIf(err)
+
+	gpsIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/GPSInfo")
+	log.PanicIf(err)
+
+	err = gpsIb.AddStandardWithName("GPSMapDatum", "")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetEmptyAsciiPolicy(EmptyAsciiSkip)
+	ibe.SetEmptyChildIfdPolicy(policy)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index
+}
+
+func TestIfdByteEncoder_SetEmptyChildIfdPolicy__Emit(t *testing.T) {
+	index := getEmptyChildIfdTestIndex(EmptyChildIfdEmit)
+
+	for _, fqIfdPath := range []string{"IFD/Exif", "IFD/Exif/Iop", "IFD/GPSInfo"} {
+		ifd, found := index.Lookup[fqIfdPath]
+		if found == false {
+			t.Fatalf("Empty IFD [%s] not written.", fqIfdPath)
+		}
+
+		if fqIfdPath != "IFD/Exif" && len(ifd.Entries()) != 0 {
+			t.Fatalf("IFD [%s] not empty: %v", fqIfdPath, ifd.Entries())
+		}
+	}
+}
+
+func TestIfdByteEncoder_SetEmptyChildIfdPolicy__Prune(t *testing.T) {
+	index := getEmptyChildIfdTestIndex(EmptyChildIfdPrune)
+
+	if len(index.Ifds) != 1 {
+		t.Fatalf("Expected only the root IFD: %v", index.Ifds)
+	}
+
+	entries := index.RootIfd.Entries()
+	if len(entries) != 1 || entries[0].TagName() != "Artist" {
+		t.Fatalf("Root IFD tags not correct: %v", entries)
+	}
+}
+
+func TestIfdByteEncoder_ValueOffsets(t *testing.T) {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	// The thumbnail is the typical out-of-band reference.
+
+	thumbnailOffset, err := ibe.ValueOffset(ThumbnailFqIfdPath, ThumbnailOffsetTagId)
+	log.PanicIf(err)
+
+	originalThumbnail, err := index.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(exifData[thumbnailOffset:thumbnailOffset+uint32(len(originalThumbnail))], originalThumbnail) != true {
+		t.Fatalf("Thumbnail not found at reported offset (%d).", thumbnailOffset)
+	}
+
+	// Confirm the rest against what the parser sees.
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	checked := 0
+	for key, offset := range ibe.ValueOffsets() {
+		if key.FqIfdPath == ThumbnailFqIfdPath && key.TagId == ThumbnailOffsetTagId {
+			continue
+		}
+
+		ifd, found := index.Lookup[key.FqIfdPath]
+		if found == false {
+			t.Fatalf("IFD not found: [%s]", key.FqIfdPath)
+		}
+
+		results, err := ifd.FindTagWithId(key.TagId)
+		log.PanicIf(err)
+
+		if results[0].getValueOffset() != offset {
+			t.Fatalf("Offset for %s not correct: (%d) != (%d)", key, offset, results[0].getValueOffset())
+		}
+
+		checked++
+	}
+
+	if checked == 0 {
+		t.Fatalf("No offsets were checked.")
+	}
+
+	// Embedded values are not present.
+	_, err = ibe.ValueOffset("IFD", 0x0112)
+	if err != ErrTagNotFound {
+		t.Fatalf("Expected no offset for an embedded value: %v", err)
+	}
+}
+
+func TestIfdByteEncoder_SetIfdPadding(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("DateTimeOriginal", "2020:01:02 03:04:05")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	unpadded, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	const ifdPadding = 64
+	const trailingPadding = 100
+
+	ibe = NewIfdByteEncoder()
+	ibe.SetIfdPadding(ifdPadding)
+	ibe.SetTrailingPadding(trailingPadding)
+	ibe.SetVerifyOutput(true)
+
+	padded, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	// There are two IFDs.
+	expectedSize := len(unpadded) + ifdPadding*2 + trailingPadding
+	if len(padded) != expectedSize {
+		t.Fatalf("Padded size not correct: (%d) != (%d)", len(padded), expectedSize)
+	}
+
+	// The padding directly follows the value of the last tag in IFD0.
+
+	valueOffset, err := ibe.ValueOffset("IFD", 0x000b)
+	log.PanicIf(err)
+
+	reserved := padded[valueOffset+11 : valueOffset+11+ifdPadding]
+	if bytes.Equal(reserved, make([]byte, ifdPadding)) != true {
+		t.Fatalf("Reserved area not empty.")
+	}
+
+	if bytes.Equal(padded[len(padded)-trailingPadding:], make([]byte, trailingPadding)) != true {
+		t.Fatalf("Trailing padding not empty.")
+	}
+}
+
+func TestIfdByteEncoder_Concurrent(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetVerifyOutput(true)
+	ibe.SetIfdPadding(8)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			err := func() (err error) {
+				defer func() {
+					if state := recover(); state != nil {
+						err = log.Wrap(state.(error))
+					}
+				}()
+
+				artist := fmt.Sprintf("artist %d with a long enough name", i)
+
+				ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+				err = ib.AddStandardWithName("Artist", artist)
+				log.PanicIf(err)
+
+				err = ib.SetExifStandardWithName("ISOSpeedRatings", []uint16{uint16(i)})
+				log.PanicIf(err)
+
+				exifData, err := ibe.EncodeToExif(ib)
+				log.PanicIf(err)
+
+				_, index, err := Collect(im, ti, exifData)
+				log.PanicIf(err)
+
+				results, err := index.RootIfd.FindTagWithName("Artist")
+				log.PanicIf(err)
+
+				value, err := results[0].Value()
+				log.PanicIf(err)
+
+				if value != artist {
+					log.Panicf("artist not correct: [%v] != [%s]", value, artist)
+				}
+
+				return nil
+			}()
+
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Concurrent encode failed: %v", err)
+	}
+
+	if len(ibe.ValueOffsets()) == 0 {
+		t.Fatalf("Value offsets of the last encode not recorded.")
+	} else if len(ibe.Journal()) == 0 {
+		t.Fatalf("Journal of the last encode not recorded.")
+	}
+}
+
+func TestIfdByteEncoder_SetProgressFn(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Make", "some camera")
+	log.PanicIf(err)
+
+	thumbnailIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	thumbnailData := make([]byte, EncodeProgressChunkSize*2+100)
+	thumbnailData[len(thumbnailData)-1] = 0xff
+
+	err = thumbnailIb.SetThumbnail(thumbnailData)
+	log.PanicIf(err)
+
+	err = rootIb.SetNextIb(thumbnailIb)
+	log.PanicIf(err)
+
+	reports := make([]EncodeProgress, 0)
+
+	ibe := NewIfdByteEncoder()
+
+	ibe.SetProgressFn(func(ep EncodeProgress) error {
+		reports = append(reports, ep)
+		return nil
+	})
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	total := uint32(len(thumbnailData))
+
+	expected := []EncodeProgress{
+		{FqIfdPath: "IFD1", TagId: ThumbnailOffsetTagId, Written: EncodeProgressChunkSize, Total: total},
+		{FqIfdPath: "IFD1", TagId: ThumbnailOffsetTagId, Written: EncodeProgressChunkSize * 2, Total: total},
+		{FqIfdPath: "IFD1", TagId: ThumbnailOffsetTagId, Written: total, Total: total},
+	}
+
+	if reflect.DeepEqual(reports, expected) != true {
+		t.Fatalf("Progress not correct: %v", reports)
+	}
+
+	// The value is intact.
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	recoveredThumbnailData, err := index.RootIfd.nextIfd.Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(recoveredThumbnailData, thumbnailData) != true {
+		t.Fatalf("Thumbnail not correct.")
+	}
+
+	// An error aborts the encode.
+
+	ibe.SetProgressFn(func(ep EncodeProgress) error {
+		return fmt.Errorf("canceled")
+	})
+
+	_, err = ibe.EncodeToExif(rootIb)
+	if err == nil || err.Error() != "canceled" {
+		t.Fatalf("Expected the encode to be aborted: %v", err)
+	}
+}
+
+func TestIfdByteEncoder_InlineMultiUnitValues(t *testing.T) {
+	for _, byteOrder := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		im, err := exifcommon.NewIfdMappingWithStandard()
+		log.PanicIf(err)
+
+		ti := NewTagIndex()
+		ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+		// Several units that still fit in four bytes.
+
+		err = ib.AddStandardWithName("Make", "abc")
+		log.PanicIf(err)
+
+		err = ib.AddStandardWithName("YCbCrSubSampling", []uint16{2, 1})
+		log.PanicIf(err)
+
+		err = ib.AddStandardWithName("XMLPacket", []byte{1, 2, 3, 4})
+		log.PanicIf(err)
+
+		// One byte too many.
+
+		err = ib.AddStandardWithName("ImageDescription", "abcd")
+		log.PanicIf(err)
+
+		exifData, err := NewIfdByteEncoder().EncodeToExif(ib)
+		log.PanicIf(err)
+
+		// Only the last value takes space after the table.
+		tableSize := 2 + 4*int(IfdTagEntrySize) + 4
+		if len(exifData) != int(ExifDefaultFirstIfdOffset)+tableSize+5 {
+			t.Fatalf("EXIF size not correct (%s): (%d)", byteOrder, len(exifData))
+		}
+
+		expectedInline := [][]byte{
+			[]byte("abc\x00"),
+			make([]byte, 4),
+			{1, 2, 3, 4},
+		}
+
+		byteOrder.PutUint16(expectedInline[1][0:2], 2)
+		byteOrder.PutUint16(expectedInline[1][2:4], 1)
+
+		for i, expected := range expectedInline {
+			offset := int(ExifDefaultFirstIfdOffset) + 2 + i*int(IfdTagEntrySize)
+
+			var raw [IfdTagEntrySize]byte
+			copy(raw[:], exifData[offset:])
+
+			rie := DecodeIfdEntry(raw, byteOrder)
+			if rie.IsEmbedded() != true {
+				t.Fatalf("Entry (%d) not embedded: %s", i, rie)
+			} else if bytes.Equal(raw[8:12], expected) != true {
+				t.Fatalf("Entry (%d) value not inline (%s): %x", i, byteOrder, raw[8:12])
+			}
+		}
+
+		_, index, err := Collect(im, ti, exifData)
+		log.PanicIf(err)
+
+		entries := index.RootIfd.Entries()
+
+		value, err := entries[1].Value()
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, []uint16{2, 1}) != true {
+			t.Fatalf("Inline SHORTs not read correctly (%s): %v", byteOrder, value)
+		}
+
+		value, err = entries[2].Value()
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, []byte{1, 2, 3, 4}) != true {
+			t.Fatalf("Inline BYTEs not read correctly (%s): %v", byteOrder, value)
+		}
+
+		// Re-encoding keeps them inline.
+
+		rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+		reencoded, err := NewIfdByteEncoder().EncodeToExif(rootIb)
+		log.PanicIf(err)
+
+		if bytes.Equal(reencoded, exifData) != true {
+			t.Fatalf("Re-encoded EXIF not identical (%s).", byteOrder)
+		}
+	}
+}
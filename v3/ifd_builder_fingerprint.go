@@ -0,0 +1,120 @@
+package exif
+
+import (
+	"hash"
+
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// These distinguish the kinds of tag values in a fingerprint.
+	fingerprintValueBytes    = byte(1)
+	fingerprintValueIb       = byte(2)
+	fingerprintValueOriginal = byte(3)
+
+	// These mark whether another IB follows in the chain.
+	fingerprintChainNext = byte(1)
+	fingerprintChainEnd  = byte(0)
+)
+
+// Fingerprint returns a hex-encoded SHA-256 of the logical content of the IB,
+// its children, and the IBs chained after it: the IFDs, their byte-orders, and
+// the IDs, types, and encoded values of their tags, in order. IBs with the
+// same content have the same fingerprint however they were built (e.g. a
+// native value and its encoded bytes), so it can key a cache of encoded
+// output. The options of the encoder aren't included and should be part of
+// such a key, too. Values that refer to the original EXIF (see
+// `NewIfdBuilderFromExistingChainWithOriginals()`) are hashed by their
+// location rather than read, so the key must also identify that EXIF.
+func (ib *IfdBuilder) Fingerprint() (fingerprint string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	h := sha256.New()
+
+	err = ib.writeFingerprint(h)
+	log.PanicIf(err)
+
+	digest := h.Sum(nil)
+
+	return hex.EncodeToString(digest), nil
+}
+
+// writeFingerprint writes the content of the IB and the IBs chained after it
+// to the hash. Every variable-length field is prefixed with its length so that
+// different content can't serialize the same way.
+func (ib *IfdBuilder) writeFingerprint(h hash.Hash) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	writeUint32 := func(value uint32) {
+		err := binary.Write(h, binary.BigEndian, value)
+		log.PanicIf(err)
+	}
+
+	writeBytes := func(data []byte) {
+		writeUint32(uint32(len(data)))
+
+		_, err := h.Write(data)
+		log.PanicIf(err)
+	}
+
+	for thisIb := ib; thisIb != nil; thisIb = thisIb.nextIb {
+		writeBytes([]byte(thisIb.IfdIdentity().String()))
+		writeBytes([]byte(thisIb.byteOrder.String()))
+
+		// The thumbnail data itself is the value of one of the tags.
+		writeUint32(uint32(thisIb.thumbnailFormat))
+
+		writeUint32(uint32(len(thisIb.tags)))
+
+		for _, bt := range thisIb.tags {
+			writeUint32(uint32(bt.tagId))
+			writeUint32(uint32(bt.typeId))
+
+			if bt.value.IsIb() == true {
+				_, err := h.Write([]byte{fingerprintValueIb})
+				log.PanicIf(err)
+
+				err = bt.value.Ib().writeFingerprint(h)
+				log.PanicIf(err)
+			} else if bt.value.IsOriginal() == true {
+				_, err := h.Write([]byte{fingerprintValueOriginal})
+				log.PanicIf(err)
+
+				offset, size := bt.value.Original()
+
+				writeUint32(offset)
+				writeUint32(size)
+			} else {
+				_, err := h.Write([]byte{fingerprintValueBytes})
+				log.PanicIf(err)
+
+				valueBytes, err := bt.EncodedBytes(thisIb.byteOrder)
+				log.PanicIf(err)
+
+				writeBytes(valueBytes)
+			}
+		}
+
+		if thisIb.nextIb != nil {
+			_, err := h.Write([]byte{fingerprintChainNext})
+			log.PanicIf(err)
+		} else {
+			_, err := h.Write([]byte{fingerprintChainEnd})
+			log.PanicIf(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getIbFingerprint(ib *IfdBuilder) string {
+	fingerprint, err := ib.Fingerprint()
+	log.PanicIf(err)
+
+	return fingerprint
+}
+
+func TestIfdBuilder_Fingerprint(t *testing.T) {
+	first := getIbFingerprint(getExifSimpleTestIb())
+	second := getIbFingerprint(getExifSimpleTestIb())
+
+	if first != second {
+		t.Fatalf("Fingerprints of identical IBs differ: [%s] != [%s]", first, second)
+	} else if len(first) != 64 {
+		t.Fatalf("Fingerprint not correct: [%s]", first)
+	}
+
+	ib := getExifSimpleTestIb()
+
+	err := ib.SetStandard(0x000b, "othervalue")
+	log.PanicIf(err)
+
+	if getIbFingerprint(ib) == first {
+		t.Fatalf("Fingerprint didn't change with a value.")
+	}
+
+	ib = getExifSimpleTestIb()
+
+	err = ib.SetByteOrder(binary.LittleEndian)
+	log.PanicIf(err)
+
+	if getIbFingerprint(ib) == first {
+		t.Fatalf("Fingerprint didn't change with the byte-order.")
+	}
+}
+
+func TestIfdBuilder_Fingerprint_ChildAndSibling(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	build := func(isoSpeed uint32, withSibling bool) *IfdBuilder {
+		rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+		err := rootIb.SetExifStandardWithName("ISOSpeed", []uint32{isoSpeed})
+		log.PanicIf(err)
+
+		if withSibling == true {
+			siblingIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+			err = rootIb.SetNextIb(siblingIb)
+			log.PanicIf(err)
+		}
+
+		return rootIb
+	}
+
+	fingerprint := getIbFingerprint(build(100, false))
+
+	if getIbFingerprint(build(100, false)) != fingerprint {
+		t.Fatalf("Fingerprints of identical IBs differ.")
+	} else if getIbFingerprint(build(200, false)) == fingerprint {
+		t.Fatalf("Fingerprint didn't change with a child value.")
+	} else if getIbFingerprint(build(100, true)) == fingerprint {
+		t.Fatalf("Fingerprint didn't change with a sibling.")
+	}
+}
+
+func TestIfdBuilder_Fingerprint_Copied(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, getExifSimpleTestIbBytes())
+	log.PanicIf(err)
+
+	first := getIbFingerprint(NewIfdBuilderFromExistingChain(index.RootIfd))
+	second := getIbFingerprint(NewIfdBuilderFromExistingChain(index.RootIfd))
+
+	if first != second {
+		t.Fatalf("Fingerprints of copies differ.")
+	}
+}
@@ -0,0 +1,120 @@
+package exif
+
+import (
+	"github.com/dsoprea/go-logging"
+)
+
+// BuilderTagIterator walks the tags of an `IfdBuilder` while they're being
+// changed. Unlike the positions in the list from `Tags()`, which shift when
+// tags are removed, the iterator follows the tags themselves. It visits the
+// tags that were present when the iteration started, in order, skipping any
+// that were removed (or replaced) before being reached. Tags added during the
+// iteration are not visited.
+type BuilderTagIterator struct {
+	ib *IfdBuilder
+
+	pending []*BuilderTag
+	current *BuilderTag
+}
+
+// Iterate returns an iterator over the tags of this IB. Call `Next()` before
+// reading the first tag.
+func (ib *IfdBuilder) Iterate() *BuilderTagIterator {
+	pending := make([]*BuilderTag, len(ib.tags))
+	copy(pending, ib.tags)
+
+	return &BuilderTagIterator{
+		ib:      ib,
+		pending: pending,
+	}
+}
+
+// Next advances to the next tag that is still in the IB. It returns false
+// once there are no more.
+func (bti *BuilderTagIterator) Next() bool {
+	for len(bti.pending) > 0 {
+		bt := bti.pending[0]
+		bti.pending = bti.pending[1:]
+
+		if bti.ib.positionOf(bt) != -1 {
+			bti.current = bt
+			return true
+		}
+	}
+
+	bti.current = nil
+	return false
+}
+
+// Tag returns the current tag.
+func (bti *BuilderTagIterator) Tag() *BuilderTag {
+	return bti.current
+}
+
+// Position returns the position of the current tag in the IB right now, or -1
+// if it has since been removed.
+func (bti *BuilderTagIterator) Position() int {
+	if bti.current == nil {
+		return -1
+	}
+
+	return bti.ib.positionOf(bti.current)
+}
+
+// Delete removes the current tag from the IB. The iteration continues with
+// the tag that followed it.
+func (bti *BuilderTagIterator) Delete() (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if bti.Position() == -1 {
+		log.Panic(ErrTagEntryNotFound)
+	}
+
+	current := bti.current
+
+	_, err = bti.ib.deleteMatching(func(bt *BuilderTag) bool {
+		return bt == current
+	}, 1)
+
+	log.PanicIf(err)
+
+	return nil
+}
+
+// Replace puts the given tag in place of the current one, which it then
+// becomes. The iteration continues with the tag that followed it.
+func (bti *BuilderTagIterator) Replace(bt *BuilderTag) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	position := bti.Position()
+	if position == -1 {
+		log.Panic(ErrTagEntryNotFound)
+	}
+
+	err = bti.ib.replaceAt(position, bt)
+	log.PanicIf(err)
+
+	bti.current = bt
+
+	return nil
+}
+
+// positionOf returns the current position of the given tag (by identity) or
+// -1 if it's not in this IB.
+func (ib *IfdBuilder) positionOf(bt *BuilderTag) int {
+	for _, position := range ib.positionsByTagId[bt.tagId] {
+		if ib.tags[position] == bt {
+			return position
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,73 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestBuilderTagIterator(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	visited := make([]uint16, 0)
+
+	bti := ib.Iterate()
+	for bti.Next() == true {
+		bt := bti.Tag()
+		visited = append(visited, bt.tagId)
+
+		switch bt.tagId {
+		case 0x000b:
+			if bti.Position() != 0 {
+				t.Fatalf("Position not correct: (%d)", bti.Position())
+			}
+
+			err := bti.Delete()
+			log.PanicIf(err)
+
+			if bti.Position() != -1 {
+				t.Fatalf("Deleted tag should have no position.")
+			}
+		case 0x00ff:
+			// Removing a tag that hasn't been reached yet and adding a new one
+			// must not upset the iteration.
+			err := ib.DeleteFirst(0x0100)
+			log.PanicIf(err)
+
+			err = ib.AddStandard(0x0131, "software")
+			log.PanicIf(err)
+
+			if bti.Position() != 0 {
+				t.Fatalf("Position not correct after deletion: (%d)", bti.Position())
+			}
+
+			replacement := NewBuilderTag(bt.ifdPath, bt.tagId, bt.typeId, NewIfdBuilderTagValueFromBytes([]byte{0, 1}), bt.byteOrder)
+
+			err = bti.Replace(replacement)
+			log.PanicIf(err)
+
+			if bti.Tag() != replacement || ib.tags[0] != replacement {
+				t.Fatalf("Tag not replaced.")
+			}
+		}
+	}
+
+	if reflect.DeepEqual(visited, []uint16{0x000b, 0x00ff, 0x013e}) != true {
+		t.Fatalf("Visited tags not correct: %v", visited)
+	}
+
+	tagIds := make([]uint16, len(ib.tags))
+	for i, bt := range ib.tags {
+		tagIds[i] = bt.tagId
+	}
+
+	if reflect.DeepEqual(tagIds, []uint16{0x00ff, 0x013e, 0x0131}) != true {
+		t.Fatalf("Remaining tags not correct: %v", tagIds)
+	}
+
+	err := bti.Delete()
+	if log.Is(err, ErrTagEntryNotFound) != true {
+		t.Fatalf("Expected not-found error after the end: %v", err)
+	}
+}
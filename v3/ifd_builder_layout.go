@@ -0,0 +1,224 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrPinnedValueOverlaps means that a pinned value would overlap the rest
+	// of the EXIF or another pinned value.
+	ErrPinnedValueOverlaps = errors.New("pinned value overlaps other data")
+)
+
+// IfdLayout describes where an IFD is written.
+type IfdLayout struct {
+	// FqIfdPath is the fully-qualified path of the IFD.
+	FqIfdPath string
+
+	// Offset is where the IFD's table starts, relative to the start of the
+	// TIFF header.
+	Offset uint32
+
+	// TableSize is the size of the table, including the tag-count and the
+	// next-IFD offset.
+	TableSize uint32
+
+	// DataSize is the size of the values allocated directly after the table.
+	DataSize uint32
+}
+
+// String returns a descriptive string.
+func (il IfdLayout) String() string {
+	return fmt.Sprintf("IfdLayout<FQ-IFD-PATH=[%s] OFFSET=(0x%08x) TABLE-SIZE=(%d) DATA-SIZE=(%d)>", il.FqIfdPath, il.Offset, il.TableSize, il.DataSize)
+}
+
+// ValueLayout describes where a value that is too large to be embedded in its
+// tag entry is written.
+type ValueLayout struct {
+	// Offset is relative to the start of the TIFF header.
+	Offset uint32
+
+	// Size is the size of the encoded value.
+	Size uint32
+}
+
+// pinnedValue is a value that is written at a fixed offset after everything
+// else.
+type pinnedValue struct {
+	key    ValueOffsetKey
+	offset uint32
+	data   []byte
+}
+
+// EncodeLayout is where everything will be written when an IB is encoded. It
+// is produced by `IfdByteEncoder.Plan()`, can be inspected and adjusted, and is
+// then written by `IfdByteEncoder.Emit()`.
+type EncodeLayout struct {
+	ib *IfdBuilder
+
+	// Ifds are the IFDs in the order that they are written.
+	Ifds []IfdLayout
+
+	// Values are the values that are not embedded in their tag entries. As
+	// with `IfdByteEncoder.ValueOffsets()`, if an IFD has more than one tag
+	// with the same ID, the last one wins.
+	Values map[ValueOffsetKey]ValueLayout
+
+	// Size is the size of the complete EXIF block, including the header.
+	Size uint32
+
+	pins map[ValueOffsetKey]uint32
+}
+
+// PinValue moves the value of the given tag to the given offset, relative to
+// the start of the TIFF header. It must be at or after the end of the rest of
+// the EXIF (`Size`); the gap is filled with zeros. This is for containers that
+// require data at a fixed location. `ErrTagNotFound` is returned if the tag
+// does not have a value outside of its entry.
+func (el *EncodeLayout) PinValue(fqIfdPath string, tagId uint16, offset uint32) (err error) {
+	key := ValueOffsetKey{
+		FqIfdPath: fqIfdPath,
+		TagId:     tagId,
+	}
+
+	if _, found := el.Values[key]; found == false {
+		return ErrTagNotFound
+	}
+
+	el.pins[key] = offset
+
+	return nil
+}
+
+// PinThumbnail moves the thumbnail to the given offset. See `PinValue()`.
+func (el *EncodeLayout) PinThumbnail(offset uint32) (err error) {
+	fqIfdPath := exifcommon.IfdStandardIfdIdentity.NewSibling(1).String()
+
+	err = el.PinValue(fqIfdPath, ThumbnailOffsetTagId, offset)
+	if err == ErrTagNotFound {
+		return ErrNoThumbnail
+	}
+
+	return err
+}
+
+// Pins returns the values that have been pinned and their offsets. The map is
+// a copy.
+func (el *EncodeLayout) Pins() map[ValueOffsetKey]uint32 {
+	pins := make(map[ValueOffsetKey]uint32, len(el.pins))
+	for key, offset := range el.pins {
+		pins[key] = offset
+	}
+
+	return pins
+}
+
+// String returns a descriptive string.
+func (el *EncodeLayout) String() string {
+	return fmt.Sprintf("EncodeLayout<IFDS=(%d) VALUES=(%d) SIZE=(%d) PINS=(%d)>", len(el.Ifds), len(el.Values), el.Size, len(el.pins))
+}
+
+// Plan determines the layout of the given IB without producing any output.
+// This is the first half of a two-phase encode: adjust the layout as required
+// and then pass it to `Emit()`. `EncodeToExif()` is equivalent to an `Emit()`
+// of an unadjusted plan. The IB must not be modified between the two.
+func (ibe *IfdByteEncoder) Plan(ib *IfdBuilder) (el *EncodeLayout, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	session := ibe.newSession()
+
+	data, err := session.encodeToExifPayload(ib)
+	log.PanicIf(err)
+
+	size, err := exifcommon.CheckedAddUint32(session.firstIfdOffset(), uint32(len(data)))
+	log.PanicIf(err)
+
+	ifds := session.ifdLayouts
+	sort.SliceStable(ifds, func(i, j int) bool {
+		return ifds[i].Offset < ifds[j].Offset
+	})
+
+	values := make(map[ValueOffsetKey]ValueLayout, len(session.valueOffsets))
+	for key, offset := range session.valueOffsets {
+		values[key] = ValueLayout{
+			Offset: offset,
+			Size:   session.valueSizes[key],
+		}
+	}
+
+	el = &EncodeLayout{
+		ib:     ib,
+		Ifds:   ifds,
+		Values: values,
+		Size:   size,
+		pins:   make(map[ValueOffsetKey]uint32),
+	}
+
+	return el, nil
+}
+
+// Emit encodes the IB of the given plan, honoring any adjustments made to it,
+// and returns a complete EXIF block. Since pinned values are taken out of
+// their IFDs' data areas, the offsets of everything written after them shift;
+// `ValueOffsets()` reports where they actually went.
+func (ibe *IfdByteEncoder) Emit(el *EncodeLayout) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	session := ibe.newSession()
+	session.pins = el.pins
+
+	data, err = session.encodeToExif(el.ib)
+	log.PanicIf(err)
+
+	ibe.storeSessionResults(session)
+
+	return data, nil
+}
+
+// appendPinnedValues writes the pinned values at their offsets after the
+// given payload.
+func (ibe *IfdByteEncoder) appendPinnedValues(payload []byte) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	sort.SliceStable(ibe.pinnedValues, func(i, j int) bool {
+		return ibe.pinnedValues[i].offset < ibe.pinnedValues[j].offset
+	})
+
+	end, err := exifcommon.CheckedAddUint32(ibe.firstIfdOffset(), uint32(len(payload)))
+	log.PanicIf(err)
+
+	for _, pv := range ibe.pinnedValues {
+		if pv.offset < end {
+			ifdBuilderLogger.Warningf(nil, "Pinned value %s at (0x%08x) starts before the end of the preceding data (0x%08x).", pv.key, pv.offset, end)
+			log.Panic(ErrPinnedValueOverlaps)
+		}
+
+		payload = append(payload, make([]byte, pv.offset-end)...)
+		payload = append(payload, pv.data...)
+
+		end, err = exifcommon.CheckedAddUint32(pv.offset, uint32(len(pv.data)))
+		log.PanicIf(err)
+
+		ibe.pushToJournal("appendPinnedValues", "-", "Pinned value %s written at (0x%08x).", pv.key, pv.offset)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,157 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getTestLayoutIb returns a builder for the test image, which has a
+// thumbnail.
+func getTestLayoutIb() *IfdBuilder {
+	rawExif := getTestExifData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	return NewIfdBuilderFromExistingChain(index.RootIfd)
+}
+
+func TestIfdByteEncoder_Plan(t *testing.T) {
+	ib := getTestLayoutIb()
+
+	ibe := NewIfdByteEncoder()
+
+	el, err := ibe.Plan(ib)
+	log.PanicIf(err)
+
+	expected, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	if el.Size != uint32(len(expected)) {
+		t.Fatalf("Planned size not correct: (%d) != (%d)", el.Size, len(expected))
+	} else if len(el.Ifds) != 5 {
+		t.Fatalf("IFD count not correct: %v", el.Ifds)
+	} else if el.Ifds[0].FqIfdPath != "IFD" || el.Ifds[0].Offset != ExifDefaultFirstIfdOffset {
+		t.Fatalf("First IFD not correct: %s", el.Ifds[0])
+	}
+
+	for i := 1; i < len(el.Ifds); i++ {
+		previous := el.Ifds[i-1]
+		if el.Ifds[i].Offset < previous.Offset+previous.TableSize+previous.DataSize {
+			t.Fatalf("IFD overlaps the previous one: %s %s", previous, el.Ifds[i])
+		}
+	}
+
+	valueOffsets := ibe.ValueOffsets()
+	if len(el.Values) != len(valueOffsets) {
+		t.Fatalf("Value count not correct: (%d) != (%d)", len(el.Values), len(valueOffsets))
+	}
+
+	for key, vl := range el.Values {
+		if vl.Offset != valueOffsets[key] {
+			t.Fatalf("Planned offset for %s not correct: (%d) != (%d)", key, vl.Offset, valueOffsets[key])
+		} else if vl.Offset+vl.Size > el.Size {
+			t.Fatalf("Value %s extends past the end.", key)
+		}
+	}
+
+	actual, err := ibe.Emit(el)
+	log.PanicIf(err)
+
+	if bytes.Equal(actual, expected) != true {
+		t.Fatalf("Emitted EXIF does not match an ordinary encode.")
+	}
+}
+
+func TestEncodeLayout_PinThumbnail(t *testing.T) {
+	ib := getTestLayoutIb()
+
+	ibe := NewIfdByteEncoder()
+
+	el, err := ibe.Plan(ib)
+	log.PanicIf(err)
+
+	pinnedOffset := (el.Size + 0x1000) &^ 0xfff
+
+	err = el.PinThumbnail(pinnedOffset)
+	log.PanicIf(err)
+
+	exifData, err := ibe.Emit(el)
+	log.PanicIf(err)
+
+	offset, err := ibe.ValueOffset("IFD1", ThumbnailOffsetTagId)
+	log.PanicIf(err)
+
+	if offset != pinnedOffset {
+		t.Fatalf("Thumbnail not written at the pinned offset: (0x%08x) != (0x%08x)", offset, pinnedOffset)
+	}
+
+	thumbnailSize := el.Values[ValueOffsetKey{FqIfdPath: "IFD1", TagId: ThumbnailOffsetTagId}].Size
+	if uint32(len(exifData)) != pinnedOffset+thumbnailSize {
+		t.Fatalf("EXIF size not correct: (%d)", len(exifData))
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	actual, err := index.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	expected, err := ib.nextIb.FindTag(ThumbnailOffsetTagId)
+	log.PanicIf(err)
+
+	if bytes.Equal(actual, expected.value.Bytes()) != true {
+		t.Fatalf("Thumbnail not correct.")
+	}
+}
+
+func TestEncodeLayout_PinValue_Overlaps(t *testing.T) {
+	ib := getTestLayoutIb()
+
+	ibe := NewIfdByteEncoder()
+
+	el, err := ibe.Plan(ib)
+	log.PanicIf(err)
+
+	err = el.PinThumbnail(ExifDefaultFirstIfdOffset)
+	log.PanicIf(err)
+
+	_, err = ibe.Emit(el)
+	if err == nil {
+		t.Fatalf("Expected an error.")
+	} else if log.Is(err, ErrPinnedValueOverlaps) == false {
+		log.Panic(err)
+	}
+}
+
+func TestEncodeLayout_PinValue_NotFound(t *testing.T) {
+	ib := getTestLayoutIb()
+
+	ibe := NewIfdByteEncoder()
+
+	el, err := ibe.Plan(ib)
+	log.PanicIf(err)
+
+	// Orientation is embedded in its entry.
+	err = el.PinValue("IFD", 0x0112, 0x10000)
+	if err != ErrTagNotFound {
+		t.Fatalf("Expected ErrTagNotFound: %v", err)
+	}
+}
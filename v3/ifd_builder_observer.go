@@ -0,0 +1,161 @@
+package exif
+
+import (
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// MutationType describes the kind of change being made to an `IfdBuilder`.
+type MutationType int
+
+const (
+	// MutationAdd indicates that a tag is being added.
+	MutationAdd MutationType = iota
+
+	// MutationReplace indicates that an existing tag is being replaced.
+	MutationReplace
+
+	// MutationDelete indicates that an existing tag is being removed.
+	MutationDelete
+)
+
+// String returns a descriptive string.
+func (mt MutationType) String() string {
+	switch mt {
+	case MutationAdd:
+		return "ADD"
+	case MutationReplace:
+		return "REPLACE"
+	case MutationDelete:
+		return "DELETE"
+	}
+
+	return fmt.Sprintf("MutationType<%d>", int(mt))
+}
+
+// MutationObserver is notified of every change to the tags of an `IfdBuilder`
+// before the change is applied. `before` is nil for additions and `after` is
+// nil for deletions. If an error is returned, the mutation is abandoned and
+// the error is returned to the caller of the mutating method. This allows an
+// audit-trail to be guaranteed to be complete.
+type MutationObserver interface {
+	ObserveMutation(ib *IfdBuilder, mutationType MutationType, before, after *BuilderTag) error
+}
+
+// SetMutationObserver installs an observer on this IB. Any IBs that are
+// implicitly created from this one (e.g. by `GetOrCreateIbFromRootIb`) will
+// inherit it. Pass nil to remove it.
+func (ib *IfdBuilder) SetMutationObserver(mo MutationObserver) {
+	ib.mutationObserver = mo
+}
+
+// MutationObserver returns the installed observer or nil.
+func (ib *IfdBuilder) MutationObserver() MutationObserver {
+	return ib.mutationObserver
+}
+
+// notifyMutation forwards a change to the observer, if one is installed.
+func (ib *IfdBuilder) notifyMutation(mutationType MutationType, before, after *BuilderTag) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.mutationObserver == nil {
+		return nil
+	}
+
+	err = ib.mutationObserver.ObserveMutation(ib, mutationType, before, after)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// replaceAt replaces the tag at the given position after notifying the
+// observer.
+func (ib *IfdBuilder) replaceAt(position int, bt *BuilderTag) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ib.notifyMutation(MutationReplace, ib.tags[position], bt)
+	log.PanicIf(err)
+
+	ib.unindexTag(ib.tags[position].tagId, position)
+	ib.indexTag(bt.tagId, position)
+
+	ib.tags[position] = bt
+
+	return nil
+}
+
+// deleteMatching removes up to `max` tags that the given function matches, or
+// all of them if `max` is less than one, in a single pass. Each removal is
+// notified to the observer first. If the observer rejects one, the tags that
+// were already removed stay removed and everything else is kept in order.
+func (ib *IfdBuilder) deleteMatching(matches func(bt *BuilderTag) bool, max int) (n int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// Compact in place. If we have to abort, the remaining tags are moved
+	// down so that the list is never left with gaps.
+	kept := 0
+
+	truncate := func() {
+		for i := kept; i < len(ib.tags); i++ {
+			ib.tags[i] = nil
+		}
+
+		ib.tags = ib.tags[:kept]
+
+		if n > 0 {
+			ib.reindexTags()
+		}
+	}
+
+	for i, bt := range ib.tags {
+		if (max < 1 || n < max) && matches(bt) == true {
+			err := ib.notifyMutation(MutationDelete, bt, nil)
+			if err != nil {
+				kept += copy(ib.tags[kept:], ib.tags[i:])
+				truncate()
+
+				log.Panic(err)
+			}
+
+			n++
+			continue
+		}
+
+		ib.tags[kept] = bt
+		kept++
+	}
+
+	truncate()
+
+	return n, nil
+}
+
+// appendTag adds the tag to the end of the list after notifying the observer.
+func (ib *IfdBuilder) appendTag(bt *BuilderTag) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ib.notifyMutation(MutationAdd, nil, bt)
+	log.PanicIf(err)
+
+	ib.tags = append(ib.tags, bt)
+	ib.indexTag(bt.tagId, len(ib.tags)-1)
+
+	return nil
+}
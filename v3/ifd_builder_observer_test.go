@@ -0,0 +1,164 @@
+package exif
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+type recordingMutationObserver struct {
+	events []string
+	err    error
+}
+
+func (rmo *recordingMutationObserver) ObserveMutation(ib *IfdBuilder, mutationType MutationType, before, after *BuilderTag) error {
+	if rmo.err != nil {
+		return rmo.err
+	}
+
+	beforeTagId := -1
+	if before != nil {
+		beforeTagId = int(before.tagId)
+	}
+
+	afterTagId := -1
+	if after != nil {
+		afterTagId = int(after.tagId)
+	}
+
+	rmo.events = append(rmo.events, mutationType.String())
+
+	if mutationType == MutationAdd && (before != nil || afterTagId == -1) {
+		rmo.events = append(rmo.events, "BAD-ADD")
+	} else if mutationType == MutationDelete && (after != nil || beforeTagId == -1) {
+		rmo.events = append(rmo.events, "BAD-DELETE")
+	} else if mutationType == MutationReplace && (beforeTagId != afterTagId) {
+		rmo.events = append(rmo.events, "BAD-REPLACE")
+	}
+
+	return nil
+}
+
+func TestIfdBuilder_SetMutationObserver(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	rmo := new(recordingMutationObserver)
+	rootIb.SetMutationObserver(rmo)
+
+	err = rootIb.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithName("Artist", "Another Person")
+	log.PanicIf(err)
+
+	_, err = rootIb.RedactWithName("Artist")
+	log.PanicIf(err)
+
+	err = rootIb.DeleteFirst(0x013b)
+	log.PanicIf(err)
+
+	// Implicitly-created children inherit the observer.
+
+	exifIb, err := GetOrCreateIbFromRootIb(rootIb, "IFD/Exif")
+	log.PanicIf(err)
+
+	err = exifIb.AddStandardWithName("BodySerialNumber", "12345")
+	log.PanicIf(err)
+
+	expected := []string{
+		"ADD",
+		"REPLACE",
+		"REPLACE",
+		"DELETE",
+
+		// The child-IFD tag in the root.
+		"ADD",
+
+		// The tag in the child.
+		"ADD",
+	}
+
+	if reflect.DeepEqual(rmo.events, expected) != true {
+		t.Fatalf("Mutation events not correct: %v", rmo.events)
+	}
+}
+
+func TestIfdBuilder_SetMutationObserver__Veto(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	vetoErr := errors.New("audit log unavailable")
+
+	rmo := &recordingMutationObserver{
+		err: vetoErr,
+	}
+
+	ib.SetMutationObserver(rmo)
+
+	err = ib.DeleteFirst(0x013b)
+	if err == nil {
+		t.Fatalf("Expected the observer to veto the mutation.")
+	} else if log.Is(err, vetoErr) == false {
+		log.Panic(err)
+	}
+
+	if len(ib.Tags()) != 1 {
+		t.Fatalf("Vetoed mutation was applied.")
+	}
+}
+
+func TestIfdBuilder_SetMutationObserver__VetoDeleteAll(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Make", "Some Make")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Artist", "Some Other Person")
+	log.PanicIf(err)
+
+	vetoErr := errors.New("audit log unavailable")
+
+	rmo := &recordingMutationObserver{
+		err: vetoErr,
+	}
+
+	ib.SetMutationObserver(rmo)
+
+	_, err = ib.DeleteAll(0x013b)
+	if err == nil {
+		t.Fatalf("Expected the observer to veto the mutation.")
+	} else if log.Is(err, vetoErr) == false {
+		log.Panic(err)
+	}
+
+	tags := ib.Tags()
+	if len(tags) != 3 {
+		t.Fatalf("Vetoed mutation was applied.")
+	} else if tags[0].tagId != 0x013b || tags[1].tagId != 0x010f || tags[2].tagId != 0x013b {
+		t.Fatalf("Tags were reordered.")
+	}
+}
@@ -0,0 +1,301 @@
+package exif
+
+import (
+	"math/rand"
+	"sort"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// RandomIbGenerator fabricates random, valid IFD trees. Every tag is a tag
+// known to the tag-index, has one of its supported types, and has a random
+// value of a random unit-count. The output is completely determined by the
+// seed, so any failure can be reproduced. This is used by our own
+// encode/decode inverse tests and is exported for downstream fuzzing.
+type RandomIbGenerator struct {
+	rand *rand.Rand
+
+	ifdMapping *exifcommon.IfdMapping
+	tagIndex   *TagIndex
+	byteOrder  binary.ByteOrder
+
+	// MaxTagsPerIfd is the maximum number of (non-IFD) tags in each IFD.
+	MaxTagsPerIfd int
+
+	// MaxUnitCount is the maximum unit-count of non-ASCII values.
+	MaxUnitCount int
+
+	// MaxStringLength is the maximum length of ASCII values (excluding the
+	// NUL).
+	MaxStringLength int
+
+	// ChildProbability is the probability that each of the possible child
+	// IFDs of an IFD will be created.
+	ChildProbability float64
+
+	// SiblingProbability is the probability that a second root IFD (IFD1)
+	// will be created.
+	SiblingProbability float64
+}
+
+// NewRandomIbGenerator returns a new RandomIbGenerator with reasonable
+// defaults.
+func NewRandomIbGenerator(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, byteOrder binary.ByteOrder, seed int64) *RandomIbGenerator {
+	return &RandomIbGenerator{
+		rand: rand.New(rand.NewSource(seed)),
+
+		ifdMapping: ifdMapping,
+		tagIndex:   tagIndex,
+		byteOrder:  byteOrder,
+
+		MaxTagsPerIfd:      20,
+		MaxUnitCount:       8,
+		MaxStringLength:    32,
+		ChildProbability:   0.5,
+		SiblingProbability: 0.5,
+	}
+}
+
+// Generate returns the root of a new random IFD tree.
+func (rig *RandomIbGenerator) Generate() (rootIb *IfdBuilder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rootIb, err = rig.generateIb(exifcommon.IfdStandardIfdIdentity)
+	log.PanicIf(err)
+
+	if rig.rand.Float64() < rig.SiblingProbability {
+		siblingIb, err := rig.generateIb(exifcommon.IfdStandardIfdIdentity.NewSibling(1))
+		log.PanicIf(err)
+
+		err = rootIb.SetNextIb(siblingIb)
+		log.PanicIf(err)
+	}
+
+	return rootIb, nil
+}
+
+// generateIb populates one IB and, recursively, its children.
+func (rig *RandomIbGenerator) generateIb(ii *exifcommon.IfdIdentity) (ib *IfdBuilder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	ib = NewIfdBuilder(rig.ifdMapping, rig.tagIndex, ii, rig.byteOrder)
+
+	candidates, err := rig.candidateTags(ii)
+	log.PanicIf(err)
+
+	tagCount := rig.rand.Intn(rig.MaxTagsPerIfd + 1)
+	if tagCount > len(candidates) {
+		tagCount = len(candidates)
+	}
+
+	// Choose a random subset of the tags without repetition.
+	for _, i := range rig.rand.Perm(len(candidates))[:tagCount] {
+		it := candidates[i]
+		tagType := it.SupportedTypes[rig.rand.Intn(len(it.SupportedTypes))]
+
+		value := rig.randomValue(tagType)
+
+		ve := exifcommon.NewValueEncoder(rig.byteOrder)
+
+		ed, err := ve.Encode(value)
+		log.PanicIf(err)
+
+		bt := NewBuilderTag(
+			ii.UnindexedString(),
+			it.Id,
+			tagType,
+			NewIfdBuilderTagValueFromBytes(ed.Encoded),
+			rig.byteOrder)
+
+		err = ib.Add(bt)
+		log.PanicIf(err)
+	}
+
+	// Only the first root IFD gets children. IFD1 is customarily just the
+	// thumbnail IFD.
+	if ii.Index() != 0 {
+		return ib, nil
+	}
+
+	mi, err := rig.ifdMapping.GetWithPath(ii.UnindexedString())
+	log.PanicIf(err)
+
+	childTagIds := make([]int, 0, len(mi.Children))
+	for childTagId := range mi.Children {
+		childTagIds = append(childTagIds, int(childTagId))
+	}
+
+	sort.Ints(childTagIds)
+
+	currentIfdTag := ii.IfdTag()
+
+	for _, childTagId := range childTagIds {
+		if rig.rand.Float64() >= rig.ChildProbability {
+			continue
+		}
+
+		childMi := mi.Children[uint16(childTagId)]
+
+		childIfdTag := exifcommon.NewIfdTag(&currentIfdTag, childMi.TagId, childMi.Name)
+		iiChild := ii.NewChild(childIfdTag, 0)
+
+		childIb, err := rig.generateIb(iiChild)
+		log.PanicIf(err)
+
+		err = ib.AddChildIb(childIb)
+		log.PanicIf(err)
+	}
+
+	return ib, nil
+}
+
+// candidateTags returns the known tags for the IFD that we can generate values
+// for, in a stable order.
+func (rig *RandomIbGenerator) candidateTags(ii *exifcommon.IfdIdentity) (candidates []*IndexedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// Make sure that the index has been loaded.
+	_, err = rig.tagIndex.getOne(ii.UnindexedString(), 0)
+	if err != nil && err != ErrTagNotFound {
+		log.Panic(err)
+	}
+
+	ifdPath := ii.UnindexedString()
+
+	rig.tagIndex.mutex.Lock()
+	family := rig.tagIndex.tagsByIfd[ifdPath]
+
+	candidates = make([]*IndexedTag, 0, len(family))
+	for _, it := range family {
+		candidates = append(candidates, it)
+	}
+
+	rig.tagIndex.mutex.Unlock()
+
+	filtered := candidates[:0]
+	for _, it := range candidates {
+		// Child-IFD pointers are created structurally.
+		_, err := rig.ifdMapping.GetChild(ifdPath, it.Id)
+		if err == nil {
+			continue
+		} else if log.Is(err, exifcommon.ErrChildIfdNotMapped) == false {
+			log.Panic(err)
+		}
+
+		// The thumbnail tags are managed by `SetThumbnail()`.
+		if it.Id == ThumbnailOffsetTagId || it.Id == ThumbnailSizeTagId {
+			continue
+		}
+
+		// Undefined-type values require tag-specific encoders.
+		if it.DoesSupportType(exifcommon.TypeUndefined) == true {
+			continue
+		}
+
+		filtered = append(filtered, it)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Id < filtered[j].Id
+	})
+
+	return filtered, nil
+}
+
+// randomValue returns a random value of the given type in the form accepted by
+// `ValueEncoder`.
+func (rig *RandomIbGenerator) randomValue(tagType exifcommon.TagTypePrimitive) interface{} {
+	unitCount := rig.rand.Intn(rig.MaxUnitCount) + 1
+
+	switch tagType {
+	case exifcommon.TypeByte:
+		value := make([]byte, unitCount)
+		rig.rand.Read(value)
+
+		return value
+	case exifcommon.TypeAscii:
+		length := rig.rand.Intn(rig.MaxStringLength + 1)
+
+		// Printable characters only, excluding NUL.
+		value := make([]byte, length)
+		for i := range value {
+			value[i] = byte(0x20 + rig.rand.Intn(0x7f-0x20))
+		}
+
+		return string(value)
+	case exifcommon.TypeShort:
+		value := make([]uint16, unitCount)
+		for i := range value {
+			value[i] = uint16(rig.rand.Uint32())
+		}
+
+		return value
+	case exifcommon.TypeLong:
+		value := make([]uint32, unitCount)
+		for i := range value {
+			value[i] = rig.rand.Uint32()
+		}
+
+		return value
+	case exifcommon.TypeSignedLong:
+		value := make([]int32, unitCount)
+		for i := range value {
+			value[i] = int32(rig.rand.Uint32())
+		}
+
+		return value
+	case exifcommon.TypeRational:
+		value := make([]exifcommon.Rational, unitCount)
+		for i := range value {
+			value[i] = exifcommon.Rational{
+				Numerator:   rig.rand.Uint32(),
+				Denominator: rig.rand.Uint32() | 1,
+			}
+		}
+
+		return value
+	case exifcommon.TypeSignedRational:
+		value := make([]exifcommon.SignedRational, unitCount)
+		for i := range value {
+			value[i] = exifcommon.SignedRational{
+				Numerator:   int32(rig.rand.Uint32()),
+				Denominator: int32(rig.rand.Uint32()) | 1,
+			}
+		}
+
+		return value
+	case exifcommon.TypeFloat:
+		value := make([]float32, unitCount)
+		for i := range value {
+			value[i] = rig.rand.Float32()
+		}
+
+		return value
+	case exifcommon.TypeDouble:
+		value := make([]float64, unitCount)
+		for i := range value {
+			value[i] = rig.rand.Float64()
+		}
+
+		return value
+	}
+
+	log.Panicf("can not generate random value for type [%s]", tagType)
+	return nil
+}
@@ -0,0 +1,86 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestRandomIbGenerator_Generate__Deterministic(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rig1 := NewRandomIbGenerator(im, ti, binary.BigEndian, 42)
+
+	ib1, err := rig1.Generate()
+	log.PanicIf(err)
+
+	rig2 := NewRandomIbGenerator(im, ti, binary.BigEndian, 42)
+
+	ib2, err := rig2.Generate()
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData1, err := ibe.EncodeToExif(ib1)
+	log.PanicIf(err)
+
+	exifData2, err := ibe.EncodeToExif(ib2)
+	log.PanicIf(err)
+
+	if bytes.Equal(exifData1, exifData2) != true {
+		t.Fatalf("Same seed did not produce the same tree.")
+	}
+}
+
+func TestRandomIbGenerator__EncodeParseInverse(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	byteOrders := []binary.ByteOrder{
+		binary.BigEndian,
+		binary.LittleEndian,
+	}
+
+	for seed := int64(0); seed < 50; seed++ {
+		byteOrder := byteOrders[seed%2]
+
+		rig := NewRandomIbGenerator(im, ti, byteOrder, seed)
+
+		ib, err := rig.Generate()
+		log.PanicIf(err)
+
+		ibe := NewIfdByteEncoder()
+
+		exifData, err := ibe.EncodeToExif(ib)
+		log.PanicIf(err)
+
+		_, index, err := Collect(im, ti, exifData)
+		log.PanicIf(err)
+
+		expected, err := ibe.flattenIb(ib, nil)
+		log.PanicIf(err)
+
+		actual, err := flattenIfd(index.RootIfd, nil)
+		log.PanicIf(err)
+
+		if len(actual) != len(expected) {
+			t.Fatalf("Seed (%d): tag count not correct: (%d) != (%d)", seed, len(actual), len(expected))
+		}
+
+		for i, line := range expected {
+			if actual[i] != line {
+				t.Fatalf("Seed (%d): tag (%d) not correct:\nACTUAL: %s\nEXPECTED: %s", seed, i, actual[i], line)
+			}
+		}
+	}
+}
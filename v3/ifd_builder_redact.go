@@ -0,0 +1,172 @@
+package exif
+
+// NOTES:
+//
+// Redaction replaces a value with a "tombstone" having the same type and the
+// same unit-count as the original. Since the encoded size of the value doesn't
+// change, the layout of the IFD (and the offsets of everything that follows it)
+// is preserved when the IB is re-encoded.
+
+import (
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// RedactedAsciiPlaceholder is the character that ASCII values are
+	// overwritten with when redacted. The trailing NUL is preserved.
+	RedactedAsciiPlaceholder = byte('X')
+)
+
+// NewRedactedBuilderTag returns a copy of the given tag whose value has been
+// replaced with a placeholder of identical type and unit-count. ASCII values
+// are filled with `RedactedAsciiPlaceholder`, rationals become (0/1), and
+// everything else is zeroed.
+func NewRedactedBuilderTag(bt *BuilderTag) (redactedBt *BuilderTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if bt.value.IsIb() == true {
+		log.Panicf("child-IFD tags can not be redacted: %s", bt)
+	}
+
+	if bt.value.IsValue() == true {
+		// Native values don't have a byte-order yet. Redact them in an
+		// arbitrary one and convert back so that the tombstone is native, too.
+		byteOrder := binary.BigEndian
+
+		valueBytes, err := bt.EncodedBytes(byteOrder)
+		log.PanicIf(err)
+
+		placeholder, err := redactedValueBytes(bt.typeId, valueBytes, byteOrder)
+		log.PanicIf(err)
+
+		var redactedValue *IfdBuilderTagValue
+		if bt.typeId == exifcommon.TypeUndefined {
+			redactedValue = NewIfdBuilderTagValueFromBytes(placeholder)
+		} else {
+			value, err := decodeBuilderTagValueBytes(bt.typeId, placeholder, byteOrder)
+			log.PanicIf(err)
+
+			redactedValue = NewIfdBuilderTagValueFromValue(value)
+		}
+
+		redactedBt = NewBuilderTag(
+			bt.ifdPath,
+			bt.tagId,
+			bt.typeId,
+			redactedValue,
+			bt.byteOrder)
+
+		return redactedBt, nil
+	}
+
+	valueBytes := bt.value.Bytes()
+
+	placeholder, err := redactedValueBytes(bt.typeId, valueBytes, bt.byteOrder)
+	log.PanicIf(err)
+
+	redactedBt = NewBuilderTag(
+		bt.ifdPath,
+		bt.tagId,
+		bt.typeId,
+		NewIfdBuilderTagValueFromBytes(placeholder),
+		bt.byteOrder)
+
+	return redactedBt, nil
+}
+
+// redactedValueBytes produces the tombstone bytes for a value of the given
+// type. The result is always the same length as the original.
+func redactedValueBytes(tagType exifcommon.TagTypePrimitive, valueBytes []byte, byteOrder binary.ByteOrder) (placeholder []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	placeholder = make([]byte, len(valueBytes))
+
+	switch tagType {
+	case exifcommon.TypeAscii, exifcommon.TypeAsciiNoNul:
+		for i, c := range valueBytes {
+			// Keep the terminator (and any padding after it) intact.
+			if c == 0 {
+				break
+			}
+
+			placeholder[i] = RedactedAsciiPlaceholder
+		}
+
+	case exifcommon.TypeRational, exifcommon.TypeSignedRational:
+		if len(valueBytes)%8 != 0 {
+			log.Panicf("rational value of (%d) bytes is not a multiple of eight", len(valueBytes))
+		}
+
+		// A zero denominator is not a valid rational. Use (0/1).
+		for i := 0; i < len(valueBytes); i += 8 {
+			byteOrder.PutUint32(placeholder[i+4:i+8], 1)
+		}
+
+	default:
+		// BYTE, UNDEFINED, SHORT, LONG, SLONG, FLOAT, and DOUBLE are all zero
+		// when all of their bytes are zero.
+	}
+
+	return placeholder, nil
+}
+
+// Redact replaces the value of every occurrence of the given tag in this IFD
+// with a placeholder of the same type and size. The number of tags redacted is
+// returned. `ErrTagEntryNotFound` is returned if there were no occurrences.
+func (ib *IfdBuilder) Redact(tagId uint16) (n int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for i, bt := range ib.tags {
+		if bt.tagId != tagId {
+			continue
+		}
+
+		redactedBt, err := NewRedactedBuilderTag(bt)
+		log.PanicIf(err)
+
+		err = ib.replaceAt(i, redactedBt)
+		log.PanicIf(err)
+
+		n++
+	}
+
+	if n == 0 {
+		log.Panic(ErrTagEntryNotFound)
+	}
+
+	return n, nil
+}
+
+// RedactWithName is a convenience wrapper for `Redact` that resolves the tag
+// by name.
+func (ib *IfdBuilder) RedactWithName(tagName string) (n int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	it, err := ib.tagIndex.GetWithName(ib.IfdIdentity(), tagName)
+	log.PanicIf(err)
+
+	n, err = ib.Redact(it.Id)
+	log.PanicIf(err)
+
+	return n, nil
+}
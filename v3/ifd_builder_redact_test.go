@@ -0,0 +1,106 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestIfdBuilder_Redact(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("XResolution", []exifcommon.Rational{{Numerator: 72, Denominator: 1}})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Orientation", []uint16{6})
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	originalExif, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	n, err := ib.RedactWithName("Artist")
+	log.PanicIf(err)
+
+	if n != 1 {
+		t.Fatalf("Redaction count not correct: (%d)", n)
+	}
+
+	_, err = ib.RedactWithName("XResolution")
+	log.PanicIf(err)
+
+	_, err = ib.RedactWithName("Orientation")
+	log.PanicIf(err)
+
+	redactedExif, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	if len(redactedExif) != len(originalExif) {
+		t.Fatalf("Redacted EXIF is not the same size as the original: (%d) != (%d)", len(redactedExif), len(originalExif))
+	}
+
+	_, index, err := Collect(im, ti, redactedExif)
+	log.PanicIf(err)
+
+	expected := map[uint16]interface{}{
+		0x013b: "XXXXXXXXXXX",
+		0x011a: []exifcommon.Rational{{Numerator: 0, Denominator: 1}},
+		0x0112: []uint16{0},
+	}
+
+	for _, ite := range index.RootIfd.Entries() {
+		value, err := ite.Value()
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, expected[ite.TagId()]) != true {
+			t.Fatalf("Redacted value for tag (0x%04x) not correct: %v", ite.TagId(), value)
+		}
+	}
+}
+
+func TestIfdBuilder_Redact__Miss(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	_, err = ib.Redact(0x013b)
+	if err == nil {
+		t.Fatalf("Expected error for missing tag.")
+	} else if log.Is(err, ErrTagEntryNotFound) == false {
+		log.Panic(err)
+	}
+}
+
+func TestIfdBuilder_Redact__ChildIfd(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	_, err = rootIb.Redact(exifcommon.IfdExifStandardIfdIdentity.TagId())
+	if err == nil {
+		t.Fatalf("Expected error for child-IFD tag.")
+	}
+}
@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
@@ -9,6 +12,8 @@ import (
 	"testing"
 	"time"
 
+	"encoding/binary"
+
 	"github.com/dsoprea/go-exif/v3/common"
 	"github.com/dsoprea/go-exif/v3/undefined"
 	"github.com/dsoprea/go-logging"
@@ -104,6 +109,90 @@ func TestIfdBuilder_Add(t *testing.T) {
 	}
 }
 
+func TestIfdBuilder_SetStandardWithName__Coercion(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.SetStandardWithName("XResolution", 300)
+	log.PanicIf(err)
+
+	err = ib.SetStandardWithName("YResolution", 72.5)
+	log.PanicIf(err)
+
+	// ImageWidth supports both SHORT and LONG and is written as LONG.
+	err = ib.SetStandardWithName("ImageWidth", uint16(640))
+	log.PanicIf(err)
+
+	expected := map[string]interface{}{
+		"XResolution": []exifcommon.Rational{{Numerator: 300, Denominator: 1}},
+		"YResolution": []exifcommon.Rational{{Numerator: 145, Denominator: 2}},
+		"ImageWidth":  []uint32{640},
+	}
+
+	for tagName, expectedValue := range expected {
+		bt, err := ib.FindTagWithName(tagName)
+		log.PanicIf(err)
+
+		value, err := decodeBuilderTagValueBytes(bt.typeId, bt.value.Bytes(), ib.byteOrder)
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(value, expectedValue) != true {
+			t.Fatalf("Value for [%s] not correct: %v", tagName, value)
+		}
+	}
+
+	err = ib.SetStandardWithName("Orientation", 70000)
+	if log.Is(err, exifcommon.ErrNotCoercible) != true {
+		t.Fatalf("Expected not-coercible error: %v", err)
+	}
+
+	ib.SetForbidLossyCoercion(true)
+
+	err = ib.SetStandardWithName("Orientation", 1.5)
+	if log.Is(err, exifcommon.ErrLossyCoercion) != true {
+		t.Fatalf("Expected lossy error: %v", err)
+	}
+
+	// Child IBs inherit the option.
+	err = ib.SetExifStandardWithName("ExposureTime", 0.123456789123)
+	if log.Is(err, exifcommon.ErrLossyCoercion) != true {
+		t.Fatalf("Expected lossy error from child IB: %v", err)
+	}
+}
+
+func TestIfdBuilder_Tags__Copies(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	tags := ib.Tags()
+
+	err := tags[0].SetValue(ib.byteOrder, "changed")
+	log.PanicIf(err)
+
+	tags[0].Value().Bytes()[0] = 'X'
+	tags[1] = tags[2]
+
+	if bytes.Equal(ib.tags[0].value.Bytes(), []byte("asciivalue\x00")) != true {
+		t.Fatalf("Changing a copy changed the IB: %s", ib.tags[0])
+	} else if ib.tags[1].tagId != 0x00ff {
+		t.Fatalf("Changing the list changed the IB.")
+	}
+
+	tags = ib.Tags()
+	tags[0].Value().Bytes()[0] = 'X'
+
+	if ib.tags[0].value.Bytes()[0] != 'a' {
+		t.Fatalf("Changing the bytes of a copy changed the IB.")
+	}
+
+	unsafeTags := ib.TagsUnsafe()
+	if len(unsafeTags) != 4 || unsafeTags[0] != ib.tags[0] {
+		t.Fatalf("Unsafe tags should be the IB's own.")
+	}
+}
+
 func TestIfdBuilder_SetNextIb(t *testing.T) {
 	im, err := exifcommon.NewIfdMappingWithStandard()
 	log.PanicIf(err)
@@ -745,6 +834,93 @@ func TestIfdBuilder_Find__Miss(t *testing.T) {
 	}
 }
 
+func TestIfdBuilder_FindN__InSyncAfterMutations(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	newTag := func(tagId uint16) *BuilderTag {
+		return &BuilderTag{
+			ifdPath: exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+			typeId:  exifcommon.TypeByte,
+			tagId:   tagId,
+			value:   NewIfdBuilderTagValueFromBytes([]byte("test string")),
+		}
+	}
+
+	for _, tagId := range []uint16{0x11, 0x22, 0x33, 0x22, 0x44, 0x11, 0x22} {
+		err = ib.Add(newTag(tagId))
+		log.PanicIf(err)
+	}
+
+	// Compare against a scan after every change.
+	check := func() {
+		for _, tagId := range []uint16{0x11, 0x22, 0x33, 0x44, 0x55} {
+			expected := make([]int, 0)
+			for i, bt := range ib.Tags() {
+				if bt.tagId == tagId {
+					expected = append(expected, i)
+				}
+			}
+
+			found, err := ib.FindN(tagId, 0)
+			log.PanicIf(err)
+
+			if reflect.DeepEqual(found, expected) == false {
+				t.Fatalf("Positions for (0x%04x) not correct: %v != %v", tagId, found, expected)
+			}
+		}
+	}
+
+	check()
+
+	err = ib.DeleteFirst(0x22)
+	log.PanicIf(err)
+
+	check()
+
+	err = ib.ReplaceAt(0, newTag(0x55))
+	log.PanicIf(err)
+
+	check()
+
+	err = ib.Replace(0x33, newTag(0x22))
+	log.PanicIf(err)
+
+	check()
+
+	_, err = ib.DeleteMany(0x11, 0x44)
+	log.PanicIf(err)
+
+	check()
+
+	err = ib.Add(newTag(0x11))
+	log.PanicIf(err)
+
+	check()
+
+	found, err := ib.FindN(0x22, 2)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(found, []int{1, 2}) == false {
+		t.Fatalf("Limited positions not correct: %v", found)
+	}
+}
+
+func BenchmarkIfdBuilder_Find(b *testing.B) {
+	ib := newLargeIfdBuilder(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := ib.Find(uint16(0x1000 + 9999))
+		log.PanicIf(err)
+	}
+}
+
 func TestIfdBuilder_Replace(t *testing.T) {
 	im, err := exifcommon.NewIfdMappingWithStandard()
 	log.PanicIf(err)
@@ -1247,6 +1423,158 @@ func TestIfdBuilder_DeleteAll(t *testing.T) {
 	}
 }
 
+func TestIfdBuilder_DeleteMany(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	for _, tagId := range []uint16{0x11, 0x22, 0x33, 0x22, 0x44, 0x33} {
+		bt := &BuilderTag{
+			ifdPath: exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+			typeId:  exifcommon.TypeByte,
+			tagId:   tagId,
+			value:   NewIfdBuilderTagValueFromBytes([]byte("test string")),
+		}
+
+		err = ib.Add(bt)
+		log.PanicIf(err)
+	}
+
+	n, err := ib.DeleteMany(0x22, 0x33, 0x55)
+	log.PanicIf(err)
+
+	if n != 4 {
+		t.Fatalf("Returned delete tag count not correct: (%d)", n)
+	}
+
+	currentIds := make([]uint16, len(ib.Tags()))
+	for i, bt := range ib.Tags() {
+		currentIds[i] = bt.tagId
+	}
+
+	if reflect.DeepEqual([]uint16{0x11, 0x44}, currentIds) == false {
+		t.Fatalf("Post-delete tags not correct: %v", currentIds)
+	}
+
+	n, err = ib.DeleteMany(0x22)
+	log.PanicIf(err)
+
+	if n != 0 {
+		t.Fatalf("Expected nothing to be deleted: (%d)", n)
+	}
+}
+
+func TestIfdBuilder_DeleteN_TooFew(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	for _, tagId := range []uint16{0x11, 0x22, 0x33} {
+		bt := &BuilderTag{
+			ifdPath: exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+			typeId:  exifcommon.TypeByte,
+			tagId:   tagId,
+			value:   NewIfdBuilderTagValueFromBytes([]byte("test string")),
+		}
+
+		err = ib.Add(bt)
+		log.PanicIf(err)
+	}
+
+	err = ib.DeleteN(0x22, 2)
+	if err == nil {
+		t.Fatalf("Expected an error.")
+	} else if log.Is(err, ErrTagEntryNotFound) == false {
+		log.Panic(err)
+	}
+
+	// The one occurrence that was there is still removed.
+	if len(ib.Tags()) != 2 {
+		t.Fatalf("Post-delete tag count not correct: (%d)", len(ib.Tags()))
+	} else if ib.Tags()[0].tagId != 0x11 || ib.Tags()[1].tagId != 0x33 {
+		t.Fatalf("Post-delete tags not correct.")
+	}
+}
+
+// newLargeIfdBuilder returns an IB with the given number of tags, every other
+// one of which has tag-ID 0x22.
+func newLargeIfdBuilder(count int) *IfdBuilder {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	value := NewIfdBuilderTagValueFromBytes([]byte{1})
+
+	for i := 0; i < count; i++ {
+		tagId := uint16(0x22)
+		if i%2 == 1 {
+			tagId = uint16(0x1000 + i)
+		}
+
+		bt := &BuilderTag{
+			ifdPath: exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+			typeId:  exifcommon.TypeByte,
+			tagId:   tagId,
+			value:   value,
+		}
+
+		err = ib.Add(bt)
+		log.PanicIf(err)
+	}
+
+	return ib
+}
+
+func BenchmarkIfdBuilder_DeleteAll(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		ib := newLargeIfdBuilder(10000)
+		b.StartTimer()
+
+		_, err := ib.DeleteAll(0x22)
+		log.PanicIf(err)
+	}
+}
+
+func BenchmarkIfdBuilder_DeleteN(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		ib := newLargeIfdBuilder(10000)
+		b.StartTimer()
+
+		err := ib.DeleteN(0x22, 5000)
+		log.PanicIf(err)
+	}
+}
+
+func BenchmarkIfdBuilder_DeleteMany(b *testing.B) {
+	tagIds := make([]uint16, 0, 5000)
+	for i := 1; i < 10000; i += 2 {
+		tagIds = append(tagIds, uint16(0x1000+i))
+	}
+
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		ib := newLargeIfdBuilder(10000)
+		b.StartTimer()
+
+		_, err := ib.DeleteMany(tagIds...)
+		log.PanicIf(err)
+	}
+}
+
 func TestIfdBuilder_NewIfdBuilderFromExistingChain(t *testing.T) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -2093,6 +2421,29 @@ func TestNewStandardBuilderTag__TwoUnits(t *testing.T) {
 	}
 }
 
+func TestNewStandardBuilderTagWithOptions(t *testing.T) {
+	ti := NewTagIndex()
+
+	it, err := ti.GetWithName(exifcommon.IfdStandardIfdIdentity, "Orientation")
+	log.PanicIf(err)
+
+	ifdPath := exifcommon.IfdStandardIfdIdentity.UnindexedString()
+
+	bt, err := NewStandardBuilderTagWithOptions(ifdPath, it, exifcommon.TestDefaultByteOrder, []uint32{6}, exifcommon.CoercionOptions{})
+	log.PanicIf(err)
+
+	if bytes.Compare(bt.value.Bytes(), []byte{0x0, 0x6}) != 0 {
+		t.Fatalf("value not correct")
+	}
+
+	// A failure is returned rather than panicking.
+
+	_, err = NewStandardBuilderTagWithOptions(ifdPath, it, exifcommon.TestDefaultByteOrder, []uint32{0x10000}, exifcommon.CoercionOptions{})
+	if log.Is(err, exifcommon.ErrNotCoercible) != true {
+		t.Fatalf("Expected not-coercible error: [%v]", err)
+	}
+}
+
 func TestIfdBuilder_AddStandardWithName(t *testing.T) {
 	im, err := exifcommon.NewIfdMappingWithStandard()
 	log.PanicIf(err)
@@ -2329,3 +2680,272 @@ func TestGetOrCreateIbFromRootIb__Child(t *testing.T) {
 		t.Fatalf("Constructed IFDs not correct.")
 	}
 }
+
+func TestIfdBuilder_SetExifStandardWithName__CreatesExifIfd(t *testing.T) {
+	// The simple test data only has IFD0, like many scanned TIFFs.
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	err = rootIb.SetExifStandardWithName("DateTimeOriginal", "2020:01:02 03:04:05")
+	log.PanicIf(err)
+
+	// A second call should find the IFD that was just created.
+	err = rootIb.SetExifStandardWithName("BodySerialNumber", "12345")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	updatedExifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, updatedExifData)
+	log.PanicIf(err)
+
+	exifIfd, err := FindIfdFromRootIfd(index.RootIfd, "IFD/Exif")
+	log.PanicIf(err)
+
+	if len(exifIfd.Entries()) != 2 {
+		t.Fatalf("Exif IFD does not have exactly two tags: (%d)", len(exifIfd.Entries()))
+	}
+
+	results, err := exifIfd.FindTagWithName("DateTimeOriginal")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "2020:01:02 03:04:05" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+
+	// The original tags must still be there.
+	if len(index.RootIfd.Entries()) != 5 {
+		t.Fatalf("Root IFD tag-count not correct: (%d)", len(index.RootIfd.Entries()))
+	}
+}
+
+func TestIfdBuilder_SetByteOrder(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, binary.BigEndian)
+
+	// An already-encoded value.
+	err = ib.AddStandardWithName("ImageWidth", []uint32{0x11223344})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	// A native value.
+	it, err := ti.GetWithName(exifcommon.IfdStandardIfdIdentity, "XResolution")
+	log.PanicIf(err)
+
+	xResolution := []exifcommon.Rational{{Numerator: 72, Denominator: 1}}
+
+	bt := NewNativeStandardBuilderTag(exifcommon.IfdStandardIfdIdentity.UnindexedString(), it, xResolution)
+
+	if bt.Value().IsValue() != true {
+		t.Fatalf("Native value not retained.")
+	}
+
+	err = ib.Add(bt)
+	log.PanicIf(err)
+
+	// A child IFD, which must follow.
+	err = ib.SetExifStandardWithName("ISOSpeedRatings", []uint16{1600})
+	log.PanicIf(err)
+
+	err = ib.SetByteOrder(binary.LittleEndian)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetVerifyOutput(true)
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	eh, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	if eh.ByteOrder != binary.LittleEndian {
+		t.Fatalf("Byte-order not changed.")
+	}
+
+	expected := map[string]interface{}{
+		"ImageWidth":         []uint32{0x11223344},
+		"ProcessingSoftware": "asciivalue",
+		"XResolution":        xResolution,
+		"ISOSpeedRatings":    []uint16{1600},
+	}
+
+	results, err := index.GetTags("ImageWidth", "ProcessingSoftware", "XResolution", "ISOSpeedRatings")
+	log.PanicIf(err)
+
+	for name, expectedValue := range expected {
+		tv := results[name]
+		if tv.Err != nil {
+			log.Panic(tv.Err)
+		}
+
+		if reflect.DeepEqual(tv.Value.Interface(), expectedValue) != true {
+			t.Fatalf("Value for [%s] not correct: %v != %v", name, tv.Value, expectedValue)
+		}
+	}
+}
+
+func TestIfdBuilder_SetPadding(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.SetPadding(2060)
+	log.PanicIf(err)
+
+	exifIb, err := rootIb.ExifIb()
+	log.PanicIf(err)
+
+	err = exifIb.SetPadding(100)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(PaddingTagId)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != (exifundefined.TagEA1CPadding{Size: 2060}) {
+		t.Fatalf("Padding not correct: %v", value)
+	}
+
+	// Confirm that it's preserved.
+
+	rebuiltIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	size, err := rebuiltIb.Padding()
+	log.PanicIf(err)
+
+	if size != 2060 {
+		t.Fatalf("Root padding not preserved: (%d)", size)
+	}
+
+	rebuiltExifIb, err := rebuiltIb.ExifIb()
+	log.PanicIf(err)
+
+	size, err = rebuiltExifIb.Padding()
+	log.PanicIf(err)
+
+	if size != 100 {
+		t.Fatalf("Exif padding not preserved: (%d)", size)
+	}
+
+	_, err = rebuiltIb.DeleteAll(PaddingTagId)
+	log.PanicIf(err)
+
+	_, err = rebuiltIb.Padding()
+	if log.Is(err, ErrTagEntryNotFound) == false {
+		t.Fatalf("Expected ErrTagEntryNotFound: %v", err)
+	}
+}
+
+func TestIfdBuilder_BuildExif(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	exifData, err := ib.BuildExif()
+	log.PanicIf(err)
+
+	validateExifSimpleTestIb(exifData, t)
+}
+
+func TestIfdBuilder_BuildExif_WithChildAndSibling(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("ProcessingSoftware", "some software")
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("ISOSpeed", []uint32{0x11223344})
+	log.PanicIf(err)
+
+	siblingIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = siblingIb.AddStandardWithName("ImageWidth", []uint32{100})
+	log.PanicIf(err)
+
+	err = rootIb.SetNextIb(siblingIb)
+	log.PanicIf(err)
+
+	exifData, err := rootIb.BuildExif()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	exifIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdExifStandardIfdIdentity)
+	log.PanicIf(err)
+
+	results, err := exifIfd.FindTagWithName("ISOSpeed")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []uint32{0x11223344}) != true {
+		t.Fatalf("Child value not correct: %v", value)
+	}
+
+	nextIfd := index.RootIfd.NextIfd()
+	if nextIfd == nil {
+		t.Fatalf("Sibling IFD not linked.")
+	}
+
+	results, err = nextIfd.FindTagWithName("ImageWidth")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []uint32{100}) != true {
+		t.Fatalf("Sibling value not correct: %v", value)
+	}
+}
+
+func TestIfdBuilder_BuildExif_NotRoot(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	_, err = exifIb.BuildExif()
+	if err == nil {
+		t.Fatalf("Expected error for non-root IB.")
+	}
+}
@@ -0,0 +1,219 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrEncodeVerificationFailed means that the encoder's output did not
+	// parse back to the content that was encoded. See
+	// `IfdByteEncoder.SetVerifyOutput()`.
+	ErrEncodeVerificationFailed = errors.New("encoded EXIF does not match builder")
+)
+
+// SetVerifyOutput enables a self-check in `EncodeToExif()`: the encoded EXIF
+// is parsed again and its logical content is compared against the builder's
+// before it is returned. If they differ, no data is returned and the error is
+// `ErrEncodeVerificationFailed`. This roughly doubles the cost of encoding but
+// guarantees that a writer bug can't silently corrupt a file.
+//
+// Tags that the parser will always skip (unknown tags, or tags with types
+// that the tag-index doesn't support for them) are not verified.
+func (ibe *IfdByteEncoder) SetVerifyOutput(flag bool) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.verifyOutput = flag
+}
+
+// VerifyOutput returns whether `EncodeToExif()` verifies its output.
+func (ibe *IfdByteEncoder) VerifyOutput() bool {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.verifyOutput
+}
+
+// verifyExif parses the given EXIF data and compares it against the IB that
+// it was encoded from.
+func (ibe *IfdByteEncoder) verifyExif(ib *IfdBuilder, exifData []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	_, index, err := Collect(ib.ifdMapping, ib.tagIndex, exifData)
+	if err != nil {
+		ifdBuilderLogger.Errorf(nil, err, "Encoded EXIF could not be parsed.")
+		log.Panic(ErrEncodeVerificationFailed)
+	}
+
+	expected, err := ibe.flattenIb(ib, nil)
+	log.PanicIf(err)
+
+	actual, err := flattenIfd(index.RootIfd, nil)
+	log.PanicIf(err)
+
+	if len(actual) != len(expected) {
+		ifdBuilderLogger.Warningf(nil, "Encoded EXIF has (%d) tags but builder has (%d).", len(actual), len(expected))
+		log.Panic(ErrEncodeVerificationFailed)
+	}
+
+	for i, line := range expected {
+		if actual[i] != line {
+			ifdBuilderLogger.Warningf(nil, "Encoded tag (%d) does not match builder:\nACTUAL: %s\nEXPECTED: %s", i, actual[i], line)
+			log.Panic(ErrEncodeVerificationFailed)
+		}
+	}
+
+	return nil
+}
+
+// isVerifiableTag returns true if the parser is expected to produce the given
+// tag from the encoded data.
+func isVerifiableTag(ib *IfdBuilder, bt *BuilderTag) (isVerifiable bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if bt.value.IsIb() == true {
+		return true, nil
+	}
+
+	it, err := ib.tagIndex.Get(ib.IfdIdentity(), bt.tagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	if ib.tagIndex.UniversalSearch() == false && it.DoesSupportType(bt.typeId) == false {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// flattenIb returns one line per tag (recursively) describing the logical
+// content of the IB as it will be written by this encoder. This is comparable
+// with the output of `flattenIfd()`.
+func (ibe *IfdByteEncoder) flattenIb(ib *IfdBuilder, lines []string) (flattened []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// IBs in a chain don't necessarily have indexed identities, so we use the
+	// position in the chain instead.
+	for i, thisIb := 0, ib; thisIb != nil; i, thisIb = i+1, thisIb.nextIb {
+		ifdPath := thisIb.IfdIdentity().UnindexedString()
+		fqIfdPath := fmt.Sprintf("%s:%d", ifdPath, i)
+
+		for _, bt := range ibe.tagsToEncode(thisIb) {
+			isVerifiable, err := isVerifiableTag(thisIb, bt)
+			log.PanicIf(err)
+
+			if isVerifiable == false {
+				continue
+			}
+
+			if bt.value.IsIb() == true {
+				lines = append(lines, fmt.Sprintf("[%s] (0x%04x) CHILD", fqIfdPath, bt.tagId))
+
+				lines, err = ibe.flattenIb(bt.value.Ib(), lines)
+				log.PanicIf(err)
+
+				continue
+			}
+
+			valueBytes, err := ibe.encodedTagBytes(thisIb, bt)
+			log.PanicIf(err)
+
+			if thisIb.isThumbnailStripBlob(bt) == true || bt.tagId == ThumbnailOffsetTagId && ifdPath == exifcommon.IfdStandardIfdIdentity.UnindexedString() && i == 1 {
+				lines = append(lines, fmt.Sprintf("[%s] (0x%04x) THUMBNAIL %x", fqIfdPath, bt.tagId, valueBytes))
+				continue
+			}
+
+			if ibe.emptyAsciiPolicy == EmptyAsciiEmitZeroLength && isEmptyAsciiTag(bt) == true {
+				valueBytes = nil
+			}
+
+			if ibe.relocateMakerNotes == true && bt.hasSourceOffset == true {
+				key := ValueOffsetKey{
+					FqIfdPath: thisIb.IfdIdentity().NewSibling(ibe.chainIndices[thisIb]).String(),
+					TagId:     bt.tagId,
+				}
+
+				valueBytes, err = relocateMakerNote(valueBytes, thisIb.byteOrder, bt.sourceOffset, ibe.valueOffsets[key])
+				log.PanicIf(err)
+			}
+
+			lines = append(lines, fmt.Sprintf("[%s] (0x%04x) [%s] %x", fqIfdPath, bt.tagId, bt.typeId, valueBytes))
+		}
+	}
+
+	return lines, nil
+}
+
+// flattenIfd is the complement of `flattenIb()` for parsed data.
+func flattenIfd(ifd *Ifd, lines []string) (flattened []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for i, thisIfd := 0, ifd; thisIfd != nil; i, thisIfd = i+1, thisIfd.nextIfd {
+		fqIfdPath := fmt.Sprintf("%s:%d", thisIfd.IfdIdentity().UnindexedString(), i)
+
+		for _, ite := range thisIfd.entries {
+			if ite.ChildIfdPath() != "" {
+				lines = append(lines, fmt.Sprintf("[%s] (0x%04x) CHILD", fqIfdPath, ite.TagId()))
+
+				childIfd, found := thisIfd.childIfdIndex[ite.ChildIfdPath()]
+				if found == false {
+					log.Panicf("child IFD not found: [%s]", ite.ChildIfdPath())
+				}
+
+				lines, err = flattenIfd(childIfd, lines)
+				log.PanicIf(err)
+
+				continue
+			}
+
+			if ite.TagId() == ThumbnailStripOffsetsTagId {
+				thumbnailFormat, err := thisIfd.ThumbnailFormat()
+				if err == nil && thumbnailFormat == ThumbnailFormatUncompressed {
+					thumbnailData, err := thisIfd.Thumbnail()
+					if err == nil {
+						lines = append(lines, fmt.Sprintf("[%s] (0x%04x) THUMBNAIL %x", fqIfdPath, ite.TagId(), thumbnailData))
+						continue
+					}
+				}
+			}
+
+			rawBytes, err := ite.GetRawBytes()
+			log.PanicIf(err)
+
+			if ite.IsThumbnailOffset() == true {
+				lines = append(lines, fmt.Sprintf("[%s] (0x%04x) THUMBNAIL %x", fqIfdPath, ite.TagId(), rawBytes))
+				continue
+			}
+
+			lines = append(lines, fmt.Sprintf("[%s] (0x%04x) [%s] %x", fqIfdPath, ite.TagId(), ite.TagType(), rawBytes))
+		}
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,78 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestIfdByteEncoder_EncodeToExif__VerifyOutput(t *testing.T) {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetVerifyOutput(true)
+
+	_, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+}
+
+func TestIfdByteEncoder_verifyExif__Mismatch(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	err = ibe.verifyExif(ib, exifData)
+	log.PanicIf(err)
+
+	// Simulate a writer bug by changing the builder after the fact.
+
+	err = ib.SetStandardWithName("ProcessingSoftware", "othervalue")
+	log.PanicIf(err)
+
+	err = ibe.verifyExif(ib, exifData)
+	if log.Is(err, ErrEncodeVerificationFailed) != true {
+		t.Fatalf("Expected verification failure for changed value: %v", err)
+	}
+
+	err = ib.AddStandardWithName("Artist", "someone")
+	log.PanicIf(err)
+
+	err = ibe.verifyExif(ib, exifData)
+	if log.Is(err, ErrEncodeVerificationFailed) != true {
+		t.Fatalf("Expected verification failure for missing tag: %v", err)
+	}
+
+	err = ibe.verifyExif(ib, exifData[:20])
+	if log.Is(err, ErrEncodeVerificationFailed) != true {
+		t.Fatalf("Expected verification failure for truncated data: %v", err)
+	}
+}
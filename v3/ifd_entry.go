@@ -0,0 +1,67 @@
+package exif
+
+import (
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// RawIfdEntry is one 12-byte entry of an IFD table, exactly as stored.
+type RawIfdEntry struct {
+	// TagId is the ID of the tag.
+	TagId uint16
+
+	// TagType is the type of the value. It is not validated.
+	TagType exifcommon.TagTypePrimitive
+
+	// UnitCount is the number of values of the type.
+	UnitCount uint32
+
+	// ValueOffset is the offset of the value or, if the value fits in four
+	// bytes, the value itself as read in the byte-order of the IFD. Either
+	// way, encoding it in the same byte-order reproduces the original bytes.
+	ValueOffset uint32
+}
+
+// String returns a descriptive string.
+func (rie RawIfdEntry) String() string {
+	return fmt.Sprintf("RawIfdEntry<TAG-ID=(0x%04x) TAG-TYPE=[%s] UNIT-COUNT=(%d) VALUE-OFFSET=(0x%08x)>", rie.TagId, rie.TagType, rie.UnitCount, rie.ValueOffset)
+}
+
+// IsEmbedded returns true if the value is stored in the entry itself rather
+// than at `ValueOffset`. It returns false if the type is not valid.
+func (rie RawIfdEntry) IsEmbedded() bool {
+	if rie.TagType.IsValid() == false {
+		return false
+	}
+
+	unitSize := uint64(1)
+	if rie.TagType != exifcommon.TypeUndefined {
+		unitSize = uint64(rie.TagType.Size())
+	}
+
+	return uint64(rie.UnitCount)*unitSize <= 4
+}
+
+// DecodeIfdEntry decodes one IFD entry. This is the same decoding that the
+// enumerator does, for tools that patch entries in place.
+func DecodeIfdEntry(raw [IfdTagEntrySize]byte, byteOrder binary.ByteOrder) RawIfdEntry {
+	return RawIfdEntry{
+		TagId:       byteOrder.Uint16(raw[0:2]),
+		TagType:     exifcommon.TagTypePrimitive(byteOrder.Uint16(raw[2:4])),
+		UnitCount:   byteOrder.Uint32(raw[4:8]),
+		ValueOffset: byteOrder.Uint32(raw[8:12]),
+	}
+}
+
+// EncodeIfdEntry is the inverse of `DecodeIfdEntry()`.
+func EncodeIfdEntry(rie RawIfdEntry, byteOrder binary.ByteOrder) (raw [IfdTagEntrySize]byte) {
+	byteOrder.PutUint16(raw[0:2], rie.TagId)
+	byteOrder.PutUint16(raw[2:4], uint16(rie.TagType))
+	byteOrder.PutUint32(raw[4:8], rie.UnitCount)
+	byteOrder.PutUint32(raw[8:12], rie.ValueOffset)
+
+	return raw
+}
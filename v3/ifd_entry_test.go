@@ -0,0 +1,61 @@
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestDecodeIfdEntry(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	eh, err := ParseExifHeader(exifData)
+	log.PanicIf(err)
+
+	// Skip the entry-count.
+	offset := eh.FirstIfdOffset + 2
+
+	var raw [IfdTagEntrySize]byte
+	copy(raw[:], exifData[offset:offset+IfdTagEntrySize])
+
+	rie := DecodeIfdEntry(raw, eh.ByteOrder)
+
+	if rie.TagId != 0x000b {
+		t.Fatalf("Tag-ID not correct: (0x%04x)", rie.TagId)
+	} else if rie.TagType != exifcommon.TypeAscii {
+		t.Fatalf("Tag-type not correct: [%s]", rie.TagType)
+	} else if rie.UnitCount != 11 {
+		t.Fatalf("Unit-count not correct: (%d)", rie.UnitCount)
+	} else if rie.IsEmbedded() != false {
+		t.Fatalf("Value should not be embedded.")
+	} else if string(exifData[rie.ValueOffset:rie.ValueOffset+rie.UnitCount]) != "asciivalue\x00" {
+		t.Fatalf("Value offset not correct: (0x%08x)", rie.ValueOffset)
+	}
+
+	if EncodeIfdEntry(rie, eh.ByteOrder) != raw {
+		t.Fatalf("Entry not reproduced.")
+	}
+}
+
+func TestRawIfdEntry_IsEmbedded(t *testing.T) {
+	rie := RawIfdEntry{
+		TagType:   exifcommon.TypeShort,
+		UnitCount: 3,
+	}
+
+	if rie.IsEmbedded() != false {
+		t.Fatalf("Three SHORTs should not be embedded.")
+	}
+
+	rie.TagType = exifcommon.TypeUndefined
+	if rie.IsEmbedded() != true {
+		t.Fatalf("Three UNDEFINED bytes should be embedded.")
+	}
+
+	rie.TagType = exifcommon.TagTypePrimitive(99)
+	if rie.IsEmbedded() != false {
+		t.Fatalf("An invalid type should not be embedded.")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -33,6 +34,18 @@ var (
 
 	// ErrOffsetInvalid means that the file offset is not valid.
 	ErrOffsetInvalid = errors.New("file offset invalid")
+
+	// ErrIfdNotFound means that the requested IFD is not present in the data.
+	// Many files (e.g. scanned TIFFs) only have IFD0, so this is not unusual.
+	ErrIfdNotFound = errors.New("ifd not found")
+
+	// ErrZeroLengthAscii means that an ASCII tag had a unit-count of zero and
+	// was skipped per `SetSkipZeroLengthAscii()`.
+	ErrZeroLengthAscii = errors.New("ascii tag has zero length")
+
+	// ErrStopScan can be returned by a `TagVisitorFn` to end a scan early.
+	// The scan then returns successfully.
+	ErrStopScan = errors.New("stop scan")
 )
 
 var (
@@ -79,6 +92,8 @@ type byteParser struct {
 	rs            io.ReadSeeker
 	ifdOffset     uint32
 	currentOffset uint32
+
+	arena *bufferArena
 }
 
 // newByteParser returns a new byteParser struct.
@@ -111,7 +126,11 @@ func (bp *byteParser) getUint16() (value uint16, raw []byte, err error) {
 
 	needBytes := 2
 
-	raw = make([]byte, needBytes)
+	if bp.arena != nil {
+		raw = bp.arena.get(needBytes)
+	} else {
+		raw = make([]byte, needBytes)
+	}
 
 	_, err = io.ReadFull(bp.rs, raw)
 	log.PanicIf(err)
@@ -137,7 +156,11 @@ func (bp *byteParser) getUint32() (value uint32, raw []byte, err error) {
 
 	needBytes := 4
 
-	raw = make([]byte, needBytes)
+	if bp.arena != nil {
+		raw = bp.arena.get(needBytes)
+	} else {
+		raw = make([]byte, needBytes)
+	}
 
 	_, err = io.ReadFull(bp.rs, raw)
 	log.PanicIf(err)
@@ -165,6 +188,17 @@ type IfdEnumerate struct {
 	furthestOffset uint32
 
 	visitedIfdOffsets map[uint32]struct{}
+
+	skipZeroLengthAscii bool
+	skipThumbnail       bool
+	skipMakerNote       bool
+	strictEnums         bool
+	maxStringLength     uint32
+
+	// bufferPool and arena are only set for the duration of a `Scan()` with
+	// a `BufferPool`.
+	bufferPool *BufferPool
+	arena      *bufferArena
 }
 
 // NewIfdEnumerate returns a new instance of IfdEnumerate.
@@ -179,6 +213,51 @@ func NewIfdEnumerate(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ebs
 	}
 }
 
+// SetSkipZeroLengthAscii determines whether ASCII tags with a unit-count of
+// zero (not even a NUL) are skipped. By default, they are read as empty
+// strings.
+func (ie *IfdEnumerate) SetSkipZeroLengthAscii(flag bool) {
+	ie.skipZeroLengthAscii = flag
+}
+
+// SetSkipThumbnail determines whether the thumbnail data in IFD1 is read. When
+// skipped, `Ifd.Thumbnail()` returns `ErrNoThumbnail`. The thumbnail tags are
+// still present.
+func (ie *IfdEnumerate) SetSkipThumbnail(flag bool) {
+	ie.skipThumbnail = flag
+}
+
+// SetSkipMakerNote determines whether the maker-note tag is skipped. It is
+// usually the largest value in the EXIF IFD and is rarely needed. When
+// skipped, it is neither visited nor collected, so it will also be absent from
+// anything built from the parsed IFDs.
+func (ie *IfdEnumerate) SetSkipMakerNote(flag bool) {
+	ie.skipMakerNote = flag
+}
+
+// SetStrictEnums determines how enumerated tags (e.g. Orientation) that are
+// stored with a different integer type than the specification calls for
+// (e.g. a SLONG with a negative value) are treated. By default, they are read
+// so that they are preserved through rebuilds and reported by `Lint()`. If
+// strict, they are skipped like any other tag with an unsupported type.
+func (ie *IfdEnumerate) SetStrictEnums(flag bool) {
+	ie.strictEnums = flag
+}
+
+// SetMaxStringLength sets the most bytes of an ASCII value that will be read.
+// Longer values (e.g. the huge counts declared by some corrupt files) are
+// truncated and flagged (see `IfdTagEntry.IsTruncated()`) rather than read in
+// full. Limits under five are raised to five, since shorter values are stored
+// in their tag entries rather than where the longer ones are. By default,
+// (0), there is no limit.
+func (ie *IfdEnumerate) SetMaxStringLength(length uint32) {
+	if length > 0 && length < 5 {
+		length = 5
+	}
+
+	ie.maxStringLength = length
+}
+
 func (ie *IfdEnumerate) getByteParser(ifdOffset uint32) (bp *byteParser, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -205,6 +284,8 @@ func (ie *IfdEnumerate) getByteParser(ifdOffset uint32) (bp *byteParser, err err
 		log.Panic(err)
 	}
 
+	bp.arena = ie.arena
+
 	return bp, nil
 }
 
@@ -239,6 +320,8 @@ func (ie *IfdEnumerate) parseTag(ii *exifcommon.IfdIdentity, tagPosition int, bp
 			"Tag (0x%04x) in IFD [%s] at position (%d) has invalid type (0x%04x) and will be skipped.",
 			tagId, ii, tagPosition, int(tagType))
 
+		recordParseWarning(ParseWarningTagTypeInvalid)
+
 		ite = &IfdTagEntry{
 			tagId:   tagId,
 			tagType: tagType,
@@ -254,6 +337,7 @@ func (ie *IfdEnumerate) parseTag(ii *exifcommon.IfdIdentity, tagPosition int, bp
 	if err != nil {
 		if log.Is(err, ErrTagNotFound) == true {
 			ifdEnumerateLogger.Warningf(nil, "Tag (0x%04x) is not known and will be skipped.", tagId)
+			recordParseWarning(ParseWarningTagUnknown)
 
 			ite = &IfdTagEntry{
 				tagId: tagId,
@@ -268,7 +352,11 @@ func (ie *IfdEnumerate) parseTag(ii *exifcommon.IfdIdentity, tagPosition int, bp
 	// If we're trying to be as forgiving as possible then use whatever type was
 	// reported in the format. Otherwise, only accept a type that's expected for
 	// this tag.
-	if ie.tagIndex.UniversalSearch() == false && it.DoesSupportType(tagType) == false {
+	if ie.strictEnums == false && it.DoesSupportType(tagType) == false && isEnumCompatibleType(tagType) == true && isEnumeratedTag(ii.UnindexedString(), it.Name) == true {
+		ifdEnumerateLogger.Warningf(nil,
+			"Enumerated tag [%s] in IFD [%s] has unexpected type [%s] and will be preserved as-is.",
+			it.Name, ii, tagType)
+	} else if ie.tagIndex.UniversalSearch() == false && it.DoesSupportType(tagType) == false {
 		// The type in the stream disagrees with the type that this tag is
 		// expected to have. This can present issues with how we handle the
 		// special-case tags (e.g. thumbnails, GPS, etc..) when those tags
@@ -278,9 +366,21 @@ func (ie *IfdEnumerate) parseTag(ii *exifcommon.IfdIdentity, tagPosition int, bp
 			"Tag (0x%04x) in IFD [%s] at position (%d) has unsupported type (0x%02x) and will be skipped.",
 			tagId, ii, tagPosition, int(tagType))
 
+		recordParseWarning(ParseWarningTagTypeUnsupported)
+
 		return nil, ErrTagTypeNotValid
 	}
 
+	if tagType == exifcommon.TypeAscii && unitCount == 0 && ie.skipZeroLengthAscii == true {
+		ifdEnumerateLogger.Warningf(nil,
+			"Tag (0x%04x) in IFD [%s] at position (%d) is a zero-length ASCII value and will be skipped.",
+			tagId, ii, tagPosition)
+
+		recordParseWarning(ParseWarningZeroLengthAscii)
+
+		return nil, ErrZeroLengthAscii
+	}
+
 	// Construct tag struct.
 
 	rs, err := ie.ebs.GetReadSeeker(0)
@@ -297,6 +397,20 @@ func (ie *IfdEnumerate) parseTag(ii *exifcommon.IfdIdentity, tagPosition int, bp
 		rs,
 		ie.byteOrder)
 
+	if erbs, ok := ie.ebs.(*ExifReadSeeker); ok == true {
+		ite.data = erbs.data
+	}
+
+	if tagType == exifcommon.TypeAscii && ie.maxStringLength > 0 && unitCount > ie.maxStringLength {
+		ifdEnumerateLogger.Warningf(nil,
+			"Tag (0x%04x) in IFD [%s] at position (%d) is an ASCII value of (%d) bytes and will be truncated to (%d).",
+			tagId, ii, tagPosition, unitCount, ie.maxStringLength)
+
+		recordParseWarning(ParseWarningAsciiTruncated)
+
+		ite.truncate(ie.maxStringLength)
+	}
+
 	ifdPath := ii.UnindexedString()
 
 	// If it's an IFD but not a standard one, it'll just be seen as a LONG
@@ -380,6 +494,8 @@ func (ie *IfdEnumerate) tagPostParse(ite *IfdTagEntry, med *MiscellaneousExifDat
 				"Tag with ID (0x%04x) in IFD [%s] is not recognized and "+
 					"will be ignored.", tagId, ii.String())
 
+			recordParseWarning(ParseWarningTagUnknown)
+
 			return ErrTagNotFound
 		}
 
@@ -393,6 +509,8 @@ func (ie *IfdEnumerate) tagPostParse(ite *IfdTagEntry, med *MiscellaneousExifDat
 			tagId, ii.UnindexedString(), it.Name, it.IfdPath,
 			tagType)
 
+		recordParseWarning(ParseWarningTagMisplaced)
+
 		if med != nil {
 			med.unknownTags[originalBt] = exifcommon.BasicTag{
 				IfdPath: it.IfdPath,
@@ -418,6 +536,8 @@ func (ie *IfdEnumerate) tagPostParse(ite *IfdTagEntry, med *MiscellaneousExifDat
 			ii.UnindexedString(), tagId, it.Name,
 			tagType, it.SupportedTypes)
 
+		recordParseWarning(ParseWarningTagTypeUnsupported)
+
 		return ErrTagNotFound
 	}
 
@@ -438,7 +558,11 @@ func (ie *IfdEnumerate) parseIfd(ii *exifcommon.IfdIdentity, bp *byteParser, vis
 
 	ifdEnumerateLogger.Debugf(nil, "IFD [%s] tag-count: (%d)", ii.String(), tagCount)
 
-	entries = make([]*IfdTagEntry, 0)
+	if ie.bufferPool != nil {
+		entries = *ie.bufferPool.getEntries()
+	} else {
+		entries = make([]*IfdTagEntry, 0)
+	}
 
 	var enumeratorThumbnailOffset *IfdTagEntry
 	var enumeratorThumbnailSize *IfdTagEntry
@@ -446,7 +570,7 @@ func (ie *IfdEnumerate) parseIfd(ii *exifcommon.IfdIdentity, bp *byteParser, vis
 	for i := 0; i < int(tagCount); i++ {
 		ite, err := ie.parseTag(ii, i, bp)
 		if err != nil {
-			if log.Is(err, ErrTagNotFound) == true || log.Is(err, ErrTagTypeNotValid) == true {
+			if log.Is(err, ErrTagNotFound) == true || log.Is(err, ErrTagTypeNotValid) == true || log.Is(err, ErrZeroLengthAscii) == true {
 				// These tags should've been fully logged in parseTag(). The
 				// ITE returned is nil so we can't print anything about them, now.
 				continue
@@ -466,6 +590,11 @@ func (ie *IfdEnumerate) parseIfd(ii *exifcommon.IfdIdentity, bp *byteParser, vis
 
 		tagId := ite.TagId()
 
+		if ie.skipMakerNote == true && tagId == MakerNoteTagId && ii.UnindexedString() == exifcommon.IfdExifStandardIfdIdentity.UnindexedString() {
+			ifdEnumerateLogger.Debugf(nil, "Skipping the maker-note tag (0x%04x).", tagId)
+			continue
+		}
+
 		if visitor != nil {
 			err := visitor(ite)
 			log.PanicIf(err)
@@ -531,7 +660,20 @@ func (ie *IfdEnumerate) parseIfd(ii *exifcommon.IfdIdentity, bp *byteParser, vis
 		entries = append(entries, ite)
 	}
 
-	if enumeratorThumbnailOffset != nil && enumeratorThumbnailSize != nil {
+	if ie.skipThumbnail == true {
+		if enumeratorThumbnailOffset != nil && enumeratorThumbnailSize != nil {
+			// Account for the data without reading it.
+
+			offset := enumeratorThumbnailOffset.getValueOffset()
+			length := enumeratorThumbnailSize.getValueOffset()
+
+			furthestOffset := offset + length
+
+			if furthestOffset > ie.furthestOffset {
+				ie.furthestOffset = furthestOffset
+			}
+		}
+	} else if enumeratorThumbnailOffset != nil && enumeratorThumbnailSize != nil {
 		thumbnailData, err = ie.parseThumbnail(enumeratorThumbnailOffset, enumeratorThumbnailSize)
 		if err != nil {
 			ifdEnumerateLogger.Errorf(
@@ -555,12 +697,25 @@ func (ie *IfdEnumerate) parseIfd(ii *exifcommon.IfdIdentity, bp *byteParser, vis
 	}
 
 	nextIfdOffset, _, err = bp.getUint32()
-	log.PanicIf(err)
+	if err != nil {
+		if log.Is(err, io.EOF) == false && log.Is(err, io.ErrUnexpectedEOF) == false {
+			log.Panic(err)
+		}
+
+		// Some writers omit (or truncate) the next-IFD offset of the last IFD
+		// when it falls at the very end of the data, most often for empty
+		// IFDs. It can only have been zero, so treat it that way.
+		ifdEnumerateLogger.Warningf(nil, "[%s] IFD is missing its next-IFD offset. Assuming the chain has terminated.", ii.String())
+		recordParseWarning(ParseWarningNextIfdOffsetMissing)
+
+		nextIfdOffset = 0
+	}
 
 	_, alreadyVisited := ie.visitedIfdOffsets[nextIfdOffset]
 
 	if alreadyVisited == true {
 		ifdEnumerateLogger.Warningf(nil, "IFD at offset (0x%08x) has been linked-to more than once. There might be a cycle in the IFD chain. Not reparsing.", nextIfdOffset)
+		recordParseWarning(ParseWarningIfdCycle)
 		nextIfdOffset = 0
 	}
 
@@ -627,9 +782,13 @@ func (ie *IfdEnumerate) scan(iiGeneral *exifcommon.IfdIdentity, ifdOffset uint32
 			log.Panic(err)
 		}
 
-		nextIfdOffset, _, _, err := ie.parseIfd(iiSibling, bp, visitor, true, med)
+		nextIfdOffset, entries, _, err := ie.parseIfd(iiSibling, bp, visitor, true, med)
 		log.PanicIf(err)
 
+		if ie.bufferPool != nil {
+			ie.bufferPool.putEntries(&entries)
+		}
+
 		currentOffset := bp.CurrentOffset()
 		if currentOffset > ie.furthestOffset {
 			ie.furthestOffset = currentOffset
@@ -660,12 +819,47 @@ func (med *MiscellaneousExifData) UnknownTags() map[exifcommon.BasicTag]exifcomm
 
 // ScanOptions tweaks parser behavior/choices.
 type ScanOptions struct {
-	// NOTE(dustin): Reserved for future usage.
+	// BufferPool, if not nil, is used to recycle the buffers allocated while
+	// scanning. The tag entries passed to the visitor must then not be used
+	// after the scan returns. See `BufferPool`.
+	BufferPool *BufferPool
+
+	// SkipThumbnail skips reading the thumbnail data. See
+	// `IfdEnumerate.SetSkipThumbnail()`.
+	SkipThumbnail bool
+
+	// SkipMakerNote skips the maker-note tag. See
+	// `IfdEnumerate.SetSkipMakerNote()`.
+	SkipMakerNote bool
+
+	// StrictEnums skips enumerated tags with unexpected types. See
+	// `IfdEnumerate.SetStrictEnums()`.
+	StrictEnums bool
+
+	// MaxStringLength, if not zero, truncates longer ASCII values. See
+	// `IfdEnumerate.SetMaxStringLength()`.
+	MaxStringLength uint32
+
+	// Dump, if not nil, summarizes large binary values in the flat tags
+	// returned by `GetFlatExifData()` and its variants (see `DumpOptions`).
+	Dump *DumpOptions
+
+	// Charsets, if not nil, converts the text of ASCII tags in the flat tags
+	// from the legacy charset that it appears to be in, trying these in
+	// order (see `SniffCharset()` and `IfdTagEntry.DecodedText()`).
+	// `ValueBytes` keeps the original bytes.
+	Charsets []string
 }
 
 // Scan enumerates the different EXIF blocks (called IFDs). `rootIfdName` will
 // be "IFD" in the TIFF standard.
 func (ie *IfdEnumerate) Scan(iiRoot *exifcommon.IfdIdentity, ifdOffset uint32, visitor TagVisitorFn, so *ScanOptions) (med *MiscellaneousExifData, err error) {
+	startedAt := time.Now()
+
+	defer func() {
+		recordParseCompleted(ParseOperationScan, ie.FurthestOffset(), startedAt, err)
+	}()
+
 	defer func() {
 		if state := recover(); state != nil {
 			err = log.Wrap(state.(error))
@@ -678,8 +872,60 @@ func (ie *IfdEnumerate) Scan(iiRoot *exifcommon.IfdIdentity, ifdOffset uint32, v
 		unknownTags: make(map[exifcommon.BasicTag]exifcommon.BasicTag),
 	}
 
+	// The options only apply to this scan. Restore whatever the enumerator was
+	// configured with so that a reused enumerator doesn't keep them.
+
+	if so != nil {
+		skipThumbnail := ie.skipThumbnail
+		skipMakerNote := ie.skipMakerNote
+		strictEnums := ie.strictEnums
+		maxStringLength := ie.maxStringLength
+
+		defer func() {
+			ie.skipThumbnail = skipThumbnail
+			ie.skipMakerNote = skipMakerNote
+			ie.strictEnums = strictEnums
+			ie.maxStringLength = maxStringLength
+		}()
+
+		if so.SkipThumbnail == true {
+			ie.SetSkipThumbnail(true)
+		}
+
+		if so.SkipMakerNote == true {
+			ie.SetSkipMakerNote(true)
+		}
+
+		if so.StrictEnums == true {
+			ie.SetStrictEnums(true)
+		}
+
+		if so.MaxStringLength > 0 {
+			ie.SetMaxStringLength(so.MaxStringLength)
+		}
+	}
+
+	if so != nil && so.BufferPool != nil {
+		ie.bufferPool = so.BufferPool
+		ie.arena = so.BufferPool.newArena()
+
+		defer func() {
+			ie.arena.release()
+
+			ie.bufferPool = nil
+			ie.arena = nil
+		}()
+	}
+
 	err = ie.scan(iiRoot, ifdOffset, visitor, med)
-	log.PanicIf(err)
+	if err != nil {
+		if log.Is(err, ErrStopScan) == false {
+			log.Panic(err)
+		}
+
+		ifdEnumerateLogger.Debugf(nil, "Scan: Stopped early by the visitor.")
+		return med, nil
+	}
 
 	ifdEnumerateLogger.Debugf(nil, "Scan: It looks like the furthest offset that contained EXIF data in the EXIF blob was (%d) (Scan).", ie.FurthestOffset())
 
@@ -712,6 +958,10 @@ type Ifd struct {
 
 	thumbnailData []byte
 
+	// hasThumbnailStrips is true if the IFD has an uncompressed thumbnail.
+	// Its strips are only read when the thumbnail is asked for.
+	hasThumbnailStrips bool
+
 	nextIfdOffset uint32
 	nextIfd       *Ifd
 }
@@ -865,15 +1115,42 @@ func (ifd *Ifd) String() string {
 	return fmt.Sprintf("Ifd<ID=(%d) IFD-PATH=[%s] INDEX=(%d) COUNT=(%d) OFF=(0x%04x) CHILDREN=(%d) PARENT=(0x%04x) NEXT-IFD=(0x%04x)>", ifd.id, ifd.ifdIdentity.UnindexedString(), ifd.ifdIdentity.Index(), len(ifd.entries), ifd.offset, len(ifd.children), parentOffset, ifd.nextIfdOffset)
 }
 
+// DebugString returns a multiline description of the IFD followed by each of
+// its tags with their names and values (see `IfdTagEntry.DebugString()`).
+// Child and sibling IFDs are not descended into.
+func (ifd *Ifd) DebugString(maxValueLength int) string {
+	lines := make([]string, len(ifd.entries)+1)
+	lines[0] = ifd.String()
+
+	for i, ite := range ifd.entries {
+		lines[i+1] = "  " + ite.DebugString(maxValueLength)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // Thumbnail returns the raw thumbnail bytes. This is typically directly
 // readable by any standard image viewer.
 func (ifd *Ifd) Thumbnail() (data []byte, err error) {
+	if ifd.thumbnailData != nil {
+		return ifd.thumbnailData, nil
+	} else if ifd.hasThumbnailStrips == false {
+		return nil, ErrNoThumbnail
+	}
+
+	// Uncompressed thumbnails are read on demand.
 
-	if ifd.thumbnailData == nil {
+	ts, _, err := findThumbnailStrips(ifd.entries)
+	if err == nil {
+		data, err = ts.Read()
+	}
+
+	if err != nil {
+		ifdEnumerateLogger.Errorf(nil, err, "Could not read uncompressed thumbnail strips.")
 		return nil, ErrNoThumbnail
 	}
 
-	return ifd.thumbnailData, nil
+	return data, nil
 }
 
 // dumpTags recursively builds a list of tags from an IFD.
@@ -1186,36 +1463,14 @@ func (ifd *Ifd) GpsInfo() (gi *GpsInfo, err error) {
 
 	// Parse altitude.
 
-	altitudeTags, foundAltitude := ifd.entriesByTagId[TagAltitudeId]
-	altitudeRefTags, foundAltitudeRef := ifd.entriesByTagId[TagAltitudeRefId]
-
-	if foundAltitude == true && foundAltitudeRef == true {
-		altitudePhrase, err := altitudeTags[0].Format()
-		log.PanicIf(err)
-
-		ifdEnumerateLogger.Debugf(nil, "Altitude is [%s].", altitudePhrase)
-
-		altitudeValue, err := altitudeTags[0].Value()
-		log.PanicIf(err)
-
-		altitudeRefPhrase, err := altitudeRefTags[0].Format()
-		log.PanicIf(err)
-
-		ifdEnumerateLogger.Debugf(nil, "Altitude-reference is [%s].", altitudeRefPhrase)
-
-		altitudeRefValue, err := altitudeRefTags[0].Value()
-		log.PanicIf(err)
-
-		altitudeRaw := altitudeValue.([]exifcommon.Rational)
-		if altitudeRaw[0].Denominator > 0 {
-			altitude := int(altitudeRaw[0].Numerator / altitudeRaw[0].Denominator)
+	altitudeMeters, foundAltitude, err := ifd.gpsAltitudeMeters()
+	log.PanicIf(err)
 
-			if altitudeRefValue.([]byte)[0] == 1 {
-				altitude *= -1
-			}
+	if foundAltitude == true {
+		ifdEnumerateLogger.Debugf(nil, "Altitude is (%f) meters.", altitudeMeters)
 
-			gi.Altitude = altitude
-		}
+		gi.AltitudeMeters = altitudeMeters
+		gi.Altitude = int(altitudeMeters)
 	}
 
 	// Parse timestamp from separate date and time tags.
@@ -1312,6 +1567,10 @@ type IfdIndex struct {
 	Ifds    []*Ifd
 	Tree    map[int]*Ifd
 	Lookup  map[string]*Ifd
+
+	// Header describes the header that the IFDs were parsed from. Pass it to
+	// `IfdByteEncoder.SetHeaderLayout()` to reproduce it in a rebuild.
+	Header HeaderLayout
 }
 
 // Collect enumerates the different EXIF blocks (called IFDs) and builds out an
@@ -1323,6 +1582,34 @@ func (ie *IfdEnumerate) Collect(rootIfdOffset uint32) (index IfdIndex, err error
 		}
 	}()
 
+	index, err = ie.collect(exifcommon.IfdStandardIfdIdentity, rootIfdOffset)
+	if err != nil {
+		if err == ErrOffsetInvalid {
+			return index, err
+		}
+
+		log.Panic(err)
+	}
+
+	return index, nil
+}
+
+// collect is `Collect()` for an IFD chain other than the standard one, such
+// as a maker-note that has its own tags. `iiRoot` must be a root identity of
+// the IFD mapping.
+func (ie *IfdEnumerate) collect(iiRoot *exifcommon.IfdIdentity, rootIfdOffset uint32) (index IfdIndex, err error) {
+	startedAt := time.Now()
+
+	defer func() {
+		recordParseCompleted(ParseOperationCollect, ie.FurthestOffset(), startedAt, err)
+	}()
+
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
 	// TODO(dustin): Add MiscellaneousExifData to IfdIndex
 
 	tree := make(map[int]*Ifd)
@@ -1331,7 +1618,7 @@ func (ie *IfdEnumerate) Collect(rootIfdOffset uint32) (index IfdIndex, err error
 
 	queue := []QueuedIfd{
 		{
-			IfdIdentity: exifcommon.IfdStandardIfdIdentity,
+			IfdIdentity: iiRoot,
 			Offset:      rootIfdOffset,
 		},
 	}
@@ -1372,6 +1659,23 @@ func (ie *IfdEnumerate) Collect(rootIfdOffset uint32) (index IfdIndex, err error
 			ie.furthestOffset = currentOffset
 		}
 
+		hasThumbnailStrips := false
+		if thumbnailData == nil && ii.String() == ThumbnailFqIfdPath && ie.skipThumbnail == false {
+			ts, found, err := findThumbnailStrips(entries)
+			if err != nil {
+				ifdEnumerateLogger.Errorf(nil, err, "Could not read the uncompressed thumbnail tags.")
+			} else if found == true && ts.Size() > 0 {
+				hasThumbnailStrips = true
+
+				for i, offset := range ts.offsets {
+					furthestOffset := uint64(offset) + uint64(ts.byteCounts[i])
+					if furthestOffset <= math.MaxUint32 && uint32(furthestOffset) > ie.furthestOffset {
+						ie.furthestOffset = uint32(furthestOffset)
+					}
+				}
+			}
+		}
+
 		id := len(ifds)
 
 		entriesByTagId := make(map[uint16][]*IfdTagEntry)
@@ -1403,8 +1707,9 @@ func (ie *IfdEnumerate) Collect(rootIfdOffset uint32) (index IfdIndex, err error
 			// This is populated as each child is processed.
 			children: make([]*Ifd, 0),
 
-			nextIfdOffset: nextIfdOffset,
-			thumbnailData: thumbnailData,
+			nextIfdOffset:      nextIfdOffset,
+			thumbnailData:      thumbnailData,
+			hasThumbnailStrips: hasThumbnailStrips,
 
 			ifdMapping: ie.ifdMapping,
 			tagIndex:   ie.tagIndex,
@@ -1481,6 +1786,12 @@ func (ie *IfdEnumerate) Collect(rootIfdOffset uint32) (index IfdIndex, err error
 	index.Tree = tree
 	index.Lookup = lookup
 
+	// Only the gap before the standard IFD0 is worth keeping.
+	captureGap := iiRoot.Equals(exifcommon.IfdStandardIfdIdentity)
+
+	index.Header, err = readHeaderLayout(ie.ebs, ie.byteOrder, rootIfdOffset, captureGap)
+	log.PanicIf(err)
+
 	err = ie.setChildrenIndex(index.RootIfd)
 	log.PanicIf(err)
 
@@ -1606,7 +1917,7 @@ func parseOneTag(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ii *exif
 }
 
 // FindIfdFromRootIfd returns the given `Ifd` given the root-IFD and path of the
-// desired IFD.
+// desired IFD. `ErrIfdNotFound` is returned if the IFD is not present.
 func FindIfdFromRootIfd(rootIfd *Ifd, ifdPath string) (ifd *Ifd, err error) {
 	defer func() {
 		if state := recover(); state != nil {
@@ -1636,7 +1947,8 @@ func FindIfdFromRootIfd(rootIfd *Ifd, ifdPath string) (ifd *Ifd, err error) {
 	thisIfd := rootIfd
 	for currentRootIndex := 0; currentRootIndex < desiredRootIndex; currentRootIndex++ {
 		if thisIfd.nextIfd == nil {
-			log.Panicf("Root-IFD index (%d) does not exist in the data.", currentRootIndex)
+			ifdEnumerateLogger.Debugf(nil, "Root-IFD index (%d) does not exist in the data.", currentRootIndex+1)
+			log.Panic(ErrIfdNotFound)
 		}
 
 		thisIfd = thisIfd.nextIfd
@@ -1651,17 +1963,17 @@ func FindIfdFromRootIfd(rootIfd *Ifd, ifdPath string) (ifd *Ifd, err error) {
 			}
 		}
 
-		// If we didn't find the child, add it.
 		if hit == nil {
-			log.Panicf("IFD [%s] in [%s] not found: %s", itii.Name, ifdPath, thisIfd.children)
+			ifdEnumerateLogger.Debugf(nil, "IFD [%s] in [%s] not found: %s", itii.Name, ifdPath, thisIfd.children)
+			log.Panic(ErrIfdNotFound)
 		}
 
 		thisIfd = hit
 
-		// If we didn't find the sibling, add it.
 		for i := 0; i < itii.Index; i++ {
 			if thisIfd.nextIfd == nil {
-				log.Panicf("IFD [%s] does not have (%d) occurrences/siblings", thisIfd.ifdIdentity.UnindexedString(), itii.Index)
+				ifdEnumerateLogger.Debugf(nil, "IFD [%s] does not have (%d) occurrences/siblings", thisIfd.ifdIdentity.UnindexedString(), itii.Index)
+				log.Panic(ErrIfdNotFound)
 			}
 
 			thisIfd = thisIfd.nextIfd
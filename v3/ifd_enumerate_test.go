@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
@@ -5,6 +8,7 @@ import (
 	"fmt"
 	"path"
 	"reflect"
+	"strings"
 	"testing"
 
 	"io/ioutil"
@@ -557,3 +561,389 @@ func ExampleIfd_FindTagWithName() {
 	// Output:
 	// Canon EOS 5D Mark III
 }
+
+func TestFindIfdFromRootIfd__Missing(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	_, err = FindIfdFromRootIfd(index.RootIfd, "IFD/Exif")
+	if err == nil {
+		t.Fatalf("Expected error for missing Exif IFD.")
+	} else if log.Is(err, ErrIfdNotFound) == false {
+		log.Panic(err)
+	}
+
+	_, err = FindIfdFromRootIfd(index.RootIfd, "IFD1")
+	if err == nil {
+		t.Fatalf("Expected error for missing IFD1.")
+	} else if log.Is(err, ErrIfdNotFound) == false {
+		log.Panic(err)
+	}
+}
+
+func TestIfd_DebugString(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	lines := strings.Split(index.RootIfd.DebugString(4), "\n")
+
+	if len(lines) != len(index.RootIfd.Entries())+1 {
+		t.Fatalf("Line count not correct: (%d)", len(lines))
+	} else if lines[0] != index.RootIfd.String() {
+		t.Fatalf("First line not correct: [%s]", lines[0])
+	}
+
+	expected := "  IfdTagEntry<TAG-IFD-PATH=[IFD] TAG-ID=(0x000b) TAG-NAME=[ProcessingSoftware] TAG-TYPE=[ASCII] UNIT-COUNT=(11) VALUE=[asci...(6 more)]>"
+	if lines[1] != expected {
+		t.Fatalf("Tag line not correct:\nACTUAL: %s\nEXPECTED: %s", lines[1], expected)
+	}
+}
+
+func TestCollect__EmptyIfds(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	// An empty IFD0 with an empty EXIF IFD, followed by a non-empty IFD1.
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	_, err = GetOrCreateIbFromRootIb(ib, "IFD/Exif")
+	log.PanicIf(err)
+
+	nextIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = nextIb.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	err = ib.SetNextIb(nextIb)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	if len(index.Ifds) != 3 {
+		t.Fatalf("Expected three IFDs: (%d)", len(index.Ifds))
+	}
+
+	exifIfd, found := index.Lookup["IFD/Exif"]
+	if found == false {
+		t.Fatalf("Empty EXIF IFD not found.")
+	} else if len(exifIfd.DumpTags()) != 0 {
+		t.Fatalf("EXIF IFD not empty.")
+	}
+
+	ifd1 := index.RootIfd.NextIfd()
+	if ifd1 == nil {
+		t.Fatalf("IFD1 not found.")
+	}
+
+	_, err = ifd1.FindTagWithName("ProcessingSoftware")
+	log.PanicIf(err)
+
+	// Make sure that the empty IFDs survive a round-trip.
+
+	reencodedIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	reencodedExifData, err := NewIfdByteEncoder().EncodeToExif(reencodedIb)
+	log.PanicIf(err)
+
+	if bytes.Equal(reencodedExifData, exifData) != true {
+		t.Fatalf("Re-encoded EXIF not correct:\nACTUAL: %x\nEXPECTED: %x", reencodedExifData, exifData)
+	}
+}
+
+func TestCollect__MissingTrailingNextIfdOffset(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	// A header followed by an empty IFD whose next-IFD offset is missing
+	// entirely.
+	exifData := []byte{
+		0x4d, 0x4d, 0x00, 0x2a, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x00,
+	}
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	if len(index.Ifds) != 1 {
+		t.Fatalf("Expected one IFD: (%d)", len(index.Ifds))
+	} else if len(index.RootIfd.DumpTags()) != 0 {
+		t.Fatalf("Root IFD not empty.")
+	} else if index.RootIfd.NextIfd() != nil {
+		t.Fatalf("Root IFD should not have a next IFD.")
+	}
+}
+
+func TestIfdEnumerate_Scan_Stop(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	visited := 0
+
+	visitor := func(ite *IfdTagEntry) error {
+		visited++
+
+		if visited == 3 {
+			return ErrStopScan
+		}
+
+		return nil
+	}
+
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, rawExif, visitor, nil)
+	log.PanicIf(err)
+
+	if visited != 3 {
+		t.Fatalf("Scan did not stop: (%d)", visited)
+	}
+}
+
+func TestIfdEnumerate_SetSkipThumbnail_SetSkipMakerNote(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	eh, err := ParseExifHeader(rawExif)
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeekerWithBytes(rawExif)
+	ie := NewIfdEnumerate(im, ti, ebs, eh.ByteOrder)
+
+	ie.SetSkipThumbnail(true)
+	ie.SetSkipMakerNote(true)
+
+	index, err := ie.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.nextIfd.Thumbnail()
+	if err != ErrNoThumbnail {
+		t.Fatalf("Expected no thumbnail: %v", err)
+	}
+
+	exifIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdExifStandardIfdIdentity)
+	log.PanicIf(err)
+
+	_, err = exifIfd.FindTagWithId(MakerNoteTagId)
+	if log.Is(err, ErrTagNotFound) != true {
+		t.Fatalf("Expected maker-note to be skipped: %v", err)
+	}
+
+	_, err = exifIfd.FindTagWithName("ExposureTime")
+	log.PanicIf(err)
+
+	// The skipped thumbnail is still accounted for.
+
+	fullIe := NewIfdEnumerate(im, ti, ebs, eh.ByteOrder)
+
+	_, err = fullIe.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	if ie.FurthestOffset() != fullIe.FurthestOffset() {
+		t.Fatalf("Furthest offset not correct: (%d) != (%d)", ie.FurthestOffset(), fullIe.FurthestOffset())
+	}
+}
+
+func TestIfdEnumerate_Scan_SkipMakerNote(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	visitor := func(ite *IfdTagEntry) error {
+		if ite.TagId() == MakerNoteTagId {
+			t.Fatalf("Maker-note was visited.")
+		}
+
+		return nil
+	}
+
+	so := &ScanOptions{
+		SkipThumbnail: true,
+		SkipMakerNote: true,
+	}
+
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, rawExif, visitor, so)
+	log.PanicIf(err)
+}
+
+func TestIfdEnumerate_Scan_OptionsNotKept(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	eh, err := ParseExifHeader(rawExif)
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeekerWithBytes(rawExif)
+	ie := NewIfdEnumerate(im, ti, ebs, eh.ByteOrder)
+
+	visited := false
+	visitor := func(ite *IfdTagEntry) error {
+		if ite.TagId() == MakerNoteTagId {
+			visited = true
+		}
+
+		return nil
+	}
+
+	so := &ScanOptions{
+		SkipMakerNote: true,
+	}
+
+	_, err = ie.Scan(exifcommon.IfdStandardIfdIdentity, eh.FirstIfdOffset, visitor, so)
+	log.PanicIf(err)
+
+	if visited != false {
+		t.Fatalf("Maker-note was visited.")
+	}
+
+	// A later scan without the options sees the maker-note again.
+
+	_, err = ie.Scan(exifcommon.IfdStandardIfdIdentity, eh.FirstIfdOffset, visitor, nil)
+	log.PanicIf(err)
+
+	if visited != true {
+		t.Fatalf("Maker-note was not visited.")
+	}
+}
+
+// getHugeAsciiTestExif returns EXIF whose 0x000b ASCII tag declares a
+// unit-count far larger than the data.
+func getHugeAsciiTestExif() []byte {
+	exifData := getExifSimpleTestIbBytes()
+
+	eh, err := ParseExifHeader(exifData)
+	log.PanicIf(err)
+
+	tableOffset := ExifAddressableAreaStart + eh.FirstIfdOffset
+	tagCount := eh.ByteOrder.Uint16(exifData[tableOffset:])
+
+	for i := uint32(0); i < uint32(tagCount); i++ {
+		entryOffset := tableOffset + 2 + i*12
+
+		if eh.ByteOrder.Uint16(exifData[entryOffset:]) == 0x000b {
+			eh.ByteOrder.PutUint32(exifData[entryOffset+4:], 0xffffffff)
+			return exifData
+		}
+	}
+
+	log.Panicf("ascii tag not found")
+	return nil
+}
+
+func TestIfdEnumerate_Scan_MaxStringLength(t *testing.T) {
+	exifData := getHugeAsciiTestExif()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	var asciiIte *IfdTagEntry
+
+	visitor := func(ite *IfdTagEntry) error {
+		if ite.TagId() == 0x000b {
+			asciiIte = ite
+		} else if ite.IsTruncated() == true {
+			t.Fatalf("Tag truncated unexpectedly: %s", ite)
+		}
+
+		return nil
+	}
+
+	so := &ScanOptions{
+		MaxStringLength: 5,
+	}
+
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, exifData, visitor, so)
+	log.PanicIf(err)
+
+	if asciiIte.IsTruncated() != true {
+		t.Fatalf("Tag not truncated.")
+	} else if asciiIte.UnitCount() != 5 {
+		t.Fatalf("Unit-count not correct: (%d)", asciiIte.UnitCount())
+	} else if asciiIte.DeclaredUnitCount() != 0xffffffff {
+		t.Fatalf("Declared unit-count not correct: (%d)", asciiIte.DeclaredUnitCount())
+	}
+
+	value, err := asciiIte.Value()
+	log.PanicIf(err)
+
+	if value != "ascii" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
+func TestIfdEnumerate_SetMaxStringLength_Minimum(t *testing.T) {
+	ie := NewIfdEnumerate(nil, nil, nil, nil)
+
+	ie.SetMaxStringLength(2)
+
+	if ie.maxStringLength != 5 {
+		t.Fatalf("Limit not raised: (%d)", ie.maxStringLength)
+	}
+}
+
+func TestIfdEnumerate_Scan_NoMaxStringLength(t *testing.T) {
+	exifData := getHugeAsciiTestExif()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(0x000b)
+	log.PanicIf(err)
+
+	if results[0].IsTruncated() != false {
+		t.Fatalf("Tag truncated without a limit.")
+	}
+
+	_, err = results[0].Value()
+	if err == nil {
+		t.Fatalf("Expected error reading the whole value.")
+	}
+}
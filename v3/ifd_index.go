@@ -0,0 +1,225 @@
+package exif
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+// TypedValue is one result from `GetTags()`.
+type TypedValue struct {
+	// Ite is the tag that the value was read from. It is nil if the tag was
+	// not found or if the value was computed.
+	Ite *IfdTagEntry
+
+	// Computed is the computed tag that the value came from if no stored tag
+	// had the name (see `ComputedTagProvider`).
+	Computed *ComputedTag
+
+	// Value is the decoded value along with its type. It's the zero
+	// TagValue if `Err` is not nil.
+	Value TagValue
+
+	// Err is `ErrTagNotFound` if the tag was not found anywhere in the tree,
+	// `ErrTagNotKnown` if a qualified name refers to a tag that the IFD does
+	// not support, or the error that prevented the value from being decoded.
+	Err error
+}
+
+// Found returns true if the tag was found, whether or not its value could be
+// decoded.
+func (tv TypedValue) Found() bool {
+	return tv.Ite != nil || tv.Computed != nil
+}
+
+// String returns a descriptive string.
+func (tv TypedValue) String() string {
+	if tv.Err != nil {
+		return fmt.Sprintf("TypedValue<ERROR=[%v]>", tv.Err)
+	} else if tv.Computed != nil {
+		return fmt.Sprintf("TypedValue<IFD-PATH=[%s] COMPUTED=[%s] VALUE=[%v]>", tv.Computed.IfdPath, tv.Computed.Name, tv.Value.Interface())
+	}
+
+	return fmt.Sprintf("TypedValue<IFD-PATH=[%s] TAG-ID=(0x%04x) TYPE=[%s] VALUE=[%v]>", tv.Ite.IfdPath(), tv.Ite.TagId(), tv.Value.Kind(), tv.Value.Interface())
+}
+
+// GetTags resolves several tags by name at once. Each name is either a bare
+// tag-name (e.g. "DateTimeOriginal"), which matches the first occurrence in
+// any IFD in the order that the IFDs were parsed, or a name qualified with a
+// fully-qualified IFD-path (e.g. "IFD1/ImageWidth"). Every name is present in
+// the result, and a name that couldn't be resolved has `Err` set rather than
+// failing the whole call. Each IFD's tags are visited only once regardless of
+// how many names are requested. Names that aren't found among the stored
+// tags are then looked-up among the computed tags.
+func (index IfdIndex) GetTags(names ...string) (results map[string]TypedValue, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results = make(map[string]TypedValue, len(names))
+
+	// Group the pending names by the IFD that they're qualified with. Bare
+	// names are stored under the empty path.
+	pending := make(map[string]map[string][]string)
+	for _, name := range names {
+		fqIfdPath := ""
+		tagName := name
+
+		if pivot := strings.LastIndex(name, "/"); pivot != -1 {
+			fqIfdPath = name[:pivot]
+			tagName = name[pivot+1:]
+		}
+
+		byTagName, found := pending[fqIfdPath]
+		if found == false {
+			byTagName = make(map[string][]string)
+			pending[fqIfdPath] = byTagName
+		}
+
+		byTagName[tagName] = append(byTagName[tagName], name)
+
+		results[name] = TypedValue{
+			Err: ErrTagNotFound,
+		}
+	}
+
+	for _, ifd := range index.Ifds {
+		if len(pending) == 0 {
+			break
+		}
+
+		fqIfdPath := ifd.ifdIdentity.String()
+		qualified := pending[fqIfdPath]
+		bare := pending[""]
+
+		if len(qualified) == 0 && len(bare) == 0 {
+			continue
+		}
+
+		for _, ite := range ifd.entries {
+			tagName := ite.TagName()
+			if tagName == "" {
+				continue
+			}
+
+			if fullNames, found := qualified[tagName]; found == true {
+				tv := resolveTypedValue(ite)
+				for _, name := range fullNames {
+					results[name] = tv
+				}
+
+				delete(qualified, tagName)
+			}
+
+			if fullNames, found := bare[tagName]; found == true {
+				tv := resolveTypedValue(ite)
+				for _, name := range fullNames {
+					results[name] = tv
+				}
+
+				delete(bare, tagName)
+			}
+		}
+
+		if qualified != nil && len(qualified) == 0 {
+			delete(pending, fqIfdPath)
+		}
+
+		if bare != nil && len(bare) == 0 {
+			delete(pending, "")
+		}
+	}
+
+	if len(pending) > 0 {
+		computed, err := index.ComputedTags()
+		log.PanicIf(err)
+
+		for i := range computed {
+			ct := &computed[i]
+
+			for _, fqIfdPath := range []string{ct.IfdPath, ""} {
+				byTagName := pending[fqIfdPath]
+
+				fullNames, found := byTagName[ct.Name]
+				if found == false {
+					continue
+				}
+
+				tv := TypedValue{
+					Computed: ct,
+				}
+
+				tv.Value, tv.Err = NewTagValue(ct.Value)
+
+				for _, name := range fullNames {
+					results[name] = tv
+				}
+
+				delete(byTagName, ct.Name)
+				if len(byTagName) == 0 {
+					delete(pending, fqIfdPath)
+				}
+			}
+		}
+	}
+
+	// Distinguish names that can never be found in a given IFD from names
+	// that just weren't present.
+	for fqIfdPath, byTagName := range pending {
+		if fqIfdPath == "" {
+			continue
+		}
+
+		ifd, found := index.Lookup[fqIfdPath]
+		if found == false {
+			continue
+		}
+
+		for tagName, fullNames := range byTagName {
+			_, err := ifd.tagIndex.GetWithName(ifd.ifdIdentity, tagName)
+			if log.Is(err, ErrTagNotFound) == false {
+				log.PanicIf(err)
+				continue
+			}
+
+			for _, name := range fullNames {
+				results[name] = TypedValue{
+					Err: ErrTagNotKnown,
+				}
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// resolveTypedValue decodes the value of the given tag, capturing any error.
+func resolveTypedValue(ite *IfdTagEntry) TypedValue {
+	tv := TypedValue{
+		Ite: ite,
+	}
+
+	value, err := ite.Value()
+	if err != nil {
+		if err == exifcommon.ErrUnhandledUndefinedTypedTag || err == exifundefined.ErrUnparseableValue {
+			tv.Err = err
+		} else {
+			tv.Err = log.Wrap(err)
+		}
+
+		return tv
+	}
+
+	tv.Value = TagValue{
+		kind:  ite.TagType(),
+		value: value,
+	}
+
+	return tv
+}
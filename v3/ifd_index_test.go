@@ -0,0 +1,81 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestIfdIndex_GetTags(t *testing.T) {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	results, err := index.GetTags(
+		"Model",
+		"DateTimeOriginal",
+		"IFD1/XResolution",
+		"GPSLatitude",
+		"IFD/Exif/Model")
+
+	log.PanicIf(err)
+
+	if len(results) != 5 {
+		t.Fatalf("Result count not correct: (%d)", len(results))
+	}
+
+	tv := results["Model"]
+	if tv.Err != nil {
+		log.Panic(tv.Err)
+	} else if tv.Ite.IfdPath() != "IFD" {
+		t.Fatalf("Model found in wrong IFD: [%s]", tv.Ite.IfdPath())
+	} else if tv.Value.Kind() != exifcommon.TypeAscii {
+		t.Fatalf("Model type not correct: [%s]", tv.Value.Kind())
+	} else if tv.Value.Ascii() != "Canon EOS 5D Mark III" {
+		t.Fatalf("Model value not correct: [%v]", tv.Value)
+	}
+
+	tv = results["DateTimeOriginal"]
+	if tv.Err != nil {
+		log.Panic(tv.Err)
+	} else if tv.Ite.IfdPath() != "IFD/Exif" {
+		t.Fatalf("DateTimeOriginal found in wrong IFD: [%s]", tv.Ite.IfdPath())
+	} else if tv.Value.Ascii() != "2017:12:02 08:18:50" {
+		t.Fatalf("DateTimeOriginal value not correct: [%v]", tv.Value)
+	}
+
+	tv = results["IFD1/XResolution"]
+	if tv.Err != nil {
+		log.Panic(tv.Err)
+	} else if tv.Ite.IfdPath() != "IFD1" {
+		t.Fatalf("XResolution found in wrong IFD: [%s]", tv.Ite.IfdPath())
+	}
+
+	tv = results["GPSLatitude"]
+	if tv.Found() != false {
+		t.Fatalf("GPSLatitude should not have been found.")
+	} else if tv.Err != ErrTagNotFound {
+		t.Fatalf("GPSLatitude error not correct: [%v]", tv.Err)
+	}
+
+	tv = results["IFD/Exif/Model"]
+	if tv.Found() != false {
+		t.Fatalf("Model should not have been found in the Exif IFD.")
+	} else if tv.Err != ErrTagNotKnown {
+		t.Fatalf("Qualified Model error not correct: [%v]", tv.Err)
+	}
+}
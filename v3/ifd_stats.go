@@ -0,0 +1,80 @@
+package exif
+
+import (
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// walkChain calls the given function for this IFD, the IFDs that follow it in
+// its chain, and all of their children, recursively.
+func (ifd *Ifd) walkChain(fn func(ifd *Ifd)) {
+	for ptr := ifd; ptr != nil; ptr = ptr.nextIfd {
+		fn(ptr)
+
+		for _, childIfd := range ptr.children {
+			childIfd.walkChain(fn)
+		}
+	}
+}
+
+// CountIfds returns the number of IFDs in the chain starting at this IFD,
+// including all of their children. On the root IFD, this is every IFD that
+// was parsed.
+func (ifd *Ifd) CountIfds() int {
+	count := 0
+
+	ifd.walkChain(func(ifd *Ifd) {
+		count++
+	})
+
+	return count
+}
+
+// CountTags returns the number of tags in the chain starting at this IFD,
+// including all of their children. The tags that point to child IFDs are
+// counted, too, since they take up entries like any other tag.
+func (ifd *Ifd) CountTags() int {
+	count := 0
+
+	ifd.walkChain(func(ifd *Ifd) {
+		count += len(ifd.entries)
+	})
+
+	return count
+}
+
+// TotalDataSize returns the number of bytes taken up by the chain starting at
+// this IFD, including all of their children: the IFD tables, the values too
+// large to fit in their entries, and the thumbnail. This is about what
+// encoding the same tags would require, before any alignment or padding, so
+// it's useful for checking against the size limit of an APP1 segment.
+func (ifd *Ifd) TotalDataSize() int64 {
+	size := int64(0)
+
+	ifd.walkChain(func(ifd *Ifd) {
+		size += int64(2) + int64(IfdTagEntrySize)*int64(len(ifd.entries)) + int64(4)
+
+		for _, ite := range ifd.entries {
+			// The units of UNDEFINED values are bytes.
+			unitSize := int64(1)
+			if ite.tagType != exifcommon.TypeUndefined {
+				unitSize = int64(ite.tagType.Size())
+			}
+
+			valueSize := int64(ite.unitCount) * unitSize
+			if valueSize > 4 {
+				size += valueSize
+			}
+		}
+
+		size += int64(len(ifd.thumbnailData))
+
+		if ifd.hasThumbnailStrips == true {
+			ts, _, err := findThumbnailStrips(ifd.entries)
+			if err == nil {
+				size += int64(ts.Size())
+			}
+		}
+	})
+
+	return size
+}
@@ -0,0 +1,107 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestIfd_CountIfds(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	if count := index.RootIfd.CountIfds(); count != len(index.Ifds) {
+		t.Fatalf("IFD count not correct: (%d) != (%d)", count, len(index.Ifds))
+	}
+
+	// Only the IFD and its children.
+
+	exifIfd := index.Lookup["IFD/Exif"]
+
+	if count := exifIfd.CountIfds(); count != 1+len(exifIfd.Children()) {
+		t.Fatalf("Exif IFD count not correct: (%d)", count)
+	}
+}
+
+func TestIfd_CountTags(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	expected := 0
+	for _, ifd := range index.Ifds {
+		expected += len(ifd.Entries())
+	}
+
+	if count := index.RootIfd.CountTags(); count != expected {
+		t.Fatalf("Tag count not correct: (%d) != (%d)", count, expected)
+	}
+}
+
+func TestIfd_TotalDataSize(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	// Everything after the header is tables and values.
+
+	expected := int64(len(exifData)) - int64(ExifDefaultFirstIfdOffset)
+
+	if size := index.RootIfd.TotalDataSize(); size != expected {
+		t.Fatalf("Total data size not correct: (%d) != (%d)", size, expected)
+	}
+}
+
+func TestIfd_TotalDataSize__Thumbnail(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	thumbnailIfd := index.RootIfd.NextIfd()
+
+	thumbnailData, err := thumbnailIfd.Thumbnail()
+	log.PanicIf(err)
+
+	tableSize := int64(2) + int64(IfdTagEntrySize)*int64(len(thumbnailIfd.Entries())) + int64(4)
+
+	if size := thumbnailIfd.TotalDataSize(); size < tableSize+int64(len(thumbnailData)) {
+		t.Fatalf("Thumbnail not accounted for: (%d)", size)
+	}
+
+	if size := index.RootIfd.TotalDataSize(); size > int64(len(rawExif)) {
+		t.Fatalf("Total data size larger than the EXIF: (%d) > (%d)", size, len(rawExif))
+	}
+}
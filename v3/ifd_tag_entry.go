@@ -1,10 +1,12 @@
 package exif
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
 	"encoding/binary"
+	"unicode/utf8"
 
 	"github.com/dsoprea/go-logging"
 
@@ -16,6 +18,37 @@ var (
 	iteLogger = log.NewLogger("exif.ifd_tag_entry")
 )
 
+// ValueStorage describes where the value of a tag is stored.
+type ValueStorage int
+
+const (
+	// ValueStorageInline means that the value fits in the four bytes of the
+	// entry and is stored there.
+	ValueStorageInline ValueStorage = iota
+
+	// ValueStorageOffset means that the value is stored elsewhere in the EXIF
+	// data and the entry has its offset.
+	ValueStorageOffset
+
+	// ValueStorageChildIfd means that the entry has the offset of a child IFD
+	// rather than a value.
+	ValueStorageChildIfd
+)
+
+// String returns the name of the storage.
+func (vs ValueStorage) String() string {
+	switch vs {
+	case ValueStorageInline:
+		return "inline"
+	case ValueStorageOffset:
+		return "offset"
+	case ValueStorageChildIfd:
+		return "child-ifd"
+	}
+
+	return "unknown"
+}
+
 // IfdTagEntry refers to a tag in the loaded EXIF block.
 type IfdTagEntry struct {
 	tagId          uint16
@@ -25,6 +58,10 @@ type IfdTagEntry struct {
 	valueOffset    uint32
 	rawValueOffset []byte
 
+	// data is the EXIF block that the value offset is relative to, if it is
+	// held in memory.
+	data []byte
+
 	// childIfdName is the right most atom in the IFD-path. We need this to
 	// construct the fully-qualified IFD-path.
 	childIfdName string
@@ -43,6 +80,11 @@ type IfdTagEntry struct {
 
 	isUnhandledUnknown bool
 
+	// declaredUnitCount is the unit-count as stored, if the value was
+	// truncated to `unitCount`.
+	declaredUnitCount uint32
+	isTruncated       bool
+
 	rs        io.ReadSeeker
 	byteOrder binary.ByteOrder
 
@@ -68,6 +110,45 @@ func (ite *IfdTagEntry) String() string {
 	return fmt.Sprintf("IfdTagEntry<TAG-IFD-PATH=[%s] TAG-ID=(0x%04x) TAG-TYPE=[%s] UNIT-COUNT=(%d)>", ite.ifdIdentity.String(), ite.tagId, ite.tagType.String(), ite.unitCount)
 }
 
+// DebugString returns a more verbose representation than String() that also
+// includes the tag-name and the formatted value. If `maxValueLength` is
+// greater than zero, the value is truncated to at most that many bytes. This
+// never fails; any problem decoding the value is described in its place.
+func (ite *IfdTagEntry) DebugString(maxValueLength int) string {
+	var valuePhrase string
+
+	if ite.childIfdPath != "" {
+		valuePhrase = fmt.Sprintf("CHILD<%s>", ite.childFqIfdPath)
+	} else {
+		var err error
+
+		valuePhrase, err = ite.Format()
+		if err != nil {
+			valuePhrase = fmt.Sprintf("!ERROR<%s>", err.Error())
+		}
+	}
+
+	valuePhrase = truncateDebugPhrase(valuePhrase, maxValueLength)
+
+	return fmt.Sprintf("IfdTagEntry<TAG-IFD-PATH=[%s] TAG-ID=(0x%04x) TAG-NAME=[%s] TAG-TYPE=[%s] UNIT-COUNT=(%d) VALUE=[%s]>", ite.ifdIdentity.String(), ite.tagId, ite.tagName, ite.tagType.String(), ite.unitCount, valuePhrase)
+}
+
+// truncateDebugPhrase caps the length of a phrase for logging, indicating how
+// much was dropped. The cut backs up to the start of a character so that a
+// multibyte character is never split.
+func truncateDebugPhrase(phrase string, maxLength int) string {
+	if maxLength <= 0 || len(phrase) <= maxLength {
+		return phrase
+	}
+
+	cut := maxLength
+	for cut > 0 && utf8.RuneStart(phrase[cut]) == false {
+		cut--
+	}
+
+	return fmt.Sprintf("%s...(%d more)", phrase[:cut], len(phrase)-cut)
+}
+
 // TagName returns the name of the tag. This is determined else and set after
 // the parse (since it's not actually stored in the stream). If it's empty, it
 // is because it is an unknown tag (nonstandard or otherwise unavailable in the
@@ -126,6 +207,30 @@ func (ite *IfdTagEntry) updateUnitCount(unitCount uint32) {
 	ite.unitCount = unitCount
 }
 
+// truncate limits the value to its first `unitCount` units.
+func (ite *IfdTagEntry) truncate(unitCount uint32) {
+	ite.declaredUnitCount = ite.unitCount
+	ite.unitCount = unitCount
+	ite.isTruncated = true
+}
+
+// IsTruncated returns true if the value was longer than the maximum string
+// length and only the start of it is read (see
+// `IfdEnumerate.SetMaxStringLength()`).
+func (ite *IfdTagEntry) IsTruncated() bool {
+	return ite.isTruncated
+}
+
+// DeclaredUnitCount returns the unit-count as it is stored, which is more than
+// `UnitCount()` if the value was truncated.
+func (ite *IfdTagEntry) DeclaredUnitCount() uint32 {
+	if ite.isTruncated == true {
+		return ite.declaredUnitCount
+	}
+
+	return ite.unitCount
+}
+
 // getValueOffset is the four-byte offset converted to an integer to point to
 // the location of its value in the EXIF block. The "get" parameter is obviously
 // used in order to differentiate the naming of the method from the field.
@@ -133,6 +238,62 @@ func (ite *IfdTagEntry) getValueOffset() uint32 {
 	return ite.valueOffset
 }
 
+// valueRange returns the offset (relative to the EXIF) and the size of the
+// value, and whether it's embedded in the tag entry rather than stored there.
+func (ite *IfdTagEntry) valueRange() (offset uint32, size int64, isEmbedded bool) {
+	unitSize := int64(1)
+	if ite.tagType != exifcommon.TypeUndefined {
+		unitSize = int64(ite.tagType.Size())
+	}
+
+	size = unitSize * int64(ite.unitCount)
+
+	return ite.valueOffset, size, size <= 4
+}
+
+// storedSize returns the size of the value as it is stored, which is larger
+// than what is read if the value was truncated.
+func (ite *IfdTagEntry) storedSize() int64 {
+	unitSize := int64(1)
+	if ite.tagType != exifcommon.TypeUndefined {
+		unitSize = int64(ite.tagType.Size())
+	}
+
+	return unitSize * int64(ite.DeclaredUnitCount())
+}
+
+// IsInline returns true if the value is stored in the entry itself rather
+// than at an offset. The offset of a child IFD is not considered a value.
+func (ite *IfdTagEntry) IsInline() bool {
+	return ite.ValueStorage() == ValueStorageInline
+}
+
+// ValueStorage returns where the value is stored.
+func (ite *IfdTagEntry) ValueStorage() ValueStorage {
+	if ite.childIfdPath != "" {
+		return ValueStorageChildIfd
+	} else if ite.storedSize() <= 4 {
+		return ValueStorageInline
+	}
+
+	return ValueStorageOffset
+}
+
+// ValueCapacity returns the number of bytes that a new value can occupy
+// without being moved: the four bytes of the entry for an inline value, or
+// the size of the current value for one at an offset. A longer value can't be
+// patched in place. It is zero for the offset of a child IFD.
+func (ite *IfdTagEntry) ValueCapacity() int64 {
+	switch ite.ValueStorage() {
+	case ValueStorageInline:
+		return 4
+	case ValueStorageOffset:
+		return ite.storedSize()
+	}
+
+	return 0
+}
+
 // GetRawBytes renders a specific list of bytes from the value in this tag.
 func (ite *IfdTagEntry) GetRawBytes() (rawBytes []byte, err error) {
 	defer func() {
@@ -171,6 +332,37 @@ func (ite *IfdTagEntry) GetRawBytes() (rawBytes []byte, err error) {
 	return rawBytes, nil
 }
 
+// ValueBytesNoCopy returns the bytes of the value, as stored, without copying
+// them. They are shared with the EXIF data and must not be modified; see
+// `exifcommon.ValueContext.ValueBytesNoCopy()`. Unlike `GetRawBytes()`,
+// UNDEFINED values are returned as stored rather than being decoded and
+// re-encoded. `exifcommon.ErrNoSourceData` is returned if the EXIF was not
+// parsed from memory.
+func (ite *IfdTagEntry) ValueBytesNoCopy() (rawBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	valueContext := ite.getValueContext()
+
+	if ite.tagType == exifcommon.TypeUndefined {
+		valueContext.SetUndefinedValueType(exifcommon.TypeByte)
+	}
+
+	rawBytes, err = valueContext.ValueBytesNoCopy()
+	if err != nil {
+		if err == exifcommon.ErrNoSourceData {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return rawBytes, nil
+}
+
 // Value returns the specific, parsed, typed value from the tag.
 func (ite *IfdTagEntry) Value() (value interface{}, err error) {
 	defer func() {
@@ -202,6 +394,26 @@ func (ite *IfdTagEntry) Value() (value interface{}, err error) {
 	return value, nil
 }
 
+// AsciiList returns the strings of an ASCII tag. Unlike `Value()`, which
+// returns one string, this splits a value that holds several NUL-terminated
+// strings.
+func (ite *IfdTagEntry) AsciiList() (value []string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ite.tagType != exifcommon.TypeAscii {
+		log.Panic(exifcommon.ErrWrongType)
+	}
+
+	value, err = ite.getValueContext().ReadAsciiList()
+	log.PanicIf(err)
+
+	return value, nil
+}
+
 // Format returns the tag's value as a string.
 func (ite *IfdTagEntry) Format() (phrase string, err error) {
 	defer func() {
@@ -252,6 +464,98 @@ func (ite *IfdTagEntry) FormatFirst() (phrase string, err error) {
 	return phrase, nil
 }
 
+// ResolvedTag is a tag's value in each of its forms.
+type ResolvedTag struct {
+	// Ite is the tag that was resolved.
+	Ite *IfdTagEntry
+
+	// RawBytes is the encoded value. It's nil if the value could not be
+	// parsed.
+	RawBytes []byte
+
+	// Value is the decoded value along with its type (see `TagValue()`).
+	// It's the zero TagValue if the value could not be parsed.
+	Value TagValue
+
+	// Formatted is the value formatted for display (see `Format()`). If the
+	// value could not be parsed, this is a placeholder.
+	Formatted string
+}
+
+// Resolve reads the value once and returns the raw bytes, the decoded value,
+// and the formatted value together. This is cheaper than calling
+// `GetRawBytes()`, `Value()`, and `Format()` separately, which each read and
+// decode the value again.
+func (ite *IfdTagEntry) Resolve() (rt ResolvedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rt.Ite = ite
+
+	valueContext := ite.getValueContext()
+
+	if ite.tagType == exifcommon.TypeUndefined {
+		value, err := exifundefined.Decode(valueContext)
+		if err != nil {
+			if err == exifcommon.ErrUnhandledUndefinedTypedTag {
+				ite.setIsUnhandledUnknown(true)
+				rt.Formatted = exifundefined.UnparseableUnknownTagValuePlaceholder
+
+				return rt, nil
+			} else if err == exifundefined.ErrUnparseableValue {
+				rt.Formatted = exifundefined.UnparseableHandledTagValuePlaceholder
+
+				return rt, nil
+			}
+
+			log.Panic(err)
+		}
+
+		rt.Value = TagValue{
+			kind:  ite.tagType,
+			value: value,
+		}
+
+		// As with `GetRawBytes()`, encode it back to get the raw bytes.
+		rt.RawBytes, _, err = exifundefined.Encode(value, ite.byteOrder)
+		log.PanicIf(err)
+	} else {
+		rt.RawBytes, err = valueContext.ReadRawEncoded()
+		log.PanicIf(err)
+
+		// Decode from what we just read rather than reading it again.
+
+		rawValueOffset := make([]byte, 4)
+		copy(rawValueOffset, rt.RawBytes)
+
+		rawValueContext := exifcommon.NewValueContext(
+			ite.ifdIdentity.String(),
+			ite.tagId,
+			ite.unitCount,
+			0,
+			rawValueOffset,
+			bytes.NewReader(rt.RawBytes),
+			ite.tagType,
+			ite.byteOrder)
+
+		value, err := rawValueContext.Values()
+		log.PanicIf(err)
+
+		rt.Value = TagValue{
+			kind:  ite.tagType,
+			value: value,
+		}
+	}
+
+	rt.Formatted, err = exifcommon.FormatFromType(rt.Value.Interface(), false)
+	log.PanicIf(err)
+
+	return rt, nil
+}
+
 func (ite *IfdTagEntry) setIsUnhandledUnknown(isUnhandledUnknown bool) {
 	ite.isUnhandledUnknown = isUnhandledUnknown
 }
@@ -286,7 +590,7 @@ func (ite *IfdTagEntry) IfdIdentity() *exifcommon.IfdIdentity {
 }
 
 func (ite *IfdTagEntry) getValueContext() *exifcommon.ValueContext {
-	return exifcommon.NewValueContext(
+	vc := exifcommon.NewValueContext(
 		ite.ifdIdentity.String(),
 		ite.tagId,
 		ite.unitCount,
@@ -295,4 +599,8 @@ func (ite *IfdTagEntry) getValueContext() *exifcommon.ValueContext {
 		ite.rs,
 		ite.tagType,
 		ite.byteOrder)
+
+	vc.SetSourceData(ite.data)
+
+	return vc
 }
@@ -1,13 +1,18 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/dsoprea/go-logging"
 	"github.com/dsoprea/go-utility/v2/filesystem"
 
 	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
 )
 
 func TestIfdTagEntry_RawBytes_Allocated(t *testing.T) {
@@ -66,6 +71,49 @@ func TestIfdTagEntry_RawBytes_Embedded(t *testing.T) {
 	}
 }
 
+func TestIfdTagEntry_ValueBytesNoCopy(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Make")
+	log.PanicIf(err)
+
+	ite := results[0]
+
+	noCopy, err := ite.ValueBytesNoCopy()
+	log.PanicIf(err)
+
+	copied, err := ite.GetRawBytes()
+	log.PanicIf(err)
+
+	if bytes.Equal(noCopy, copied) != true {
+		t.Fatalf("Value not correct: %v != %v", noCopy, copied)
+	}
+
+	// Modifying the copy doesn't affect the shared bytes.
+
+	copied[0] = 0
+
+	if noCopy[0] != 'C' {
+		t.Fatalf("Modifying the copy modified the source data: %v", noCopy)
+	}
+
+	value, err := ite.Value()
+	log.PanicIf(err)
+
+	if value.(string) != "Canon" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
 func TestIfdTagEntry_String(t *testing.T) {
 	ite := newIfdTagEntry(
 		exifcommon.IfdStandardIfdIdentity,
@@ -83,3 +131,211 @@ func TestIfdTagEntry_String(t *testing.T) {
 		t.Fatalf("string representation not expected: [%s] != [%s]", ite.String(), expected)
 	}
 }
+
+func TestIfdTagEntry_DebugString(t *testing.T) {
+	data := []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+
+	sb := rifs.NewSeekableBufferWithBytes(data)
+
+	ite := newIfdTagEntry(
+		exifcommon.IfdStandardIfdIdentity,
+		0x1,
+		0,
+		exifcommon.TypeByte,
+		6,
+		0,
+		nil,
+		sb,
+		exifcommon.TestDefaultByteOrder)
+
+	ite.setTagName("SomeTag")
+
+	expected := "IfdTagEntry<TAG-IFD-PATH=[IFD] TAG-ID=(0x0001) TAG-NAME=[SomeTag] TAG-TYPE=[BYTE] UNIT-COUNT=(6) VALUE=[11 22 33 44 55 66]>"
+	if ite.DebugString(0) != expected {
+		t.Fatalf("Debug string not expected: [%s] != [%s]", ite.DebugString(0), expected)
+	}
+
+	expected = "IfdTagEntry<TAG-IFD-PATH=[IFD] TAG-ID=(0x0001) TAG-NAME=[SomeTag] TAG-TYPE=[BYTE] UNIT-COUNT=(6) VALUE=[11 22...(12 more)]>"
+	if ite.DebugString(5) != expected {
+		t.Fatalf("Truncated debug string not expected: [%s] != [%s]", ite.DebugString(5), expected)
+	}
+}
+
+func TestTruncateDebugPhrase__Multibyte(t *testing.T) {
+	// "é" is two bytes, so a cut at (2) would split it.
+	truncated := truncateDebugPhrase("aéb", 2)
+
+	expected := "a...(3 more)"
+	if truncated != expected {
+		t.Fatalf("Truncated phrase not correct: [%s] != [%s]", truncated, expected)
+	}
+
+	truncated = truncateDebugPhrase("aéb", 3)
+
+	expected = "aé...(1 more)"
+	if truncated != expected {
+		t.Fatalf("Truncated phrase not correct: [%s] != [%s]", truncated, expected)
+	}
+}
+
+func TestIfdTagEntry_Resolve(t *testing.T) {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	for _, ite := range index.RootIfd.DumpTags() {
+		if ite.IsThumbnailOffset() == true {
+			continue
+		}
+
+		rt, err := ite.Resolve()
+		log.PanicIf(err)
+
+		if rt.Ite != ite {
+			t.Fatalf("ITE not set.")
+		}
+
+		formatted, err := ite.Format()
+		log.PanicIf(err)
+
+		if rt.Formatted != formatted {
+			t.Fatalf("Formatted value for %s not correct: [%s] != [%s]", ite, rt.Formatted, formatted)
+		}
+
+		rawBytes, err := ite.GetRawBytes()
+		if err != nil {
+			if log.Is(err, exifundefined.ErrUnparseableValue) == false {
+				log.Panic(err)
+			}
+
+			if rt.RawBytes != nil || rt.Value.IsZero() != true {
+				t.Fatalf("Unparseable value should not be resolved: %s", ite)
+			}
+
+			continue
+		}
+
+		if bytes.Equal(rt.RawBytes, rawBytes) != true {
+			t.Fatalf("Raw bytes for %s not correct.", ite)
+		}
+
+		value, err := ite.Value()
+		log.PanicIf(err)
+
+		if reflect.DeepEqual(rt.Value.Interface(), value) != true {
+			t.Fatalf("Value for %s not correct: %v != %v", ite, rt.Value, value)
+		}
+	}
+}
+
+func TestIfdTagEntry_AsciiList(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	// The copyright legally holds the photographer's and the editor's.
+	err = ib.AddStandardWithName("Copyright", []string{"Photographer", "Editor"})
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("Artist", "Some Person")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Copyright")
+	log.PanicIf(err)
+
+	value, err := results[0].AsciiList()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []string{"Photographer", "Editor"}) != true {
+		t.Fatalf("Value not correct: %q", value)
+	}
+
+	results, err = index.RootIfd.FindTagWithName("Artist")
+	log.PanicIf(err)
+
+	value, err = results[0].AsciiList()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []string{"Some Person"}) != true {
+		t.Fatalf("Single value not correct: %q", value)
+	}
+}
+
+func TestIfdTagEntry_ValueStorage(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, getExifSimpleTestIbBytes())
+	log.PanicIf(err)
+
+	cases := []struct {
+		tagId    uint16
+		storage  ValueStorage
+		capacity int64
+	}{
+		{0x000b, ValueStorageOffset, 11},
+		{0x00ff, ValueStorageInline, 4},
+		{0x0100, ValueStorageInline, 4},
+		{0x013e, ValueStorageOffset, 8},
+	}
+
+	for _, c := range cases {
+		results, err := index.RootIfd.FindTagWithId(c.tagId)
+		log.PanicIf(err)
+
+		ite := results[0]
+
+		if ite.ValueStorage() != c.storage {
+			t.Fatalf("Storage of (0x%04x) not correct: [%s]", c.tagId, ite.ValueStorage())
+		} else if ite.IsInline() != (c.storage == ValueStorageInline) {
+			t.Fatalf("Inline flag of (0x%04x) not correct.", c.tagId)
+		} else if ite.ValueCapacity() != c.capacity {
+			t.Fatalf("Capacity of (0x%04x) not correct: (%d)", c.tagId, ite.ValueCapacity())
+		}
+	}
+}
+
+func TestIfdTagEntry_ValueStorage_ChildIfd(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, getTestExifData())
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(exifcommon.IfdExifStandardIfdIdentity.TagId())
+	log.PanicIf(err)
+
+	ite := results[0]
+
+	if ite.ValueStorage() != ValueStorageChildIfd {
+		t.Fatalf("Storage not correct: [%s]", ite.ValueStorage())
+	} else if ite.IsInline() != false {
+		t.Fatalf("Child IFD reported as inline.")
+	} else if ite.ValueCapacity() != 0 {
+		t.Fatalf("Capacity not correct: (%d)", ite.ValueCapacity())
+	}
+}
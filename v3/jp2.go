@@ -0,0 +1,188 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// jp2MaxExifBoxSize is the most that we'll read for an EXIF box. The
+	// size comes from the file, so it's not trusted to allocate with.
+	jp2MaxExifBoxSize = 64 * 1024 * 1024
+)
+
+var (
+	// jp2Signature is the JPEG 2000 signature box, which must be first.
+	jp2Signature = []byte{0x00, 0x00, 0x00, 0x0c, 'j', 'P', ' ', ' ', 0x0d, 0x0a, 0x87, 0x0a}
+
+	// Jp2ExifUuid is the UUID of the 'uuid' box that JPEG 2000 files store
+	// the EXIF in ("JpgTiffExif->JP2").
+	Jp2ExifUuid = []byte("JpgTiffExif->JP2")
+)
+
+var (
+	// ErrJp2Format means that the JPEG 2000 box structure could not be
+	// parsed.
+	ErrJp2Format = errors.New("jp2 format error")
+)
+
+// IsJp2 returns true if the data starts with the JPEG 2000 (JP2 or JPX)
+// signature box.
+func IsJp2(data []byte) bool {
+	return bytes.HasPrefix(data, jp2Signature)
+}
+
+// ExtractExifFromJp2 returns the EXIF stored in the EXIF 'uuid' box of a JPEG
+// 2000 file. Only the top-level boxes are read, and the codestream is skipped
+// rather than read. Some writers precede the TIFF data with the
+// "Exif\0\0" preamble that JPEG uses; it is not returned. `ErrNoExif` is
+// returned if there is no EXIF box.
+func ExtractExifFromJp2(r io.Reader) (rawExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, _, err = extractExifFromJp2(r)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return rawExif, nil
+}
+
+// extractExifFromJp2 returns the EXIF data and its offset from the start of
+// the file.
+func extractExifFromJp2(r io.Reader) (rawExif []byte, offset int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	signature := make([]byte, len(jp2Signature))
+
+	_, err = io.ReadFull(r, signature)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		log.Panic(ErrJp2Format)
+	}
+
+	log.PanicIf(err)
+
+	if IsJp2(signature) == false {
+		log.Panic(ErrJp2Format)
+	}
+
+	offset = len(jp2Signature)
+
+	header := make([]byte, 16)
+
+	for {
+		_, err := io.ReadFull(r, header[:8])
+		if err == io.EOF {
+			break
+		} else if err == io.ErrUnexpectedEOF {
+			log.Panic(ErrJp2Format)
+		}
+
+		log.PanicIf(err)
+
+		// A size of zero means that the box runs to the end of the file, and
+		// one means that the size follows the type as 64 bits.
+		size := uint64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := uint64(8)
+
+		if size == 1 {
+			_, err := io.ReadFull(r, header[8:16])
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				log.Panic(ErrJp2Format)
+			}
+
+			log.PanicIf(err)
+
+			size = binary.BigEndian.Uint64(header[8:16])
+			headerSize = 16
+		}
+
+		if size != 0 && size < headerSize {
+			log.Panic(ErrJp2Format)
+		}
+
+		offset += int(headerSize)
+
+		if boxType == "uuid" {
+			uuid := make([]byte, len(Jp2ExifUuid))
+
+			_, err := io.ReadFull(r, uuid)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				log.Panic(ErrJp2Format)
+			}
+
+			log.PanicIf(err)
+
+			offset += len(uuid)
+
+			if size != 0 && size < headerSize+uint64(len(uuid)) {
+				log.Panic(ErrJp2Format)
+			}
+
+			if bytes.Equal(uuid, Jp2ExifUuid) == true {
+				var lr io.Reader = io.LimitReader(r, jp2MaxExifBoxSize+1)
+				if size != 0 {
+					if size-headerSize-uint64(len(uuid)) > jp2MaxExifBoxSize {
+						log.Panicf("jp2 exif box too large: (%d)", size)
+					}
+
+					lr = io.LimitReader(r, int64(size-headerSize-uint64(len(uuid))))
+				}
+
+				rawExif, err = ioutil.ReadAll(lr)
+				log.PanicIf(err)
+
+				if len(rawExif) > jp2MaxExifBoxSize {
+					log.Panicf("jp2 exif box too large")
+				} else if size != 0 && uint64(len(rawExif)) != size-headerSize-uint64(len(uuid)) {
+					log.Panic(ErrJp2Format)
+				}
+
+				if bytes.HasPrefix(rawExif, jpegExifPreamble) == true {
+					rawExif = rawExif[len(jpegExifPreamble):]
+					offset += len(jpegExifPreamble)
+				}
+
+				exifLogger.Debugf(nil, "Found JP2 EXIF box (%d) bytes at offset (%d).", len(rawExif), offset)
+
+				return rawExif, offset, nil
+			}
+
+			headerSize += uint64(len(uuid))
+		}
+
+		if size == 0 {
+			break
+		}
+
+		_, err = io.CopyN(ioutil.Discard, r, int64(size-headerSize))
+		if err == io.EOF {
+			log.Panic(ErrJp2Format)
+		}
+
+		log.PanicIf(err)
+
+		offset += int(size - headerSize)
+	}
+
+	return nil, 0, ErrNoExif
+}
@@ -0,0 +1,88 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func buildTestJp2Box(boxType string, payload []byte) []byte {
+	box := make([]byte, 8)
+	binary.BigEndian.PutUint32(box[:4], uint32(8+len(payload)))
+	copy(box[4:], boxType)
+
+	return append(box, payload...)
+}
+
+// buildTestJp2 returns a minimal JP2 file with, if `exifPayload` is not nil,
+// an EXIF box between the header and the codestream.
+func buildTestJp2(exifPayload []byte) []byte {
+	data := append([]byte{}, jp2Signature...)
+	data = append(data, buildTestJp2Box("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 "))...)
+	data = append(data, buildTestJp2Box("jp2h", buildTestJp2Box("ihdr", make([]byte, 14)))...)
+
+	// An unrelated UUID box.
+	data = append(data, buildTestJp2Box("uuid", append([]byte("0123456789abcdef"), 1, 2, 3))...)
+
+	if exifPayload != nil {
+		data = append(data, buildTestJp2Box("uuid", append(append([]byte{}, Jp2ExifUuid...), exifPayload...))...)
+	}
+
+	// The codestream runs to the end of the file.
+	data = append(data, 0, 0, 0, 0, 'j', 'p', '2', 'c', 0xff, 0x4f, 0xff, 0x51)
+
+	return data
+}
+
+func TestExtractExifFromJp2(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	for _, payload := range [][]byte{exifData, append(append([]byte{}, jpegExifPreamble...), exifData...)} {
+		rawExif, err := ExtractExifFromJp2(bytes.NewReader(buildTestJp2(payload)))
+		log.PanicIf(err)
+
+		if bytes.Equal(rawExif, exifData) != true {
+			t.Fatalf("EXIF not correct.")
+		}
+	}
+}
+
+func TestExtractExifFromJp2_NoExif(t *testing.T) {
+	_, err := ExtractExifFromJp2(bytes.NewReader(buildTestJp2(nil)))
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+}
+
+func TestExtractExifFromJp2_Truncated(t *testing.T) {
+	data := buildTestJp2(getExifSimpleTestIbBytes())
+
+	_, err := ExtractExifFromJp2(bytes.NewReader(data[:len(jp2Signature)+30]))
+	if log.Is(err, ErrJp2Format) == false {
+		t.Fatalf("Expected format error: [%v]", err)
+	}
+}
+
+func TestSearchAndExtractExif_Jp2(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+	data := buildTestJp2(exifData)
+
+	rawExif, err := SearchAndExtractExif(data)
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF data not correct.")
+	}
+
+	ep, err := ProbeExif(bytes.NewReader(data))
+	log.PanicIf(err)
+
+	if ep.HasExif != true {
+		t.Fatalf("EXIF not found by probe.")
+	} else if bytes.Equal(data[ep.Offset:ep.Offset+len(exifData)], exifData) != true {
+		t.Fatalf("Probe offset not correct: (%d)", ep.Offset)
+	}
+}
@@ -0,0 +1,132 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrJpegExifTooLarge means that the EXIF does not fit in a JPEG segment.
+	ErrJpegExifTooLarge = errors.New("exif too large for a jpeg segment")
+)
+
+// BuildJpegExifSegment returns a complete APP1 segment (marker, length, and
+// the "Exif\0\0" preamble) that carries the given EXIF.
+func BuildJpegExifSegment(rawExif []byte) (segment []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	payloadLength := len(jpegExifPreamble) + len(rawExif)
+	if payloadLength > jpegMaxSegmentPayloadSize {
+		return nil, ErrJpegExifTooLarge
+	}
+
+	b := new(bytes.Buffer)
+
+	_, err = b.Write([]byte{0xff, JpegMarkerApp1})
+	log.PanicIf(err)
+
+	err = binary.Write(b, binary.BigEndian, uint16(payloadLength+2))
+	log.PanicIf(err)
+
+	_, err = b.Write(jpegExifPreamble)
+	log.PanicIf(err)
+
+	_, err = b.Write(rawExif)
+	log.PanicIf(err)
+
+	return b.Bytes(), nil
+}
+
+// SetJpegExif returns a copy of the given JPEG with its EXIF replaced by
+// `rawExif`. The new EXIF takes the place of the first EXIF segment or, if
+// there is none, is inserted after any APP0 (JFIF) segments, as described by
+// `PlanJpegExifRewrite()`. Every other segment, the image data, and anything
+// after the image are kept as they are.
+func SetJpegExif(data []byte, rawExif []byte) (updated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	oldRawExif, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != nil {
+		if err == ErrNotJpeg {
+			return nil, err
+		} else if err != ErrNoExif {
+			log.Panic(err)
+		}
+	}
+
+	segment, err := BuildJpegExifSegment(rawExif)
+	if err != nil {
+		if err == ErrJpegExifTooLarge {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	// Find the range of the stream that the segment replaces. When inserting,
+	// this range is empty.
+
+	start := -1
+	end := -1
+
+	for _, js := range segments {
+		if oldRawExif != nil && js.Marker == JpegMarkerApp1 && js.Identifier == "Exif" {
+			start = js.Offset
+			end = js.Offset + js.Length
+
+			break
+		} else if oldRawExif == nil && js.Marker != JpegMarkerSoi && js.Marker != JpegMarkerApp0 {
+			start = js.Offset
+			end = js.Offset
+
+			break
+		}
+	}
+
+	if start < 0 {
+		log.Panicf("no place to put the exif segment in the jpeg")
+	}
+
+	updated = make([]byte, 0, len(data)-(end-start)+len(segment))
+	updated = append(updated, data[:start]...)
+	updated = append(updated, segment...)
+	updated = append(updated, data[end:]...)
+
+	return updated, nil
+}
+
+// SetJpegExifFromBuilder encodes the chain of the given root IB and puts it in
+// the given JPEG with `SetJpegExif()`.
+func SetJpegExifFromBuilder(data []byte, rootIb *IfdBuilder) (updated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, err := rootIb.BuildExif()
+	log.PanicIf(err)
+
+	updated, err = SetJpegExif(data, rawExif)
+	if err != nil {
+		if err == ErrNotJpeg || err == ErrJpegExifTooLarge {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return updated, nil
+}
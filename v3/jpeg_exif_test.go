@@ -0,0 +1,173 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getJpegExifTestIb() *IfdBuilder {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("ProcessingSoftware", "replacement")
+	log.PanicIf(err)
+
+	return rootIb
+}
+
+func checkJpegExifTestIb(data []byte, t *testing.T) {
+	rawExif, _, err := ExtractExifAndSegmentsFromJpeg(data)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("ProcessingSoftware")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "replacement" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
+func jpegSegmentMarkers(data []byte) []byte {
+	_, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != nil && err != ErrNoExif {
+		log.Panic(err)
+	}
+
+	markers := make([]byte, len(segments))
+	for i, js := range segments {
+		markers[i] = js.Marker
+	}
+
+	return markers
+}
+
+func TestBuildJpegExifSegment(t *testing.T) {
+	segment, err := BuildJpegExifSegment([]byte{1, 2, 3})
+	log.PanicIf(err)
+
+	expected := []byte{0xff, 0xe1, 0x00, 0x0b, 'E', 'x', 'i', 'f', 0, 0, 1, 2, 3}
+	if bytes.Equal(segment, expected) != true {
+		t.Fatalf("Segment not correct: %v", segment)
+	}
+}
+
+func TestBuildJpegExifSegment_TooLarge(t *testing.T) {
+	_, err := BuildJpegExifSegment(make([]byte, jpegMaxSegmentPayloadSize))
+	if err != ErrJpegExifTooLarge {
+		t.Fatalf("Expected ErrJpegExifTooLarge: %v", err)
+	}
+}
+
+func TestSetJpegExifFromBuilder_Replace(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rootIb := getJpegExifTestIb()
+
+	updated, err := SetJpegExifFromBuilder(data, rootIb)
+	log.PanicIf(err)
+
+	checkJpegExifTestIb(updated, t)
+
+	if reflect.DeepEqual(jpegSegmentMarkers(updated), jpegSegmentMarkers(data)) != true {
+		t.Fatalf("Segments not preserved.")
+	}
+
+	// The size should be what the plan said it would be.
+
+	rawExif, err := rootIb.BuildExif()
+	log.PanicIf(err)
+
+	rp, err := PlanJpegExifRewrite(data, rawExif)
+	log.PanicIf(err)
+
+	if len(updated) != rp.NewFileSize {
+		t.Fatalf("Size not correct: (%d) != (%d)", len(updated), rp.NewFileSize)
+	}
+}
+
+func TestSetJpegExifFromBuilder_Insert(t *testing.T) {
+	data := buildTestJpeg(nil)
+
+	updated, err := SetJpegExifFromBuilder(data, getJpegExifTestIb())
+	log.PanicIf(err)
+
+	checkJpegExifTestIb(updated, t)
+
+	markers := jpegSegmentMarkers(updated)
+	expected := []byte{JpegMarkerSoi, JpegMarkerApp0, JpegMarkerApp1, JpegMarkerSos, JpegMarkerEoi}
+
+	if bytes.Equal(markers, expected) != true {
+		t.Fatalf("Segments not correct: %v", markers)
+	}
+}
+
+func TestSetJpegExif_NotJpeg(t *testing.T) {
+	_, err := SetJpegExif([]byte("not a jpeg"), getExifSimpleTestIbBytes())
+	if err != ErrNotJpeg {
+		t.Fatalf("Expected ErrNotJpeg: %v", err)
+	}
+}
+
+func TestSetJpegFileExif(t *testing.T) {
+	tempPath, err := ioutil.TempDir("", "exif")
+	log.PanicIf(err)
+
+	defer os.RemoveAll(tempPath)
+
+	jpegFilepath := filepath.Join(tempPath, "image.jpg")
+
+	err = ioutil.WriteFile(jpegFilepath, buildTestJpeg(getExifSimpleTestIbBytes()), 0640)
+	log.PanicIf(err)
+
+	err = SetJpegFileExif(jpegFilepath, getJpegExifTestIb())
+	log.PanicIf(err)
+
+	data, err := ioutil.ReadFile(jpegFilepath)
+	log.PanicIf(err)
+
+	checkJpegExifTestIb(data, t)
+
+	fi, err := os.Stat(jpegFilepath)
+	log.PanicIf(err)
+
+	if fi.Mode().Perm() != 0640 {
+		t.Fatalf("Mode not preserved: %v", fi.Mode())
+	}
+
+	// The temporary file should be gone.
+
+	names, err := ioutil.ReadDir(tempPath)
+	log.PanicIf(err)
+
+	if len(names) != 1 {
+		t.Fatalf("Extra files left behind: (%d)", len(names))
+	}
+}
@@ -0,0 +1,178 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+const (
+	// JpegXmpIdentifier is the identifier of the APP1 segment with the main
+	// XMP packet.
+	JpegXmpIdentifier = "http://ns.adobe.com/xap/1.0/"
+
+	// JpegExtendedXmpIdentifier is the identifier of the APP1 segments that
+	// carry the chunks of an Extended XMP packet.
+	JpegExtendedXmpIdentifier = "http://ns.adobe.com/xmp/extension/"
+
+	// jpegExtendedXmpGuidLength is the length of the GUID, which is the MD5
+	// of the full packet as uppercase hexadecimal.
+	jpegExtendedXmpGuidLength = 32
+
+	// jpegExtendedXmpHeaderLength is the size of the identifier (with its
+	// NUL), the GUID, the full length, and the chunk offset.
+	jpegExtendedXmpHeaderLength = len(JpegExtendedXmpIdentifier) + 1 + jpegExtendedXmpGuidLength + 4 + 4
+)
+
+// JpegExtendedXmp is an Extended XMP packet: XMP that didn't fit in one
+// segment and was split across several APP1 segments that share a GUID. The
+// main XMP packet refers to it with its xmpNote:HasExtendedXMP property.
+type JpegExtendedXmp struct {
+	// Guid identifies the packet.
+	Guid string
+
+	// FullLength is the size of the packet, as given by the segments.
+	FullLength uint32
+
+	// Segments are the positions of the segments of the packet in the
+	// segment list.
+	Segments []int
+
+	// Payload is the reassembled packet. It is nil if the packet is not
+	// complete.
+	Payload []byte
+
+	// Complete indicates that the chunks cover the whole packet.
+	Complete bool
+
+	// Verified indicates that the MD5 of the reassembled packet matches the
+	// GUID.
+	Verified bool
+
+	// Referenced indicates that the main XMP packet refers to this GUID.
+	Referenced bool
+}
+
+// String returns a descriptive string.
+func (jex *JpegExtendedXmp) String() string {
+	return fmt.Sprintf("JpegExtendedXmp<GUID=[%s] FULL-LENGTH=(%d) SEGMENTS=(%d) COMPLETE=[%v] VERIFIED=[%v] REFERENCED=[%v]>", jex.Guid, jex.FullLength, len(jex.Segments), jex.Complete, jex.Verified, jex.Referenced)
+}
+
+// jpegExtendedXmpChunk is one segment of an Extended XMP packet.
+type jpegExtendedXmpChunk struct {
+	offset uint32
+	data   []byte
+}
+
+// assembleJpegExtendedXmp finds the Extended XMP segments in the list,
+// reassembles their packets, and links every segment to its packet.
+// Malformed segments are left alone.
+func assembleJpegExtendedXmp(segments []JpegSegment) {
+	packets := make(map[string]*JpegExtendedXmp)
+	chunks := make(map[string][]jpegExtendedXmpChunk)
+	order := make([]string, 0)
+
+	mainXmp := []byte(nil)
+
+	for i, js := range segments {
+		if js.Marker != JpegMarkerApp1 {
+			continue
+		}
+
+		if js.Identifier == JpegXmpIdentifier && mainXmp == nil {
+			mainXmp = js.Payload[len(JpegXmpIdentifier)+1:]
+			continue
+		} else if js.Identifier != JpegExtendedXmpIdentifier {
+			continue
+		}
+
+		if len(js.Payload) < jpegExtendedXmpHeaderLength {
+			exifLogger.Warningf(nil, "Extended XMP segment at offset (%d) is too short: (%d)", js.Offset, len(js.Payload))
+			continue
+		}
+
+		header := js.Payload[len(JpegExtendedXmpIdentifier)+1:]
+
+		guid := string(header[:jpegExtendedXmpGuidLength])
+		fullLength := binary.BigEndian.Uint32(header[jpegExtendedXmpGuidLength:])
+		offset := binary.BigEndian.Uint32(header[jpegExtendedXmpGuidLength+4:])
+
+		jex, found := packets[guid]
+		if found == false {
+			jex = &JpegExtendedXmp{
+				Guid:       guid,
+				FullLength: fullLength,
+				Segments:   make([]int, 0),
+			}
+
+			packets[guid] = jex
+			order = append(order, guid)
+		} else if jex.FullLength != fullLength {
+			exifLogger.Warningf(nil, "Extended XMP segment at offset (%d) disagrees on the full length: (%d) != (%d)", js.Offset, fullLength, jex.FullLength)
+			continue
+		}
+
+		chunk := jpegExtendedXmpChunk{
+			offset: offset,
+			data:   js.Payload[jpegExtendedXmpHeaderLength:],
+		}
+
+		chunks[guid] = append(chunks[guid], chunk)
+		jex.Segments = append(jex.Segments, i)
+		segments[i].ExtendedXmp = jex
+	}
+
+	for _, guid := range order {
+		jex := packets[guid]
+
+		jex.Referenced = mainXmp != nil && bytes.Contains(mainXmp, []byte(guid)) == true
+
+		// Don't allocate the length that the segments claim unless they can
+		// actually fill it.
+		total := uint64(0)
+		for _, chunk := range chunks[guid] {
+			total += uint64(len(chunk.data))
+		}
+
+		if total < uint64(jex.FullLength) {
+			continue
+		}
+
+		payload := make([]byte, jex.FullLength)
+		covered := make([]bool, jex.FullLength)
+
+		for _, chunk := range chunks[guid] {
+			end := uint64(chunk.offset) + uint64(len(chunk.data))
+			if end > uint64(jex.FullLength) {
+				continue
+			}
+
+			copy(payload[chunk.offset:], chunk.data)
+
+			for j := chunk.offset; j < uint32(end); j++ {
+				covered[j] = true
+			}
+		}
+
+		jex.Complete = true
+		for _, isCovered := range covered {
+			if isCovered == false {
+				jex.Complete = false
+				break
+			}
+		}
+
+		if jex.Complete == false {
+			continue
+		}
+
+		jex.Payload = payload
+
+		digest := md5.Sum(payload)
+		jex.Verified = strings.EqualFold(hex.EncodeToString(digest[:]), guid)
+	}
+}
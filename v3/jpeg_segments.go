@@ -0,0 +1,265 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// These are the markers (the byte after 0xff) of the JPEG segments that
+	// callers most often look for.
+	JpegMarkerSoi   = byte(0xd8)
+	JpegMarkerEoi   = byte(0xd9)
+	JpegMarkerSos   = byte(0xda)
+	JpegMarkerApp0  = byte(0xe0)
+	JpegMarkerApp1  = byte(0xe1)
+	JpegMarkerApp2  = byte(0xe2)
+	JpegMarkerApp14 = byte(0xee)
+	JpegMarkerCom   = byte(0xfe)
+
+	// jpegMaxIdentifierLength is the most that we'll look at for the
+	// identifier of an application segment.
+	jpegMaxIdentifierLength = 80
+
+	// JpegMaxExifPadding is the most padding that we'll skip between the
+	// "Exif\0\0" preamble and the TIFF header. Looking any further risks
+	// finding something that only looks like a header.
+	JpegMaxExifPadding = 64
+)
+
+// JpegSegment describes one segment of a JPEG stream.
+type JpegSegment struct {
+	// Marker is the second byte of the marker (e.g. 0xe1 for APP1).
+	Marker byte
+
+	// Offset is the position of the marker from the start of the stream.
+	Offset int
+
+	// Length is the total size of the segment, including the marker. For
+	// SOS, this includes the entropy-coded data that follows it.
+	Length int
+
+	// Identifier identifies the content of an application segment. It is the
+	// leading NUL-terminated string of the payload (e.g. "Exif",
+	// "http://ns.adobe.com/xap/1.0/", "ICC_PROFILE", or "Adobe"), and empty
+	// for other segments.
+	Identifier string
+
+	// Payload is the data after the marker and the length, and empty for SOS
+	// and for the markers that have no length. This is a slice of the stream.
+	Payload []byte
+
+	// ExtendedXmp is the Extended XMP packet that this segment is a part of,
+	// or nil. It is shared by all of the segments of the packet.
+	ExtendedXmp *JpegExtendedXmp
+}
+
+// MarkerName returns the common name of the marker (e.g. "APP1").
+func (js JpegSegment) MarkerName() string {
+	marker := js.Marker
+
+	switch {
+	case marker == JpegMarkerSoi:
+		return "SOI"
+	case marker == JpegMarkerEoi:
+		return "EOI"
+	case marker == JpegMarkerSos:
+		return "SOS"
+	case marker == 0xc4:
+		return "DHT"
+	case marker == 0xcc:
+		return "DAC"
+	case marker == 0xdb:
+		return "DQT"
+	case marker == 0xdd:
+		return "DRI"
+	case marker == JpegMarkerCom:
+		return "COM"
+	case marker >= 0xc0 && marker <= 0xcf && marker != 0xc8:
+		return fmt.Sprintf("SOF%d", marker-0xc0)
+	case marker >= 0xd0 && marker <= 0xd7:
+		return fmt.Sprintf("RST%d", marker-0xd0)
+	case marker >= JpegMarkerApp0 && marker <= 0xef:
+		return fmt.Sprintf("APP%d", marker-JpegMarkerApp0)
+	}
+
+	return fmt.Sprintf("0x%02x", marker)
+}
+
+// String returns a descriptive string.
+func (js JpegSegment) String() string {
+	return fmt.Sprintf("JpegSegment<MARKER=[%s] OFFSET=(%d) LENGTH=(%d) IDENTIFIER=[%s]>", js.MarkerName(), js.Offset, js.Length, js.Identifier)
+}
+
+// jpegMarkerHasLength returns true if the marker is followed by a length and
+// a payload.
+func jpegMarkerHasLength(marker byte) bool {
+	if marker == JpegMarkerSoi || marker == JpegMarkerEoi || marker == 0x01 {
+		return false
+	}
+
+	if marker >= 0xd0 && marker <= 0xd7 {
+		return false
+	}
+
+	return true
+}
+
+// jpegSegmentIdentifier returns the identifier of an application segment.
+func jpegSegmentIdentifier(marker byte, payload []byte) string {
+	if marker < JpegMarkerApp0 || marker > 0xef {
+		return ""
+	}
+
+	if bytes.HasPrefix(payload, jpegExifPreamble) == true {
+		return "Exif"
+	}
+
+	for i, c := range payload {
+		if i >= jpegMaxIdentifierLength {
+			break
+		} else if c == 0 {
+			return string(payload[:i])
+		} else if c < 0x20 || c > 0x7e {
+			break
+		}
+	}
+
+	return ""
+}
+
+// SplitJpegExifPayload splits the payload of an APP1 EXIF segment into the
+// padding that some editors insert between the "Exif\0\0" preamble and the
+// TIFF header, which is usually empty, and the EXIF data. The padding is
+// returned so that it can be written back (see
+// `ExifStreamTransformer.SetPreservePadding()`). `ErrNoExif` is returned if
+// there is no preamble or no TIFF header within `JpegMaxExifPadding` bytes of
+// it.
+func SplitJpegExifPayload(payload []byte) (padding []byte, rawExif []byte, err error) {
+	if bytes.HasPrefix(payload, jpegExifPreamble) == false {
+		return nil, nil, ErrNoExif
+	}
+
+	data := payload[len(jpegExifPreamble):]
+
+	for i := 0; i <= JpegMaxExifPadding && i+ExifSignatureLength <= len(data); i++ {
+		if _, err := ParseExifHeader(data[i:]); err == nil {
+			return data[:i], data[i:], nil
+		}
+	}
+
+	return nil, nil, ErrNoExif
+}
+
+// ExtractExifAndSegmentsFromJpeg walks every segment of a JPEG stream and
+// returns the EXIF from the first APP1 EXIF segment along with the list of
+// all of the segments in order, so that callers planning a rewrite can see
+// what else the file has (XMP, ICC profiles, Adobe color information, etc.).
+// The segments run contiguously from SOI to EOI; data after EOI is not
+// listed. Extended XMP packets are reassembled from their segments. If there
+// is no EXIF, the segments are returned along with `ErrNoExif`.
+func ExtractExifAndSegmentsFromJpeg(data []byte) (rawExif []byte, segments []JpegSegment, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(data) < 2 || data[0] != 0xff || data[1] != JpegMarkerSoi {
+		return nil, nil, ErrNotJpeg
+	}
+
+	segments = make([]JpegSegment, 0)
+	position := 0
+
+	for position < len(data) {
+		if data[position] != 0xff {
+			log.Panicf("jpeg marker not found at offset (%d): (0x%02x)", position, data[position])
+		}
+
+		// Markers may be preceded by any number of fill bytes, which we
+		// include in the segment.
+		markerAt := position + 1
+		for markerAt < len(data) && data[markerAt] == 0xff {
+			markerAt++
+		}
+
+		if markerAt >= len(data) {
+			log.Panicf("jpeg marker truncated at offset (%d)", position)
+		}
+
+		js := JpegSegment{
+			Marker: data[markerAt],
+			Offset: position,
+		}
+
+		end := markerAt + 1
+
+		if jpegMarkerHasLength(js.Marker) == true {
+			if end+2 > len(data) {
+				log.Panicf("jpeg segment length truncated at offset (%d)", position)
+			}
+
+			length := int(binary.BigEndian.Uint16(data[end : end+2]))
+			if length < 2 || end+length > len(data) {
+				log.Panicf("jpeg segment length not valid at offset (%d): (%d)", position, length)
+			}
+
+			js.Payload = data[end+2 : end+length]
+			end += length
+		}
+
+		if js.Marker == JpegMarkerSos {
+			js.Payload = nil
+			end = findJpegScanEnd(data, end)
+		}
+
+		js.Length = end - position
+		js.Identifier = jpegSegmentIdentifier(js.Marker, js.Payload)
+
+		if rawExif == nil && js.Marker == JpegMarkerApp1 && js.Identifier == "Exif" {
+			_, rawExif, _ = SplitJpegExifPayload(js.Payload)
+		}
+
+		segments = append(segments, js)
+		position = end
+
+		if js.Marker == JpegMarkerEoi {
+			break
+		}
+	}
+
+	assembleJpegExtendedXmp(segments)
+
+	if rawExif == nil {
+		return nil, segments, ErrNoExif
+	}
+
+	return rawExif, segments, nil
+}
+
+// findJpegScanEnd returns the position of the first marker after the
+// entropy-coded data that starts at `position`, skipping stuffed zeros and
+// restart markers.
+func findJpegScanEnd(data []byte, position int) int {
+	for position+1 < len(data) {
+		if data[position] != 0xff {
+			position++
+			continue
+		}
+
+		next := data[position+1]
+		if next == 0x00 || next == 0xff || (next >= 0xd0 && next <= 0xd7) {
+			position++
+			continue
+		}
+
+		return position
+	}
+
+	return len(data)
+}
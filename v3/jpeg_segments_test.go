@@ -0,0 +1,202 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestExtractExifAndSegmentsFromJpeg(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rawExif, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	log.PanicIf(err)
+
+	expectedExif, err := ExtractExifFromJpegSegments(bytes.NewReader(data))
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, expectedExif) != true {
+		t.Fatalf("EXIF not correct.")
+	}
+
+	if segments[0].Marker != JpegMarkerSoi || segments[0].Offset != 0 || segments[0].Length != 2 {
+		t.Fatalf("First segment not correct: %s", segments[0])
+	}
+
+	last := segments[len(segments)-1]
+	if last.Marker != JpegMarkerEoi {
+		t.Fatalf("Last segment not correct: %s", last)
+	}
+
+	// The segments must be contiguous.
+	position := 0
+	foundSos := false
+	for _, js := range segments {
+		if js.Offset != position {
+			t.Fatalf("Segment not contiguous: %s", js)
+		}
+
+		position += js.Length
+
+		if js.Marker == JpegMarkerSos {
+			foundSos = true
+		}
+	}
+
+	if foundSos != true {
+		t.Fatalf("No SOS segment.")
+	} else if segments[1].Marker != JpegMarkerApp1 || segments[1].Identifier != "Exif" || segments[1].MarkerName() != "APP1" {
+		t.Fatalf("EXIF segment not correct: %s", segments[1])
+	}
+}
+
+func TestExtractExifAndSegmentsFromJpeg_NoExif(t *testing.T) {
+	data := []byte{0xff, 0xd8}
+	data = append(data, 0xff, 0xe1, 0x00, 0x07, 'a', 'b', 0x00, 1, 2)
+	data = append(data, 0xff, 0xee, 0x00, 0x09, 'A', 'd', 'o', 'b', 'e', 0x00, 0x64)
+	data = append(data, 0xff, 0xda, 0x00, 0x02, 1, 2, 0xff, 0x00, 3, 0xff, 0xd0, 4)
+	data = append(data, 0xff, 0xd9, 0xaa)
+
+	_, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+
+	expected := []JpegSegment{
+		{Marker: JpegMarkerSoi, Offset: 0, Length: 2},
+		{Marker: JpegMarkerApp1, Offset: 2, Length: 9, Identifier: "ab"},
+		{Marker: JpegMarkerApp14, Offset: 11, Length: 11, Identifier: "Adobe"},
+		{Marker: JpegMarkerSos, Offset: 22, Length: 12},
+		{Marker: JpegMarkerEoi, Offset: 34, Length: 2},
+	}
+
+	if len(segments) != len(expected) {
+		t.Fatalf("Segment count not correct: %v", segments)
+	}
+
+	for i, js := range segments {
+		e := expected[i]
+		if js.Marker != e.Marker || js.Offset != e.Offset || js.Length != e.Length || js.Identifier != e.Identifier {
+			t.Fatalf("Segment (%d) not correct: %s", i, js)
+		}
+	}
+
+	if segments[2].MarkerName() != "APP14" {
+		t.Fatalf("Marker name not correct: [%s]", segments[2].MarkerName())
+	}
+}
+
+func TestSplitJpegExifPayload(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	for _, padding := range [][]byte{{}, {0, 0}, []byte("\xff\xff\x00garbage")} {
+		payload := append(append(append([]byte{}, jpegExifPreamble...), padding...), exifData...)
+
+		actualPadding, rawExif, err := SplitJpegExifPayload(payload)
+		log.PanicIf(err)
+
+		if bytes.Equal(actualPadding, padding) != true {
+			t.Fatalf("Padding not correct: %v != %v", actualPadding, padding)
+		} else if bytes.Equal(rawExif, exifData) != true {
+			t.Fatalf("EXIF not correct (padding=%v).", padding)
+		}
+
+		extractedExif, _, err := ExtractExifAndSegmentsFromJpeg(buildTestJpeg(append(append([]byte{}, padding...), exifData...)))
+		log.PanicIf(err)
+
+		if bytes.Equal(extractedExif, exifData) != true {
+			t.Fatalf("Extracted EXIF not correct (padding=%v).", padding)
+		}
+	}
+
+	tooFar := append(append([]byte{}, jpegExifPreamble...), make([]byte, JpegMaxExifPadding+1)...)
+	tooFar = append(tooFar, exifData...)
+
+	invalid := [][]byte{
+		exifData,
+		append(append([]byte{}, jpegExifPreamble...), 1, 2, 3),
+		tooFar,
+	}
+
+	for i, payload := range invalid {
+		_, _, err := SplitJpegExifPayload(payload)
+		if err != ErrNoExif {
+			t.Fatalf("Expected no-EXIF error for case (%d): %v", i, err)
+		}
+	}
+}
+
+func buildTestJpegApp1(payload []byte) []byte {
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+
+	return append(segment, payload...)
+}
+
+func buildTestExtendedXmpSegment(guid string, fullLength, offset uint32, chunk []byte) []byte {
+	payload := []byte(JpegExtendedXmpIdentifier + "\x00" + guid)
+	payload = append(payload, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(payload[len(payload)-8:], fullLength)
+	binary.BigEndian.PutUint32(payload[len(payload)-4:], offset)
+
+	return buildTestJpegApp1(append(payload, chunk...))
+}
+
+func TestExtractExifAndSegmentsFromJpeg_ExtendedXmp(t *testing.T) {
+	extended := []byte("<x:xmpmeta><rdf:RDF>extended</rdf:RDF></x:xmpmeta>")
+
+	digest := md5.Sum(extended)
+	guid := strings.ToUpper(hex.EncodeToString(digest[:]))
+
+	mainXmp := []byte(JpegXmpIdentifier + "\x00<x:xmpmeta xmpNote:HasExtendedXMP=\"" + guid + "\"/>")
+
+	data := []byte{0xff, 0xd8}
+	data = append(data, buildTestJpegApp1(mainXmp)...)
+
+	// The chunks may come in any order.
+	data = append(data, buildTestExtendedXmpSegment(guid, uint32(len(extended)), 20, extended[20:])...)
+	data = append(data, buildTestExtendedXmpSegment(guid, uint32(len(extended)), 0, extended[:20])...)
+
+	// A packet that is missing a chunk.
+	data = append(data, buildTestExtendedXmpSegment("0123456789ABCDEF0123456789ABCDEF", 100, 0, []byte("partial"))...)
+
+	data = append(data, 0xff, 0xd9)
+
+	_, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+
+	if segments[1].Identifier != JpegXmpIdentifier || segments[1].ExtendedXmp != nil {
+		t.Fatalf("Main XMP segment not correct: %s", segments[1])
+	}
+
+	jex := segments[2].ExtendedXmp
+	if jex == nil || segments[3].ExtendedXmp != jex {
+		t.Fatalf("Extended XMP segments not linked.")
+	} else if segments[2].Identifier != JpegExtendedXmpIdentifier {
+		t.Fatalf("Extended XMP identifier not correct: [%s]", segments[2].Identifier)
+	} else if jex.Guid != guid || jex.Complete != true || jex.Verified != true || jex.Referenced != true {
+		t.Fatalf("Extended XMP not correct: %s", jex)
+	} else if bytes.Equal(jex.Payload, extended) != true {
+		t.Fatalf("Extended XMP payload not correct: [%s]", jex.Payload)
+	} else if len(jex.Segments) != 2 || jex.Segments[0] != 2 || jex.Segments[1] != 3 {
+		t.Fatalf("Extended XMP segment positions not correct: %v", jex.Segments)
+	}
+
+	partial := segments[4].ExtendedXmp
+	if partial == nil || partial.Complete != false || partial.Payload != nil || partial.Referenced != false {
+		t.Fatalf("Partial Extended XMP not correct: %s", partial)
+	}
+}
@@ -0,0 +1,804 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+// LintSeverity describes how serious a finding is.
+type LintSeverity int
+
+const (
+	// LintSeverityInfo is for things that are unusual but that readers handle
+	// sensibly.
+	LintSeverityInfo LintSeverity = iota
+
+	// LintSeverityWarning is for things that some readers will misinterpret.
+	LintSeverityWarning
+
+	// LintSeverityError is for things that are wrong or that will lose
+	// information.
+	LintSeverityError
+)
+
+// String returns the name of the severity.
+func (ls LintSeverity) String() string {
+	switch ls {
+	case LintSeverityInfo:
+		return "info"
+	case LintSeverityWarning:
+		return "warning"
+	case LintSeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("LintSeverity(%d)", int(ls))
+}
+
+// LintCode identifies the kind of problem that a finding describes.
+type LintCode string
+
+const (
+	// LintPixelDimensionMismatch means that PixelXDimension or PixelYDimension
+	// does not agree with the dimensions in the JPEG SOF segment.
+	LintPixelDimensionMismatch LintCode = "pixel-dimension-mismatch"
+
+	// LintResolutionUnitMissing means that XResolution or YResolution is
+	// present without ResolutionUnit.
+	LintResolutionUnitMissing LintCode = "resolution-unit-missing"
+
+	// LintGpsLatitudeRefMissing means that GPSLatitude is present without
+	// GPSLatitudeRef.
+	LintGpsLatitudeRefMissing LintCode = "gps-latitude-ref-missing"
+
+	// LintGpsLongitudeRefMissing means that GPSLongitude is present without
+	// GPSLongitudeRef.
+	LintGpsLongitudeRefMissing LintCode = "gps-longitude-ref-missing"
+
+	// LintGpsAltitudeRefMissing means that GPSAltitude is present without
+	// GPSAltitudeRef.
+	LintGpsAltitudeRefMissing LintCode = "gps-altitude-ref-missing"
+
+	// LintDateTimeInvalid means that a timestamp tag is not in the
+	// "YYYY:MM:DD HH:MM:SS" (or, for GPSDateStamp, "YYYY:MM:DD") format or is
+	// not a real date.
+	LintDateTimeInvalid LintCode = "datetime-invalid"
+
+	// LintEnumValueOutOfRange means that an enumerated tag (e.g. Orientation)
+	// has a value that the specification does not define. The value is
+	// preserved as-is.
+	LintEnumValueOutOfRange LintCode = "enum-value-out-of-range"
+
+	// LintMandatoryTagMissing means that a tag that the requested conformance
+	// level requires is not present.
+	LintMandatoryTagMissing LintCode = "mandatory-tag-missing"
+
+	// LintUnreadableTag means that a tag could not be read, either because it
+	// is not known (and was skipped while parsing) or because its value could
+	// not be decoded. How seriously this is taken depends on the conformance
+	// level.
+	LintUnreadableTag LintCode = "unreadable-tag"
+)
+
+// ConformanceLevel is the specification that `Lint()` checks conformance
+// with. The levels are not cumulative: each describes a different ecosystem.
+type ConformanceLevel int
+
+const (
+	// ConformanceNone checks only the relationships between tags. Nothing is
+	// required to be present and unreadable tags are not reported.
+	ConformanceNone ConformanceLevel = iota
+
+	// ConformanceExif is a baseline EXIF JPEG. The tags that the EXIF
+	// specification marks as mandatory for the primary image and, if there
+	// is one, the thumbnail are required. Unreadable tags are warnings since
+	// other EXIF readers are unlikely to understand them either.
+	ConformanceExif
+
+	// ConformanceTiffEp is TIFF/EP (ISO 12234-2). The baseline TIFF fields and
+	// TIFFEPStandardID are required in IFD0. Unreadable tags are informational
+	// since private tags are common.
+	ConformanceTiffEp
+
+	// ConformanceDng is Adobe DNG. DNGVersion and UniqueCameraModel are
+	// required in IFD0. Unreadable tags are not reported since DNG writers
+	// routinely add private tags.
+	ConformanceDng
+)
+
+// String returns the name of the level.
+func (cl ConformanceLevel) String() string {
+	switch cl {
+	case ConformanceNone:
+		return "none"
+	case ConformanceExif:
+		return "exif"
+	case ConformanceTiffEp:
+		return "tiff-ep"
+	case ConformanceDng:
+		return "dng"
+	}
+
+	return fmt.Sprintf("ConformanceLevel(%d)", int(cl))
+}
+
+// LintFinding describes one inconsistency found by `Lint()`.
+type LintFinding struct {
+	// Code identifies the kind of problem.
+	Code LintCode
+
+	// Severity is how serious the problem is.
+	Severity LintSeverity
+
+	// IfdPath is the fully-qualified path of the IFD that the problem is in.
+	IfdPath string
+
+	// TagName is the tag that is missing or wrong.
+	TagName string
+
+	// Message describes the problem.
+	Message string
+
+	// Suggested is a value for `TagName` that would resolve the problem, in
+	// the form taken by `IfdBuilder.SetStandardWithName()`, or nil if there is
+	// no safe correction.
+	Suggested interface{}
+}
+
+// String returns a descriptive string.
+func (lf LintFinding) String() string {
+	return fmt.Sprintf("LintFinding<SEVERITY=[%s] CODE=[%s] IFD-PATH=[%s] TAG=[%s] MESSAGE=[%s]>", lf.Severity, lf.Code, lf.IfdPath, lf.TagName, lf.Message)
+}
+
+// IsFixable returns true if `Fix()` can correct the problem.
+func (lf LintFinding) IsFixable() bool {
+	return lf.Suggested != nil
+}
+
+// LintOptions adjusts the checks done by `Lint()`.
+type LintOptions struct {
+	// ImageData is the complete JPEG that the EXIF came from. If provided, the
+	// pixel dimensions are compared with those of the image.
+	ImageData []byte
+
+	// Conformance determines which tags are mandatory and how tags that can't
+	// be read are reported. The default, `ConformanceNone`, requires nothing.
+	Conformance ConformanceLevel
+}
+
+var (
+	lintDateTimeTags = map[string][]string{
+		exifcommon.IfdStandardIfdIdentity.UnindexedString():     {"DateTime"},
+		exifcommon.IfdExifStandardIfdIdentity.UnindexedString(): {"DateTimeOriginal", "DateTimeDigitized"},
+	}
+
+	// conformanceRequiredTags are the tags that each conformance level
+	// requires, by fully-qualified IFD-path. The requirements of an IFD only
+	// apply if the IFD is present; the tags that point to child IFDs are
+	// required where the child is.
+	conformanceRequiredTags = map[ConformanceLevel]map[string][]string{
+		ConformanceExif: {
+			exifcommon.IfdStandardIfdIdentity.String(): {
+				"XResolution", "YResolution", "ResolutionUnit", "YCbCrPositioning", "ExifTag",
+			},
+			exifcommon.IfdExifStandardIfdIdentity.String(): {
+				"ExifVersion", "ComponentsConfiguration", "FlashpixVersion", "ColorSpace", "PixelXDimension", "PixelYDimension",
+			},
+			exifcommon.IfdStandardIfdIdentity.NewSibling(1).String(): {
+				"Compression", "XResolution", "YResolution", "ResolutionUnit", "JPEGInterchangeFormat", "JPEGInterchangeFormatLength",
+			},
+		},
+		ConformanceTiffEp: {
+			exifcommon.IfdStandardIfdIdentity.String(): {
+				"NewSubfileType", "ImageWidth", "ImageLength", "BitsPerSample", "Compression", "PhotometricInterpretation", "SamplesPerPixel", "XResolution", "YResolution", "ResolutionUnit", "DateTime", "TIFFEPStandardID",
+			},
+		},
+		ConformanceDng: {
+			exifcommon.IfdStandardIfdIdentity.String(): {
+				"DNGVersion", "UniqueCameraModel",
+			},
+		},
+	}
+
+	// conformanceUnreadableSeverity is the severity that unreadable tags are
+	// reported with at each conformance level. Levels that are not present do
+	// not report them.
+	conformanceUnreadableSeverity = map[ConformanceLevel]LintSeverity{
+		ConformanceExif:   LintSeverityWarning,
+		ConformanceTiffEp: LintSeverityInfo,
+	}
+
+	// lintLooseTimestampRe matches the timestamp formats commonly written by
+	// mistake (e.g. ISO 8601 separators).
+	lintLooseTimestampRe = regexp.MustCompile(`^\s*(\d{4})[:\-/.](\d{1,2})[:\-/.](\d{1,2})(?:[ T](\d{1,2})[:\-.](\d{1,2})[:\-.](\d{1,2}))?`)
+)
+
+// Lint checks the relationships between tags and returns the inconsistencies
+// that it finds. A nil `lo` is the same as empty options. An empty list is
+// returned if there is nothing to report.
+func Lint(rootIfd *Ifd, lo *LintOptions) (findings []LintFinding, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if lo == nil {
+		lo = &LintOptions{}
+	}
+
+	findings = make([]LintFinding, 0)
+
+	err = lintIfdTree(rootIfd, &findings)
+	log.PanicIf(err)
+
+	if lo.ImageData != nil {
+		err = lintPixelDimensions(rootIfd, lo.ImageData, &findings)
+		log.PanicIf(err)
+	}
+
+	if lo.Conformance != ConformanceNone {
+		err = lintConformanceTree(rootIfd, lo.Conformance, &findings)
+		log.PanicIf(err)
+	}
+
+	return findings, nil
+}
+
+// Fix applies the suggested corrections of the given findings to the IB tree
+// rooted at `rootIb` (e.g. one from
+// `NewIfdBuilderFromExistingChainWithReport()` for the same IFDs that were
+// linted) and returns the findings that were fixed. Only
+// safe corrections are ever suggested: missing references are filled with
+// the default that readers already assume, timestamps are reformatted without
+// changing their value, and pixel dimensions are taken from the image.
+// Findings that have no suggestion are skipped.
+func Fix(rootIb *IfdBuilder, findings []LintFinding) (fixed []LintFinding, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fixed = make([]LintFinding, 0)
+
+	for _, lf := range findings {
+		if lf.IsFixable() == false {
+			continue
+		}
+
+		err := rootIb.SetStandardWithNameInIfd(lf.IfdPath, lf.TagName, lf.Suggested)
+		log.PanicIf(err)
+
+		fixed = append(fixed, lf)
+	}
+
+	return fixed, nil
+}
+
+func lintIfdTree(ifd *Ifd, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for ; ifd != nil; ifd = ifd.nextIfd {
+		err := lintIfd(ifd, findings)
+		log.PanicIf(err)
+
+		for _, childIfd := range ifd.children {
+			err := lintIfdTree(childIfd, findings)
+			log.PanicIf(err)
+		}
+	}
+
+	return nil
+}
+
+func lintIfd(ifd *Ifd, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fqIfdPath := ifd.ifdIdentity.String()
+	ifdPath := ifd.ifdIdentity.UnindexedString()
+
+	if ifdPath == exifcommon.IfdStandardIfdIdentity.UnindexedString() {
+		if (lintHasTag(ifd, "XResolution") == true || lintHasTag(ifd, "YResolution") == true) && lintHasTag(ifd, "ResolutionUnit") == false {
+			*findings = append(*findings, LintFinding{
+				Code:      LintResolutionUnitMissing,
+				Severity:  LintSeverityWarning,
+				IfdPath:   fqIfdPath,
+				TagName:   "ResolutionUnit",
+				Message:   "resolution is present without a unit; readers will assume inches",
+				Suggested: []uint16{2},
+			})
+		}
+	}
+
+	if ifdPath == exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString() {
+		if lintHasTag(ifd, "GPSLatitude") == true && lintHasTag(ifd, "GPSLatitudeRef") == false {
+			*findings = append(*findings, LintFinding{
+				Code:      LintGpsLatitudeRefMissing,
+				Severity:  LintSeverityError,
+				IfdPath:   fqIfdPath,
+				TagName:   "GPSLatitudeRef",
+				Message:   "latitude is present without a hemisphere; readers will assume north",
+				Suggested: "N",
+			})
+		}
+
+		if lintHasTag(ifd, "GPSLongitude") == true && lintHasTag(ifd, "GPSLongitudeRef") == false {
+			*findings = append(*findings, LintFinding{
+				Code:      LintGpsLongitudeRefMissing,
+				Severity:  LintSeverityError,
+				IfdPath:   fqIfdPath,
+				TagName:   "GPSLongitudeRef",
+				Message:   "longitude is present without a hemisphere; readers will assume east",
+				Suggested: "E",
+			})
+		}
+
+		if lintHasTag(ifd, "GPSAltitude") == true && lintHasTag(ifd, "GPSAltitudeRef") == false {
+			*findings = append(*findings, LintFinding{
+				Code:      LintGpsAltitudeRefMissing,
+				Severity:  LintSeverityInfo,
+				IfdPath:   fqIfdPath,
+				TagName:   "GPSAltitudeRef",
+				Message:   "altitude is present without a reference; the default is above sea level",
+				Suggested: []uint8{0},
+			})
+		}
+
+		err := lintTimestampTag(ifd, "GPSDateStamp", true, findings)
+		log.PanicIf(err)
+	}
+
+	for _, tagName := range lintDateTimeTags[ifdPath] {
+		err := lintTimestampTag(ifd, tagName, false, findings)
+		log.PanicIf(err)
+	}
+
+	err = lintEnumeratedTags(ifd, findings)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// lintEnumeratedTags reports enumerated tags with values that aren't defined
+// for them. There's no safe correction, so nothing is suggested.
+func lintEnumeratedTags(ifd *Ifd, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	ifdPath := ifd.ifdIdentity.UnindexedString()
+
+	for _, ite := range ifd.entries {
+		tagName := ite.TagName()
+
+		if isEnumeratedTag(ifdPath, tagName) == false {
+			continue
+		}
+
+		value, err := ite.Value()
+		if err != nil {
+			continue
+		}
+
+		values, ok := enumIntegerValues(value)
+		if ok == false {
+			continue
+		}
+
+		for _, v := range values {
+			if isEnumValueDefined(ifdPath, tagName, v) == true {
+				continue
+			}
+
+			*findings = append(*findings, LintFinding{
+				Code:     LintEnumValueOutOfRange,
+				Severity: LintSeverityWarning,
+				IfdPath:  ifd.ifdIdentity.String(),
+				TagName:  tagName,
+				Message:  fmt.Sprintf("value (%d) is not defined for this tag", v),
+			})
+
+			break
+		}
+	}
+
+	return nil
+}
+
+func lintConformanceTree(ifd *Ifd, cl ConformanceLevel, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for ; ifd != nil; ifd = ifd.nextIfd {
+		err := lintConformance(ifd, cl, findings)
+		log.PanicIf(err)
+
+		for _, childIfd := range ifd.children {
+			err := lintConformanceTree(childIfd, cl, findings)
+			log.PanicIf(err)
+		}
+	}
+
+	return nil
+}
+
+// lintConformance reports the tags that the given level requires of this IFD
+// but that are missing, and the tags that couldn't be read.
+func lintConformance(ifd *Ifd, cl ConformanceLevel, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fqIfdPath := ifd.ifdIdentity.String()
+
+	for _, tagName := range conformanceRequiredTags[cl][fqIfdPath] {
+		if lintHasTag(ifd, tagName) == true || lintHasFinding(*findings, fqIfdPath, tagName) == true {
+			continue
+		}
+
+		*findings = append(*findings, LintFinding{
+			Code:     LintMandatoryTagMissing,
+			Severity: LintSeverityError,
+			IfdPath:  fqIfdPath,
+			TagName:  tagName,
+			Message:  fmt.Sprintf("tag is mandatory for %s", cl),
+		})
+	}
+
+	severity, found := conformanceUnreadableSeverity[cl]
+	if found == false {
+		return nil
+	}
+
+	// Tags that the parser skipped leave gaps in the positions.
+	expectedPosition := 0
+	for _, ite := range ifd.entries {
+		if skipped := ite.Position() - expectedPosition; skipped > 0 {
+			*findings = append(*findings, LintFinding{
+				Code:     LintUnreadableTag,
+				Severity: severity,
+				IfdPath:  fqIfdPath,
+				Message:  fmt.Sprintf("(%d) tag(s) before position (%d) could not be read", skipped, ite.Position()),
+			})
+		}
+
+		expectedPosition = ite.Position() + 1
+
+		if ite.TagType() != exifcommon.TypeUndefined {
+			continue
+		}
+
+		_, err := ite.Value()
+		if err == nil {
+			continue
+		} else if log.Is(err, exifcommon.ErrUnhandledUndefinedTypedTag) == false && log.Is(err, exifundefined.ErrUnparseableValue) == false {
+			log.Panic(err)
+		}
+
+		*findings = append(*findings, LintFinding{
+			Code:     LintUnreadableTag,
+			Severity: severity,
+			IfdPath:  fqIfdPath,
+			TagName:  ite.TagName(),
+			Message:  "value could not be decoded",
+		})
+	}
+
+	return nil
+}
+
+// lintHasFinding returns true if a finding has already been made about the
+// given tag.
+func lintHasFinding(findings []LintFinding, fqIfdPath, tagName string) bool {
+	for _, lf := range findings {
+		if lf.IfdPath == fqIfdPath && lf.TagName == tagName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lintHasTag(ifd *Ifd, tagName string) bool {
+	results, err := ifd.FindTagWithName(tagName)
+	return err == nil && len(results) > 0
+}
+
+// lintTimestampTag checks the format of a timestamp tag. If `isDateOnly` is
+// true, the value is expected to be a date without a time.
+func lintTimestampTag(ifd *Ifd, tagName string, isDateOnly bool, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := ifd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true || log.Is(err, ErrTagNotKnown) == true {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	if err != nil {
+		if log.Is(err, exifcommon.ErrUnhandledUndefinedTypedTag) == true {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	phrase, ok := value.(string)
+	if ok == false {
+		*findings = append(*findings, LintFinding{
+			Code:     LintDateTimeInvalid,
+			Severity: LintSeverityError,
+			IfdPath:  ifd.ifdIdentity.String(),
+			TagName:  tagName,
+			Message:  fmt.Sprintf("timestamp is not a string: [%v]", value),
+		})
+
+		return nil
+	}
+
+	// The specification allows unknown timestamps to be blanked with spaces
+	// (and the separators kept).
+	if strings.Trim(phrase, " :") == "" {
+		return nil
+	}
+
+	normalized, isValid := normalizeExifTimestamp(phrase, isDateOnly)
+	if isValid == true && normalized == phrase {
+		return nil
+	}
+
+	lf := LintFinding{
+		Code:     LintDateTimeInvalid,
+		Severity: LintSeverityWarning,
+		IfdPath:  ifd.ifdIdentity.String(),
+		TagName:  tagName,
+	}
+
+	if isValid == true {
+		lf.Message = fmt.Sprintf("timestamp is not in the standard format: [%s]", phrase)
+		lf.Suggested = normalized
+	} else {
+		lf.Severity = LintSeverityError
+		lf.Message = fmt.Sprintf("timestamp could not be parsed: [%s]", phrase)
+	}
+
+	*findings = append(*findings, lf)
+
+	return nil
+}
+
+// normalizeExifTimestamp reformats a timestamp written with common variations
+// (dashes or slashes, a "T" separator, single-digit fields, a zone suffix)
+// into the standard format. `isValid` is false if the value isn't a
+// recognizable or real date.
+func normalizeExifTimestamp(phrase string, isDateOnly bool) (normalized string, isValid bool) {
+	match := lintLooseTimestampRe.FindStringSubmatch(phrase)
+	if match == nil {
+		return "", false
+	}
+
+	if isDateOnly == false && match[4] == "" {
+		return "", false
+	}
+
+	fields := make([]int, 6)
+	for i := range fields {
+		if match[i+1] == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return "", false
+		}
+
+		fields[i] = n
+	}
+
+	t := time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, time.UTC)
+
+	// time.Date() normalizes out-of-range fields (e.g. month 13), so check
+	// that the fields survived.
+	if t.Year() != fields[0] || int(t.Month()) != fields[1] || t.Day() != fields[2] || t.Hour() != fields[3] || t.Minute() != fields[4] || t.Second() != fields[5] {
+		return "", false
+	}
+
+	if isDateOnly == true {
+		return fmt.Sprintf("%04d:%02d:%02d", t.Year(), t.Month(), t.Day()), true
+	}
+
+	return exifcommon.ExifFullTimestampString(t), true
+}
+
+func lintPixelDimensions(rootIfd *Ifd, imageData []byte, findings *[]LintFinding) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	width, height, err := JpegDimensions(bytes.NewReader(imageData))
+	if err != nil {
+		if err == ErrNotJpeg {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	exifIfd, err := FindIfdFromRootIfd(rootIfd, exifcommon.IfdExifStandardIfdIdentity.UnindexedString())
+	if err != nil {
+		if log.Is(err, ErrIfdNotFound) == true {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	dimensions := []struct {
+		tagName string
+		actual  uint32
+	}{
+		{"PixelXDimension", width},
+		{"PixelYDimension", height},
+	}
+
+	for _, dimension := range dimensions {
+		recorded, found, err := recordedPixelDimension(exifIfd, dimension.tagName)
+		log.PanicIf(err)
+
+		if found == false || recorded == dimension.actual {
+			continue
+		}
+
+		*findings = append(*findings, LintFinding{
+			Code:      LintPixelDimensionMismatch,
+			Severity:  LintSeverityError,
+			IfdPath:   exifIfd.ifdIdentity.String(),
+			TagName:   dimension.tagName,
+			Message:   fmt.Sprintf("recorded as (%d) but the image is (%d)", recorded, dimension.actual),
+			Suggested: []uint32{dimension.actual},
+		})
+	}
+
+	return nil
+}
+
+// recordedPixelDimension returns the value of the given dimension tag of the
+// Exif IFD, which may be a SHORT or a LONG.
+func recordedPixelDimension(exifIfd *Ifd, tagName string) (recorded uint32, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := exifIfd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return 0, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	switch t := value.(type) {
+	case []uint16:
+		recorded = uint32(t[0])
+	case []uint32:
+		recorded = t[0]
+	default:
+		log.Panicf("%s has unexpected type: [%T]", tagName, value)
+	}
+
+	return recorded, true, nil
+}
+
+// JpegDimensions reads the segments of a JPEG stream up to the first frame
+// header (SOFn) and returns the dimensions of the image. `ErrNotJpeg` is
+// returned if the data is not a JPEG.
+func JpegDimensions(r io.Reader) (width, height uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	soi := make([]byte, 2)
+
+	_, err = io.ReadFull(r, soi)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, 0, ErrNotJpeg
+	}
+
+	log.PanicIf(err)
+
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return 0, 0, ErrNotJpeg
+	}
+
+	markerHeader := make([]byte, 4)
+	for {
+		_, err := io.ReadFull(r, markerHeader)
+		log.PanicIf(err)
+
+		if markerHeader[0] != 0xff {
+			log.Panicf("jpeg marker not found: (0x%02x)", markerHeader[0])
+		}
+
+		marker := markerHeader[1]
+
+		// Start-of-scan. A frame header must come before this.
+		if marker == 0xda {
+			log.Panicf("jpeg frame header not found")
+		}
+
+		length := int64(binary.BigEndian.Uint16(markerHeader[2:]))
+		if length < 2 {
+			log.Panicf("jpeg segment length not valid: (%d)", length)
+		}
+
+		payloadLength := length - 2
+
+		// SOF0 through SOF15, excluding DHT (0xc4), JPG (0xc8), and DAC (0xcc).
+		if marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			if payloadLength < 5 {
+				log.Panicf("jpeg frame header too short: (%d)", payloadLength)
+			}
+
+			frameHeader := make([]byte, 5)
+
+			_, err := io.ReadFull(r, frameHeader)
+			log.PanicIf(err)
+
+			// The first byte is the sample precision.
+			height = uint32(binary.BigEndian.Uint16(frameHeader[1:3]))
+			width = uint32(binary.BigEndian.Uint16(frameHeader[3:5]))
+
+			return width, height, nil
+		}
+
+		_, err = io.CopyN(ioutil.Discard, r, payloadLength)
+		log.PanicIf(err)
+	}
+}
@@ -0,0 +1,466 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getLintTestRootIfd builds EXIF with one of each problem that `Lint()`
+// reports, except for the pixel dimensions, and returns the parsed root IFD.
+func getLintTestRootIfd() *Ifd {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("XResolution", []exifcommon.Rational{{Numerator: 72, Denominator: 1}})
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("DateTime", "2019-01-02T03:04:05Z")
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("DateTimeOriginal", "not a date")
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("DateTimeDigitized", "    :  :     :  :  ")
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(exifcommon.IfdGpsInfoStandardIfdIdentity.String(), "GPSLatitude", []exifcommon.Rational{{Numerator: 1, Denominator: 1}, {Numerator: 2, Denominator: 1}, {Numerator: 3, Denominator: 1}})
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(exifcommon.IfdGpsInfoStandardIfdIdentity.String(), "GPSLongitudeRef", "W")
+	log.PanicIf(err)
+
+	err = rootIb.SetStandardWithNameInIfd(exifcommon.IfdGpsInfoStandardIfdIdentity.String(), "GPSDateStamp", "2019/1/2")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index.RootIfd
+}
+
+func TestLint(t *testing.T) {
+	rootIfd := getLintTestRootIfd()
+
+	findings, err := Lint(rootIfd, nil)
+	log.PanicIf(err)
+
+	type summary struct {
+		code      LintCode
+		severity  LintSeverity
+		ifdPath   string
+		tagName   string
+		suggested interface{}
+	}
+
+	actual := make([]summary, len(findings))
+	for i, lf := range findings {
+		actual[i] = summary{lf.Code, lf.Severity, lf.IfdPath, lf.TagName, lf.Suggested}
+	}
+
+	expected := []summary{
+		{LintResolutionUnitMissing, LintSeverityWarning, "IFD", "ResolutionUnit", []uint16{2}},
+		{LintDateTimeInvalid, LintSeverityWarning, "IFD", "DateTime", "2019:01:02 03:04:05"},
+		{LintDateTimeInvalid, LintSeverityError, "IFD/Exif", "DateTimeOriginal", nil},
+		{LintGpsLatitudeRefMissing, LintSeverityError, "IFD/GPSInfo", "GPSLatitudeRef", "N"},
+		{LintDateTimeInvalid, LintSeverityWarning, "IFD/GPSInfo", "GPSDateStamp", "2019:01:02"},
+	}
+
+	if reflect.DeepEqual(actual, expected) == false {
+		for i, s := range actual {
+			t.Logf("ACTUAL(%d): %v", i, s)
+		}
+
+		t.Fatalf("Findings not correct.")
+	}
+}
+
+func TestLint_PixelDimensions(t *testing.T) {
+	imageData, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rawExif, err := SearchAndExtractExif(imageData)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	findings, err := Lint(index.RootIfd, &LintOptions{ImageData: imageData})
+	log.PanicIf(err)
+
+	if len(findings) != 0 {
+		t.Fatalf("Expected no findings for the test image: %v", findings)
+	}
+
+	width, height, err := JpegDimensions(bytes.NewReader(imageData))
+	log.PanicIf(err)
+
+	// Change the image's height in its frame header. The last match is the
+	// image's rather than the thumbnail's (the scan data is byte-stuffed).
+
+	sofAt := bytes.LastIndex(imageData, []byte{0xff, 0xc0})
+	if sofAt == -1 {
+		t.Fatalf("Frame header not found.")
+	}
+
+	modified := make([]byte, len(imageData))
+	copy(modified, imageData)
+
+	modified[sofAt+5] = 0
+	modified[sofAt+6] = 100
+
+	findings, err = Lint(index.RootIfd, &LintOptions{ImageData: modified})
+	log.PanicIf(err)
+
+	if len(findings) != 1 {
+		t.Fatalf("Expected one finding: %v", findings)
+	}
+
+	lf := findings[0]
+
+	if lf.Code != LintPixelDimensionMismatch {
+		t.Fatalf("Code not correct: [%s]", lf.Code)
+	} else if lf.IfdPath != "IFD/Exif" {
+		t.Fatalf("IFD-path not correct: [%s]", lf.IfdPath)
+	} else if lf.TagName != "PixelYDimension" {
+		t.Fatalf("Tag not correct: [%s]", lf.TagName)
+	} else if reflect.DeepEqual(lf.Suggested, []uint32{100}) == false {
+		t.Fatalf("Suggested value not correct: %v", lf.Suggested)
+	}
+
+	if width == 0 || height == 100 {
+		t.Fatalf("Original dimensions not expected: (%d) (%d)", width, height)
+	}
+}
+
+func TestJpegDimensions(t *testing.T) {
+	f, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	width, height, err := JpegDimensions(bytes.NewReader(f))
+	log.PanicIf(err)
+
+	if width != 3840 || height != 2560 {
+		t.Fatalf("Dimensions not correct: (%d) (%d)", width, height)
+	}
+
+	_, _, err = JpegDimensions(bytes.NewReader([]byte("not a jpeg")))
+	if err != ErrNotJpeg {
+		t.Fatalf("Expected ErrNotJpeg: %v", err)
+	}
+}
+
+func TestNormalizeExifTimestamp(t *testing.T) {
+	cases := []struct {
+		phrase     string
+		isDateOnly bool
+		normalized string
+		isValid    bool
+	}{
+		{"2019:01:02 03:04:05", false, "2019:01:02 03:04:05", true},
+		{"2019-01-02 03:04:05", false, "2019:01:02 03:04:05", true},
+		{"2019-1-2T3:04:05+02:00", false, "2019:01:02 03:04:05", true},
+		{"2019:13:02 03:04:05", false, "", false},
+		{"2019:02:30 03:04:05", false, "", false},
+		{"2019:01:02", false, "", false},
+		{"2019:01:02", true, "2019:01:02", true},
+		{"2019/1/2", true, "2019:01:02", true},
+		{"garbage", true, "", false},
+	}
+
+	for _, c := range cases {
+		normalized, isValid := normalizeExifTimestamp(c.phrase, c.isDateOnly)
+		if normalized != c.normalized || isValid != c.isValid {
+			t.Fatalf("Normalization of [%s] not correct: [%s] %v", c.phrase, normalized, isValid)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	rootIfd := getLintTestRootIfd()
+
+	findings, err := Lint(rootIfd, nil)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(rootIfd)
+
+	fixed, err := Fix(rootIb, findings)
+	log.PanicIf(err)
+
+	if len(fixed) != 4 {
+		t.Fatalf("Expected four fixes: %v", fixed)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(rootIfd.ifdMapping, rootIfd.tagIndex, exifData)
+	log.PanicIf(err)
+
+	remaining, err := Lint(index.RootIfd, nil)
+	log.PanicIf(err)
+
+	if len(remaining) != 1 {
+		t.Fatalf("Expected one unfixable finding to remain: %v", remaining)
+	} else if remaining[0].TagName != "DateTimeOriginal" {
+		t.Fatalf("Remaining finding not correct: %v", remaining[0])
+	}
+
+	results, err := index.RootIfd.FindTagWithName("DateTime")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "2019:01:02 03:04:05" {
+		t.Fatalf("DateTime not fixed: [%v]", value)
+	}
+}
+
+func TestFix_PixelDimensions(t *testing.T) {
+	imageData, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rawExif, err := SearchAndExtractExif(imageData)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	findings := []LintFinding{
+		{
+			Code:      LintPixelDimensionMismatch,
+			Severity:  LintSeverityError,
+			IfdPath:   "IFD/Exif",
+			TagName:   "PixelYDimension",
+			Suggested: []uint32{100},
+		},
+	}
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	_, err = Fix(rootIb, findings)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	exifIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdExifStandardIfdIdentity)
+	log.PanicIf(err)
+
+	results, err := exifIfd.FindTagWithName("PixelYDimension")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []uint32{100}) == false {
+		t.Fatalf("PixelYDimension not fixed: %v", value)
+	}
+}
+
+func TestLint_Conformance_Exif(t *testing.T) {
+	rawExif := getTestExifData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	lo := &LintOptions{
+		Conformance: ConformanceExif,
+	}
+
+	findings, err := Lint(index.RootIfd, lo)
+	log.PanicIf(err)
+
+	if len(findings) != 0 {
+		t.Fatalf("Camera EXIF should conform: %v", findings)
+	}
+
+	// The ResolutionUnit that's missing from the other test data is already
+	// reported and isn't reported again.
+
+	findings, err = Lint(getLintTestRootIfd(), lo)
+	log.PanicIf(err)
+
+	missing := make([]string, 0)
+	for _, lf := range findings {
+		if lf.Code == LintMandatoryTagMissing {
+			missing = append(missing, lf.IfdPath+"/"+lf.TagName)
+		}
+	}
+
+	expected := []string{
+		"IFD/YResolution",
+		"IFD/YCbCrPositioning",
+		"IFD/Exif/ExifVersion",
+		"IFD/Exif/ComponentsConfiguration",
+		"IFD/Exif/FlashpixVersion",
+		"IFD/Exif/ColorSpace",
+		"IFD/Exif/PixelXDimension",
+		"IFD/Exif/PixelYDimension",
+	}
+
+	if reflect.DeepEqual(missing, expected) == false {
+		t.Fatalf("Missing tags not correct: %v", missing)
+	}
+}
+
+func TestLint_Conformance_TiffEpAndDng(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("ImageWidth", []uint32{100})
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("ImageLength", []uint32{100})
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("DNGVersion", []uint8{1, 4, 0, 0})
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	missingFor := func(cl ConformanceLevel) []string {
+		findings, err := Lint(index.RootIfd, &LintOptions{Conformance: cl})
+		log.PanicIf(err)
+
+		missing := make([]string, 0)
+		for _, lf := range findings {
+			if lf.Code == LintMandatoryTagMissing {
+				missing = append(missing, lf.TagName)
+			}
+		}
+
+		return missing
+	}
+
+	expectedTiffEp := []string{
+		"NewSubfileType",
+		"BitsPerSample",
+		"Compression",
+		"PhotometricInterpretation",
+		"SamplesPerPixel",
+		"XResolution",
+		"YResolution",
+		"ResolutionUnit",
+		"DateTime",
+		"TIFFEPStandardID",
+	}
+
+	if missing := missingFor(ConformanceTiffEp); reflect.DeepEqual(missing, expectedTiffEp) == false {
+		t.Fatalf("Missing TIFF/EP tags not correct: %v", missing)
+	}
+
+	if missing := missingFor(ConformanceDng); reflect.DeepEqual(missing, []string{"UniqueCameraModel"}) == false {
+		t.Fatalf("Missing DNG tags not correct: %v", missing)
+	}
+
+	if missing := missingFor(ConformanceNone); len(missing) != 0 {
+		t.Fatalf("Nothing should be mandatory without a conformance level: %v", missing)
+	}
+}
+
+func TestLint_Conformance_UnreadableTag(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	// A private tag that the parser doesn't know and will skip.
+	bt := NewBuilderTag(
+		exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		0xfff0,
+		exifcommon.TypeByte,
+		NewIfdBuilderTagValueFromBytes([]byte{1, 2, 3, 4, 5}),
+		exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.Add(bt)
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("Make", "some make")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	unreadableSeverities := func(cl ConformanceLevel) []LintSeverity {
+		findings, err := Lint(index.RootIfd, &LintOptions{Conformance: cl})
+		log.PanicIf(err)
+
+		severities := make([]LintSeverity, 0)
+		for _, lf := range findings {
+			if lf.Code == LintUnreadableTag {
+				severities = append(severities, lf.Severity)
+			}
+		}
+
+		return severities
+	}
+
+	if severities := unreadableSeverities(ConformanceExif); reflect.DeepEqual(severities, []LintSeverity{LintSeverityWarning}) == false {
+		t.Fatalf("EXIF severities not correct: %v", severities)
+	} else if severities := unreadableSeverities(ConformanceTiffEp); reflect.DeepEqual(severities, []LintSeverity{LintSeverityInfo}) == false {
+		t.Fatalf("TIFF/EP severities not correct: %v", severities)
+	} else if severities := unreadableSeverities(ConformanceDng); len(severities) != 0 {
+		t.Fatalf("DNG should not report unreadable tags: %v", severities)
+	} else if severities := unreadableSeverities(ConformanceNone); len(severities) != 0 {
+		t.Fatalf("Unreadable tags should not be reported by default: %v", severities)
+	}
+}
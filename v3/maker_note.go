@@ -0,0 +1,338 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrMakerNoteNotSupported means that no registered parser accepts the
+	// maker-note.
+	ErrMakerNoteNotSupported = errors.New("maker-note not supported")
+)
+
+// MakerNoteContext is what a `MakerNoteParser` is given: the maker-note and
+// what it needs to know about the EXIF that it's in.
+type MakerNoteContext struct {
+	// Make is the Make from IFD0, or empty if there isn't one.
+	Make string
+
+	// Model is the Model from IFD0, or empty if there isn't one.
+	Model string
+
+	// Data is the raw value of the MakerNote tag.
+	Data []byte
+
+	// Offset is the position of the maker-note in the EXIF. Maker-notes
+	// without their own TIFF header usually have offsets relative to the
+	// EXIF rather than to themselves.
+	Offset uint32
+
+	// ByteOrder is the byte-order of the EXIF.
+	ByteOrder binary.ByteOrder
+
+	// ebs reads the EXIF that the maker-note is in.
+	ebs ExifBlobSeeker
+}
+
+// String returns a descriptive string.
+func (mnc MakerNoteContext) String() string {
+	return fmt.Sprintf("MakerNoteContext<MAKE=[%s] MODEL=[%s] OFFSET=(%d) SIZE=(%d)>", mnc.Make, mnc.Model, mnc.Offset, len(mnc.Data))
+}
+
+// ParseIfd parses the IFDs of an IFD-structured maker-note with the given
+// identity and tags. The preamble, if it's one that we recognize (see
+// `MakerNoteHeaders`), decides where the IFD is, its byte-order, and what its
+// offsets are relative to. Otherwise, the maker-note is taken to be a bare IFD
+// in the byte-order and offset-space of the EXIF (as Canon's are). The
+// identity is only known to the mapping that the IFD is parsed with, so its
+// tags don't collide with the standard ones.
+func (mnc MakerNoteContext) ParseIfd(ii *exifcommon.IfdIdentity, tagIndex *TagIndex) (index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	im := exifcommon.NewIfdMapping()
+
+	err = im.Add([]uint16{}, ii.TagId(), ii.Name())
+	log.PanicIf(err)
+
+	root, ebs, makerNoteOffset, err := mnc.rootOffsetScope()
+	log.PanicIf(err)
+
+	scope, ifdOffset, err := root.NewMakerNoteOffsetScope(makerNoteOffset, mnc.Data)
+	log.PanicIf(err)
+
+	// A maker-note with its own offset-space is read by itself, so that its
+	// offsets can't reach outside of it.
+	if scope.Origin() != root.Origin() {
+		start := scope.Origin() - makerNoteOffset
+		ebs = NewExifReadSeekerWithBytes(mnc.Data[start : start+scope.Size()])
+	}
+
+	byteOrder := scope.ByteOrder()
+	if byteOrder == nil {
+		log.Panicf("maker-note byte-order not known")
+	}
+
+	ie := NewIfdEnumerate(im, tagIndex, ebs, byteOrder)
+
+	index, err = ie.collect(ii, ifdOffset)
+	log.PanicIf(err)
+
+	return index, nil
+}
+
+// rootOffsetScope returns the offset-scope of the EXIF that the maker-note is
+// in, what reads it, and the offset of the maker-note in it. Without the EXIF,
+// the maker-note is its own root (which is enough for those that have their
+// own TIFF header).
+func (mnc MakerNoteContext) rootOffsetScope() (root *OffsetScope, ebs ExifBlobSeeker, makerNoteOffset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if mnc.ebs == nil {
+		size, err := exifcommon.CheckedIntToUint32(len(mnc.Data))
+		log.PanicIf(err)
+
+		root = NewOffsetScope("MakerNote", mnc.ByteOrder, size)
+
+		return root, NewExifReadSeekerWithBytes(mnc.Data), 0, nil
+	}
+
+	rs, err := mnc.ebs.GetReadSeeker(0)
+	log.PanicIf(err)
+
+	end, err := rs.Seek(0, io.SeekEnd)
+	log.PanicIf(err)
+
+	if end > math.MaxUint32 {
+		end = math.MaxUint32
+	}
+
+	root = NewOffsetScope("Exif", mnc.ByteOrder, uint32(end))
+
+	return root, mnc.ebs, mnc.Offset, nil
+}
+
+// MakerNoteParser parses the maker-notes of one vendor (or one format of a
+// vendor's).
+type MakerNoteParser interface {
+	// Name returns a unique name for the parser (e.g. "Nikon").
+	Name() string
+
+	// Accepts returns true if the parser handles the given maker-note. This
+	// is decided from the Make and the maker-note signature and should be
+	// cheap.
+	Accepts(mnc MakerNoteContext) bool
+
+	// Parse parses the maker-note into an IFD tree. Most maker-notes are
+	// IFD-structured and can use `MakerNoteContext.ParseIfd()`.
+	Parse(mnc MakerNoteContext) (index IfdIndex, err error)
+}
+
+var (
+	makerNoteParsers      = make([]MakerNoteParser, 0)
+	makerNoteParsersMutex sync.RWMutex
+)
+
+// RegisterMakerNoteParser adds a parser to those consulted for maker-notes.
+// Parsers are consulted in the order that they were registered and the first
+// that accepts a maker-note parses it. It is a programming error to register
+// two parsers with the same name.
+func RegisterMakerNoteParser(mnp MakerNoteParser) {
+	makerNoteParsersMutex.Lock()
+	defer makerNoteParsersMutex.Unlock()
+
+	name := mnp.Name()
+	for _, existing := range makerNoteParsers {
+		if existing.Name() == name {
+			log.Panicf("maker-note parser already registered: [%s]", name)
+		}
+	}
+
+	makerNoteParsers = append(makerNoteParsers, mnp)
+}
+
+// UnregisterMakerNoteParser removes the parser with the given name. Returns
+// false if it was not registered.
+func UnregisterMakerNoteParser(name string) bool {
+	makerNoteParsersMutex.Lock()
+	defer makerNoteParsersMutex.Unlock()
+
+	for i, existing := range makerNoteParsers {
+		if existing.Name() == name {
+			makerNoteParsers = append(makerNoteParsers[:i], makerNoteParsers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// findMakerNoteParser returns the first registered parser that accepts the
+// maker-note, or nil.
+func findMakerNoteParser(mnc MakerNoteContext) MakerNoteParser {
+	makerNoteParsersMutex.RLock()
+	defer makerNoteParsersMutex.RUnlock()
+
+	for _, mnp := range makerNoteParsers {
+		if mnp.Accepts(mnc) == true {
+			return mnp
+		}
+	}
+
+	return nil
+}
+
+// MakerNote is a parsed maker-note.
+type MakerNote struct {
+	// Parser is the name of the parser that parsed it.
+	Parser string
+
+	// Index has the IFDs of the maker-note.
+	Index IfdIndex
+}
+
+// String returns a descriptive string.
+func (mn MakerNote) String() string {
+	return fmt.Sprintf("MakerNote<PARSER=[%s] IFDS=(%d)>", mn.Parser, len(mn.Index.Ifds))
+}
+
+// NewMakerNoteContext returns the context for the maker-note of the Exif IFD
+// of the given index. `found` is false if there's no maker-note.
+func NewMakerNoteContext(index IfdIndex) (mnc MakerNoteContext, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	exifIfd, found := index.Lookup[exifcommon.IfdExifStandardIfdIdentity.String()]
+	if found == false {
+		return mnc, false, nil
+	}
+
+	results, err := exifIfd.FindTagWithId(MakerNoteTagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return mnc, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	ite := results[0]
+
+	data, err := ite.GetRawBytes()
+	log.PanicIf(err)
+
+	mnc = MakerNoteContext{
+		Data:      data,
+		Offset:    ite.getValueOffset(),
+		ByteOrder: exifIfd.ByteOrder(),
+	}
+
+	if ite.data != nil {
+		mnc.ebs = NewExifReadSeekerWithBytes(ite.data)
+	} else {
+		mnc.ebs = NewExifReadSeeker(ite.rs)
+	}
+
+	for _, tagName := range []string{"Make", "Model"} {
+		results, err := index.RootIfd.FindTagWithName(tagName)
+		if err != nil {
+			if log.Is(err, ErrTagNotFound) == true {
+				continue
+			}
+
+			log.Panic(err)
+		}
+
+		value, err := results[0].Value()
+		log.PanicIf(err)
+
+		s, _ := value.(string)
+		s = strings.TrimSpace(s)
+
+		if tagName == "Make" {
+			mnc.Make = s
+		} else {
+			mnc.Model = s
+		}
+	}
+
+	return mnc, true, nil
+}
+
+// ParseMakerNote parses the maker-note of the given index with the first
+// registered parser that accepts it. `found` is false if there's no
+// maker-note. `ErrMakerNoteNotSupported` is returned if no parser accepts it.
+func ParseMakerNote(index IfdIndex) (mn MakerNote, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	mnc, found, err := NewMakerNoteContext(index)
+	log.PanicIf(err)
+
+	if found == false {
+		return mn, false, nil
+	}
+
+	mnp := findMakerNoteParser(mnc)
+	if mnp == nil {
+		return mn, true, ErrMakerNoteNotSupported
+	}
+
+	makerNoteIndex, err := mnp.Parse(mnc)
+	log.PanicIf(err)
+
+	mn = MakerNote{
+		Parser: mnp.Name(),
+		Index:  makerNoteIndex,
+	}
+
+	return mn, true, nil
+}
+
+// nikonMakerNoteParser parses the maker-notes of Nikons since the D1 (see
+// `ParseNikonMakerNote()`).
+type nikonMakerNoteParser struct{}
+
+// Name returns the name of the parser.
+func (nikonMakerNoteParser) Name() string {
+	return "Nikon"
+}
+
+// Accepts returns true for maker-notes with the Nikon preamble.
+func (nikonMakerNoteParser) Accepts(mnc MakerNoteContext) bool {
+	mnh, found := DetectMakerNoteHeader(mnc.Data)
+	return found == true && mnh.Name == "Nikon3"
+}
+
+// Parse parses the maker-note.
+func (nikonMakerNoteParser) Parse(mnc MakerNoteContext) (index IfdIndex, err error) {
+	return ParseNikonMakerNote(mnc.Data)
+}
+
+func init() {
+	RegisterMakerNoteParser(nikonMakerNoteParser{})
+}
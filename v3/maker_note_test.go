@@ -0,0 +1,160 @@
+package exif
+
+import (
+	"strings"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+var (
+	testMakerNoteIfdTag      = exifcommon.NewIfdTag(nil, MakerNoteTagId, "MakerNoteTest")
+	testMakerNoteIfdIdentity = exifcommon.NewIfdIdentity(testMakerNoteIfdTag, exifcommon.IfdIdentityPart{Name: "MakerNoteTest", Index: 0})
+)
+
+// testMakerNoteParser parses the bare-IFD maker-notes of a fictional vendor.
+type testMakerNoteParser struct{}
+
+func (testMakerNoteParser) Name() string {
+	return "Test"
+}
+
+func (testMakerNoteParser) Accepts(mnc MakerNoteContext) bool {
+	return strings.HasPrefix(mnc.Make, "TESTCAM")
+}
+
+func (testMakerNoteParser) Parse(mnc MakerNoteContext) (index IfdIndex, err error) {
+	ti := NewTagIndex()
+
+	it := &IndexedTag{
+		Id:             0x0001,
+		Name:           "Mode",
+		IfdPath:        testMakerNoteIfdIdentity.UnindexedString(),
+		SupportedTypes: []exifcommon.TagTypePrimitive{exifcommon.TypeShort},
+	}
+
+	err = ti.Add(it)
+	log.PanicIf(err)
+
+	return mnc.ParseIfd(testMakerNoteIfdIdentity, ti)
+}
+
+// buildTestMakerNoteExif returns EXIF with the given make and maker-note.
+func buildTestMakerNoteExif(byteOrder binary.ByteOrder, makeValue string, makerNoteData []byte) []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib.AddStandardWithName("Make", makeValue)
+	log.PanicIf(err)
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, byteOrder)
+
+	makerNote := exifundefined.Tag927CMakerNote{
+		MakerNoteBytes: makerNoteData,
+	}
+
+	err = exifIb.AddStandardWithName("MakerNote", makerNote)
+	log.PanicIf(err)
+
+	err = ib.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	data, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	return data
+}
+
+func collectTestMakerNoteExif(data []byte) IfdIndex {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, NewTagIndex(), data)
+	log.PanicIf(err)
+
+	return index
+}
+
+func TestParseMakerNote_Nikon(t *testing.T) {
+	index := collectTestMakerNoteExif(buildTestNef())
+
+	mn, found, err := ParseMakerNote(index)
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Maker-note not found.")
+	} else if mn.Parser != "Nikon" {
+		t.Fatalf("Parser not correct: [%s]", mn.Parser)
+	}
+
+	_, err = mn.Index.RootIfd.FindTagWithName("ISO")
+	log.PanicIf(err)
+}
+
+func TestRegisterMakerNoteParser(t *testing.T) {
+	RegisterMakerNoteParser(testMakerNoteParser{})
+	defer UnregisterMakerNoteParser("Test")
+
+	byteOrder := binary.BigEndian
+
+	// A bare IFD with one SHORT.
+
+	makerNoteData := make([]byte, 2+12+4)
+	byteOrder.PutUint16(makerNoteData[0:2], 1)
+	byteOrder.PutUint16(makerNoteData[2:4], 0x0001)
+	byteOrder.PutUint16(makerNoteData[4:6], uint16(exifcommon.TypeShort))
+	byteOrder.PutUint32(makerNoteData[6:10], 1)
+	byteOrder.PutUint16(makerNoteData[10:12], 3)
+
+	index := collectTestMakerNoteExif(buildTestMakerNoteExif(byteOrder, "TESTCAM", makerNoteData))
+
+	mn, found, err := ParseMakerNote(index)
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Maker-note not found.")
+	} else if mn.Parser != "Test" {
+		t.Fatalf("Parser not correct: [%s]", mn.Parser)
+	}
+
+	results, err := mn.Index.RootIfd.FindTagWithName("Mode")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint16)[0] != 3 {
+		t.Fatalf("Mode not correct: %v", value)
+	}
+
+	// Once it's gone, nothing handles the maker-note.
+
+	if UnregisterMakerNoteParser("Test") != true {
+		t.Fatalf("Parser not unregistered.")
+	}
+
+	_, found, err = ParseMakerNote(index)
+	if found != true || err != ErrMakerNoteNotSupported {
+		t.Fatalf("Expected not-supported error: [%v]", err)
+	}
+}
+
+func TestParseMakerNote_NoMakerNote(t *testing.T) {
+	_, found, err := ParseMakerNote(collectTestMakerNoteExif(getExifSimpleTestIbBytes()))
+	log.PanicIf(err)
+
+	if found != false {
+		t.Fatalf("Expected no maker-note.")
+	}
+}
@@ -0,0 +1,179 @@
+package exif
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ParseOperation names the kind of parse that a measurement is for.
+type ParseOperation string
+
+const (
+	// ParseOperationScan is `IfdEnumerate.Scan()` (and, so, `Visit()` and
+	// `GetFlatExifData()`).
+	ParseOperationScan ParseOperation = "scan"
+
+	// ParseOperationCollect is `IfdEnumerate.Collect()` (and, so,
+	// `Collect()`).
+	ParseOperationCollect ParseOperation = "collect"
+)
+
+// ParseWarning names a recoverable problem found while parsing. The data was
+// parsed anyway, usually by skipping the offending element.
+type ParseWarning string
+
+const (
+	// ParseWarningTagTypeInvalid means that a tag had a type that isn't
+	// defined by the specification.
+	ParseWarningTagTypeInvalid ParseWarning = "tag-type-invalid"
+
+	// ParseWarningTagTypeUnsupported means that a tag had a type that isn't
+	// allowed for that tag.
+	ParseWarningTagTypeUnsupported ParseWarning = "tag-type-unsupported"
+
+	// ParseWarningTagUnknown means that a tag isn't known in its IFD.
+	ParseWarningTagUnknown ParseWarning = "tag-unknown"
+
+	// ParseWarningTagMisplaced means that a tag isn't known in its IFD but
+	// was identified as belonging to a different one.
+	ParseWarningTagMisplaced ParseWarning = "tag-misplaced"
+
+	// ParseWarningZeroLengthAscii means that an empty ASCII value was skipped.
+	ParseWarningZeroLengthAscii ParseWarning = "zero-length-ascii"
+
+	// ParseWarningNextIfdOffsetMissing means that the last IFD was missing its
+	// next-IFD offset.
+	ParseWarningNextIfdOffsetMissing ParseWarning = "next-ifd-offset-missing"
+
+	// ParseWarningIfdCycle means that an IFD was linked-to more than once.
+	ParseWarningIfdCycle ParseWarning = "ifd-cycle"
+
+	// ParseWarningAsciiTruncated means that an ASCII value was longer than
+	// the maximum string length and was truncated.
+	ParseWarningAsciiTruncated ParseWarning = "ascii-truncated"
+)
+
+// Metrics receives measurements of parse operations so that services can
+// monitor extraction without wrapping every call. Implementations must be
+// safe for concurrent use and should be cheap, since they are called inline.
+type Metrics interface {
+	// ParseCompleted is called once for each scan or collect. `bytesProcessed`
+	// is the furthest offset reached in the EXIF data. `err` is nil on
+	// success.
+	ParseCompleted(operation ParseOperation, bytesProcessed uint32, duration time.Duration, err error)
+
+	// ParseWarned is called for each recoverable problem.
+	ParseWarned(warning ParseWarning)
+}
+
+var (
+	metrics      Metrics
+	metricsMutex sync.RWMutex
+)
+
+// SetMetrics installs the receiver for parse measurements. Passing nil
+// disables them (the default).
+func SetMetrics(m Metrics) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+
+	metrics = m
+}
+
+func currentMetrics() Metrics {
+	metricsMutex.RLock()
+	defer metricsMutex.RUnlock()
+
+	return metrics
+}
+
+func recordParseWarning(warning ParseWarning) {
+	if m := currentMetrics(); m != nil {
+		m.ParseWarned(warning)
+	}
+}
+
+func recordParseCompleted(operation ParseOperation, bytesProcessed uint32, startedAt time.Time, err error) {
+	if m := currentMetrics(); m != nil {
+		m.ParseCompleted(operation, bytesProcessed, time.Since(startedAt), err)
+	}
+}
+
+// CounterMetrics is a `Metrics` that keeps running totals, in the manner of
+// Prometheus counters. Use `Snapshot()` to read them, e.g. from a metrics
+// endpoint.
+type CounterMetrics struct {
+	parses         uint64
+	parseErrors    uint64
+	bytesProcessed uint64
+	durationNs     uint64
+
+	warningsMutex sync.Mutex
+	warnings      map[ParseWarning]uint64
+}
+
+// CounterMetricsSnapshot is a point-in-time copy of the totals.
+type CounterMetricsSnapshot struct {
+	// Parses is the number of parses, successful or not.
+	Parses uint64
+
+	// ParseErrors is the number of parses that failed.
+	ParseErrors uint64
+
+	// BytesProcessed is the sum of the bytes processed by all parses.
+	BytesProcessed uint64
+
+	// Duration is the time spent in all parses.
+	Duration time.Duration
+
+	// Warnings are the counts of each kind of warning.
+	Warnings map[ParseWarning]uint64
+}
+
+// NewCounterMetrics returns a `CounterMetrics` with all totals at zero.
+func NewCounterMetrics() *CounterMetrics {
+	return &CounterMetrics{
+		warnings: make(map[ParseWarning]uint64),
+	}
+}
+
+// ParseCompleted implements `Metrics`.
+func (cm *CounterMetrics) ParseCompleted(operation ParseOperation, bytesProcessed uint32, duration time.Duration, err error) {
+	atomic.AddUint64(&cm.parses, 1)
+
+	if err != nil {
+		atomic.AddUint64(&cm.parseErrors, 1)
+	}
+
+	atomic.AddUint64(&cm.bytesProcessed, uint64(bytesProcessed))
+	atomic.AddUint64(&cm.durationNs, uint64(duration))
+}
+
+// ParseWarned implements `Metrics`.
+func (cm *CounterMetrics) ParseWarned(warning ParseWarning) {
+	cm.warningsMutex.Lock()
+	defer cm.warningsMutex.Unlock()
+
+	cm.warnings[warning]++
+}
+
+// Snapshot returns the current totals.
+func (cm *CounterMetrics) Snapshot() CounterMetricsSnapshot {
+	cm.warningsMutex.Lock()
+
+	warnings := make(map[ParseWarning]uint64, len(cm.warnings))
+	for warning, count := range cm.warnings {
+		warnings[warning] = count
+	}
+
+	cm.warningsMutex.Unlock()
+
+	return CounterMetricsSnapshot{
+		Parses:         atomic.LoadUint64(&cm.parses),
+		ParseErrors:    atomic.LoadUint64(&cm.parseErrors),
+		BytesProcessed: atomic.LoadUint64(&cm.bytesProcessed),
+		Duration:       time.Duration(atomic.LoadUint64(&cm.durationNs)),
+		Warnings:       warnings,
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestCounterMetrics_Collect(t *testing.T) {
+	cm := NewCounterMetrics()
+
+	SetMetrics(cm)
+	defer SetMetrics(nil)
+
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, _, err = Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	_, _, err = Collect(im, ti, rawExif[:100])
+	if err == nil {
+		t.Fatalf("Expected error for truncated data.")
+	}
+
+	cms := cm.Snapshot()
+
+	if cms.Parses != 2 {
+		t.Fatalf("Parse count not correct: (%d)", cms.Parses)
+	} else if cms.ParseErrors != 1 {
+		t.Fatalf("Parse-error count not correct: (%d)", cms.ParseErrors)
+	} else if cms.BytesProcessed == 0 {
+		t.Fatalf("Bytes processed not recorded.")
+	} else if cms.Duration <= 0 {
+		t.Fatalf("Duration not recorded.")
+	}
+}
+
+func TestCounterMetrics_Warnings(t *testing.T) {
+	cm := NewCounterMetrics()
+
+	SetMetrics(cm)
+	defer SetMetrics(nil)
+
+	// One IFD with a tag of an invalid type and a tag that isn't known.
+
+	exifData, err := BuildExifHeader(binary.BigEndian, ExifDefaultFirstIfdOffset)
+	log.PanicIf(err)
+
+	ifd := make([]byte, 2+12*2+4)
+	binary.BigEndian.PutUint16(ifd[0:], 2)
+
+	binary.BigEndian.PutUint16(ifd[2:], 0x010f)
+	binary.BigEndian.PutUint16(ifd[4:], 0x99)
+	binary.BigEndian.PutUint32(ifd[6:], 1)
+
+	binary.BigEndian.PutUint16(ifd[14:], 0xfffe)
+	binary.BigEndian.PutUint16(ifd[16:], uint16(exifcommon.TypeShort))
+	binary.BigEndian.PutUint32(ifd[18:], 1)
+
+	exifData = append(exifData, ifd...)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, exifData, func(ite *IfdTagEntry) error { return nil }, nil)
+	log.PanicIf(err)
+
+	cms := cm.Snapshot()
+
+	if cms.Parses != 1 || cms.ParseErrors != 0 {
+		t.Fatalf("Parse counts not correct: (%d) (%d)", cms.Parses, cms.ParseErrors)
+	} else if cms.Warnings[ParseWarningTagTypeInvalid] != 1 {
+		t.Fatalf("Invalid-type warning not recorded: %v", cms.Warnings)
+	} else if cms.Warnings[ParseWarningTagUnknown] != 1 {
+		t.Fatalf("Unknown-tag warning not recorded: %v", cms.Warnings)
+	}
+}
+
+func TestSetMetrics_Nil(t *testing.T) {
+	SetMetrics(nil)
+
+	// Nothing should be recorded and nothing should break.
+	recordParseWarning(ParseWarningIfdCycle)
+}
@@ -0,0 +1,203 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+var (
+	// ErrNotNef means that the data is not a TIFF made by a Nikon camera.
+	ErrNotNef = errors.New("not a nef file")
+
+	// ErrNotNikonMakerNote means that a maker-note doesn't have the Nikon
+	// preamble with an embedded TIFF header (the format of every Nikon since
+	// the D1).
+	ErrNotNikonMakerNote = errors.New("not a nikon maker-note")
+)
+
+var (
+	// nikonMakerNoteIfdTag is the root of the tags of Nikon maker-notes.
+	nikonMakerNoteIfdTag = exifcommon.NewIfdTag(nil, MakerNoteTagId, "MakerNoteNikon")
+
+	// NikonMakerNoteIfdIdentity is the identity of the IFD of a Nikon
+	// maker-note. Its tags are only known to the index that the maker-note is
+	// parsed with.
+	NikonMakerNoteIfdIdentity = exifcommon.NewIfdIdentity(nikonMakerNoteIfdTag, exifcommon.IfdIdentityPart{Name: "MakerNoteNikon", Index: 0})
+)
+
+var (
+	// nikonMakerNoteTags are the Nikon maker-note tags that we recognize.
+	// Others are skipped when parsing.
+	nikonMakerNoteTags = []struct {
+		id       uint16
+		name     string
+		typeName exifcommon.TagTypePrimitive
+	}{
+		{0x0001, "MakerNoteVersion", exifcommon.TypeUndefined},
+		{0x0002, "ISO", exifcommon.TypeShort},
+		{0x0003, "ColorMode", exifcommon.TypeAscii},
+		{0x0004, "Quality", exifcommon.TypeAscii},
+		{0x0005, "WhiteBalance", exifcommon.TypeAscii},
+		{0x0006, "Sharpness", exifcommon.TypeAscii},
+		{0x0007, "FocusMode", exifcommon.TypeAscii},
+		{0x0008, "FlashSetting", exifcommon.TypeAscii},
+		{0x0009, "FlashType", exifcommon.TypeAscii},
+		{0x000c, "WB_RBLevels", exifcommon.TypeRational},
+		{0x001d, "SerialNumber", exifcommon.TypeAscii},
+		{0x001e, "ColorSpace", exifcommon.TypeShort},
+		{0x0022, "ActiveDLighting", exifcommon.TypeShort},
+		{0x0083, "LensType", exifcommon.TypeByte},
+		{0x0084, "Lens", exifcommon.TypeRational},
+		{0x0093, "NEFCompression", exifcommon.TypeShort},
+		{0x00a7, "ShutterCount", exifcommon.TypeLong},
+		{0x00ab, "VariProgram", exifcommon.TypeAscii},
+	}
+
+	nikonMakerNoteTagIndex     *TagIndex
+	nikonMakerNoteTagIndexOnce sync.Once
+)
+
+// getNikonMakerNoteTagIndex returns the tag index for Nikon maker-notes. It is
+// separate from the standard tags so that the names (several of which are
+// also standard names) don't collide.
+func getNikonMakerNoteTagIndex() *TagIndex {
+	nikonMakerNoteTagIndexOnce.Do(func() {
+		ti := NewTagIndex()
+
+		ifdPath := NikonMakerNoteIfdIdentity.UnindexedString()
+		for _, nmt := range nikonMakerNoteTags {
+			it := &IndexedTag{
+				Id:             nmt.id,
+				Name:           nmt.name,
+				IfdPath:        ifdPath,
+				SupportedTypes: []exifcommon.TagTypePrimitive{nmt.typeName},
+				Group:          TagGroupCamera,
+			}
+
+			err := ti.Add(it)
+			log.PanicIf(err)
+		}
+
+		nikonMakerNoteTagIndex = ti
+	})
+
+	return nikonMakerNoteTagIndex
+}
+
+// ParseNikonMakerNote parses the IFDs of the given Nikon maker-note (the raw
+// value of the MakerNote tag). The maker-note embeds its own TIFF header and
+// all offsets are relative to it. `ErrNotNikonMakerNote` is returned if the
+// maker-note isn't in this format.
+func ParseNikonMakerNote(makerNoteData []byte) (index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	mnh, found := DetectMakerNoteHeader(makerNoteData)
+	if found == false || mnh.Name != "Nikon3" {
+		return index, ErrNotNikonMakerNote
+	}
+
+	// The embedded TIFF header makes the maker-note self-contained, so nothing
+	// else about the EXIF is needed.
+	mnc := MakerNoteContext{
+		Data: makerNoteData,
+	}
+
+	index, err = mnc.ParseIfd(NikonMakerNoteIfdIdentity, getNikonMakerNoteTagIndex())
+	log.PanicIf(err)
+
+	return index, nil
+}
+
+// NefFile is a parsed Nikon NEF raw file. A NEF is a TIFF whose IFD0 describes
+// a small thumbnail and has the EXIF and GPS IFDs as children (with the Nikon
+// maker-note in the EXIF IFD) and a SubIFDs tag that lists the IFDs of the
+// JPEG preview and the raw data.
+type NefFile struct {
+	*TiffRawFile
+}
+
+// NewNefFile parses the IFDs of the given NEF file, including the sub-IFDs of
+// IFD0. `ErrNotNef` is returned if the data isn't a TIFF or IFD0 doesn't have
+// a Nikon make.
+func NewNefFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (nf *NefFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	trf, err := NewTiffRawFile(ifdMapping, tagIndex, data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, ErrNotNef
+		}
+
+		log.Panic(err)
+	}
+
+	isNikon, err := trf.hasMakePrefix("NIKON")
+	log.PanicIf(err)
+
+	if isNikon == false {
+		return nil, ErrNotNef
+	}
+
+	nf = &NefFile{
+		TiffRawFile: trf,
+	}
+
+	return nf, nil
+}
+
+// MakerNote parses the Nikon maker-note of the EXIF IFD. `found` is false if
+// there isn't a maker-note or it isn't in the format that
+// `ParseNikonMakerNote()` supports (older models).
+func (nf *NefFile) MakerNote() (index IfdIndex, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	exifIfd, found := nf.ExifIfd()
+	if found == false {
+		return index, false, nil
+	}
+
+	results, err := exifIfd.FindTagWithId(MakerNoteTagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return index, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	makerNoteData, err := results[0].GetRawBytes()
+	log.PanicIf(err)
+
+	index, err = ParseNikonMakerNote(makerNoteData)
+	if err != nil {
+		if err == ErrNotNikonMakerNote {
+			return index, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	return index, true, nil
+}
+
+// String returns a descriptive string.
+func (nf *NefFile) String() string {
+	return fmt.Sprintf("NefFile<SIZE=(%d) IFDS=(%d) SUB-IFDS=(%d)>", len(nf.data), len(nf.index.Ifds), len(nf.subIfds))
+}
@@ -0,0 +1,234 @@
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+// buildTestNikonMakerNote returns a Nikon maker-note with an ISO, a serial
+// number, and a tag that we don't know.
+func buildTestNikonMakerNote() []byte {
+	byteOrder := binary.BigEndian
+
+	data := []byte("Nikon\x00\x02\x10\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+
+	ifd := make([]byte, 2+3*12+4)
+	byteOrder.PutUint16(ifd[0:2], 3)
+
+	entries := []struct {
+		tagId     uint16
+		tagType   exifcommon.TagTypePrimitive
+		unitCount uint32
+		value     []byte
+	}{
+		{0x0002, exifcommon.TypeShort, 1, []byte{0x01, 0x90, 0, 0}},
+		{0x001d, exifcommon.TypeAscii, 4, []byte("123\x00")},
+		{0x0fff, exifcommon.TypeLong, 1, []byte{0, 0, 0, 1}},
+	}
+
+	for i, entry := range entries {
+		raw := ifd[2+i*12:]
+
+		byteOrder.PutUint16(raw[0:2], entry.tagId)
+		byteOrder.PutUint16(raw[2:4], uint16(entry.tagType))
+		byteOrder.PutUint32(raw[4:8], entry.unitCount)
+		copy(raw[8:12], entry.value)
+	}
+
+	return append(data, ifd...)
+}
+
+// buildTestNefSubIfd returns an IFD with the given NewSubfileType and width.
+func buildTestNefSubIfd(byteOrder binary.ByteOrder, subfileType, width uint32) []byte {
+	ifd := make([]byte, 2+2*12+4)
+	byteOrder.PutUint16(ifd[0:2], 2)
+
+	byteOrder.PutUint16(ifd[2:4], NewSubfileTypeTagId)
+	byteOrder.PutUint16(ifd[4:6], uint16(exifcommon.TypeLong))
+	byteOrder.PutUint32(ifd[6:10], 1)
+	byteOrder.PutUint32(ifd[10:14], subfileType)
+
+	byteOrder.PutUint16(ifd[14:16], 0x0100)
+	byteOrder.PutUint16(ifd[16:18], uint16(exifcommon.TypeLong))
+	byteOrder.PutUint32(ifd[18:22], 1)
+	byteOrder.PutUint32(ifd[22:26], width)
+
+	return ifd
+}
+
+// buildTestNef returns a minimal NEF with a maker-note and two sub-IFDs: a
+// preview and the raw data.
+func buildTestNef() []byte {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	byteOrder := binary.LittleEndian
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, byteOrder)
+
+	err = ib.AddStandardWithName("Make", "NIKON CORPORATION")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("SubIFDs", []uint32{0, 0})
+	log.PanicIf(err)
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, byteOrder)
+
+	makerNote := exifundefined.Tag927CMakerNote{
+		MakerNoteBytes: buildTestNikonMakerNote(),
+	}
+
+	err = exifIb.AddStandardWithName("MakerNote", makerNote)
+	log.PanicIf(err)
+
+	err = ib.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	data, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	// Put the sub-IFDs at the end and then point the SubIFDs tag at them.
+
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+
+	previewOffset := uint32(len(data))
+	data = append(data, buildTestNefSubIfd(byteOrder, 1, 640)...)
+
+	rawOffset := uint32(len(data))
+	data = append(data, buildTestNefSubIfd(byteOrder, 0, 6000)...)
+
+	_, index, err := Collect(im, ti, data)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(SubIfdsTagId)
+	log.PanicIf(err)
+
+	valueOffset := results[0].getValueOffset()
+	byteOrder.PutUint32(data[valueOffset:], previewOffset)
+	byteOrder.PutUint32(data[valueOffset+4:], rawOffset)
+
+	return data
+}
+
+func TestNewNefFile(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	nf, err := NewNefFile(im, ti, buildTestNef())
+	log.PanicIf(err)
+
+	if len(nf.Ifds()) != 1 {
+		t.Fatalf("IFD count not correct: (%d)", len(nf.Ifds()))
+	}
+
+	subIfds := nf.SubIfds()
+	if len(subIfds) != 2 {
+		t.Fatalf("Sub-IFD count not correct: (%d)", len(subIfds))
+	}
+
+	rawIfd, err := nf.RawIfd()
+	log.PanicIf(err)
+
+	if rawIfd != subIfds[1] {
+		t.Fatalf("Raw IFD not correct: [%v]", rawIfd)
+	}
+
+	results, err := rawIfd.FindTagWithName("ImageWidth")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint32)[0] != 6000 {
+		t.Fatalf("Raw width not correct: %v", value)
+	}
+
+	if _, found := nf.ExifIfd(); found != true {
+		t.Fatalf("EXIF IFD not found.")
+	}
+}
+
+func TestNefFile_MakerNote(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	nf, err := NewNefFile(im, ti, buildTestNef())
+	log.PanicIf(err)
+
+	index, found, err := nf.MakerNote()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Maker-note not found.")
+	}
+
+	ifd := index.RootIfd
+
+	if ifd.IfdIdentity().Equals(NikonMakerNoteIfdIdentity) != true {
+		t.Fatalf("Maker-note identity not correct: [%s]", ifd.IfdIdentity())
+	} else if ifd.ByteOrder() != binary.BigEndian {
+		t.Fatalf("Maker-note byte-order not correct: [%v]", ifd.ByteOrder())
+	} else if len(ifd.Entries()) != 2 {
+		t.Fatalf("Maker-note tag count not correct: (%d)", len(ifd.Entries()))
+	}
+
+	results, err := ifd.FindTagWithName("ISO")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint16)[0] != 400 {
+		t.Fatalf("ISO not correct: %v", value)
+	}
+
+	results, err = ifd.FindTagWithName("SerialNumber")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if value != "123" {
+		t.Fatalf("Serial number not correct: [%v]", value)
+	}
+}
+
+func TestNewNefFile_NotNef(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, err = NewNefFile(im, ti, getExifSimpleTestIbBytes())
+	if err != ErrNotNef {
+		t.Fatalf("Expected not-NEF error: [%v]", err)
+	}
+
+	_, err = NewNefFile(im, ti, []byte("not a tiff"))
+	if err != ErrNotNef {
+		t.Fatalf("Expected not-NEF error for non-TIFF: [%v]", err)
+	}
+}
+
+func TestParseNikonMakerNote_NotNikon(t *testing.T) {
+	_, err := ParseNikonMakerNote([]byte("FUJIFILM\x0c\x00\x00\x00"))
+	if err != ErrNotNikonMakerNote {
+		t.Fatalf("Expected not-Nikon error: [%v]", err)
+	}
+}
@@ -0,0 +1,386 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// MaxOffsetScopeDepth is the deepest that offset-scopes may be nested.
+	// Real data has, at most, an EXIF block containing a maker-note containing
+	// a preview. Anything deeper is assumed to be malicious or corrupt.
+	MaxOffsetScopeDepth = 4
+)
+
+var (
+	// ErrOffsetOutOfScope means that an offset refers to data outside of the
+	// region governed by its offset-scope.
+	ErrOffsetOutOfScope = errors.New("offset out of scope")
+
+	// ErrOffsetScopeTooDeep means that offset-scopes were nested deeper than
+	// `MaxOffsetScopeDepth`.
+	ErrOffsetScopeTooDeep = errors.New("offset scope nested too deeply")
+)
+
+// OffsetScope describes an address space. Offsets that are read from a
+// structure are relative to the origin of the scope that the structure was
+// found in, which is not necessarily the origin of the enclosing EXIF data.
+// For example, Nikon maker-notes embed a complete TIFF header and all offsets
+// within the maker-note are relative to that header.
+//
+// Origins are stored absolutely (relative to the root scope) so that
+// resolving an offset never has to walk the chain.
+type OffsetScope struct {
+	name      string
+	origin    uint32
+	size      uint32
+	byteOrder binary.ByteOrder
+	parent    *OffsetScope
+	depth     int
+}
+
+// NewOffsetScope returns a root scope that covers `size` bytes from offset
+// zero.
+func NewOffsetScope(name string, byteOrder binary.ByteOrder, size uint32) *OffsetScope {
+	return &OffsetScope{
+		name:      name,
+		size:      size,
+		byteOrder: byteOrder,
+	}
+}
+
+// NewChild returns a scope whose origin is at `relativeOrigin` within this
+// scope and which covers `size` bytes. The child must be entirely contained
+// by this scope. If `byteOrder` is nil, ours is inherited.
+func (scope *OffsetScope) NewChild(name string, relativeOrigin uint32, size uint32, byteOrder binary.ByteOrder) (child *OffsetScope, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if scope.depth+1 > MaxOffsetScopeDepth {
+		log.Panic(ErrOffsetScopeTooDeep)
+	}
+
+	if uint64(relativeOrigin)+uint64(size) > uint64(scope.size) {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	if byteOrder == nil {
+		byteOrder = scope.byteOrder
+	}
+
+	child = &OffsetScope{
+		name:      name,
+		origin:    scope.origin + relativeOrigin,
+		size:      size,
+		byteOrder: byteOrder,
+		parent:    scope,
+		depth:     scope.depth + 1,
+	}
+
+	return child, nil
+}
+
+// Name returns the name of the scope.
+func (scope *OffsetScope) Name() string {
+	return scope.name
+}
+
+// Origin returns the position of offset zero relative to the root scope.
+func (scope *OffsetScope) Origin() uint32 {
+	return scope.origin
+}
+
+// Size returns the number of addressable bytes.
+func (scope *OffsetScope) Size() uint32 {
+	return scope.size
+}
+
+// ByteOrder returns the byte-order of structures in this scope.
+func (scope *OffsetScope) ByteOrder() binary.ByteOrder {
+	return scope.byteOrder
+}
+
+// Parent returns the enclosing scope or nil if this is the root.
+func (scope *OffsetScope) Parent() *OffsetScope {
+	return scope.parent
+}
+
+// Depth returns the number of scopes enclosing this one.
+func (scope *OffsetScope) Depth() int {
+	return scope.depth
+}
+
+// String returns a descriptive string.
+func (scope *OffsetScope) String() string {
+	return fmt.Sprintf("OffsetScope<NAME=[%s] ORIGIN=(0x%08x) SIZE=(%d) DEPTH=(%d)>", scope.name, scope.origin, scope.size, scope.depth)
+}
+
+// Resolve converts an offset in this scope to an offset relative to the root
+// scope after confirming that all `length` bytes at that offset are within
+// the scope. This is what prevents a nested structure from reaching back into
+// the data of its parents.
+func (scope *OffsetScope) Resolve(offset uint32, length uint32) (absolute uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if uint64(offset)+uint64(length) > uint64(scope.size) {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	return scope.origin + offset, nil
+}
+
+// Relativize converts an offset relative to the root scope to an offset in
+// this scope. This is the inverse of `Resolve()` and is used when encoding.
+func (scope *OffsetScope) Relativize(absolute uint32) (offset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if absolute < scope.origin || absolute-scope.origin > scope.size {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	return absolute - scope.origin, nil
+}
+
+// Slice returns the portion of `rootData` (which must be addressed by the root
+// scope) that is governed by this scope. Offsets within the returned data are
+// offsets in this scope.
+func (scope *OffsetScope) Slice(rootData []byte) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if uint64(scope.origin)+uint64(scope.size) > uint64(len(rootData)) {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	return rootData[scope.origin : scope.origin+scope.size], nil
+}
+
+// NewMakerNoteOffsetScope returns the scope that governs the maker-note found
+// at `makerNoteOffset` in this scope along with the offset of the maker-note's
+// IFD in that scope. The preamble of the maker-note determines whether it has
+// its own offset-space (see `MakerNoteHeader`). Unrecognized maker-notes are
+// assumed to be headerless IFDs that share our offset-space.
+func (scope *OffsetScope) NewMakerNoteOffsetScope(makerNoteOffset uint32, makerNoteData []byte) (child *OffsetScope, ifdOffset uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	_, err = scope.Resolve(makerNoteOffset, uint32(len(makerNoteData)))
+	log.PanicIf(err)
+
+	mnh, found := DetectMakerNoteHeader(makerNoteData)
+	if found == false {
+		child, err = scope.NewChild("MakerNote", 0, scope.size, nil)
+		log.PanicIf(err)
+
+		return child, makerNoteOffset, nil
+	}
+
+	byteOrder, err := mnh.ResolveByteOrder(makerNoteData, scope.byteOrder)
+	log.PanicIf(err)
+
+	if mnh.TiffHeaderOffset >= 0 {
+		th, err := ParseTiffHeader(makerNoteData[mnh.TiffHeaderOffset:])
+		log.PanicIf(err)
+
+		tiffHeaderOffset := uint32(mnh.TiffHeaderOffset)
+
+		child, err = scope.NewChild(mnh.Name, makerNoteOffset+tiffHeaderOffset, uint32(len(makerNoteData))-tiffHeaderOffset, byteOrder)
+		log.PanicIf(err)
+
+		return child, th.FirstIfdOffset, nil
+	} else if mnh.OffsetsRelativeToMakerNote == true {
+		child, err = scope.NewChild(mnh.Name, makerNoteOffset, uint32(len(makerNoteData)), byteOrder)
+		log.PanicIf(err)
+
+		return child, uint32(mnh.IfdOffset), nil
+	}
+
+	child, err = scope.NewChild(mnh.Name, 0, scope.size, byteOrder)
+	log.PanicIf(err)
+
+	return child, makerNoteOffset + uint32(mnh.IfdOffset), nil
+}
+
+// subIfdPointerTagIds are the tags whose values are offsets of other IFDs
+// rather than offsets of their data. They're offsets even when they're small
+// enough to be embedded in the entry.
+var subIfdPointerTagIds = map[uint16]struct{}{
+	exifcommon.IfdExifStandardIfdIdentity.TagId():    {},
+	exifcommon.IfdExifIopStandardIfdIdentity.TagId(): {},
+	exifcommon.IfdGpsInfoStandardIfdIdentity.TagId(): {},
+	SubIfdsTagId: {},
+}
+
+// RelocateIfdValueOffsets adjusts, in place, the value offsets of all of the
+// tags of the IFD at the front of `ifdData` by `delta`. Values that are small
+// enough to be embedded in the entry are left alone unless they're LONG
+// sub-IFD pointers. The next-IFD offset is adjusted too if the IFD has one.
+// This is necessary when re-encoding an IFD whose offsets are relative to an
+// enclosing scope at a different position, such as maker-notes whose offsets
+// are relative to the outer TIFF header.
+//
+// The original offsets of the sub-IFDs and the next IFD are returned so that
+// the caller can relocate those IFDs as well if it has their data.
+func RelocateIfdValueOffsets(ifdData []byte, byteOrder binary.ByteOrder, delta int64) (linkedOffsets []uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(ifdData) < 2 {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	tagCount := int(byteOrder.Uint16(ifdData[:2]))
+	entrySize := int(IfdTagEntrySize)
+
+	if len(ifdData) < 2+tagCount*entrySize {
+		log.Panic(ErrOffsetOutOfScope)
+	}
+
+	for i := 0; i < tagCount; i++ {
+		entry := ifdData[2+i*entrySize:]
+
+		tagId := byteOrder.Uint16(entry[0:2])
+		tagType := exifcommon.TagTypePrimitive(byteOrder.Uint16(entry[2:4]))
+		unitCount := byteOrder.Uint32(entry[4:8])
+
+		if tagType.IsValid() == false {
+			continue
+		}
+
+		effectiveType := tagType
+		if tagType == exifcommon.TypeUndefined {
+			effectiveType = exifcommon.TypeByte
+		}
+
+		if uint64(effectiveType.Size())*uint64(unitCount) <= 4 {
+			_, isSubIfdPointer := subIfdPointerTagIds[tagId]
+			if isSubIfdPointer == false || tagType != exifcommon.TypeLong || unitCount != 1 {
+				continue
+			}
+
+			linkedOffsets = append(linkedOffsets, byteOrder.Uint32(entry[8:12]))
+		}
+
+		err := relocateOffset(entry[8:12], byteOrder, delta)
+		log.PanicIf(err)
+	}
+
+	// The next-IFD offset is occasionally omitted by maker-notes. Zero means
+	// that there isn't another IFD.
+
+	nextIfdOffsetPosition := 2 + tagCount*entrySize
+	if len(ifdData) >= nextIfdOffsetPosition+4 {
+		nextIfdOffsetRaw := ifdData[nextIfdOffsetPosition : nextIfdOffsetPosition+4]
+
+		if nextIfdOffset := byteOrder.Uint32(nextIfdOffsetRaw); nextIfdOffset != 0 {
+			linkedOffsets = append(linkedOffsets, nextIfdOffset)
+
+			err := relocateOffset(nextIfdOffsetRaw, byteOrder, delta)
+			log.PanicIf(err)
+		}
+	}
+
+	return linkedOffsets, nil
+}
+
+// relocateOffset adjusts, in place, the offset encoded in the four bytes of
+// `raw` by `delta`.
+func relocateOffset(raw []byte, byteOrder binary.ByteOrder, delta int64) (err error) {
+	relocated := int64(byteOrder.Uint32(raw)) + delta
+	if relocated < 0 || relocated > 0xffffffff {
+		return ErrOffsetOutOfScope
+	}
+
+	byteOrder.PutUint32(raw, uint32(relocated))
+
+	return nil
+}
+
+// relocateMakerNote returns the maker-note that was at `sourceOffset` in the
+// EXIF that it was copied from with the value offsets of its IFD adjusted for
+// it being at `offset` instead. Maker-notes with their own offset-space don't
+// need it and are returned as-is, as are those that turn out not to be IFDs.
+func relocateMakerNote(makerNoteData []byte, byteOrder binary.ByteOrder, sourceOffset uint32, offset uint32) (relocated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if offset == sourceOffset {
+		return makerNoteData, nil
+	}
+
+	// We don't have the original EXIF, so the root scope is as large as any
+	// EXIF can be.
+	root := NewOffsetScope("Exif", byteOrder, math.MaxUint32)
+
+	scope, ifdOffset, err := root.NewMakerNoteOffsetScope(sourceOffset, makerNoteData)
+	log.PanicIf(err)
+
+	if scope.Origin() != root.Origin() {
+		return makerNoteData, nil
+	}
+
+	relocated = make([]byte, len(makerNoteData))
+	copy(relocated, makerNoteData)
+
+	// Relocate every IFD that's linked from the first one and that's also
+	// inside the maker-note. Anything outside of it isn't ours to change.
+
+	pending := []uint32{ifdOffset}
+	visited := make(map[uint32]struct{})
+
+	for len(pending) > 0 {
+		thisIfdOffset := pending[0]
+		pending = pending[1:]
+
+		if _, found := visited[thisIfdOffset]; found == true {
+			continue
+		} else if thisIfdOffset < sourceOffset || thisIfdOffset-sourceOffset >= uint32(len(relocated)) {
+			continue
+		}
+
+		visited[thisIfdOffset] = struct{}{}
+
+		linkedOffsets, err := RelocateIfdValueOffsets(relocated[thisIfdOffset-sourceOffset:], scope.ByteOrder(), int64(offset)-int64(sourceOffset))
+		if err != nil {
+			if log.Is(err, ErrOffsetOutOfScope) == true {
+				return makerNoteData, nil
+			}
+
+			log.Panic(err)
+		}
+
+		pending = append(pending, linkedOffsets...)
+	}
+
+	return relocated, nil
+}
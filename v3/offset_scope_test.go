@@ -0,0 +1,312 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestOffsetScope_Resolve(t *testing.T) {
+	root := NewOffsetScope("Exif", exifcommon.TestDefaultByteOrder, 1000)
+
+	child, err := root.NewChild("Nested", 100, 200, nil)
+	log.PanicIf(err)
+
+	if child.ByteOrder() != exifcommon.TestDefaultByteOrder {
+		t.Fatalf("Byte-order not inherited.")
+	}
+
+	absolute, err := child.Resolve(10, 4)
+	log.PanicIf(err)
+
+	if absolute != 110 {
+		t.Fatalf("Absolute offset not correct: (%d)", absolute)
+	}
+
+	relative, err := child.Relativize(absolute)
+	log.PanicIf(err)
+
+	if relative != 10 {
+		t.Fatalf("Relative offset not correct: (%d)", relative)
+	}
+
+	// The last bytes of the scope are addressable but nothing beyond.
+
+	_, err = child.Resolve(196, 4)
+	log.PanicIf(err)
+
+	_, err = child.Resolve(197, 4)
+	if log.Is(err, ErrOffsetOutOfScope) != true {
+		t.Fatalf("Expected out-of-scope error: %v", err)
+	}
+
+	// Make sure that huge values can't wrap around.
+	_, err = child.Resolve(0xfffffffe, 4)
+	if log.Is(err, ErrOffsetOutOfScope) != true {
+		t.Fatalf("Expected out-of-scope error for overflow: %v", err)
+	}
+
+	_, err = child.Relativize(50)
+	if log.Is(err, ErrOffsetOutOfScope) != true {
+		t.Fatalf("Expected out-of-scope error for parent offset: %v", err)
+	}
+}
+
+func TestOffsetScope_NewChild__Guards(t *testing.T) {
+	root := NewOffsetScope("Exif", exifcommon.TestDefaultByteOrder, 1000)
+
+	_, err := root.NewChild("Nested", 900, 200, nil)
+	if log.Is(err, ErrOffsetOutOfScope) != true {
+		t.Fatalf("Expected out-of-scope error: %v", err)
+	}
+
+	scope := root
+	for i := 1; i <= MaxOffsetScopeDepth; i++ {
+		scope, err = scope.NewChild("Nested", 0, scope.Size(), nil)
+		log.PanicIf(err)
+	}
+
+	_, err = scope.NewChild("Nested", 0, scope.Size(), nil)
+	if log.Is(err, ErrOffsetScopeTooDeep) != true {
+		t.Fatalf("Expected too-deep error: %v", err)
+	}
+}
+
+func TestOffsetScope_NewMakerNoteOffsetScope__EmbeddedTiffHeader(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	// Produce a complete TIFF structure to embed in a Nikon-style maker-note.
+	embeddedExifData := getExifSimpleTestIbBytes()
+
+	makerNoteData := []byte("Nikon\x00\x02\x10\x00\x00")
+	makerNoteData = append(makerNoteData, embeddedExifData...)
+
+	const makerNoteOffset = 100
+
+	rootData := make([]byte, makerNoteOffset)
+	rootData = append(rootData, makerNoteData...)
+	rootData = append(rootData, make([]byte, 50)...)
+
+	root := NewOffsetScope("Exif", exifcommon.TestDefaultByteOrder, uint32(len(rootData)))
+
+	scope, ifdOffset, err := root.NewMakerNoteOffsetScope(makerNoteOffset, makerNoteData)
+	log.PanicIf(err)
+
+	if scope.Name() != "Nikon3" {
+		t.Fatalf("Scope name not correct: [%s]", scope.Name())
+	} else if scope.Origin() != makerNoteOffset+10 {
+		t.Fatalf("Scope origin not correct: (%d)", scope.Origin())
+	} else if ifdOffset != ExifDefaultFirstIfdOffset {
+		t.Fatalf("IFD offset not correct: (%d)", ifdOffset)
+	}
+
+	scopeData, err := scope.Slice(rootData)
+	log.PanicIf(err)
+
+	// The embedded structure parses on its own once isolated by its scope.
+
+	_, index, err := Collect(im, ti, scopeData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(0x000b)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "asciivalue" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
+func TestOffsetScope_NewMakerNoteOffsetScope__SharedOffsets(t *testing.T) {
+	root := NewOffsetScope("Exif", exifcommon.TestDefaultByteOrder, 1000)
+
+	makerNoteData := []byte("Panasonic\x00\x00\x00\x00\x00")
+
+	scope, ifdOffset, err := root.NewMakerNoteOffsetScope(200, makerNoteData)
+	log.PanicIf(err)
+
+	if scope.Origin() != 0 {
+		t.Fatalf("Scope origin not correct: (%d)", scope.Origin())
+	} else if ifdOffset != 212 {
+		t.Fatalf("IFD offset not correct: (%d)", ifdOffset)
+	}
+}
+
+func TestRelocateIfdValueOffsets(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("ImageWidth", []uint32{0x11223344})
+	log.PanicIf(err)
+
+	// The sub-IFD pointer and the next-IFD offset are small enough to be
+	// embedded but still have to be relocated.
+
+	exifIb := NewIfdBuilder(im, ti, exifcommon.IfdExifStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = exifIb.AddStandardWithName("LensMake", "lensmakevalue")
+	log.PanicIf(err)
+
+	err = ib.AddChildIb(exifIb)
+	log.PanicIf(err)
+
+	nextIb := NewIfdBuilder(im, ti, exifcommon.Ifd1StandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = nextIb.AddStandardWithName("ProcessingSoftware", "nextvalue")
+	log.PanicIf(err)
+
+	err = ib.SetNextIb(nextIb)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	ifdData, err := ibe.encodeAndAttachIfd(ib, ExifDefaultFirstIfdOffset)
+	log.PanicIf(err)
+
+	// Move the IFDs forward and repair their offsets.
+
+	const delta = 16
+
+	linkedOffsets, err := RelocateIfdValueOffsets(ifdData, exifcommon.TestDefaultByteOrder, delta)
+	log.PanicIf(err)
+
+	if len(linkedOffsets) != 2 {
+		t.Fatalf("Expected the sub-IFD and next-IFD offsets: %v", linkedOffsets)
+	}
+
+	for _, linkedOffset := range linkedOffsets {
+		_, err := RelocateIfdValueOffsets(ifdData[linkedOffset-ExifDefaultFirstIfdOffset:], exifcommon.TestDefaultByteOrder, delta)
+		log.PanicIf(err)
+	}
+
+	headerBytes, err := BuildExifHeader(exifcommon.TestDefaultByteOrder, ExifDefaultFirstIfdOffset+delta)
+	log.PanicIf(err)
+
+	exifData := append(headerBytes, make([]byte, delta)...)
+	exifData = append(exifData, ifdData...)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("ProcessingSoftware")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "asciivalue" {
+		t.Fatalf("Relocated value not correct: [%v]", value)
+	}
+
+	results, err = index.RootIfd.FindTagWithName("ImageWidth")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint32)[0] != 0x11223344 {
+		t.Fatalf("Embedded value not correct: [%v]", value)
+	}
+
+	results, err = index.Lookup["IFD/Exif"].FindTagWithName("LensMake")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "lensmakevalue" {
+		t.Fatalf("Sub-IFD value not correct: [%v]", value)
+	}
+
+	results, err = index.RootIfd.NextIfd().FindTagWithName("ProcessingSoftware")
+	log.PanicIf(err)
+
+	value, err = results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "nextvalue" {
+		t.Fatalf("Next-IFD value not correct: [%v]", value)
+	}
+}
+
+func TestIfdByteEncoder_SetRelocateMakerNotes(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, getTestExifData())
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	// Push the maker-note somewhere else.
+
+	err = rootIb.SetStandardWithName("Software", "a much longer software name than before")
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetRelocateMakerNotes(true)
+	ibe.SetVerifyOutput(true)
+
+	updatedExif, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, updatedIndex, err := Collect(im, ti, updatedExif)
+	log.PanicIf(err)
+
+	originalResults, err := index.Lookup["IFD/Exif"].FindTagWithId(MakerNoteTagId)
+	log.PanicIf(err)
+
+	updatedResults, err := updatedIndex.Lookup["IFD/Exif"].FindTagWithId(MakerNoteTagId)
+	log.PanicIf(err)
+
+	if updatedResults[0].getValueOffset() == originalResults[0].getValueOffset() {
+		t.Fatalf("Maker-note did not move.")
+	}
+
+	// The Canon maker-note shares the offset-space of the EXIF, so its values
+	// are only found if it was relocated.
+
+	originalMn, _, err := ParseMakerNote(index)
+	log.PanicIf(err)
+
+	updatedMn, _, err := ParseMakerNote(updatedIndex)
+	log.PanicIf(err)
+
+	originalCmn, err := NewCanonMakerNote(originalMn)
+	log.PanicIf(err)
+
+	updatedCmn, err := NewCanonMakerNote(updatedMn)
+	log.PanicIf(err)
+
+	originalCameraSettings, _, err := originalCmn.CameraSettings()
+	log.PanicIf(err)
+
+	updatedCameraSettings, found, err := updatedCmn.CameraSettings()
+	log.PanicIf(err)
+
+	if found != true {
+		t.Fatalf("Camera settings not found.")
+	} else if reflect.DeepEqual(updatedCameraSettings, originalCameraSettings) != true {
+		t.Fatalf("Relocated maker-note not correct:\nACTUAL: %v\nEXPECTED: %v", updatedCameraSettings, originalCameraSettings)
+	}
+}
@@ -0,0 +1,118 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrUnresolvedOriginalValue means that a value that refers to the
+	// original EXIF data was needed but there is nothing to read it from (see
+	// `SetOriginalDataProvider()`).
+	ErrUnresolvedOriginalValue = errors.New("value refers to original data that was not provided")
+)
+
+// OriginalDataProvider supplies the bytes of the EXIF that an IB was copied
+// from, so that large values don't have to be kept in memory between the
+// copy and the encode (see `NewIfdBuilderFromExistingChainWithOriginals()`).
+// Offsets are relative to the start of the EXIF (the byte-order header).
+type OriginalDataProvider interface {
+	// ReadOriginal returns exactly `size` bytes at `offset`.
+	ReadOriginal(offset uint32, size uint32) (data []byte, err error)
+}
+
+// ReaderAtOriginalDataProvider reads the original EXIF from an `io.ReaderAt`,
+// such as an open file.
+type ReaderAtOriginalDataProvider struct {
+	r    io.ReaderAt
+	base int64
+}
+
+// NewReaderAtOriginalDataProvider returns a provider that reads from `r`.
+// `base` is the position of the EXIF within it (e.g. the position in the file
+// just after the "Exif\0\0" preamble of a JPEG).
+func NewReaderAtOriginalDataProvider(r io.ReaderAt, base int64) *ReaderAtOriginalDataProvider {
+	return &ReaderAtOriginalDataProvider{
+		r:    r,
+		base: base,
+	}
+}
+
+// ReadOriginal returns exactly `size` bytes at `offset`.
+func (raodp *ReaderAtOriginalDataProvider) ReadOriginal(offset uint32, size uint32) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data = make([]byte, size)
+
+	n, err := raodp.r.ReadAt(data, raodp.base+int64(offset))
+	if err == io.EOF && n == len(data) {
+		err = nil
+	}
+
+	log.PanicIf(err)
+
+	return data, nil
+}
+
+// String returns a descriptive string.
+func (raodp *ReaderAtOriginalDataProvider) String() string {
+	return fmt.Sprintf("ReaderAtOriginalDataProvider<BASE=(%d)>", raodp.base)
+}
+
+// SetOriginalDataProvider sets where the values that refer to the original
+// EXIF are read from. They are only read in the final pass of the encode,
+// directly before being written, and are not retained.
+func (ibe *IfdByteEncoder) SetOriginalDataProvider(odp OriginalDataProvider) {
+	ibe.mutex.Lock()
+	defer ibe.mutex.Unlock()
+
+	ibe.originalDataProvider = odp
+}
+
+// OriginalDataProvider returns the provider set by
+// `SetOriginalDataProvider()`, or nil.
+func (ibe *IfdByteEncoder) OriginalDataProvider() OriginalDataProvider {
+	ibe.mutex.RLock()
+	defer ibe.mutex.RUnlock()
+
+	return ibe.originalDataProvider
+}
+
+// encodedTagBytes returns the encoded value of the tag. A value that refers
+// to the original EXIF is read from the `OriginalDataProvider`.
+func (ibe *IfdByteEncoder) encodedTagBytes(ib *IfdBuilder, bt *BuilderTag) (valueBytes []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if bt.value.IsOriginal() == false {
+		valueBytes, err = bt.EncodedBytes(ib.byteOrder)
+		log.PanicIf(err)
+
+		return valueBytes, nil
+	}
+
+	offset, size := bt.value.Original()
+
+	if ibe.originalDataProvider == nil {
+		return nil, ErrUnresolvedOriginalValue
+	}
+
+	valueBytes, err = ibe.originalDataProvider.ReadOriginal(offset, size)
+	log.PanicIf(err)
+
+	if uint32(len(valueBytes)) != size {
+		log.Panicf("original data for tag (0x%04x) is the wrong size: (%d) != (%d)", bt.tagId, len(valueBytes), size)
+	}
+
+	return valueBytes, nil
+}
@@ -0,0 +1,128 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+type countingOriginalDataProvider struct {
+	OriginalDataProvider
+
+	reads int
+}
+
+func (codp *countingOriginalDataProvider) ReadOriginal(offset uint32, size uint32) (data []byte, err error) {
+	codp.reads++
+	return codp.OriginalDataProvider.ReadOriginal(offset, size)
+}
+
+func countOriginalValues(ib *IfdBuilder) (count int) {
+	for ; ib != nil; ib = ib.nextIb {
+		for _, bt := range ib.tags {
+			if bt.value.IsIb() == true {
+				count += countOriginalValues(bt.value.Ib())
+			} else if bt.value.IsOriginal() == true {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func TestNewIfdBuilderFromExistingChainWithOriginals(t *testing.T) {
+	original, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rawExif, err := SearchAndExtractExif(original)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb, _, err := NewIfdBuilderFromExistingChainWithReport(index.RootIfd)
+	log.PanicIf(err)
+
+	expected, err := NewIfdByteEncoder().EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	lazyRootIb, _, err := NewIfdBuilderFromExistingChainWithOriginals(index.RootIfd)
+	log.PanicIf(err)
+
+	bt, err := lazyRootIb.FindTagWithName("Make")
+	log.PanicIf(err)
+
+	if bt.Value().IsOriginal() != true {
+		t.Fatalf("Far value not deferred: %s", bt.Value())
+	}
+
+	// Opaque UNDEFINED values are deferred, too.
+
+	exifIb, err := GetOrCreateIbFromRootIb(lazyRootIb, "IFD/Exif")
+	log.PanicIf(err)
+
+	bt, err = exifIb.FindTag(MakerNoteTagId)
+	log.PanicIf(err)
+
+	if bt.Value().IsOriginal() != true {
+		t.Fatalf("Maker-note not deferred: %s", bt.Value())
+	}
+
+	// Without a provider, there is nothing to read the values from.
+
+	_, err = NewIfdByteEncoder().EncodeToExif(lazyRootIb)
+	if log.Is(err, ErrUnresolvedOriginalValue) == false {
+		t.Fatalf("Expected unresolved-value error: %v", err)
+	}
+
+	codp := &countingOriginalDataProvider{
+		OriginalDataProvider: NewReaderAtOriginalDataProvider(bytes.NewReader(rawExif), 0),
+	}
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetOriginalDataProvider(codp)
+
+	actual, err := ibe.EncodeToExif(lazyRootIb)
+	log.PanicIf(err)
+
+	if bytes.Equal(actual, expected) != true {
+		t.Fatalf("Encoding from original data differs from the copied values.")
+	}
+
+	// Each value is only read once, in the final pass.
+
+	deferred := countOriginalValues(lazyRootIb)
+
+	if codp.reads != deferred {
+		t.Fatalf("Original data not read once per value: (%d) != (%d)", codp.reads, deferred)
+	}
+}
+
+func TestReaderAtOriginalDataProvider_ReadOriginal(t *testing.T) {
+	raodp := NewReaderAtOriginalDataProvider(bytes.NewReader([]byte("xxabcdef")), 2)
+
+	data, err := raodp.ReadOriginal(2, 4)
+	log.PanicIf(err)
+
+	if string(data) != "cdef" {
+		t.Fatalf("Data not correct: [%s]", string(data))
+	}
+
+	_, err = raodp.ReadOriginal(4, 4)
+	if err == nil {
+		t.Fatalf("Expected error for short read.")
+	}
+}
@@ -5,4 +5,15 @@
 // The EXIF data must first be extracted and then provided to us. Conversely,
 // when constructing new EXIF data, the caller is responsible for packaging
 // this in whichever format they require.
+//
+// Concurrency: a `TagIndex`, an `IfdMapping` (once populated), and an
+// `IfdByteEncoder` may each be shared by any number of goroutines, so a
+// service only needs one of each. Separate parses may run concurrently, but
+// the tags of one parsed `Ifd` tree share a reader over the EXIF data, so
+// their values must be read by one goroutine at a time. An `IfdBuilder` is not
+// safe for concurrent use and must not be modified while it is being encoded.
+//
+// The package doesn't depend on the OS other than for the helpers that read
+// from files, which can be left out with the "exif_nofile" build tag (e.g.
+// for GOOS=js in a browser).
 package exif
@@ -0,0 +1,308 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	// ErrNotJpeg means that the data does not start with a JPEG SOI marker.
+	ErrNotJpeg = errors.New("not jpeg data")
+)
+
+var (
+	pdfObjectHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfFilterRe       = regexp.MustCompile(`/Filter\s*(\[[^\]]*\]|/[A-Za-z0-9]+)`)
+	pdfNameRe         = regexp.MustCompile(`/([A-Za-z0-9]+)`)
+	pdfLengthRe       = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+
+	pdfStreamKeyword    = []byte("stream")
+	pdfEndStreamKeyword = []byte("endstream")
+	pdfEndObjKeyword    = []byte("endobj")
+
+	jpegExifPreamble = []byte("Exif\x00\x00")
+)
+
+// PdfImageExif is the EXIF found in one JPEG image embedded in a PDF.
+type PdfImageExif struct {
+	// ObjectNumber is the number of the PDF object that holds the image.
+	ObjectNumber int
+
+	// GenerationNumber is the generation of the PDF object.
+	GenerationNumber int
+
+	// StreamOffset is the offset of the (possibly compressed) stream data
+	// from the start of the PDF.
+	StreamOffset int
+
+	// RawExif is the EXIF data from the image's APP1 segment.
+	RawExif []byte
+}
+
+// String returns a descriptive string.
+func (pie PdfImageExif) String() string {
+	return fmt.Sprintf("PdfImageExif<OBJECT=(%d %d) STREAM-OFFSET=(%d) EXIF-SIZE=(%d)>", pie.ObjectNumber, pie.GenerationNumber, pie.StreamOffset, len(pie.RawExif))
+}
+
+// ExtractExifFromPdf walks the objects of a PDF, finds the streams that
+// contain JPEG (DCT-encoded) images, and returns the EXIF of every one that
+// has any. Streams that are additionally Flate-compressed are inflated, but
+// only as far as the EXIF. Images that can't be read are skipped. An empty
+// list is returned if no image has EXIF.
+//
+// This is not a general PDF parser: objects are found lexically, so objects
+// in compressed object-streams are not visited. Images are always stored as
+// regular objects in practice.
+func ExtractExifFromPdf(data []byte) (results []PdfImageExif, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results = make([]PdfImageExif, 0)
+
+	cursor := 0
+	for cursor < len(data) {
+		loc := pdfObjectHeaderRe.FindSubmatchIndex(data[cursor:])
+		if loc == nil {
+			break
+		}
+
+		objectNumber, err := strconv.Atoi(string(data[cursor+loc[2] : cursor+loc[3]]))
+		log.PanicIf(err)
+
+		generationNumber, err := strconv.Atoi(string(data[cursor+loc[4] : cursor+loc[5]]))
+		log.PanicIf(err)
+
+		bodyStart := cursor + loc[1]
+		body := data[bodyStart:]
+
+		streamAt := findPdfStreamKeyword(body)
+		endObjAt := bytes.Index(body, pdfEndObjKeyword)
+
+		if streamAt == -1 || (endObjAt != -1 && endObjAt < streamAt) {
+			// No stream in this object.
+
+			if endObjAt == -1 {
+				break
+			}
+
+			cursor = bodyStart + endObjAt + len(pdfEndObjKeyword)
+			continue
+		}
+
+		dictionary := body[:streamAt]
+
+		streamStart := bodyStart + streamAt + len(pdfStreamKeyword)
+		if streamStart < len(data) && data[streamStart] == '\r' {
+			streamStart++
+		}
+
+		if streamStart < len(data) && data[streamStart] == '\n' {
+			streamStart++
+		}
+
+		streamEnd := findPdfStreamEnd(data, streamStart, dictionary)
+		cursor = streamEnd
+
+		isDct, isFlated := parsePdfImageFilters(dictionary)
+		if isDct == false {
+			continue
+		}
+
+		var r io.Reader = bytes.NewReader(data[streamStart:streamEnd])
+		if isFlated == true {
+			zr, err := zlib.NewReader(r)
+			if err != nil {
+				exifLogger.Debugf(nil, "Could not inflate PDF object (%d %d): %v", objectNumber, generationNumber, err)
+				continue
+			}
+
+			r = zr
+		}
+
+		rawExif, err := ExtractExifFromJpegSegments(r)
+		if err != nil {
+			if err != ErrNoExif {
+				exifLogger.Debugf(nil, "Could not read JPEG in PDF object (%d %d): %v", objectNumber, generationNumber, err)
+			}
+
+			continue
+		}
+
+		pie := PdfImageExif{
+			ObjectNumber:     objectNumber,
+			GenerationNumber: generationNumber,
+			StreamOffset:     streamStart,
+			RawExif:          rawExif,
+		}
+
+		results = append(results, pie)
+	}
+
+	return results, nil
+}
+
+// findPdfStreamKeyword returns the position of the "stream" keyword that
+// starts stream data, skipping the tail of any "endstream" keyword.
+func findPdfStreamKeyword(body []byte) int {
+	offset := 0
+	for {
+		i := bytes.Index(body[offset:], pdfStreamKeyword)
+		if i == -1 {
+			return -1
+		}
+
+		i += offset
+		if i >= 3 && bytes.Equal(body[i-3:i], []byte("end")) == true {
+			offset = i + len(pdfStreamKeyword)
+			continue
+		}
+
+		return i
+	}
+}
+
+// findPdfStreamEnd returns the position just past the stream data. A direct
+// /Length is trusted if it is in bounds. Otherwise, the data ends at the next
+// "endstream" keyword.
+func findPdfStreamEnd(data []byte, streamStart int, dictionary []byte) int {
+	if match := pdfLengthRe.FindSubmatch(dictionary); match != nil && len(match[2]) == 0 {
+		length, err := strconv.Atoi(string(match[1]))
+		if err == nil && streamStart+length <= len(data) {
+			return streamStart + length
+		}
+	}
+
+	i := bytes.Index(data[streamStart:], pdfEndStreamKeyword)
+	if i == -1 {
+		return len(data)
+	}
+
+	streamEnd := streamStart + i
+	if streamEnd > streamStart && data[streamEnd-1] == '\n' {
+		streamEnd--
+	}
+
+	if streamEnd > streamStart && data[streamEnd-1] == '\r' {
+		streamEnd--
+	}
+
+	return streamEnd
+}
+
+// parsePdfImageFilters returns whether the stream is a JPEG and, if so, whether
+// it is also Flate-compressed. Any other filter chain is not supported.
+func parsePdfImageFilters(dictionary []byte) (isDct, isFlated bool) {
+	match := pdfFilterRe.FindSubmatch(dictionary)
+	if match == nil {
+		return false, false
+	}
+
+	names := pdfNameRe.FindAllSubmatch(match[1], -1)
+	if len(names) == 0 || len(names) > 2 {
+		return false, false
+	}
+
+	if string(names[len(names)-1][1]) != "DCTDecode" {
+		return false, false
+	}
+
+	if len(names) == 2 {
+		if string(names[0][1]) != "FlateDecode" {
+			return false, false
+		}
+
+		return true, true
+	}
+
+	return true, false
+}
+
+// ExtractExifFromJpegSegments reads the segments of a JPEG stream up to the
+// image data and returns the EXIF from the APP1 segment. Unlike
+// `SearchAndExtractExifWithReader`, the returned data is exactly the EXIF
+// block. `ErrNoExif` is returned if there is no EXIF segment.
+func ExtractExifFromJpegSegments(r io.Reader) (rawExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	soi := make([]byte, 2)
+
+	_, err = io.ReadFull(r, soi)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrNotJpeg
+	}
+
+	log.PanicIf(err)
+
+	if soi[0] != 0xff || soi[1] != 0xd8 {
+		return nil, ErrNotJpeg
+	}
+
+	markerHeader := make([]byte, 4)
+	for {
+		_, err := io.ReadFull(r, markerHeader)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNoExif
+		}
+
+		log.PanicIf(err)
+
+		if markerHeader[0] != 0xff {
+			log.Panicf("jpeg marker not found: (0x%02x)", markerHeader[0])
+		}
+
+		marker := markerHeader[1]
+
+		// Start-of-scan. Nothing of interest follows.
+		if marker == 0xda {
+			return nil, ErrNoExif
+		}
+
+		length := int64(binary.BigEndian.Uint16(markerHeader[2:]))
+		if length < 2 {
+			log.Panicf("jpeg segment length not valid: (%d)", length)
+		}
+
+		payloadLength := length - 2
+
+		if marker == 0xe1 && payloadLength > int64(len(jpegExifPreamble)) {
+			payload := make([]byte, payloadLength)
+
+			_, err := io.ReadFull(r, payload)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, ErrNoExif
+			}
+
+			log.PanicIf(err)
+
+			if _, rawExif, err := SplitJpegExifPayload(payload); err == nil {
+				return rawExif, nil
+			}
+
+			continue
+		}
+
+		_, err = io.CopyN(ioutil.Discard, r, payloadLength)
+		if err == io.EOF {
+			return nil, ErrNoExif
+		}
+
+		log.PanicIf(err)
+	}
+}
@@ -0,0 +1,134 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"compress/zlib"
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// buildTestJpeg returns a skeletal JPEG with an APP0 segment, an optional
+// EXIF APP1 segment, and a start-of-scan.
+func buildTestJpeg(exifData []byte) []byte {
+	b := new(bytes.Buffer)
+
+	b.Write([]byte{0xff, 0xd8})
+
+	app0 := []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	b.Write([]byte{0xff, 0xe0})
+	binary.Write(b, binary.BigEndian, uint16(len(app0)+2))
+	b.Write(app0)
+
+	if exifData != nil {
+		b.Write([]byte{0xff, 0xe1})
+		binary.Write(b, binary.BigEndian, uint16(len(jpegExifPreamble)+len(exifData)+2))
+		b.Write(jpegExifPreamble)
+		b.Write(exifData)
+	}
+
+	b.Write([]byte{0xff, 0xda, 0x00, 0x02})
+	b.Write([]byte("endstream endobj 9 0 obj"))
+	b.Write([]byte{0xff, 0xd9})
+
+	return b.Bytes()
+}
+
+func buildTestPdf(exifData []byte) []byte {
+	b := new(bytes.Buffer)
+
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+	jpeg := buildTestJpeg(exifData)
+	fmt.Fprintf(b, "2 0 obj\n<< /Type /XObject /Subtype /Image /Filter /DCTDecode /Length %d >>\nstream\r\n", len(jpeg))
+	b.Write(jpeg)
+	b.WriteString("\r\nendstream\nendobj\n")
+
+	flated := new(bytes.Buffer)
+	zw := zlib.NewWriter(flated)
+
+	_, err := zw.Write(jpeg)
+	log.PanicIf(err)
+
+	err = zw.Close()
+	log.PanicIf(err)
+
+	b.WriteString("3 0 obj\n<< /Subtype /Image /Filter [/FlateDecode /DCTDecode] /Length 4 0 R >>\nstream\n")
+	b.Write(flated.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+
+	fmt.Fprintf(b, "4 0 obj\n%d\nendobj\n", flated.Len())
+
+	plain := buildTestJpeg(nil)
+	fmt.Fprintf(b, "5 1 obj\n<< /Subtype /Image /Filter /DCTDecode /Length %d >>\nstream\n", len(plain))
+	b.Write(plain)
+	b.WriteString("\nendstream\nendobj\n")
+
+	b.WriteString("6 0 obj\n<< /Filter /FlateDecode /Length 3 >>\nstream\nabc\nendstream\nendobj\n")
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+
+	return b.Bytes()
+}
+
+func TestExtractExifFromPdf(t *testing.T) {
+	exifData := getTestPsdExif()
+	pdfData := buildTestPdf(exifData)
+
+	results, err := ExtractExifFromPdf(pdfData)
+	log.PanicIf(err)
+
+	if len(results) != 2 {
+		t.Fatalf("Result count not correct: (%d) %v", len(results), results)
+	}
+
+	if results[0].ObjectNumber != 2 || results[0].GenerationNumber != 0 {
+		t.Fatalf("First result not correct: %s", results[0])
+	} else if bytes.Equal(results[0].RawExif, exifData) != true {
+		t.Fatalf("First result EXIF not correct.")
+	} else if bytes.HasPrefix(pdfData[results[0].StreamOffset:], []byte{0xff, 0xd8}) != true {
+		t.Fatalf("First result stream offset not correct: (%d)", results[0].StreamOffset)
+	}
+
+	if results[1].ObjectNumber != 3 {
+		t.Fatalf("Second result not correct: %s", results[1])
+	} else if bytes.Equal(results[1].RawExif, exifData) != true {
+		t.Fatalf("Second result EXIF not correct.")
+	}
+}
+
+func TestExtractExifFromPdf_NoImages(t *testing.T) {
+	results, err := ExtractExifFromPdf([]byte("%PDF-1.4\n1 0 obj\n<< >>\nendobj\n%%EOF\n"))
+	log.PanicIf(err)
+
+	if len(results) != 0 {
+		t.Fatalf("Expected no results: (%d)", len(results))
+	}
+}
+
+func TestExtractExifFromJpegSegments(t *testing.T) {
+	exifData := []byte("MM\x00\x2a\x00\x00\x00\x08")
+
+	rawExif, err := ExtractExifFromJpegSegments(bytes.NewReader(buildTestJpeg(exifData)))
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF not correct: %v", rawExif)
+	}
+
+	_, err = ExtractExifFromJpegSegments(bytes.NewReader(buildTestJpeg(nil)))
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: [%v]", err)
+	}
+
+	_, err = ExtractExifFromJpegSegments(bytes.NewReader([]byte("GIF89a")))
+	if err != ErrNotJpeg {
+		t.Fatalf("Expected not-JPEG error: [%v]", err)
+	}
+}
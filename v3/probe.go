@@ -0,0 +1,135 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// ExifProbe describes EXIF data without having parsed any of its tags.
+type ExifProbe struct {
+	// HasExif is false if no EXIF data was found. Nothing else is set then.
+	HasExif bool
+
+	// Offset is the number of bytes that precede the EXIF data.
+	Offset int
+
+	// ByteOrder is the byte-order of the EXIF data.
+	ByteOrder binary.ByteOrder
+
+	// FirstIfdOffset is the offset of IFD0 from the beginning of the EXIF
+	// data.
+	FirstIfdOffset uint32
+
+	// RootIfdTagCount is the number of entries recorded in IFD0.
+	RootIfdTagCount uint16
+}
+
+// String returns a string representation.
+func (ep ExifProbe) String() string {
+	if ep.HasExif == false {
+		return "ExifProbe<NO-EXIF>"
+	}
+
+	return fmt.Sprintf("ExifProbe<OFFSET=(%d) BYTE-ORDER=[%v] FIRST-IFD-OFFSET=(0x%02x) ROOT-IFD-TAG-COUNT=(%d)>", ep.Offset, ep.ByteOrder, ep.FirstIfdOffset, ep.RootIfdTagCount)
+}
+
+// ProbeExif finds the EXIF data and reads only its header and the number of
+// entries in IFD0. No tags are decoded and the rest of the stream is not read,
+// so this is cheap enough to triage large numbers of files before deciding
+// which to fully parse. The absence of EXIF data is not an error.
+func ProbeExif(r io.Reader) (ep ExifProbe, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	br := bufio.NewReader(r)
+
+	// The EXIF is an image resource in Photoshop documents, a box in JPEG
+	// 2000 files, and a chunk in WebP files. These are small enough to read.
+	var extractFn func(r io.Reader) (rawExif []byte, offset int, err error)
+	if signature, err := br.Peek(len(psdSignature)); err == nil && IsPsd(signature) == true {
+		extractFn = extractExifFromPsd
+	} else if signature, err := br.Peek(len(jp2Signature)); err == nil && IsJp2(signature) == true {
+		extractFn = extractExifFromJp2
+	} else if signature, err := br.Peek(webpHeaderSize); err == nil && IsWebp(signature) == true {
+		extractFn = extractExifFromWebp
+	}
+
+	if extractFn != nil {
+		rawExif, discarded, err := extractFn(br)
+		if err != nil {
+			if err == ErrNoExif {
+				return ep, nil
+			}
+
+			log.Panic(err)
+		}
+
+		br = bufio.NewReader(bytes.NewReader(rawExif))
+
+		ep, err = probeExifHeader(br, discarded)
+		log.PanicIf(err)
+
+		return ep, nil
+	}
+
+	discarded, err := searchExifHeader(br)
+	if err != nil {
+		if err == ErrNoExif {
+			return ep, nil
+		}
+
+		log.Panic(err)
+	}
+
+	ep, err = probeExifHeader(br, discarded)
+	log.PanicIf(err)
+
+	return ep, nil
+}
+
+// probeExifHeader reads the header and the IFD0 tag-count from a reader
+// positioned at the beginning of the EXIF data.
+func probeExifHeader(br *bufio.Reader, offset int) (ep ExifProbe, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	headerData, err := br.Peek(ExifSignatureLength)
+	log.PanicIf(err)
+
+	eh, err := ParseExifHeader(headerData)
+	log.PanicIf(err)
+
+	if eh.FirstIfdOffset < ExifSignatureLength {
+		log.Panicf("first IFD overlaps the header: (%d)", eh.FirstIfdOffset)
+	}
+
+	_, err = br.Discard(int(eh.FirstIfdOffset))
+	log.PanicIf(err)
+
+	tagCountRaw := make([]byte, 2)
+
+	_, err = io.ReadFull(br, tagCountRaw)
+	log.PanicIf(err)
+
+	ep = ExifProbe{
+		HasExif:         true,
+		Offset:          offset,
+		ByteOrder:       eh.ByteOrder,
+		FirstIfdOffset:  eh.FirstIfdOffset,
+		RootIfdTagCount: eh.ByteOrder.Uint16(tagCountRaw),
+	}
+
+	return ep, nil
+}
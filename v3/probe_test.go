@@ -0,0 +1,60 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestProbeExifFile(t *testing.T) {
+	ep, err := ProbeExifFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	expected := ExifProbe{
+		HasExif:         true,
+		Offset:          12,
+		ByteOrder:       binary.LittleEndian,
+		FirstIfdOffset:  8,
+		RootIfdTagCount: 12,
+	}
+
+	if ep != expected {
+		t.Fatalf("Probe not correct: %s", ep)
+	}
+}
+
+func TestProbeExif_NoExif(t *testing.T) {
+	ep, err := ProbeExif(bytes.NewBufferString("not an image"))
+	log.PanicIf(err)
+
+	if ep.HasExif != false {
+		t.Fatalf("Expected no EXIF: %s", ep)
+	}
+}
+
+func TestProbeExif_Psd(t *testing.T) {
+	psdData := buildTestPsd(getTestPsdExif())
+
+	ep, err := ProbeExif(bytes.NewBuffer(psdData))
+	log.PanicIf(err)
+
+	if ep.HasExif != true || ep.RootIfdTagCount != 12 {
+		t.Fatalf("Probe not correct: %s", ep)
+	}
+}
+
+func TestProbeExif_Truncated(t *testing.T) {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	_, err = ProbeExif(bytes.NewBuffer(rawExif[:9]))
+	if err == nil {
+		t.Fatalf("Expected error for truncated IFD.")
+	}
+}
@@ -0,0 +1,243 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// PsdExifResourceId is the ID of the Photoshop image resource that stores
+	// the EXIF data ("EXIF data 1"). The data is a complete TIFF stream.
+	PsdExifResourceId = uint16(1058)
+
+	// psdHeaderSize is the size of the fixed file-header, including the
+	// signature.
+	psdHeaderSize = 26
+
+	// psdMaxExifResourceSize is the most that we'll read for an EXIF
+	// resource. The size comes from the file, so it's not trusted to allocate
+	// with.
+	psdMaxExifResourceSize = 64 * 1024 * 1024
+)
+
+var (
+	psdSignature = []byte{'8', 'B', 'P', 'S'}
+
+	// psdResourceSignatures are the signatures that may introduce an image
+	// resource block. Everything but "8BIM" is rare, but Photoshop accepts
+	// them.
+	psdResourceSignatures = [][]byte{
+		[]byte("8BIM"),
+		[]byte("MeSa"),
+		[]byte("AgHg"),
+		[]byte("PHUT"),
+		[]byte("DCSR"),
+	}
+)
+
+var (
+	// ErrPsdFormat means that the PSD structure could not be parsed.
+	ErrPsdFormat = errors.New("psd format error")
+)
+
+// IsPsd returns true if the data starts with the Photoshop (PSD or PSB)
+// signature.
+func IsPsd(data []byte) bool {
+	return bytes.HasPrefix(data, psdSignature)
+}
+
+// ExtractExifFromPsd returns the EXIF stored in the image resources of a
+// Photoshop document. Unlike `SearchAndExtractExifWithReader`, the returned
+// data is exactly the EXIF block. `ErrNoExif` is returned if the document has
+// no EXIF resource.
+func ExtractExifFromPsd(r io.Reader) (rawExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, _, err = extractExifFromPsd(r)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return rawExif, nil
+}
+
+// extractExifFromPsd returns the EXIF resource data and its offset from the
+// start of the document.
+func extractExifFromPsd(r io.Reader) (rawExif []byte, offset int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	header := make([]byte, psdHeaderSize)
+
+	_, err = io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		log.Panic(ErrPsdFormat)
+	}
+
+	log.PanicIf(err)
+
+	if IsPsd(header) == false {
+		log.Panic(ErrPsdFormat)
+	}
+
+	// Version 1 is PSD and version 2 is PSB (large document). The header and
+	// image-resources section are identical for both.
+	version := binary.BigEndian.Uint16(header[4:6])
+	if version != 1 && version != 2 {
+		log.Panicf("psd version not supported: (%d)", version)
+	}
+
+	offset = psdHeaderSize
+
+	// Skip the color-mode data section.
+
+	colorModeLength, err := readPsdUint32(r)
+	log.PanicIf(err)
+
+	_, err = io.CopyN(ioutil.Discard, r, int64(colorModeLength))
+	if err == io.EOF {
+		log.Panic(ErrPsdFormat)
+	}
+
+	log.PanicIf(err)
+
+	offset += 4 + int(colorModeLength)
+
+	resourcesLength, err := readPsdUint32(r)
+	log.PanicIf(err)
+
+	offset += 4
+
+	remaining := int64(resourcesLength)
+	for remaining > 0 {
+		// Signature (4), ID (2), and the name-length (1).
+		if remaining < 4+2+1 {
+			log.Panic(ErrPsdFormat)
+		}
+
+		blockHeader := make([]byte, 4+2+1)
+
+		_, err := io.ReadFull(r, blockHeader)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			log.Panic(ErrPsdFormat)
+		}
+
+		log.PanicIf(err)
+
+		if isPsdResourceSignature(blockHeader[:4]) == false {
+			log.Panic(ErrPsdFormat)
+		}
+
+		resourceId := binary.BigEndian.Uint16(blockHeader[4:6])
+
+		// The name is a Pascal string padded so that its total size, including
+		// the length byte, is even.
+		nameLength := int64(blockHeader[6])
+		namePadded := nameLength
+		if (1+nameLength)%2 != 0 {
+			namePadded++
+		}
+
+		if remaining < 4+2+1+namePadded+4 {
+			log.Panic(ErrPsdFormat)
+		}
+
+		_, err = io.CopyN(ioutil.Discard, r, namePadded)
+		if err == io.EOF {
+			log.Panic(ErrPsdFormat)
+		}
+
+		log.PanicIf(err)
+
+		dataLength, err := readPsdUint32(r)
+		log.PanicIf(err)
+
+		consumed := 4 + 2 + 1 + namePadded + 4
+		remaining -= consumed
+		offset += int(consumed)
+
+		dataPadded := int64(dataLength)
+		if dataPadded%2 != 0 {
+			dataPadded++
+		}
+
+		if int64(dataLength) > remaining {
+			log.Panic(ErrPsdFormat)
+		}
+
+		if resourceId == PsdExifResourceId {
+			if dataLength > psdMaxExifResourceSize {
+				log.Panicf("psd exif resource too large: (%d)", dataLength)
+			}
+
+			// Read through a limit so that the buffer only grows as far as the
+			// data actually goes.
+			rawExif, err = ioutil.ReadAll(io.LimitReader(r, int64(dataLength)))
+			log.PanicIf(err)
+
+			if len(rawExif) != int(dataLength) {
+				log.Panic(ErrPsdFormat)
+			}
+
+			exifLogger.Debugf(nil, "Found PSD EXIF resource (%d) bytes at offset (%d).", dataLength, offset)
+
+			return rawExif, offset, nil
+		}
+
+		if dataPadded > remaining {
+			dataPadded = remaining
+		}
+
+		_, err = io.CopyN(ioutil.Discard, r, dataPadded)
+		if err == io.EOF {
+			log.Panic(ErrPsdFormat)
+		}
+
+		log.PanicIf(err)
+
+		remaining -= dataPadded
+		offset += int(dataPadded)
+	}
+
+	return nil, 0, ErrNoExif
+}
+
+func isPsdResourceSignature(signature []byte) bool {
+	for _, candidate := range psdResourceSignatures {
+		if bytes.Equal(signature, candidate) == true {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readPsdUint32(r io.Reader) (value uint32, err error) {
+	raw := make([]byte, 4)
+
+	_, err = io.ReadFull(r, raw)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return 0, ErrPsdFormat
+	} else if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(raw), nil
+}
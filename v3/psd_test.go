@@ -0,0 +1,187 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// buildTestPsd returns a minimal PSD document with an unrelated resource
+// followed, if `exifData` is not nil, by the EXIF resource.
+func buildTestPsd(exifData []byte) []byte {
+	b := new(bytes.Buffer)
+
+	header := make([]byte, psdHeaderSize)
+	copy(header, psdSignature)
+	binary.BigEndian.PutUint16(header[4:6], 1)
+	binary.BigEndian.PutUint16(header[12:14], 3)
+	binary.BigEndian.PutUint32(header[14:18], 1)
+	binary.BigEndian.PutUint32(header[18:22], 1)
+	binary.BigEndian.PutUint16(header[22:24], 8)
+	binary.BigEndian.PutUint16(header[24:26], 3)
+	b.Write(header)
+
+	// Color-mode data.
+	binary.Write(b, binary.BigEndian, uint32(3))
+	b.Write([]byte{1, 2, 3})
+
+	resources := new(bytes.Buffer)
+
+	writeResource := func(id uint16, name string, data []byte) {
+		resources.WriteString("8BIM")
+		binary.Write(resources, binary.BigEndian, id)
+
+		resources.WriteByte(byte(len(name)))
+		resources.WriteString(name)
+		if (1+len(name))%2 != 0 {
+			resources.WriteByte(0)
+		}
+
+		binary.Write(resources, binary.BigEndian, uint32(len(data)))
+		resources.Write(data)
+		if len(data)%2 != 0 {
+			resources.WriteByte(0)
+		}
+	}
+
+	writeResource(1005, "ab", []byte{1, 2, 3, 4, 5})
+
+	if exifData != nil {
+		writeResource(PsdExifResourceId, "", exifData)
+	}
+
+	binary.Write(b, binary.BigEndian, uint32(resources.Len()))
+	b.Write(resources.Bytes())
+
+	// Empty layer-and-mask section followed by some image data.
+	binary.Write(b, binary.BigEndian, uint32(0))
+	b.Write([]byte{0, 0, 0xff, 0xff, 0xff})
+
+	return b.Bytes()
+}
+
+func getTestPsdExif() []byte {
+	rawExif, err := SearchFileAndExtractExif(getTestImageFilepath())
+	log.PanicIf(err)
+
+	// Trim the trailing image data and make the length odd so that the
+	// resource padding is exercised.
+	return rawExif[:0x4001]
+}
+
+func TestExtractExifFromPsd(t *testing.T) {
+	exifData := getTestPsdExif()
+	psdData := buildTestPsd(exifData)
+
+	rawExif, err := ExtractExifFromPsd(bytes.NewBuffer(psdData))
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF data not correct.")
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Model")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "Canon EOS 5D Mark III" {
+		t.Fatalf("Model not correct: [%v]", value)
+	}
+}
+
+func TestExtractExifFromPsd_NoExif(t *testing.T) {
+	psdData := buildTestPsd(nil)
+
+	_, err := ExtractExifFromPsd(bytes.NewBuffer(psdData))
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: [%v]", err)
+	}
+}
+
+func TestExtractExifFromPsd_Truncated(t *testing.T) {
+	psdData := buildTestPsd(getTestPsdExif())
+
+	_, err := ExtractExifFromPsd(bytes.NewBuffer(psdData[:100]))
+	if log.Is(err, ErrPsdFormat) == false {
+		t.Fatalf("Expected format error: [%v]", err)
+	}
+}
+
+func TestExtractExifFromPsd_TruncatedResource(t *testing.T) {
+	// The EXIF resource claims almost as much data as the cap allows, and the
+	// resources section claims even more, but the file ends right after the
+	// resource header.
+
+	b := new(bytes.Buffer)
+
+	header := make([]byte, psdHeaderSize)
+	copy(header, psdSignature)
+	binary.BigEndian.PutUint16(header[4:6], 1)
+	b.Write(header)
+
+	binary.Write(b, binary.BigEndian, uint32(0))
+	binary.Write(b, binary.BigEndian, uint32(0xffffffff))
+
+	b.WriteString("8BIM")
+	binary.Write(b, binary.BigEndian, PsdExifResourceId)
+	b.Write([]byte{0, 0})
+	binary.Write(b, binary.BigEndian, uint32(psdMaxExifResourceSize-2))
+	b.Write([]byte{'M', 'M'})
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	_, err := ExtractExifFromPsd(b)
+	if log.Is(err, ErrPsdFormat) == false {
+		t.Fatalf("Expected format error: [%v]", err)
+	}
+
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Fatalf("Too much allocated for a truncated resource: (%d)", allocated)
+	}
+
+	// Anything over the cap is refused outright.
+
+	tooLarge := append([]byte{}, header...)
+	tooLarge = append(tooLarge, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff)
+	tooLarge = append(tooLarge, "8BIM"...)
+	tooLarge = append(tooLarge, 0x04, 0x22, 0, 0, 0xff, 0xff, 0xff, 0xf0)
+
+	_, err = ExtractExifFromPsd(bytes.NewBuffer(tooLarge))
+	if err == nil {
+		t.Fatalf("Expected error for oversized resource.")
+	}
+}
+
+func TestSearchAndExtractExif_Psd(t *testing.T) {
+	exifData := getTestPsdExif()
+	psdData := buildTestPsd(exifData)
+
+	rawExif, err := SearchAndExtractExif(psdData)
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF data not correct.")
+	}
+}
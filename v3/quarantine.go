@@ -0,0 +1,283 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// quarantineDataSuffix is the suffix of the files with the inputs.
+	quarantineDataSuffix = ".bin"
+
+	// quarantineErrorSuffix is the suffix of the files with the errors that
+	// the inputs originally caused.
+	quarantineErrorSuffix = ".error"
+)
+
+var (
+	quarantineLogger = log.NewLogger("exif.quarantine")
+)
+
+var (
+	// ErrQuarantineEntryCorrupt means that the content of a quarantined input
+	// doesn't match its name.
+	ErrQuarantineEntryCorrupt = errors.New("quarantine entry does not match its hash")
+)
+
+// QuarantineParseFn parses an input. Returning an error (or panicking) means
+// that the input is broken.
+type QuarantineParseFn func(data []byte) (err error)
+
+// Quarantine is a directory of inputs that failed to parse, so that they can
+// be replayed once the parser has been fixed (see `Replay()`). Each input is
+// stored once, named by the SHA-256 of its content, alongside a file with the
+// error that it caused.
+type Quarantine struct {
+	path string
+}
+
+// NewQuarantine returns a quarantine in the given directory, which is created
+// if it doesn't exist.
+func NewQuarantine(path string) (q *Quarantine, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = os.MkdirAll(path, 0755)
+	log.PanicIf(err)
+
+	q = &Quarantine{
+		path: path,
+	}
+
+	return q, nil
+}
+
+// Path returns the directory of the quarantine.
+func (q *Quarantine) Path() string {
+	return q.path
+}
+
+// Record stores the input and the error that it caused. Recording the same
+// input again leaves the original record alone.
+func (q *Quarantine) Record(data []byte, parseErr error) (hash string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	digest := sha256.Sum256(data)
+	hash = hex.EncodeToString(digest[:])
+
+	dataFilepath := path.Join(q.path, hash+quarantineDataSuffix)
+
+	if _, err := os.Stat(dataFilepath); err == nil {
+		return hash, nil
+	} else if os.IsNotExist(err) == false {
+		log.Panic(err)
+	}
+
+	errorFilepath := path.Join(q.path, hash+quarantineErrorSuffix)
+
+	err = ioutil.WriteFile(errorFilepath, []byte(parseErr.Error()+"\n"), 0644)
+	log.PanicIf(err)
+
+	// The input is written last, under a temporary name, so that a partial
+	// input never shows up as an entry.
+
+	tempFilepath := dataFilepath + ".tmp"
+
+	err = ioutil.WriteFile(tempFilepath, data, 0644)
+	log.PanicIf(err)
+
+	err = os.Rename(tempFilepath, dataFilepath)
+	log.PanicIf(err)
+
+	return hash, nil
+}
+
+// Parse calls the parse function and, if it fails, records the input. A panic
+// is treated as a failure. The error from the parse is returned. A failure to
+// record it is only logged, so that the quarantine can't break the caller.
+func (q *Quarantine) Parse(data []byte, parseFn QuarantineParseFn) (err error) {
+	err = callQuarantineParseFn(data, parseFn)
+	if err == nil {
+		return nil
+	}
+
+	hash, recordErr := q.Record(data, err)
+	if recordErr != nil {
+		quarantineLogger.Warningf(nil, "Could not quarantine input: [%s]", recordErr)
+	} else {
+		quarantineLogger.Debugf(nil, "Quarantined input [%s]: [%s]", hash, err)
+	}
+
+	return err
+}
+
+// QuarantineEntry is one quarantined input.
+type QuarantineEntry struct {
+	// Hash is the SHA-256 of the input, in hex.
+	Hash string
+
+	// Filepath is the file with the input.
+	Filepath string
+
+	// RecordedError is the error that the input caused when it was recorded.
+	RecordedError string
+}
+
+// String returns a descriptive string.
+func (qe QuarantineEntry) String() string {
+	return fmt.Sprintf("QuarantineEntry<HASH=[%s] RECORDED-ERROR=[%s]>", qe.Hash, qe.RecordedError)
+}
+
+// Entries returns the quarantined inputs, ordered by hash.
+func (q *Quarantine) Entries() (entries []QuarantineEntry, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	fis, err := ioutil.ReadDir(q.path)
+	log.PanicIf(err)
+
+	entries = make([]QuarantineEntry, 0)
+	for _, fi := range fis {
+		name := fi.Name()
+
+		if fi.IsDir() == true || strings.HasSuffix(name, quarantineDataSuffix) == false {
+			continue
+		}
+
+		hash := strings.TrimSuffix(name, quarantineDataSuffix)
+
+		qe := QuarantineEntry{
+			Hash:     hash,
+			Filepath: path.Join(q.path, name),
+		}
+
+		recordedError, err := ioutil.ReadFile(path.Join(q.path, hash+quarantineErrorSuffix))
+		if err == nil {
+			qe.RecordedError = strings.TrimSpace(string(recordedError))
+		} else if os.IsNotExist(err) == false {
+			log.Panic(err)
+		}
+
+		entries = append(entries, qe)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Hash < entries[j].Hash
+	})
+
+	return entries, nil
+}
+
+// QuarantineReplayResult is the outcome of replaying one input.
+type QuarantineReplayResult struct {
+	Entry QuarantineEntry
+
+	// Err is what the parse function returned (or panicked with) this time.
+	// It is nil if the input now parses.
+	Err error
+}
+
+// Replay calls the parse function for every quarantined input. This is what
+// keeps fixes from regressing: keep the inputs of the bugs that have been
+// fixed in a quarantine and check that they all still parse (see
+// `TestQuarantineReplay`). An input whose content doesn't match its name
+// fails with `ErrQuarantineEntryCorrupt`.
+func (q *Quarantine) Replay(parseFn QuarantineParseFn) (results []QuarantineReplayResult, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	entries, err := q.Entries()
+	log.PanicIf(err)
+
+	results = make([]QuarantineReplayResult, len(entries))
+	for i, qe := range entries {
+		results[i].Entry = qe
+
+		data, err := ioutil.ReadFile(qe.Filepath)
+		log.PanicIf(err)
+
+		digest := sha256.Sum256(data)
+		if hex.EncodeToString(digest[:]) != qe.Hash {
+			results[i].Err = ErrQuarantineEntryCorrupt
+			continue
+		}
+
+		results[i].Err = callQuarantineParseFn(data, parseFn)
+	}
+
+	return results, nil
+}
+
+// callQuarantineParseFn calls the parse function and turns a panic into an
+// error.
+func callQuarantineParseFn(data []byte, parseFn QuarantineParseFn) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			if stateErr, ok := state.(error); ok == true {
+				err = log.Wrap(stateErr)
+			} else {
+				err = fmt.Errorf("parse panicked: %v", state)
+			}
+		}
+	}()
+
+	return parseFn(data)
+}
+
+// CollectForQuarantine is a `QuarantineParseFn` that finds the EXIF in any
+// supported format and collects all of its IFDs with the standard mapping and
+// tags. Data without EXIF isn't a failure.
+func CollectForQuarantine(data []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, err := SearchAndExtractExif(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil
+		}
+
+		log.Panic(err)
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, _, err = Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,164 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func newTestQuarantine() (q *Quarantine, cleanup func()) {
+	tempPath, err := ioutil.TempDir("", "exif-quarantine")
+	log.PanicIf(err)
+
+	q, err = NewQuarantine(path.Join(tempPath, "quarantine"))
+	log.PanicIf(err)
+
+	return q, func() {
+		os.RemoveAll(tempPath)
+	}
+}
+
+func TestQuarantine_Parse(t *testing.T) {
+	q, cleanup := newTestQuarantine()
+	defer cleanup()
+
+	errBroken := errors.New("broken")
+
+	parseFn := func(data []byte) error {
+		if data[0] == 'x' {
+			return errBroken
+		} else if data[0] == 'p' {
+			panic("unexpected")
+		}
+
+		return nil
+	}
+
+	inputs := []string{"good", "x-one", "x-one", "panics", "x-two"}
+	for _, input := range inputs {
+		q.Parse([]byte(input), parseFn)
+	}
+
+	entries, err := q.Entries()
+	log.PanicIf(err)
+
+	// The repeated input is only stored once.
+	if len(entries) != 3 {
+		t.Fatalf("Entry count not correct: %v", entries)
+	}
+
+	recordedErrors := make(map[string]string)
+	for _, qe := range entries {
+		data, err := ioutil.ReadFile(qe.Filepath)
+		log.PanicIf(err)
+
+		recordedErrors[string(data)] = qe.RecordedError
+	}
+
+	if recordedErrors["x-one"] != "broken" || recordedErrors["x-two"] != "broken" {
+		t.Fatalf("Recorded errors not correct: %v", recordedErrors)
+	} else if recordedErrors["panics"] != "parse panicked: unexpected" {
+		t.Fatalf("Panic not recorded: %v", recordedErrors)
+	}
+}
+
+func TestQuarantine_Replay(t *testing.T) {
+	q, cleanup := newTestQuarantine()
+	defer cleanup()
+
+	_, err := q.Record([]byte("fixed"), errors.New("was broken"))
+	log.PanicIf(err)
+
+	hash, err := q.Record([]byte("still broken"), errors.New("is broken"))
+	log.PanicIf(err)
+
+	corruptHash, err := q.Record([]byte("corrupt"), errors.New("was broken"))
+	log.PanicIf(err)
+
+	err = ioutil.WriteFile(path.Join(q.Path(), corruptHash+quarantineDataSuffix), []byte("changed"), 0644)
+	log.PanicIf(err)
+
+	parseFn := func(data []byte) error {
+		if string(data) == "still broken" {
+			return errors.New("is broken")
+		}
+
+		return nil
+	}
+
+	results, err := q.Replay(parseFn)
+	log.PanicIf(err)
+
+	if len(results) != 3 {
+		t.Fatalf("Result count not correct: (%d)", len(results))
+	}
+
+	for _, result := range results {
+		switch result.Entry.Hash {
+		case hash:
+			if result.Err == nil {
+				t.Fatalf("Expected broken input to still fail.")
+			}
+		case corruptHash:
+			if result.Err != ErrQuarantineEntryCorrupt {
+				t.Fatalf("Expected corruption error: [%v]", result.Err)
+			}
+		default:
+			if result.Err != nil {
+				t.Fatalf("Fixed input failed: [%v]", result.Err)
+			}
+		}
+	}
+}
+
+func TestCollectForQuarantine(t *testing.T) {
+	err := CollectForQuarantine(getTestExifData())
+	log.PanicIf(err)
+
+	err = CollectForQuarantine([]byte("no exif here"))
+	log.PanicIf(err)
+
+	truncated := getExifSimpleTestIbBytes()[:20]
+
+	err = CollectForQuarantine(truncated)
+	if err == nil {
+		t.Fatalf("Expected error for truncated EXIF.")
+	}
+}
+
+// TestQuarantineReplay replays the regression corpus: the inputs of parse
+// failures that have since been fixed. It's in assets/quarantine, or the
+// directory in EXIF_QUARANTINE_PATH (e.g. to check a quarantine recorded by a
+// service against a fix). Every input has to parse.
+func TestQuarantineReplay(t *testing.T) {
+	quarantinePath := os.Getenv("EXIF_QUARANTINE_PATH")
+	if quarantinePath == "" {
+		quarantinePath = path.Join(exifcommon.GetTestAssetsPath(), "quarantine")
+	}
+
+	if _, err := os.Stat(quarantinePath); os.IsNotExist(err) == true {
+		t.Skipf("No quarantine at [%s].", quarantinePath)
+	}
+
+	q, err := NewQuarantine(quarantinePath)
+	log.PanicIf(err)
+
+	results, err := q.Replay(CollectForQuarantine)
+	log.PanicIf(err)
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("Quarantined input [%s] still fails (originally [%s]): %v", result.Entry.Hash, result.Entry.RecordedError, result.Err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package exif
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// ByteRange is a span of bytes in an object.
+type ByteRange struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// End returns the offset just past the range.
+func (br ByteRange) End() int64 {
+	return br.Offset + br.Length
+}
+
+// HttpRange returns the range as the value of an HTTP Range header.
+func (br ByteRange) HttpRange() string {
+	return fmt.Sprintf("bytes=%d-%d", br.Offset, br.End()-1)
+}
+
+// String returns a descriptive string.
+func (br ByteRange) String() string {
+	return fmt.Sprintf("ByteRange<OFFSET=(%d) LENGTH=(%d)>", br.Offset, br.Length)
+}
+
+// RangeReadPlan describes the reads that are required to resolve the values of
+// a set of tags, once the header and the IFD tables have been read.
+type RangeReadPlan struct {
+	// ExifOffset is the offset of the EXIF (TIFF) header in the object.
+	ExifOffset int64
+
+	// Index has the IFDs that were read while planning. The thumbnail is not
+	// read.
+	Index IfdIndex
+
+	// Ranges are the spans of the object, in order, that hold the values of
+	// the requested tags. Values that are small enough to be embedded in
+	// their tag entries have already been read and need no range. Ranges that
+	// are within the gap given to `PlanRangeReads()` of each other are merged.
+	Ranges []ByteRange
+
+	// Missing are the requested tags that are not in the EXIF.
+	Missing []ValueOffsetKey
+}
+
+// Size returns the total number of bytes in the ranges.
+func (rrp *RangeReadPlan) Size() (size int64) {
+	for _, br := range rrp.Ranges {
+		size += br.Length
+	}
+
+	return size
+}
+
+// String returns a descriptive string.
+func (rrp *RangeReadPlan) String() string {
+	return fmt.Sprintf("RangeReadPlan<EXIF-OFFSET=(%d) RANGES=(%d) SIZE=(%d) MISSING=(%d)>", rrp.ExifOffset, len(rrp.Ranges), rrp.Size(), len(rrp.Missing))
+}
+
+// PlanRangeReads reads the header and the IFD tables of the EXIF in a JPEG or
+// TIFF-structured object and reports the byte ranges that would have to be
+// read to resolve the values of the given tags, so that remote sources can be
+// asked for exactly those (e.g. with HTTP range requests). If `tags` is nil,
+// every tag is planned for. Ranges that are no more than `maxGap` bytes apart
+// are merged, trading a few unneeded bytes for fewer requests. Use a
+// `RangeReaderAt` with a small block-size for `ra` to keep the planning reads
+// themselves small.
+func PlanRangeReads(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ra io.ReaderAt, size int64, tags []ValueOffsetKey, maxGap int64) (rrp *RangeReadPlan, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	_, exifOffset, index, err := collectFromReaderAt(ifdMapping, tagIndex, ra, size, true)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	rrp = &RangeReadPlan{
+		ExifOffset: exifOffset,
+		Index:      index,
+		Ranges:     make([]ByteRange, 0),
+		Missing:    make([]ValueOffsetKey, 0),
+	}
+
+	entries := make([]*IfdTagEntry, 0)
+
+	if tags == nil {
+		for _, ifd := range index.Ifds {
+			entries = append(entries, ifd.Entries()...)
+		}
+	} else {
+		for _, vok := range tags {
+			found := false
+
+			if ifd, ok := index.Lookup[vok.FqIfdPath]; ok == true {
+				for _, ite := range ifd.Entries() {
+					if ite.TagId() == vok.TagId {
+						entries = append(entries, ite)
+						found = true
+					}
+				}
+			}
+
+			if found == false {
+				rrp.Missing = append(rrp.Missing, vok)
+			}
+		}
+	}
+
+	ranges := make([]ByteRange, 0, len(entries))
+
+	for _, ite := range entries {
+		valueOffset, valueSize, isEmbedded := ite.valueRange()
+		if isEmbedded == true {
+			continue
+		}
+
+		br := ByteRange{
+			Offset: exifOffset + int64(valueOffset),
+			Length: valueSize,
+		}
+
+		if br.End() > size {
+			log.Panicf("value of tag (0x%04x) in IFD [%s] runs past the end of the object: %s", ite.TagId(), ite.IfdPath(), br)
+		}
+
+		ranges = append(ranges, br)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Offset < ranges[j].Offset
+	})
+
+	for _, br := range ranges {
+		if len(rrp.Ranges) > 0 {
+			last := &rrp.Ranges[len(rrp.Ranges)-1]
+
+			if br.Offset <= last.End()+maxGap {
+				if br.End() > last.End() {
+					last.Length = br.End() - last.Offset
+				}
+
+				continue
+			}
+		}
+
+		rrp.Ranges = append(rrp.Ranges, br)
+	}
+
+	return rrp, nil
+}
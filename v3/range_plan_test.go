@@ -0,0 +1,126 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestByteRange_HttpRange(t *testing.T) {
+	br := ByteRange{Offset: 100, Length: 10}
+
+	if br.HttpRange() != "bytes=100-109" {
+		t.Fatalf("HTTP range not correct: [%s]", br.HttpRange())
+	}
+}
+
+func TestPlanRangeReads(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+	data := buildTestJpeg(exifData)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIfdPath := exifcommon.IfdStandardIfdIdentity.String()
+
+	tags := []ValueOffsetKey{
+		{FqIfdPath: rootIfdPath, TagId: 0x000b},
+		{FqIfdPath: rootIfdPath, TagId: 0x00ff},
+		{FqIfdPath: rootIfdPath, TagId: 0x9999},
+	}
+
+	rrp, err := PlanRangeReads(im, ti, bytes.NewReader(data), int64(len(data)), tags, 0)
+	log.PanicIf(err)
+
+	if bytes.Equal(data[rrp.ExifOffset:rrp.ExifOffset+int64(len(exifData))], exifData) != true {
+		t.Fatalf("EXIF offset not correct: (%d)", rrp.ExifOffset)
+	}
+
+	// The SHORT is embedded, so only the ASCII needs to be read.
+	if len(rrp.Ranges) != 1 {
+		t.Fatalf("Expected one range: %v", rrp.Ranges)
+	}
+
+	br := rrp.Ranges[0]
+	if string(data[br.Offset:br.End()]) != "asciivalue\x00" {
+		t.Fatalf("Range not correct: %s", br)
+	}
+
+	if len(rrp.Missing) != 1 || rrp.Missing[0] != tags[2] {
+		t.Fatalf("Missing not correct: %v", rrp.Missing)
+	}
+}
+
+func TestPlanRangeReads_AllTags_Merged(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rrp, err := PlanRangeReads(im, ti, bytes.NewReader(exifData), int64(len(exifData)), nil, 0)
+	log.PanicIf(err)
+
+	// The ASCII and the RATIONAL.
+	if rrp.Size() != 11+8 {
+		t.Fatalf("Size not correct: (%d) %v", rrp.Size(), rrp.Ranges)
+	}
+
+	merged, err := PlanRangeReads(im, ti, bytes.NewReader(exifData), int64(len(exifData)), nil, 1024)
+	log.PanicIf(err)
+
+	if len(merged.Ranges) != 1 {
+		t.Fatalf("Ranges not merged: %v", merged.Ranges)
+	}
+
+	first := rrp.Ranges[0]
+	last := rrp.Ranges[len(rrp.Ranges)-1]
+
+	if merged.Ranges[0].Offset != first.Offset || merged.Ranges[0].End() != last.End() {
+		t.Fatalf("Merged range not correct: %s", merged.Ranges[0])
+	}
+}
+
+func TestPlanRangeReads_Jpeg(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rra := NewRangeReaderAt(rangeReadFnFromBytes(data), int64(len(data)), 1024)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	tags := []ValueOffsetKey{
+		{FqIfdPath: exifcommon.IfdStandardIfdIdentity.String(), TagId: 0x0110},
+	}
+
+	rrp, err := PlanRangeReads(im, ti, rra, rra.Size(), tags, 0)
+	log.PanicIf(err)
+
+	if len(rrp.Ranges) != 1 {
+		t.Fatalf("Expected one range: %v", rrp.Ranges)
+	}
+
+	br := rrp.Ranges[0]
+	if string(data[br.Offset:br.End()]) != "Canon EOS 5D Mark III\x00" {
+		t.Fatalf("Range not correct: %s", br)
+	}
+
+	// Planning shouldn't have read the thumbnail.
+	if rra.BytesFetched() >= 16*1024 {
+		t.Fatalf("Too much was read while planning: %s", rra)
+	}
+}
@@ -0,0 +1,323 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// RangeReaderAtDefaultBlockSize is the size of the reads that
+	// `RangeReaderAt` makes when no block-size is given. IFD tables and their
+	// values are usually close together, so this keeps the number of requests
+	// low without fetching much more than is needed.
+	RangeReaderAtDefaultBlockSize = 16 * 1024
+)
+
+var (
+	// ErrRangeReadShort indicates that a range-read returned fewer bytes than
+	// were requested from within the bounds of the object.
+	ErrRangeReadShort = errors.New("range-read returned short")
+)
+
+// RangeReadFn opens a reader for `length` bytes of an object starting at
+// `offset`. This is the shape of most object-store clients once the bucket and
+// key are bound. With gocloud.dev/blob:
+//
+//	readFn := func(offset, length int64) (io.ReadCloser, error) {
+//		return bucket.NewRangeReader(ctx, key, offset, length, nil)
+//	}
+type RangeReadFn func(offset, length int64) (rc io.ReadCloser, err error)
+
+// RangeReaderAt adapts a `RangeReadFn` to an `io.ReaderAt`. Data is fetched in
+// aligned blocks which are cached, so the many small reads that parsing makes
+// only cost a request for each block that they touch.
+type RangeReaderAt struct {
+	readFn    RangeReadFn
+	size      int64
+	blockSize int64
+
+	blocks       map[int64][]byte
+	requests     int
+	bytesFetched int64
+
+	mutex sync.Mutex
+}
+
+// NewRangeReaderAt returns a new `RangeReaderAt` for an object of `size` bytes.
+// If `blockSize` is (0), `RangeReaderAtDefaultBlockSize` is used.
+func NewRangeReaderAt(readFn RangeReadFn, size int64, blockSize int64) *RangeReaderAt {
+	if blockSize <= 0 {
+		blockSize = RangeReaderAtDefaultBlockSize
+	}
+
+	return &RangeReaderAt{
+		readFn:    readFn,
+		size:      size,
+		blockSize: blockSize,
+		blocks:    make(map[int64][]byte),
+	}
+}
+
+// Size returns the size of the object.
+func (rra *RangeReaderAt) Size() int64 {
+	return rra.size
+}
+
+// Requests returns the number of range-reads that have been made.
+func (rra *RangeReaderAt) Requests() int {
+	rra.mutex.Lock()
+	defer rra.mutex.Unlock()
+
+	return rra.requests
+}
+
+// BytesFetched returns the number of bytes that have been fetched.
+func (rra *RangeReaderAt) BytesFetched() int64 {
+	rra.mutex.Lock()
+	defer rra.mutex.Unlock()
+
+	return rra.bytesFetched
+}
+
+// block returns the block at the given index, fetching it if necessary. This
+// must be called with the lock held.
+func (rra *RangeReaderAt) block(index int64) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if data, found := rra.blocks[index]; found == true {
+		return data, nil
+	}
+
+	offset := index * rra.blockSize
+
+	length := rra.blockSize
+	if offset+length > rra.size {
+		length = rra.size - offset
+	}
+
+	rc, err := rra.readFn(offset, length)
+	log.PanicIf(err)
+
+	defer rc.Close()
+
+	data = make([]byte, length)
+
+	_, err = io.ReadFull(rc, data)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		log.Panic(ErrRangeReadShort)
+	}
+
+	log.PanicIf(err)
+
+	rra.requests++
+	rra.bytesFetched += length
+	rra.blocks[index] = data
+
+	return data, nil
+}
+
+// ReadAt reads from the object at the given offset. It satisfies
+// `io.ReaderAt`.
+func (rra *RangeReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if off < 0 {
+		log.Panicf("offset is negative: (%d)", off)
+	} else if off >= rra.size {
+		return 0, io.EOF
+	}
+
+	rra.mutex.Lock()
+	defer rra.mutex.Unlock()
+
+	for n < len(p) && off < rra.size {
+		index := off / rra.blockSize
+
+		data, err := rra.block(index)
+		log.PanicIf(err)
+
+		copied := copy(p[n:], data[off-index*rra.blockSize:])
+
+		n += copied
+		off += int64(copied)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// String returns a descriptive string.
+func (rra *RangeReaderAt) String() string {
+	return fmt.Sprintf("RangeReaderAt<SIZE=(%d) BLOCK-SIZE=(%d) REQUESTS=(%d) FETCHED=(%d)>", rra.size, rra.blockSize, rra.Requests(), rra.BytesFetched())
+}
+
+// LocateExifInReaderAt finds the EXIF data in a JPEG or TIFF-structured object
+// without reading the rest of it. Only the JPEG segment headers that come
+// before the EXIF are read. The returned offset is that of the EXIF (TIFF)
+// header and the length runs to the end of the segment (or the object, for
+// TIFFs). `ErrNoExif` is returned for any other format.
+func LocateExifInReaderAt(ra io.ReaderAt, size int64) (offset int64, length int64, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	header := make([]byte, ExifSignatureLength)
+
+	_, err = ra.ReadAt(header, 0)
+	if err == io.EOF {
+		return 0, 0, ErrNoExif
+	}
+
+	log.PanicIf(err)
+
+	if _, err := ParseExifHeader(header); err == nil {
+		return 0, size, nil
+	}
+
+	if header[0] != 0xff || header[1] != JpegMarkerSoi {
+		return 0, 0, ErrNoExif
+	}
+
+	position := int64(2)
+	markerHeader := make([]byte, 4)
+
+	for position+int64(len(markerHeader)) <= size {
+		_, err := ra.ReadAt(markerHeader, position)
+		log.PanicIf(err)
+
+		if markerHeader[0] != 0xff {
+			log.Panicf("jpeg marker not found at offset (%d): (0x%02x)", position, markerHeader[0])
+		}
+
+		// Skip fill bytes.
+		if markerHeader[1] == 0xff {
+			position++
+			continue
+		}
+
+		marker := markerHeader[1]
+
+		// The EXIF has to come before the image data.
+		if marker == JpegMarkerSos || marker == JpegMarkerEoi {
+			break
+		}
+
+		if jpegMarkerHasLength(marker) == false {
+			position += 2
+			continue
+		}
+
+		segmentLength := int64(binary.BigEndian.Uint16(markerHeader[2:]))
+		if segmentLength < 2 || position+2+segmentLength > size {
+			log.Panicf("jpeg segment length not valid at offset (%d): (%d)", position, segmentLength)
+		}
+
+		payloadOffset := position + 4
+		payloadLength := segmentLength - 2
+
+		if marker == JpegMarkerApp1 {
+			// We only need enough of the payload to find the TIFF header.
+			prefixLength := int64(len(jpegExifPreamble) + JpegMaxExifPadding + ExifSignatureLength)
+			if prefixLength > payloadLength {
+				prefixLength = payloadLength
+			}
+
+			prefix := make([]byte, prefixLength)
+
+			_, err := ra.ReadAt(prefix, payloadOffset)
+			log.PanicIf(err)
+
+			if padding, _, err := SplitJpegExifPayload(prefix); err == nil {
+				skipped := int64(len(jpegExifPreamble) + len(padding))
+				return payloadOffset + skipped, payloadLength - skipped, nil
+			}
+		}
+
+		position = payloadOffset + payloadLength
+	}
+
+	return 0, 0, ErrNoExif
+}
+
+// CollectFromReaderAt locates and parses the EXIF in a JPEG or
+// TIFF-structured object, reading only the header, the IFD tables, and the
+// values that are looked at. Pair it with a `RangeReaderAt` to index objects
+// in cloud storage without downloading them. Values are read from `ra` when
+// they are asked for, so it must remain readable while the index is in use.
+func CollectFromReaderAt(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ra io.ReaderAt, size int64) (eh ExifHeader, index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	eh, _, index, err = collectFromReaderAt(ifdMapping, tagIndex, ra, size, false)
+	if err != nil {
+		if err == ErrNoExif {
+			return eh, index, err
+		}
+
+		log.Panic(err)
+	}
+
+	return eh, index, nil
+}
+
+// collectFromReaderAt does the work of `CollectFromReaderAt()` and also
+// returns the offset of the EXIF in the object.
+func collectFromReaderAt(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ra io.ReaderAt, size int64, skipThumbnail bool) (eh ExifHeader, exifOffset int64, index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	exifOffset, length, err := LocateExifInReaderAt(ra, size)
+	if err != nil {
+		if err == ErrNoExif {
+			return eh, 0, index, err
+		}
+
+		log.Panic(err)
+	}
+
+	sr := io.NewSectionReader(ra, exifOffset, length)
+
+	header := make([]byte, ExifSignatureLength)
+
+	_, err = sr.ReadAt(header, 0)
+	log.PanicIf(err)
+
+	eh, err = ParseExifHeader(header)
+	log.PanicIf(err)
+
+	ebs := NewExifReadSeeker(sr)
+	ie := NewIfdEnumerate(ifdMapping, tagIndex, ebs, eh.ByteOrder)
+	ie.SetSkipThumbnail(skipThumbnail)
+
+	index, err = ie.Collect(eh.FirstIfdOffset)
+	log.PanicIf(err)
+
+	return eh, exifOffset, index, nil
+}
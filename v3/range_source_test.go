@@ -0,0 +1,204 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func rangeReadFnFromBytes(data []byte) RangeReadFn {
+	return func(offset, length int64) (rc io.ReadCloser, err error) {
+		if offset < 0 || offset+length > int64(len(data)) {
+			return nil, fmt.Errorf("range out of bounds: (%d) (%d)", offset, length)
+		}
+
+		return ioutil.NopCloser(bytes.NewReader(data[offset : offset+length])), nil
+	}
+}
+
+func TestRangeReaderAt_ReadAt(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+
+	rra := NewRangeReaderAt(rangeReadFnFromBytes(data), int64(len(data)), 4)
+
+	p := make([]byte, 7)
+
+	n, err := rra.ReadAt(p, 3)
+	log.PanicIf(err)
+
+	if n != 7 || string(p) != "3456789" {
+		t.Fatalf("Read not correct: (%d) [%s]", n, p)
+	}
+
+	// Blocks (0), (1), and (2).
+	if rra.Requests() != 3 || rra.BytesFetched() != 12 {
+		t.Fatalf("Fetches not correct: %s", rra)
+	}
+
+	// Cached.
+	_, err = rra.ReadAt(p[:2], 4)
+	log.PanicIf(err)
+
+	if rra.Requests() != 3 {
+		t.Fatalf("Block not cached: %s", rra)
+	}
+
+	// Past the end.
+	n, err = rra.ReadAt(p, 16)
+	if err != io.EOF {
+		t.Fatalf("Expected EOF: %v", err)
+	} else if n != 4 || string(p[:n]) != "ghij" {
+		t.Fatalf("Short read not correct: (%d) [%s]", n, p[:n])
+	}
+}
+
+func TestRangeReaderAt_ReadAt_Short(t *testing.T) {
+	data := []byte("0123456789")
+
+	// Claim more data than the object has.
+	readFn := func(offset, length int64) (rc io.ReadCloser, err error) {
+		return ioutil.NopCloser(bytes.NewReader(data[offset:])), nil
+	}
+
+	rra := NewRangeReaderAt(readFn, 20, 8)
+
+	_, err := rra.ReadAt(make([]byte, 4), 8)
+	if err == nil {
+		t.Fatalf("Expected error for short range-read.")
+	} else if log.Is(err, ErrRangeReadShort) == false {
+		t.Fatalf("Error not correct: %v", err)
+	}
+}
+
+func TestCollectFromReaderAt_Jpeg(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rra := NewRangeReaderAt(rangeReadFnFromBytes(data), int64(len(data)), 0)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := CollectFromReaderAt(im, ti, rra, rra.Size())
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Model")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "Canon EOS 5D Mark III" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+
+	// Only the head of the file should have been read.
+	if rra.BytesFetched() >= int64(len(data))/10 {
+		t.Fatalf("Too much was fetched: %s", rra)
+	}
+
+	// It should match a complete parse.
+
+	rawExif, err := SearchAndExtractExif(data)
+	log.PanicIf(err)
+
+	_, expectedIndex, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	if len(index.Ifds) != len(expectedIndex.Ifds) {
+		t.Fatalf("IFD count not correct: (%d) != (%d)", len(index.Ifds), len(expectedIndex.Ifds))
+	}
+
+	for i, ifd := range index.Ifds {
+		expectedIfd := expectedIndex.Ifds[i]
+
+		if len(ifd.Entries()) != len(expectedIfd.Entries()) {
+			t.Fatalf("Entry count for [%s] not correct: (%d) != (%d)", ifd, len(ifd.Entries()), len(expectedIfd.Entries()))
+		}
+
+		for j, ite := range ifd.Entries() {
+			actualBytes, err := ite.GetRawBytes()
+			log.PanicIf(err)
+
+			expectedBytes, err := expectedIfd.Entries()[j].GetRawBytes()
+			log.PanicIf(err)
+
+			if bytes.Equal(actualBytes, expectedBytes) != true {
+				t.Fatalf("Value for [%s] not correct.", ite)
+			}
+		}
+	}
+}
+
+func TestCollectFromReaderAt_JpegSynthetic(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+	data := buildTestJpeg(exifData)
+
+	offset, length, err := LocateExifInReaderAt(bytes.NewReader(data), int64(len(data)))
+	log.PanicIf(err)
+
+	if bytes.Equal(data[offset:offset+length], exifData) != true {
+		t.Fatalf("EXIF not located correctly: (%d) (%d)", offset, length)
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := CollectFromReaderAt(im, ti, bytes.NewReader(data), int64(len(data)))
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithId(0x00ff)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(value, []uint16{0x1122}) != true {
+		t.Fatalf("Value not correct: %v", value)
+	}
+}
+
+func TestCollectFromReaderAt_Tiff(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := CollectFromReaderAt(im, ti, bytes.NewReader(exifData), int64(len(exifData)))
+	log.PanicIf(err)
+
+	if len(index.RootIfd.Entries()) == 0 {
+		t.Fatalf("No entries collected.")
+	}
+}
+
+func TestCollectFromReaderAt_NoExif(t *testing.T) {
+	data := buildTestJpeg(nil)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, _, err = CollectFromReaderAt(im, ti, bytes.NewReader(data), int64(len(data)))
+	if err != ErrNoExif {
+		t.Fatalf("Expected ErrNoExif: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package exif
+
+import (
+	"fmt"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// SegmentShift is a segment that would move if the EXIF were rewritten.
+type SegmentShift struct {
+	// MarkerName is the common name of the marker (e.g. "APP2").
+	MarkerName string `json:"marker"`
+
+	// Identifier is the identifier of an application segment, if any.
+	Identifier string `json:"identifier,omitempty"`
+
+	OldOffset int `json:"old_offset"`
+	NewOffset int `json:"new_offset"`
+}
+
+// String returns a descriptive string.
+func (ss SegmentShift) String() string {
+	return fmt.Sprintf("SegmentShift<MARKER=[%s] IDENTIFIER=[%s] OLD-OFFSET=(%d) NEW-OFFSET=(%d)>", ss.MarkerName, ss.Identifier, ss.OldOffset, ss.NewOffset)
+}
+
+// IfdOffsetChange is an IFD whose offset within the EXIF would change. The
+// old offset is zero for an IFD that would be added and the new offset is
+// zero for an IFD that would be removed.
+type IfdOffsetChange struct {
+	FqIfdPath string `json:"fq_ifd_path"`
+	OldOffset uint32 `json:"old_offset"`
+	NewOffset uint32 `json:"new_offset"`
+}
+
+// String returns a descriptive string.
+func (ioc IfdOffsetChange) String() string {
+	return fmt.Sprintf("IfdOffsetChange<FQ-IFD-PATH=[%s] OLD-OFFSET=(0x%08x) NEW-OFFSET=(0x%08x)>", ioc.FqIfdPath, ioc.OldOffset, ioc.NewOffset)
+}
+
+// RewritePlan describes what replacing the EXIF of a JPEG would do, without
+// doing it, so that callers can warn users before touching large files.
+type RewritePlan struct {
+	// OldExifSize is the size of the current EXIF, or zero if there is none.
+	OldExifSize int `json:"old_exif_size"`
+
+	// NewExifSize is the size of the new EXIF.
+	NewExifSize int `json:"new_exif_size"`
+
+	OldFileSize int `json:"old_file_size"`
+	NewFileSize int `json:"new_file_size"`
+
+	// FitsInSegment is false if the new EXIF is too large for a JPEG segment,
+	// in which case the rewrite can't be done.
+	FitsInSegment bool `json:"fits_in_segment"`
+
+	// NeedsRelocation is true if the segments after the EXIF would move,
+	// which means that the rest of the file must be rewritten rather than
+	// just the EXIF segment.
+	NeedsRelocation bool `json:"needs_relocation"`
+
+	// ShiftedSegments are the segments that would move, in order. The image
+	// data moves along with the SOS segment.
+	ShiftedSegments []SegmentShift `json:"shifted_segments"`
+
+	// ChangedIfdOffsets are the IFDs that would be at a different offset
+	// within the EXIF, in the order of the new EXIF followed by the IFDs that
+	// would be removed. Anything that refers into the EXIF by offset (e.g.
+	// an external index of the thumbnail) would be stale.
+	ChangedIfdOffsets []IfdOffsetChange `json:"changed_ifd_offsets"`
+}
+
+// String returns a descriptive string.
+func (rp *RewritePlan) String() string {
+	return fmt.Sprintf("RewritePlan<OLD-EXIF-SIZE=(%d) NEW-EXIF-SIZE=(%d) FITS=[%v] RELOCATION=[%v] SHIFTED-SEGMENTS=(%d) CHANGED-IFDS=(%d)>", rp.OldExifSize, rp.NewExifSize, rp.FitsInSegment, rp.NeedsRelocation, len(rp.ShiftedSegments), len(rp.ChangedIfdOffsets))
+}
+
+// PlanJpegExifRewrite reports what replacing the EXIF of the given JPEG with
+// `newRawExif` would change. Nothing is modified. The new EXIF takes the place
+// of the first EXIF segment or, if there is none, is inserted after any APP0
+// (JFIF) segments.
+func PlanJpegExifRewrite(data []byte, newRawExif []byte) (rp *RewritePlan, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	oldRawExif, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	if err != nil && err != ErrNoExif {
+		log.Panic(err)
+	}
+
+	newSegmentLength := 4 + len(jpegExifPreamble) + len(newRawExif)
+
+	rp = &RewritePlan{
+		OldExifSize:       len(oldRawExif),
+		NewExifSize:       len(newRawExif),
+		OldFileSize:       len(data),
+		FitsInSegment:     len(jpegExifPreamble)+len(newRawExif) <= jpegMaxSegmentPayloadSize,
+		ShiftedSegments:   make([]SegmentShift, 0),
+		ChangedIfdOffsets: make([]IfdOffsetChange, 0),
+	}
+
+	// Find where the new segment goes and how much it grows by.
+
+	at := -1
+	delta := 0
+
+	for i, js := range segments {
+		if oldRawExif != nil && js.Marker == JpegMarkerApp1 && js.Identifier == "Exif" {
+			at = i + 1
+			delta = newSegmentLength - js.Length
+
+			break
+		} else if oldRawExif == nil && js.Marker != JpegMarkerSoi && js.Marker != JpegMarkerApp0 {
+			at = i
+			delta = newSegmentLength
+
+			break
+		}
+	}
+
+	rp.NewFileSize = len(data) + delta
+
+	if at >= 0 && delta != 0 {
+		for _, js := range segments[at:] {
+			ss := SegmentShift{
+				MarkerName: js.MarkerName(),
+				Identifier: js.Identifier,
+				OldOffset:  js.Offset,
+				NewOffset:  js.Offset + delta,
+			}
+
+			rp.ShiftedSegments = append(rp.ShiftedSegments, ss)
+		}
+
+		last := segments[len(segments)-1]
+		rp.NeedsRelocation = at < len(segments) || last.Offset+last.Length < len(data)
+	}
+
+	changes, err := compareIfdOffsets(oldRawExif, newRawExif)
+	log.PanicIf(err)
+
+	rp.ChangedIfdOffsets = changes
+
+	return rp, nil
+}
+
+// compareIfdOffsets returns the IFDs whose offsets differ between the two
+// EXIF blocks. `oldRawExif` may be nil.
+func compareIfdOffsets(oldRawExif, newRawExif []byte) (changes []IfdOffsetChange, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	oldIfds := make([]*Ifd, 0)
+	oldOffsets := make(map[string]uint32)
+
+	if oldRawExif != nil {
+		_, index, err := Collect(im, ti, oldRawExif)
+		log.PanicIf(err)
+
+		oldIfds = index.Ifds
+
+		for _, ifd := range index.Ifds {
+			oldOffsets[ifd.ifdIdentity.String()] = ifd.Offset()
+		}
+	}
+
+	_, index, err := Collect(im, ti, newRawExif)
+	log.PanicIf(err)
+
+	changes = make([]IfdOffsetChange, 0)
+	newOffsets := make(map[string]uint32)
+
+	for _, ifd := range index.Ifds {
+		fqIfdPath := ifd.ifdIdentity.String()
+		newOffsets[fqIfdPath] = ifd.Offset()
+
+		if oldOffsets[fqIfdPath] == ifd.Offset() {
+			continue
+		}
+
+		ioc := IfdOffsetChange{
+			FqIfdPath: fqIfdPath,
+			OldOffset: oldOffsets[fqIfdPath],
+			NewOffset: ifd.Offset(),
+		}
+
+		changes = append(changes, ioc)
+	}
+
+	for _, ifd := range oldIfds {
+		fqIfdPath := ifd.ifdIdentity.String()
+
+		if _, found := newOffsets[fqIfdPath]; found == true {
+			continue
+		}
+
+		ioc := IfdOffsetChange{
+			FqIfdPath: fqIfdPath,
+			OldOffset: ifd.Offset(),
+		}
+
+		changes = append(changes, ioc)
+	}
+
+	return changes, nil
+}
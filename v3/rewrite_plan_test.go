@@ -0,0 +1,113 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestPlanJpegExifRewrite(t *testing.T) {
+	data, err := ioutil.ReadFile(getTestImageFilepath())
+	log.PanicIf(err)
+
+	rawExif, segments, err := ExtractExifAndSegmentsFromJpeg(data)
+	log.PanicIf(err)
+
+	// Rewriting the same EXIF changes nothing.
+
+	rp, err := PlanJpegExifRewrite(data, rawExif)
+	log.PanicIf(err)
+
+	if rp.NeedsRelocation != false || len(rp.ShiftedSegments) != 0 || len(rp.ChangedIfdOffsets) != 0 {
+		t.Fatalf("Expected no changes: %s", rp)
+	} else if rp.NewFileSize != len(data) {
+		t.Fatalf("File size not correct: (%d)", rp.NewFileSize)
+	}
+
+	// Adding a tag grows the EXIF.
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	err = rootIb.SetStandardWithName("Artist", "a rather long name for an artist")
+	log.PanicIf(err)
+
+	newRawExif, err := NewIfdByteEncoder().EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	rp, err = PlanJpegExifRewrite(data, newRawExif)
+	log.PanicIf(err)
+
+	exifAt := 0
+	for i, js := range segments {
+		if js.Identifier == "Exif" {
+			exifAt = i
+			break
+		}
+	}
+
+	if rp.OldExifSize != len(rawExif) || rp.NewExifSize != len(newRawExif) {
+		t.Fatalf("EXIF sizes not correct: %s", rp)
+	} else if rp.NewFileSize-rp.OldFileSize != len(newRawExif)-len(rawExif) {
+		t.Fatalf("File size not correct: (%d)", rp.NewFileSize)
+	} else if rp.FitsInSegment != true || rp.NeedsRelocation != true {
+		t.Fatalf("Flags not correct: %s", rp)
+	} else if len(rp.ShiftedSegments) != len(segments)-exifAt-1 {
+		t.Fatalf("Shifted segments not correct: %v", rp.ShiftedSegments)
+	}
+
+	first := rp.ShiftedSegments[0]
+	if first.OldOffset != segments[exifAt+1].Offset || first.NewOffset-first.OldOffset != len(newRawExif)-len(rawExif) {
+		t.Fatalf("Shift not correct: %s", first)
+	}
+
+	// The root IFD is still at the front; the ones after the changed value
+	// move.
+	for _, ioc := range rp.ChangedIfdOffsets {
+		if ioc.FqIfdPath == "IFD" {
+			t.Fatalf("Root IFD should not move: %s", ioc)
+		}
+	}
+
+	if len(rp.ChangedIfdOffsets) == 0 {
+		t.Fatalf("Expected IFD offsets to change.")
+	}
+}
+
+func TestPlanJpegExifRewrite__Insert(t *testing.T) {
+	data := []byte{
+		0xff, 0xd8,
+		0xff, 0xe0, 0x00, 0x04, 'J', 'F',
+		0xff, 0xdb, 0x00, 0x03, 0x01,
+		0xff, 0xda, 0x00, 0x02, 0x11, 0x22,
+		0xff, 0xd9,
+	}
+
+	newRawExif := getExifSimpleTestIbBytes()
+
+	rp, err := PlanJpegExifRewrite(data, newRawExif)
+	log.PanicIf(err)
+
+	growth := 4 + len(jpegExifPreamble) + len(newRawExif)
+
+	if rp.OldExifSize != 0 || rp.NewFileSize != len(data)+growth {
+		t.Fatalf("Sizes not correct: %s", rp)
+	} else if len(rp.ShiftedSegments) != 3 || rp.ShiftedSegments[0].MarkerName != "DQT" || rp.ShiftedSegments[0].NewOffset != 8+growth {
+		t.Fatalf("Shifted segments not correct: %v", rp.ShiftedSegments)
+	} else if len(rp.ChangedIfdOffsets) != 1 || rp.ChangedIfdOffsets[0].OldOffset != 0 || rp.ChangedIfdOffsets[0].NewOffset != 8 {
+		t.Fatalf("IFD changes not correct: %v", rp.ChangedIfdOffsets)
+	}
+}
@@ -0,0 +1,314 @@
+package exif
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// SequenceCode identifies the kind of problem that a sequence finding
+// describes.
+type SequenceCode string
+
+const (
+	// SequenceTimestampMissing means that the file has no DateTimeOriginal
+	// (or DateTime) that can be parsed.
+	SequenceTimestampMissing SequenceCode = "timestamp-missing"
+
+	// SequenceTimestampOutOfOrder means that the file was taken before a file
+	// that precedes it in the sequence.
+	SequenceTimestampOutOfOrder SequenceCode = "timestamp-out-of-order"
+
+	// SequenceSerialMismatch means that the file was taken by a different
+	// camera body than most of the sequence.
+	SequenceSerialMismatch SequenceCode = "serial-mismatch"
+
+	// SequenceFileNumberOutOfOrder means that the file-number of the file
+	// does not follow the one of the file before it.
+	SequenceFileNumberOutOfOrder SequenceCode = "file-number-out-of-order"
+
+	// SequenceFileNumberGap means that file-numbers are missing between the
+	// file and the one before it.
+	SequenceFileNumberGap SequenceCode = "file-number-gap"
+)
+
+var (
+	// sequenceFileNumberRe matches the number at the end of a file-name
+	// (without its extension), e.g. "0123" in "IMG_0123".
+	sequenceFileNumberRe = regexp.MustCompile(`(\d+)$`)
+)
+
+// SequenceItem is one file of a sequence.
+type SequenceItem struct {
+	// Name identifies the file (e.g. its path). If the EXIF has no
+	// ImageNumber, the number at the end of the file-name is used as the
+	// file-number.
+	Name string
+
+	// Index is the collected EXIF of the file.
+	Index IfdIndex
+}
+
+// SequenceFinding describes one inconsistency found by `CheckSequence()`.
+type SequenceFinding struct {
+	// Code identifies the kind of problem.
+	Code SequenceCode
+
+	// Position is the position of the file in the sequence.
+	Position int
+
+	// Name is the name of the file.
+	Name string
+
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a descriptive string.
+func (sf SequenceFinding) String() string {
+	return fmt.Sprintf("SequenceFinding<CODE=[%s] POSITION=(%d) NAME=[%s] MESSAGE=[%s]>", sf.Code, sf.Position, sf.Name, sf.Message)
+}
+
+// sequenceFacts are the values of one file that the checks compare.
+type sequenceFacts struct {
+	timestamp    time.Time
+	hasTimestamp bool
+
+	serial string
+
+	fileNumber    int64
+	hasFileNumber bool
+}
+
+// CheckSequence checks the EXIF of files that are expected to form one
+// sequence (e.g. a burst, or the files of one event in the order that they
+// were shot) and returns the files that don't fit: timestamps that go
+// backwards, camera bodies that differ from the rest, and file-numbers that
+// go backwards or skip. A file is only blamed if it is an outlier: the files
+// that are consistent with each other are the longest run that is in order,
+// and everything else is flagged. Equal timestamps are fine since bursts are
+// often faster than the timestamp resolution (SubSecTimeOriginal is used when
+// present). File-numbers that wrap around (e.g. from 9999 to 0001) are
+// reported as out of order. An empty list is returned if there is nothing to
+// report.
+func CheckSequence(items []SequenceItem) (findings []SequenceFinding, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	facts := make([]sequenceFacts, len(items))
+	for i, item := range items {
+		facts[i], err = readSequenceFacts(item)
+		log.PanicIf(err)
+	}
+
+	findings = make([]SequenceFinding, 0)
+
+	add := func(code SequenceCode, i int, format string, args ...interface{}) {
+		sf := SequenceFinding{
+			Code:     code,
+			Position: i,
+			Name:     items[i].Name,
+			Message:  fmt.Sprintf(format, args...),
+		}
+
+		findings = append(findings, sf)
+	}
+
+	// Timestamps.
+
+	positions := make([]int, 0, len(items))
+	keys := make([]int64, 0, len(items))
+
+	for i, sf := range facts {
+		if sf.hasTimestamp == false {
+			add(SequenceTimestampMissing, i, "no timestamp")
+			continue
+		}
+
+		positions = append(positions, i)
+		keys = append(keys, sf.timestamp.UnixNano())
+	}
+
+	for j, inOrder := range sequenceInOrder(keys, false) {
+		if inOrder == false {
+			i := positions[j]
+			add(SequenceTimestampOutOfOrder, i, "timestamp [%s] is out of order", facts[i].timestamp.Format(time.RFC3339Nano))
+		}
+	}
+
+	// Serials. The most common one wins.
+
+	counts := make(map[string]int)
+	for _, sf := range facts {
+		if sf.serial != "" {
+			counts[sf.serial]++
+		}
+	}
+
+	expectedSerial := ""
+	for serial, count := range counts {
+		if count > counts[expectedSerial] || (count == counts[expectedSerial] && serial < expectedSerial) {
+			expectedSerial = serial
+		}
+	}
+
+	for i, sf := range facts {
+		if sf.serial != "" && sf.serial != expectedSerial {
+			add(SequenceSerialMismatch, i, "serial [%s] is not [%s]", sf.serial, expectedSerial)
+		}
+	}
+
+	// File-numbers.
+
+	positions = positions[:0]
+	keys = keys[:0]
+
+	for i, sf := range facts {
+		if sf.hasFileNumber == true {
+			positions = append(positions, i)
+			keys = append(keys, sf.fileNumber)
+		}
+	}
+
+	previous := -1
+	for j, inOrder := range sequenceInOrder(keys, true) {
+		i := positions[j]
+
+		if inOrder == false {
+			add(SequenceFileNumberOutOfOrder, i, "file-number (%d) is out of order", facts[i].fileNumber)
+			continue
+		}
+
+		if previous != -1 {
+			missing := facts[i].fileNumber - facts[previous].fileNumber - 1
+			if missing > 0 {
+				add(SequenceFileNumberGap, i, "(%d) file-numbers missing after (%d)", missing, facts[previous].fileNumber)
+			}
+		}
+
+		previous = i
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Position < findings[j].Position
+	})
+
+	return findings, nil
+}
+
+// readSequenceFacts reads the values that are compared from the file.
+func readSequenceFacts(item SequenceItem) (sf sequenceFacts, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := item.Index.GetTags("DateTimeOriginal", "DateTime", "SubSecTimeOriginal", "BodySerialNumber", "CameraSerialNumber", "ImageNumber")
+	log.PanicIf(err)
+
+	asString := func(tv TypedValue) string {
+		return strings.TrimSpace(strings.TrimRight(tv.Value.Ascii(), "\000"))
+	}
+
+	for _, name := range []string{"DateTimeOriginal", "DateTime"} {
+		phrase := asString(results[name])
+		if phrase == "" {
+			continue
+		}
+
+		timestamp, err := exifcommon.ParseExifFullTimestamp(phrase)
+		if err != nil {
+			continue
+		}
+
+		sf.timestamp = timestamp
+		sf.hasTimestamp = true
+
+		// The fraction only belongs to the original timestamp.
+		if name == "DateTimeOriginal" {
+			subSec := asString(results["SubSecTimeOriginal"])
+			if fraction, err := strconv.ParseFloat("0."+subSec, 64); subSec != "" && err == nil {
+				sf.timestamp = sf.timestamp.Add(time.Duration(fraction * float64(time.Second)))
+			}
+		}
+
+		break
+	}
+
+	sf.serial = asString(results["BodySerialNumber"])
+	if sf.serial == "" {
+		sf.serial = asString(results["CameraSerialNumber"])
+	}
+
+	if values := results["ImageNumber"].Value.Longs(); len(values) > 0 {
+		sf.fileNumber = int64(values[0])
+		sf.hasFileNumber = true
+	}
+
+	if sf.hasFileNumber == false && item.Name != "" {
+		baseName := path.Base(item.Name)
+		baseName = strings.TrimSuffix(baseName, path.Ext(baseName))
+
+		if match := sequenceFileNumberRe.FindStringSubmatch(baseName); match != nil {
+			if fileNumber, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+				sf.fileNumber = fileNumber
+				sf.hasFileNumber = true
+			}
+		}
+	}
+
+	return sf, nil
+}
+
+// sequenceInOrder returns which keys belong to the longest subsequence that
+// is in order (increasing if `strict`, otherwise non-decreasing). The others
+// are the outliers.
+func sequenceInOrder(keys []int64, strict bool) []bool {
+	// tails[k] is the position of the smallest key that ends an in-order
+	// subsequence of length k+1.
+	tails := make([]int, 0)
+	previous := make([]int, len(keys))
+
+	for i, key := range keys {
+		k := sort.Search(len(tails), func(k int) bool {
+			if strict == true {
+				return keys[tails[k]] >= key
+			}
+
+			return keys[tails[k]] > key
+		})
+
+		if k > 0 {
+			previous[i] = tails[k-1]
+		} else {
+			previous[i] = -1
+		}
+
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	inOrder := make([]bool, len(keys))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i != -1; i = previous[i] {
+			inOrder[i] = true
+		}
+	}
+
+	return inOrder
+}
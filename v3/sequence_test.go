@@ -0,0 +1,116 @@
+package exif
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getSequenceTestIndex(timestamp, serial string, imageNumber uint32) IfdIndex {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	if timestamp != "" {
+		err = ib.AddStandardWithName("DateTime", timestamp)
+		log.PanicIf(err)
+	}
+
+	if serial != "" {
+		err = ib.AddStandardWithName("CameraSerialNumber", serial)
+		log.PanicIf(err)
+	}
+
+	if imageNumber != 0 {
+		err = ib.AddStandardWithName("ImageNumber", []uint32{imageNumber})
+		log.PanicIf(err)
+	}
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index
+}
+
+func TestCheckSequence_Consistent(t *testing.T) {
+	items := []SequenceItem{
+		{"IMG_0101.JPG", getSequenceTestIndex("2020:01:01 10:00:00", "123", 0)},
+		{"IMG_0102.JPG", getSequenceTestIndex("2020:01:01 10:00:00", "123", 0)},
+		{"IMG_0103.JPG", getSequenceTestIndex("2020:01:01 10:00:01", "123", 0)},
+	}
+
+	findings, err := CheckSequence(items)
+	log.PanicIf(err)
+
+	if len(findings) != 0 {
+		t.Fatalf("No findings expected: %v", findings)
+	}
+}
+
+func TestCheckSequence_Outliers(t *testing.T) {
+	items := []SequenceItem{
+		{"a", getSequenceTestIndex("2020:01:01 10:00:00", "123", 11)},
+		{"b", getSequenceTestIndex("2020:01:01 10:00:01", "123", 12)},
+		{"c", getSequenceTestIndex("2019:06:01 08:00:00", "456", 13)},
+		{"d", getSequenceTestIndex("2020:01:01 10:00:02", "123", 12)},
+		{"e", getSequenceTestIndex("", "", 16)},
+	}
+
+	findings, err := CheckSequence(items)
+	log.PanicIf(err)
+
+	expected := []struct {
+		code     SequenceCode
+		position int
+	}{
+		{SequenceTimestampOutOfOrder, 2},
+		{SequenceSerialMismatch, 2},
+		{SequenceFileNumberOutOfOrder, 3},
+		{SequenceTimestampMissing, 4},
+		{SequenceFileNumberGap, 4},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("Finding count not correct: %v", findings)
+	}
+
+	for i, e := range expected {
+		sf := findings[i]
+		if sf.Code != e.code || sf.Position != e.position {
+			t.Fatalf("Finding (%d) not correct: %s", i, sf)
+		}
+	}
+
+	if findings[4].Message != "(2) file-numbers missing after (13)" {
+		t.Fatalf("Gap message not correct: [%s]", findings[4].Message)
+	}
+}
+
+func TestCheckSequence_FileNumberFromName(t *testing.T) {
+	index := getSequenceTestIndex("2020:01:01 10:00:00", "", 0)
+
+	items := []SequenceItem{
+		{"/photos/DSC01234.ARW", index},
+		{"/photos/DSC01236.ARW", index},
+		{"/photos/DSC01235.ARW", index},
+	}
+
+	findings, err := CheckSequence(items)
+	log.PanicIf(err)
+
+	if len(findings) != 1 {
+		t.Fatalf("Finding count not correct: %v", findings)
+	} else if findings[0].Code != SequenceFileNumberOutOfOrder || findings[0].Name != "/photos/DSC01236.ARW" {
+		t.Fatalf("Finding not correct: %s", findings[0])
+	}
+}
@@ -0,0 +1,636 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/v2/filesystem"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// SnapshotVersion is the version of the snapshot schema that we write.
+	SnapshotVersion = uint32(1)
+)
+
+var (
+	// ErrSnapshotFormat means that serialized snapshot data could not be
+	// decoded or is inconsistent.
+	ErrSnapshotFormat = errors.New("snapshot format error")
+)
+
+// Snapshot is a self-contained copy of a collected IFD tree that can be
+// serialized compactly and rehydrated into an `IfdIndex` without the original
+// EXIF data. The serialized form is the protobuf message described by
+// snapshot.proto.
+type Snapshot struct {
+	Version      uint32
+	LittleEndian bool
+	Ifds         []SnapshotIfd
+}
+
+// SnapshotIfd is one IFD in a snapshot.
+type SnapshotIfd struct {
+	FqIfdPath string
+	Offset    uint32
+
+	// ParentId is one more than the position of the parent IFD in
+	// `Snapshot.Ifds`, or zero if there is no parent.
+	ParentId       uint32
+	ParentTagIndex uint32
+
+	// NextId is one more than the position of the next IFD in the chain in
+	// `Snapshot.Ifds`, or zero if this is the last one.
+	NextId        uint32
+	NextIfdOffset uint32
+
+	Tags      []SnapshotTag
+	Thumbnail []byte
+}
+
+// SnapshotTag is one tag in a snapshot.
+type SnapshotTag struct {
+	TagId     uint16
+	TagType   exifcommon.TagTypePrimitive
+	UnitCount uint32
+
+	// Value is the encoded value exactly as stored, in the snapshot's
+	// byte-order.
+	Value []byte
+
+	Name           string
+	ChildFqIfdPath string
+}
+
+// NewSnapshot captures the given index. All tag values are read, so the
+// underlying EXIF data must still be available.
+func NewSnapshot(index IfdIndex) (snapshot *Snapshot, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if index.RootIfd == nil {
+		log.Panicf("index is empty")
+	}
+
+	positions := make(map[*Ifd]uint32, len(index.Ifds))
+	for i, ifd := range index.Ifds {
+		positions[ifd] = uint32(i)
+	}
+
+	snapshot = &Snapshot{
+		Version:      SnapshotVersion,
+		LittleEndian: index.RootIfd.byteOrder == binary.LittleEndian,
+		Ifds:         make([]SnapshotIfd, len(index.Ifds)),
+	}
+
+	for i, ifd := range index.Ifds {
+		si := SnapshotIfd{
+			FqIfdPath:     ifd.ifdIdentity.String(),
+			Offset:        ifd.offset,
+			NextIfdOffset: ifd.nextIfdOffset,
+			Tags:          make([]SnapshotTag, len(ifd.entries)),
+		}
+
+		thumbnailData, err := ifd.Thumbnail()
+		if err == nil {
+			si.Thumbnail = thumbnailData
+		} else if log.Is(err, ErrNoThumbnail) == false {
+			log.Panic(err)
+		}
+
+		if ifd.parentIfd != nil {
+			si.ParentId = positions[ifd.parentIfd] + 1
+			si.ParentTagIndex = uint32(ifd.parentTagIndex)
+		}
+
+		if ifd.nextIfd != nil {
+			si.NextId = positions[ifd.nextIfd] + 1
+		}
+
+		for j, ite := range ifd.entries {
+			value, err := ite.readStoredBytes()
+			log.PanicIf(err)
+
+			si.Tags[j] = SnapshotTag{
+				TagId:          ite.tagId,
+				TagType:        ite.tagType,
+				UnitCount:      ite.unitCount,
+				Value:          value,
+				Name:           ite.tagName,
+				ChildFqIfdPath: ite.childFqIfdPath,
+			}
+		}
+
+		snapshot.Ifds[i] = si
+	}
+
+	return snapshot, nil
+}
+
+// readStoredBytes returns the value bytes exactly as stored, without
+// decoding undefined-type values.
+func (ite *IfdTagEntry) readStoredBytes() (value []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	valueContext := ite.getValueContext()
+	if ite.tagType == exifcommon.TypeUndefined {
+		valueContext.SetUndefinedValueType(exifcommon.TypeByte)
+	}
+
+	value, err = valueContext.ReadRawEncoded()
+	log.PanicIf(err)
+
+	return value, nil
+}
+
+// ByteOrder returns the byte-order of the values in the snapshot.
+func (snapshot *Snapshot) ByteOrder() binary.ByteOrder {
+	if snapshot.LittleEndian == true {
+		return binary.LittleEndian
+	}
+
+	return binary.BigEndian
+}
+
+// Marshal encodes the snapshot. The encoding is deterministic.
+func (snapshot *Snapshot) Marshal() (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	pw := new(protoWriter)
+	pw.uint32Field(1, snapshot.Version)
+	pw.boolField(2, snapshot.LittleEndian)
+
+	for _, si := range snapshot.Ifds {
+		ifdPw := new(protoWriter)
+		ifdPw.stringField(1, si.FqIfdPath)
+		ifdPw.uint32Field(2, si.Offset)
+		ifdPw.uint32Field(3, si.ParentId)
+		ifdPw.uint32Field(4, si.ParentTagIndex)
+		ifdPw.uint32Field(5, si.NextId)
+		ifdPw.uint32Field(6, si.NextIfdOffset)
+
+		for _, st := range si.Tags {
+			tagPw := new(protoWriter)
+			tagPw.uint32Field(1, uint32(st.TagId))
+			tagPw.uint32Field(2, uint32(st.TagType))
+			tagPw.uint32Field(3, st.UnitCount)
+			tagPw.bytesField(4, st.Value)
+			tagPw.stringField(5, st.Name)
+			tagPw.stringField(6, st.ChildFqIfdPath)
+
+			ifdPw.messageField(7, tagPw.b)
+		}
+
+		ifdPw.bytesField(8, si.Thumbnail)
+
+		pw.messageField(3, ifdPw.b)
+	}
+
+	return pw.b, nil
+}
+
+// ContentId returns a content-address for the snapshot: the hex-encoded
+// SHA-256 of its serialized form. Identical trees produce identical IDs.
+func (snapshot *Snapshot) ContentId() (id string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	data, err := snapshot.Marshal()
+	log.PanicIf(err)
+
+	digest := sha256.Sum256(data)
+
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// UnmarshalSnapshot decodes a snapshot produced by `Snapshot.Marshal()`.
+// Unknown fields are ignored.
+func UnmarshalSnapshot(data []byte) (snapshot *Snapshot, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	snapshot = new(Snapshot)
+
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireVarint:
+			snapshot.Version = pr.uint32()
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			snapshot.LittleEndian = pr.varint() != 0
+		case fieldNumber == 3 && wireType == protoWireBytes:
+			si := unmarshalSnapshotIfd(pr.bytes())
+			snapshot.Ifds = append(snapshot.Ifds, si)
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	if snapshot.Version > SnapshotVersion {
+		log.Panicf("snapshot version not supported: (%d)", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
+
+func unmarshalSnapshotIfd(data []byte) (si SnapshotIfd) {
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireBytes:
+			si.FqIfdPath = string(pr.bytes())
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			si.Offset = pr.uint32()
+		case fieldNumber == 3 && wireType == protoWireVarint:
+			si.ParentId = pr.uint32()
+		case fieldNumber == 4 && wireType == protoWireVarint:
+			si.ParentTagIndex = pr.uint32()
+		case fieldNumber == 5 && wireType == protoWireVarint:
+			si.NextId = pr.uint32()
+		case fieldNumber == 6 && wireType == protoWireVarint:
+			si.NextIfdOffset = pr.uint32()
+		case fieldNumber == 7 && wireType == protoWireBytes:
+			st := unmarshalSnapshotTag(pr.bytes())
+			si.Tags = append(si.Tags, st)
+		case fieldNumber == 8 && wireType == protoWireBytes:
+			si.Thumbnail = pr.bytes()
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	return si
+}
+
+func unmarshalSnapshotTag(data []byte) (st SnapshotTag) {
+	pr := &protoReader{b: data}
+	for pr.done() == false {
+		fieldNumber, wireType := pr.key()
+
+		switch {
+		case fieldNumber == 1 && wireType == protoWireVarint:
+			tagId := pr.uint32()
+			if tagId > math.MaxUint16 {
+				log.Panic(ErrSnapshotFormat)
+			}
+
+			st.TagId = uint16(tagId)
+		case fieldNumber == 2 && wireType == protoWireVarint:
+			tagType := pr.uint32()
+			if tagType > math.MaxUint16 {
+				log.Panic(ErrSnapshotFormat)
+			}
+
+			st.TagType = exifcommon.TagTypePrimitive(tagType)
+		case fieldNumber == 3 && wireType == protoWireVarint:
+			st.UnitCount = pr.uint32()
+		case fieldNumber == 4 && wireType == protoWireBytes:
+			st.Value = pr.bytes()
+		case fieldNumber == 5 && wireType == protoWireBytes:
+			st.Name = string(pr.bytes())
+		case fieldNumber == 6 && wireType == protoWireBytes:
+			st.ChildFqIfdPath = string(pr.bytes())
+		default:
+			pr.skip(wireType)
+		}
+	}
+
+	return st
+}
+
+// Index rehydrates the snapshot into an index that behaves like one returned
+// by `Collect()`. The tag values are served from the snapshot.
+func (snapshot *Snapshot) Index(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex) (index IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(snapshot.Ifds) == 0 {
+		log.Panicf("snapshot is empty")
+	}
+
+	byteOrder := snapshot.ByteOrder()
+
+	// Lay all of the values that don't fit in the offset field out in one
+	// buffer that the tags will read from.
+
+	valueOffsets := make([][]uint32, len(snapshot.Ifds))
+	valueData := new(bytes.Buffer)
+
+	for i, si := range snapshot.Ifds {
+		valueOffsets[i] = make([]uint32, len(si.Tags))
+
+		for j, st := range si.Tags {
+			if st.TagType.IsValid() == false {
+				log.Panicf("snapshot tag (0x%04x) in IFD [%s] has invalid type (%d)", st.TagId, si.FqIfdPath, st.TagType)
+			}
+
+			unitSize := uint32(1)
+			if st.TagType != exifcommon.TypeUndefined {
+				unitSize = uint32(st.TagType.Size())
+			}
+
+			byteCount, err := exifcommon.CheckedMulUint32(unitSize, st.UnitCount)
+			log.PanicIf(err)
+
+			if uint32(len(st.Value)) != byteCount {
+				log.Panicf("snapshot tag (0x%04x) in IFD [%s] has (%d) value bytes but requires (%d): %v", st.TagId, si.FqIfdPath, len(st.Value), byteCount, ErrSnapshotFormat)
+			}
+
+			if byteCount > 4 {
+				valueOffsets[i][j] = uint32(valueData.Len())
+				valueData.Write(st.Value)
+			}
+		}
+	}
+
+	sb := rifs.NewSeekableBufferWithBytes(valueData.Bytes())
+
+	ifds := make([]*Ifd, len(snapshot.Ifds))
+	tree := make(map[int]*Ifd)
+	lookup := make(map[string]*Ifd)
+
+	for i, si := range snapshot.Ifds {
+		ii, err := exifcommon.NewIfdIdentityFromString(ifdMapping, si.FqIfdPath)
+		log.PanicIf(err)
+
+		entries := make([]*IfdTagEntry, len(si.Tags))
+		entriesByTagId := make(map[uint16][]*IfdTagEntry)
+
+		for j, st := range si.Tags {
+			rawValueOffset := make([]byte, 4)
+
+			var valueOffset uint32
+			if len(st.Value) > 4 {
+				valueOffset = valueOffsets[i][j]
+				byteOrder.PutUint32(rawValueOffset, valueOffset)
+			} else {
+				copy(rawValueOffset, st.Value)
+				valueOffset = byteOrder.Uint32(rawValueOffset)
+			}
+
+			ite := newIfdTagEntry(
+				ii,
+				st.TagId,
+				j,
+				st.TagType,
+				st.UnitCount,
+				valueOffset,
+				rawValueOffset,
+				sb,
+				byteOrder)
+
+			ite.setTagName(st.Name)
+
+			if st.ChildFqIfdPath != "" {
+				iiChild, err := exifcommon.NewIfdIdentityFromString(ifdMapping, st.ChildFqIfdPath)
+				log.PanicIf(err)
+
+				ite.SetChildIfd(iiChild)
+			}
+
+			entries[j] = ite
+			entriesByTagId[st.TagId] = append(entriesByTagId[st.TagId], ite)
+		}
+
+		ifd := &Ifd{
+			ifdIdentity: ii,
+
+			byteOrder: byteOrder,
+
+			id: i,
+
+			parentTagIndex: int(si.ParentTagIndex),
+
+			offset:         si.Offset,
+			entries:        entries,
+			entriesByTagId: entriesByTagId,
+
+			children:      make([]*Ifd, 0),
+			childIfdIndex: make(map[string]*Ifd),
+
+			nextIfdOffset: si.NextIfdOffset,
+			thumbnailData: si.Thumbnail,
+
+			ifdMapping: ifdMapping,
+			tagIndex:   tagIndex,
+		}
+
+		ifds[i] = ifd
+		tree[i] = ifd
+		lookup[ii.String()] = ifd
+	}
+
+	// Link the IFDs now that they all exist.
+
+	resolve := func(id uint32) *Ifd {
+		if id == 0 {
+			return nil
+		} else if int(id) > len(ifds) {
+			log.Panic(ErrSnapshotFormat)
+		}
+
+		return ifds[id-1]
+	}
+
+	for i, si := range snapshot.Ifds {
+		ifd := ifds[i]
+
+		if parentIfd := resolve(si.ParentId); parentIfd != nil {
+			ifd.parentIfd = parentIfd
+			parentIfd.children = append(parentIfd.children, ifd)
+			parentIfd.childIfdIndex[ifd.ifdIdentity.UnindexedString()] = ifd
+		}
+
+		ifd.nextIfd = resolve(si.NextId)
+	}
+
+	index.RootIfd = ifds[0]
+	index.Ifds = ifds
+	index.Tree = tree
+	index.Lookup = lookup
+
+	return index, nil
+}
+
+// String returns a descriptive string.
+func (snapshot *Snapshot) String() string {
+	return fmt.Sprintf("Snapshot<VERSION=(%d) BYTE-ORDER=[%v] IFDS=(%d)>", snapshot.Version, snapshot.ByteOrder(), len(snapshot.Ifds))
+}
+
+// The protobuf wire-types that we use.
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+// protoWriter encodes protobuf fields. Scalar fields that have their zero
+// value are omitted, as proto3 requires.
+type protoWriter struct {
+	b []byte
+}
+
+func (pw *protoWriter) varint(value uint64) {
+	for value >= 0x80 {
+		pw.b = append(pw.b, byte(value)|0x80)
+		value >>= 7
+	}
+
+	pw.b = append(pw.b, byte(value))
+}
+
+func (pw *protoWriter) key(fieldNumber int, wireType int) {
+	pw.varint(uint64(fieldNumber)<<3 | uint64(wireType))
+}
+
+func (pw *protoWriter) uint32Field(fieldNumber int, value uint32) {
+	if value == 0 {
+		return
+	}
+
+	pw.key(fieldNumber, protoWireVarint)
+	pw.varint(uint64(value))
+}
+
+func (pw *protoWriter) boolField(fieldNumber int, value bool) {
+	if value == false {
+		return
+	}
+
+	pw.key(fieldNumber, protoWireVarint)
+	pw.varint(1)
+}
+
+func (pw *protoWriter) bytesField(fieldNumber int, value []byte) {
+	if len(value) == 0 {
+		return
+	}
+
+	pw.messageField(fieldNumber, value)
+}
+
+func (pw *protoWriter) stringField(fieldNumber int, value string) {
+	pw.bytesField(fieldNumber, []byte(value))
+}
+
+// messageField writes an embedded message. Unlike the scalars, this is
+// written even if empty so that repeated messages keep their count.
+func (pw *protoWriter) messageField(fieldNumber int, value []byte) {
+	pw.key(fieldNumber, protoWireBytes)
+	pw.varint(uint64(len(value)))
+	pw.b = append(pw.b, value...)
+}
+
+// protoReader decodes protobuf fields. It panics with `ErrSnapshotFormat` on
+// malformed data.
+type protoReader struct {
+	b   []byte
+	pos int
+}
+
+func (pr *protoReader) done() bool {
+	return pr.pos >= len(pr.b)
+}
+
+func (pr *protoReader) varint() (value uint64) {
+	for shift := uint(0); shift < 64; shift += 7 {
+		if pr.pos >= len(pr.b) {
+			log.Panic(ErrSnapshotFormat)
+		}
+
+		c := pr.b[pr.pos]
+		pr.pos++
+
+		value |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return value
+		}
+	}
+
+	log.Panic(ErrSnapshotFormat)
+	return 0
+}
+
+func (pr *protoReader) uint32() uint32 {
+	value := pr.varint()
+	if value > math.MaxUint32 {
+		log.Panic(ErrSnapshotFormat)
+	}
+
+	return uint32(value)
+}
+
+func (pr *protoReader) key() (fieldNumber int, wireType int) {
+	key := pr.varint()
+	return int(key >> 3), int(key & 0x7)
+}
+
+func (pr *protoReader) bytes() []byte {
+	length := pr.varint()
+	if length > uint64(len(pr.b)-pr.pos) {
+		log.Panic(ErrSnapshotFormat)
+	}
+
+	value := pr.b[pr.pos : pr.pos+int(length)]
+	pr.pos += int(length)
+
+	return value
+}
+
+func (pr *protoReader) skip(wireType int) {
+	var length int
+
+	switch wireType {
+	case protoWireVarint:
+		pr.varint()
+		return
+	case protoWireBytes:
+		pr.bytes()
+		return
+	case protoWireFixed64:
+		length = 8
+	case protoWireFixed32:
+		length = 4
+	default:
+		log.Panic(ErrSnapshotFormat)
+	}
+
+	if length > len(pr.b)-pr.pos {
+		log.Panic(ErrSnapshotFormat)
+	}
+
+	pr.pos += length
+}
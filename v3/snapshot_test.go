@@ -0,0 +1,194 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	snapshot, err := NewSnapshot(index)
+	log.PanicIf(err)
+
+	data, err := snapshot.Marshal()
+	log.PanicIf(err)
+
+	recovered, err := UnmarshalSnapshot(data)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recovered, snapshot) != true {
+		t.Fatalf("Unmarshaled snapshot not equal.")
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rehydrated, err := recovered.Index(im, ti)
+	log.PanicIf(err)
+
+	if len(rehydrated.Ifds) != len(index.Ifds) {
+		t.Fatalf("IFD count not correct: (%d) != (%d)", len(rehydrated.Ifds), len(index.Ifds))
+	}
+
+	for i, ifd := range index.Ifds {
+		rehydratedIfd := rehydrated.Ifds[i]
+
+		if rehydratedIfd.String() != ifd.String() {
+			t.Fatalf("IFD not correct:\nACTUAL: %s\nEXPECTED: %s", rehydratedIfd, ifd)
+		}
+
+		for j, ite := range ifd.entries {
+			rehydratedIte := rehydratedIfd.entries[j]
+
+			if rehydratedIte.DebugString(0) != ite.DebugString(0) {
+				t.Fatalf("Tag not correct:\nACTUAL: %s\nEXPECTED: %s", rehydratedIte.DebugString(0), ite.DebugString(0))
+			}
+		}
+	}
+
+	if reflect.DeepEqual(rehydrated.RootIfd.DumpTree(), index.RootIfd.DumpTree()) != true {
+		t.Fatalf("Tree not correct.")
+	}
+
+	expectedThumbnail, err := index.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	actualThumbnail, err := rehydrated.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(actualThumbnail, expectedThumbnail) != true {
+		t.Fatalf("Thumbnail not correct.")
+	}
+
+	results, err := rehydrated.GetTags("Model", "DateTimeOriginal")
+	log.PanicIf(err)
+
+	if results["Model"].Value.Ascii() != "Canon EOS 5D Mark III" {
+		t.Fatalf("Model not correct: [%v]", results["Model"].Value)
+	}
+}
+
+func TestSnapshot_ContentId(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	snapshot1, err := NewSnapshot(index)
+	log.PanicIf(err)
+
+	snapshot2, err := NewSnapshot(index)
+	log.PanicIf(err)
+
+	id1, err := snapshot1.ContentId()
+	log.PanicIf(err)
+
+	id2, err := snapshot2.ContentId()
+	log.PanicIf(err)
+
+	if id1 != id2 {
+		t.Fatalf("Content IDs differ for identical trees.")
+	} else if len(id1) != 64 {
+		t.Fatalf("Content ID not correct: [%s]", id1)
+	}
+
+	snapshot2.Ifds[0].Tags[0].Value = []byte("different")
+
+	id3, err := snapshot2.ContentId()
+	log.PanicIf(err)
+
+	if id3 == id1 {
+		t.Fatalf("Content ID did not change.")
+	}
+}
+
+func TestUnmarshalSnapshot_Truncated(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	snapshot, err := NewSnapshot(index)
+	log.PanicIf(err)
+
+	data, err := snapshot.Marshal()
+	log.PanicIf(err)
+
+	_, err = UnmarshalSnapshot(data[:len(data)/2])
+	if log.Is(err, ErrSnapshotFormat) == false {
+		t.Fatalf("Expected format error: [%v]", err)
+	}
+}
+
+func TestSnapshot_Index_BadValueLength(t *testing.T) {
+	index := getComputedTagTestIndex()
+
+	snapshot, err := NewSnapshot(index)
+	log.PanicIf(err)
+
+	snapshot.Ifds[0].Tags[0].Value = append(snapshot.Ifds[0].Tags[0].Value, 0)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, err = snapshot.Index(im, NewTagIndex())
+	if err == nil {
+		t.Fatalf("Expected error for inconsistent value length.")
+	}
+}
+
+func TestNewSnapshot_CorruptedUnitCount(t *testing.T) {
+	exifData := make([]byte, len(getTestExifData()))
+	copy(exifData, getTestExifData())
+
+	eh, err := ParseExifHeader(exifData)
+	log.PanicIf(err)
+
+	// Give the ExifTag pointer a count that asks for about 3GB. The child IFD
+	// is still found from the offset.
+
+	tableOffset := ExifAddressableAreaStart + eh.FirstIfdOffset
+	tagCount := eh.ByteOrder.Uint16(exifData[tableOffset:])
+
+	for i := uint32(0); i < uint32(tagCount); i++ {
+		entryOffset := tableOffset + 2 + i*12
+
+		if eh.ByteOrder.Uint16(exifData[entryOffset:]) == exifcommon.IfdExifStandardIfdIdentity.TagId() {
+			eh.ByteOrder.PutUint32(exifData[entryOffset+4:], 788529153)
+		}
+	}
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, NewTagIndex(), exifData)
+	log.PanicIf(err)
+
+	_, err = NewSnapshot(index)
+	if err == nil {
+		t.Fatalf("Expected error for value past the end of the data.")
+	}
+
+	_, _, err = NewIfdBuilderFromExistingChainWithReport(index.RootIfd)
+	log.PanicIf(err)
+}
+
+func TestProtoReader_SkipsUnknownFields(t *testing.T) {
+	pw := new(protoWriter)
+	pw.uint32Field(1, SnapshotVersion)
+	pw.uint32Field(99, 12345)
+	pw.bytesField(100, []byte("ignored"))
+	pw.boolField(2, true)
+
+	snapshot, err := UnmarshalSnapshot(pw.b)
+	log.PanicIf(err)
+
+	if snapshot.Version != SnapshotVersion || snapshot.LittleEndian != true {
+		t.Fatalf("Snapshot not correct: %s", snapshot)
+	}
+}
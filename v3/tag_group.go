@@ -0,0 +1,244 @@
+package exif
+
+import (
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// TagGroup is a category of tags that a user would expect to find together,
+// e.g. in one panel of a metadata viewer.
+type TagGroup int
+
+const (
+	// TagGroupOther is every tag that isn't in one of the other groups,
+	// including the tags that aren't known.
+	TagGroupOther TagGroup = iota
+
+	// TagGroupCamera is the device, the lens, and the settings that the image
+	// was captured with.
+	TagGroupCamera
+
+	// TagGroupImage is the dimensions, layout, and color of the image data.
+	TagGroupImage
+
+	// TagGroupGps is the location and movement of the device (everything in
+	// the GPS IFD).
+	TagGroupGps
+
+	// TagGroupTime is when the image was captured, digitized, or modified.
+	TagGroupTime
+
+	// TagGroupDescription is the title, the comments, and the authorship of
+	// the image.
+	TagGroupDescription
+)
+
+// String returns the name of the group.
+func (tg TagGroup) String() string {
+	switch tg {
+	case TagGroupOther:
+		return "other"
+	case TagGroupCamera:
+		return "camera"
+	case TagGroupImage:
+		return "image"
+	case TagGroupGps:
+		return "gps"
+	case TagGroupTime:
+		return "time"
+	case TagGroupDescription:
+		return "description"
+	}
+
+	return "unknown"
+}
+
+// standardTagGroups are the groups of the standard tags, by IFD-path and
+// tag-name. Tags of the GPS IFD are all in `TagGroupGps` and aren't listed,
+// and tags that aren't listed are in `TagGroupOther`.
+var standardTagGroups = map[string]map[string]TagGroup{
+	exifcommon.IfdStandardIfdIdentity.UnindexedString(): {
+		"Make":                      TagGroupCamera,
+		"Model":                     TagGroupCamera,
+		"UniqueCameraModel":         TagGroupCamera,
+		"LocalizedCameraModel":      TagGroupCamera,
+		"CameraSerialNumber":        TagGroupCamera,
+		"LensInfo":                  TagGroupCamera,
+		"ExposureTime":              TagGroupCamera,
+		"FNumber":                   TagGroupCamera,
+		"ExposureProgram":           TagGroupCamera,
+		"ISOSpeedRatings":           TagGroupCamera,
+		"ShutterSpeedValue":         TagGroupCamera,
+		"ApertureValue":             TagGroupCamera,
+		"ExposureBiasValue":         TagGroupCamera,
+		"MaxApertureValue":          TagGroupCamera,
+		"SubjectDistance":           TagGroupCamera,
+		"MeteringMode":              TagGroupCamera,
+		"LightSource":               TagGroupCamera,
+		"Flash":                     TagGroupCamera,
+		"FocalLength":               TagGroupCamera,
+		"SelfTimerMode":             TagGroupCamera,
+		"ImageWidth":                TagGroupImage,
+		"ImageLength":               TagGroupImage,
+		"BitsPerSample":             TagGroupImage,
+		"Compression":               TagGroupImage,
+		"PhotometricInterpretation": TagGroupImage,
+		"Orientation":               TagGroupImage,
+		"SamplesPerPixel":           TagGroupImage,
+		"PlanarConfiguration":       TagGroupImage,
+		"XResolution":               TagGroupImage,
+		"YResolution":               TagGroupImage,
+		"ResolutionUnit":            TagGroupImage,
+		"TransferFunction":          TagGroupImage,
+		"WhitePoint":                TagGroupImage,
+		"PrimaryChromaticities":     TagGroupImage,
+		"YCbCrCoefficients":         TagGroupImage,
+		"YCbCrSubSampling":          TagGroupImage,
+		"YCbCrPositioning":          TagGroupImage,
+		"ReferenceBlackWhite":       TagGroupImage,
+		"InterColorProfile":         TagGroupImage,
+		"DateTime":                  TagGroupTime,
+		"DateTimeOriginal":          TagGroupTime,
+		"ImageDescription":          TagGroupDescription,
+		"DocumentName":              TagGroupDescription,
+		"Artist":                    TagGroupDescription,
+		"Copyright":                 TagGroupDescription,
+		"Rating":                    TagGroupDescription,
+		"RatingPercent":             TagGroupDescription,
+		"XPTitle":                   TagGroupDescription,
+		"XPComment":                 TagGroupDescription,
+		"XPAuthor":                  TagGroupDescription,
+		"XPKeywords":                TagGroupDescription,
+		"XPSubject":                 TagGroupDescription,
+	},
+	exifcommon.IfdExifStandardIfdIdentity.UnindexedString(): {
+		"ExposureTime":              TagGroupCamera,
+		"FNumber":                   TagGroupCamera,
+		"ExposureProgram":           TagGroupCamera,
+		"SpectralSensitivity":       TagGroupCamera,
+		"ISOSpeedRatings":           TagGroupCamera,
+		"SensitivityType":           TagGroupCamera,
+		"StandardOutputSensitivity": TagGroupCamera,
+		"RecommendedExposureIndex":  TagGroupCamera,
+		"ISOSpeed":                  TagGroupCamera,
+		"ShutterSpeedValue":         TagGroupCamera,
+		"ApertureValue":             TagGroupCamera,
+		"BrightnessValue":           TagGroupCamera,
+		"ExposureBiasValue":         TagGroupCamera,
+		"MaxApertureValue":          TagGroupCamera,
+		"SubjectDistance":           TagGroupCamera,
+		"MeteringMode":              TagGroupCamera,
+		"LightSource":               TagGroupCamera,
+		"Flash":                     TagGroupCamera,
+		"FocalLength":               TagGroupCamera,
+		"SubjectArea":               TagGroupCamera,
+		"MakerNote":                 TagGroupCamera,
+		"FlashEnergy":               TagGroupCamera,
+		"FocalPlaneXResolution":     TagGroupCamera,
+		"FocalPlaneYResolution":     TagGroupCamera,
+		"FocalPlaneResolutionUnit":  TagGroupCamera,
+		"SubjectLocation":           TagGroupCamera,
+		"ExposureIndex":             TagGroupCamera,
+		"SensingMethod":             TagGroupCamera,
+		"ExposureMode":              TagGroupCamera,
+		"WhiteBalance":              TagGroupCamera,
+		"DigitalZoomRatio":          TagGroupCamera,
+		"FocalLengthIn35mmFilm":     TagGroupCamera,
+		"SceneCaptureType":          TagGroupCamera,
+		"GainControl":               TagGroupCamera,
+		"Contrast":                  TagGroupCamera,
+		"Saturation":                TagGroupCamera,
+		"Sharpness":                 TagGroupCamera,
+		"SubjectDistanceRange":      TagGroupCamera,
+		"CameraOwnerName":           TagGroupCamera,
+		"BodySerialNumber":          TagGroupCamera,
+		"LensSpecification":         TagGroupCamera,
+		"LensMake":                  TagGroupCamera,
+		"LensModel":                 TagGroupCamera,
+		"LensSerialNumber":          TagGroupCamera,
+		"ComponentsConfiguration":   TagGroupImage,
+		"CompressedBitsPerPixel":    TagGroupImage,
+		"ColorSpace":                TagGroupImage,
+		"PixelXDimension":           TagGroupImage,
+		"PixelYDimension":           TagGroupImage,
+		"CustomRendered":            TagGroupImage,
+		"DateTimeOriginal":          TagGroupTime,
+		"DateTimeDigitized":         TagGroupTime,
+		"OffsetTime":                TagGroupTime,
+		"OffsetTimeOriginal":        TagGroupTime,
+		"OffsetTimeDigitized":       TagGroupTime,
+		"SubSecTime":                TagGroupTime,
+		"SubSecTimeOriginal":        TagGroupTime,
+		"SubSecTimeDigitized":       TagGroupTime,
+		"UserComment":               TagGroupDescription,
+		"ImageUniqueID":             TagGroupDescription,
+	},
+}
+
+// standardTagGroup returns the group of the given standard tag.
+func standardTagGroup(ifdPath, tagName string) TagGroup {
+	if ifdPath == exifcommon.IfdGpsInfoStandardIfdIdentity.UnindexedString() {
+		return TagGroupGps
+	}
+
+	// A missing entry is `TagGroupOther`.
+	return standardTagGroups[ifdPath][tagName]
+}
+
+// GroupOf returns the group of the given tag, as recorded in the index. Tags
+// that aren't in the index are in `TagGroupOther`.
+func (ti *TagIndex) GroupOf(ii *exifcommon.IfdIdentity, tagId uint16) (group TagGroup, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	it, err := ti.Get(ii, tagId)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return TagGroupOther, nil
+		}
+
+		log.Panic(err)
+	}
+
+	return it.Group, nil
+}
+
+// EnumerateTagsInGroup calls the visitor for every tag in the given group in
+// this IFD, its descendants, and the IFDs chained after it, in the order that
+// they were stored.
+func (ifd *Ifd) EnumerateTagsInGroup(group TagGroup, visitor ParsedTagVisitor) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	filteredVisitor := func(currentIfd *Ifd, ite *IfdTagEntry) (err error) {
+		defer func() {
+			if state := recover(); state != nil {
+				err = log.Wrap(state.(error))
+			}
+		}()
+
+		currentGroup, err := currentIfd.tagIndex.GroupOf(currentIfd.ifdIdentity, ite.TagId())
+		log.PanicIf(err)
+
+		if currentGroup != group {
+			return nil
+		}
+
+		err = visitor(currentIfd, ite)
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	err = ifd.EnumerateTagsInOrder(TagOrderOriginal, filteredVisitor)
+	log.PanicIf(err)
+
+	return nil
+}
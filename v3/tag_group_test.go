@@ -0,0 +1,99 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestStandardTagGroups_AreStandardTags(t *testing.T) {
+	ti := NewTagIndex()
+
+	err := LoadStandardTags(ti)
+	log.PanicIf(err)
+
+	for ifdPath, groups := range standardTagGroups {
+		for tagName := range groups {
+			if _, found := ti.tagsByIfdR[ifdPath][tagName]; found == false {
+				t.Fatalf("Grouped tag is not a standard tag: [%s] [%s]", ifdPath, tagName)
+			}
+		}
+	}
+}
+
+func TestTagIndex_GroupOf(t *testing.T) {
+	ti := NewTagIndex()
+
+	cases := []struct {
+		ii    *exifcommon.IfdIdentity
+		tagId uint16
+		group TagGroup
+	}{
+		{exifcommon.IfdStandardIfdIdentity, 0x010f, TagGroupCamera},
+		{exifcommon.IfdStandardIfdIdentity, 0x0112, TagGroupImage},
+		{exifcommon.IfdStandardIfdIdentity, 0x8298, TagGroupDescription},
+		{exifcommon.IfdStandardIfdIdentity, 0x0131, TagGroupOther},
+		{exifcommon.IfdExifStandardIfdIdentity, 0x9003, TagGroupTime},
+		{exifcommon.IfdGpsInfoStandardIfdIdentity, TagLatitudeId, TagGroupGps},
+		{exifcommon.IfdGpsInfoStandardIfdIdentity, TagDatestampId, TagGroupGps},
+		{exifcommon.IfdStandardIfdIdentity, 0xfffe, TagGroupOther},
+	}
+
+	for _, c := range cases {
+		group, err := ti.GroupOf(c.ii, c.tagId)
+		log.PanicIf(err)
+
+		if group != c.group {
+			t.Fatalf("Group of [%s] (0x%04x) not correct: [%s] != [%s]", c.ii, c.tagId, group, c.group)
+		}
+	}
+}
+
+func TestIfd_EnumerateTagsInGroup(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, getTestExifData())
+	log.PanicIf(err)
+
+	collect := func(group TagGroup) []string {
+		names := make([]string, 0)
+
+		visitor := func(ifd *Ifd, ite *IfdTagEntry) (err error) {
+			names = append(names, ifd.IfdIdentity().UnindexedString()+"/"+ite.TagName())
+			return nil
+		}
+
+		err := index.RootIfd.EnumerateTagsInGroup(group, visitor)
+		log.PanicIf(err)
+
+		return names
+	}
+
+	expected := []string{
+		"IFD/DateTime",
+		"IFD/Exif/DateTimeOriginal",
+		"IFD/Exif/DateTimeDigitized",
+		"IFD/Exif/SubSecTime",
+		"IFD/Exif/SubSecTimeOriginal",
+		"IFD/Exif/SubSecTimeDigitized",
+	}
+
+	names := collect(TagGroupTime)
+	if reflect.DeepEqual(names, expected) != true {
+		t.Fatalf("Time tags not correct: %v", names)
+	}
+
+	cameraNames := collect(TagGroupCamera)
+	if len(cameraNames) < 2 || cameraNames[0] != "IFD/Make" || cameraNames[1] != "IFD/Model" {
+		t.Fatalf("Camera tags not correct: %v", cameraNames)
+	}
+}
@@ -0,0 +1,101 @@
+package exif
+
+import (
+	"sort"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// TagOrder determines the order that the tags of an IFD are traversed in.
+type TagOrder int
+
+const (
+	// TagOrderOriginal is the order that the tags were stored in. The
+	// specification requires ascending tag-IDs, but writers differ in how
+	// they deviate from it, which can help identify them.
+	TagOrderOriginal TagOrder = iota
+
+	// TagOrderSorted is ascending order of tag-ID. Tags with the same ID keep
+	// their original order.
+	TagOrderSorted
+)
+
+// String returns the name of the order.
+func (to TagOrder) String() string {
+	switch to {
+	case TagOrderOriginal:
+		return "original"
+	case TagOrderSorted:
+		return "sorted"
+	}
+
+	return "unknown"
+}
+
+// Position returns the position of the tag within its IFD as stored. Tags that
+// were skipped while parsing still count, so positions may have gaps.
+func (ite *IfdTagEntry) Position() int {
+	return ite.tagIndex
+}
+
+// EntriesInOrder returns the tags of this IFD in the given order. The slice is
+// always a copy.
+func (ifd *Ifd) EntriesInOrder(to TagOrder) []*IfdTagEntry {
+	entries := make([]*IfdTagEntry, len(ifd.entries))
+	copy(entries, ifd.entries)
+
+	if to == TagOrderSorted {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].tagId < entries[j].tagId
+		})
+	}
+
+	return entries
+}
+
+// EnumerateTagsInOrder calls the visitor for every tag in this IFD, its
+// descendants, and the IFDs chained after it. The tags of each IFD are visited
+// in the given order, and the tags of a child IFD are visited at the position
+// of the tag that points to it.
+func (ifd *Ifd) EnumerateTagsInOrder(to TagOrder, visitor ParsedTagVisitor) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for ptr := ifd; ptr != nil; ptr = ptr.nextIfd {
+		err := ptr.enumerateTagsInOrder(to, visitor)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+func (ifd *Ifd) enumerateTagsInOrder(to TagOrder, visitor ParsedTagVisitor) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	for _, ite := range ifd.EntriesInOrder(to) {
+		childIfdPath := ite.ChildIfdPath()
+		if childIfdPath == "" {
+			err := visitor(ifd, ite)
+			log.PanicIf(err)
+
+			continue
+		}
+
+		childIfd, found := ifd.childIfdIndex[childIfdPath]
+		if found == false {
+			continue
+		}
+
+		err := childIfd.enumerateTagsInOrder(to, visitor)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getUnsortedTestRootIfd returns IFDs whose tags are stored out of order.
+func getUnsortedTestRootIfd() *Ifd {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	rootIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = rootIb.AddStandardWithName("Software", "some software")
+	log.PanicIf(err)
+
+	err = rootIb.SetExifStandardWithName("ISOSpeedRatings", []uint16{100})
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("Make", "some make")
+	log.PanicIf(err)
+
+	err = rootIb.AddStandardWithName("Model", "some model")
+	log.PanicIf(err)
+
+	ifd1Ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ifd1Ib.AddStandardWithName("Orientation", []uint16{1})
+	log.PanicIf(err)
+
+	err = rootIb.SetNextIb(ifd1Ib)
+	log.PanicIf(err)
+
+	ibe := NewIfdByteEncoder()
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	return index.RootIfd
+}
+
+func TestIfd_EntriesInOrder(t *testing.T) {
+	rootIfd := getUnsortedTestRootIfd()
+
+	tagIds := func(entries []*IfdTagEntry) []uint16 {
+		ids := make([]uint16, len(entries))
+		for i, ite := range entries {
+			ids[i] = ite.TagId()
+		}
+
+		return ids
+	}
+
+	original := rootIfd.EntriesInOrder(TagOrderOriginal)
+
+	if reflect.DeepEqual(tagIds(original), []uint16{0x0131, 0x8769, 0x010f, 0x0110}) != true {
+		t.Fatalf("Original order not correct: %x", tagIds(original))
+	}
+
+	for i, ite := range original {
+		if ite.Position() != i {
+			t.Fatalf("Position not correct: (%d) != (%d)", ite.Position(), i)
+		}
+	}
+
+	sorted := rootIfd.EntriesInOrder(TagOrderSorted)
+
+	if reflect.DeepEqual(tagIds(sorted), []uint16{0x010f, 0x0110, 0x0131, 0x8769}) != true {
+		t.Fatalf("Sorted order not correct: %x", tagIds(sorted))
+	}
+
+	// The stored order is unaffected.
+
+	if reflect.DeepEqual(tagIds(rootIfd.Entries()), tagIds(original)) != true {
+		t.Fatalf("Entries were reordered.")
+	}
+}
+
+func TestIfd_EnumerateTagsInOrder(t *testing.T) {
+	rootIfd := getUnsortedTestRootIfd()
+
+	enumerate := func(to TagOrder) []string {
+		visited := make([]string, 0)
+
+		cb := func(ifd *Ifd, ite *IfdTagEntry) error {
+			visited = append(visited, ifd.ifdIdentity.String()+"/"+ite.TagName())
+			return nil
+		}
+
+		err := rootIfd.EnumerateTagsInOrder(to, cb)
+		log.PanicIf(err)
+
+		return visited
+	}
+
+	expected := []string{"IFD/Software", "IFD/Exif/ISOSpeedRatings", "IFD/Make", "IFD/Model", "IFD1/Orientation"}
+
+	if actual := enumerate(TagOrderOriginal); reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Original order not correct: %v", actual)
+	}
+
+	expected = []string{"IFD/Make", "IFD/Model", "IFD/Software", "IFD/Exif/ISOSpeedRatings", "IFD1/Orientation"}
+
+	if actual := enumerate(TagOrderSorted); reflect.DeepEqual(actual, expected) != true {
+		t.Fatalf("Sorted order not correct: %v", actual)
+	}
+}
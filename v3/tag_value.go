@@ -0,0 +1,422 @@
+package exif
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"encoding/json"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+	"github.com/dsoprea/go-exif/v3/undefined"
+)
+
+// TagValue is a decoded value along with its type (its kind). It's what
+// `IfdTagEntry.TagValue()`, `BuilderTag.TagValue()`, and `ExifTag.TagValue()`
+// return so that callers can use the getter for the type they expect rather
+// than switching on an `interface{}`. Each getter returns the value if it has
+// that kind and a zero value (nil or empty) otherwise, and the zero TagValue
+// has no kind, so a missing or mismatched value never needs to be checked for
+// separately. A TagValue can also be given wherever the builder takes a value.
+type TagValue struct {
+	kind  exifcommon.TagTypePrimitive
+	value interface{}
+}
+
+// NewTagValue returns the TagValue for the given native value. The kind is
+// inferred as described for `InferTagType()`, and single values are stored as
+// one-element slices. UNDEFINED values (e.g. `exifundefined.Tag9000ExifVersion`)
+// are accepted too. `ErrTagTypeNotInferable` is returned for anything else.
+func NewTagValue(value interface{}) (tv TagValue, err error) {
+	if t, ok := value.(time.Time); ok == true {
+		value = exifcommon.ExifFullTimestampString(t)
+	}
+
+	if _, ok := value.(exifundefined.EncodeableValue); ok == true {
+		return TagValue{kind: exifcommon.TypeUndefined, value: value}, nil
+	}
+
+	value = normalizeCustomValue(value)
+
+	kind, err := InferTagType(value)
+	if err != nil {
+		return tv, err
+	}
+
+	return TagValue{kind: kind, value: value}, nil
+}
+
+// Kind returns the type of the value, or zero for the zero TagValue.
+func (tv TagValue) Kind() exifcommon.TagTypePrimitive {
+	return tv.kind
+}
+
+// IsZero returns true if there's no value.
+func (tv TagValue) IsZero() bool {
+	return tv.kind == 0
+}
+
+// Interface returns the value as the native type that `IfdTagEntry.Value()`
+// returns.
+func (tv TagValue) Interface() interface{} {
+	return tv.value
+}
+
+// Len returns the number of units in the value (the length of the string for
+// ASCII). It's zero for UNDEFINED values that aren't bytes.
+func (tv TagValue) Len() int {
+	if tv.value == nil {
+		return 0
+	}
+
+	v := reflect.ValueOf(tv.value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.String {
+		return 0
+	}
+
+	return v.Len()
+}
+
+// Ascii returns the string of an ASCII value.
+func (tv TagValue) Ascii() string {
+	s, _ := tv.value.(string)
+	return s
+}
+
+// Bytes returns the bytes of a BYTE value, or of an UNDEFINED value that
+// isn't decoded into a structure.
+func (tv TagValue) Bytes() []byte {
+	b, _ := tv.value.([]byte)
+	return b
+}
+
+// Shorts returns the units of a SHORT value.
+func (tv TagValue) Shorts() []uint16 {
+	if tv.kind != exifcommon.TypeShort {
+		return nil
+	}
+
+	s, _ := tv.value.([]uint16)
+	return s
+}
+
+// Longs returns the units of a LONG value.
+func (tv TagValue) Longs() []uint32 {
+	if tv.kind != exifcommon.TypeLong {
+		return nil
+	}
+
+	l, _ := tv.value.([]uint32)
+	return l
+}
+
+// SignedLongs returns the units of an SLONG value.
+func (tv TagValue) SignedLongs() []int32 {
+	l, _ := tv.value.([]int32)
+	return l
+}
+
+// Rationals returns the units of a RATIONAL value.
+func (tv TagValue) Rationals() []exifcommon.Rational {
+	r, _ := tv.value.([]exifcommon.Rational)
+	return r
+}
+
+// SignedRationals returns the units of an SRATIONAL value.
+func (tv TagValue) SignedRationals() []exifcommon.SignedRational {
+	r, _ := tv.value.([]exifcommon.SignedRational)
+	return r
+}
+
+// Floats returns the units of a FLOAT value.
+func (tv TagValue) Floats() []float32 {
+	f, _ := tv.value.([]float32)
+	return f
+}
+
+// Doubles returns the units of a DOUBLE value.
+func (tv TagValue) Doubles() []float64 {
+	d, _ := tv.value.([]float64)
+	return d
+}
+
+// Undefined returns the decoded structure of an UNDEFINED value (e.g.
+// `exifundefined.Tag9101ComponentsConfiguration`).
+func (tv TagValue) Undefined() interface{} {
+	if tv.kind != exifcommon.TypeUndefined {
+		return nil
+	}
+
+	return tv.value
+}
+
+// Uint32s returns the units of a BYTE, SHORT, or LONG value widened to
+// uint32s, since the same tag can often be stored as any of them.
+func (tv TagValue) Uint32s() []uint32 {
+	switch t := tv.value.(type) {
+	case []uint8:
+		if tv.kind != exifcommon.TypeByte {
+			return nil
+		}
+
+		values := make([]uint32, len(t))
+		for i, b := range t {
+			values[i] = uint32(b)
+		}
+
+		return values
+	case []uint16:
+		values := make([]uint32, len(t))
+		for i, s := range t {
+			values[i] = uint32(s)
+		}
+
+		return values
+	case []uint32:
+		return t
+	}
+
+	return nil
+}
+
+// Float64s returns the units of any numeric value as float64s. Rationals are
+// divided out (a zero denominator gives an infinity or NaN).
+func (tv TagValue) Float64s() []float64 {
+	var values []float64
+
+	switch t := tv.value.(type) {
+	case []int32:
+		values = make([]float64, len(t))
+		for i, v := range t {
+			values[i] = float64(v)
+		}
+	case []float32:
+		values = make([]float64, len(t))
+		for i, v := range t {
+			values[i] = float64(v)
+		}
+	case []float64:
+		values = t
+	case []exifcommon.Rational:
+		values = make([]float64, len(t))
+		for i, v := range t {
+			values[i] = float64(v.Numerator) / float64(v.Denominator)
+		}
+	case []exifcommon.SignedRational:
+		values = make([]float64, len(t))
+		for i, v := range t {
+			values[i] = float64(v.Numerator) / float64(v.Denominator)
+		}
+	default:
+		integers := tv.Uint32s()
+		if integers == nil {
+			return nil
+		}
+
+		values = make([]float64, len(integers))
+		for i, v := range integers {
+			values[i] = float64(v)
+		}
+	}
+
+	return values
+}
+
+// Equal returns true if both values have the same kind and content.
+func (tv TagValue) Equal(other TagValue) bool {
+	return tv.kind == other.kind && reflect.DeepEqual(tv.value, other.value)
+}
+
+// String returns a descriptive string.
+func (tv TagValue) String() string {
+	if tv.IsZero() == true {
+		return "TagValue<>"
+	}
+
+	return fmt.Sprintf("TagValue<KIND=[%s] VALUE=[%v]>", tv.kind, tv.value)
+}
+
+// tagValueJson is how a TagValue is serialized.
+type tagValueJson struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MarshalJSON encodes the value as its kind and value. The zero TagValue is
+// encoded as null.
+func (tv TagValue) MarshalJSON() (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if tv.IsZero() == true {
+		return []byte("null"), nil
+	}
+
+	encoded, err := json.Marshal(tv.value)
+	log.PanicIf(err)
+
+	tvj := tagValueJson{
+		Kind:  tv.kind.String(),
+		Value: encoded,
+	}
+
+	data, err = json.Marshal(tvj)
+	log.PanicIf(err)
+
+	return data, nil
+}
+
+// UnmarshalJSON decodes what `MarshalJSON()` encodes. UNDEFINED values are
+// decoded as bytes, so only those that weren't decoded into a structure
+// survive the trip.
+func (tv *TagValue) UnmarshalJSON(data []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if string(data) == "null" {
+		*tv = TagValue{}
+		return nil
+	}
+
+	tvj := tagValueJson{}
+
+	err = json.Unmarshal(data, &tvj)
+	log.PanicIf(err)
+
+	kind, found := exifcommon.GetTypeByName(tvj.Kind)
+	if found == false {
+		log.Panicf("tag-value kind not valid: [%s]", tvj.Kind)
+	}
+
+	var value interface{}
+
+	switch kind {
+	case exifcommon.TypeAscii, exifcommon.TypeAsciiNoNul:
+		value = new(string)
+	case exifcommon.TypeByte, exifcommon.TypeUndefined:
+		value = new([]byte)
+	case exifcommon.TypeShort:
+		value = new([]uint16)
+	case exifcommon.TypeLong:
+		value = new([]uint32)
+	case exifcommon.TypeSignedLong:
+		value = new([]int32)
+	case exifcommon.TypeRational:
+		value = new([]exifcommon.Rational)
+	case exifcommon.TypeSignedRational:
+		value = new([]exifcommon.SignedRational)
+	case exifcommon.TypeFloat:
+		value = new([]float32)
+	case exifcommon.TypeDouble:
+		value = new([]float64)
+	default:
+		log.Panicf("tag-value kind not supported: [%s]", kind)
+	}
+
+	err = json.Unmarshal(tvj.Value, value)
+	log.PanicIf(err)
+
+	tv.kind = kind
+	tv.value = reflect.ValueOf(value).Elem().Interface()
+
+	return nil
+}
+
+// TagValue returns the decoded value of the tag along with its type. See
+// `Value()`.
+func (ite *IfdTagEntry) TagValue() (tv TagValue, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	value, err := ite.Value()
+	if err != nil {
+		if err == exifcommon.ErrUnhandledUndefinedTypedTag {
+			return tv, err
+		}
+
+		log.Panic(err)
+	}
+
+	tv = TagValue{
+		kind:  ite.TagType(),
+		value: value,
+	}
+
+	return tv, nil
+}
+
+// TagValue returns the value of the tag along with its type. The zero
+// TagValue is returned for a child IFD or a value that is still in the
+// original EXIF.
+func (bt *BuilderTag) TagValue() (tv TagValue, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var value interface{}
+
+	if bt.value.IsBytes() == true {
+		if bt.typeId == exifcommon.TypeUndefined {
+			value = bt.value.Bytes()
+		} else {
+			value, err = decodeBuilderTagValueBytes(bt.typeId, bt.value.Bytes(), bt.byteOrder)
+			log.PanicIf(err)
+		}
+	} else if bt.value.IsValue() == true {
+		value = bt.value.Value()
+
+		if t, ok := value.(time.Time); ok == true {
+			value = exifcommon.ExifFullTimestampString(t)
+		} else if bt.typeId != exifcommon.TypeUndefined {
+			value, err = exifcommon.CoerceValue(normalizeCustomValue(value), bt.typeId, exifcommon.CoercionOptions{})
+			log.PanicIf(err)
+		}
+	} else {
+		return tv, nil
+	}
+
+	tv = TagValue{
+		kind:  bt.typeId,
+		value: value,
+	}
+
+	return tv, nil
+}
+
+// TagValue returns the value along with its type. The zero TagValue is
+// returned if the value was summarized.
+func (et ExifTag) TagValue() TagValue {
+	if et.IsSummarized == true || et.Value == nil {
+		return TagValue{}
+	}
+
+	return TagValue{
+		kind:  et.TagTypeId,
+		value: et.Value,
+	}
+}
+
+// unwrapTagValue returns the native value if the given value is a TagValue.
+func unwrapTagValue(value interface{}) interface{} {
+	switch t := value.(type) {
+	case TagValue:
+		return t.value
+	case *TagValue:
+		return t.value
+	}
+
+	return value
+}
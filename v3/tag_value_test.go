@@ -0,0 +1,167 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"reflect"
+	"testing"
+
+	"encoding/json"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestNewTagValue(t *testing.T) {
+	tv, err := NewTagValue(uint16(6))
+	log.PanicIf(err)
+
+	if tv.Kind() != exifcommon.TypeShort {
+		t.Fatalf("Kind not correct: [%s]", tv.Kind())
+	} else if reflect.DeepEqual(tv.Shorts(), []uint16{6}) != true {
+		t.Fatalf("Shorts not correct: %v", tv.Shorts())
+	} else if reflect.DeepEqual(tv.Uint32s(), []uint32{6}) != true {
+		t.Fatalf("Uint32s not correct: %v", tv.Uint32s())
+	}
+
+	// Getters of other kinds return zero values.
+
+	if tv.Longs() != nil || tv.Ascii() != "" || tv.Rationals() != nil || tv.Undefined() != nil {
+		t.Fatalf("Mismatched getters should return zero values.")
+	}
+
+	_, err = NewTagValue(struct{}{})
+	if err != ErrTagTypeNotInferable {
+		t.Fatalf("Expected not-inferable error: [%v]", err)
+	}
+}
+
+func TestTagValue_Zero(t *testing.T) {
+	tv := TagValue{}
+
+	if tv.IsZero() != true {
+		t.Fatalf("Zero value should be zero.")
+	} else if tv.Len() != 0 || tv.Bytes() != nil || tv.Float64s() != nil {
+		t.Fatalf("Zero value should return zero values.")
+	}
+
+	data, err := json.Marshal(tv)
+	log.PanicIf(err)
+
+	if string(data) != "null" {
+		t.Fatalf("Zero value JSON not correct: [%s]", string(data))
+	}
+}
+
+func TestTagValue_Float64s(t *testing.T) {
+	tv, err := NewTagValue(exifcommon.Rational{Numerator: 1, Denominator: 4})
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(tv.Float64s(), []float64{0.25}) != true {
+		t.Fatalf("Float64s not correct: %v", tv.Float64s())
+	}
+}
+
+func TestTagValue_Json(t *testing.T) {
+	values := []interface{}{
+		"text",
+		[]uint8{1, 2},
+		[]uint16{3},
+		[]uint32{4, 5},
+		[]int32{-6},
+		[]exifcommon.Rational{{Numerator: 7, Denominator: 8}},
+		[]exifcommon.SignedRational{{Numerator: -9, Denominator: 10}},
+		[]float32{1.5},
+		[]float64{2.5},
+	}
+
+	for _, value := range values {
+		tv, err := NewTagValue(value)
+		log.PanicIf(err)
+
+		data, err := json.Marshal(tv)
+		log.PanicIf(err)
+
+		var recovered TagValue
+
+		err = json.Unmarshal(data, &recovered)
+		log.PanicIf(err)
+
+		if recovered.Equal(tv) != true {
+			t.Fatalf("Value did not survive JSON: [%s] != [%s]", recovered, tv)
+		}
+	}
+}
+
+func TestIfdTagEntry_TagValue(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	_, index, err := Collect(im, NewTagIndex(), getTestExifData())
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("Make")
+	log.PanicIf(err)
+
+	tv, err := results[0].TagValue()
+	log.PanicIf(err)
+
+	if tv.Kind() != exifcommon.TypeAscii || tv.Ascii() != "Canon" {
+		t.Fatalf("Make not correct: [%s]", tv)
+	}
+
+	exifIfd, err := index.RootIfd.ChildWithIfdPath(exifcommon.IfdExifStandardIfdIdentity)
+	log.PanicIf(err)
+
+	results, err = exifIfd.FindTagWithName("ExposureTime")
+	log.PanicIf(err)
+
+	tv, err = results[0].TagValue()
+	log.PanicIf(err)
+
+	if len(tv.Rationals()) != 1 || tv.Rationals()[0].Denominator == 0 {
+		t.Fatalf("ExposureTime not correct: [%s]", tv)
+	}
+}
+
+func TestBuilderTag_TagValue(t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.EncodeDefaultByteOrder)
+
+	tv, err := NewTagValue([]uint16{6})
+	log.PanicIf(err)
+
+	// The builder takes a TagValue wherever it takes a value.
+
+	err = ib.SetStandardWithName("Orientation", tv)
+	log.PanicIf(err)
+
+	err = ib.AddStandardWithName("ImageWidth", uint16(100))
+	log.PanicIf(err)
+
+	bt, err := ib.FindTagWithName("Orientation")
+	log.PanicIf(err)
+
+	recovered, err := bt.TagValue()
+	log.PanicIf(err)
+
+	if recovered.Equal(tv) != true {
+		t.Fatalf("Orientation not correct: [%s]", recovered)
+	}
+
+	bt, err = ib.FindTagWithName("ImageWidth")
+	log.PanicIf(err)
+
+	recovered, err = bt.TagValue()
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(recovered.Uint32s(), []uint32{100}) != true {
+		t.Fatalf("ImageWidth not correct: [%s]", recovered)
+	}
+}
@@ -2,6 +2,7 @@ package exif
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/dsoprea/go-logging"
@@ -22,6 +23,30 @@ const (
 
 	// ThumbnailSizeTagId returns the tag-ID of the thumbnail size.
 	ThumbnailSizeTagId = 0x0202
+
+	// ThumbnailStripOffsetsTagId is the tag-ID of the strip offsets of an
+	// uncompressed thumbnail.
+	ThumbnailStripOffsetsTagId = 0x0111
+
+	// ThumbnailStripByteCountsTagId is the tag-ID of the strip sizes of an
+	// uncompressed thumbnail.
+	ThumbnailStripByteCountsTagId = 0x0117
+
+	// IFD
+
+	// InterColorProfileTagId is the tag-ID of the embedded ICC profile.
+	InterColorProfileTagId = 0x8773
+
+	// IFD and IFD/Exif
+
+	// PaddingTagId is the tag-ID of the padding that Windows reserves for later
+	// edits.
+	PaddingTagId = 0xea1c
+
+	// IFD/Exif
+
+	// MakerNoteTagId is the tag-ID of the vendor-specific maker-note.
+	MakerNoteTagId = 0x927c
 )
 
 const (
@@ -95,6 +120,10 @@ type IndexedTag struct {
 
 	// SupportedTypes is an unsorted list of allowed tag-types.
 	SupportedTypes []exifcommon.TagTypePrimitive
+
+	// Group is the category of the tag. The standard tags are assigned one
+	// when they're loaded.
+	Group TagGroup
 }
 
 // String returns a descriptive string.
@@ -145,6 +174,13 @@ func (it *IndexedTag) GetEncodingType(value interface{}) exifcommon.TagTypePrimi
 	// We specifically check for the cases that we know to expect.
 
 	if supportsLong == true && supportsShort == true {
+		// Some DNG tags (e.g. BlackLevel) can also be rationals.
+		if supportsRational == true {
+			if inferredType, err := InferTagType(value); err == nil && inferredType == exifcommon.TypeRational {
+				return exifcommon.TypeRational
+			}
+		}
+
 		return exifcommon.TypeLong
 	} else if supportsRational == true && supportsSignedRational == true {
 		if value == nil {
@@ -153,11 +189,19 @@ func (it *IndexedTag) GetEncodingType(value interface{}) exifcommon.TagTypePrimi
 
 		if _, ok := value.(exifcommon.SignedRational); ok == true {
 			return exifcommon.TypeSignedRational
+		} else if _, ok := value.([]exifcommon.SignedRational); ok == true {
+			return exifcommon.TypeSignedRational
 		}
 
 		return exifcommon.TypeRational
 	}
 
+	// Otherwise, the value has to tell us (e.g. DNGPrivateData is bytes in a
+	// DNG but an IFD offset in an ARW).
+	if inferredType, err := InferTagType(value); err == nil && it.DoesSupportType(inferredType) == true {
+		return inferredType
+	}
+
 	log.Panicf("WidestSupportedType() case is not handled for tag [%s] (0x%04x): %v", it.IfdPath, it.Id, it.SupportedTypes)
 	return 0
 }
@@ -174,12 +218,25 @@ func (it *IndexedTag) DoesSupportType(tagType exifcommon.TagTypePrimitive) bool
 	return false
 }
 
-// TagIndex is a tag-lookup facility.
+// TagIndex is a tag-lookup facility. It is safe for concurrent use, so one
+// index can be shared by all parses and encodes. Tags should be added before
+// the index is shared, since a tag added later is only seen by lookups that
+// come after it.
 type TagIndex struct {
 	tagsByIfd  map[string]map[uint16]*IndexedTag
 	tagsByIfdR map[string]map[string]*IndexedTag
 
-	mutex sync.Mutex
+	// mutex guards the maps and the flags.
+	mutex sync.RWMutex
+
+	// loadMutex makes sure that the standard tags are only loaded once when
+	// the first lookups happen concurrently.
+	loadMutex sync.Mutex
+
+	// standardTagsChecked is set once the first lookup has loaded the
+	// standard tags (or found the index already populated). Until then, the
+	// index can be non-empty while it's still being loaded.
+	standardTagsChecked bool
 
 	doUniversalSearch bool
 }
@@ -196,11 +253,17 @@ func NewTagIndex() *TagIndex {
 
 // SetUniversalSearch enables a fallback to matching tags under *any* IFD.
 func (ti *TagIndex) SetUniversalSearch(flag bool) {
+	ti.mutex.Lock()
+	defer ti.mutex.Unlock()
+
 	ti.doUniversalSearch = flag
 }
 
 // UniversalSearch enables a fallback to matching tags under *any* IFD.
 func (ti *TagIndex) UniversalSearch() bool {
+	ti.mutex.RLock()
+	defer ti.mutex.RUnlock()
+
 	return ti.doUniversalSearch
 }
 
@@ -246,20 +309,60 @@ func (ti *TagIndex) Add(it *IndexedTag) (err error) {
 	return nil
 }
 
-func (ti *TagIndex) getOne(ifdPath string, id uint16) (it *IndexedTag, err error) {
+// loadStandardTagsIfEmpty loads the standard tags if no tags have been added.
+func (ti *TagIndex) loadStandardTagsIfEmpty() (err error) {
 	defer func() {
 		if state := recover(); state != nil {
 			err = log.Wrap(state.(error))
 		}
 	}()
 
-	if len(ti.tagsByIfd) == 0 {
-		err := LoadStandardTags(ti)
+	ti.mutex.RLock()
+	checked := ti.standardTagsChecked
+	ti.mutex.RUnlock()
+
+	if checked == true {
+		return nil
+	}
+
+	ti.loadMutex.Lock()
+	defer ti.loadMutex.Unlock()
+
+	// Another goroutine may have loaded them while we waited.
+
+	ti.mutex.RLock()
+	checked = ti.standardTagsChecked
+	isEmpty := len(ti.tagsByIfd) == 0
+	ti.mutex.RUnlock()
+
+	if checked == true {
+		return nil
+	}
+
+	if isEmpty == true {
+		err = LoadStandardTags(ti)
 		log.PanicIf(err)
 	}
 
 	ti.mutex.Lock()
-	defer ti.mutex.Unlock()
+	ti.standardTagsChecked = true
+	ti.mutex.Unlock()
+
+	return nil
+}
+
+func (ti *TagIndex) getOne(ifdPath string, id uint16) (it *IndexedTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ti.loadStandardTagsIfEmpty()
+	log.PanicIf(err)
+
+	ti.mutex.RLock()
+	defer ti.mutex.RUnlock()
 
 	family, found := ti.tagsByIfd[ifdPath]
 	if found == false {
@@ -292,7 +395,7 @@ func (ti *TagIndex) Get(ii *exifcommon.IfdIdentity, id uint16) (it *IndexedTag,
 		log.Panic(err)
 	}
 
-	if ti.doUniversalSearch == false {
+	if ti.UniversalSearch() == false {
 		return nil, ErrTagNotFound
 	}
 
@@ -300,7 +403,16 @@ func (ti *TagIndex) Get(ii *exifcommon.IfdIdentity, id uint16) (it *IndexedTag,
 
 	skipIfdPath := ii.UnindexedString()
 
-	for currentIfdPath, _ := range ti.tagsByIfd {
+	ti.mutex.RLock()
+
+	ifdPaths := make([]string, 0, len(ti.tagsByIfd))
+	for currentIfdPath := range ti.tagsByIfd {
+		ifdPaths = append(ifdPaths, currentIfdPath)
+	}
+
+	ti.mutex.RUnlock()
+
+	for _, currentIfdPath := range ifdPaths {
 		if currentIfdPath == skipIfdPath {
 			// Skip the primary IFD, which has already been checked.
 			continue
@@ -384,14 +496,15 @@ func (ti *TagIndex) GetWithName(ii *exifcommon.IfdIdentity, name string) (it *In
 		}
 	}()
 
-	if len(ti.tagsByIfdR) == 0 {
-		err := LoadStandardTags(ti)
-		log.PanicIf(err)
-	}
+	err = ti.loadStandardTagsIfEmpty()
+	log.PanicIf(err)
 
 	ifdPath := ii.UnindexedString()
 
+	ti.mutex.RLock()
 	it, found := ti.tagsByIfdR[ifdPath][name]
+	ti.mutex.RUnlock()
+
 	if found != true {
 		log.Panic(ErrTagNotFound)
 	}
@@ -399,6 +512,93 @@ func (ti *TagIndex) GetWithName(ii *exifcommon.IfdIdentity, name string) (it *In
 	return it, nil
 }
 
+// TagLocation is one place that a tag-name is registered.
+type TagLocation struct {
+	// IfdPath is the unindexed path of the IFD.
+	IfdPath string
+
+	// TagId is the ID of the tag in that IFD.
+	TagId uint16
+
+	// Tag is the registered tag.
+	Tag *IndexedTag
+}
+
+// String returns a descriptive string.
+func (tl TagLocation) String() string {
+	return fmt.Sprintf("TagLocation<IFD-PATH=[%s] TAG-ID=(0x%04x)>", tl.IfdPath, tl.TagId)
+}
+
+// Lookup returns every IFD that the given tag-name is registered in, ordered
+// by IFD-path. Some names are legitimately registered in more than one IFD
+// (e.g. "DateTimeOriginal" in both IFD0 and the Exif IFD), so tools that
+// resolve names from strings should check for more than one result rather
+// than taking the first. `ErrTagNotFound` is returned if the name is not
+// registered anywhere.
+func (ti *TagIndex) Lookup(name string) (locations []TagLocation, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	err = ti.loadStandardTagsIfEmpty()
+	log.PanicIf(err)
+
+	ti.mutex.RLock()
+
+	locations = make([]TagLocation, 0)
+	for ifdPath, familyR := range ti.tagsByIfdR {
+		if it, found := familyR[name]; found == true {
+			tl := TagLocation{
+				IfdPath: ifdPath,
+				TagId:   it.Id,
+				Tag:     it,
+			}
+
+			locations = append(locations, tl)
+		}
+	}
+
+	ti.mutex.RUnlock()
+
+	if len(locations) == 0 {
+		return nil, ErrTagNotFound
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].IfdPath < locations[j].IfdPath
+	})
+
+	return locations, nil
+}
+
+// LookupUnique is like `Lookup()` but requires the name to be registered in
+// exactly one IFD. `ErrTagNameAmbiguous` is returned, along with all of the
+// locations, if it is registered in more than one.
+func (ti *TagIndex) LookupUnique(name string) (tl TagLocation, locations []TagLocation, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	locations, err = ti.Lookup(name)
+	if err != nil {
+		if err == ErrTagNotFound {
+			return tl, nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	if len(locations) > 1 {
+		return tl, locations, ErrTagNameAmbiguous
+	}
+
+	return locations[0], locations, nil
+}
+
 // LoadStandardTags registers the tags that all devices/applications should
 // support.
 func LoadStandardTags(ti *TagIndex) (err error) {
@@ -460,6 +660,7 @@ func LoadStandardTags(ti *TagIndex) (err error) {
 				Id:             tagId,
 				Name:           tagName,
 				SupportedTypes: tagTypes,
+				Group:          standardTagGroup(ifdPath, tagName),
 			}
 
 			err = ti.Add(it)
@@ -230,6 +230,10 @@ IFD/Exif:
 - id: 0xa435
   name: LensSerialNumber
   type_name: ASCII
+# Reserved space written by Windows. See IFD.
+- id: 0xea1c
+  name: Padding
+  type_name: UNDEFINED
 IFD/GPSInfo:
 - id: 0x0000
   name: GPSVersionID
@@ -251,7 +255,7 @@ IFD/GPSInfo:
   type_name: BYTE
 - id: 0x0006
   name: GPSAltitude
-  type_name: RATIONAL
+  type_names: [RATIONAL, SRATIONAL]
 - id: 0x0007
   name: GPSTimeStamp
   type_name: RATIONAL
@@ -550,6 +554,12 @@ IFD:
 - id: 0x4749
   name: RatingPercent
   type_name: SHORT
+- id: 0x7000
+  name: SonyRawFileType
+  type_name: SHORT
+- id: 0x7010
+  name: SonyToneCurve
+  type_name: SHORT
 - id: 0x800d
   name: ImageID
   type_name: ASCII
@@ -710,7 +720,7 @@ IFD:
   type_name: ASCII
 - id: 0xc615
   name: LocalizedCameraModel
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc616
   name: CFAPlaneColor
   type_name: BYTE
@@ -725,7 +735,7 @@ IFD:
   type_name: SHORT
 - id: 0xc61a
   name: BlackLevel
-  type_name: RATIONAL
+  type_names: [SHORT, LONG, RATIONAL]
 - id: 0xc61b
   name: BlackLevelDeltaH
   type_name: SRATIONAL
@@ -734,16 +744,16 @@ IFD:
   type_name: SRATIONAL
 - id: 0xc61d
   name: WhiteLevel
-  type_name: SHORT
+  type_names: [LONG, SHORT]
 - id: 0xc61e
   name: DefaultScale
   type_name: RATIONAL
 - id: 0xc61f
   name: DefaultCropOrigin
-  type_name: SHORT
+  type_names: [SHORT, LONG, RATIONAL]
 - id: 0xc620
   name: DefaultCropSize
-  type_name: SHORT
+  type_names: [SHORT, LONG, RATIONAL]
 - id: 0xc621
   name: ColorMatrix1
   type_name: SRATIONAL
@@ -767,7 +777,7 @@ IFD:
   type_name: RATIONAL
 - id: 0xc628
   name: AsShotNeutral
-  type_name: SHORT
+  type_names: [SHORT, RATIONAL]
 - id: 0xc629
   name: AsShotWhiteXY
   type_name: RATIONAL
@@ -803,7 +813,7 @@ IFD:
   type_name: SRATIONAL
 - id: 0xc634
   name: DNGPrivateData
-  type_name: BYTE
+  type_names: [BYTE, LONG]
 - id: 0xc635
   name: MakerNoteSafety
   type_name: SHORT
@@ -821,16 +831,16 @@ IFD:
   type_name: BYTE
 - id: 0xc68b
   name: OriginalRawFileName
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc68c
   name: OriginalRawFileData
   type_name: UNDEFINED
 - id: 0xc68d
   name: ActiveArea
-  type_name: SHORT
+  type_names: [LONG, SHORT]
 - id: 0xc68e
   name: MaskedAreas
-  type_name: SHORT
+  type_names: [LONG, SHORT]
 - id: 0xc68f
   name: AsShotICCProfile
   type_name: UNDEFINED
@@ -848,28 +858,37 @@ IFD:
   type_name: SHORT
 - id: 0xc6f3
   name: CameraCalibrationSignature
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc6f4
   name: ProfileCalibrationSignature
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc6f6
   name: AsShotProfileName
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc6f7
   name: NoiseReductionApplied
   type_name: RATIONAL
 - id: 0xc6f8
   name: ProfileName
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc6f9
   name: ProfileHueSatMapDims
   type_name: LONG
+- id: 0xc6fa
+  name: ProfileHueSatMapData1
+  type_name: FLOAT
+- id: 0xc6fb
+  name: ProfileHueSatMapData2
+  type_name: FLOAT
+- id: 0xc6fc
+  name: ProfileToneCurve
+  type_name: FLOAT
 - id: 0xc6fd
   name: ProfileEmbedPolicy
   type_name: LONG
 - id: 0xc6fe
   name: ProfileCopyright
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc714
   name: ForwardMatrix1
   type_name: SRATIONAL
@@ -878,13 +897,13 @@ IFD:
   type_name: SRATIONAL
 - id: 0xc716
   name: PreviewApplicationName
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc717
   name: PreviewApplicationVersion
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc718
   name: PreviewSettingsName
-  type_name: BYTE
+  type_names: [ASCII, BYTE]
 - id: 0xc719
   name: PreviewSettingsDigest
   type_name: BYTE
@@ -902,13 +921,16 @@ IFD:
   type_name: UNDEFINED
 - id: 0xc71e
   name: SubTileBlockSize
-  type_name: LONG
+  type_names: [LONG, SHORT]
 - id: 0xc71f
   name: RowInterleaveFactor
-  type_name: LONG
+  type_names: [LONG, SHORT]
 - id: 0xc725
   name: ProfileLookTableDims
   type_name: LONG
+- id: 0xc726
+  name: ProfileLookTableData
+  type_name: FLOAT
 - id: 0xc740
   name: OpcodeList1
   type_name: UNDEFINED
@@ -918,6 +940,105 @@ IFD:
 - id: 0xc74e
   name: OpcodeList3
   type_name: UNDEFINED
+- id: 0xc761
+  name: NoiseProfile
+  type_name: DOUBLE
+- id: 0xc763
+  name: DefaultUserCrop
+  type_name: RATIONAL
+- id: 0xc764
+  name: DefaultBlackRender
+  type_name: LONG
+- id: 0xc765
+  name: BaselineExposureOffset
+  type_name: SRATIONAL
+- id: 0xc766
+  name: ProfileLookTableEncoding
+  type_name: LONG
+- id: 0xc767
+  name: ProfileHueSatMapEncoding
+  type_name: LONG
+- id: 0xc768
+  name: OriginalDefaultFinalSize
+  type_names: [LONG, SHORT]
+- id: 0xc769
+  name: OriginalBestQualityFinalSize
+  type_names: [LONG, SHORT]
+- id: 0xc76a
+  name: OriginalDefaultCropSize
+  type_names: [SHORT, LONG, RATIONAL]
+- id: 0xc76b
+  name: NewRawImageDigest
+  type_name: BYTE
+- id: 0xc76c
+  name: RawToPreviewGain
+  type_name: DOUBLE
+- id: 0xc791
+  name: DepthFormat
+  type_name: SHORT
+- id: 0xc792
+  name: DepthNear
+  type_name: RATIONAL
+- id: 0xc793
+  name: DepthFar
+  type_name: RATIONAL
+- id: 0xc794
+  name: DepthUnits
+  type_name: SHORT
+- id: 0xc795
+  name: DepthMeasureType
+  type_name: SHORT
+- id: 0xc796
+  name: EnhanceParams
+  type_name: ASCII
+- id: 0xc7a1
+  name: ProfileGainTableMap
+  type_name: UNDEFINED
+- id: 0xc7a2
+  name: SemanticName
+  type_name: ASCII
+- id: 0xc7a4
+  name: SemanticInstanceID
+  type_name: ASCII
+- id: 0xc7a5
+  name: CalibrationIlluminant3
+  type_name: SHORT
+- id: 0xc7a6
+  name: CameraCalibration3
+  type_name: SRATIONAL
+- id: 0xc7a7
+  name: ColorMatrix3
+  type_name: SRATIONAL
+- id: 0xc7a8
+  name: ForwardMatrix3
+  type_name: SRATIONAL
+- id: 0xc7a9
+  name: IlluminantData1
+  type_name: UNDEFINED
+- id: 0xc7aa
+  name: IlluminantData2
+  type_name: UNDEFINED
+- id: 0xc7ab
+  name: IlluminantData3
+  type_name: UNDEFINED
+- id: 0xc7ac
+  name: MaskSubArea
+  type_name: LONG
+- id: 0xc7ad
+  name: ProfileHueSatMapData3
+  type_name: FLOAT
+- id: 0xc7ae
+  name: ReductionMatrix3
+  type_name: SRATIONAL
+- id: 0xc7b5
+  name: RGBTables
+  type_name: UNDEFINED
+# Windows reserves space for later edits with this tag (in both this IFD and
+# the Exif IFD) and consumes it as the other tags grow, so that the file
+# doesn't have to be relaid. The value is opaque.
+- id: 0xea1c
+  name: Padding
+  type_name: UNDEFINED
 # This tag may be used to specify the size of raster pixel spacing in the
 # model space units, when the raster space can be embedded in the model space
 # coordinate system without rotation, and consists of the following 3 values:
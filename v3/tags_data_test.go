@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
@@ -2,6 +2,7 @@ package exif
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -401,3 +402,85 @@ func TestLoadStandardTags(t *testing.T) {
 		t.Fatalf("tagsByIfdR should be non-empty at the end.")
 	}
 }
+
+func TestTagIndex_Concurrent(t *testing.T) {
+	// The standard tags are loaded by whichever lookup comes first. They must
+	// only be loaded once.
+
+	ti := NewTagIndex()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			if i%2 == 0 {
+				_, err = ti.Get(exifcommon.IfdStandardIfdIdentity, 0x010f)
+			} else {
+				_, err = ti.GetWithName(exifcommon.IfdExifStandardIfdIdentity, "ExposureTime")
+			}
+
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Concurrent lookup failed: %v", err)
+	}
+}
+
+func TestTagIndex_Lookup(t *testing.T) {
+	ti := NewTagIndex()
+
+	locations, err := ti.Lookup("DateTimeOriginal")
+	log.PanicIf(err)
+
+	if len(locations) != 2 {
+		t.Fatalf("Expected two locations: %v", locations)
+	} else if locations[0].IfdPath != "IFD" || locations[1].IfdPath != "IFD/Exif" {
+		t.Fatalf("Locations not correct: %v", locations)
+	} else if locations[0].TagId != 0x9003 || locations[1].TagId != 0x9003 {
+		t.Fatalf("Tag-IDs not correct: %v", locations)
+	} else if locations[1].Tag.Name != "DateTimeOriginal" {
+		t.Fatalf("Tag not correct: %v", locations[1].Tag)
+	}
+}
+
+func TestTagIndex_Lookup__NotFound(t *testing.T) {
+	ti := NewTagIndex()
+
+	_, err := ti.Lookup("NotARealTag")
+	if err != ErrTagNotFound {
+		t.Fatalf("Expected not-found error: %v", err)
+	}
+}
+
+func TestTagIndex_LookupUnique(t *testing.T) {
+	ti := NewTagIndex()
+
+	tl, locations, err := ti.LookupUnique("PixelXDimension")
+	log.PanicIf(err)
+
+	if tl.IfdPath != "IFD/Exif" || tl.TagId != 0xa002 {
+		t.Fatalf("Location not correct: %s", tl)
+	} else if len(locations) != 1 {
+		t.Fatalf("Expected one location: %v", locations)
+	}
+
+	_, locations, err = ti.LookupUnique("DateTimeOriginal")
+	if err != ErrTagNameAmbiguous {
+		t.Fatalf("Expected ambiguity error: %v", err)
+	} else if len(locations) != 2 {
+		t.Fatalf("Ambiguous locations not returned: %v", locations)
+	}
+}
@@ -1,21 +1,14 @@
 package exif
 
 import (
-	"path"
 	"reflect"
 	"testing"
 
-	"io/ioutil"
-
 	"github.com/dsoprea/go-logging"
 
 	"github.com/dsoprea/go-exif/v3/common"
 )
 
-var (
-	testExifData []byte
-)
-
 func getExifSimpleTestIb() *IfdBuilder {
 	defer func() {
 		if state := recover(); state != nil {
@@ -154,35 +147,3 @@ func validateExifSimpleTestIb(exifData []byte, t *testing.T) {
 		}
 	}
 }
-
-func getTestImageFilepath() string {
-	assetsPath := exifcommon.GetTestAssetsPath()
-	testImageFilepath := path.Join(assetsPath, "NDM_8901.jpg")
-	return testImageFilepath
-}
-
-func getTestExifData() []byte {
-	if testExifData == nil {
-		assetsPath := exifcommon.GetTestAssetsPath()
-		filepath := path.Join(assetsPath, "NDM_8901.jpg.exif")
-
-		var err error
-
-		testExifData, err = ioutil.ReadFile(filepath)
-		log.PanicIf(err)
-	}
-
-	return testExifData
-}
-
-func getTestGpsImageFilepath() string {
-	assetsPath := exifcommon.GetTestAssetsPath()
-	testGpsImageFilepath := path.Join(assetsPath, "gps.jpg")
-	return testGpsImageFilepath
-}
-
-func getTestGeotiffFilepath() string {
-	assetsPath := exifcommon.GetTestAssetsPath()
-	testGeotiffFilepath := path.Join(assetsPath, "geotiff_example.tif")
-	return testGeotiffFilepath
-}
@@ -0,0 +1,643 @@
+package exif
+
+// NOTES:
+//
+// The thumbnail in IFD1 may be stored in one of two ways:
+//
+// - As a JPEG, referred to by JPEGInterchangeFormat (0x0201) and
+//   JPEGInterchangeFormatLength (0x0202). This is what practically every
+//   camera writes.
+// - As uncompressed pixels (Compression=1), referred to by StripOffsets
+//   (0x0111) and StripByteCounts (0x0117) and described by the usual TIFF
+//   tags (ImageWidth, ImageLength, BitsPerSample, etc..).
+//
+// The parser reads either one and the builder can write either one. Strips are
+// always written as a single strip. Conversion is only supported for 8-bit,
+// chunky RGB, which is the only uncompressed layout that EXIF recommends.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// uncompressedThumbnailCompression is the value of the Compression tag
+	// for uncompressed thumbnails.
+	uncompressedThumbnailCompression = 1
+
+	// jpegThumbnailCompression is the value of the Compression tag for JPEG
+	// thumbnails.
+	jpegThumbnailCompression = 6
+
+	// compressionTagId is the tag-ID of the Compression tag.
+	compressionTagId = 0x0103
+
+	// newSubfileTypeReducedResolution is the NewSubfileType flag that marks a
+	// reduced-resolution (thumbnail) image.
+	newSubfileTypeReducedResolution = 1
+
+	// maxThumbnailStripsSize is the most that we'll read for an uncompressed
+	// thumbnail. A 160x120 thumbnail is about 56K.
+	maxThumbnailStripsSize = 1024 * 1024
+)
+
+var (
+	// ErrThumbnailNotConvertible means that the thumbnail is not in a layout
+	// that we know how to convert.
+	ErrThumbnailNotConvertible = errors.New("thumbnail can not be converted")
+)
+
+// ThumbnailFormat describes how a thumbnail is stored.
+type ThumbnailFormat int
+
+const (
+	// ThumbnailFormatJpeg is a JPEG image referred to by
+	// JPEGInterchangeFormat.
+	ThumbnailFormatJpeg ThumbnailFormat = iota
+
+	// ThumbnailFormatUncompressed is uncompressed pixel data referred to by
+	// StripOffsets.
+	ThumbnailFormatUncompressed
+)
+
+// String returns a descriptive string.
+func (tf ThumbnailFormat) String() string {
+	switch tf {
+	case ThumbnailFormatJpeg:
+		return "JPEG"
+	case ThumbnailFormatUncompressed:
+		return "UNCOMPRESSED"
+	}
+
+	return fmt.Sprintf("ThumbnailFormat<%d>", int(tf))
+}
+
+// uncompressedThumbnailLayout describes the pixels of an uncompressed
+// thumbnail.
+type uncompressedThumbnailLayout struct {
+	width           uint32
+	height          uint32
+	bitsPerSample   []uint32
+	samplesPerPixel uint32
+	photometric     uint32
+	planar          uint32
+}
+
+// thumbnailTagLookupFn returns the values of the given integer tag and whether
+// it was present.
+type thumbnailTagLookupFn func(tagName string) (values []uint32, found bool, err error)
+
+// readUncompressedThumbnailLayout reads the layout tags using the given
+// lookup and confirms that they describe 8-bit, chunky RGB.
+func readUncompressedThumbnailLayout(lookup thumbnailTagLookupFn) (utl uncompressedThumbnailLayout, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	// Missing optional tags get their TIFF defaults.
+	utl.samplesPerPixel = 1
+	utl.planar = 1
+
+	required := map[string]*uint32{
+		"ImageWidth":                &utl.width,
+		"ImageLength":               &utl.height,
+		"PhotometricInterpretation": &utl.photometric,
+	}
+
+	optional := map[string]*uint32{
+		"SamplesPerPixel":     &utl.samplesPerPixel,
+		"PlanarConfiguration": &utl.planar,
+	}
+
+	for tagName, field := range required {
+		values, found, err := lookup(tagName)
+		log.PanicIf(err)
+
+		if found == false || len(values) != 1 {
+			ifdEnumerateLogger.Warningf(nil, "Thumbnail layout tag [%s] is missing or not correct.", tagName)
+			log.Panic(ErrThumbnailNotConvertible)
+		}
+
+		*field = values[0]
+	}
+
+	for tagName, field := range optional {
+		values, found, err := lookup(tagName)
+		log.PanicIf(err)
+
+		if found == true && len(values) == 1 {
+			*field = values[0]
+		}
+	}
+
+	utl.bitsPerSample, _, err = lookup("BitsPerSample")
+	log.PanicIf(err)
+
+	if utl.photometric != 2 || utl.samplesPerPixel != 3 || utl.planar != 1 {
+		log.Panic(ErrThumbnailNotConvertible)
+	}
+
+	for _, bits := range utl.bitsPerSample {
+		if bits != 8 {
+			log.Panic(ErrThumbnailNotConvertible)
+		}
+	}
+
+	return utl, nil
+}
+
+// thumbnailValueToUint32s normalizes SHORT and LONG values.
+func thumbnailValueToUint32s(value interface{}) (values []uint32, err error) {
+	switch t := value.(type) {
+	case []uint32:
+		return t, nil
+	case []uint16:
+		values = make([]uint32, len(t))
+		for i, v := range t {
+			values[i] = uint32(v)
+		}
+
+		return values, nil
+	}
+
+	return nil, fmt.Errorf("thumbnail tag value not an integer: [%T]", value)
+}
+
+// UncompressedThumbnailToJpeg encodes 8-bit, chunky RGB pixels as a JPEG.
+func UncompressedThumbnailToJpeg(pixels []byte, width, height uint32) (data []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if uint64(len(pixels)) != uint64(width)*uint64(height)*3 {
+		log.Panicf("(%d) bytes is not correct for a (%d)x(%d) RGB thumbnail", len(pixels), width, height)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+
+	for i := 0; i < len(pixels); i += 3 {
+		pixel := i / 3
+		x := pixel % int(width)
+		y := pixel / int(width)
+
+		img.SetRGBA(x, y, color.RGBA{pixels[i], pixels[i+1], pixels[i+2], 0xff})
+	}
+
+	b := new(bytes.Buffer)
+
+	err = jpeg.Encode(b, img, nil)
+	log.PanicIf(err)
+
+	return b.Bytes(), nil
+}
+
+// JpegThumbnailToUncompressed decodes a JPEG to 8-bit, chunky RGB pixels.
+func JpegThumbnailToUncompressed(data []byte) (pixels []byte, width, height uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	log.PanicIf(err)
+
+	bounds := img.Bounds()
+
+	width = uint32(bounds.Dx())
+	height = uint32(bounds.Dy())
+
+	pixels = make([]byte, 0, width*height*3)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, byte(r>>8), byte(g>>8), byte(b>>8))
+		}
+	}
+
+	return pixels, width, height, nil
+}
+
+// thumbnailStrips describes the strips of an uncompressed thumbnail.
+type thumbnailStrips struct {
+	offsetsIte *IfdTagEntry
+	offsets    []uint32
+	byteCounts []uint32
+}
+
+// Size returns the total size of the strips.
+func (ts thumbnailStrips) Size() (size uint64) {
+	for _, byteCount := range ts.byteCounts {
+		size += uint64(byteCount)
+	}
+
+	return size
+}
+
+// findThumbnailStrips returns the strips of an uncompressed thumbnail
+// described by the given entries. Only the tags are read, not the strips.
+// Strips are only taken as a thumbnail if NewSubfileType marks them as a
+// reduced-resolution image, since a TIFF can also keep its full image in
+// IFD1. `found` is false if the entries don't describe such a thumbnail.
+func findThumbnailStrips(entries []*IfdTagEntry) (ts thumbnailStrips, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var byteCountsIte *IfdTagEntry
+	var compressionIte *IfdTagEntry
+	var subfileTypeIte *IfdTagEntry
+
+	for _, ite := range entries {
+		switch ite.TagId() {
+		case ThumbnailStripOffsetsTagId:
+			ts.offsetsIte = ite
+		case ThumbnailStripByteCountsTagId:
+			byteCountsIte = ite
+		case NewSubfileTypeTagId:
+			subfileTypeIte = ite
+		case compressionTagId:
+			compressionIte = ite
+		}
+	}
+
+	if ts.offsetsIte == nil || byteCountsIte == nil || subfileTypeIte == nil {
+		return ts, false, nil
+	}
+
+	value, err := subfileTypeIte.Value()
+	log.PanicIf(err)
+
+	subfileType, err := thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	if len(subfileType) != 1 || subfileType[0]&newSubfileTypeReducedResolution == 0 {
+		return ts, false, nil
+	}
+
+	// Compression defaults to (1) if not present.
+	if compressionIte != nil {
+		value, err := compressionIte.Value()
+		log.PanicIf(err)
+
+		compression, err := thumbnailValueToUint32s(value)
+		log.PanicIf(err)
+
+		if len(compression) != 1 || compression[0] != uncompressedThumbnailCompression {
+			return ts, false, nil
+		}
+	}
+
+	value, err = ts.offsetsIte.Value()
+	log.PanicIf(err)
+
+	ts.offsets, err = thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	value, err = byteCountsIte.Value()
+	log.PanicIf(err)
+
+	ts.byteCounts, err = thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	if len(ts.offsets) != len(ts.byteCounts) {
+		log.Panicf("strip offsets (%d) and byte-counts (%d) do not agree", len(ts.offsets), len(ts.byteCounts))
+	}
+
+	return ts, true, nil
+}
+
+// Read reads the strips into one buffer. Their total size is checked against
+// `maxThumbnailStripsSize` first, since it comes from the data.
+func (ts thumbnailStrips) Read() (thumbnailData []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	size := ts.Size()
+	if size > maxThumbnailStripsSize {
+		log.Panicf("thumbnail strips too large: (%d)", size)
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, size))
+
+	for i, offset := range ts.offsets {
+		_, err := exifcommon.CheckedAddUint32(offset, ts.byteCounts[i])
+		log.PanicIf(err)
+
+		_, err = ts.offsetsIte.rs.Seek(int64(offset), io.SeekStart)
+		log.PanicIf(err)
+
+		_, err = io.CopyN(b, ts.offsetsIte.rs, int64(ts.byteCounts[i]))
+		log.PanicIf(err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// ThumbnailFormat returns how the thumbnail is stored. `ErrNoThumbnail` is
+// returned if there isn't one.
+func (ifd *Ifd) ThumbnailFormat() (format ThumbnailFormat, err error) {
+	if ifd.thumbnailData == nil && ifd.hasThumbnailStrips == false {
+		return 0, ErrNoThumbnail
+	}
+
+	if _, found := ifd.entriesByTagId[ThumbnailOffsetTagId]; found == true {
+		return ThumbnailFormatJpeg, nil
+	}
+
+	return ThumbnailFormatUncompressed, nil
+}
+
+// thumbnailTagLookup reads integer tags for `readUncompressedThumbnailLayout`.
+func (ifd *Ifd) thumbnailTagLookup(tagName string) (values []uint32, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := ifd.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	values, err = thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	return values, true, nil
+}
+
+// ThumbnailAs returns the thumbnail in the given format, converting it if it is
+// stored in the other one. Uncompressed thumbnails are 8-bit, chunky RGB. The
+// dimensions are returned for both formats.
+func (ifd *Ifd) ThumbnailAs(format ThumbnailFormat) (data []byte, width, height uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	currentFormat, err := ifd.ThumbnailFormat()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	thumbnailData, err := ifd.Thumbnail()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if currentFormat == ThumbnailFormatJpeg {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(thumbnailData))
+		log.PanicIf(err)
+
+		if format == ThumbnailFormatJpeg {
+			return thumbnailData, uint32(config.Width), uint32(config.Height), nil
+		}
+
+		data, width, height, err = JpegThumbnailToUncompressed(thumbnailData)
+		log.PanicIf(err)
+
+		return data, width, height, nil
+	}
+
+	utl, err := readUncompressedThumbnailLayout(ifd.thumbnailTagLookup)
+	log.PanicIf(err)
+
+	if format == ThumbnailFormatUncompressed {
+		return thumbnailData, utl.width, utl.height, nil
+	}
+
+	data, err = UncompressedThumbnailToJpeg(thumbnailData, utl.width, utl.height)
+	log.PanicIf(err)
+
+	return data, utl.width, utl.height, nil
+}
+
+// ThumbnailFormat returns how the thumbnail will be stored. This is only
+// meaningful if a thumbnail has been set.
+func (ib *IfdBuilder) ThumbnailFormat() ThumbnailFormat {
+	return ib.thumbnailFormat
+}
+
+// isThumbnailStripBlob returns true if the given tag carries the thumbnail
+// strip itself (as opposed to its offset). Like the JPEG thumbnail offset,
+// this is resolved to an offset when encoded.
+func (ib *IfdBuilder) isThumbnailStripBlob(bt *BuilderTag) bool {
+	return bt.tagId == ThumbnailStripOffsetsTagId && ib.thumbnailFormat == ThumbnailFormatUncompressed && ib.thumbnailData != nil && bt.value.IsBytes() == true
+}
+
+// setThumbnailStrip stores uncompressed thumbnail data as a single strip. The
+// layout tags are not touched.
+func (ib *IfdBuilder) setThumbnailStrip(data []byte) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.IfdIdentity().UnindexedString() != exifcommon.IfdStandardIfdIdentity.UnindexedString() {
+		log.Panicf("thumbnails can only go into a root Ifd (and only the second one)")
+	}
+
+	if len(data) == 0 {
+		log.Panic("thumbnail is empty")
+	}
+
+	_, err = ib.DeleteAll(ThumbnailOffsetTagId)
+	log.PanicIf(err)
+
+	_, err = ib.DeleteAll(ThumbnailSizeTagId)
+	log.PanicIf(err)
+
+	ib.thumbnailData = data
+	ib.thumbnailFormat = ThumbnailFormatUncompressed
+
+	offsetsBt :=
+		NewBuilderTag(
+			ib.IfdIdentity().UnindexedString(),
+			ThumbnailStripOffsetsTagId,
+			exifcommon.TypeLong,
+			NewIfdBuilderTagValueFromBytes(data),
+			ib.byteOrder)
+
+	err = ib.Set(offsetsBt)
+	log.PanicIf(err)
+
+	byteCountsIt, err := ib.tagIndex.Get(ib.IfdIdentity(), ThumbnailStripByteCountsTagId)
+	log.PanicIf(err)
+
+	byteCountsBt, err := NewStandardBuilderTagWithOptions(ib.IfdIdentity().UnindexedString(), byteCountsIt, ib.byteOrder, []uint32{uint32(len(data))}, exifcommon.CoercionOptions{})
+	log.PanicIf(err)
+
+	err = ib.Set(byteCountsBt)
+	log.PanicIf(err)
+
+	// A missing RowsPerStrip means that the whole image is in one strip.
+	rowsPerStripIt, err := ib.tagIndex.GetWithName(ib.IfdIdentity(), "RowsPerStrip")
+	log.PanicIf(err)
+
+	_, err = ib.DeleteAll(rowsPerStripIt.Id)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// SetUncompressedThumbnail sets an uncompressed thumbnail of 8-bit, chunky RGB
+// pixels along with the tags that describe it. As with `SetThumbnail()`, this
+// must be set on the second root IFD.
+func (ib *IfdBuilder) SetUncompressedThumbnail(pixels []byte, width, height uint32) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if uint64(len(pixels)) != uint64(width)*uint64(height)*3 {
+		log.Panicf("(%d) bytes is not correct for a (%d)x(%d) RGB thumbnail", len(pixels), width, height)
+	}
+
+	err = ib.setThumbnailStrip(pixels)
+	log.PanicIf(err)
+
+	layout := []struct {
+		tagName string
+		value   interface{}
+	}{
+		{"NewSubfileType", []uint32{newSubfileTypeReducedResolution}},
+		{"ImageWidth", []uint32{width}},
+		{"ImageLength", []uint32{height}},
+		{"BitsPerSample", []uint16{8, 8, 8}},
+		{"Compression", []uint16{uncompressedThumbnailCompression}},
+		{"PhotometricInterpretation", []uint16{2}},
+		{"SamplesPerPixel", []uint16{3}},
+		{"PlanarConfiguration", []uint16{1}},
+	}
+
+	for _, tag := range layout {
+		err := ib.SetStandardWithName(tag.tagName, tag.value)
+		log.PanicIf(err)
+	}
+
+	return nil
+}
+
+// thumbnailTagLookup reads integer tags for `readUncompressedThumbnailLayout`.
+func (ib *IfdBuilder) thumbnailTagLookup(tagName string) (values []uint32, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	bt, err := ib.FindTagWithName(tagName)
+	if err != nil {
+		if log.Is(err, ErrTagEntryNotFound) == true {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	valueBytes, err := bt.EncodedBytes(ib.byteOrder)
+	log.PanicIf(err)
+
+	value, err := decodeBuilderTagValueBytes(bt.typeId, valueBytes, ib.byteOrder)
+	log.PanicIf(err)
+
+	values, err = thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	return values, true, nil
+}
+
+// ConvertThumbnail re-stores the current thumbnail in the given format. The
+// tags that describe the old format are replaced with those of the new one.
+// Only 8-bit, chunky RGB uncompressed thumbnails can be converted to JPEG.
+func (ib *IfdBuilder) ConvertThumbnail(format ThumbnailFormat) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if ib.thumbnailData == nil {
+		log.Panic(ErrNoThumbnail)
+	}
+
+	if format == ib.thumbnailFormat {
+		return nil
+	}
+
+	if format == ThumbnailFormatUncompressed {
+		pixels, width, height, err := JpegThumbnailToUncompressed(ib.thumbnailData)
+		log.PanicIf(err)
+
+		err = ib.SetUncompressedThumbnail(pixels, width, height)
+		log.PanicIf(err)
+
+		return nil
+	}
+
+	utl, err := readUncompressedThumbnailLayout(ib.thumbnailTagLookup)
+	log.PanicIf(err)
+
+	jpegData, err := UncompressedThumbnailToJpeg(ib.thumbnailData, utl.width, utl.height)
+	log.PanicIf(err)
+
+	// These only describe uncompressed data.
+	tagNames := []string{
+		"StripOffsets",
+		"StripByteCounts",
+		"RowsPerStrip",
+		"ImageWidth",
+		"ImageLength",
+		"BitsPerSample",
+		"PhotometricInterpretation",
+		"SamplesPerPixel",
+		"PlanarConfiguration",
+	}
+
+	for _, tagName := range tagNames {
+		it, err := ib.tagIndex.GetWithName(ib.IfdIdentity(), tagName)
+		log.PanicIf(err)
+
+		_, err = ib.DeleteAll(it.Id)
+		log.PanicIf(err)
+	}
+
+	err = ib.SetThumbnail(jpegData)
+	log.PanicIf(err)
+
+	err = ib.SetStandardWithName("Compression", []uint16{jpegThumbnailCompression})
+	log.PanicIf(err)
+
+	return nil
+}
@@ -0,0 +1,289 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"image/jpeg"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getUncompressedThumbnailTestIb() (ib *IfdBuilder, pixels []byte) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	ib = NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	err = ib.AddStandardWithName("ProcessingSoftware", "asciivalue")
+	log.PanicIf(err)
+
+	thumbnailIb := NewIfdBuilder(im, ti, exifcommon.IfdStandardIfdIdentity, exifcommon.TestDefaultByteOrder)
+
+	// A 2x2 image: red, green, blue, white.
+	pixels = []byte{
+		0xff, 0x00, 0x00, 0x00, 0xff, 0x00,
+		0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+	}
+
+	err = thumbnailIb.SetUncompressedThumbnail(pixels, 2, 2)
+	log.PanicIf(err)
+
+	err = ib.SetNextIb(thumbnailIb)
+	log.PanicIf(err)
+
+	return ib, pixels
+}
+
+func TestThumbnailFormat_String(t *testing.T) {
+	if ThumbnailFormatJpeg.String() != "JPEG" {
+		t.Fatalf("JPEG format string not correct: [%s]", ThumbnailFormatJpeg)
+	} else if ThumbnailFormatUncompressed.String() != "UNCOMPRESSED" {
+		t.Fatalf("Uncompressed format string not correct: [%s]", ThumbnailFormatUncompressed)
+	}
+}
+
+func TestIfdBuilder_SetUncompressedThumbnail(t *testing.T) {
+	ib, pixels := getUncompressedThumbnailTestIb()
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetVerifyOutput(true)
+
+	exifData, err := ibe.EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(ib.ifdMapping, ib.tagIndex, exifData)
+	log.PanicIf(err)
+
+	thumbnailIfd := index.RootIfd.NextIfd()
+
+	format, err := thumbnailIfd.ThumbnailFormat()
+	log.PanicIf(err)
+
+	if format != ThumbnailFormatUncompressed {
+		t.Fatalf("Thumbnail format not correct: [%s]", format)
+	}
+
+	thumbnailData, err := thumbnailIfd.Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(thumbnailData, pixels) != true {
+		t.Fatalf("Thumbnail not correct: %x", thumbnailData)
+	}
+
+	results, err := thumbnailIfd.FindTagWithName("StripOffsets")
+	log.PanicIf(err)
+
+	if results[0].UnitCount() != 1 {
+		t.Fatalf("Expected exactly one strip: (%d)", results[0].UnitCount())
+	}
+
+	jpegData, width, height, err := thumbnailIfd.ThumbnailAs(ThumbnailFormatJpeg)
+	log.PanicIf(err)
+
+	if width != 2 || height != 2 {
+		t.Fatalf("Dimensions not correct: (%d)x(%d)", width, height)
+	}
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(jpegData))
+	log.PanicIf(err)
+
+	if config.Width != 2 || config.Height != 2 {
+		t.Fatalf("JPEG dimensions not correct: (%d)x(%d)", config.Width, config.Height)
+	}
+
+	// Make sure that the strip survives being copied from existing data.
+
+	reencodedIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	reencodedExifData, err := ibe.EncodeToExif(reencodedIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(ib.ifdMapping, ib.tagIndex, reencodedExifData)
+	log.PanicIf(err)
+
+	thumbnailData, err = index.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(thumbnailData, pixels) != true {
+		t.Fatalf("Re-encoded thumbnail not correct: %x", thumbnailData)
+	}
+}
+
+func TestIfdBuilder_ConvertThumbnail(t *testing.T) {
+	testImageFilepath := getTestImageFilepath()
+
+	rawExif, err := SearchFileAndExtractExif(testImageFilepath)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	originalJpegData, err := index.RootIfd.NextIfd().Thumbnail()
+	log.PanicIf(err)
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(originalJpegData))
+	log.PanicIf(err)
+
+	rootIb := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	thumbnailIb, err := rootIb.NextIb()
+	log.PanicIf(err)
+
+	// JPEG to uncompressed.
+
+	err = thumbnailIb.ConvertThumbnail(ThumbnailFormatUncompressed)
+	log.PanicIf(err)
+
+	if thumbnailIb.ThumbnailFormat() != ThumbnailFormatUncompressed {
+		t.Fatalf("Builder thumbnail format not correct: [%s]", thumbnailIb.ThumbnailFormat())
+	}
+
+	ibe := NewIfdByteEncoder()
+	ibe.SetVerifyOutput(true)
+
+	exifData, err := ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	thumbnailIfd := index.RootIfd.NextIfd()
+
+	format, err := thumbnailIfd.ThumbnailFormat()
+	log.PanicIf(err)
+
+	if format != ThumbnailFormatUncompressed {
+		t.Fatalf("Thumbnail format not correct: [%s]", format)
+	}
+
+	pixels, width, height, err := thumbnailIfd.ThumbnailAs(ThumbnailFormatUncompressed)
+	log.PanicIf(err)
+
+	if int(width) != config.Width || int(height) != config.Height {
+		t.Fatalf("Dimensions not correct: (%d)x(%d) != (%d)x(%d)", width, height, config.Width, config.Height)
+	} else if len(pixels) != int(width*height*3) {
+		t.Fatalf("Pixel data not correct: (%d)", len(pixels))
+	}
+
+	// And back.
+
+	err = thumbnailIb.ConvertThumbnail(ThumbnailFormatJpeg)
+	log.PanicIf(err)
+
+	exifData, err = ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	_, index, err = Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	thumbnailIfd = index.RootIfd.NextIfd()
+
+	format, err = thumbnailIfd.ThumbnailFormat()
+	log.PanicIf(err)
+
+	if format != ThumbnailFormatJpeg {
+		t.Fatalf("Thumbnail format not correct: [%s]", format)
+	}
+
+	_, err = thumbnailIfd.FindTagWithName("StripOffsets")
+	if log.Is(err, ErrTagNotFound) == false {
+		t.Fatalf("Expected strip tags to be removed: %v", err)
+	}
+
+	results, err := thumbnailIfd.FindTagWithName("Compression")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.([]uint16)[0] != jpegThumbnailCompression {
+		t.Fatalf("Compression not correct: %v", value)
+	}
+
+	jpegData, err := thumbnailIfd.Thumbnail()
+	log.PanicIf(err)
+
+	convertedConfig, err := jpeg.DecodeConfig(bytes.NewReader(jpegData))
+	log.PanicIf(err)
+
+	if convertedConfig.Width != config.Width || convertedConfig.Height != config.Height {
+		t.Fatalf("JPEG dimensions not correct: (%d)x(%d)", convertedConfig.Width, convertedConfig.Height)
+	}
+}
+
+func TestIfdBuilder_ConvertThumbnail__NoThumbnail(t *testing.T) {
+	ib := getExifSimpleTestIb()
+
+	err := ib.ConvertThumbnail(ThumbnailFormatUncompressed)
+	if log.Is(err, ErrNoThumbnail) == false {
+		t.Fatalf("Expected no-thumbnail error: %v", err)
+	}
+}
+
+func TestIfd_Thumbnail__StripsReadOnDemand(t *testing.T) {
+	ib, pixels := getUncompressedThumbnailTestIb()
+
+	exifData, err := NewIfdByteEncoder().EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(ib.ifdMapping, ib.tagIndex, exifData)
+	log.PanicIf(err)
+
+	thumbnailIfd := index.RootIfd.NextIfd()
+
+	if thumbnailIfd.thumbnailData != nil {
+		t.Fatalf("Expected the strips to not be read during the collect.")
+	} else if thumbnailIfd.hasThumbnailStrips != true {
+		t.Fatalf("Expected the strips to be found.")
+	}
+
+	thumbnailData, err := thumbnailIfd.Thumbnail()
+	log.PanicIf(err)
+
+	if bytes.Equal(thumbnailData, pixels) != true {
+		t.Fatalf("Thumbnail not correct: %x", thumbnailData)
+	}
+}
+
+func TestIfd_Thumbnail__StripsNotReducedResolution(t *testing.T) {
+	ib, _ := getUncompressedThumbnailTestIb()
+
+	_, err := ib.nextIb.DeleteAll(NewSubfileTypeTagId)
+	log.PanicIf(err)
+
+	exifData, err := NewIfdByteEncoder().EncodeToExif(ib)
+	log.PanicIf(err)
+
+	_, index, err := Collect(ib.ifdMapping, ib.tagIndex, exifData)
+	log.PanicIf(err)
+
+	_, err = index.RootIfd.NextIfd().Thumbnail()
+	if err != ErrNoThumbnail {
+		t.Fatalf("Expected no thumbnail for strips not marked as reduced-resolution: %v", err)
+	}
+}
+
+func TestThumbnailStrips_Read__TooLarge(t *testing.T) {
+	ts := thumbnailStrips{
+		offsets:    []uint32{0, 0},
+		byteCounts: []uint32{maxThumbnailStripsSize, 1},
+	}
+
+	_, err := ts.Read()
+	if err == nil {
+		t.Fatalf("Expected failure for strips that are too large.")
+	}
+}
@@ -0,0 +1,341 @@
+package exif
+
+import (
+	"errors"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// TileOffsetsTagId is the tag-ID of the offsets of the tiles of a tiled
+	// TIFF image.
+	TileOffsetsTagId = 0x0144
+
+	// TileByteCountsTagId is the tag-ID of the sizes of the tiles of a tiled
+	// TIFF image.
+	TileByteCountsTagId = 0x0145
+
+	// NewSubfileTypeTagId is the tag-ID of the flags that describe what kind
+	// of image an IFD has. Zero is a full-resolution image.
+	NewSubfileTypeTagId = 0x00fe
+
+	// SubIfdsTagId is the tag-ID of the offsets of the sub-IFDs of an IFD.
+	// Raw files use these for the raw data and the larger previews.
+	SubIfdsTagId = 0x014a
+)
+
+var (
+	// ErrTiffLayoutChanged means that the IFDs of a rewritten TIFF came out
+	// a different size once the real image-data offsets were set.
+	ErrTiffLayoutChanged = errors.New("tiff ifd layout changed between passes")
+)
+
+// TiffUpdateFn modifies the IFD chain of a TIFF that is being rewritten.
+type TiffUpdateFn func(rootIb *IfdBuilder) (err error)
+
+// tiffImageData is the image data (strips or tiles) of one IFD in the root
+// chain. The IFD only refers to it by offset, so it has to be carried
+// separately from the IFDs and the offsets set once it has been placed.
+type tiffImageData struct {
+	offsetsTagId    uint16
+	byteCountsTagId uint16
+	chunks          [][]byte
+}
+
+// TiffRewriter rewrites the IFD structure of plain TIFF files. The IFDs are
+// loaded into an `IfdBuilder` chain which can be modified, and then encoded
+// with the image data of each IFD in the root chain (its strips or tiles)
+// written after them. Image data is matched to IFDs by their position in the
+// chain. Since a TIFF refers to its image data by offset from anywhere in the
+// file, the whole file is held in memory.
+type TiffRewriter struct {
+	ifdMapping *exifcommon.IfdMapping
+	tagIndex   *TagIndex
+	ibe        *IfdByteEncoder
+}
+
+// NewTiffRewriter returns a new `TiffRewriter`.
+func NewTiffRewriter(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex) *TiffRewriter {
+	return &TiffRewriter{
+		ifdMapping: ifdMapping,
+		tagIndex:   tagIndex,
+		ibe:        NewIfdByteEncoder(),
+	}
+}
+
+// SetEncoder sets the encoder that the IFDs are written with, so that its
+// options can be chosen.
+func (tr *TiffRewriter) SetEncoder(ibe *IfdByteEncoder) {
+	tr.ibe = ibe
+}
+
+// Rewrite parses the given TIFF, passes its root IB to `updateFn` (if not
+// nil), and returns the re-encoded TIFF. An IFD that loses its offsets tag in
+// the update loses its image data, too. Anything in the original that is not
+// reachable from the IFDs (and isn't image data) is dropped.
+func (tr *TiffRewriter) Rewrite(data []byte, updateFn TiffUpdateFn) (updated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	_, index, err := Collect(tr.ifdMapping, tr.tagIndex, data)
+	log.PanicIf(err)
+
+	imageData := make([]*tiffImageData, 0)
+
+	for ifd := index.RootIfd; ifd != nil; ifd = ifd.NextIfd() {
+		tid, err := readTiffImageData(ifd, data)
+		log.PanicIf(err)
+
+		imageData = append(imageData, tid)
+	}
+
+	rootIb, report, err := NewIfdBuilderFromExistingChainWithReport(index.RootIfd)
+	log.PanicIf(err)
+
+	report.logSkipped()
+
+	if updateFn != nil {
+		err = updateFn(rootIb)
+		log.PanicIf(err)
+	}
+
+	// Encode once with placeholder offsets to find out where the image data
+	// will start, and then again with the real ones. The offsets are always
+	// written as LONGs, so the size doesn't change between the passes.
+
+	placed := make(map[*IfdBuilder]*tiffImageData)
+
+	i := 0
+	for ib := rootIb; ib != nil && i < len(imageData); ib = ib.nextIb {
+		tid := imageData[i]
+		i++
+
+		// An uncompressed thumbnail in IFD1 is already carried by the IB.
+		if tid == nil || ib.thumbnailData != nil {
+			continue
+		}
+
+		if _, err := ib.FindTag(tid.offsetsTagId); err != nil {
+			if log.Is(err, ErrTagEntryNotFound) == true {
+				continue
+			}
+
+			log.Panic(err)
+		}
+
+		offsets := make([]uint32, len(tid.chunks))
+
+		err := tid.setTags(ib, offsets)
+		log.PanicIf(err)
+
+		placed[ib] = tid
+	}
+
+	ifdData, err := tr.ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	if len(placed) == 0 {
+		return ifdData, nil
+	}
+
+	ifdSize := len(ifdData)
+	position := uint32(ifdSize)
+
+	// The image data is written in the order of the chain, and each chunk is
+	// word-aligned, as TIFF recommends.
+
+	chunks := make([][]byte, 0)
+
+	for ib := rootIb; ib != nil; ib = ib.nextIb {
+		tid, found := placed[ib]
+		if found == false {
+			continue
+		}
+
+		offsets := make([]uint32, len(tid.chunks))
+
+		for j, chunk := range tid.chunks {
+			position += position % 2
+
+			offsets[j] = position
+			position += uint32(len(chunk))
+
+			chunks = append(chunks, chunk)
+		}
+
+		err := tid.setTags(ib, offsets)
+		log.PanicIf(err)
+	}
+
+	ifdData, err = tr.ibe.EncodeToExif(rootIb)
+	log.PanicIf(err)
+
+	if len(ifdData) != ifdSize {
+		log.Panic(ErrTiffLayoutChanged)
+	}
+
+	updated = make([]byte, 0, position)
+	updated = append(updated, ifdData...)
+
+	for _, chunk := range chunks {
+		if len(updated)%2 == 1 {
+			updated = append(updated, 0)
+		}
+
+		updated = append(updated, chunk...)
+	}
+
+	return updated, nil
+}
+
+// setTags sets the offsets and byte-counts tags of the image data in the
+// given IB.
+func (tid *tiffImageData) setTags(ib *IfdBuilder, offsets []uint32) (err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	byteCounts := make([]uint32, len(tid.chunks))
+	for i, chunk := range tid.chunks {
+		byteCounts[i] = uint32(len(chunk))
+	}
+
+	ve := exifcommon.NewValueEncoder(ib.byteOrder)
+	ifdPath := ib.IfdIdentity().UnindexedString()
+
+	ed, err := ve.Encode(offsets)
+	log.PanicIf(err)
+
+	offsetsBt := NewBuilderTag(ifdPath, tid.offsetsTagId, exifcommon.TypeLong, NewIfdBuilderTagValueFromBytes(ed.Encoded), ib.byteOrder)
+
+	err = ib.Set(offsetsBt)
+	log.PanicIf(err)
+
+	ed, err = ve.Encode(byteCounts)
+	log.PanicIf(err)
+
+	byteCountsBt := NewBuilderTag(ifdPath, tid.byteCountsTagId, exifcommon.TypeLong, NewIfdBuilderTagValueFromBytes(ed.Encoded), ib.byteOrder)
+
+	err = ib.Set(byteCountsBt)
+	log.PanicIf(err)
+
+	return nil
+}
+
+// readTiffImageData returns the strips or tiles of the given IFD, or nil if
+// it has neither.
+func readTiffImageData(ifd *Ifd, data []byte) (tid *tiffImageData, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	pairs := [][2]uint16{
+		{ThumbnailStripOffsetsTagId, ThumbnailStripByteCountsTagId},
+		{TileOffsetsTagId, TileByteCountsTagId},
+	}
+
+	for _, pair := range pairs {
+		offsets, found, err := readTiffUint32s(ifd, pair[0])
+		log.PanicIf(err)
+
+		if found == false {
+			continue
+		}
+
+		byteCounts, found, err := readTiffUint32s(ifd, pair[1])
+		log.PanicIf(err)
+
+		if found == false {
+			log.Panicf("tiff ifd [%s] has offsets tag (0x%04x) but no byte-counts tag (0x%04x)", ifd, pair[0], pair[1])
+		} else if len(offsets) != len(byteCounts) {
+			log.Panicf("tiff ifd [%s] has (%d) offsets but (%d) byte-counts", ifd, len(offsets), len(byteCounts))
+		}
+
+		tid = &tiffImageData{
+			offsetsTagId:    pair[0],
+			byteCountsTagId: pair[1],
+			chunks:          make([][]byte, len(offsets)),
+		}
+
+		for i, offset := range offsets {
+			end := uint64(offset) + uint64(byteCounts[i])
+			if end > uint64(len(data)) {
+				log.Panicf("tiff ifd [%s] image data (%d) runs past the end of the file: (%d) > (%d)", ifd, i, end, len(data))
+			}
+
+			tid.chunks[i] = data[offset:end]
+		}
+
+		return tid, nil
+	}
+
+	return nil, nil
+}
+
+// readTiffUint32s returns the value of the given SHORT or LONG tag.
+func readTiffUint32s(ifd *Ifd, tagId uint16) (values []uint32, found bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := ifd.FindTagWithId(tagId)
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return nil, false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	values, err = thumbnailValueToUint32s(value)
+	log.PanicIf(err)
+
+	return values, true, nil
+}
+
+// readTiffSubIfds parses the IFDs listed by the SubIFDs tag of the given IFD,
+// in order, along with any IFDs chained after them. Sub-IFDs describe images
+// and use the same tags as IFD0, so they're parsed with its identity; each
+// one is the root of its own index. Nothing is returned if there's no SubIFDs
+// tag.
+func readTiffSubIfds(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, ifd *Ifd, data []byte) (indices []IfdIndex, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	offsets, found, err := readTiffUint32s(ifd, SubIfdsTagId)
+	log.PanicIf(err)
+
+	if found == false {
+		return nil, nil
+	}
+
+	indices = make([]IfdIndex, len(offsets))
+	for i, offset := range offsets {
+		ebs := NewExifReadSeekerWithBytes(data)
+		ie := NewIfdEnumerate(ifdMapping, tagIndex, ebs, ifd.ByteOrder())
+
+		index, err := ie.Collect(offset)
+		log.PanicIf(err)
+
+		indices[i] = index
+	}
+
+	return indices, nil
+}
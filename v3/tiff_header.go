@@ -0,0 +1,234 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+)
+
+// TiffHeaderVariant identifies which flavor of TIFF header was found.
+type TiffHeaderVariant int
+
+const (
+	// TiffHeaderStandard is the standard TIFF header ("II*\0" or "MM\0*").
+	// This is the only variant that can begin an EXIF block.
+	TiffHeaderStandard TiffHeaderVariant = iota
+
+	// TiffHeaderOlympusOrf is the header of Olympus raw (ORF) files ("IIRO",
+	// "IIRS", or "MMOR"). It is otherwise identical to the standard header.
+	TiffHeaderOlympusOrf
+
+	// TiffHeaderPanasonicRw2 is the header of Panasonic raw (RW2) files
+	// ("IIU\0").
+	TiffHeaderPanasonicRw2
+)
+
+// String returns a descriptive string.
+func (thv TiffHeaderVariant) String() string {
+	switch thv {
+	case TiffHeaderStandard:
+		return "Standard"
+	case TiffHeaderOlympusOrf:
+		return "OlympusOrf"
+	case TiffHeaderPanasonicRw2:
+		return "PanasonicRw2"
+	}
+
+	return fmt.Sprintf("TiffHeaderVariant<%d>", int(thv))
+}
+
+var (
+	// OlympusOrfLittleEndianSignature is the little-endian ORF signature.
+	OlympusOrfLittleEndianSignature = [4]byte{'I', 'I', 'R', 'O'}
+
+	// OlympusOrfLittleEndianAltSignature is the alternative little-endian ORF
+	// signature that some models write.
+	OlympusOrfLittleEndianAltSignature = [4]byte{'I', 'I', 'R', 'S'}
+
+	// OlympusOrfBigEndianSignature is the big-endian ORF signature.
+	OlympusOrfBigEndianSignature = [4]byte{'M', 'M', 'O', 'R'}
+
+	// PanasonicRw2LittleEndianSignature is the Panasonic RW2 signature.
+	PanasonicRw2LittleEndianSignature = [4]byte{'I', 'I', 'U', 0x00}
+)
+
+// TiffHeaderSignature associates a four-byte signature with the byte-order and
+// variant that it indicates.
+type TiffHeaderSignature struct {
+	Signature [4]byte
+	ByteOrder binary.ByteOrder
+	Variant   TiffHeaderVariant
+}
+
+var (
+	// TiffHeaderSignatures is the list of all of the TIFF-header signatures
+	// that we recognize.
+	TiffHeaderSignatures = []TiffHeaderSignature{
+		{ExifBigEndianSignature, binary.BigEndian, TiffHeaderStandard},
+		{ExifLittleEndianSignature, binary.LittleEndian, TiffHeaderStandard},
+		{OlympusOrfLittleEndianSignature, binary.LittleEndian, TiffHeaderOlympusOrf},
+		{OlympusOrfLittleEndianAltSignature, binary.LittleEndian, TiffHeaderOlympusOrf},
+		{OlympusOrfBigEndianSignature, binary.BigEndian, TiffHeaderOlympusOrf},
+		{PanasonicRw2LittleEndianSignature, binary.LittleEndian, TiffHeaderPanasonicRw2},
+	}
+)
+
+// TiffHeader is an `ExifHeader` that also describes which TIFF variant was
+// found.
+type TiffHeader struct {
+	ExifHeader
+
+	Signature [4]byte
+	Variant   TiffHeaderVariant
+}
+
+// String returns a descriptive string.
+func (th TiffHeader) String() string {
+	return fmt.Sprintf("TiffHeader<SIGNATURE=[%q] VARIANT=[%s] BYTE-ORDER=[%v] FIRST-IFD-OFFSET=(0x%02x)>", th.Signature[:], th.Variant, th.ByteOrder, th.FirstIfdOffset)
+}
+
+// IsStandard returns true if this is a standard TIFF header.
+func (th TiffHeader) IsStandard() bool {
+	return th.Variant == TiffHeaderStandard
+}
+
+// ParseTiffHeader parses a TIFF header of any of the variants that we
+// recognize. Like `ParseExifHeader`, `ErrNoExif` is returned if the data does
+// not start with a header. The first-IFD offset is not required to be (8), but
+// it can't point into the header.
+func ParseTiffHeader(data []byte) (th TiffHeader, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(data) < ExifSignatureLength {
+		return th, ErrNoExif
+	}
+
+	for _, ths := range TiffHeaderSignatures {
+		if bytes.Equal(data[:4], ths.Signature[:]) == false {
+			continue
+		}
+
+		th = TiffHeader{
+			ExifHeader: ExifHeader{
+				ByteOrder:      ths.ByteOrder,
+				FirstIfdOffset: ths.ByteOrder.Uint32(data[4:8]),
+			},
+			Signature: ths.Signature,
+			Variant:   ths.Variant,
+		}
+
+		if th.FirstIfdOffset < ExifDefaultFirstIfdOffset {
+			return TiffHeader{}, ErrNoExif
+		}
+
+		return th, nil
+	}
+
+	return th, ErrNoExif
+}
+
+// MakerNoteHeader describes the vendor-specific preamble that precedes the IFD
+// in some maker-notes. Most of these maker-notes are otherwise IFD-structured.
+type MakerNoteHeader struct {
+	// Name describes the vendor/format.
+	Name string
+
+	// Signature is the literal prefix of the maker-note.
+	Signature []byte
+
+	// TiffHeaderOffset is the position of a complete, embedded TIFF header
+	// relative to the start of the maker-note, or (-1) if there isn't one. If
+	// there is one, all offsets within the maker-note are relative to it.
+	TiffHeaderOffset int
+
+	// IfdOffset is the position of the IFD relative to the start of the
+	// maker-note. This is not meaningful if there is an embedded TIFF header.
+	IfdOffset int
+
+	// ByteOrderOffset is the position of an "II" or "MM" marker relative to
+	// the start of the maker-note, or (-1) if there isn't one.
+	ByteOrderOffset int
+
+	// ByteOrder is the fixed byte-order of the maker-note, if any. If nil and
+	// there is no marker, the byte-order of the enclosing EXIF is used.
+	ByteOrder binary.ByteOrder
+
+	// OffsetsRelativeToMakerNote indicates that the offsets in the IFD are
+	// relative to the start of the maker-note rather than the enclosing TIFF
+	// header.
+	OffsetsRelativeToMakerNote bool
+}
+
+// String returns a descriptive string.
+func (mnh MakerNoteHeader) String() string {
+	return fmt.Sprintf("MakerNoteHeader<NAME=[%s] TIFF-HEADER-OFFSET=(%d) IFD-OFFSET=(%d)>", mnh.Name, mnh.TiffHeaderOffset, mnh.IfdOffset)
+}
+
+var (
+	// MakerNoteHeaders is the list of maker-note preambles that we recognize.
+	// More-specific signatures must precede less-specific ones.
+	MakerNoteHeaders = []MakerNoteHeader{
+		{Name: "Nikon3", Signature: []byte("Nikon\x00\x02"), TiffHeaderOffset: 10, ByteOrderOffset: -1},
+		{Name: "OlympusNew", Signature: []byte("OLYMPUS\x00"), TiffHeaderOffset: -1, IfdOffset: 12, ByteOrderOffset: 8, OffsetsRelativeToMakerNote: true},
+		{Name: "OlympusOld", Signature: []byte("OLYMP\x00"), TiffHeaderOffset: -1, IfdOffset: 8, ByteOrderOffset: -1},
+		{Name: "OmSystem", Signature: []byte("OM SYSTEM\x00\x00\x00"), TiffHeaderOffset: -1, IfdOffset: 16, ByteOrderOffset: 12, OffsetsRelativeToMakerNote: true},
+		{Name: "Panasonic", Signature: []byte("Panasonic\x00\x00\x00"), TiffHeaderOffset: -1, IfdOffset: 12, ByteOrderOffset: -1},
+		{Name: "Fujifilm", Signature: []byte("FUJIFILM"), TiffHeaderOffset: -1, IfdOffset: 12, ByteOrderOffset: -1, ByteOrder: binary.LittleEndian, OffsetsRelativeToMakerNote: true},
+		{Name: "Sony", Signature: []byte("SONY DSC \x00\x00\x00"), TiffHeaderOffset: -1, IfdOffset: 12, ByteOrderOffset: -1},
+		{Name: "PentaxNew", Signature: []byte("PENTAX \x00"), TiffHeaderOffset: -1, IfdOffset: 10, ByteOrderOffset: 8, OffsetsRelativeToMakerNote: true},
+		{Name: "PentaxOld", Signature: []byte("AOC\x00"), TiffHeaderOffset: -1, IfdOffset: 6, ByteOrderOffset: 4},
+	}
+)
+
+// DetectMakerNoteHeader returns the preamble information for the given
+// maker-note data, if recognized.
+func DetectMakerNoteHeader(makerNoteData []byte) (mnh MakerNoteHeader, found bool) {
+	for _, mnh := range MakerNoteHeaders {
+		if bytes.HasPrefix(makerNoteData, mnh.Signature) == true {
+			return mnh, true
+		}
+	}
+
+	return mnh, false
+}
+
+// ResolveByteOrder returns the byte-order of the maker-note, defaulting to
+// `defaultByteOrder` if the preamble does not determine one.
+func (mnh MakerNoteHeader) ResolveByteOrder(makerNoteData []byte, defaultByteOrder binary.ByteOrder) (byteOrder binary.ByteOrder, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if mnh.TiffHeaderOffset >= 0 {
+		th, err := ParseTiffHeader(makerNoteData[mnh.TiffHeaderOffset:])
+		log.PanicIf(err)
+
+		return th.ByteOrder, nil
+	} else if mnh.ByteOrder != nil {
+		return mnh.ByteOrder, nil
+	} else if mnh.ByteOrderOffset >= 0 {
+		if len(makerNoteData) < mnh.ByteOrderOffset+2 {
+			log.Panicf("maker-note too short for byte-order marker: (%d)", len(makerNoteData))
+		}
+
+		marker := string(makerNoteData[mnh.ByteOrderOffset : mnh.ByteOrderOffset+2])
+		if marker == "II" {
+			return binary.LittleEndian, nil
+		} else if marker == "MM" {
+			return binary.BigEndian, nil
+		}
+
+		log.Panicf("maker-note byte-order marker not valid: [%s]", marker)
+	}
+
+	return defaultByteOrder, nil
+}
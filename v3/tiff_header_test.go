@@ -0,0 +1,182 @@
+package exif
+
+import (
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestParseTiffHeader(t *testing.T) {
+	cases := []struct {
+		data      []byte
+		byteOrder binary.ByteOrder
+		variant   TiffHeaderVariant
+		offset    uint32
+	}{
+		{[]byte{'I', 'I', 0x2a, 0x00, 0x08, 0x00, 0x00, 0x00}, binary.LittleEndian, TiffHeaderStandard, 8},
+		{[]byte{'M', 'M', 0x00, 0x2a, 0x00, 0x00, 0x00, 0x08}, binary.BigEndian, TiffHeaderStandard, 8},
+		{[]byte{'M', 'M', 0x00, 0x2a, 0x00, 0x00, 0x01, 0x00}, binary.BigEndian, TiffHeaderStandard, 0x100},
+		{[]byte{'I', 'I', 'R', 'O', 0x08, 0x00, 0x00, 0x00}, binary.LittleEndian, TiffHeaderOlympusOrf, 8},
+		{[]byte{'I', 'I', 'R', 'S', 0x08, 0x00, 0x00, 0x00}, binary.LittleEndian, TiffHeaderOlympusOrf, 8},
+		{[]byte{'M', 'M', 'O', 'R', 0x00, 0x00, 0x00, 0x08}, binary.BigEndian, TiffHeaderOlympusOrf, 8},
+		{[]byte{'I', 'I', 'U', 0x00, 0x18, 0x00, 0x00, 0x00}, binary.LittleEndian, TiffHeaderPanasonicRw2, 0x18},
+	}
+
+	for i, c := range cases {
+		th, err := ParseTiffHeader(c.data)
+		log.PanicIf(err)
+
+		if th.ByteOrder != c.byteOrder {
+			t.Fatalf("Case (%d): byte-order not correct: %v", i, th.ByteOrder)
+		} else if th.Variant != c.variant {
+			t.Fatalf("Case (%d): variant not correct: %s", i, th.Variant)
+		} else if th.FirstIfdOffset != c.offset {
+			t.Fatalf("Case (%d): first-IFD offset not correct: (%d)", i, th.FirstIfdOffset)
+		}
+	}
+}
+
+func TestParseTiffHeader__Miss(t *testing.T) {
+	_, err := ParseTiffHeader([]byte{'I', 'I', 0x2b, 0x00, 0x08, 0x00, 0x00, 0x00})
+	if err != ErrNoExif {
+		t.Fatalf("Expected ErrNoExif: %v", err)
+	}
+
+	_, err = ParseTiffHeader([]byte{'I', 'I'})
+	if err != ErrNoExif {
+		t.Fatalf("Expected ErrNoExif for short data: %v", err)
+	}
+
+	// The first IFD can't overlap the header.
+
+	_, err = ParseTiffHeader([]byte{'I', 'I', 0x2a, 0x00, 0x04, 0x00, 0x00, 0x00})
+	if err != ErrNoExif {
+		t.Fatalf("Expected ErrNoExif for overlapping first IFD: %v", err)
+	}
+}
+
+func TestParseExifHeader__NonstandardVariantRejected(t *testing.T) {
+	_, err := ParseExifHeader([]byte{'I', 'I', 'R', 'O', 0x08, 0x00, 0x00, 0x00})
+	if err != ErrNoExif {
+		t.Fatalf("Expected ErrNoExif for ORF header: %v", err)
+	}
+}
+
+func TestCollect__NonDefaultFirstIfdOffset(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	// Move the IFD block forward by inserting padding after the header. All
+	// of the offsets in the IFD have to move with it.
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	ib := NewIfdBuilderFromExistingChain(index.RootIfd)
+
+	ibe := NewIfdByteEncoder()
+
+	const padding = 8
+	firstIfdOffset := ExifDefaultFirstIfdOffset + padding
+
+	payload, err := ibe.encodeAndAttachIfd(ib, firstIfdOffset)
+	log.PanicIf(err)
+
+	headerBytes, err := BuildExifHeader(exifcommon.TestDefaultByteOrder, firstIfdOffset)
+	log.PanicIf(err)
+
+	shiftedExifData := append(headerBytes, make([]byte, padding)...)
+	shiftedExifData = append(shiftedExifData, payload...)
+
+	eh, err := ParseExifHeader(shiftedExifData)
+	log.PanicIf(err)
+
+	if eh.FirstIfdOffset != firstIfdOffset {
+		t.Fatalf("First-IFD offset not correct: (%d)", eh.FirstIfdOffset)
+	}
+
+	validateExifSimpleTestIbAtOffset(shiftedExifData, firstIfdOffset, t)
+}
+
+func validateExifSimpleTestIbAtOffset(exifData []byte, firstIfdOffset uint32, t *testing.T) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, exifData)
+	log.PanicIf(err)
+
+	if index.RootIfd.Offset() != firstIfdOffset {
+		t.Fatalf("Root IFD offset not correct: (%d)", index.RootIfd.Offset())
+	}
+
+	results, err := index.RootIfd.FindTagWithId(0x000b)
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value.(string) != "asciivalue" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+}
+
+func TestDetectMakerNoteHeader(t *testing.T) {
+	nikon := []byte("Nikon\x00\x02\x10\x00\x00MM\x00\x2a\x00\x00\x00\x08")
+
+	mnh, found := DetectMakerNoteHeader(nikon)
+	if found != true {
+		t.Fatalf("Nikon header not detected.")
+	} else if mnh.Name != "Nikon3" {
+		t.Fatalf("Wrong header detected: %s", mnh)
+	}
+
+	byteOrder, err := mnh.ResolveByteOrder(nikon, binary.LittleEndian)
+	log.PanicIf(err)
+
+	if byteOrder != binary.BigEndian {
+		t.Fatalf("Nikon byte-order not read from embedded TIFF header.")
+	}
+
+	olympus := []byte("OLYMPUS\x00II\x03\x00")
+
+	mnh, found = DetectMakerNoteHeader(olympus)
+	if found != true || mnh.Name != "OlympusNew" {
+		t.Fatalf("Olympus header not detected: %s", mnh)
+	}
+
+	byteOrder, err = mnh.ResolveByteOrder(olympus, binary.BigEndian)
+	log.PanicIf(err)
+
+	if byteOrder != binary.LittleEndian {
+		t.Fatalf("Olympus byte-order not read from marker.")
+	}
+
+	olympusOld := []byte("OLYMP\x00\x01\x00")
+
+	mnh, found = DetectMakerNoteHeader(olympusOld)
+	if found != true || mnh.Name != "OlympusOld" {
+		t.Fatalf("Old Olympus header not detected: %s", mnh)
+	}
+
+	byteOrder, err = mnh.ResolveByteOrder(olympusOld, binary.BigEndian)
+	log.PanicIf(err)
+
+	if byteOrder != binary.BigEndian {
+		t.Fatalf("Old Olympus byte-order should be inherited.")
+	}
+
+	_, found = DetectMakerNoteHeader([]byte{0x00, 0x10, 0x00, 0x00})
+	if found != false {
+		t.Fatalf("Headerless maker-note should not be detected.")
+	}
+}
@@ -0,0 +1,155 @@
+package exif
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// TiffRawFile is what the TIFF-based raw formats (`NefFile`, `ArwFile`,
+// `DngFile`, and `Cr2File`) have in common: a TIFF whose IFD0 has the EXIF and
+// GPS IFDs as children and, usually, a SubIFDs tag that lists the IFDs of
+// the raw data and of the previews. Since the images are located by offset
+// from anywhere in the file, the whole file is held in memory.
+type TiffRawFile struct {
+	data    []byte
+	header  ExifHeader
+	index   IfdIndex
+	subIfds []IfdIndex
+}
+
+// NewTiffRawFile parses the IFDs of the given TIFF, including the sub-IFDs of
+// IFD0. `ErrNoExif` is returned if the data isn't a TIFF. The format-specific
+// constructors should usually be used instead, since they also check that the
+// file is of that format.
+func NewTiffRawFile(ifdMapping *exifcommon.IfdMapping, tagIndex *TagIndex, data []byte) (trf *TiffRawFile, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	header, err := ParseExifHeader(data)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	_, index, err := Collect(ifdMapping, tagIndex, data)
+	log.PanicIf(err)
+
+	subIfds, err := readTiffSubIfds(ifdMapping, tagIndex, index.RootIfd, data)
+	log.PanicIf(err)
+
+	trf = &TiffRawFile{
+		data:    data,
+		header:  header,
+		index:   index,
+		subIfds: subIfds,
+	}
+
+	return trf, nil
+}
+
+// Header returns the TIFF header.
+func (trf *TiffRawFile) Header() ExifHeader {
+	return trf.header
+}
+
+// Index returns the parsed IFDs. `Ifds` has every IFD, including the EXIF and
+// GPS ones, but not the sub-IFDs or any IFDs that only vendor tags point to.
+func (trf *TiffRawFile) Index() IfdIndex {
+	return trf.index
+}
+
+// Ifds returns the IFDs of the root chain, in order.
+func (trf *TiffRawFile) Ifds() []*Ifd {
+	ifds := make([]*Ifd, 0)
+	for ifd := trf.index.RootIfd; ifd != nil; ifd = ifd.NextIfd() {
+		ifds = append(ifds, ifd)
+	}
+
+	return ifds
+}
+
+// SubIfds returns the sub-IFDs of IFD0, in the order that they're listed.
+func (trf *TiffRawFile) SubIfds() []*Ifd {
+	ifds := make([]*Ifd, len(trf.subIfds))
+	for i, index := range trf.subIfds {
+		ifds[i] = index.RootIfd
+	}
+
+	return ifds
+}
+
+// RawIfd returns the IFD of the full-resolution image (the one whose
+// NewSubfileType is zero), or nil if there isn't one. The sub-IFDs are
+// searched first and then the root chain, where some older models (and DNGs
+// without a preview) put it.
+func (trf *TiffRawFile) RawIfd() (ifd *Ifd, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	candidates := append(trf.SubIfds(), trf.Ifds()...)
+	for _, candidate := range candidates {
+		values, found, err := readTiffUint32s(candidate, NewSubfileTypeTagId)
+		log.PanicIf(err)
+
+		if found == true && len(values) == 1 && values[0] == 0 {
+			return candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ExifIfd returns the EXIF IFD.
+func (trf *TiffRawFile) ExifIfd() (ifd *Ifd, found bool) {
+	ifd, found = trf.index.Lookup[exifcommon.IfdExifStandardIfdIdentity.String()]
+	return ifd, found
+}
+
+// GpsIfd returns the GPS IFD.
+func (trf *TiffRawFile) GpsIfd() (ifd *Ifd, found bool) {
+	ifd, found = trf.index.Lookup[exifcommon.IfdGpsInfoStandardIfdIdentity.String()]
+	return ifd, found
+}
+
+// hasMakePrefix returns true if the Make of IFD0 starts with the given
+// (upper-case) prefix, ignoring case.
+func (trf *TiffRawFile) hasMakePrefix(prefix string) (has bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	results, err := trf.index.RootIfd.FindTagWithName("Make")
+	if err != nil {
+		if log.Is(err, ErrTagNotFound) == true {
+			return false, nil
+		}
+
+		log.Panic(err)
+	}
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	makeValue, ok := value.(string)
+	return ok == true && strings.HasPrefix(strings.ToUpper(makeValue), prefix) == true, nil
+}
+
+// String returns a descriptive string.
+func (trf *TiffRawFile) String() string {
+	return fmt.Sprintf("TiffRawFile<SIZE=(%d) IFDS=(%d) SUB-IFDS=(%d)>", len(trf.data), len(trf.index.Ifds), len(trf.subIfds))
+}
@@ -0,0 +1,136 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func getTestTiffData() []byte {
+	filepath := path.Join(exifcommon.GetTestAssetsPath(), "geotiff_example.tif")
+
+	data, err := ioutil.ReadFile(filepath)
+	log.PanicIf(err)
+
+	return data
+}
+
+func getTestTiffImageData(data []byte) (index IfdIndex, tid *tiffImageData) {
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err = Collect(im, ti, data)
+	log.PanicIf(err)
+
+	tid, err = readTiffImageData(index.RootIfd, data)
+	log.PanicIf(err)
+
+	return index, tid
+}
+
+func TestTiffRewriter_Rewrite(t *testing.T) {
+	data := getTestTiffData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	tr := NewTiffRewriter(im, ti)
+
+	updateFn := func(rootIb *IfdBuilder) (err error) {
+		return rootIb.SetStandardWithName("ImageDescription", "rewritten")
+	}
+
+	updated, err := tr.Rewrite(data, updateFn)
+	log.PanicIf(err)
+
+	index, tid := getTestTiffImageData(updated)
+
+	results, err := index.RootIfd.FindTagWithName("ImageDescription")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "rewritten" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+
+	_, err = index.RootIfd.FindTagWithName("ModelPixelScaleTag")
+	log.PanicIf(err)
+
+	// The strips should have moved but be the same.
+
+	_, originalTid := getTestTiffImageData(data)
+
+	if len(tid.chunks) != len(originalTid.chunks) {
+		t.Fatalf("Strip count not correct: (%d) != (%d)", len(tid.chunks), len(originalTid.chunks))
+	}
+
+	for i, chunk := range tid.chunks {
+		if bytes.Equal(chunk, originalTid.chunks[i]) != true {
+			t.Fatalf("Strip (%d) not correct.", i)
+		}
+	}
+}
+
+func TestTiffRewriter_Rewrite_Idempotent(t *testing.T) {
+	data := getTestTiffData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	tr := NewTiffRewriter(im, ti)
+
+	first, err := tr.Rewrite(data, nil)
+	log.PanicIf(err)
+
+	second, err := tr.Rewrite(first, nil)
+	log.PanicIf(err)
+
+	if bytes.Equal(first, second) != true {
+		t.Fatalf("Rewriting a rewritten TIFF changed it.")
+	}
+}
+
+func TestTiffRewriter_Rewrite_DropImageData(t *testing.T) {
+	data := getTestTiffData()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	tr := NewTiffRewriter(im, ti)
+
+	updateFn := func(rootIb *IfdBuilder) (err error) {
+		_, err = rootIb.DeleteAll(ThumbnailStripOffsetsTagId)
+		return err
+	}
+
+	updated, err := tr.Rewrite(data, updateFn)
+	log.PanicIf(err)
+
+	_, tid := getTestTiffImageData(updated)
+	if tid != nil {
+		t.Fatalf("Image data not dropped.")
+	}
+
+	if len(updated) >= len(data)/10 {
+		t.Fatalf("Output too large: (%d)", len(updated))
+	}
+}
@@ -29,6 +29,25 @@ func Encode(value EncodeableValue, byteOrder binary.ByteOrder) (encoded []byte,
 	return encoded, unitCount, nil
 }
 
+// IsOpaque returns true if the decoder registered for the given tag is an
+// `OpaqueUndefinedValueDecoder` that says that its values are the stored
+// bytes, unchanged.
+func IsOpaque(ifdPath string, tagId uint16) bool {
+	uth := UndefinedTagHandle{
+		IfdPath: ifdPath,
+		TagId:   tagId,
+	}
+
+	decoder, found := decoders[uth]
+	if found == false {
+		return false
+	}
+
+	oud, ok := decoder.(OpaqueUndefinedValueDecoder)
+
+	return ok == true && oud.IsOpaque() == true
+}
+
 // Decode constructs a value from raw encoded bytes
 func Decode(valueContext *exifcommon.ValueContext) (value EncodeableValue, err error) {
 	defer func() {
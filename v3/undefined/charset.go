@@ -0,0 +1,142 @@
+package exifundefined
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"unicode/utf8"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// CharsetAscii is the character-code name for ASCII text.
+	CharsetAscii = "ASCII"
+
+	// CharsetJis is the character-code name for JIS X0208 text.
+	CharsetJis = "JIS"
+
+	// CharsetUnicode is the character-code name for Unicode (UCS-2) text.
+	CharsetUnicode = "UNICODE"
+
+	// CharsetUtf8 is the name for UTF-8 text. Like ASCII, it's always
+	// supported.
+	CharsetUtf8 = "UTF-8"
+
+	// CharsetShiftJis is the name for Shift_JIS (Japanese) text, which many
+	// cameras write into ASCII tags.
+	CharsetShiftJis = "Shift_JIS"
+
+	// CharsetGbk is the name for GBK (Simplified Chinese, a superset of
+	// GB2312) text, which many cameras write into ASCII tags.
+	CharsetGbk = "GBK"
+)
+
+var (
+	// ErrCharsetNotSupported means that no converter is registered for the
+	// character-code of a value.
+	ErrCharsetNotSupported = errors.New("character set not supported")
+
+	// ErrCharsetNotDecodable means that the text is not valid in the
+	// character set that it was decoded from.
+	ErrCharsetNotDecodable = errors.New("text not valid in character set")
+)
+
+// CharsetConverter converts text from a character set that is allowed by the
+// character-code prefix of UserComment and GPSProcessingMethod to UTF-8.
+type CharsetConverter interface {
+	// Decode returns the text as UTF-8.
+	Decode(raw []byte) (text string, err error)
+}
+
+var (
+	charsetConverters      = make(map[string]CharsetConverter)
+	charsetConvertersMutex sync.RWMutex
+)
+
+// RegisterCharsetConverter installs the converter for the given
+// character-code name (e.g. `CharsetJis`). ASCII and UTF-8 are always
+// supported. JIS, Shift_JIS, and GBK converters backed by golang.org/x/text
+// are registered automatically when built with the "exif_xtext" tag. It is a
+// programming error to register a name twice.
+func RegisterCharsetConverter(name string, cc CharsetConverter) {
+	charsetConvertersMutex.Lock()
+	defer charsetConvertersMutex.Unlock()
+
+	if _, found := charsetConverters[name]; found == true {
+		log.Panicf("charset converter already registered: [%s]", name)
+	}
+
+	charsetConverters[name] = cc
+}
+
+// UnregisterCharsetConverter removes the converter for the given name.
+// Returns false if it was not registered.
+func UnregisterCharsetConverter(name string) bool {
+	charsetConvertersMutex.Lock()
+	defer charsetConvertersMutex.Unlock()
+
+	if _, found := charsetConverters[name]; found == false {
+		return false
+	}
+
+	delete(charsetConverters, name)
+	return true
+}
+
+// DecodeCharset converts the text in the given character set to UTF-8.
+// Trailing NULs and spaces, which are used as padding, are removed.
+// `ErrCharsetNotSupported` is returned if there is no converter for the
+// character set. Converters return `ErrCharsetNotDecodable` if the text isn't
+// valid in it.
+func DecodeCharset(name string, raw []byte) (text string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if name == CharsetAscii {
+		return string(bytes.TrimRight(raw, "\x00 ")), nil
+	} else if name == CharsetUtf8 {
+		if utf8.Valid(raw) == false {
+			return "", ErrCharsetNotDecodable
+		}
+
+		return string(bytes.TrimRight(raw, "\x00 ")), nil
+	}
+
+	charsetConvertersMutex.RLock()
+	cc, found := charsetConverters[name]
+	charsetConvertersMutex.RUnlock()
+
+	if found == false {
+		return "", ErrCharsetNotSupported
+	}
+
+	text, err = cc.Decode(raw)
+	log.PanicIf(err)
+
+	return string(bytes.TrimRight([]byte(text), "\x00 ")), nil
+}
+
+// splitCharacterCode separates the eight-byte character-code prefix from the
+// text. `found` is false if the data has no recognized prefix.
+func splitCharacterCode(data []byte) (name string, text []byte, found bool) {
+	if len(data) < 8 {
+		return "", nil, false
+	}
+
+	for encodingType, encodingBytes := range TagUndefinedType_9286_UserComment_Encodings {
+		if encodingType == TagUndefinedType_9286_UserComment_Encoding_UNDEFINED {
+			continue
+		}
+
+		if bytes.Equal(data[:8], encodingBytes) == true {
+			return TagUndefinedType_9286_UserComment_Encoding_Names[encodingType], data[8:], true
+		}
+	}
+
+	return "", nil, false
+}
@@ -0,0 +1,119 @@
+package exifundefined
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+type testUpperCharsetConverter struct{}
+
+func (testUpperCharsetConverter) Decode(raw []byte) (text string, err error) {
+	return string(bytes.ToUpper(raw)), nil
+}
+
+func TestDecodeCharset_Ascii(t *testing.T) {
+	text, err := DecodeCharset(CharsetAscii, []byte("abc \x00\x00"))
+	log.PanicIf(err)
+
+	if text != "abc" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
+
+func TestDecodeCharset_NotSupported(t *testing.T) {
+	_, err := DecodeCharset("OTHER", []byte("abc"))
+	if err != ErrCharsetNotSupported {
+		t.Fatalf("Expected ErrCharsetNotSupported: [%v]", err)
+	}
+}
+
+func TestRegisterCharsetConverter(t *testing.T) {
+	RegisterCharsetConverter("TEST", testUpperCharsetConverter{})
+	defer UnregisterCharsetConverter("TEST")
+
+	text, err := DecodeCharset("TEST", []byte("abc"))
+	log.PanicIf(err)
+
+	if text != "ABC" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+
+	if UnregisterCharsetConverter("TEST") != true {
+		t.Fatalf("Expected converter to be unregistered.")
+	} else if UnregisterCharsetConverter("TEST") != false {
+		t.Fatalf("Expected converter to already be unregistered.")
+	}
+}
+
+func TestRegisterCharsetConverter_Duplicate(t *testing.T) {
+	RegisterCharsetConverter("TEST", testUpperCharsetConverter{})
+	defer UnregisterCharsetConverter("TEST")
+
+	defer func() {
+		if state := recover(); state == nil {
+			t.Fatalf("Expected panic for duplicate registration.")
+		}
+	}()
+
+	RegisterCharsetConverter("TEST", testUpperCharsetConverter{})
+}
+
+func TestTag9286UserComment_Text(t *testing.T) {
+	uc := Tag9286UserComment{
+		EncodingType:  TagUndefinedType_9286_UserComment_Encoding_ASCII,
+		EncodingBytes: []byte("a comment   "),
+	}
+
+	text, err := uc.Text()
+	log.PanicIf(err)
+
+	if text != "a comment" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
+
+func TestTag9286UserComment_Text_Unicode(t *testing.T) {
+	uc := Tag9286UserComment{
+		EncodingType:  TagUndefinedType_9286_UserComment_Encoding_UNICODE,
+		EncodingBytes: []byte("abc"),
+	}
+
+	_, err := uc.Text()
+	if err != ErrCharsetNotSupported {
+		t.Fatalf("Expected ErrCharsetNotSupported: [%v]", err)
+	}
+
+	RegisterCharsetConverter(CharsetUnicode, testUpperCharsetConverter{})
+	defer UnregisterCharsetConverter(CharsetUnicode)
+
+	text, err := uc.Text()
+	log.PanicIf(err)
+
+	if text != "ABC" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
+
+func TestTag001BGPSProcessingMethod_Text(t *testing.T) {
+	gpm := Tag001BGPSProcessingMethod{"ASCII\x00\x00\x00GPS"}
+
+	text, err := gpm.Text()
+	log.PanicIf(err)
+
+	if text != "GPS" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+
+	// Without a character-code.
+
+	gpm = Tag001BGPSProcessingMethod{"NETWORK"}
+
+	text, err = gpm.Text()
+	log.PanicIf(err)
+
+	if text != "NETWORK" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
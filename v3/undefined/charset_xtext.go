@@ -0,0 +1,115 @@
+//go:build exif_xtext
+// +build exif_xtext
+
+// This file is only built with the "exif_xtext" tag so that the package
+// doesn't otherwise import golang.org/x/text. The module requires it (at the
+// version that golang.org/x/net already brings in), so the tag needs nothing
+// else.
+
+package exifundefined
+
+import (
+	"bytes"
+
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/dsoprea/go-logging"
+)
+
+var (
+	jisShiftIn  = []byte("\x1b$B")
+	jisShiftOut = []byte("\x1b(B")
+)
+
+// XtextJisCharsetConverter decodes JIS text using golang.org/x/text. The
+// specification calls for JIS X0208 codes, which are decoded by wrapping them
+// in ISO-2022-JP escapes. Text that already has escapes is decoded as
+// ISO-2022-JP and anything else (which is what some cameras write) as
+// Shift_JIS.
+type XtextJisCharsetConverter struct{}
+
+// Decode implements `CharsetConverter`.
+func (XtextJisCharsetConverter) Decode(raw []byte) (text string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	raw = bytes.TrimRight(raw, "\x00 ")
+
+	if bytes.IndexByte(raw, 0x1b) == -1 && isJisX0208(raw) == true {
+		wrapped := make([]byte, 0, len(jisShiftIn)+len(raw)+len(jisShiftOut))
+		wrapped = append(wrapped, jisShiftIn...)
+		wrapped = append(wrapped, raw...)
+		wrapped = append(wrapped, jisShiftOut...)
+
+		raw = wrapped
+	}
+
+	var decoded []byte
+	if bytes.IndexByte(raw, 0x1b) != -1 {
+		decoded, err = japanese.ISO2022JP.NewDecoder().Bytes(raw)
+		log.PanicIf(err)
+	} else {
+		decoded, err = japanese.ShiftJIS.NewDecoder().Bytes(raw)
+		log.PanicIf(err)
+	}
+
+	return string(decoded), nil
+}
+
+// isJisX0208 returns true if the data looks like a sequence of two-byte JIS
+// X0208 codes, which only use the printable 7-bit range.
+func isJisX0208(raw []byte) bool {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return false
+	}
+
+	for _, b := range raw {
+		if b < 0x21 || b > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// XtextShiftJisCharsetConverter decodes Shift_JIS text using
+// golang.org/x/text.
+type XtextShiftJisCharsetConverter struct{}
+
+// Decode implements `CharsetConverter`.
+func (XtextShiftJisCharsetConverter) Decode(raw []byte) (text string, err error) {
+	return decodeXtextStrictly(japanese.ShiftJIS, raw)
+}
+
+// XtextGbkCharsetConverter decodes GBK text using golang.org/x/text.
+type XtextGbkCharsetConverter struct{}
+
+// Decode implements `CharsetConverter`.
+func (XtextGbkCharsetConverter) Decode(raw []byte) (text string, err error) {
+	return decodeXtextStrictly(simplifiedchinese.GBK, raw)
+}
+
+// decodeXtextStrictly decodes the text with the given encoding. The decoders
+// substitute rather than fail on invalid sequences, so
+// `ErrCharsetNotDecodable` is returned if there were any.
+func decodeXtextStrictly(e encoding.Encoding, raw []byte) (text string, err error) {
+	decoded, err := e.NewDecoder().Bytes(raw)
+	if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) == true {
+		return "", ErrCharsetNotDecodable
+	}
+
+	return string(decoded), nil
+}
+
+func init() {
+	RegisterCharsetConverter(CharsetJis, XtextJisCharsetConverter{})
+	RegisterCharsetConverter(CharsetShiftJis, XtextShiftJisCharsetConverter{})
+	RegisterCharsetConverter(CharsetGbk, XtextGbkCharsetConverter{})
+}
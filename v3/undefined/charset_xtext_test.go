@@ -0,0 +1,61 @@
+//go:build exif_xtext
+// +build exif_xtext
+
+package exifundefined
+
+import (
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+)
+
+func TestXtextJisCharsetConverter_Decode(t *testing.T) {
+	cases := map[string][]byte{
+		"JIS X0208":   {0x46, 0x7c, 0x4b, 0x5c, 0, 0},
+		"ISO-2022-JP": []byte("\x1b$B\x46\x7c\x4b\x5c\x1b(B"),
+		"Shift_JIS":   {0x93, 0xfa, 0x96, 0x7b},
+	}
+
+	for description, raw := range cases {
+		text, err := DecodeCharset(CharsetJis, raw)
+		log.PanicIf(err)
+
+		if text != "日本" {
+			t.Fatalf("%s text not correct: [%s]", description, text)
+		}
+	}
+}
+
+func TestTag001BGPSProcessingMethod_Text_Jis(t *testing.T) {
+	gpm := Tag001BGPSProcessingMethod{"JIS\x00\x00\x00\x00\x00\x46\x7c\x4b\x5c"}
+
+	text, err := gpm.Text()
+	log.PanicIf(err)
+
+	if text != "日本" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
+
+func TestXtextShiftJisCharsetConverter_Decode(t *testing.T) {
+	text, err := DecodeCharset(CharsetShiftJis, []byte{0x8e, 0x52, 0x93, 0x63})
+	log.PanicIf(err)
+
+	if text != "山田" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+}
+
+func TestXtextGbkCharsetConverter_Decode(t *testing.T) {
+	text, err := DecodeCharset(CharsetGbk, []byte{0xd5, 0xc5, 0xc8, 0xfd})
+	log.PanicIf(err)
+
+	if text != "张三" {
+		t.Fatalf("Text not correct: [%s]", text)
+	}
+
+	_, err = DecodeCharset(CharsetGbk, []byte{0xd5})
+	if log.Is(err, ErrCharsetNotDecodable) != true {
+		t.Fatalf("Expected ErrCharsetNotDecodable: [%v]", err)
+	}
+}
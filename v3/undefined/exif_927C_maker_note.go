@@ -58,6 +58,12 @@ func (Codec927CMakerNote) Encode(value interface{}, byteOrder binary.ByteOrder)
 	return mn.MakerNoteBytes, uint32(len(mn.MakerNoteBytes)), nil
 }
 
+// IsOpaque implements `OpaqueUndefinedValueDecoder`. The maker-note is kept as
+// it is stored.
+func (Codec927CMakerNote) IsOpaque() bool {
+	return true
+}
+
 func (Codec927CMakerNote) Decode(valueContext *exifcommon.ValueContext) (value EncodeableValue, err error) {
 	defer func() {
 		if state := recover(); state != nil {
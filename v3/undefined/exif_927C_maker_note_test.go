@@ -73,3 +73,13 @@ func TestCodec927CMakerNote_Decode(t *testing.T) {
 		t.Fatalf("Decoded value not correct: %s != %s", value, ut)
 	}
 }
+
+func TestIsOpaque(t *testing.T) {
+	if IsOpaque(exifcommon.IfdExifStandardIfdIdentity.UnindexedString(), 0x927c) != true {
+		t.Fatalf("Expected the maker-note to be opaque.")
+	} else if IsOpaque(exifcommon.IfdExifStandardIfdIdentity.UnindexedString(), 0x9286) != false {
+		t.Fatalf("Expected the user-comment to not be opaque.")
+	} else if IsOpaque(exifcommon.IfdExifStandardIfdIdentity.UnindexedString(), 0x1234) != false {
+		t.Fatalf("Expected an unknown tag to not be opaque.")
+	}
+}
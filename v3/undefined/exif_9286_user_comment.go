@@ -63,6 +63,31 @@ func (uc Tag9286UserComment) String() string {
 	return fmt.Sprintf("UserComment<SIZE=(%d) ENCODING=[%s] V=%v LEN=(%d)>", len(uc.EncodingBytes), TagUndefinedType_9286_UserComment_Encoding_Names[uc.EncodingType], valuePhrase, len(uc.EncodingBytes))
 }
 
+// Text returns the comment as UTF-8. An undefined encoding is treated as
+// ASCII. `ErrCharsetNotSupported` is returned for JIS or Unicode comments if
+// no converter is registered for them (see `RegisterCharsetConverter()`).
+func (uc Tag9286UserComment) Text() (text string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	name := CharsetAscii
+	if uc.EncodingType != TagUndefinedType_9286_UserComment_Encoding_UNDEFINED {
+		name = TagUndefinedType_9286_UserComment_Encoding_Names[uc.EncodingType]
+	}
+
+	text, err = DecodeCharset(name, uc.EncodingBytes)
+	if err == ErrCharsetNotSupported {
+		return "", err
+	}
+
+	log.PanicIf(err)
+
+	return text, nil
+}
+
 type Codec9286UserComment struct {
 }
 
@@ -0,0 +1,75 @@
+package exifundefined
+
+import (
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// TagEA1CPadding is the padding tag written by Windows into IFD0 and the Exif
+// IFD. Its value is a block of reserved bytes that Windows shrinks when it
+// edits the metadata so that the file doesn't have to be relaid. Only the
+// size is meaningful.
+type TagEA1CPadding struct {
+	Size uint32
+}
+
+func (TagEA1CPadding) EncoderName() string {
+	return "CodecEA1CPadding"
+}
+
+func (p TagEA1CPadding) String() string {
+	return fmt.Sprintf("Padding<SIZE=(%d)>", p.Size)
+}
+
+type CodecEA1CPadding struct {
+}
+
+func (CodecEA1CPadding) Encode(value interface{}, byteOrder binary.ByteOrder) (encoded []byte, unitCount uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	p, ok := value.(TagEA1CPadding)
+	if ok == false {
+		log.Panicf("can only encode a TagEA1CPadding")
+	}
+
+	return make([]byte, p.Size), p.Size, nil
+}
+
+func (CodecEA1CPadding) Decode(valueContext *exifcommon.ValueContext) (value EncodeableValue, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	p := TagEA1CPadding{
+		Size: valueContext.UnitCount(),
+	}
+
+	return p, nil
+}
+
+func init() {
+	registerEncoder(
+		TagEA1CPadding{},
+		CodecEA1CPadding{})
+
+	registerDecoder(
+		exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		0xea1c,
+		CodecEA1CPadding{})
+
+	registerDecoder(
+		exifcommon.IfdExifStandardIfdIdentity.UnindexedString(),
+		0xea1c,
+		CodecEA1CPadding{})
+}
@@ -0,0 +1,66 @@
+package exifundefined
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/v2/filesystem"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func TestTagEA1CPadding_String(t *testing.T) {
+	ut := TagEA1CPadding{Size: 2060}
+
+	s := ut.String()
+	if s != "Padding<SIZE=(2060)>" {
+		t.Fatalf("String not correct: [%s]", s)
+	}
+}
+
+func TestCodecEA1CPadding_Encode(t *testing.T) {
+	ut := TagEA1CPadding{Size: 10}
+
+	codec := CodecEA1CPadding{}
+
+	encoded, unitCount, err := codec.Encode(ut, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if bytes.Equal(encoded, make([]byte, 10)) != true {
+		exifcommon.DumpBytesClause(encoded)
+
+		t.Fatalf("Encoding not correct.")
+	} else if unitCount != 10 {
+		t.Fatalf("Unit-count not correct: (%d)", unitCount)
+	}
+}
+
+func TestCodecEA1CPadding_Decode(t *testing.T) {
+	expectedUt := TagEA1CPadding{Size: 10}
+
+	encoded := make([]byte, 10)
+
+	addressableBytes := encoded
+	sb := rifs.NewSeekableBufferWithBytes(addressableBytes)
+
+	valueContext := exifcommon.NewValueContext(
+		"",
+		0,
+		uint32(len(encoded)),
+		0,
+		nil,
+		sb,
+		exifcommon.TypeUndefined,
+		exifcommon.TestDefaultByteOrder)
+
+	codec := CodecEA1CPadding{}
+
+	decoded, err := codec.Decode(valueContext)
+	log.PanicIf(err)
+
+	if reflect.DeepEqual(decoded, expectedUt) != true {
+		t.Fatalf("Decoded struct not correct.")
+	}
+}
@@ -20,6 +20,34 @@ func (gpm Tag001BGPSProcessingMethod) String() string {
 	return gpm.string
 }
 
+// Text returns the method as UTF-8. The value should begin with the same
+// eight-byte character-code as UserComment, but it is commonly written as
+// bare ASCII, so a value without a recognized character-code is returned
+// as-is. `ErrCharsetNotSupported` is returned for JIS or Unicode values if no
+// converter is registered for them (see `RegisterCharsetConverter()`).
+func (gpm Tag001BGPSProcessingMethod) Text() (text string, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	name, raw, found := splitCharacterCode([]byte(gpm.string))
+	if found == false {
+		name = CharsetAscii
+		raw = []byte(gpm.string)
+	}
+
+	text, err = DecodeCharset(name, raw)
+	if err == ErrCharsetNotSupported {
+		return "", err
+	}
+
+	log.PanicIf(err)
+
+	return text, nil
+}
+
 type Codec001BGPSProcessingMethod struct {
 }
 
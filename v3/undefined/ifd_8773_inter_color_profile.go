@@ -0,0 +1,140 @@
+package exifundefined
+
+import (
+	"bytes"
+	"fmt"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+const (
+	// iccHeaderSize is the size of the fixed header of an ICC profile.
+	iccHeaderSize = 128
+)
+
+var (
+	// iccSignature is found at offset 36 of every ICC profile.
+	iccSignature = []byte("acsp")
+)
+
+// Tag8773InterColorProfile is an ICC profile stored directly as a tag, as
+// TIFF and DNG files do. Profiles can be several megabytes.
+type Tag8773InterColorProfile struct {
+	Data []byte
+}
+
+func (Tag8773InterColorProfile) EncoderName() string {
+	return "Codec8773InterColorProfile"
+}
+
+// IsValid returns true if the data has a complete ICC header with the correct
+// signature.
+func (icp Tag8773InterColorProfile) IsValid() bool {
+	return len(icp.Data) >= iccHeaderSize && bytes.Equal(icp.Data[36:40], iccSignature) == true
+}
+
+// ProfileSize returns the size recorded in the ICC header, or zero if the
+// header is not valid.
+func (icp Tag8773InterColorProfile) ProfileSize() uint32 {
+	if icp.IsValid() == false {
+		return 0
+	}
+
+	return binary.BigEndian.Uint32(icp.Data[0:4])
+}
+
+// Version returns the ICC version (e.g. "4.3.0"), or an empty string if the
+// header is not valid.
+func (icp Tag8773InterColorProfile) Version() string {
+	if icp.IsValid() == false {
+		return ""
+	}
+
+	return fmt.Sprintf("%d.%d.%d", icp.Data[8], icp.Data[9]>>4, icp.Data[9]&0xf)
+}
+
+// DeviceClass returns the four-character profile/device class (e.g. "mntr"),
+// or an empty string if the header is not valid.
+func (icp Tag8773InterColorProfile) DeviceClass() string {
+	if icp.IsValid() == false {
+		return ""
+	}
+
+	return string(icp.Data[12:16])
+}
+
+// ColorSpace returns the four-character data color-space (e.g. "RGB "), or an
+// empty string if the header is not valid.
+func (icp Tag8773InterColorProfile) ColorSpace() string {
+	if icp.IsValid() == false {
+		return ""
+	}
+
+	return string(icp.Data[16:20])
+}
+
+func (icp Tag8773InterColorProfile) String() string {
+	if icp.IsValid() == false {
+		return fmt.Sprintf("InterColorProfile<INVALID LEN=(%d)>", len(icp.Data))
+	}
+
+	return fmt.Sprintf("InterColorProfile<CLASS=[%s] COLOR-SPACE=[%s] VERSION=[%s] LEN=(%d)>", icp.DeviceClass(), icp.ColorSpace(), icp.Version(), len(icp.Data))
+}
+
+type Codec8773InterColorProfile struct {
+}
+
+func (Codec8773InterColorProfile) Encode(value interface{}, byteOrder binary.ByteOrder) (encoded []byte, unitCount uint32, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	icp, ok := value.(Tag8773InterColorProfile)
+	if ok == false {
+		log.Panicf("can only encode a Tag8773InterColorProfile")
+	}
+
+	return icp.Data, uint32(len(icp.Data)), nil
+}
+
+// IsOpaque implements `OpaqueUndefinedValueDecoder`. The profile is kept as it
+// is stored.
+func (Codec8773InterColorProfile) IsOpaque() bool {
+	return true
+}
+
+func (Codec8773InterColorProfile) Decode(valueContext *exifcommon.ValueContext) (value EncodeableValue, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	valueContext.SetUndefinedValueType(exifcommon.TypeByte)
+
+	valueBytes, err := valueContext.ReadBytes()
+	log.PanicIf(err)
+
+	icp := Tag8773InterColorProfile{
+		Data: valueBytes,
+	}
+
+	return icp, nil
+}
+
+func init() {
+	registerEncoder(
+		Tag8773InterColorProfile{},
+		Codec8773InterColorProfile{})
+
+	registerDecoder(
+		exifcommon.IfdStandardIfdIdentity.UnindexedString(),
+		0x8773,
+		Codec8773InterColorProfile{})
+}
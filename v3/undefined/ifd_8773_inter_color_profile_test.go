@@ -0,0 +1,112 @@
+package exifundefined
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dsoprea/go-logging"
+	"github.com/dsoprea/go-utility/v2/filesystem"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+// getTestIccProfile returns a minimal ICC profile: a header and some padding.
+func getTestIccProfile() []byte {
+	data := make([]byte, 200)
+
+	data[3] = 200
+	data[8] = 4
+	data[9] = 0x30
+
+	copy(data[12:], "mntr")
+	copy(data[16:], "RGB ")
+	copy(data[36:], "acsp")
+
+	return data
+}
+
+func TestTag8773InterColorProfile_Header(t *testing.T) {
+	icp := Tag8773InterColorProfile{
+		Data: getTestIccProfile(),
+	}
+
+	if icp.IsValid() != true {
+		t.Fatalf("Profile should be valid.")
+	} else if icp.ProfileSize() != 200 {
+		t.Fatalf("Profile size not correct: (%d)", icp.ProfileSize())
+	} else if icp.Version() != "4.3.0" {
+		t.Fatalf("Version not correct: [%s]", icp.Version())
+	} else if icp.DeviceClass() != "mntr" {
+		t.Fatalf("Device class not correct: [%s]", icp.DeviceClass())
+	} else if icp.ColorSpace() != "RGB " {
+		t.Fatalf("Color-space not correct: [%s]", icp.ColorSpace())
+	}
+
+	s := icp.String()
+	if s != "InterColorProfile<CLASS=[mntr] COLOR-SPACE=[RGB ] VERSION=[4.3.0] LEN=(200)>" {
+		t.Fatalf("String not correct: [%s]", s)
+	}
+}
+
+func TestTag8773InterColorProfile_Invalid(t *testing.T) {
+	icp := Tag8773InterColorProfile{
+		Data: []byte{1, 2, 3},
+	}
+
+	if icp.IsValid() != false {
+		t.Fatalf("Profile should not be valid.")
+	} else if icp.ProfileSize() != 0 || icp.DeviceClass() != "" {
+		t.Fatalf("Header fields should be empty.")
+	} else if icp.String() != "InterColorProfile<INVALID LEN=(3)>" {
+		t.Fatalf("String not correct: [%s]", icp.String())
+	}
+}
+
+func TestCodec8773InterColorProfile_Encode(t *testing.T) {
+	data := getTestIccProfile()
+
+	icp := Tag8773InterColorProfile{
+		Data: data,
+	}
+
+	codec := Codec8773InterColorProfile{}
+
+	encoded, unitCount, err := codec.Encode(icp, exifcommon.TestDefaultByteOrder)
+	log.PanicIf(err)
+
+	if bytes.Equal(encoded, data) != true {
+		t.Fatalf("Encoding not correct.")
+	} else if unitCount != uint32(len(data)) {
+		t.Fatalf("Unit-count not correct: (%d)", unitCount)
+	}
+}
+
+func TestCodec8773InterColorProfile_Decode(t *testing.T) {
+	data := getTestIccProfile()
+
+	sb := rifs.NewSeekableBufferWithBytes(data)
+
+	valueContext := exifcommon.NewValueContext(
+		"",
+		0,
+		uint32(len(data)),
+		0,
+		nil,
+		sb,
+		exifcommon.TypeUndefined,
+		exifcommon.TestDefaultByteOrder)
+
+	codec := Codec8773InterColorProfile{}
+
+	value, err := codec.Decode(valueContext)
+	log.PanicIf(err)
+
+	expected := Tag8773InterColorProfile{
+		Data: data,
+	}
+
+	if reflect.DeepEqual(value, expected) != true {
+		t.Fatalf("Decoded value not correct: %s != %s", value, expected)
+	}
+}
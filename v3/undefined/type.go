@@ -42,3 +42,13 @@ type EncodeableValue interface {
 type UndefinedValueDecoder interface {
 	Decode(valueContext *exifcommon.ValueContext) (value EncodeableValue, err error)
 }
+
+// OpaqueUndefinedValueDecoder is implemented by decoders whose values always
+// encode back to exactly the bytes that they were decoded from, so the bytes
+// can be copied without decoding them.
+type OpaqueUndefinedValueDecoder interface {
+	UndefinedValueDecoder
+
+	// IsOpaque returns true if the value is the stored bytes, unchanged.
+	IsOpaque() bool
+}
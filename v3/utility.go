@@ -53,6 +53,20 @@ type ExifTag struct {
 	// ChildIfdPath is the name of the child IFD this tag represents (if it
 	// represents any). Otherwise, this is empty.
 	ChildIfdPath string `json:"child_ifd_path"`
+
+	// IsSummarized is true if the value was too large to show and `Value`,
+	// `Formatted`, and `FormattedFirst` are a summary of it instead (see
+	// `DumpOptions`). `ValueBytes` is then empty.
+	IsSummarized bool `json:"is_summarized,omitempty"`
+
+	// Charset is the legacy charset that the text of an ASCII tag was
+	// converted from, if any (see `ScanOptions.Charsets`).
+	Charset string `json:"charset,omitempty"`
+
+	// IsTruncated is true if the value of an ASCII tag was longer than the
+	// maximum string length and only the start of it was read (see
+	// `ScanOptions.MaxStringLength`).
+	IsTruncated bool `json:"is_truncated,omitempty"`
 }
 
 // String returns a string representation.
@@ -157,62 +171,178 @@ func getFlatExifDataUniversalSearchWithReadSeeker(rs io.ReadSeeker, so *ScanOpti
 
 	exifTags = make([]ExifTag, 0)
 
+	var do *DumpOptions
+	var charsets []string
+	if so != nil {
+		do = so.Dump
+		charsets = so.Charsets
+	}
+
 	visitor := func(ite *IfdTagEntry) (err error) {
-		// This encodes down to base64. Since this an example tool and we do not
-		// expect to ever decode the output, we are not worried about
-		// specifically base64-encoding it in order to have a measure of
-		// control.
-		valueBytes, err := ite.GetRawBytes()
-		if err != nil {
-			if err == exifundefined.ErrUnparseableValue {
-				return nil
-			}
+		et, isValid, err := newExifTag(ite, do, charsets)
+		log.PanicIf(err)
 
-			log.Panic(err)
+		if isValid == true {
+			exifTags = append(exifTags, et)
 		}
 
-		value, err := ite.Value()
-		if err != nil {
-			if err == exifcommon.ErrUnhandledUndefinedTypedTag {
-				value = exifundefined.UnparseableUnknownTagValuePlaceholder
-			} else if log.Is(err, exifcommon.ErrParseFail) == true {
-				utilityLogger.Warningf(nil,
-					"Could not parse value for tag [%s] (%04x) [%s].",
-					ite.IfdPath(), ite.TagId(), ite.TagName())
+		return nil
+	}
 
-				return nil
-			} else {
-				log.Panic(err)
-			}
+	med, err = ie.Scan(exifcommon.IfdStandardIfdIdentity, eh.FirstIfdOffset, visitor, nil)
+	log.PanicIf(err)
+
+	return exifTags, med, nil
+}
+
+// newExifTag returns the flat representation of the tag. `isValid` is false
+// if the value can not be read. If `do` is not nil, large binary values are
+// summarized (see `DumpOptions`). If `charsets` is not nil, ASCII text is
+// converted from the legacy charset that it appears to be in.
+func newExifTag(ite *IfdTagEntry, do *DumpOptions, charsets []string) (et ExifTag, isValid bool, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
 		}
+	}()
+
+	// This encodes down to base64. Since this an example tool and we do not
+	// expect to ever decode the output, we are not worried about
+	// specifically base64-encoding it in order to have a measure of
+	// control.
+	valueBytes, err := ite.GetRawBytes()
+	if err != nil {
+		if err == exifundefined.ErrUnparseableValue {
+			return et, false, nil
+		}
+
+		log.Panic(err)
+	}
 
-		et := ExifTag{
-			IfdPath:      ite.IfdPath(),
-			TagId:        ite.TagId(),
-			TagName:      ite.TagName(),
-			UnitCount:    ite.UnitCount(),
-			TagTypeId:    ite.TagType(),
-			TagTypeName:  ite.TagType().String(),
-			Value:        value,
-			ValueBytes:   valueBytes,
-			ChildIfdPath: ite.ChildIfdPath(),
+	value, err := ite.Value()
+	if err != nil {
+		if err == exifcommon.ErrUnhandledUndefinedTypedTag {
+			value = exifundefined.UnparseableUnknownTagValuePlaceholder
+		} else if log.Is(err, exifcommon.ErrParseFail) == true {
+			utilityLogger.Warningf(nil,
+				"Could not parse value for tag [%s] (%04x) [%s].",
+				ite.IfdPath(), ite.TagId(), ite.TagName())
+
+			return et, false, nil
+		} else {
+			log.Panic(err)
 		}
+	}
+
+	et = ExifTag{
+		IfdPath:      ite.IfdPath(),
+		TagId:        ite.TagId(),
+		TagName:      ite.TagName(),
+		UnitCount:    ite.UnitCount(),
+		TagTypeId:    ite.TagType(),
+		TagTypeName:  ite.TagType().String(),
+		Value:        value,
+		ValueBytes:   valueBytes,
+		ChildIfdPath: ite.ChildIfdPath(),
+		IsTruncated:  ite.IsTruncated(),
+	}
+
+	et.Formatted, err = ite.Format()
+	log.PanicIf(err)
+
+	et.FormattedFirst, err = ite.FormatFirst()
+	log.PanicIf(err)
 
-		et.Formatted, err = ite.Format()
+	if charsets != nil && et.TagTypeId == exifcommon.TypeAscii {
+		text, charset, err := ite.DecodedText(charsets)
 		log.PanicIf(err)
 
-		et.FormattedFirst, err = ite.FormatFirst()
+		if charset != "" && charset != exifundefined.CharsetAscii && charset != exifundefined.CharsetUtf8 {
+			et.Value = text
+			et.Formatted = text
+			et.FormattedFirst = text
+			et.Charset = charset
+		}
+	}
+
+	if do.ShouldSummarize(et.TagId, et.TagTypeId, valueBytes) == true {
+		summary := do.Summarize(valueBytes)
+
+		et.Value = summary
+		et.ValueBytes = nil
+		et.Formatted = summary
+		et.FormattedFirst = summary
+		et.IsSummarized = true
+	} else {
+		et.Formatted = do.truncate(et.TagId, et.Formatted)
+	}
+
+	return et, true, nil
+}
+
+// ExtractTags returns only the named tags. Scanning stops as soon as all of
+// them have been found and only their values are decoded, so this is much
+// faster than reading everything when only a couple of tags are needed (e.g.
+// the capture date and the orientation). A name may be qualified with the
+// IFD-path (e.g. "IFD/Exif/DateTimeOriginal" or "IFD1/Orientation"), or not,
+// in which case the first matching tag in any IFD is returned. The result is
+// keyed by the names as given. Tags that were not found are not present.
+func ExtractTags(exifData []byte, tagNames []string) (exifTags map[string]ExifTag, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	remaining := make(map[string]struct{}, len(tagNames))
+	for _, tagName := range tagNames {
+		remaining[tagName] = struct{}{}
+	}
+
+	exifTags = make(map[string]ExifTag, len(tagNames))
+
+	visitor := func(ite *IfdTagEntry) (err error) {
+		if len(remaining) == 0 {
+			return ErrStopScan
+		}
+
+		tagName := ite.TagName()
+		fqTagName := fmt.Sprintf("%s/%s", ite.IfdPath(), tagName)
+
+		requestedName := fqTagName
+		if _, found := remaining[requestedName]; found == false {
+			requestedName = tagName
+			if _, found := remaining[requestedName]; found == false {
+				return nil
+			}
+		}
+
+		et, isValid, err := newExifTag(ite, nil, nil)
 		log.PanicIf(err)
 
-		exifTags = append(exifTags, et)
+		if isValid == false {
+			return nil
+		}
+
+		exifTags[requestedName] = et
+		delete(remaining, requestedName)
+
+		if len(remaining) == 0 {
+			return ErrStopScan
+		}
 
 		return nil
 	}
 
-	med, err = ie.Scan(exifcommon.IfdStandardIfdIdentity, eh.FirstIfdOffset, visitor, nil)
+	_, _, err = Visit(exifcommon.IfdStandardIfdIdentity, im, ti, exifData, visitor, nil)
 	log.PanicIf(err)
 
-	return exifTags, med, nil
+	return exifTags, nil
 }
 
 // GpsDegreesEquals returns true if the two `GpsDegrees` are identical.
@@ -1,3 +1,6 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
 package exif
 
 import (
@@ -98,3 +101,40 @@ func TestGetFlatExifDataUniversalSearchWithReadSeeker(t *testing.T) {
 		t.Fatalf("Tag count not correct: (%d)", len(exifTags))
 	}
 }
+
+func TestExtractTags(t *testing.T) {
+	testExifData := getTestExifData()
+
+	exifTags, err := ExtractTags(testExifData, []string{"DateTimeOriginal", "Orientation", "IFD1/XResolution", "GPSLatitude"})
+	log.PanicIf(err)
+
+	if len(exifTags) != 3 {
+		t.Fatalf("Tag count not correct: (%d)", len(exifTags))
+	}
+
+	if et := exifTags["DateTimeOriginal"]; et.IfdPath != "IFD/Exif" || et.Value != "2017:12:02 08:18:50" {
+		t.Fatalf("DateTimeOriginal not correct: %s", et)
+	} else if et := exifTags["Orientation"]; et.IfdPath != "IFD" || et.FormattedFirst != "1" {
+		t.Fatalf("Orientation not correct: %s", et)
+	} else if et := exifTags["IFD1/XResolution"]; et.IfdPath != "IFD1" {
+		t.Fatalf("XResolution not correct: %s", et)
+	}
+}
+
+func TestExtractTags_One(t *testing.T) {
+	testExifData := getTestExifData()
+
+	exifTags, err := ExtractTags(testExifData, []string{"Make"})
+	log.PanicIf(err)
+
+	if exifTags["Make"].Value != "Canon" {
+		t.Fatalf("Make not correct: %s", exifTags["Make"])
+	}
+
+	exifTags, err = ExtractTags(testExifData, nil)
+	log.PanicIf(err)
+
+	if len(exifTags) != 0 {
+		t.Fatalf("Expected no tags: %v", exifTags)
+	}
+}
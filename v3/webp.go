@@ -0,0 +1,394 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"encoding/binary"
+	"io/ioutil"
+
+	"github.com/dsoprea/go-logging"
+)
+
+const (
+	// webpHeaderSize is the size of the RIFF header: "RIFF", the size of
+	// everything after it, and "WEBP".
+	webpHeaderSize = 12
+
+	// webpMaxExifChunkSize is the most that we'll read for an EXIF chunk. The
+	// size comes from the file, so it's not trusted to allocate with.
+	webpMaxExifChunkSize = 64 * 1024 * 1024
+
+	// webpVp8xPayloadSize is the size of the payload of a VP8X chunk.
+	webpVp8xPayloadSize = 10
+)
+
+const (
+	// These are the feature flags of the VP8X chunk.
+	webpVp8xFlagExif  = byte(0x08)
+	webpVp8xFlagAlpha = byte(0x10)
+)
+
+var (
+	// webpVp8StartCode follows the frame tag of a VP8 key frame.
+	webpVp8StartCode = []byte{0x9d, 0x01, 0x2a}
+)
+
+var (
+	// ErrWebpFormat means that the RIFF chunk structure of a WebP file could
+	// not be parsed.
+	ErrWebpFormat = errors.New("webp format error")
+)
+
+// webpChunk is one top-level chunk of a WebP file.
+type webpChunk struct {
+	fourCc  string
+	payload []byte
+}
+
+// IsWebp returns true if the data starts with the RIFF header of a WebP file.
+func IsWebp(data []byte) bool {
+	return len(data) >= webpHeaderSize && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// ExtractExifFromWebp returns the EXIF stored in the "EXIF" chunk of a WebP
+// file. Only the chunk headers are read, and the image data is skipped rather
+// than read. Some writers precede the TIFF data with the "Exif\0\0" preamble
+// that JPEG uses; it is not returned. `ErrNoExif` is returned if there is no
+// EXIF chunk.
+func ExtractExifFromWebp(r io.Reader) (rawExif []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, _, err = extractExifFromWebp(r)
+	if err != nil {
+		if err == ErrNoExif {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return rawExif, nil
+}
+
+// extractExifFromWebp returns the EXIF data and its offset from the start of
+// the file.
+func extractExifFromWebp(r io.Reader) (rawExif []byte, offset int, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	header := make([]byte, webpHeaderSize)
+
+	_, err = io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		log.Panic(ErrWebpFormat)
+	}
+
+	log.PanicIf(err)
+
+	if IsWebp(header) == false {
+		log.Panic(ErrWebpFormat)
+	}
+
+	offset = webpHeaderSize
+
+	for {
+		_, err := io.ReadFull(r, header[:8])
+		if err == io.EOF {
+			break
+		} else if err == io.ErrUnexpectedEOF {
+			log.Panic(ErrWebpFormat)
+		}
+
+		log.PanicIf(err)
+
+		fourCc := string(header[:4])
+		size := binary.LittleEndian.Uint32(header[4:8])
+
+		offset += 8
+
+		if fourCc == "EXIF" {
+			if size > webpMaxExifChunkSize {
+				log.Panicf("webp exif chunk too large: (%d)", size)
+			}
+
+			rawExif = make([]byte, size)
+
+			_, err := io.ReadFull(r, rawExif)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				log.Panic(ErrWebpFormat)
+			}
+
+			log.PanicIf(err)
+
+			if bytes.HasPrefix(rawExif, jpegExifPreamble) == true {
+				rawExif = rawExif[len(jpegExifPreamble):]
+				offset += len(jpegExifPreamble)
+			}
+
+			exifLogger.Debugf(nil, "Found WebP EXIF chunk (%d) bytes at offset (%d).", len(rawExif), offset)
+
+			return rawExif, offset, nil
+		}
+
+		// Chunks are padded to an even size.
+		skip := int64(size) + int64(size%2)
+
+		_, err = io.CopyN(ioutil.Discard, r, skip)
+		if err == io.EOF {
+			// The padding of the last chunk is sometimes left off.
+			if size%2 == 1 {
+				break
+			}
+
+			log.Panic(ErrWebpFormat)
+		}
+
+		log.PanicIf(err)
+
+		offset += int(skip)
+	}
+
+	return nil, 0, ErrNoExif
+}
+
+// parseWebpChunks returns the top-level chunks of a WebP file. Anything after
+// the size given in the RIFF header is ignored.
+func parseWebpChunks(data []byte) (chunks []webpChunk, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if IsWebp(data) == false {
+		return nil, ErrWebpFormat
+	}
+
+	end := 8 + uint64(binary.LittleEndian.Uint32(data[4:8]))
+	if end > uint64(len(data)) {
+		return nil, ErrWebpFormat
+	}
+
+	chunks = make([]webpChunk, 0)
+
+	for position := uint64(webpHeaderSize); position < end; {
+		if position+8 > end {
+			return nil, ErrWebpFormat
+		}
+
+		fourCc := string(data[position : position+4])
+		size := uint64(binary.LittleEndian.Uint32(data[position+4 : position+8]))
+
+		position += 8
+
+		if position+size > end {
+			return nil, ErrWebpFormat
+		}
+
+		wc := webpChunk{
+			fourCc:  fourCc,
+			payload: data[position : position+size],
+		}
+
+		chunks = append(chunks, wc)
+
+		position += size + size%2
+	}
+
+	return chunks, nil
+}
+
+// buildWebpVp8x returns the payload of a VP8X chunk for a WebP in the simple
+// format, whose only chunk is the given "VP8 " or "VP8L" one. The canvas size
+// is read from the bitstream.
+func buildWebpVp8x(wc webpChunk) (payload []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	var width, height uint32
+	var flags byte
+
+	if wc.fourCc == "VP8 " {
+		// A frame tag of three bytes, the start code, and then the width and
+		// height as 14 bits each (the top two bits are the scale).
+		if len(wc.payload) < 10 || bytes.Equal(wc.payload[3:6], webpVp8StartCode) == false {
+			return nil, ErrWebpFormat
+		}
+
+		width = uint32(binary.LittleEndian.Uint16(wc.payload[6:8]) & 0x3fff)
+		height = uint32(binary.LittleEndian.Uint16(wc.payload[8:10]) & 0x3fff)
+	} else if wc.fourCc == "VP8L" {
+		// A signature byte, and then the width and height (less one) as 14
+		// bits each, followed by the alpha hint.
+		if len(wc.payload) < 5 || wc.payload[0] != 0x2f {
+			return nil, ErrWebpFormat
+		}
+
+		bits := binary.LittleEndian.Uint32(wc.payload[1:5])
+
+		width = 1 + bits&0x3fff
+		height = 1 + (bits>>14)&0x3fff
+
+		if (bits>>28)&1 == 1 {
+			flags |= webpVp8xFlagAlpha
+		}
+	} else {
+		return nil, ErrWebpFormat
+	}
+
+	if width == 0 || height == 0 {
+		return nil, ErrWebpFormat
+	}
+
+	payload = make([]byte, webpVp8xPayloadSize)
+	payload[0] = flags
+
+	putWebpUint24(payload[4:7], width-1)
+	putWebpUint24(payload[7:10], height-1)
+
+	return payload, nil
+}
+
+// putWebpUint24 writes a little-endian, 24-bit integer.
+func putWebpUint24(b []byte, value uint32) {
+	b[0] = byte(value)
+	b[1] = byte(value >> 8)
+	b[2] = byte(value >> 16)
+}
+
+// SetWebpExif returns a copy of the given WebP with its EXIF replaced by
+// `rawExif`. A file in the simple format (a single "VP8 " or "VP8L" chunk) is
+// converted to the extended format by adding a VP8X chunk, since only that can
+// carry metadata, and the EXIF flag of the VP8X chunk is set. Any existing
+// EXIF chunk is removed and the new one is put before the XMP chunk or at the
+// end, where the specification orders it. Every other chunk is kept as it is.
+func SetWebpExif(data []byte, rawExif []byte) (updated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	if len(rawExif) > webpMaxExifChunkSize {
+		log.Panicf("exif too large for a webp chunk: (%d)", len(rawExif))
+	}
+
+	chunks, err := parseWebpChunks(data)
+	if err != nil {
+		if err == ErrWebpFormat {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	if len(chunks) == 0 {
+		return nil, ErrWebpFormat
+	}
+
+	var vp8x []byte
+	if chunks[0].fourCc == "VP8X" {
+		if len(chunks[0].payload) < webpVp8xPayloadSize {
+			return nil, ErrWebpFormat
+		}
+
+		vp8x = append([]byte{}, chunks[0].payload...)
+		chunks = chunks[1:]
+	} else {
+		vp8x, err = buildWebpVp8x(chunks[0])
+		if err != nil {
+			if err == ErrWebpFormat {
+				return nil, err
+			}
+
+			log.Panic(err)
+		}
+	}
+
+	vp8x[0] |= webpVp8xFlagExif
+
+	exifChunk := webpChunk{
+		fourCc:  "EXIF",
+		payload: rawExif,
+	}
+
+	output := []webpChunk{{fourCc: "VP8X", payload: vp8x}}
+	placed := false
+
+	for _, wc := range chunks {
+		if wc.fourCc == "EXIF" {
+			continue
+		} else if wc.fourCc == "XMP " && placed == false {
+			output = append(output, exifChunk)
+			placed = true
+		}
+
+		output = append(output, wc)
+	}
+
+	if placed == false {
+		output = append(output, exifChunk)
+	}
+
+	b := new(bytes.Buffer)
+
+	_, err = b.Write([]byte("RIFF\x00\x00\x00\x00WEBP"))
+	log.PanicIf(err)
+
+	for _, wc := range output {
+		_, err := b.Write([]byte(wc.fourCc))
+		log.PanicIf(err)
+
+		err = binary.Write(b, binary.LittleEndian, uint32(len(wc.payload)))
+		log.PanicIf(err)
+
+		_, err = b.Write(wc.payload)
+		log.PanicIf(err)
+
+		if len(wc.payload)%2 == 1 {
+			err := b.WriteByte(0)
+			log.PanicIf(err)
+		}
+	}
+
+	updated = b.Bytes()
+	binary.LittleEndian.PutUint32(updated[4:8], uint32(len(updated)-8))
+
+	return updated, nil
+}
+
+// SetWebpExifFromBuilder encodes the chain of the given root IB and puts it in
+// the given WebP with `SetWebpExif()`.
+func SetWebpExifFromBuilder(data []byte, rootIb *IfdBuilder) (updated []byte, err error) {
+	defer func() {
+		if state := recover(); state != nil {
+			err = log.Wrap(state.(error))
+		}
+	}()
+
+	rawExif, err := rootIb.BuildExif()
+	log.PanicIf(err)
+
+	updated, err = SetWebpExif(data, rawExif)
+	if err != nil {
+		if err == ErrWebpFormat {
+			return nil, err
+		}
+
+		log.Panic(err)
+	}
+
+	return updated, nil
+}
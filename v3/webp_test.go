@@ -0,0 +1,236 @@
+//go:build !exif_nofile
+// +build !exif_nofile
+
+package exif
+
+import (
+	"bytes"
+	"testing"
+
+	"encoding/binary"
+
+	"github.com/dsoprea/go-logging"
+
+	"github.com/dsoprea/go-exif/v3/common"
+)
+
+func buildTestWebpChunk(fourCc string, payload []byte) []byte {
+	chunk := make([]byte, 8)
+	copy(chunk[:4], fourCc)
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(payload)))
+
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+
+	return chunk
+}
+
+func buildTestWebp(chunks ...[]byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	return data
+}
+
+// buildTestWebpLossless returns a simple-format WebP with a (truncated) VP8L
+// bitstream for a 300x200 image with alpha.
+func buildTestWebpLossless() []byte {
+	bits := uint32(300-1) | uint32(200-1)<<14 | 1<<28
+
+	payload := []byte{0x2f, 0, 0, 0, 0, 0xaa, 0xbb, 0xcc}
+	binary.LittleEndian.PutUint32(payload[1:5], bits)
+
+	return buildTestWebp(buildTestWebpChunk("VP8L", payload))
+}
+
+func getTestWebpChunks(data []byte) []webpChunk {
+	chunks, err := parseWebpChunks(data)
+	log.PanicIf(err)
+
+	return chunks
+}
+
+func TestIsWebp(t *testing.T) {
+	if IsWebp(buildTestWebpLossless()) != true {
+		t.Fatalf("WebP not detected.")
+	} else if IsWebp([]byte("RIFF\x00\x00\x00\x00WAVE")) != false {
+		t.Fatalf("WAVE detected as WebP.")
+	}
+}
+
+func TestSetWebpExif_Lossless(t *testing.T) {
+	exifData := getExifSimpleTestIbBytes()
+
+	updated, err := SetWebpExif(buildTestWebpLossless(), exifData)
+	log.PanicIf(err)
+
+	chunks := getTestWebpChunks(updated)
+
+	if len(chunks) != 3 {
+		t.Fatalf("Chunk count not correct: (%d)", len(chunks))
+	} else if chunks[0].fourCc != "VP8X" || chunks[1].fourCc != "VP8L" || chunks[2].fourCc != "EXIF" {
+		t.Fatalf("Chunks not correct: [%s] [%s] [%s]", chunks[0].fourCc, chunks[1].fourCc, chunks[2].fourCc)
+	}
+
+	vp8x := chunks[0].payload
+
+	if vp8x[0] != webpVp8xFlagExif|webpVp8xFlagAlpha {
+		t.Fatalf("VP8X flags not correct: (0x%02x)", vp8x[0])
+	}
+
+	width := uint32(vp8x[4]) | uint32(vp8x[5])<<8 | uint32(vp8x[6])<<16
+	height := uint32(vp8x[7]) | uint32(vp8x[8])<<8 | uint32(vp8x[9])<<16
+
+	if width != 300-1 || height != 200-1 {
+		t.Fatalf("VP8X canvas not correct: (%d) (%d)", width, height)
+	}
+
+	rawExif, err := ExtractExifFromWebp(bytes.NewReader(updated))
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF not correct.")
+	}
+}
+
+func TestSetWebpExif_Lossy(t *testing.T) {
+	payload := []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0, 0, 0, 0, 0xaa}
+	binary.LittleEndian.PutUint16(payload[6:8], 640)
+	binary.LittleEndian.PutUint16(payload[8:10], 480)
+
+	data := buildTestWebp(buildTestWebpChunk("VP8 ", payload))
+
+	// An odd size, to require padding.
+	exifData := append(getExifSimpleTestIbBytes(), 0)
+	if len(exifData)%2 == 0 {
+		exifData = exifData[:len(exifData)-1]
+	}
+
+	updated, err := SetWebpExif(data, exifData)
+	log.PanicIf(err)
+
+	chunks := getTestWebpChunks(updated)
+	vp8x := chunks[0].payload
+
+	if vp8x[0] != webpVp8xFlagExif {
+		t.Fatalf("VP8X flags not correct: (0x%02x)", vp8x[0])
+	} else if bytes.Equal(vp8x[4:10], []byte{0x7f, 0x02, 0x00, 0xdf, 0x01, 0x00}) != true {
+		t.Fatalf("VP8X canvas not correct: %v", vp8x[4:10])
+	} else if len(updated)%2 != 0 {
+		t.Fatalf("WebP not padded.")
+	}
+
+	rawExif, err := ExtractExifFromWebp(bytes.NewReader(updated))
+	log.PanicIf(err)
+
+	if bytes.Equal(rawExif, exifData) != true {
+		t.Fatalf("EXIF not correct.")
+	}
+}
+
+func TestSetWebpExif_Extended(t *testing.T) {
+	vp8x := make([]byte, webpVp8xPayloadSize)
+	vp8x[0] = 0x24
+
+	data := buildTestWebp(
+		buildTestWebpChunk("VP8X", vp8x),
+		buildTestWebpChunk("ICCP", []byte{1, 2, 3}),
+		buildTestWebpChunk("VP8L", []byte{0x2f, 0, 0, 0, 0}),
+		buildTestWebpChunk("EXIF", []byte("old")),
+		buildTestWebpChunk("XMP ", []byte("<x/>")))
+
+	exifData := getExifSimpleTestIbBytes()
+
+	updated, err := SetWebpExif(data, exifData)
+	log.PanicIf(err)
+
+	// Setting it again shouldn't change anything.
+
+	again, err := SetWebpExif(updated, exifData)
+	log.PanicIf(err)
+
+	if bytes.Equal(again, updated) != true {
+		t.Fatalf("Setting the same EXIF again changed the file.")
+	}
+
+	chunks := getTestWebpChunks(updated)
+
+	fourCcs := make([]string, len(chunks))
+	for i, wc := range chunks {
+		fourCcs[i] = wc.fourCc
+	}
+
+	expected := []string{"VP8X", "ICCP", "VP8L", "EXIF", "XMP "}
+	if len(fourCcs) != len(expected) {
+		t.Fatalf("Chunks not correct: %v", fourCcs)
+	}
+
+	for i, fourCc := range expected {
+		if fourCcs[i] != fourCc {
+			t.Fatalf("Chunks not correct: %v", fourCcs)
+		}
+	}
+
+	if chunks[0].payload[0] != 0x2c {
+		t.Fatalf("VP8X flags not correct: (0x%02x)", chunks[0].payload[0])
+	} else if bytes.Equal(chunks[3].payload, exifData) != true {
+		t.Fatalf("EXIF not correct.")
+	} else if bytes.Equal(chunks[1].payload, []byte{1, 2, 3}) != true {
+		t.Fatalf("Other chunk not preserved.")
+	}
+}
+
+func TestSetWebpExif_NotWebp(t *testing.T) {
+	_, err := SetWebpExif([]byte("not a webp file"), getExifSimpleTestIbBytes())
+	if err != ErrWebpFormat {
+		t.Fatalf("Expected format error: [%v]", err)
+	}
+}
+
+func TestExtractExifFromWebp_NoExif(t *testing.T) {
+	_, err := ExtractExifFromWebp(bytes.NewReader(buildTestWebpLossless()))
+	if err != ErrNoExif {
+		t.Fatalf("Expected no-EXIF error: %v", err)
+	}
+}
+
+func TestSetWebpExifFromBuilder(t *testing.T) {
+	updated, err := SetWebpExifFromBuilder(buildTestWebpLossless(), getJpegExifTestIb())
+	log.PanicIf(err)
+
+	rawExif, err := SearchAndExtractExif(updated)
+	log.PanicIf(err)
+
+	im, err := exifcommon.NewIfdMappingWithStandard()
+	log.PanicIf(err)
+
+	ti := NewTagIndex()
+
+	_, index, err := Collect(im, ti, rawExif)
+	log.PanicIf(err)
+
+	results, err := index.RootIfd.FindTagWithName("ProcessingSoftware")
+	log.PanicIf(err)
+
+	value, err := results[0].Value()
+	log.PanicIf(err)
+
+	if value != "replacement" {
+		t.Fatalf("Value not correct: [%v]", value)
+	}
+
+	ep, err := ProbeExif(bytes.NewReader(updated))
+	log.PanicIf(err)
+
+	if ep.HasExif != true {
+		t.Fatalf("EXIF not found by probe.")
+	} else if bytes.Equal(updated[ep.Offset:ep.Offset+len(rawExif)], rawExif) != true {
+		t.Fatalf("Probe offset not correct: (%d)", ep.Offset)
+	}
+}